@@ -16,12 +16,81 @@ var appCmd = &cobra.Command{
 var createAppCmd = &cobra.Command{
 	Use:   "create [name]",
 	Short: "Create a new Grayv app",
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new Grayv app. The api template scaffolds a Go server; the fullstack template adds a frontend
+in web/, served by the app, which forwards /api/ to the CRUD endpoints of 'grayv-lsm serve'.
+Frontends:
+  htmx    a static page with htmx, swapping in HTML fragments rendered by the app (default)
+  react   a React single-page application built with Vite`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		appName := args[0]
-		if err := appCreator.CreateApp(appName); err != nil {
+		templateName, _ := cmd.Flags().GetString("template")
+		frontendName, _ := cmd.Flags().GetString("frontend")
+		template, err := app.ParseTemplate(templateName)
+		if err != nil {
 			log.WithError(err).Errorf("Failed to create Grayv app '%s'", appName)
-		} else {
+			return
+		}
+		frontend, err := app.ParseFrontend(frontendName)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to create Grayv app '%s'", appName)
+			return
+		}
+		if frontendName != "" && template != app.TemplateFullstack {
+			log.Error("--frontend is only used with the fullstack template")
+			return
+		}
+		var runner app.TaskRunner
+		if runnerName, _ := cmd.Flags().GetString("tasks"); runnerName != "" {
+			if runner, err = app.ParseTaskRunner(runnerName); err != nil {
+				log.WithError(err).Errorf("Failed to create Grayv app '%s'", appName)
+				return
+			}
+		}
+		var ci app.CIProvider
+		if ciName, _ := cmd.Flags().GetString("with-ci"); ciName != "" {
+			if ci, err = app.ParseCIProvider(ciName); err != nil {
+				log.WithError(err).Errorf("Failed to create Grayv app '%s'", appName)
+				return
+			}
+		}
+
+		w := generationWriter(cmd)
+		if err := appCreator.CreateApp(appName, w); err != nil {
+			log.WithError(err).Errorf("Failed to create Grayv app '%s'", appName)
+			return
+		}
+		if template == app.TemplateFullstack {
+			if err := appCreator.CreateFrontend(appName, frontend, w); err != nil {
+				log.WithError(err).Errorf("Failed to create the frontend of Grayv app '%s'", appName)
+				return
+			}
+			if !w.Preview() {
+				log.Infof("Build the frontend with 'npm install && npm run build' in %s_grav/web, then run the app next to 'grayv-lsm serve'", appName)
+			}
+		}
+		if uploads, _ := cmd.Flags().GetBool("uploads"); uploads {
+			if err := appCreator.CreateUploadHandlers(appName, w); err != nil {
+				log.WithError(err).Errorf("Failed to create upload handlers for Grayv app '%s'", appName)
+				return
+			}
+			if !w.Preview() {
+				log.Info("Upload handlers need the grayv-lsm module; run 'go get github.com/ooyeku/grayv-lsm' in the app directory")
+			}
+		}
+		if runner != "" {
+			if err := appCreator.CreateTaskFile(appName, runner, w); err != nil {
+				log.WithError(err).Errorf("Failed to create the task file of Grayv app '%s'", appName)
+				return
+			}
+		}
+		if ci != "" {
+			if err := appCreator.CreateCI(appName, ci, w); err != nil {
+				log.WithError(err).Errorf("Failed to create the CI pipeline of Grayv app '%s'", appName)
+				return
+			}
+		}
+		if !w.Preview() {
 			log.Infof("Grayv app '%s' created successfully", appName)
 		}
 	},
@@ -67,6 +136,12 @@ var deleteAppCmd = &cobra.Command{
 
 func init() {
 	appCreator = app.NewAppCreator()
+	addGenerationFlags(createAppCmd)
+	createAppCmd.Flags().Bool("uploads", false, "Scaffold handlers for file uploads stored through pkg/storage")
+	createAppCmd.Flags().String("template", string(app.TemplateAPI), "App template: api or fullstack")
+	createAppCmd.Flags().String("tasks", "", "Generate a task file with the common grayv-lsm workflows: make (Makefile) or task (Taskfile.yml)")
+	createAppCmd.Flags().String("with-ci", "", "Generate a CI pipeline running the tests against a provisioned database: github or gitlab")
+	createAppCmd.Flags().String("frontend", "", "Frontend of the fullstack template: htmx or react (default htmx)")
 
 	appCmd.AddCommand(createAppCmd)
 	appCmd.AddCommand(listAppsCmd)
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure the throughput and latency of the database",
+	Long: `Run a read/write workload against the database from concurrent clients and report the transactions per
+second and the latency percentiles. Reads select a random row by primary key and writes update one, in a table
+named ` + orm.BenchTable + ` that is created and filled for the run and dropped afterwards.
+Run it before and after changing the resources of the database container to compare them.
+Interrupting the benchmark stops it early and still reports the results.`,
+	Args: cobra.NoArgs,
+	Run:  runBench,
+}
+
+var modelBenchCmd = &cobra.Command{
+	Use:   "bench [name]",
+	Short: "Bulk load generated rows into the table of a model and measure the ingest throughput",
+	Long: `Generate realistic rows for the table of a model and bulk load them in one transaction, streamed with COPY,
+then report the ingest throughput and the size of the table and its indexes, to size indexes before production.
+Field names pick the kind of value, so an email field gets email addresses; foreign keys pick random rows of the
+referenced table, which must not be empty. The rows are kept; --rows accepts numbers such as 1e6.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runModelBench,
+}
+
+func init() {
+	modelBenchCmd.Flags().String("rows", "10000", "Number of rows to generate, such as 50000 or 1e6")
+	modelBenchCmd.Flags().Int64("seed", 0, "Seed of the random values, for repeatable data (default: random)")
+	modelBenchCmd.Flags().String("tenant", "", "Tenant the rows belong to, if the model is scoped to tenants")
+	modelBenchCmd.Flags().Int("batch-size", orm.DefaultBatchSize, "Rows per INSERT statement when COPY is not available")
+	modelCmd.AddCommand(modelBenchCmd)
+
+	benchCmd.Flags().Int("clients", 10, "Number of concurrent clients")
+	benchCmd.Flags().Duration("duration", 30*time.Second, "How long the workload runs")
+	benchCmd.Flags().Float64("read-ratio", 0.8, "Share of transactions that read, between 0 (write-only) and 1 (read-only)")
+	benchCmd.Flags().Int("rows", 10000, "Number of rows in the benchmark table")
+	benchCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	addProfileFlag(benchCmd, modelBenchCmd)
+	dbCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	var opts orm.BenchOptions
+	opts.Clients, _ = cmd.Flags().GetInt("clients")
+	opts.Duration, _ = cmd.Flags().GetDuration("duration")
+	opts.ReadRatio, _ = cmd.Flags().GetFloat64("read-ratio")
+	opts.Rows, _ = cmd.Flags().GetInt("rows")
+	output, _ := cmd.Flags().GetString("output")
+	if output != "table" && output != "json" {
+		log.Errorf("Unknown output format %q: use table or json", output)
+		return
+	}
+
+	var result *orm.BenchResult
+	err := withDBConnection(func(conn *orm.Connection) error {
+		if output == "table" {
+			log.Infof("Running the benchmark with %d clients for %s", opts.Clients, opts.Duration)
+		}
+		return runUntilSignal(cfg, func(ctx context.Context) error {
+			defer phase("bench")()
+			var err error
+			result, err = conn.Bench(ctx, opts)
+			return err
+		})
+	})
+	if err != nil {
+		log.WithError(err).Error("Error running the benchmark")
+		return
+	}
+
+	if output == "json" {
+		if err := printJSON(result); err != nil {
+			log.WithError(err).Error("Error printing the results")
+		}
+		return
+	}
+	fmt.Printf("Clients:       %d\n", result.Clients)
+	fmt.Printf("Duration:      %s\n", result.Elapsed.Round(time.Millisecond))
+	fmt.Printf("Transactions:  %d (%d reads, %d writes)\n", result.Transactions, result.Reads, result.Writes)
+	fmt.Printf("Errors:        %d\n", result.Errors)
+	fmt.Printf("TPS:           %.1f\n", result.TPS)
+	fmt.Printf("Latency:       mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		roundLatency(result.Mean), roundLatency(result.P50), roundLatency(result.P90),
+		roundLatency(result.P95), roundLatency(result.P99), roundLatency(result.Max))
+}
+
+func runModelBench(cmd *cobra.Command, args []string) {
+	rowsFlag, _ := cmd.Flags().GetString("rows")
+	seedValue, _ := cmd.Flags().GetInt64("seed")
+	tenant, _ := cmd.Flags().GetString("tenant")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+	rows, err := parseRowCount(rowsFlag)
+	if err != nil {
+		log.WithError(err).Error("Invalid number of rows")
+		return
+	}
+	if seedValue == 0 {
+		seedValue = time.Now().UnixNano()
+	}
+
+	var def *model.ModelDefinition
+	var count int64
+	var elapsed time.Duration
+	var tableSize, indexSize string
+	err = withDBConnection(func(conn *orm.Connection) error {
+		if err := conn.CheckWritable("generate rows"); err != nil {
+			return err
+		}
+		var err error
+		endPrepare := phase("prepare")
+		if def, err = loadSeedModel(conn, args[0]); err != nil {
+			return err
+		}
+
+		factory, err := newSeedFactory(conn, def, seedValue, tenant)
+		endPrepare()
+		if err != nil {
+			return err
+		}
+
+		loader := conn.BulkLoader()
+		loader.BatchSize = batchSize
+		log.Infof("Loading %d rows into %s", rows, def.TableName())
+		start := time.Now()
+		endLoad := phase("load")
+		count, err = loader.Load(def.TableName(), factory.Columns(), factory.Source(rows))
+		endLoad()
+		if err != nil {
+			return err
+		}
+		elapsed = time.Since(start)
+		defer phase("size")()
+
+		return conn.GetDB().QueryRow("SELECT pg_size_pretty(pg_table_size($1)), pg_size_pretty(pg_indexes_size($1))",
+			conn.Dialect().Quote(def.TableName())).Scan(&tableSize, &indexSize)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error generating rows")
+		return
+	}
+
+	fmt.Printf("Rows:        %d\n", count)
+	fmt.Printf("Duration:    %s\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("Throughput:  %.0f rows/s\n", float64(count)/elapsed.Seconds())
+	fmt.Printf("Table size:  %s\n", tableSize)
+	fmt.Printf("Index size:  %s\n", indexSize)
+	fmt.Printf("Seed:        %d\n", seedValue)
+}
+
+// parseRowCount parses a positive whole number of rows, written as an integer or in scientific notation such as 1e6.
+func parseRowCount(s string) (int, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 1 || n != math.Trunc(n) || n > math.MaxInt32 {
+		return 0, fmt.Errorf("%q is not a positive whole number", s)
+	}
+	return int(n), nil
+}
+
+// maxReferenceIDs is the number of rows of a referenced table that generated foreign keys pick from.
+const maxReferenceIDs = 100000
+
+// referenceIDs returns the ids of up to maxReferenceIDs rows of the table the foreign key field references.
+func referenceIDs(conn *orm.Connection, field model.Field) ([]int64, error) {
+	table := model.NewModelDefinition(field.References, nil).TableName()
+	rows, err := conn.Query(fmt.Sprintf("SELECT id FROM %s LIMIT %d", conn.Dialect().Quote(table), maxReferenceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error loading the rows referenced by %s: %w", field.Name, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error loading the rows referenced by %s: %w", field.Name, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// roundLatency rounds a latency to a precision that stays readable from microseconds to seconds.
+func roundLatency(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/spf13/cobra"
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch",
+	Short: "Keep a snapshot of the development database per git branch",
+	Long: `Save and restore copies of the development database per git branch, so schema experiments on a feature
+branch never touch the data of the main branch. Snapshots are databases on the same server, copied with
+CREATE DATABASE ... TEMPLATE; copying closes the other connections to the database, such as a running server.
+Run 'db branch install-hook' to switch the database whenever a branch is checked out.`,
+}
+
+var branchSaveCmd = &cobra.Command{
+	Use:   "save [branch]",
+	Short: "Save the database as the snapshot of a branch (default: the current branch)",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runBranchSave,
+}
+
+var branchSwitchCmd = &cobra.Command{
+	Use:   "switch [branch]",
+	Short: "Save the database for its branch and restore the snapshot of another (default: the current branch)",
+	Long: `Save the database as the snapshot of the branch it holds, then restore the snapshot of the given branch.
+If the branch has no snapshot yet, the database is kept as is, so a new branch starts from the data of the
+branch it was created from. The branch the database holds is recorded by every switch; --from names it for
+a database that was never switched.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runBranchSwitch,
+}
+
+var branchListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the branch snapshots",
+	Args:  cobra.NoArgs,
+	Run:   runBranchList,
+}
+
+var branchDropCmd = &cobra.Command{
+	Use:   "drop [branch]",
+	Short: "Delete the snapshot of a branch",
+	Args:  cobra.ExactArgs(1),
+	Run:   runBranchDrop,
+}
+
+var branchInstallHookCmd = &cobra.Command{
+	Use:   "install-hook",
+	Short: "Install a git post-checkout hook running 'db branch switch' on every checkout",
+	Args:  cobra.NoArgs,
+	Run:   runBranchInstallHook,
+}
+
+func init() {
+	branchSwitchCmd.Flags().String("from", "", "Branch the database holds, if no switch recorded it")
+	branchInstallHookCmd.Flags().Bool("force", false, "Replace an existing post-checkout hook")
+	branchCmd.AddCommand(branchSaveCmd, branchSwitchCmd, branchListCmd, branchDropCmd, branchInstallHookCmd)
+	dbCmd.AddCommand(branchCmd)
+}
+
+func runBranchSave(cmd *cobra.Command, args []string) {
+	if dbManager == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	branch, err := branchArg(args)
+	if err != nil {
+		log.WithError(err).Error("Error saving branch snapshot")
+		return
+	}
+	if err := dbManager.SaveBranch(branch); err != nil {
+		log.WithError(err).Error("Error saving branch snapshot")
+		return
+	}
+	log.Infof("Saved the database as the snapshot of branch %s", branch)
+}
+
+func runBranchSwitch(cmd *cobra.Command, args []string) {
+	if dbManager == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	from, _ := cmd.Flags().GetString("from")
+	branch, err := branchArg(args)
+	if err != nil {
+		log.WithError(err).Error("Error switching branch")
+		return
+	}
+	restored, err := dbManager.SwitchBranch(branch, from)
+	if err != nil {
+		log.WithError(err).Error("Error switching branch")
+		return
+	}
+	if restored {
+		log.Infof("Restored the snapshot of branch %s", branch)
+	} else {
+		log.Infof("Branch %s has no snapshot yet; the database is kept as is", branch)
+	}
+}
+
+func runBranchList(cmd *cobra.Command, args []string) {
+	if dbManager == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	snapshots, err := dbManager.ListBranches()
+	if err != nil {
+		log.WithError(err).Error("Error listing branch snapshots")
+		return
+	}
+	if len(snapshots) == 0 {
+		log.Info("No branch snapshots found")
+		return
+	}
+	fmt.Printf("%-30s %-10s %s\n", "BRANCH", "SIZE", "DATABASE")
+	for _, snapshot := range snapshots {
+		fmt.Printf("%-30s %-10s %s\n", snapshot.Branch, snapshot.Size, snapshot.Database)
+	}
+}
+
+func runBranchDrop(cmd *cobra.Command, args []string) {
+	if dbManager == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	if err := dbManager.DropBranch(args[0]); err != nil {
+		log.WithError(err).Error("Error dropping branch snapshot")
+		return
+	}
+	log.Infof("Snapshot of branch %s dropped", args[0])
+}
+
+func runBranchInstallHook(cmd *cobra.Command, args []string) {
+	force, _ := cmd.Flags().GetBool("force")
+	path, err := lsm.InstallBranchHook(force)
+	if err != nil {
+		log.WithError(err).Error("Error installing git hook")
+		return
+	}
+	log.Infof("Installed %s; the database now follows git checkouts", path)
+}
+
+// branchArg returns the branch named in args, or the current git branch if none is.
+func branchArg(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	return lsm.CurrentGitBranch()
+}
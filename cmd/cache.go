@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the optional Redis cache container",
+	Long: `Start, stop, and inspect a Redis container used by the Redis session store and the API query cache.
+Its address, password, and image are read from the Cache section of the configuration.`,
+}
+
+var cacheStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the Redis cache container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		if err := lsm.NewCacheLifecycleManager(cfg).StartContainer(); err != nil {
+			log.WithError(err).Error("Error starting cache container")
+			return
+		}
+		log.Infof("Cache is available at %s; run 'grayv-lsm config set cache.enabled true' to cache API queries", cfg.Cache.Addr())
+	},
+}
+
+var cacheStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop and remove the Redis cache container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		if err := lsm.NewCacheLifecycleManager(cfg).StopContainer(); err != nil {
+			log.WithError(err).Error("Error stopping cache container")
+		}
+	},
+}
+
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check the status of the Redis cache container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		status, err := lsm.NewCacheLifecycleManager(cfg).GetStatus()
+		if err != nil {
+			log.WithError(err).Error("Error checking cache status")
+			return
+		}
+		log.Info(status)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStartCmd, cacheStopCmd, cacheStatusCmd)
+	RootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/internal/serve"
+	"github.com/spf13/cobra"
+)
+
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Generate clients for the REST API of serve",
+}
+
+var clientGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a typed HTTP client for the CRUD endpoints",
+	Long: `Generate a typed HTTP client for the CRUD endpoints grayv-lsm serve exposes for the stored models, with
+list, get, create, update, and delete methods for every model. --lang go writes a Go package using net/http,
+--lang ts a TypeScript module using fetch. The usual generation flags apply.`,
+	Args: cobra.NoArgs,
+	Run:  runClientGenerate,
+}
+
+func init() {
+	clientGenerateCmd.Flags().String("lang", "go", "Language of the client: go or ts")
+	clientGenerateCmd.Flags().String("out", "", "File to write the client to (default client/client.go, or client.ts for ts)")
+	clientGenerateCmd.Flags().String("package", serve.DefaultClientPackage, "Package name of the Go client")
+	addGenerationFlags(clientGenerateCmd)
+	clientCmd.AddCommand(clientGenerateCmd)
+	RootCmd.AddCommand(clientCmd)
+}
+
+func runClientGenerate(cmd *cobra.Command, args []string) {
+	lang, _ := cmd.Flags().GetString("lang")
+	out, _ := cmd.Flags().GetString("out")
+	pkg, _ := cmd.Flags().GetString("package")
+
+	var defs []*model.ModelDefinition
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		if defs, err = loadModelDefinitions(conn); err != nil {
+			return fmt.Errorf("error loading models: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error generating client")
+		return
+	}
+	if len(defs) == 0 {
+		log.Warn("No models found")
+		return
+	}
+
+	source, err := serve.GenerateClient(defs, lang, pkg)
+	if err != nil {
+		log.WithError(err).Error("Error generating client")
+		return
+	}
+	w := generationWriter(cmd)
+	if lang == "go" {
+		if out == "" {
+			out = "client/client.go"
+		}
+		err = w.WriteGoFile(out, source)
+	} else {
+		if out == "" {
+			out = "client.ts"
+		}
+		err = w.WriteFile(out, source)
+	}
+	if err != nil {
+		log.WithError(err).Error("Error writing client")
+		return
+	}
+	if !w.Preview() {
+		log.Infof("Wrote the %s client for %d models to %s", lang, len(defs), out)
+	}
+}
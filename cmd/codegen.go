@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+	"github.com/ooyeku/grayv-lsm/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// addGenerationFlags registers the flags controlling how a command writes generated files.
+func addGenerationFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "List the files that would be written without writing them")
+	cmd.Flags().Bool("diff", false, "Show how generated files would change without writing them")
+	cmd.Flags().Bool("force", false, "Overwrite generated files even if they were modified by hand")
+}
+
+// generationWriter returns the codegen.Writer configured by the flags added with addGenerationFlags.
+func generationWriter(cmd *cobra.Command) *codegen.Writer {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	diff, _ := cmd.Flags().GetBool("diff")
+	force, _ := cmd.Flags().GetBool("force")
+	return &codegen.Writer{DryRun: dryRun, Diff: diff, Force: force, Out: cmd.OutOrStdout()}
+}
+
+// printDiff prints the colored unified diff turning old into new, the content of name, to stdout.
+// It prints nothing if they are equal.
+func printDiff(name string, old, new []byte) error {
+	diff, err := utils.UnifiedDiff("a/"+name, "b/"+name, old, new)
+	if err != nil {
+		return err
+	}
+	fmt.Print(utils.ColorDiff(diff))
+	return nil
+}
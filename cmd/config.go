@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
 	"github.com/ooyeku/grayv-lsm/pkg/logging"
 	"github.com/spf13/cobra"
@@ -36,9 +39,54 @@ var configSetCmd = &cobra.Command{
 	Run:   runConfigSet,
 }
 
+var configSetSecretCmd = &cobra.Command{
+	Use:   "set-secret [key]",
+	Short: "Store a secret in the credential store and reference it from the config",
+	Long: `Store the secret for a configuration key, such as database.password, in the credential store selected by
+credentialstore, and set the key to a reference to it, so config.json no longer holds the secret.
+The secret is read from --password-stdin, ` + passwordEnvVar + `, or a prompt.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runConfigSetSecret,
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt config.json in place",
+	Long: `Encrypt config.json with a passphrase so the database credentials it holds can be committed or synced safely.
+The passphrase is read from ` + config.KeyEnvVar + ` or the OS keychain; with --generate-key a new one is generated and printed.
+Every command decrypts the file transparently when the passphrase is available.`,
+	Args: cobra.NoArgs,
+	Run:  runConfigEncrypt,
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt config.json in place",
+	Args:  cobra.NoArgs,
+	Run:   runConfigDecrypt,
+}
+
+var configMessagesCmd = &cobra.Command{
+	Use:   "messages",
+	Short: "Print the English message templates, as a starting point for a message catalog",
+	Long: `Print the English templates of the messages grayv-lsm prints, as a JSON object by message key. Save the
+templates to translate or rebrand as <messages.dir>/<locale>.json and set messages.locale to use them; the
+templates left out keep their English text.`,
+	Args: cobra.NoArgs,
+	Run:  runConfigMessages,
+}
+
 func init() {
 	configCmd.AddCommand(configGetCmd)
+	configSetCmd.Flags().Bool("dry-run", false, "Show how config.json would change without saving it")
 	configCmd.AddCommand(configSetCmd)
+	configSetSecretCmd.Flags().String("alias", "", "Name the secret is stored under (default: the key)")
+	addPasswordFlags(configSetSecretCmd, "Secret to store")
+	configCmd.AddCommand(configSetSecretCmd)
+	configEncryptCmd.Flags().Bool("generate-key", false, "Generate a new passphrase and print it instead of reading "+config.KeyEnvVar)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+	configCmd.AddCommand(configMessagesCmd)
 	RootCmd.AddCommand(configCmd)
 }
 
@@ -65,7 +113,24 @@ func runConfigSet(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	before, err := config.MarshalConfig(cfg)
+	if err != nil {
+		configLogger.Error(fmt.Sprintf("Error encoding config: %v", err))
+		return
+	}
 	if setConfigValue(cfg, args[0], args[1]) {
+		after, err := config.MarshalConfig(cfg)
+		if err != nil {
+			configLogger.Error(fmt.Sprintf("Error encoding config: %v", err))
+			return
+		}
+		if err := printDiff("config.json", before, after); err != nil {
+			configLogger.Error(fmt.Sprintf("Error showing changes: %v", err))
+			return
+		}
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			return
+		}
 		err = config.SaveConfig(cfg)
 		if err != nil {
 			configLogger.Error(fmt.Sprintf("Error saving config: %v", err))
@@ -77,7 +142,95 @@ func runConfigSet(cmd *cobra.Command, args []string) {
 	}
 }
 
+func runConfigMessages(cmd *cobra.Command, args []string) {
+	if err := printJSON(messages.Defaults()); err != nil {
+		configLogger.Error(fmt.Sprintf("Error printing messages: %v", err))
+	}
+}
+
+func runConfigSetSecret(cmd *cobra.Command, args []string) {
+	key := args[0]
+	if !config.IsSecretKey(key) {
+		configLogger.Warn(fmt.Sprintf("Configuration key '%s' cannot reference a secret", key))
+		return
+	}
+	alias, _ := cmd.Flags().GetString("alias")
+	if alias == "" {
+		alias = key
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		configLogger.Error(fmt.Sprintf("Error loading config: %v", err))
+		return
+	}
+	secret, err := readPassword(cmd, true, true)
+	if err != nil {
+		configLogger.Error(fmt.Sprintf("Error reading secret: %v", err))
+		return
+	}
+	if err := config.SetSecret(cfg.CredentialStore, alias, secret); err != nil {
+		configLogger.Error(fmt.Sprintf("Error storing secret: %v", err))
+		return
+	}
+
+	setConfigValue(cfg, key, config.SecretPrefix+alias)
+	if err := config.SaveConfig(cfg); err != nil {
+		configLogger.Error(fmt.Sprintf("Error saving config: %v", err))
+		return
+	}
+	configLogger.Info(fmt.Sprintf("Secret stored as %q; %s now references it", alias, key))
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) {
+	var key string
+	generate, _ := cmd.Flags().GetBool("generate-key")
+	if generate {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			configLogger.Error(fmt.Sprintf("Error generating key: %v", err))
+			return
+		}
+		key = base64.RawURLEncoding.EncodeToString(secret)
+	} else {
+		var err error
+		if key, err = config.EncryptionKey(); err != nil {
+			configLogger.Error(fmt.Sprintf("No passphrase found: set %s, store it in the OS keychain, or pass --generate-key", config.KeyEnvVar))
+			return
+		}
+	}
+
+	if err := config.EncryptFile("config.json", key); err != nil {
+		configLogger.Error(fmt.Sprintf("Error encrypting config: %v", err))
+		return
+	}
+	configLogger.Info("config.json encrypted")
+	if generate {
+		configLogger.Warn(fmt.Sprintf("Keep this passphrase safe and export it as %s; the config cannot be read without it:", config.KeyEnvVar))
+		fmt.Fprintln(cmd.OutOrStdout(), key)
+	}
+}
+
+func runConfigDecrypt(cmd *cobra.Command, args []string) {
+	key, err := config.EncryptionKey()
+	if err != nil {
+		configLogger.Error(fmt.Sprintf("Error decrypting config: %v", err))
+		return
+	}
+	if err := config.DecryptFile("config.json", key); err != nil {
+		configLogger.Error(fmt.Sprintf("Error decrypting config: %v", err))
+		return
+	}
+	configLogger.Info("config.json decrypted")
+}
+
+// variablesPrefix starts the keys of SQL variables, such as variables.ADMIN_EMAIL. The variable name keeps its case.
+const variablesPrefix = "variables."
+
 func getConfigValue(cfg *config.Config, key string) string {
+	if strings.HasPrefix(strings.ToLower(key), variablesPrefix) {
+		return cfg.Variables[key[len(variablesPrefix):]]
+	}
 	switch strings.ToLower(key) {
 	case "database.driver":
 		return cfg.Database.Driver
@@ -91,24 +244,209 @@ func getConfigValue(cfg *config.Config, key string) string {
 		return cfg.Database.Password
 	case "database.name":
 		return cfg.Database.Name
+	case "database.path":
+		return cfg.Database.Path
 	case "database.sslmode":
 		return cfg.Database.SSLMode
 	case "server.host":
 		return cfg.Server.Host
 	case "server.port":
 		return fmt.Sprintf("%d", cfg.Server.Port)
+	case "server.staticdir":
+		return cfg.Server.StaticDir
+	case "server.shutdowntimeout", "server.shutdown_timeout":
+		return cfg.Server.ShutdownTimeout
 	case "logging.level":
 		return cfg.Logging.Level
 	case "logging.file":
 		return cfg.Logging.File
+	case "password.algorithm":
+		return cfg.Password.Algorithm
+	case "password.bcryptcost":
+		return fmt.Sprintf("%d", cfg.Password.BcryptCost)
+	case "password.minlength":
+		return fmt.Sprintf("%d", cfg.Password.MinLength)
+	case "password.requireupper":
+		return strconv.FormatBool(cfg.Password.RequireUpper)
+	case "password.requirelower":
+		return strconv.FormatBool(cfg.Password.RequireLower)
+	case "password.requiredigit":
+		return strconv.FormatBool(cfg.Password.RequireDigit)
+	case "password.requiresymbol":
+		return strconv.FormatBool(cfg.Password.RequireSymbol)
+	case "tracing.enabled":
+		return strconv.FormatBool(cfg.Tracing.Enabled)
+	case "tracing.endpoint":
+		return cfg.Tracing.Endpoint
+	case "tracing.insecure":
+		return strconv.FormatBool(cfg.Tracing.Insecure)
+	case "tracing.servicename":
+		return cfg.Tracing.ServiceName
+	case "tracing.sampleratio":
+		return strconv.FormatFloat(cfg.Tracing.SampleRatio, 'f', -1, 64)
+	case "stats.enabled":
+		return strconv.FormatBool(cfg.Stats.Enabled)
+	case "stats.file":
+		return cfg.Stats.File
+	case "messages.locale":
+		return cfg.Messages.Locale
+	case "messages.dir":
+		return cfg.Messages.Dir
+	case "messages.dateformat", "messages.date_format":
+		return cfg.Messages.DateFormat
+	case "messages.thousandsseparator", "messages.thousands_separator":
+		return cfg.Messages.ThousandsSeparator
+	case "messages.decimalseparator", "messages.decimal_separator":
+		return cfg.Messages.DecimalSeparator
 	case "database.containername":
 		return cfg.Database.ContainerName
+	case "database.readonly":
+		return strconv.FormatBool(cfg.Database.ReadOnly)
+	case "database.statementtimeout", "database.statement_timeout":
+		return cfg.Database.StatementTimeout
+	case "database.timezone", "database.time_zone":
+		return cfg.Database.TimeZone
+	case "database.tenancy":
+		return cfg.Database.Tenancy
+	case "database.snowflakenode", "database.snowflake_node":
+		return strconv.Itoa(cfg.Database.SnowflakeNode)
+	case "database.echosql", "database.echo_sql":
+		return strconv.FormatBool(cfg.Database.EchoSQL)
+	case "database.nopublish", "database.no_publish":
+		return strconv.FormatBool(cfg.Database.NoPublish)
+	case "docker.network":
+		return cfg.Docker.Network
+	case "naming.columns":
+		return cfg.Naming.Columns
+	case "naming.tables":
+		return cfg.Naming.Tables
+	case "naming.prefix":
+		return cfg.Naming.Prefix
+	case "database.docker.baseimage", "database.docker.base_image":
+		return cfg.Database.Docker.BaseImage
+	case "database.docker.version":
+		return cfg.Database.Docker.Version
+	case "database.docker.locale":
+		return cfg.Database.Docker.Locale
+	case "database.docker.extensions":
+		return strings.Join(cfg.Database.Docker.Extensions, ",")
+	case "database.docker.initscripts", "database.docker.init_scripts":
+		return strings.Join(cfg.Database.Docker.InitScripts, ",")
+	case "server.cors.enabled":
+		return strconv.FormatBool(cfg.Server.CORS.Enabled)
+	case "server.cors.allowedorigins":
+		return strings.Join(cfg.Server.CORS.AllowedOrigins, ",")
+	case "server.cors.allowedmethods":
+		return strings.Join(cfg.Server.CORS.AllowedMethods, ",")
+	case "server.cors.allowedheaders":
+		return strings.Join(cfg.Server.CORS.AllowedHeaders, ",")
+	case "server.cors.allowcredentials":
+		return strconv.FormatBool(cfg.Server.CORS.AllowCredentials)
+	case "server.cors.maxage":
+		return fmt.Sprintf("%d", cfg.Server.CORS.MaxAge)
+	case "server.csrf.enabled":
+		return strconv.FormatBool(cfg.Server.CSRF.Enabled)
+	case "server.csrf.cookiename":
+		return cfg.Server.CSRF.CookieName
+	case "server.csrf.headername":
+		return cfg.Server.CSRF.HeaderName
+	case "server.csrf.secure":
+		return strconv.FormatBool(cfg.Server.CSRF.Secure)
+	case "server.ratelimit.enabled":
+		return strconv.FormatBool(cfg.Server.RateLimit.Enabled)
+	case "server.ratelimit.backend":
+		return cfg.Server.RateLimit.Backend
+	case "server.ratelimit.rate":
+		return strconv.FormatFloat(cfg.Server.RateLimit.Rate, 'f', -1, 64)
+	case "server.ratelimit.burst":
+		return fmt.Sprintf("%d", cfg.Server.RateLimit.Burst)
+	case "server.ratelimit.keyby":
+		return cfg.Server.RateLimit.KeyBy
+	case "cache.enabled":
+		return strconv.FormatBool(cfg.Cache.Enabled)
+	case "cache.host":
+		return cfg.Cache.Host
+	case "cache.port":
+		return fmt.Sprintf("%d", cfg.Cache.Port)
+	case "cache.password":
+		return cfg.Cache.Password
+	case "cache.db":
+		return fmt.Sprintf("%d", cfg.Cache.DB)
+	case "cache.containername":
+		return cfg.Cache.ContainerName
+	case "cache.image":
+		return cfg.Cache.Image
+	case "cache.ttl":
+		return cfg.Cache.TTL
+	case "cache.addr":
+		return cfg.Cache.Addr()
+	case "storage.backend":
+		return cfg.Storage.Backend
+	case "storage.dir":
+		return cfg.Storage.Dir
+	case "storage.endpoint":
+		return cfg.Storage.Endpoint
+	case "storage.bucket":
+		return cfg.Storage.Bucket
+	case "storage.accesskey":
+		return cfg.Storage.AccessKey
+	case "storage.secretkey":
+		return cfg.Storage.SecretKey
+	case "storage.region":
+		return cfg.Storage.Region
+	case "storage.secure":
+		return strconv.FormatBool(cfg.Storage.Secure)
+	case "storage.containername":
+		return cfg.Storage.ContainerName
+	case "storage.image":
+		return cfg.Storage.Image
+	case "mail.host":
+		return cfg.Mail.Host
+	case "mail.port":
+		return fmt.Sprintf("%d", cfg.Mail.Port)
+	case "mail.username":
+		return cfg.Mail.Username
+	case "mail.password":
+		return cfg.Mail.Password
+	case "mail.from":
+		return cfg.Mail.From
+	case "mail.containername":
+		return cfg.Mail.ContainerName
+	case "mail.image":
+		return cfg.Mail.Image
+	case "mail.uiport":
+		return fmt.Sprintf("%d", cfg.Mail.UIPort)
+	case "pooler.enabled":
+		return strconv.FormatBool(cfg.Pooler.Enabled)
+	case "pooler.port":
+		return fmt.Sprintf("%d", cfg.Pooler.Port)
+	case "pooler.poolmode", "pooler.pool_mode":
+		return cfg.Pooler.PoolMode
+	case "pooler.poolsize", "pooler.pool_size":
+		return fmt.Sprintf("%d", cfg.Pooler.PoolSize)
+	case "pooler.maxclientconn", "pooler.max_client_conn":
+		return fmt.Sprintf("%d", cfg.Pooler.MaxClientConn)
+	case "pooler.containername":
+		return cfg.Pooler.ContainerName
+	case "pooler.image":
+		return cfg.Pooler.Image
+	case "retention.batchsize", "retention.batch_size":
+		return fmt.Sprintf("%d", cfg.Retention.BatchSize)
+	case "credentialstore", "credential_store":
+		return cfg.CredentialStore
 	default:
 		return ""
 	}
 }
 
 func setConfigValue(cfg *config.Config, key, value string) bool {
+	if strings.HasPrefix(strings.ToLower(key), variablesPrefix) && len(key) > len(variablesPrefix) {
+		if cfg.Variables == nil {
+			cfg.Variables = make(map[string]string)
+		}
+		cfg.Variables[key[len(variablesPrefix):]] = value
+		return true
+	}
 	switch strings.ToLower(key) {
 	case "database.driver":
 		cfg.Database.Driver = value
@@ -122,18 +460,194 @@ func setConfigValue(cfg *config.Config, key, value string) bool {
 		cfg.Database.Password = value
 	case "database.name":
 		cfg.Database.Name = value
+	case "database.path":
+		cfg.Database.Path = value
 	case "database.sslmode":
 		cfg.Database.SSLMode = value
 	case "server.host":
 		cfg.Server.Host = value
 	case "server.port":
 		cfg.Server.Port = parseInt(value)
+	case "server.staticdir":
+		cfg.Server.StaticDir = value
+	case "server.shutdowntimeout", "server.shutdown_timeout":
+		cfg.Server.ShutdownTimeout = value
 	case "logging.level":
 		cfg.Logging.Level = value
 	case "logging.file":
 		cfg.Logging.File = value
+	case "password.algorithm":
+		cfg.Password.Algorithm = value
+	case "password.bcryptcost":
+		cfg.Password.BcryptCost = parseInt(value)
+	case "password.minlength":
+		cfg.Password.MinLength = parseInt(value)
+	case "password.requireupper":
+		cfg.Password.RequireUpper = parseBool(value)
+	case "password.requirelower":
+		cfg.Password.RequireLower = parseBool(value)
+	case "password.requiredigit":
+		cfg.Password.RequireDigit = parseBool(value)
+	case "password.requiresymbol":
+		cfg.Password.RequireSymbol = parseBool(value)
+	case "tracing.enabled":
+		cfg.Tracing.Enabled = parseBool(value)
+	case "tracing.endpoint":
+		cfg.Tracing.Endpoint = value
+	case "tracing.insecure":
+		cfg.Tracing.Insecure = parseBool(value)
+	case "tracing.servicename":
+		cfg.Tracing.ServiceName = value
+	case "tracing.sampleratio":
+		cfg.Tracing.SampleRatio, _ = strconv.ParseFloat(value, 64)
+	case "stats.enabled":
+		cfg.Stats.Enabled = parseBool(value)
+	case "stats.file":
+		cfg.Stats.File = value
+	case "messages.locale":
+		cfg.Messages.Locale = value
+	case "messages.dir":
+		cfg.Messages.Dir = value
+	case "messages.dateformat", "messages.date_format":
+		cfg.Messages.DateFormat = value
+	case "messages.thousandsseparator", "messages.thousands_separator":
+		cfg.Messages.ThousandsSeparator = value
+	case "messages.decimalseparator", "messages.decimal_separator":
+		cfg.Messages.DecimalSeparator = value
 	case "database.containername":
 		cfg.Database.ContainerName = value
+	case "database.readonly":
+		cfg.Database.ReadOnly = parseBool(value)
+	case "database.statementtimeout", "database.statement_timeout":
+		cfg.Database.StatementTimeout = value
+	case "database.timezone", "database.time_zone":
+		cfg.Database.TimeZone = value
+	case "database.tenancy":
+		cfg.Database.Tenancy = value
+	case "database.snowflakenode", "database.snowflake_node":
+		cfg.Database.SnowflakeNode = parseInt(value)
+	case "database.echosql", "database.echo_sql":
+		cfg.Database.EchoSQL = parseBool(value)
+	case "database.nopublish", "database.no_publish":
+		cfg.Database.NoPublish = parseBool(value)
+	case "docker.network":
+		cfg.Docker.Network = value
+	case "naming.columns":
+		cfg.Naming.Columns = value
+	case "naming.tables":
+		cfg.Naming.Tables = value
+	case "naming.prefix":
+		cfg.Naming.Prefix = value
+	case "database.docker.baseimage", "database.docker.base_image":
+		cfg.Database.Docker.BaseImage = value
+	case "database.docker.version":
+		cfg.Database.Docker.Version = value
+	case "database.docker.locale":
+		cfg.Database.Docker.Locale = value
+	case "database.docker.extensions":
+		cfg.Database.Docker.Extensions = parseList(value)
+	case "database.docker.initscripts", "database.docker.init_scripts":
+		cfg.Database.Docker.InitScripts = parseList(value)
+	case "server.cors.enabled":
+		cfg.Server.CORS.Enabled = parseBool(value)
+	case "server.cors.allowedorigins":
+		cfg.Server.CORS.AllowedOrigins = parseList(value)
+	case "server.cors.allowedmethods":
+		cfg.Server.CORS.AllowedMethods = parseList(value)
+	case "server.cors.allowedheaders":
+		cfg.Server.CORS.AllowedHeaders = parseList(value)
+	case "server.cors.allowcredentials":
+		cfg.Server.CORS.AllowCredentials = parseBool(value)
+	case "server.cors.maxage":
+		cfg.Server.CORS.MaxAge = parseInt(value)
+	case "server.csrf.enabled":
+		cfg.Server.CSRF.Enabled = parseBool(value)
+	case "server.csrf.cookiename":
+		cfg.Server.CSRF.CookieName = value
+	case "server.csrf.headername":
+		cfg.Server.CSRF.HeaderName = value
+	case "server.csrf.secure":
+		cfg.Server.CSRF.Secure = parseBool(value)
+	case "server.ratelimit.enabled":
+		cfg.Server.RateLimit.Enabled = parseBool(value)
+	case "server.ratelimit.backend":
+		cfg.Server.RateLimit.Backend = value
+	case "server.ratelimit.rate":
+		cfg.Server.RateLimit.Rate, _ = strconv.ParseFloat(value, 64)
+	case "server.ratelimit.burst":
+		cfg.Server.RateLimit.Burst = parseInt(value)
+	case "server.ratelimit.keyby":
+		cfg.Server.RateLimit.KeyBy = value
+	case "cache.enabled":
+		cfg.Cache.Enabled = parseBool(value)
+	case "cache.host":
+		cfg.Cache.Host = value
+	case "cache.port":
+		cfg.Cache.Port = parseInt(value)
+	case "cache.password":
+		cfg.Cache.Password = value
+	case "cache.db":
+		cfg.Cache.DB = parseInt(value)
+	case "cache.containername":
+		cfg.Cache.ContainerName = value
+	case "cache.image":
+		cfg.Cache.Image = value
+	case "cache.ttl":
+		cfg.Cache.TTL = value
+	case "storage.backend":
+		cfg.Storage.Backend = value
+	case "storage.dir":
+		cfg.Storage.Dir = value
+	case "storage.endpoint":
+		cfg.Storage.Endpoint = value
+	case "storage.bucket":
+		cfg.Storage.Bucket = value
+	case "storage.accesskey":
+		cfg.Storage.AccessKey = value
+	case "storage.secretkey":
+		cfg.Storage.SecretKey = value
+	case "storage.region":
+		cfg.Storage.Region = value
+	case "storage.secure":
+		cfg.Storage.Secure = parseBool(value)
+	case "storage.containername":
+		cfg.Storage.ContainerName = value
+	case "storage.image":
+		cfg.Storage.Image = value
+	case "mail.host":
+		cfg.Mail.Host = value
+	case "mail.port":
+		cfg.Mail.Port = parseInt(value)
+	case "mail.username":
+		cfg.Mail.Username = value
+	case "mail.password":
+		cfg.Mail.Password = value
+	case "mail.from":
+		cfg.Mail.From = value
+	case "mail.containername":
+		cfg.Mail.ContainerName = value
+	case "mail.image":
+		cfg.Mail.Image = value
+	case "mail.uiport":
+		cfg.Mail.UIPort = parseInt(value)
+	case "pooler.enabled":
+		cfg.Pooler.Enabled = parseBool(value)
+	case "pooler.port":
+		cfg.Pooler.Port = parseInt(value)
+	case "pooler.poolmode", "pooler.pool_mode":
+		cfg.Pooler.PoolMode = value
+	case "pooler.poolsize", "pooler.pool_size":
+		cfg.Pooler.PoolSize = parseInt(value)
+	case "pooler.maxclientconn", "pooler.max_client_conn":
+		cfg.Pooler.MaxClientConn = parseInt(value)
+	case "pooler.containername":
+		cfg.Pooler.ContainerName = value
+	case "pooler.image":
+		cfg.Pooler.Image = value
+	case "retention.batchsize", "retention.batch_size":
+		cfg.Retention.BatchSize = parseInt(value)
+	case "credentialstore", "credential_store":
+		cfg.CredentialStore = value
 	default:
 		return false
 	}
@@ -144,3 +658,19 @@ func parseInt(value string) int {
 	i, _ := strconv.Atoi(value)
 	return i
 }
+
+func parseBool(value string) bool {
+	b, _ := strconv.ParseBool(value)
+	return b
+}
+
+// parseList splits a comma-separated value into its trimmed, non-empty parts.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// passwordEnvVar is the environment variable read by commands that take a password, for non-interactive use.
+const passwordEnvVar = "GRAYV_PASSWORD"
+
+// addPasswordFlags registers the flags used by readPassword on a command that takes a password.
+func addPasswordFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().String("password", "", usage+" (insecure: visible in shell history and process lists)")
+	cmd.Flags().Bool("password-stdin", false, "Read the password from standard input")
+	cmd.MarkFlagsMutuallyExclusive("password", "password-stdin")
+}
+
+// readPassword returns the password for a command that was set up with addPasswordFlags. Sources are tried in order:
+//   - the --password flag, with a warning because it leaks the password
+//   - the first line of standard input when --password-stdin is set
+//   - the GRAYV_PASSWORD environment variable, if required is set
+//   - a hidden prompt when standard input is a terminal, if required is set; asked twice when confirm is set
+//
+// If required is not set and neither flag is given, an empty password means none was given.
+func readPassword(cmd *cobra.Command, required, confirm bool) (string, error) {
+	if cmd.Flags().Changed("password") {
+		log.Warn("Passing --password on the command line is insecure; use --password-stdin, " + passwordEnvVar + ", or the prompt")
+		password, _ := cmd.Flags().GetString("password")
+		return password, nil
+	}
+
+	if fromStdin, _ := cmd.Flags().GetBool("password-stdin"); fromStdin {
+		return readPasswordLine(cmd.InOrStdin())
+	}
+
+	// Optional passwords are only taken from explicit flags, so an exported variable
+	// cannot silently reset a password during an unrelated update.
+	if !required {
+		return "", nil
+	}
+
+	if password, ok := os.LookupEnv(passwordEnvVar); ok {
+		return password, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("a password is required: use --password-stdin or set " + passwordEnvVar)
+	}
+
+	password, err := promptHidden(fd, "Password: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := promptHidden(fd, "Confirm password: ")
+		if err != nil {
+			return "", err
+		}
+		if again != password {
+			return "", errors.New("passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+// promptHidden prints prompt to standard error and reads a line from the terminal without echoing it.
+func promptHidden(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading password: %w", err)
+	}
+	return string(password), nil
+}
+
+// readPasswordLine reads the first line of r, without its line ending.
+func readPasswordLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("error reading password from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var ormCrudCmd = &cobra.Command{
+	Use:   "crud",
+	Short: "Update or delete the records of a stored model",
+}
+
+var ormCrudUpdateCmd = &cobra.Command{
+	Use:   "update [model]",
+	Short: "Set columns of every record matching a condition",
+	Long: `Set columns of every record of a stored model matching --where, a SQL condition such as
+"status = 'draft' AND created_at < now() - interval '30 days'". Use --where TRUE to update every record.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCrudUpdate,
+}
+
+var ormCrudDeleteCmd = &cobra.Command{
+	Use:   "delete [model]",
+	Short: "Delete every record matching a condition",
+	Long: `Delete every record of a stored model matching --where, a SQL condition such as "status = 'spam'".
+Use --where TRUE to delete every record.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCrudDelete,
+}
+
+func init() {
+	ormCrudCmd.AddCommand(ormCrudUpdateCmd)
+	ormCrudCmd.AddCommand(ormCrudDeleteCmd)
+	ormCmd.AddCommand(ormCrudCmd)
+
+	for _, cmd := range []*cobra.Command{ormCrudUpdateCmd, ormCrudDeleteCmd} {
+		cmd.Flags().String("where", "", "SQL condition the records must match (required)")
+		cmd.Flags().String("tenant", "", "Tenant whose records are changed, when database.tenancy is set")
+		cmd.MarkFlagRequired("where")
+	}
+	ormCrudUpdateCmd.Flags().StringArray("set", nil, "Column to set, as column=value (repeatable)")
+	ormCrudUpdateCmd.MarkFlagRequired("set")
+}
+
+// storedModel lets the CRUD operations of the orm package work on the records of a stored model definition.
+type storedModel struct {
+	model.DefaultModel
+	def *model.ModelDefinition
+}
+
+func (s *storedModel) TableName() string  { return s.def.TableName() }
+func (s *storedModel) PrimaryKey() string { return s.def.PrimaryKey() }
+func (s *storedModel) Columns() []string  { return s.def.Columns() }
+func (s *storedModel) ReadOnly() bool     { return s.def.IsView() }
+
+// parseAssignments parses column=value pairs into the columns and values of an update.
+func parseAssignments(assignments []string) (map[string]interface{}, error) {
+	set := make(map[string]interface{}, len(assignments))
+	for _, assignment := range assignments {
+		column, value, ok := strings.Cut(assignment, "=")
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid assignment %q: use column=value", assignment)
+		}
+		set[column] = value
+	}
+	return set, nil
+}
+
+// withStoredModel runs fn with the stored model named by the command argument and a CRUD scoped to the
+// tenant flag, if it is set.
+func withStoredModel(cmd *cobra.Command, name string, fn func(*orm.CRUD, *storedModel) error) error {
+	tenant, _ := cmd.Flags().GetString("tenant")
+	return withDBConnection(func(conn *orm.Connection) error {
+		if normalized, err := model.NormalizeModelName(name); err == nil {
+			name = normalized
+		}
+		defs, err := loadModelDefinitions(conn, name)
+		if err != nil {
+			return err
+		}
+		if len(defs) == 0 {
+			return fmt.Errorf("model %s not found", name)
+		}
+
+		crud := orm.NewCRUD(conn)
+		if tenant != "" {
+			if crud, err = crud.ForTenant(orm.WithTenant(context.Background(), tenant)); err != nil {
+				return err
+			}
+		}
+		return fn(crud, &storedModel{def: defs[0]})
+	})
+}
+
+func runCrudUpdate(cmd *cobra.Command, args []string) {
+	where, _ := cmd.Flags().GetString("where")
+	assignments, _ := cmd.Flags().GetStringArray("set")
+	set, err := parseAssignments(assignments)
+	if err != nil {
+		log.WithError(err).Error("Invalid --set")
+		return
+	}
+
+	err = withStoredModel(cmd, args[0], func(crud *orm.CRUD, m *storedModel) error {
+		count, err := crud.UpdateWhere(m, set, where)
+		if err != nil {
+			return err
+		}
+		log.Infof("Updated %d %s record(s)", count, m.def.Name)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to update records")
+	}
+}
+
+func runCrudDelete(cmd *cobra.Command, args []string) {
+	where, _ := cmd.Flags().GetString("where")
+	err := withStoredModel(cmd, args[0], func(crud *orm.CRUD, m *storedModel) error {
+		count, err := crud.DeleteWhere(m, where)
+		if err != nil {
+			return err
+		}
+		log.Infof("Deleted %d %s record(s)", count, m.def.Name)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to delete records")
+	}
+}
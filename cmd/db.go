@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"os"
 	"strconv"
+	"time"
 
 	"fmt"
 	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
 	"github.com/ooyeku/grayv-lsm/internal/database/migration"
 	"github.com/ooyeku/grayv-lsm/internal/database/seed"
+	"github.com/ooyeku/grayv-lsm/internal/database/vars"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
 	"github.com/ooyeku/grayv-lsm/internal/orm"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
 	"github.com/sirupsen/logrus"
@@ -47,8 +51,8 @@ var buildCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := dbManager.BuildImage(); err != nil {
 			log.WithError(err).Error("Error building database image")
-		} else {
-			log.Info("Database image built successfully")
+		} else if !cfg.Database.IsSQLite() {
+			log.Info(messages.Text("db.build.done", nil))
 		}
 	},
 }
@@ -60,8 +64,16 @@ var startCmd = &cobra.Command{
 		err := dbManager.StartContainer()
 		if err != nil {
 			log.WithError(err).Error("Error starting database container")
-		} else {
-			log.Info("Database container started successfully")
+			return
+		}
+		if cfg.Database.IsSQLite() {
+			return
+		}
+		log.Info(messages.Text("db.start.done", nil))
+		if cfg.Pooler.Enabled {
+			if err := lsm.NewPoolerLifecycleManager(cfg).StartContainer(); err != nil {
+				log.WithError(err).Error("Error starting pooler container")
+			}
 		}
 	},
 }
@@ -70,10 +82,15 @@ var stopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop the database Docker container",
 	Run: func(cmd *cobra.Command, args []string) {
+		if cfg.Pooler.Enabled {
+			if err := lsm.NewPoolerLifecycleManager(cfg).StopContainer(); err != nil {
+				log.WithError(err).Error("Error stopping pooler container")
+			}
+		}
 		if err := dbManager.StopContainer(); err != nil {
 			log.WithError(err).Error("Error stopping database container")
-		} else {
-			log.Info("Database container stopped successfully")
+		} else if !cfg.Database.IsSQLite() {
+			log.Info(messages.Text("db.stop.done", nil))
 		}
 	},
 }
@@ -84,8 +101,8 @@ var removeCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := dbManager.RemoveContainer(); err != nil {
 			log.WithError(err).Error("Error removing database container")
-		} else {
-			log.Info("Database container removed successfully")
+		} else if !cfg.Database.IsSQLite() {
+			log.Info(messages.Text("db.remove.done", nil))
 		}
 	},
 }
@@ -101,9 +118,16 @@ var statusCmd = &cobra.Command{
 		}
 
 		log.Info(status)
+		if cfg.Pooler.Enabled {
+			if status, err := lsm.NewPoolerLifecycleManager(cfg).GetStatus(); err != nil {
+				log.WithError(err).Error("Error checking pooler status")
+			} else {
+				log.Info(status)
+			}
+		}
 
 		if strings.Contains(status, "Container is running") {
-			conn, err := orm.NewConnection(&cfg.Database)
+			conn, err := openConnection(cfg)
 			if err != nil {
 				log.WithError(err).Error("Error connecting to database")
 				return
@@ -113,21 +137,17 @@ var statusCmd = &cobra.Command{
 			metrics, err := conn.GetDatabaseMetrics()
 			if err != nil {
 				if strings.Contains(err.Error(), "converting NULL to float64 is unsupported") {
-					log.Info("Database is empty. No tables or data found.")
+					log.Info(messages.Text("db.metrics.empty", nil))
 				} else {
 					log.WithError(err).Error("Error fetching database metrics")
 				}
 				return
 			}
 
-			log.Info("Database Metrics:")
-			log.Infof("- Number of tables: %d", metrics.TableCount)
-			log.Infof("- Database size: %s", metrics.DatabaseSize)
-			log.Infof("- Active connections: %d", metrics.ActiveConnections)
-			log.Infof("- Uptime: %s", metrics.Uptime)
-			log.Infof("- Transactions (commits/rollbacks): %d/%d", metrics.Commits, metrics.Rollbacks)
-			log.Infof("- Cache hit ratio: %.2f%%", metrics.CacheHitRatio)
-			log.Infof("- Slow queries (last hour): %d", metrics.SlowQueryCount)
+			log.Info(messages.Text("db.metrics.header", metrics))
+			for _, key := range []string{"tables", "size", "connections", "uptime", "transactions", "cache_hit_ratio", "slow_queries", "dead_tuples"} {
+				log.Info(messages.Text("db.metrics."+key, metrics))
+			}
 		}
 	},
 }
@@ -135,27 +155,99 @@ var statusCmd = &cobra.Command{
 var seedCmd = &cobra.Command{
 	Use:   "seed",
 	Short: "Seed the database with initial data",
+	Long: `Seed the database with initial data. With --jobs greater than 1, seeds that touch disjoint tables run
+concurrently; a seed can declare its tables with a "-- tables: users, posts" comment, otherwise they are inferred.
+With --continue-on-error, a failing statement of a SQL seed is rolled back to a savepoint and skipped.
+With --dir, the seeds in a directory, such as those generated by db make-seed, run instead of the embedded ones.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		dir, _ := cmd.Flags().GetString("dir")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		endConnect := phase("connect")
 		err := withDBConnection(func(conn *orm.Connection) error {
+			endConnect()
+			if err := conn.CheckWritable("seed the database"); err != nil {
+				return err
+			}
 			seeder := seed.NewSeeder(conn.GetDB())
-			if err := seeder.LoadSeeds(); err != nil {
+			seeder.SetBulkLoader(conn.BulkLoader())
+			seeder.ContinueOnError = continueOnError
+			seeder.Vars = vars.FromConfig(cfg.Variables)
+			endLoad := phase("load")
+			var err error
+			if dir != "" {
+				err = seeder.LoadSeedsFS(os.DirFS(dir), ".")
+			} else {
+				err = seeder.LoadSeeds()
+			}
+			endLoad()
+			if err != nil {
 				return fmt.Errorf("error loading seeds: %w", err)
 			}
-			return seeder.Seed()
+			defer phase("seed")()
+			return seeder.SeedConcurrently(jobs)
 		})
 		if err != nil {
 			log.WithError(err).Error("Error seeding database")
 		} else {
-			log.Info("Database seeded successfully")
+			log.Info(messages.Text("db.seed.done", nil))
+		}
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [table] [file]",
+	Short: "Bulk load a CSV file into a table",
+	Long: `Bulk load a CSV file into a table in one transaction. The first record of the file names the columns
+and empty fields are loaded as NULL. With the postgres driver the rows are streamed with COPY; other drivers
+fall back to multi-row INSERT statements of --batch-size rows.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		table, path := args[0], args[1]
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+		file, err := os.Open(path)
+		if err != nil {
+			log.WithError(err).Error("Error opening CSV file")
+			return
+		}
+		defer file.Close()
+
+		var count int64
+		start := time.Now()
+		err = withDBConnection(func(conn *orm.Connection) error {
+			if err := conn.CheckWritable("import data"); err != nil {
+				return err
+			}
+			loader := conn.BulkLoader()
+			loader.BatchSize = batchSize
+			count, err = loader.LoadCSV(table, bufio.NewReader(file))
+			return err
+		})
+		if err != nil {
+			log.WithError(err).Errorf("Error importing %s", path)
+			return
 		}
+		log.Info(messages.Text("db.import.done", map[string]interface{}{
+			"Rows": count, "Table": table, "Elapsed": time.Since(start).Round(time.Millisecond),
+		}))
 	},
 }
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
+	Long: `Run the pending database migrations, each in its own transaction. With --continue-on-error, the statements
+of a migration run one at a time, and a failing statement is rolled back to a savepoint and skipped.
+With --jobs above 1, migrations marked "-- migrate:parallel" or declaring the objects they touch with
+"-- migrate:objects table[, table]" are applied concurrently when their objects are disjoint; the other
+migrations still run alone, in order. SQLite databases are always migrated one by one.
+With --dir, the migration files in a directory, such as those written by db migrate import or generated for an
+app, are applied alongside the embedded migrations.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		conn, err := orm.NewConnection(&cfg.Database)
+		endConnect := phase("connect")
+		conn, err := openConnection(cfg)
+		endConnect()
 		if err != nil {
 			log.WithError(err).Error("Error connecting to database")
 			return
@@ -167,18 +259,30 @@ var migrateCmd = &cobra.Command{
 			}
 		}(conn)
 
-		migrator := migration.NewMigrator(conn.GetDB(), log)
-		err = migrator.LoadMigrations()
+		if err := conn.CheckWritable("run migrations"); err != nil {
+			log.WithError(err).Error("Error running migrations")
+			return
+		}
+
+		migrator := migration.NewMigratorFor(conn, log)
+		migrator.ContinueOnError, _ = cmd.Flags().GetBool("continue-on-error")
+		migrator.Workers, _ = cmd.Flags().GetInt("jobs")
+		migrator.Vars = vars.FromConfig(cfg.Variables)
+		endLoad := phase("load")
+		err = loadMigrations(cmd, migrator)
+		endLoad()
 		if err != nil {
 			log.WithError(err).Error("Error loading migrations")
 			return
 		}
 
+		endMigrate := phase("migrate")
 		err = migrator.Migrate()
+		endMigrate()
 		if err != nil {
 			log.WithError(err).Error("Error running migrations")
 		} else {
-			log.Info("Database migrations completed successfully")
+			log.Info(messages.Text("db.migrate.done", nil))
 		}
 	},
 }
@@ -198,7 +302,7 @@ var rollbackCmd = &cobra.Command{
 			}
 		}
 
-		conn, err := orm.NewConnection(&cfg.Database)
+		conn, err := openConnection(cfg)
 		if err != nil {
 			log.WithError(err).Error("Error connecting to database")
 			return
@@ -210,8 +314,14 @@ var rollbackCmd = &cobra.Command{
 			}
 		}(conn)
 
-		migrator := migration.NewMigrator(conn.GetDB(), log)
-		err = migrator.LoadMigrations()
+		if err := conn.CheckWritable("roll back migrations"); err != nil {
+			log.WithError(err).Error("Error rolling back migrations")
+			return
+		}
+
+		migrator := migration.NewMigratorFor(conn, log)
+		migrator.Vars = vars.FromConfig(cfg.Variables)
+		err = loadMigrations(cmd, migrator)
 		if err != nil {
 			log.WithError(err).Error("Error loading migrations")
 			return
@@ -221,16 +331,37 @@ var rollbackCmd = &cobra.Command{
 		if err != nil {
 			log.WithError(err).Error("Error rolling back migrations")
 		} else {
-			log.Infof("Rolled back %d migration(s) successfully", steps)
+			log.Info(messages.Text("db.rollback.done", map[string]interface{}{"Steps": steps}))
 		}
 	},
 }
 
+var showDockerfileCmd = &cobra.Command{
+	Use:   "show-dockerfile",
+	Short: "Print the Dockerfile of the database image",
+	Long: `Print the Dockerfile db build builds the database image from, rendered with the database.docker settings:
+the base image and version, the locale, the extension packages, and the init scripts. For the mysql driver, it is
+the Dockerfile of the MySQL or MariaDB image instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		dockerfile, err := lsm.RenderDatabaseDockerfile(cfg.Database)
+		if err != nil {
+			log.WithError(err).Error("Error rendering Dockerfile")
+			return
+		}
+		fmt.Print(dockerfile)
+	},
+}
+
 var listTablesCmd = &cobra.Command{
 	Use:   "list-tables",
 	Short: "List all tables in the database",
 	Run: func(cmd *cobra.Command, args []string) {
-		conn, err := orm.NewConnection(&cfg.Database)
+		conn, err := openConnection(cfg)
 		if err != nil {
 			log.WithError(err).Error("Error connecting to database")
 			return
@@ -249,11 +380,11 @@ var listTablesCmd = &cobra.Command{
 		}
 
 		if len(tables) == 0 {
-			log.Info("No tables found in the database")
+			log.Info(messages.Text("db.tables.none", nil))
 		} else {
-			log.Info("Tables in the database:")
+			log.Info(messages.Text("db.tables.header", nil))
 			for _, table := range tables {
-				log.Infof("- %s", table)
+				log.Info(messages.Text("db.tables.item", table))
 			}
 		}
 	},
@@ -261,24 +392,63 @@ var listTablesCmd = &cobra.Command{
 
 func init() {
 	dbCmd.AddCommand(buildCmd)
+	dbCmd.AddCommand(showDockerfileCmd)
 	dbCmd.AddCommand(startCmd)
 	dbCmd.AddCommand(stopCmd)
 	dbCmd.AddCommand(removeCmd)
 	dbCmd.AddCommand(statusCmd)
+	seedCmd.Flags().Int("jobs", 1, "Number of seeds to run concurrently")
+	seedCmd.Flags().Bool("continue-on-error", false, "Skip failing statements instead of failing the seed")
+	seedCmd.Flags().String("dir", "", "Run the seeds in this directory instead of the embedded seeds")
+	migrateCmd.Flags().Bool("continue-on-error", false, "Skip failing statements instead of failing the migration")
+	migrateCmd.Flags().Int("jobs", 1, "Number of independent migrations applied at once")
+	addProfileFlag(seedCmd, migrateCmd)
+	addMigrationDirFlag(migrateCmd, rollbackCmd)
 	dbCmd.AddCommand(seedCmd)
+	importCmd.Flags().Int("batch-size", orm.DefaultBatchSize, "Rows per INSERT statement when COPY is not available")
+	dbCmd.AddCommand(importCmd)
 	dbCmd.AddCommand(migrateCmd)
 	dbCmd.AddCommand(rollbackCmd)
 	dbCmd.AddCommand(listTablesCmd)
 	RootCmd.AddCommand(dbCmd)
 }
 
+// addMigrationDirFlag adds the --dir flag to cmds, naming a directory of migration files loaded by loadMigrations.
+func addMigrationDirFlag(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		cmd.Flags().String("dir", "", "Also load the migration files in this directory, such as those written by db migrate import")
+	}
+}
+
+// loadMigrations loads the embedded migrations into migrator, and the migration files of the directory named by
+// the --dir flag of cmd, if it has one.
+func loadMigrations(cmd *cobra.Command, migrator *migration.Migrator) error {
+	if err := migrator.LoadMigrations(); err != nil {
+		return err
+	}
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return migrator.LoadMigrationDir(dir)
+	}
+	return nil
+}
+
+// openConnection connects to the database configured in cfg, through the pooler if it is enabled, and in
+// read-only mode if the configuration or the --read-only flag asks for it.
+func openConnection(cfg *config.Config) (*orm.Connection, error) {
+	database := cfg.Pooler.Route(cfg.Database)
+	database.ReadOnly = database.ReadOnly || readOnly
+	database.EchoSQL = database.EchoSQL || echoSQL
+	database.ExplainOnly = database.ExplainOnly || explainOnly
+	return orm.NewConnection(&database)
+}
+
 func withDBConnection(action func(*orm.Connection) error) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("error loading config: %w", err)
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	conn, err := openConnection(cfg)
 	if err != nil {
 		return fmt.Errorf("error connecting to database: %w", err)
 	}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/app"
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/database/vars"
+	"github.com/ooyeku/grayv-lsm/internal/demo"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+// demoSeed makes every demo database contain the same fake data.
+const demoSeed = 42
+
+var demoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "Create and serve a sample blog app end to end",
+	Long: `Provision the database, create a sample blog app with Author, Post, and Comment models, generate their code
+and migrations, create their tables by applying the migrations, seed them with fake data, and serve them as a JSON
+REST API. Running the command again reuses the app and the data it created.`,
+	Run: runDemo,
+}
+
+func init() {
+	demoCmd.Flags().String("name", "blog", "Name of the demo app")
+	demoCmd.Flags().Bool("skip-db", false, "Use the configured database instead of building and starting the database container")
+	demoCmd.Flags().Bool("no-serve", false, "Set everything up without starting the API server")
+	RootCmd.AddCommand(demoCmd)
+}
+
+func runDemo(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	name, _ := cmd.Flags().GetString("name")
+	skipDB, _ := cmd.Flags().GetBool("skip-db")
+	noServe, _ := cmd.Flags().GetBool("no-serve")
+
+	defs, err := demo.Definitions()
+	if err != nil {
+		log.WithError(err).Error("Invalid demo models")
+		return
+	}
+
+	if !skipDB {
+		log.Info("Starting the database container")
+		if err := dbManager.BuildImage(); err != nil {
+			log.WithError(err).Error("Error building database image")
+			return
+		}
+		if err := dbManager.StartContainer(); err != nil {
+			log.WithError(err).Error("Error starting database container")
+			return
+		}
+	}
+
+	conn, err := openConnection(cfg)
+	if err != nil {
+		log.WithError(err).Error("Error connecting to database")
+		return
+	}
+	defer conn.Close()
+	if err := waitForDatabase(conn, 30*time.Second); err != nil {
+		log.WithError(err).Error("Database is not accepting connections")
+		return
+	}
+
+	target, err := createDemoApp(cmd, name, defs)
+	if err != nil {
+		log.WithError(err).Error("Error creating demo app")
+		return
+	}
+
+	migrator := migration.NewMigratorFor(conn, log)
+	migrator.Vars = vars.FromConfig(cfg.Variables)
+	if err := migrator.LoadMigrations(); err != nil {
+		log.WithError(err).Error("Error loading migrations")
+		return
+	}
+	if err := migrator.LoadMigrationDir(filepath.Join(target.Dir, "migrations")); err != nil {
+		log.WithError(err).Error("Error loading the migrations of the demo app")
+		return
+	}
+	if err := migrator.Migrate(); err != nil {
+		log.WithError(err).Error("Error running migrations")
+		return
+	}
+
+	if err := storeDemoModels(conn, defs); err != nil {
+		log.WithError(err).Error("Error storing demo models")
+		return
+	}
+
+	seeded, err := seedDemo(cmd.Context(), conn)
+	if err != nil {
+		log.WithError(err).Error("Error seeding demo data")
+		return
+	}
+	if seeded {
+		log.Info("Seeded the demo tables with fake data")
+	} else {
+		log.Info("Demo tables already contain data; skipping seed")
+	}
+
+	log.Infof("Demo app is in %s", target.Dir)
+	if noServe {
+		log.Info("Run 'grayv-lsm serve' to serve the demo models")
+		return
+	}
+	log.Infof("Try: curl http://%s:%d/api/posts", cfg.Server.Host, cfg.Server.Port)
+	serveAPI(cfg)
+}
+
+// waitForDatabase pings conn until the database answers or timeout elapses, since a container that
+// was just started takes a few seconds to accept connections.
+func waitForDatabase(conn *orm.Connection, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := conn.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// createDemoApp creates the demo app with the code and migrations of defs, or returns the app if it already exists.
+func createDemoApp(cmd *cobra.Command, name string, defs []*model.ModelDefinition) (*app.App, error) {
+	w := generationWriter(cmd)
+	if target, err := appCreator.FindApp(name); err == nil {
+		log.Infof("Reusing existing app %s", target.Name)
+		return target, nil
+	}
+
+	if err := appCreator.CreateApp(name, w); err != nil {
+		return nil, err
+	}
+	target, err := appCreator.FindApp(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.WriteFile(filepath.Join(target.Dir, "models.yaml"), []byte(demo.Manifest)); err != nil {
+		return nil, err
+	}
+	if err := generateModels(defs, target, true, w); err != nil {
+		return nil, fmt.Errorf("error generating models: %w", err)
+	}
+	if _, err := model.GenerateMigrationFiles(filepath.Join(target.Dir, "migrations"), defs, dialect.ForDriver(cfg.Database.Driver), w); err != nil {
+		return nil, fmt.Errorf("error generating migrations: %w", err)
+	}
+	log.Infof("Created app %s", target.Name)
+	return target, nil
+}
+
+// storeDemoModels stores the demo models that are not in the models table yet, so `grayv-lsm serve` exposes them.
+func storeDemoModels(conn *orm.Connection, defs []*model.ModelDefinition) error {
+	existing, err := listModelsFromDB(conn)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		stored[name] = true
+	}
+
+	var missing []*model.ModelDefinition
+	for _, def := range defs {
+		if !stored[def.Name] {
+			missing = append(missing, def)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return storeModels(conn, missing)
+}
+
+// seedDemo fills the demo tables, created by the migrations of the demo app, with fake data in a single transaction.
+// Tables that already contain authors are left alone, and seedDemo reports false.
+func seedDemo(ctx context.Context, conn *orm.Connection) (bool, error) {
+	tx, err := conn.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var authors int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM authors").Scan(&authors); err != nil {
+		return false, err
+	}
+	if authors > 0 {
+		return false, nil
+	}
+
+	data := demo.Generate(demo.DefaultSize, rand.New(rand.NewSource(demoSeed)))
+	if err := demo.Seed(ctx, conn, tx, data); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
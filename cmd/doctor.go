@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/ooyeku/grayv-lsm/internal/doctor"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the workspace for common problems",
+	Long: `Check Docker, the configuration, the module paths of apps in the current directory, the embedded assets,
+the server port, the database connection, and pending migrations, and print the fixes for any problems found,
+most important first.`,
+	Run: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+	RootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		cfg = nil
+	}
+
+	results := doctor.Run(context.Background(), []doctor.Check{
+		doctor.Config(cfg, err),
+		doctor.EmbeddedAssets(),
+		doctor.Docker(cfg),
+		doctor.Database(cfg),
+		doctor.Migrations(cfg),
+		doctor.Port(cfg),
+		doctor.Apps(appCreator),
+	})
+
+	if output == "json" {
+		if err := printJSON(results); err != nil {
+			log.WithError(err).Error("Failed to print results")
+		}
+		return
+	}
+	doctor.Print(os.Stdout, results)
+}
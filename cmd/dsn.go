@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var dsnCmd = &cobra.Command{
+	Use:   "dsn",
+	Short: "Print the connection parameters of the database",
+	Long: `Print the effective connection parameters of the database, with secrets resolved, in one of the formats:
+  url   a postgres:// URL, for psql, DBeaver, and most drivers (default)
+  env   DATABASE_URL and the PG* variables read by libpq tools, as a .env file
+  go    the key/value string passed to sql.Open with the lib/pq driver
+With --out or --app, the parameters are written to a file readable only by its owner instead, in env format
+unless --format says otherwise. Generated apps read DATABASE_URL.`,
+	Args: cobra.NoArgs,
+	Run:  runDSN,
+}
+
+func init() {
+	dsnCmd.Flags().String("format", "", "Output format: url, env, or go")
+	dsnCmd.Flags().String("out", "", "File to write the parameters to, such as .env")
+	dsnCmd.Flags().String("app", "", "Name of the Grayv app whose .env file is written")
+	dbCmd.AddCommand(dsnCmd)
+}
+
+func runDSN(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+	appName, _ := cmd.Flags().GetString("app")
+
+	if appName != "" {
+		if out != "" {
+			log.Error("--out and --app cannot be combined")
+			return
+		}
+		target, err := appCreator.FindApp(appName)
+		if err != nil {
+			log.WithError(err).Error("Failed to find app")
+			return
+		}
+		out = filepath.Join(target.Dir, ".env")
+	}
+	if format == "" {
+		format = "url"
+		if out != "" {
+			format = "env"
+		}
+	}
+
+	content, err := formatDSN(cfg.Database, format)
+	if err != nil {
+		log.WithError(err).Error("Error formatting connection parameters")
+		return
+	}
+	if out == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := os.WriteFile(out, []byte(content), 0600); err != nil {
+		log.WithError(err).Error("Error writing connection parameters")
+		return
+	}
+	log.Infof("Wrote the connection parameters to %s; keep it out of version control", out)
+}
+
+// formatDSN returns the connection parameters of db in the given format: url, env, or go.
+func formatDSN(db config.DatabaseConfig, format string) (string, error) {
+	switch format {
+	case "url":
+		return db.URL() + "\n", nil
+	case "go":
+		return db.DSN() + "\n", nil
+	case "env":
+		vars := [][2]string{
+			{"DATABASE_URL", db.URL()},
+			{"PGHOST", db.Host},
+			{"PGPORT", strconv.Itoa(db.Port)},
+			{"PGUSER", db.User},
+			{"PGPASSWORD", db.Password},
+			{"PGDATABASE", db.Name},
+			{"PGSSLMODE", db.SSLMode},
+		}
+		var b strings.Builder
+		for _, v := range vars {
+			b.WriteString(v[0] + "=" + envValue(v[1]) + "\n")
+		}
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("unknown format %q: use url, env, or go", format)
+	}
+}
+
+// envSafeValue matches values written to .env files without quotes.
+var envSafeValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%?=&+-]*$`)
+
+// envValue quotes value for a .env file, in a way sh also understands when the file is sourced.
+func envValue(value string) string {
+	if envSafeValue.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/jobs"
+	"github.com/spf13/cobra"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage background jobs",
+	Long:  `Enqueue, inspect, and process background jobs stored in the database job queue.`,
+}
+
+var jobsWorkCmd = &cobra.Command{
+	Use:   "work",
+	Short: "Run a worker that processes queued jobs",
+	Run:   runJobsWork,
+}
+
+var jobsEnqueueCmd = &cobra.Command{
+	Use:   "enqueue [type]",
+	Short: "Add a job to the queue",
+	Args:  cobra.ExactArgs(1),
+	Run:   runJobsEnqueue,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List jobs in the queue",
+	Run:   runJobsList,
+}
+
+var jobsRetryCmd = &cobra.Command{
+	Use:   "retry [id]",
+	Short: "Move a dead-lettered job back to the queue",
+	Args:  cobra.ExactArgs(1),
+	Run:   runJobsRetry,
+}
+
+func init() {
+	jobsCmd.PersistentFlags().String("queue", jobs.DefaultQueue, "Name of the job queue")
+
+	jobsWorkCmd.Flags().Duration("interval", time.Second, "How often to poll for new jobs when the queue is empty")
+
+	jobsEnqueueCmd.Flags().String("payload", "{}", "JSON payload for the job")
+	jobsEnqueueCmd.Flags().Int("max-attempts", jobs.DefaultMaxAttempts, "Maximum number of attempts before the job is dead-lettered")
+	jobsEnqueueCmd.Flags().Duration("delay", 0, "Delay before the job becomes runnable")
+
+	jobsListCmd.Flags().String("status", "", "Only list jobs with this status (pending, running, done, dead)")
+	jobsListCmd.Flags().Int("limit", 50, "Maximum number of jobs to list")
+
+	jobsCmd.AddCommand(jobsWorkCmd)
+	jobsCmd.AddCommand(jobsEnqueueCmd)
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsRetryCmd)
+	RootCmd.AddCommand(jobsCmd)
+}
+
+func runJobsWork(cmd *cobra.Command, args []string) {
+	queueName, _ := cmd.Flags().GetString("queue")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	if cfg == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+
+	err := runUntilSignal(cfg, func(ctx context.Context) error {
+		return withDBConnection(func(conn *orm.Connection) error {
+			worker := jobs.NewWorker(jobs.NewQueue(conn.GetDB(), queueName), log)
+			worker.PollInterval = interval
+			worker.Register("webhook", jobs.WebhookHandler(nil))
+			return worker.Run(ctx)
+		})
+	})
+	if err != nil {
+		log.WithError(err).Error("Error running job worker")
+	}
+}
+
+func runJobsEnqueue(cmd *cobra.Command, args []string) {
+	queueName, _ := cmd.Flags().GetString("queue")
+	payload, _ := cmd.Flags().GetString("payload")
+	maxAttempts, _ := cmd.Flags().GetInt("max-attempts")
+	delay, _ := cmd.Flags().GetDuration("delay")
+
+	if !json.Valid([]byte(payload)) {
+		log.Error("Payload must be valid JSON")
+		return
+	}
+
+	var id int64
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		id, err = jobs.NewQueue(conn.GetDB(), queueName).EnqueueWithOptions(args[0], json.RawMessage(payload), jobs.EnqueueOptions{
+			RunAt:       time.Now().Add(delay),
+			MaxAttempts: maxAttempts,
+		})
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error enqueueing job")
+	} else {
+		log.Infof("Job %d (%s) enqueued on queue %s", id, args[0], queueName)
+	}
+}
+
+func runJobsList(cmd *cobra.Command, args []string) {
+	queueName, _ := cmd.Flags().GetString("queue")
+	status, _ := cmd.Flags().GetString("status")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		list, err := jobs.NewQueue(conn.GetDB(), queueName).List(status, limit)
+		if err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			log.Info("No jobs found")
+			return nil
+		}
+
+		log.Infof("Jobs in queue %s:", queueName)
+		for _, job := range list {
+			if job.LastError != "" {
+				log.Infof("- %d %s [%s] attempts %d/%d, last error: %s", job.ID, job.Type, job.Status, job.Attempts, job.MaxAttempts, job.LastError)
+			} else {
+				log.Infof("- %d %s [%s] attempts %d/%d", job.ID, job.Type, job.Status, job.Attempts, job.MaxAttempts)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error listing jobs")
+	}
+}
+
+func runJobsRetry(cmd *cobra.Command, args []string) {
+	queueName, _ := cmd.Flags().GetString("queue")
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		log.WithError(err).Error("Invalid job ID")
+		return
+	}
+
+	err = withDBConnection(func(conn *orm.Connection) error {
+		return jobs.NewQueue(conn.GetDB(), queueName).Retry(id)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error retrying job")
+	} else {
+		log.Infof("Job %d moved back to queue %s", id, queueName)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var jsonSchemaCmd = &cobra.Command{
+	Use:   "jsonschema [name]",
+	Short: "Print the JSON Schema of a model",
+	Long: `Print a JSON Schema document describing the JSON encoding of a model, for generating frontend forms
+and validating requests. Without a name, the document defines every model in its $defs. With --out, the
+document is written to a file, and the usual generation flags apply.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runJSONSchema,
+}
+
+func init() {
+	jsonSchemaCmd.Flags().String("out", "", "File to write the schema to, such as web/schema.json")
+	addGenerationFlags(jsonSchemaCmd)
+	modelCmd.AddCommand(jsonSchemaCmd)
+}
+
+func runJSONSchema(cmd *cobra.Command, args []string) {
+	out, _ := cmd.Flags().GetString("out")
+
+	var schema *model.Schema
+	err := withDBConnection(func(conn *orm.Connection) error {
+		if len(args) == 1 {
+			def, err := loadSeedModel(conn, args[0])
+			if err != nil {
+				return err
+			}
+			schema = model.JSONSchema(def)
+			return nil
+		}
+		defs, err := loadModelDefinitions(conn)
+		if err != nil {
+			return fmt.Errorf("error loading models: %w", err)
+		}
+		schema = model.JSONSchemas(defs)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error generating JSON Schema")
+		return
+	}
+
+	if out == "" {
+		if err := printJSON(schema); err != nil {
+			log.WithError(err).Error("Error printing JSON Schema")
+		}
+		return
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		log.WithError(err).Error("Error generating JSON Schema")
+		return
+	}
+	w := generationWriter(cmd)
+	if err := w.WriteFile(out, append(data, '\n')); err != nil {
+		log.WithError(err).Error("Error writing JSON Schema")
+		return
+	}
+	if !w.Preview() {
+		log.Infof("Wrote JSON Schema %s", out)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var migrateLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check pending migrations for dangerous statements",
+	Long: `Statically check the pending migrations for statements that are dangerous to run on a live database:
+  drop-column-irreversible   a column is dropped and the down migration does not add it back
+  not-null-without-default   a NOT NULL column without a default is added to an existing table
+  volatile-default           a column with a per-row default, such as gen_random_uuid(), rewrites an existing table
+  index-not-concurrent       an index is built without CONCURRENTLY on a big existing table
+
+Table sizes are estimated from the database. With --all or --dir, no connection is made and every existing
+table counts as big. A migration skips the rules listed in a "-- lint:ignore rule" comment.
+The command exits with status 1 if violations are found, so it can fail a CI job.`,
+	Args: cobra.NoArgs,
+	Run:  runMigrateLint,
+}
+
+func init() {
+	migrateLintCmd.Flags().Bool("all", false, "Lint every embedded migration without connecting to the database")
+	migrateLintCmd.Flags().String("dir", "", "Lint the migration files in this directory, such as an app's migrations, without connecting")
+	migrateLintCmd.Flags().Int64("big-table-rows", migration.DefaultBigTableRows, "Estimated rows from which a table is big")
+	migrateLintCmd.MarkFlagsMutuallyExclusive("all", "dir")
+	migrateCmd.AddCommand(migrateLintCmd)
+}
+
+func runMigrateLint(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	dir, _ := cmd.Flags().GetString("dir")
+	linter := migration.Linter{}
+	linter.BigTableRows, _ = cmd.Flags().GetInt64("big-table-rows")
+
+	var migrations []*migration.Migration
+	var err error
+	switch {
+	case dir != "":
+		migrator := migration.NewMigrator(nil, log)
+		if err = migrator.LoadMigrationDir(dir); err == nil {
+			migrations = migrator.Migrations()
+		}
+	case all:
+		migrator := migration.NewMigrator(nil, log)
+		if err = migrator.LoadMigrations(); err == nil {
+			migrations = migrator.Migrations()
+		}
+	default:
+		err = withDBConnection(func(conn *orm.Connection) error {
+			migrator := migration.NewMigratorFor(conn, log)
+			if err := migrator.LoadMigrations(); err != nil {
+				return fmt.Errorf("error loading migrations: %w", err)
+			}
+			var err error
+			if migrations, err = migrator.Pending(); err != nil {
+				return err
+			}
+			linter.TableRows, err = migration.TableRows(conn.GetDB())
+			return err
+		})
+	}
+	if err != nil {
+		log.WithError(err).Error("Error linting migrations")
+		os.Exit(1)
+	}
+
+	violations := linter.Lint(migrations)
+	for _, violation := range violations {
+		fmt.Println(violation)
+	}
+	if len(violations) > 0 {
+		log.Errorf("Found %d violation(s) in %d migration(s)", len(violations), len(migrations))
+		os.Exit(1)
+	}
+	log.Infof("Checked %d migration(s): no violations found", len(migrations))
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/ooyeku/grayv-lsm/pkg/mail"
+	"github.com/spf13/cobra"
+)
+
+var mailCmd = &cobra.Command{
+	Use:   "mail",
+	Short: "Manage the optional Mailpit development mailbox",
+	Long: `Start, stop, and inspect a Mailpit container that catches the email sent by your app during development.
+Its SMTP address, web interface port, and image are read from the Mail section of the configuration.`,
+}
+
+var mailStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the Mailpit container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		if err := lsm.NewMailLifecycleManager(cfg).StartContainer(); err != nil {
+			log.WithError(err).Error("Error starting mail container")
+		}
+	},
+}
+
+var mailStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop and remove the Mailpit container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		if err := lsm.NewMailLifecycleManager(cfg).StopContainer(); err != nil {
+			log.WithError(err).Error("Error stopping mail container")
+		}
+	},
+}
+
+var mailStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check the status of the Mailpit container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		status, err := lsm.NewMailLifecycleManager(cfg).GetStatus()
+		if err != nil {
+			log.WithError(err).Error("Error checking mail status")
+			return
+		}
+		log.Info(status)
+	},
+}
+
+var mailSendTestCmd = &cobra.Command{
+	Use:   "send-test [address]",
+	Short: "Send a test email using the mail settings",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		msg := mail.Message{
+			To:      []string{args[0]},
+			Subject: "grayv-lsm test email",
+			Body:    "This is a test email sent by grayv-lsm. Your mail settings work.\n",
+		}
+		if err := mail.FromConfig(cfg.Mail).Send(ctx, msg); err != nil {
+			log.WithError(err).Error("Error sending test email")
+			return
+		}
+		log.Infof("Test email sent to %s", args[0])
+	},
+}
+
+func init() {
+	mailCmd.AddCommand(mailStartCmd, mailStopCmd, mailStatusCmd, mailSendTestCmd)
+	RootCmd.AddCommand(mailCmd)
+}
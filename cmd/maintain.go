@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+// maintenanceTaskName is the name of the scheduled task saved by db maintain --schedule.
+const maintenanceTaskName = "db-maintenance"
+
+// maxStatsTables is the number of tables listed by db maintain, those with the most dead tuples.
+const maxStatsTables = 10
+
+var maintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Vacuum the database to reclaim the space of dead tuples",
+	Long: `Vacuum the database, reclaiming the space of the dead tuples left behind by updates and deletes, after listing
+the tables with the most of them. --analyze also refreshes the planner statistics, and --vacuum-full rewrites
+the given tables to return their unused space to the operating system, locking each table while it runs.
+--stats only lists the tables. With --schedule, the same maintenance is saved as the scheduled task
+` + maintenanceTaskName + ` of type maintain instead, and runs with 'schedule run'.`,
+	Args: cobra.NoArgs,
+	Run:  runMaintain,
+}
+
+func init() {
+	maintainCmd.Flags().Bool("analyze", false, "Also refresh the planner statistics")
+	maintainCmd.Flags().StringSlice("vacuum-full", nil, "Tables to rewrite with VACUUM FULL")
+	maintainCmd.Flags().Bool("stats", false, "Only list the tables with the most dead tuples")
+	maintainCmd.Flags().String("schedule", "", "Cron expression to run the maintenance on, such as @weekly, instead of running it now")
+	dbCmd.AddCommand(maintainCmd)
+}
+
+func runMaintain(cmd *cobra.Command, args []string) {
+	var opts orm.MaintenanceOptions
+	opts.Analyze, _ = cmd.Flags().GetBool("analyze")
+	opts.VacuumFull, _ = cmd.Flags().GetStringSlice("vacuum-full")
+	statsOnly, _ := cmd.Flags().GetBool("stats")
+	cronExpr, _ := cmd.Flags().GetString("schedule")
+
+	if cronExpr != "" {
+		err := withDBConnection(func(conn *orm.Connection) error {
+			return schedule.SaveTask(conn.GetDB(), maintenanceTaskName, cronExpr, "maintain", opts.String())
+		})
+		if err != nil {
+			log.WithError(err).Error("Error scheduling maintenance")
+			return
+		}
+		log.Infof("Scheduled task %s saved; it runs on %q with 'schedule run'", maintenanceTaskName, cronExpr)
+		return
+	}
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		stats, err := conn.ListTableStats()
+		if err != nil {
+			return err
+		}
+		printTableStats(stats)
+		if statsOnly {
+			return nil
+		}
+		log.Info("Vacuuming the database")
+		return conn.Maintain(context.Background(), opts)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error maintaining the database")
+		return
+	}
+	if !statsOnly {
+		log.Info("Database maintenance completed successfully")
+	}
+}
+
+// printTableStats prints the tables with the most dead tuples.
+func printTableStats(stats []orm.TableStats) {
+	if len(stats) == 0 {
+		log.Info("No tables found in the database")
+		return
+	}
+	if len(stats) > maxStatsTables {
+		stats = stats[:maxStatsTables]
+	}
+	fmt.Printf("%-30s %12s %12s %7s  %s\n", "TABLE", "LIVE", "DEAD", "DEAD%", "LAST VACUUM")
+	for _, s := range stats {
+		lastVacuum := "never"
+		if last := s.LastVacuumed(); last != nil {
+			lastVacuum = messages.Time(*last, "2006-01-02 15:04")
+		}
+		fmt.Printf("%-30s %12d %12d %6.1f%%  %s\n", s.Table, s.LiveTuples, s.DeadTuples, s.DeadRatio()*100, lastVacuum)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var migrateBaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Record migrations as applied without running them",
+	Long: `Record the embedded migrations up to and including --version as applied, without running them, so grayv can
+take over a database that was already provisioned by other means. The later migrations stay pending and are applied
+by db migrate. --version must be the version of a migration, as listed by db migrate-status. Migrations already
+recorded are left as they are. With --dir, the migration files in a directory are recorded alongside the embedded
+ones.`,
+	Args: cobra.NoArgs,
+	Run:  runMigrateBaseline,
+}
+
+func init() {
+	migrateBaselineCmd.Flags().Int64("version", 0, "Version of the last migration the database already has")
+	migrateBaselineCmd.MarkFlagRequired("version")
+	addMigrationDirFlag(migrateBaselineCmd)
+	migrateCmd.AddCommand(migrateBaselineCmd)
+}
+
+func runMigrateBaseline(cmd *cobra.Command, args []string) {
+	version, _ := cmd.Flags().GetInt64("version")
+
+	var recorded int
+	err := withDBConnection(func(conn *orm.Connection) error {
+		if err := conn.CheckWritable("baseline migrations"); err != nil {
+			return err
+		}
+		migrator := migration.NewMigratorFor(conn, log)
+		if err := loadMigrations(cmd, migrator); err != nil {
+			return err
+		}
+		var err error
+		recorded, err = migrator.Baseline(version)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error baselining migrations")
+		return
+	}
+	log.Info(messages.Text("db.migrate.baseline", map[string]interface{}{"Count": recorded, "Version": version}))
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var migrateImportCmd = &cobra.Command{
+	Use:   "import <dir>",
+	Short: "Import the migrations of golang-migrate or goose",
+	Long: `Convert the migrations of another tool in dir into grayv migration files, written to --out, and record the
+ones the tool already applied in the migrations table, so they are not applied again:
+  golang-migrate   000001_create_users.up.sql and .down.sql become 000001_create_users.sql; the applied
+                   migrations are those up to the version in schema_migrations, which must not be dirty
+  goose            20240901120000_create_users.sql keeps its name without the goose annotations; the applied
+                   migrations are read from goose_db_version, and Go migrations are skipped with a warning
+
+The migrations keep their versions. Set --table if the tool was configured with another table, and
+--skip-backfill to only convert the files. Pass --out as the --dir of db migrate, db rollback, and
+db migrate-status, so they load the converted files alongside the embedded migrations.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runMigrateImport,
+}
+
+func init() {
+	migrateImportCmd.Flags().String("from", "", "Tool the migrations come from: golang-migrate or goose")
+	migrateImportCmd.Flags().String("out", "migrations", "Directory the grayv migration files are written to")
+	migrateImportCmd.Flags().String("table", "", "Table the tool records applied migrations in (default schema_migrations or goose_db_version)")
+	migrateImportCmd.Flags().Bool("skip-backfill", false, "Only convert the files, without recording applied migrations")
+	migrateImportCmd.MarkFlagRequired("from")
+	addGenerationFlags(migrateImportCmd)
+	migrateCmd.AddCommand(migrateImportCmd)
+}
+
+func runMigrateImport(cmd *cobra.Command, args []string) {
+	from, _ := cmd.Flags().GetString("from")
+	out, _ := cmd.Flags().GetString("out")
+	table, _ := cmd.Flags().GetString("table")
+	skipBackfill, _ := cmd.Flags().GetBool("skip-backfill")
+
+	tool, err := migration.ParseTool(from)
+	if err != nil {
+		log.WithError(err).Error("Error importing migrations")
+		return
+	}
+	if table == "" {
+		table = tool.Table()
+	}
+	migrations, warnings, err := migration.ImportMigrations(tool, args[0])
+	if err != nil {
+		log.WithError(err).Error("Error importing migrations")
+		return
+	}
+	for _, warning := range warnings {
+		log.Warn(warning)
+	}
+	if len(migrations) == 0 {
+		log.Errorf("No %s migrations found in %s", tool, args[0])
+		return
+	}
+
+	// The imported migrations are recorded in the same table as the embedded ones, so their versions must differ.
+	embedded := migration.NewMigrator(nil, log)
+	if err := embedded.LoadMigrations(); err != nil {
+		log.WithError(err).Error("Error loading migrations")
+		return
+	}
+	for _, imported := range migrations {
+		for _, existing := range embedded.Migrations() {
+			if existing.Version == imported.Version {
+				log.Errorf("Migration %s has the version of the embedded migration %s; renumber it first", imported.Name, existing.Name)
+				return
+			}
+		}
+	}
+
+	w := generationWriter(cmd)
+	for _, imported := range migrations {
+		if err := w.WriteFile(filepath.Join(out, imported.Name), []byte(migration.FormatMigration(imported))); err != nil {
+			log.WithError(err).Error("Error writing migrations")
+			return
+		}
+	}
+	if w.Preview() {
+		return
+	}
+	log.Infof("Wrote %d migrations to %s", len(migrations), out)
+	if skipBackfill {
+		log.Infof("Run db migrate --dir %s to apply them", out)
+		return
+	}
+
+	var recorded int
+	err = withDBConnection(func(conn *orm.Connection) error {
+		if err := conn.CheckWritable("record imported migrations"); err != nil {
+			return err
+		}
+		var err error
+		recorded, err = migration.NewMigratorFor(conn, log).Backfill(tool, table, migrations)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Error recording the migrations applied by %s", tool)
+		return
+	}
+	log.Infof("Recorded %d migrations applied by %s", recorded, tool)
+	log.Infof("Run db migrate --dir %s to apply the others", out)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+// slowestMigrations is the number of slowest migrations listed by migrate-status --verbose.
+const slowestMigrations = 3
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "migrate-status",
+	Short: "List the migrations and whether they were applied",
+	Long: `List the embedded migrations, whether each was applied and when, and the applied migrations whose file is
+missing. With --verbose, also show how long each migration took and how many rows it changed, and name the
+slowest ones, so slow migrations are spotted before they run on larger databases. Migrations applied before
+durations were recorded show "-". With --dir, the migration files in a directory, such as those written by
+db migrate import, are listed alongside the embedded migrations.`,
+	Args: cobra.NoArgs,
+	Run:  runMigrateStatus,
+}
+
+func init() {
+	migrateStatusCmd.Flags().BoolP("verbose", "v", false, "Show the duration and row count of every applied migration")
+	migrateStatusCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	addMigrationDirFlag(migrateStatusCmd)
+	dbCmd.AddCommand(migrateStatusCmd)
+}
+
+func runMigrateStatus(cmd *cobra.Command, args []string) {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	output, _ := cmd.Flags().GetString("output")
+	if output != "table" && output != "json" {
+		log.Errorf("Unknown output format %q: use table or json", output)
+		return
+	}
+
+	var statuses []migration.Status
+	err := withDBConnection(func(conn *orm.Connection) error {
+		migrator := migration.NewMigratorFor(conn, log)
+		if err := loadMigrations(cmd, migrator); err != nil {
+			return fmt.Errorf("error loading migrations: %w", err)
+		}
+		var err error
+		statuses, err = migrator.Status()
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error getting migration status")
+		return
+	}
+
+	if output == "json" {
+		if err := printJSON(statuses); err != nil {
+			log.WithError(err).Error("Error printing migration status")
+		}
+		return
+	}
+
+	if verbose {
+		fmt.Printf("%-16s %-50s %-8s %-17s %10s %10s\n", "VERSION", "NAME", "STATUS", "APPLIED AT", "DURATION", "ROWS")
+	} else {
+		fmt.Printf("%-16s %-50s %-8s %s\n", "VERSION", "NAME", "STATUS", "APPLIED AT")
+	}
+	pending := 0
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		switch {
+		case s.Missing:
+			state = "missing"
+		case s.Applied:
+			state = "applied"
+		default:
+			pending++
+		}
+		if s.AppliedAt != nil {
+			appliedAt = messages.Time(*s.AppliedAt, "2006-01-02 15:04")
+		}
+		if !verbose {
+			fmt.Printf("%-16d %-50s %-8s %s\n", s.Version, s.Name, state, appliedAt)
+			continue
+		}
+		duration, rows := "-", "-"
+		if s.Duration != nil {
+			duration = s.Duration.String()
+		}
+		if s.RowsAffected != nil {
+			rows = strconv.FormatInt(*s.RowsAffected, 10)
+		}
+		fmt.Printf("%-16d %-50s %-8s %-17s %10s %10s\n", s.Version, s.Name, state, appliedAt, duration, rows)
+	}
+
+	log.Infof("%d migration(s), %d pending", len(statuses), pending)
+	if verbose {
+		for _, s := range slowest(statuses, slowestMigrations) {
+			log.Infof("Slow migration: %s took %s", s.Name, s.Duration.Round(time.Millisecond))
+		}
+	}
+}
+
+// slowest returns up to n of the applied migrations with a recorded duration, slowest first.
+func slowest(statuses []migration.Status, n int) []migration.Status {
+	var timed []migration.Status
+	for _, s := range statuses {
+		if s.Duration != nil && *s.Duration > 0 {
+			timed = append(timed, s)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool { return *timed[i].Duration > *timed[j].Duration })
+	if len(timed) > n {
+		timed = timed[:n]
+	}
+	return timed
+}
@@ -1,10 +1,19 @@
 package cmd
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 
+	"github.com/ooyeku/grayv-lsm/internal/app"
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
 	"github.com/ooyeku/grayv-lsm/internal/model"
 	"github.com/ooyeku/grayv-lsm/internal/orm"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
@@ -20,8 +29,10 @@ var modelCmd = &cobra.Command{
 var createModelCmd = &cobra.Command{
 	Use:   "create [name]",
 	Short: "Create a new model",
-	Args:  cobra.ExactArgs(1),
-	Run:   runCreateModel,
+	Long: `Create a new model from --fields, or create every model defined in a manifest with --file.
+Models created from a manifest are stored in dependency order, and their code and migrations are generated.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runCreateModel,
 }
 
 var updateModelCmd = &cobra.Command{
@@ -40,27 +51,84 @@ var listModelsCmd = &cobra.Command{
 var generateModelCmd = &cobra.Command{
 	Use:   "generate [name]",
 	Short: "Generate Go code for an existing model",
-	Args:  cobra.ExactArgs(1),
+	Long:  `Generate Go code for an existing model, or for every model with --all, in dependency order.`,
+	Args:  cobra.MaximumNArgs(1),
 	Run:   runGenerateModel,
 }
 
+var historyModelCmd = &cobra.Command{
+	Use:   "history [name]",
+	Short: "Show every recorded version of a model",
+	Args:  cobra.ExactArgs(1),
+	Run:   runModelHistory,
+}
+
+var revertModelCmd = &cobra.Command{
+	Use:   "revert [name]",
+	Short: "Restore a previous version of a model",
+	Long: `Restore the fields of a previous version of a model, as listed by model history, and write a migration
+that changes the model's table back to that shape. The revert is recorded as a new version.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRevertModel,
+}
+
 func init() {
 
 	createModelCmd.Flags().StringSlice("fields", []string{}, "Comma-separated list of fields in the format name:type")
+	createModelCmd.Flags().StringP("file", "f", "", "Manifest (models.yaml) defining several models to create at once")
+	createModelCmd.Flags().String("app", "", "Name of the Grayv app to generate manifest models and migrations in")
+	createModelCmd.Flags().Bool("with-tests", false, "Also generate a _test.go file for every manifest model")
+	createModelCmd.Flags().String("id", "", "ID strategy: serial (default), identity, uuid, snowflake, or app")
+	createModelCmd.Flags().String("partition-by", "", "Partition the table by month on a timestamp column, as in range(created_at)")
+	createModelCmd.Flags().String("view", "", "Query of a view backing a read-only model, instead of a table")
+	createModelCmd.Flags().String("view-kind", "", "Kind of the view of --view: materialized (default) or plain")
+	addGenerationFlags(createModelCmd)
 	updateModelCmd.Flags().StringSlice("add-fields", []string{}, "Comma-separated list of fields to add in the format name:type")
 	updateModelCmd.Flags().StringSlice("remove-fields", []string{}, "Comma-separated list of field names to remove")
+	updateModelCmd.Flags().Bool("dry-run", false, "Show how the model's table would change without updating the model")
 
 	generateModelCmd.Flags().String("app", "", "Name of the Grayv app to generate the model in")
+	generateModelCmd.Flags().Bool("with-tests", false, "Also generate a _test.go file for the model")
+	generateModelCmd.Flags().Bool("all", false, "Generate every model")
+	addGenerationFlags(generateModelCmd)
 
 	modelCmd.AddCommand(createModelCmd)
 	modelCmd.AddCommand(updateModelCmd)
 	RootCmd.AddCommand(modelCmd)
 	modelCmd.AddCommand(listModelsCmd)
 	modelCmd.AddCommand(generateModelCmd)
+
+	historyModelCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	revertModelCmd.Flags().Int("to", 0, "Version to restore")
+	revertModelCmd.Flags().String("app", "", "Name of the Grayv app to write the migration to")
+	_ = revertModelCmd.MarkFlagRequired("to")
+	addGenerationFlags(revertModelCmd)
+	modelCmd.AddCommand(historyModelCmd)
+	modelCmd.AddCommand(revertModelCmd)
 }
 
 func runCreateModel(cmd *cobra.Command, args []string) {
-	modelName := sanitizeIdentifier(args[0])
+	if manifestPath, _ := cmd.Flags().GetString("file"); manifestPath != "" {
+		if len(args) > 0 {
+			log.Error("Pass either a model name or --file, not both")
+			return
+		}
+		runCreateModelsFromManifest(cmd, manifestPath)
+		return
+	}
+	if len(args) != 1 {
+		log.Error("A model name or --file is required")
+		return
+	}
+
+	modelName, err := model.NormalizeModelName(args[0])
+	if err != nil {
+		log.WithError(err).Error("Invalid model name")
+		return
+	}
+	if modelName != args[0] {
+		log.Infof("Using model name %s", modelName)
+	}
 	fields, _ := cmd.Flags().GetStringSlice("fields")
 
 	modelFields, err := parseFields(fields)
@@ -68,22 +136,31 @@ func runCreateModel(cmd *cobra.Command, args []string) {
 		log.WithError(err).Error("Failed to parse fields")
 		return
 	}
-
-	conn, err := getDBConnection()
-	if err != nil {
-		log.WithError(err).Error("Failed to get database connection")
+	if err := model.ValidateModel(modelName, modelFields); err != nil {
+		log.WithError(err).Error("Invalid model definition")
 		return
 	}
-	defer conn.Close()
-
-	fieldsJSON, err := json.Marshal(modelFields)
-	if err != nil {
-		log.WithError(err).Error("Failed to marshal model fields")
+	def := model.NewModelDefinition(modelName, modelFields)
+	idStrategy, _ := cmd.Flags().GetString("id")
+	if def.IDStrategy, err = model.ParseIDStrategy(idStrategy); err != nil {
+		log.WithError(err).Error("Invalid model definition")
+		return
+	}
+	partitionBy, _ := cmd.Flags().GetString("partition-by")
+	if err := def.SetPartitionBy(partitionBy); err != nil {
+		log.WithError(err).Error("Invalid model definition")
+		return
+	}
+	view, _ := cmd.Flags().GetString("view")
+	viewKind, _ := cmd.Flags().GetString("view-kind")
+	if err := def.SetView(view, viewKind); err != nil {
+		log.WithError(err).Error("Invalid model definition")
 		return
 	}
 
-	query := "INSERT INTO models (name, fields) VALUES ($1, $2)"
-	_, err = conn.Query(query, modelName, fieldsJSON)
+	err = withDBConnection(func(conn *orm.Connection) error {
+		return storeModels(conn, []*model.ModelDefinition{def})
+	})
 	if err != nil {
 		log.WithError(err).Errorf("Failed to create model %s", modelName)
 		return
@@ -93,9 +170,14 @@ func runCreateModel(cmd *cobra.Command, args []string) {
 }
 
 func runUpdateModel(cmd *cobra.Command, args []string) {
-	modelName := sanitizeIdentifier(args[0])
+	modelName, err := model.NormalizeModelName(args[0])
+	if err != nil {
+		log.WithError(err).Error("Invalid model name")
+		return
+	}
 	addFields, _ := cmd.Flags().GetStringSlice("add-fields")
 	removeFields, _ := cmd.Flags().GetStringSlice("remove-fields")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 	conn, err := getDBConnection()
 	if err != nil {
@@ -105,7 +187,8 @@ func runUpdateModel(cmd *cobra.Command, args []string) {
 	defer conn.Close()
 
 	var fieldsJSON []byte
-	rows, err := conn.Query("SELECT fields FROM models WHERE name = $1", modelName)
+	query, params := conn.NewQuery("models").Select("name", "fields").Where("name IN (?, ?)", modelName, sanitizeIdentifier(args[0])).Build()
+	rows, err := conn.Query(query, params...)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to get model %s", modelName)
 		return
@@ -113,7 +196,8 @@ func runUpdateModel(cmd *cobra.Command, args []string) {
 	defer rows.Close()
 
 	for rows.Next() {
-		err := rows.Scan(&fieldsJSON)
+		var storedName string
+		err := rows.Scan(&storedName, &fieldsJSON)
 		if err != nil {
 			log.WithError(err).Error("Failed to scan model fields")
 			return
@@ -125,6 +209,7 @@ func runUpdateModel(cmd *cobra.Command, args []string) {
 			log.WithError(err).Error("Failed to unmarshal model fields")
 			return
 		}
+		original := append([]model.Field{}, modelFields...)
 
 		if len(addFields) > 0 {
 			newFields, err := parseFields(addFields)
@@ -139,14 +224,19 @@ func runUpdateModel(cmd *cobra.Command, args []string) {
 			modelFields = removeFieldsFromModel(modelFields, removeFields)
 		}
 
-		updatedFieldsJSON, err := json.Marshal(modelFields)
-		if err != nil {
-			log.WithError(err).Error("Failed to marshal updated model fields")
+		if err := model.ValidateModel(modelName, modelFields); err != nil {
+			log.WithError(err).Error("Invalid model definition")
 			return
 		}
 
-		_, err = conn.Query("UPDATE models SET fields = $1 WHERE name = $2", updatedFieldsJSON, modelName)
-		if err != nil {
+		if err := printModelDiff(modelName, original, modelFields); err != nil {
+			log.WithError(err).Error("Failed to show the model changes")
+			return
+		}
+		if dryRun {
+			continue
+		}
+		if err := updateModelFields(conn, storedName, original, modelFields, "updated"); err != nil {
 			log.WithError(err).Errorf("Failed to update model %s", modelName)
 			return
 		}
@@ -199,70 +289,408 @@ func listModelsFromDB(conn *orm.Connection) ([]string, error) {
 	return models, rows.Err()
 }
 
-func runGenerateModel(cmd *cobra.Command, args []string) {
-	modelName := args[0]
+// runCreateModelsFromManifest stores every model of the manifest at path in one transaction and generates
+// their code and migrations, in dependency order.
+func runCreateModelsFromManifest(cmd *cobra.Command, path string) {
+	manifest, err := model.LoadManifest(path)
+	if err != nil {
+		log.WithError(err).Error("Failed to load manifest")
+		return
+	}
+	defs, err := manifest.Definitions()
+	if err != nil {
+		log.WithError(err).Error("Invalid manifest")
+		return
+	}
+	applyTenancy(defs)
 
-	conn, err := getDBConnection()
+	appName, _ := cmd.Flags().GetString("app")
+	withTests, _ := cmd.Flags().GetBool("with-tests")
+	w := generationWriter(cmd)
+
+	var target *app.App
+	if appName != "" {
+		if target, err = appCreator.FindApp(appName); err != nil {
+			log.WithError(err).Error("Failed to find app")
+			return
+		}
+	}
+
+	if !w.Preview() {
+		err := withDBConnection(func(conn *orm.Connection) error {
+			return storeModels(conn, defs)
+		})
+		if err != nil {
+			log.WithError(err).Error("Failed to create models")
+			return
+		}
+	}
+
+	if err := generateModels(defs, target, withTests, w); err != nil {
+		log.WithError(err).Error("Failed to generate models")
+		return
+	}
+
+	migrationsDir := "migrations"
+	if target != nil {
+		migrationsDir = filepath.Join(target.Dir, "migrations")
+	}
+	paths, err := model.GenerateMigrationFiles(migrationsDir, defs, dialect.ForDriver(cfg.Database.Driver), w)
 	if err != nil {
-		log.WithError(err).Error("Failed to get database connection")
+		log.WithError(err).Error("Failed to generate migrations")
 		return
 	}
-	defer conn.Close()
 
-	var fieldsJSON []byte
-	rows, err := conn.Query("SELECT fields FROM models WHERE name = $1", modelName)
+	if !w.Preview() {
+		for _, def := range defs {
+			log.Infof("Model %s created successfully", def.Name)
+		}
+		log.Infof("Wrote %d migrations to %s", len(paths), migrationsDir)
+	}
+}
+
+// storeModels inserts defs into the models table and records their first version in the model history,
+// in a single transaction, so a failure leaves no model behind.
+func storeModels(conn *orm.Connection, defs []*model.ModelDefinition) error {
+	tx, err := conn.GetDB().Begin()
 	if err != nil {
-		log.WithError(err).Errorf("Failed to get model %s from database", modelName)
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, def := range defs {
+		fieldsJSON, err := json.Marshal(def.Fields)
+		if err != nil {
+			return fmt.Errorf("error marshaling fields of %s: %w", def.Name, err)
+		}
+		query, params := conn.NewQuery("models").
+			Insert("name", "fields", "id_strategy", "partition_by", "view_sql", "view_kind").
+			Values(def.Name, string(fieldsJSON), def.KeyStrategy(), def.PartitionBy, def.View, def.ViewKind).
+			Build()
+		if _, err := tx.Exec(query, params...); err != nil {
+			if err = orm.TranslateError(err); errors.Is(err, orm.ErrUniqueViolation) {
+				return fmt.Errorf("model %s already exists", def.Name)
+			}
+			return fmt.Errorf("error creating model %s: %w", def.Name, err)
+		}
+		if _, err := model.RecordRevision(tx, conn.Dialect(), def.Name, def.Fields, currentAuthor(), "created"); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// updateModelFields replaces the fields of the stored model name and records the change in the model history,
+// in a single transaction. Models created before history was kept first get their previous fields as a baseline.
+// History is kept under the normalized model name, even for models stored under a name from an older version.
+func updateModelFields(conn *orm.Connection, name string, previous, fields []model.Field, note string) error {
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("error marshaling fields: %w", err)
+	}
+
+	tx, err := conn.GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(conn.Rebind("UPDATE models SET fields = ?, updated_at = CURRENT_TIMESTAMP WHERE name = ?"), string(fieldsJSON), name)
+	if err != nil {
+		return fmt.Errorf("error updating model: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("model %s not found", name)
+	}
+
+	historyName := name
+	if normalized, err := model.NormalizeModelName(name); err == nil {
+		historyName = normalized
+	}
+	author := currentAuthor()
+	if err := model.RecordBaseline(tx, conn.Dialect(), historyName, previous, author); err != nil {
+		return err
+	}
+	if _, err := model.RecordRevision(tx, conn.Dialect(), historyName, fields, author, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// printModelDiff prints how the CREATE TABLE statement of a model changes from the fields in previous to those in fields.
+func printModelDiff(name string, previous, fields []model.Field) error {
+	mm := model.ModelManager{Dialect: dialect.ForDriver(cfg.Database.Driver)}
+	before := model.NewModelDefinition(name, previous)
+	after := model.NewModelDefinition(name, fields)
+	return printDiff(after.TableName()+".sql", []byte(mm.GenerateMigration(before)), []byte(mm.GenerateMigration(after)))
+}
+
+// currentAuthor returns the name recorded as the author of model changes: GRAYV_AUTHOR if set,
+// otherwise the name of the operating system user.
+func currentAuthor() string {
+	if author := os.Getenv("GRAYV_AUTHOR"); author != "" {
+		return author
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+func runGenerateModel(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	if all == (len(args) == 1) {
+		log.Error("Pass either a model name or --all")
 		return
 	}
+	appName, _ := cmd.Flags().GetString("app")
+	withTests, _ := cmd.Flags().GetBool("with-tests")
+	w := generationWriter(cmd)
+
+	var names []string
+	if !all {
+		modelName, err := model.NormalizeModelName(args[0])
+		if err != nil {
+			log.WithError(err).Error("Invalid model name")
+			return
+		}
+		names = []string{modelName, sanitizeIdentifier(args[0])}
+	}
+
+	var defs []*model.ModelDefinition
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		defs, err = loadModelDefinitions(conn, names...)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to get models from database")
+		return
+	}
+	if len(defs) == 0 {
+		log.Error("No matching models found")
+		return
+	}
+	if defs, err = model.SortByDependencies(defs); err != nil {
+		log.WithError(err).Error("Failed to order models")
+		return
+	}
+
+	var target *app.App
+	if appName != "" {
+		if target, err = appCreator.FindApp(appName); err != nil {
+			log.WithError(err).Error("Failed to find app")
+			return
+		}
+	}
+
+	if err := generateModels(defs, target, withTests, w); err != nil {
+		log.WithError(err).Error("Failed to generate models")
+		return
+	}
+	if !w.Preview() {
+		for _, def := range defs {
+			log.Infof("Model %s generated successfully", def.Name)
+		}
+	}
+}
+
+// loadModelDefinitions reads the models with the given names from the models table, or every model if no name is given.
+// Model names are normalized, since older versions stored them as typed. The defaults and generated columns of the
+// fields are those of the tables in the database, so the code and schemas generated from the models match them.
+func loadModelDefinitions(conn *orm.Connection, names ...string) ([]*model.ModelDefinition, error) {
+	query := "SELECT name, fields, id_strategy, partition_by, view_sql, view_kind FROM models ORDER BY name"
+	var args []interface{}
+	if len(names) > 0 {
+		for _, name := range names {
+			args = append(args, name)
+		}
+		marks := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+		query, args = conn.NewQuery("models").Select("name", "fields", "id_strategy", "partition_by", "view_sql", "view_kind").
+			Where("name IN ("+marks+")", args...).Limit(1).Build()
+	}
+
+	rows, err := conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	var defs []*model.ModelDefinition
 	for rows.Next() {
-		err := rows.Scan(&fieldsJSON)
+		var name, idStrategy, partitionBy, view, viewKind string
+		var fieldsJSON []byte
+		if err := rows.Scan(&name, &fieldsJSON, &idStrategy, &partitionBy, &view, &viewKind); err != nil {
+			return nil, fmt.Errorf("failed to scan model: %w", err)
+		}
+
+		var fields []model.Field
+		if err := json.Unmarshal(fieldsJSON, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields of model %s: %w", name, err)
+		}
+		if normalized, err := model.NormalizeModelName(name); err == nil {
+			name = normalized
+		}
+		def := model.NewModelDefinition(name, fields)
+		def.IDStrategy = model.IDStrategy(idStrategy)
+		def.PartitionBy = partitionBy
+		def.View, def.ViewKind = view, model.ViewKind(viewKind)
+		defs = append(defs, def)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, def := range defs {
+		columns, err := model.IntrospectDefaults(conn.GetDB(), def.TableName())
 		if err != nil {
-			log.WithError(err).Error("Failed to scan model fields")
-			return
+			return nil, err
 		}
+		def.ApplyDefaults(columns)
+	}
+	return defs, nil
+}
 
-		var modelFields []model.Field
-		err = json.Unmarshal(fieldsJSON, &modelFields)
+// generateModels generates the code of defs, into target if it is not nil, and their tests if withTests is set.
+func generateModels(defs []*model.ModelDefinition, target *app.App, withTests bool, w *codegen.Writer) error {
+	if target != nil {
+		if err := model.GenerateBaseModelFile(filepath.Join(target.Dir, "internal", "model"), w); err != nil {
+			return fmt.Errorf("failed to generate base model for app %s: %w", target.Name, err)
+		}
+		if model.UsesVector(defs...) {
+			if err := model.GenerateVectorFile(filepath.Join(target.Dir, "internal", "model"), w); err != nil {
+				return fmt.Errorf("failed to generate vector type for app %s: %w", target.Name, err)
+			}
+		}
+		if model.UsesDecimal(defs...) {
+			if err := model.GenerateDecimalFile(filepath.Join(target.Dir, "internal", "model"), w); err != nil {
+				return fmt.Errorf("failed to generate decimal type for app %s: %w", target.Name, err)
+			}
+		}
+	}
+
+	for _, def := range defs {
+		if target != nil {
+			def.SetOutputDir(target.ModelsDir())
+			def.ModulePath = target.ModulePath
+		}
+
+		if err := model.GenerateModelFile(def, w); err != nil {
+			return fmt.Errorf("failed to generate model file for %s: %w", def.Name, err)
+		}
+		if withTests {
+			if err := model.GenerateModelTestFile(def, w); err != nil {
+				return fmt.Errorf("failed to generate test file for %s: %w", def.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func runModelHistory(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	modelName, err := model.NormalizeModelName(args[0])
+	if err != nil {
+		log.WithError(err).Error("Invalid model name")
+		return
+	}
+
+	var revisions []model.Revision
+	err = withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		revisions, err = model.ListRevisions(conn.GetDB(), conn.Dialect(), modelName)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to get model history")
+		return
+	}
+
+	if output == "json" {
+		if revisions == nil {
+			revisions = []model.Revision{}
+		}
+		if err := printJSON(revisions); err != nil {
+			log.WithError(err).Error("Failed to print model history")
+		}
+		return
+	}
+
+	if len(revisions) == 0 {
+		log.Infof("No history recorded for model %s", modelName)
+		return
+	}
+	fmt.Printf("%-8s %-17s %-15s %-10s %s\n", "VERSION", "CREATED", "AUTHOR", "NOTE", "FIELDS")
+	for _, r := range revisions {
+		var fields []string
+		for _, field := range r.Fields {
+			fields = append(fields, field.Spec())
+		}
+		fmt.Printf("%-8d %-17s %-15s %-10s %s\n", r.Version, messages.Time(r.CreatedAt, "2006-01-02 15:04"), r.Author, r.Note, strings.Join(fields, ", "))
+	}
+}
+
+func runRevertModel(cmd *cobra.Command, args []string) {
+	version, _ := cmd.Flags().GetInt("to")
+	appName, _ := cmd.Flags().GetString("app")
+	w := generationWriter(cmd)
+
+	modelName, err := model.NormalizeModelName(args[0])
+	if err != nil {
+		log.WithError(err).Error("Invalid model name")
+		return
+	}
+
+	migrationsDir := "migrations"
+	if appName != "" {
+		target, err := appCreator.FindApp(appName)
 		if err != nil {
-			log.WithError(err).Error("Failed to unmarshal model fields")
+			log.WithError(err).Error("Failed to find app")
 			return
 		}
+		migrationsDir = filepath.Join(target.Dir, "migrations")
+	}
+
+	err = withDBConnection(func(conn *orm.Connection) error {
+		defs, err := loadModelDefinitions(conn, modelName, sanitizeIdentifier(args[0]))
+		if err != nil {
+			return err
+		}
+		if len(defs) == 0 {
+			return fmt.Errorf("model %s not found", modelName)
+		}
+		def := defs[0]
 
-		modelDef := &model.ModelDefinition{
-			Name:   modelName,
-			Fields: modelFields,
+		revision, err := model.GetRevision(conn.GetDB(), conn.Dialect(), def.Name, version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("model %s has no version %d; see model history", def.Name, version)
+		} else if err != nil {
+			return err
 		}
 
-		err = model.GenerateModelFile(modelDef)
+		path, err := model.GenerateRevertMigration(migrationsDir, def, revision, conn.Dialect(), w)
 		if err != nil {
-			log.WithError(err).Errorf("Failed to generate model file for %s", modelName)
-			return
+			return fmt.Errorf("failed to write migration: %w", err)
+		}
+		if w.Preview() {
+			return nil
 		}
 
-		log.Infof("Model %s generated successfully", modelName)
+		if err := updateModelFields(conn, def.Name, def.Fields, revision.Fields, fmt.Sprintf("reverted to %d", version)); err != nil {
+			return err
+		}
+		log.Infof("Model %s reverted to version %d; apply %s and run model generate to update its code", def.Name, version, path)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to revert model %s", modelName)
 	}
 }
 
 // parseFields parses the given list of fields and returns a slice of model.Field.
 // If no error occurs, it returns the slice of model.Field and a nil error. Otherwise, it returns nil and an error.
 func parseFields(fields []string) ([]model.Field, error) {
-	var modelFields []model.Field
-	for _, field := range fields {
-		parts := strings.Split(field, ":")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid field format: %s", field)
-		}
-		name := sanitizeIdentifier(parts[0])
-		fieldType := parts[1]
-		tag := fmt.Sprintf(`json:"%s"`, strings.ToLower(name))
-		isNull := false
-		isPrimary := name == "ID" || name == "Id" || name == "id"
-		modelFields = append(modelFields, model.NewField(name, fieldType, tag, isNull, isPrimary))
-	}
-	return modelFields, nil
+	return model.ParseFields(fields)
 }
 
 // removeFieldsFromModel removes specified fields from a list of model fields and returns the updated list.
@@ -274,9 +702,17 @@ func parseFields(fields []string) ([]model.Field, error) {
 // Returns:
 // - updatedFields: The list of model fields after removing the specified fields.
 func removeFieldsFromModel(fields []model.Field, fieldsToRemove []string) []model.Field {
+	// Field names are stored normalized, so accept the names in either form.
+	names := append([]string{}, fieldsToRemove...)
+	for _, name := range fieldsToRemove {
+		if normalized, err := model.NormalizeFieldName(name); err == nil {
+			names = append(names, normalized)
+		}
+	}
+
 	var updatedFields []model.Field
 	for _, field := range fields {
-		if !contains(fieldsToRemove, field.Name) {
+		if !contains(names, field.Name) {
 			updatedFields = append(updatedFields, field)
 		}
 	}
@@ -300,7 +736,7 @@ func getDBConnection() (*orm.Connection, error) {
 		return nil, fmt.Errorf("error loading config: %w", err)
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	conn, err := openConnection(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
@@ -308,6 +744,8 @@ func getDBConnection() (*orm.Connection, error) {
 	return conn, nil
 }
 
+// sanitizeIdentifier strips characters that are not alphanumeric or underscores. Model names were stored
+// this way before they were normalized, so it is still used to find models created by older versions.
 func sanitizeIdentifier(identifier string) string {
 	// Remove any characters that aren't alphanumeric or underscores
 	return regexp.MustCompile(`[^a-zA-Z0-9_]+`).ReplaceAllString(identifier, "")
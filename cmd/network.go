@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/spf13/cobra"
+)
+
+var networkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "Manage the Docker network of the grayv containers",
+	Long: `The database, cache, mail, and storage containers are attached to the Docker network named by docker.network
+(default grayv), where containers reach them by the service names db, cache, mail, and storage. Set
+database.nopublish to stop publishing the database port on the host.`,
+}
+
+var networkConnectCmd = &cobra.Command{
+	Use:   "connect [container]",
+	Short: "Attach a running container, such as a dockerized app, to the grayv network",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		network := cfg.Docker.WithDefaults().Network
+		if err := lsm.ConnectContainer(network, args[0]); err != nil {
+			log.WithError(err).Error("Error connecting container")
+			return
+		}
+		log.Infof("Container %s is on the %s network; it reaches the database at %s:5432", args[0], network, lsm.DatabaseService)
+	},
+}
+
+func init() {
+	networkCmd.AddCommand(networkConnectCmd)
+	dbCmd.AddCommand(networkCmd)
+}
@@ -4,9 +4,18 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/messages"
 	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/apitoken"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/mail"
 	"github.com/ooyeku/grayv-lsm/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -44,22 +53,52 @@ var deleteUserCmd = &cobra.Command{
 
 var listUsersCmd = &cobra.Command{
 	Use:   "list-users",
-	Short: "List all users in the database",
+	Short: "List the users in the database",
 	Run:   runListUsers,
 }
 
+var getUserCmd = &cobra.Command{
+	Use:   "get-user",
+	Short: "Show a user by ID or email",
+	Run:   runGetUser,
+}
+
+var createTokenCmd = &cobra.Command{
+	Use:   "create-token",
+	Short: "Create an API token for a user",
+	Long: `Create an API token that authenticates machine clients as the given user.
+The token is printed once and cannot be recovered afterwards; only its hash is stored.`,
+	Run: runCreateToken,
+}
+
+var listTokensCmd = &cobra.Command{
+	Use:   "list-tokens",
+	Short: "List API tokens",
+	Run:   runListTokens,
+}
+
+var revokeTokenCmd = &cobra.Command{
+	Use:   "revoke-token",
+	Short: "Revoke an API token",
+	Run:   runRevokeToken,
+}
+
 func init() {
 	ormCmd.AddCommand(queryCmd)
 	ormCmd.AddCommand(createUserCmd)
 	ormCmd.AddCommand(updateUserCmd)
 	ormCmd.AddCommand(deleteUserCmd)
 	ormCmd.AddCommand(listUsersCmd)
+	ormCmd.AddCommand(getUserCmd)
+	ormCmd.AddCommand(createTokenCmd)
+	ormCmd.AddCommand(listTokensCmd)
+	ormCmd.AddCommand(revokeTokenCmd)
 	RootCmd.AddCommand(ormCmd)
 
 	updateUserCmd.Flags().Int("id", 0, "ID of the user to update")
 	updateUserCmd.Flags().String("username", "", "New username for the user")
 	updateUserCmd.Flags().String("email", "", "New email for the user")
-	updateUserCmd.Flags().String("password", "", "New password for the user")
+	addPasswordFlags(updateUserCmd, "New password for the user")
 	updateUserCmd.MarkFlagRequired("id")
 
 	deleteUserCmd.Flags().Int("id", 0, "ID of the user to delete")
@@ -67,10 +106,31 @@ func init() {
 
 	createUserCmd.Flags().String("username", "", "Username for the new user")
 	createUserCmd.Flags().String("email", "", "Email for the new user")
-	createUserCmd.Flags().String("password", "", "Password for the new user")
+	addPasswordFlags(createUserCmd, "Password for the new user")
+	createUserCmd.Flags().Bool("welcome", false, "Send a welcome email to the new user using the mail settings")
 	createUserCmd.MarkFlagRequired("username")
 	createUserCmd.MarkFlagRequired("email")
-	createUserCmd.MarkFlagRequired("password")
+
+	listUsersCmd.Flags().String("filter", "", "Only list users whose username or email contains this text")
+	listUsersCmd.Flags().Int("limit", 50, "Maximum number of users to list (0 for no limit)")
+	listUsersCmd.Flags().Int("offset", 0, "Number of users to skip")
+	listUsersCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	getUserCmd.Flags().Int("id", 0, "ID of the user")
+	getUserCmd.Flags().String("email", "", "Email of the user")
+	getUserCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	getUserCmd.MarkFlagsMutuallyExclusive("id", "email")
+
+	createTokenCmd.Flags().Int("user", 0, "ID of the user the token acts as")
+	createTokenCmd.Flags().String("name", "default", "Name describing what the token is used for")
+	createTokenCmd.Flags().StringSlice("scope", []string{"read"}, "Scopes granted to the token (repeatable, * grants all)")
+	createTokenCmd.Flags().Duration("expires", 0, "Lifetime of the token, e.g. 720h (0 never expires)")
+	createTokenCmd.MarkFlagRequired("user")
+
+	listTokensCmd.Flags().Int("user", 0, "Only list the tokens of this user")
+
+	revokeTokenCmd.Flags().Int("id", 0, "ID of the token to revoke")
+	revokeTokenCmd.MarkFlagRequired("id")
 }
 
 func runQuery(cmd *cobra.Command, args []string) {
@@ -80,7 +140,7 @@ func runQuery(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	conn, err := openConnection(cfg)
 	if err != nil {
 		log.WithError(err).Error("Error connecting to database")
 		return
@@ -88,6 +148,11 @@ func runQuery(cmd *cobra.Command, args []string) {
 	defer conn.Close()
 
 	query := args[0]
+	if err := conn.CheckStatement(query); err != nil {
+		log.WithError(err).Error("Error executing query")
+		return
+	}
+
 	rows, err := conn.Query(query)
 	if err != nil {
 		log.WithError(err).Error("Error executing query")
@@ -134,13 +199,19 @@ func runQuery(cmd *cobra.Command, args []string) {
 }
 
 func runCreateUser(cmd *cobra.Command, args []string) {
+	password, err := readPassword(cmd, true, true)
+	if err != nil {
+		log.WithError(err).Error("Error reading password")
+		return
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.WithError(err).Error("Error loading config")
 		return
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	conn, err := openConnection(cfg)
 	if err != nil {
 		log.WithError(err).Error("Error connecting to database")
 		return
@@ -149,33 +220,57 @@ func runCreateUser(cmd *cobra.Command, args []string) {
 
 	username, _ := cmd.Flags().GetString("username")
 	email, _ := cmd.Flags().GetString("email")
-	password, _ := cmd.Flags().GetString("password")
+
+	policy, hashOptions := passwordSettings(cfg)
+	if err := policy.Validate(password); err != nil {
+		log.WithError(err).Error("Password rejected")
+		return
+	}
 
 	// Hash the password
-	hashedPassword, err := utils.HashPassword(password)
+	hashedPassword, err := utils.HashPasswordWithOptions(password, hashOptions)
 	if err != nil {
 		log.WithError(err).Error("Error hashing password")
 		return
 	}
 
-	query := "INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3)"
-	_, err = conn.Query(query, username, email, hashedPassword)
+	query, params := conn.NewQuery("users").
+		Insert("username", "email", "password_hash").
+		Values(username, email, hashedPassword).
+		Build()
+	_, err = conn.GetDB().Exec(query, params...)
 	if err != nil {
 		log.WithError(err).Error("Error creating new user")
 		return
 	}
 
 	log.Info("New user created successfully")
+
+	if welcome, _ := cmd.Flags().GetBool("welcome"); welcome {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := mail.FromConfig(cfg.Mail).Send(ctx, mail.Welcome(username, email)); err != nil {
+			log.WithError(err).Warn("Error sending welcome email")
+			return
+		}
+		log.Infof("Welcome email sent to %s", email)
+	}
 }
 
 func runUpdateUser(cmd *cobra.Command, args []string) {
+	password, err := readPassword(cmd, false, false)
+	if err != nil {
+		log.WithError(err).Error("Error reading password")
+		return
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.WithError(err).Error("Error loading config")
 		return
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	conn, err := openConnection(cfg)
 	if err != nil {
 		log.WithError(err).Error("Error connecting to database")
 		return
@@ -185,48 +280,56 @@ func runUpdateUser(cmd *cobra.Command, args []string) {
 	id, _ := cmd.Flags().GetInt("id")
 	username, _ := cmd.Flags().GetString("username")
 	email, _ := cmd.Flags().GetString("email")
-	password, _ := cmd.Flags().GetString("password")
 
-	updateFields := make(map[string]interface{})
+	var fields []string
+	var values []interface{}
 	if username != "" {
-		updateFields["username"] = username
+		fields = append(fields, "username")
+		values = append(values, username)
 	}
 	if email != "" {
-		updateFields["email"] = email
+		fields = append(fields, "email")
+		values = append(values, email)
 	}
 	if password != "" {
-		hashedPassword, err := utils.HashPassword(password)
+		policy, hashOptions := passwordSettings(cfg)
+		if err := policy.Validate(password); err != nil {
+			log.WithError(err).Error("Password rejected")
+			return
+		}
+		hashedPassword, err := utils.HashPasswordWithOptions(password, hashOptions)
 		if err != nil {
 			log.WithError(err).Error("Error hashing password")
 			return
 		}
-		updateFields["password_hash"] = hashedPassword
+		fields = append(fields, "password_hash")
+		values = append(values, hashedPassword)
 	}
 
-	if len(updateFields) == 0 {
+	if len(fields) == 0 {
 		log.Error("No fields to update")
 		return
 	}
-
-	query := "UPDATE users SET "
-	var values []interface{}
-	i := 0
-	for field, value := range updateFields {
-		if i > 0 {
-			query += ", "
-		}
-		query += field + " = $" + fmt.Sprintf("%d", i+1)
-		values = append(values, value)
-		i++
+	if err := orm.CheckColumns(fields, updatableUserColumns...); err != nil {
+		log.WithError(err).Error("Error updating user")
+		return
 	}
-	query += " WHERE id = $" + fmt.Sprintf("%d", i+1)
-	values = append(values, id)
 
-	_, err = conn.GetDB().Exec(query, values...)
+	query, params := conn.NewQuery("users").
+		Update(fields...).
+		Values(values...).
+		Where("id = ?", id).
+		Build()
+
+	result, err := conn.GetDB().Exec(query, params...)
 	if err != nil {
 		log.WithError(err).Error("Error updating user")
 		return
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		log.Errorf("User %d not found", id)
+		return
+	}
 
 	log.Info("User updated successfully")
 }
@@ -238,7 +341,7 @@ func runDeleteUser(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	conn, err := openConnection(cfg)
 	if err != nil {
 		log.WithError(err).Error("Error connecting to database")
 		return
@@ -247,8 +350,8 @@ func runDeleteUser(cmd *cobra.Command, args []string) {
 
 	id, _ := cmd.Flags().GetInt("id")
 
-	query := "DELETE FROM users WHERE id = $1"
-	_, err = conn.GetDB().Exec(query, id)
+	query, params := conn.NewQuery("users").Delete().Where("id = ?", id).Build()
+	_, err = conn.GetDB().Exec(query, params...)
 	if err != nil {
 		log.WithError(err).Error("Error deleting user")
 		return
@@ -258,48 +361,227 @@ func runDeleteUser(cmd *cobra.Command, args []string) {
 }
 
 func runListUsers(cmd *cobra.Command, args []string) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.WithError(err).Error("Error loading config")
+	filter, _ := cmd.Flags().GetString("filter")
+	limit, _ := cmd.Flags().GetInt("limit")
+	offset, _ := cmd.Flags().GetInt("offset")
+	output, _ := cmd.Flags().GetString("output")
+	if output != "table" && output != "json" {
+		log.Errorf("Unknown output format %q: use table or json", output)
 		return
 	}
 
-	conn, err := orm.NewConnection(&cfg.Database)
+	err := withDBConnection(func(conn *orm.Connection) error {
+		users, err := queryUsers(conn, func(q *orm.Query) {
+			q.OrderBy("id", "ASC").Limit(limit).Offset(offset)
+			if filter != "" {
+				// LOWER rather than ILIKE, which only PostgreSQL has. The filter is matched literally, so its % and _
+				// are escaped with !, which unlike a backslash means the same on every database.
+				pattern := "%" + likeEscaper.Replace(strings.ToLower(filter)) + "%"
+				q.Where("(LOWER(username) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!')", pattern, pattern)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return printUsers(users, output)
+	})
 	if err != nil {
-		log.WithError(err).Error("Error connecting to database")
+		log.WithError(err).Error("Error listing users")
+	}
+}
+
+func runGetUser(cmd *cobra.Command, args []string) {
+	id, _ := cmd.Flags().GetInt("id")
+	email, _ := cmd.Flags().GetString("email")
+	output, _ := cmd.Flags().GetString("output")
+	if output != "table" && output != "json" {
+		log.Errorf("Unknown output format %q: use table or json", output)
 		return
 	}
-	defer conn.Close()
 
-	query := "SELECT id, username, email FROM users"
-	rows, err := conn.GetDB().Query(query)
-	if err != nil {
-		log.WithError(err).Error("Error querying users")
+	var condition string
+	var param interface{}
+	switch {
+	case id != 0:
+		condition, param = "id = ?", id
+	case email != "":
+		condition, param = "email = ?", email
+	default:
+		log.Error("Either --id or --email is required")
 		return
 	}
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		users, err := queryUsers(conn, func(q *orm.Query) { q.Where(condition, param) })
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("user not found")
+		}
+		if output == "json" {
+			return printJSON(users[0])
+		}
+		return printUsers(users, output)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error getting user")
+	}
+}
+
+// likeEscaper escapes the wildcards of LIKE, and its escape character !, in text matched literally.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// updatableUserColumns are the columns of the users table that update-user may write.
+var updatableUserColumns = []string{"username", "email", "password_hash"}
+
+// userColumns are the columns of the users table shown by the user commands. The password hash is never shown.
+var userColumns = []string{"id", "username", "email", "created_at"}
+
+// userRecord is a row of the users table as shown by the user commands.
+type userRecord struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// queryUsers runs a query on conn selecting userColumns, narrowed down by filter, and scans the result.
+func queryUsers(conn *orm.Connection, filter func(q *orm.Query)) ([]userRecord, error) {
+	q := conn.NewQuery("users").Select(userColumns...)
+	filter(q)
+	query, params := q.Build()
+	rows, err := conn.GetDB().Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %w", err)
+	}
 	defer rows.Close()
 
-	var users []map[string]interface{}
+	var users []userRecord
 	for rows.Next() {
-		var id int
-		var username, email string
-		if err := rows.Scan(&id, &username, &email); err != nil {
-			log.WithError(err).Error("Error scanning user row")
-			continue
+		var u userRecord
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning user row: %w", err)
 		}
-		users = append(users, map[string]interface{}{
-			"id":       id,
-			"username": username,
-			"email":    email,
-		})
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// printUsers writes users as a table, or as a JSON array when output is "json".
+func printUsers(users []userRecord, output string) error {
+	if output == "json" {
+		if users == nil {
+			users = []userRecord{}
+		}
+		return printJSON(users)
 	}
 
 	if len(users) == 0 {
 		log.Info("No users found")
-	} else {
-		log.Info("Users:")
-		for _, user := range users {
-			log.Infof("ID: %d, Username: %s, Email: %s", user["id"], user["username"], user["email"])
+		return nil
+	}
+	fmt.Printf("%-6s %-20s %-30s %s\n", "ID", "USERNAME", "EMAIL", "CREATED")
+	for _, u := range users {
+		fmt.Printf("%-6d %-20s %-30s %s\n", u.ID, u.Username, u.Email, messages.Time(u.CreatedAt, "2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func runCreateToken(cmd *cobra.Command, args []string) {
+	userID, _ := cmd.Flags().GetInt("user")
+	name, _ := cmd.Flags().GetString("name")
+	scopes, _ := cmd.Flags().GetStringSlice("scope")
+	expires, _ := cmd.Flags().GetDuration("expires")
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		plaintext, token, err := apitoken.NewStore(conn.GetDB()).Create(context.Background(), userID, name, scopes, expires)
+		if err != nil {
+			return err
 		}
+
+		log.Infof("Created token %d (%s) for user %d with scopes %s", token.ID, token.Prefix, token.UserID, strings.Join(token.Scopes, ","))
+		fmt.Println(plaintext)
+		log.Warn("Store this token now; it cannot be shown again")
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error creating token")
+	}
+}
+
+func runListTokens(cmd *cobra.Command, args []string) {
+	userID, _ := cmd.Flags().GetInt("user")
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		tokens, err := apitoken.NewStore(conn.GetDB()).List(context.Background(), userID)
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) == 0 {
+			log.Info("No tokens found")
+			return nil
+		}
+
+		fmt.Printf("%-5s %-5s %-20s %-14s %-15s %-20s %s\n", "ID", "USER", "NAME", "PREFIX", "SCOPES", "EXPIRES", "STATUS")
+		for _, t := range tokens {
+			expires := "never"
+			if t.ExpiresAt != nil {
+				expires = messages.Time(*t.ExpiresAt, "2006-01-02 15:04")
+			}
+			status := "active"
+			if t.RevokedAt != nil {
+				status = "revoked"
+			} else if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+				status = "expired"
+			}
+			fmt.Printf("%-5d %-5d %-20s %-14s %-15s %-20s %s\n", t.ID, t.UserID, t.Name, t.Prefix, strings.Join(t.Scopes, ","), expires, status)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error listing tokens")
+	}
+}
+
+func runRevokeToken(cmd *cobra.Command, args []string) {
+	id, _ := cmd.Flags().GetInt("id")
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		return apitoken.NewStore(conn.GetDB()).Revoke(context.Background(), id)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error revoking token")
+		return
+	}
+	log.Infof("Token %d revoked", id)
+}
+
+// passwordSettings returns the password policy and hashing options described by the Password section of the configuration.
+func passwordSettings(cfg *config.Config) (utils.PasswordPolicy, utils.HashOptions) {
+	policy := utils.DefaultPasswordPolicy
+	if cfg.Password.MinLength > 0 {
+		policy.MinLength = cfg.Password.MinLength
+	}
+	policy.RequireUpper = cfg.Password.RequireUpper
+	policy.RequireLower = cfg.Password.RequireLower
+	policy.RequireDigit = cfg.Password.RequireDigit
+	policy.RequireSymbol = cfg.Password.RequireSymbol
+
+	options := utils.DefaultHashOptions
+	if cfg.Password.Algorithm != "" {
+		options.Algorithm = utils.Algorithm(cfg.Password.Algorithm)
+	}
+	if cfg.Password.BcryptCost > 0 {
+		options.BcryptCost = cfg.Password.BcryptCost
 	}
+	return policy, options
 }
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var partitionsCmd = &cobra.Command{
+	Use:   "partitions",
+	Short: "Manage the partitions of partitioned model tables",
+	Long:  `Manage the monthly partitions of the tables of models created with partition_by: range(column).`,
+}
+
+var partitionsCreateCmd = &cobra.Command{
+	Use:   "create [model...]",
+	Short: "Create the monthly partitions of partitioned tables ahead of time",
+	Long: `Create a partition for every month from --from, the current month by default, through --through, for the
+tables of the given models or of every partitioned model. Existing partitions are left as they are. Rows of a month
+without a partition go to the default partition of the table, and a month whose rows are already there cannot get a
+partition, so create them before they are needed.`,
+	Run: runPartitionsCreate,
+}
+
+func init() {
+	partitionsCreateCmd.Flags().String("through", "", "Last month to create a partition for, as YYYY-MM")
+	partitionsCreateCmd.Flags().String("from", "", "First month to create a partition for, as YYYY-MM; defaults to the current month")
+	partitionsCreateCmd.Flags().Bool("dry-run", false, "Print the statements instead of running them")
+	_ = partitionsCreateCmd.MarkFlagRequired("through")
+
+	partitionsCmd.AddCommand(partitionsCreateCmd)
+	dbCmd.AddCommand(partitionsCmd)
+}
+
+func runPartitionsCreate(cmd *cobra.Command, args []string) {
+	throughFlag, _ := cmd.Flags().GetString("through")
+	fromFlag, _ := cmd.Flags().GetString("from")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	through, err := time.Parse("2006-01", throughFlag)
+	if err != nil {
+		log.Errorf("Invalid --through %q: use YYYY-MM, such as 2025-12", throughFlag)
+		return
+	}
+	from := time.Now().UTC()
+	if fromFlag != "" {
+		if from, err = time.Parse("2006-01", fromFlag); err != nil {
+			log.Errorf("Invalid --from %q: use YYYY-MM, such as 2025-01", fromFlag)
+			return
+		}
+	}
+
+	err = withDBConnection(func(conn *orm.Connection) error {
+		defs, err := partitionedModels(conn, args)
+		if err != nil {
+			return err
+		}
+		if len(defs) == 0 {
+			log.Info("No partitioned models; create them with partition_by: range(created_at)")
+			return nil
+		}
+
+		for _, def := range defs {
+			partitions := model.MonthlyPartitions(def, from, through)
+			if len(partitions) == 0 {
+				log.Warnf("%s: --through %s is before the first month", def.TableName(), throughFlag)
+				continue
+			}
+			created := 0
+			for _, partition := range partitions {
+				if dryRun {
+					fmt.Println(partition.Statement())
+					continue
+				}
+				var exists bool
+				if err := conn.GetDB().QueryRow("SELECT to_regclass($1) IS NOT NULL", partition.Table).Scan(&exists); err != nil {
+					return err
+				}
+				if exists {
+					continue
+				}
+				if _, err := conn.GetDB().Exec(partition.Statement()); err != nil {
+					return fmt.Errorf("error creating partition %s: %w (rows of that month may already be in %s%s)",
+						partition.Table, orm.TranslateError(err), def.TableName(), model.DefaultPartitionSuffix)
+				}
+				created++
+			}
+			if !dryRun {
+				log.Infof("%s: created %d of %d partitions through %s", def.TableName(), created, len(partitions), through.Format("2006-01"))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error creating partitions")
+	}
+}
+
+// partitionedModels returns the partitioned models with the given names, or every partitioned model if no name is
+// given. It returns an error if a named model does not exist or is not partitioned.
+func partitionedModels(conn *orm.Connection, names []string) ([]*model.ModelDefinition, error) {
+	defs, err := loadModelDefinitions(conn)
+	if err != nil {
+		return nil, fmt.Errorf("error loading models: %w", err)
+	}
+	byName := make(map[string]*model.ModelDefinition, len(defs))
+	var partitioned []*model.ModelDefinition
+	for _, def := range defs {
+		byName[def.Name] = def
+		if def.PartitionBy != "" {
+			partitioned = append(partitioned, def)
+		}
+	}
+	if len(names) == 0 {
+		return partitioned, nil
+	}
+
+	var selected []*model.ModelDefinition
+	for _, name := range names {
+		normalized, err := model.NormalizeModelName(name)
+		if err != nil {
+			return nil, err
+		}
+		def, ok := byName[normalized]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("model %s not found", normalized)
+		case def.PartitionBy == "":
+			return nil, fmt.Errorf("model %s is not partitioned", normalized)
+		}
+		selected = append(selected, def)
+	}
+	return selected, nil
+}
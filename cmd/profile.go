@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+// profiler profiles the running command if its --profile flag is set, and is nil otherwise. It is set by
+// beforeCommand and stopped by afterCommand.
+var profiler *profile.Profiler
+
+// addProfileFlag adds the --profile flag to the heavy commands that mark their phases with phase.
+func addProfileFlag(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		cmd.Flags().Bool("profile", false, "Record CPU and heap profiles and the time of each phase in "+profile.DefaultDir)
+	}
+}
+
+// phase starts timing the phase name of the running command, if it is profiled, and returns the function ending it.
+func phase(name string) func() {
+	return profiler.Phase(name)
+}
+
+// startProfile starts profiling cmd if its --profile flag is set. Commands without the flag are never profiled.
+func startProfile(cmd *cobra.Command) {
+	if enabled, _ := cmd.Flags().GetBool("profile"); !enabled {
+		return
+	}
+	var err error
+	if profiler, err = profile.Start(profile.DefaultDir, commandName(cmd)); err != nil {
+		log.WithError(err).Warn("Error starting the profile")
+	}
+}
+
+// stopProfile writes the profile of the command and prints the time of each of its phases.
+func stopProfile() {
+	if profiler == nil {
+		return
+	}
+	timings, err := profiler.Stop()
+	if err != nil {
+		log.WithError(err).Warn("Error writing the profile")
+		return
+	}
+	for _, p := range timings.Phases {
+		log.Infof("Phase %s took %s", p.Name, p.Duration.Round(time.Millisecond))
+	}
+	log.Infof("Command took %s; profile written to %s. Inspect it with go tool pprof %s",
+		timings.Duration.Round(time.Millisecond), profiler.Dir(), filepath.Join(profiler.Dir(), profile.CPUFile))
+	profiler = nil
+}
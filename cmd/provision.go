@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/database/vars"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var provisionCmd = &cobra.Command{
+	Use:   "provision",
+	Short: "Create the configured database if it does not exist and run the migrations",
+	Long: `Create the configured database on the server if it does not exist, then run the pending migrations.
+Unlike db start, it works with any PostgreSQL server, such as the database service of a CI pipeline, and can
+run again. It exits with status 1 on failure.`,
+	Args: cobra.NoArgs,
+	Run:  runProvision,
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait until the database server accepts connections",
+	Long:  `Wait until the database server accepts connections. It exits with status 1 if the timeout elapses first.`,
+	Args:  cobra.NoArgs,
+	Run:   runWait,
+}
+
+func init() {
+	provisionCmd.Flags().Int("jobs", 1, "Number of independent migrations applied at once, as with db migrate")
+	waitCmd.Flags().Duration("timeout", time.Minute, "How long to wait for the server")
+	dbCmd.AddCommand(provisionCmd, waitCmd)
+}
+
+func runProvision(cmd *cobra.Command, args []string) {
+	if dbManager == nil {
+		log.Error("Configuration is not loaded")
+		os.Exit(1)
+	}
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	created, err := dbManager.Provision()
+	if err != nil {
+		log.WithError(err).Error("Error provisioning database")
+		os.Exit(1)
+	}
+	if created {
+		log.Infof("Created database %s", cfg.Database.Name)
+	}
+
+	err = withDBConnection(func(conn *orm.Connection) error {
+		if err := conn.CheckWritable("run migrations"); err != nil {
+			return err
+		}
+		migrator := migration.NewMigratorFor(conn, log)
+		migrator.Workers = jobs
+		migrator.Vars = vars.FromConfig(cfg.Variables)
+		if err := migrator.LoadMigrations(); err != nil {
+			return fmt.Errorf("error loading migrations: %w", err)
+		}
+		return migrator.Migrate()
+	})
+	if err != nil {
+		log.WithError(err).Error("Error running migrations")
+		os.Exit(1)
+	}
+	log.Infof("Database %s is provisioned", cfg.Database.Name)
+}
+
+func runWait(cmd *cobra.Command, args []string) {
+	if dbManager == nil {
+		log.Error("Configuration is not loaded")
+		os.Exit(1)
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if err := dbManager.WaitReady(timeout); err != nil {
+		log.WithError(err).Error("Error waiting for database")
+		os.Exit(1)
+	}
+	log.Info("Database server is ready")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [table...]",
+	Short: "Delete or archive the rows older than the retention rules allow",
+	Long: `Apply the retention rules of the Retention section of config.json: the rows of each table whose timestamp
+column is older than the maximum age of its rule are deleted, or moved into its archive table, in batches of
+retention.batchsize rows. Without tables, every rule is applied. --dry-run only counts the expired rows.
+Schedule it with 'schedule add <name> --type prune --target <tables>'; an empty target applies every rule.`,
+	Run: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().Bool("dry-run", false, "Only count the rows that would be pruned")
+	pruneCmd.Flags().Int("batch-size", 0, "Rows deleted by each statement; defaults to retention.batchsize")
+	dbCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	if batchSize == 0 {
+		batchSize = cfg.Retention.BatchSize
+	}
+
+	policies, err := orm.RetentionPolicies(cfg.Retention, args...)
+	if err != nil {
+		log.WithError(err).Error("Invalid retention rules")
+		return
+	}
+	if len(policies) == 0 {
+		log.Info("No retention rules configured; add them to the Retention section of config.json")
+		return
+	}
+
+	err = runUntilSignal(cfg, func(ctx context.Context) error {
+		return withDBConnection(func(conn *orm.Connection) error {
+			for _, policy := range policies {
+				if dryRun {
+					result, err := conn.CountExpired(ctx, policy)
+					if err != nil {
+						return err
+					}
+					log.Infof("%s: %d rows older than %s would be %s", policy.Table, result.Rows, policy.MaxAge, pruneAction(result))
+					continue
+				}
+				result, err := conn.Prune(ctx, policy, batchSize)
+				if err != nil {
+					return fmt.Errorf("%w (%d rows of %s pruned before)", err, result.Rows, policy.Table)
+				}
+				log.Infof("%s: %d rows older than %s %s in %d batches", policy.Table, result.Rows, policy.MaxAge, pruneAction(result), result.Batches)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.WithError(err).Error("Error pruning the database")
+	}
+}
+
+// pruneAction describes what happens to the rows of result.
+func pruneAction(result orm.PruneResult) string {
+	if result.Archived {
+		return "archived"
+	}
+	return "deleted"
+}
+
+// pruneTask applies the retention rules of cfg for the tables of a scheduled prune task, a comma-separated list
+// that is empty to apply every rule.
+func pruneTask(ctx context.Context, conn *orm.Connection, cfg *config.Config, target string) error {
+	policies, err := orm.RetentionPolicies(cfg.Retention, pruneTables(target)...)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		result, err := conn.Prune(ctx, policy, cfg.Retention.BatchSize)
+		if err != nil {
+			return err
+		}
+		log.Infof("%s: %d rows %s", policy.Table, result.Rows, pruneAction(result))
+	}
+	return nil
+}
+
+// pruneTables returns the tables in the target of a prune task.
+func pruneTables(target string) []string {
+	var tables []string
+	for _, table := range strings.Split(target, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
@@ -1,16 +1,45 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/ooyeku/grayv-lsm/internal/stats"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
+// shutdownTracing flushes the spans recorded while running a command. It is set by setupTracing.
+var shutdownTracing tracing.ShutdownFunc
+
+// statsFile is the file the duration of the running command is recorded in, or empty if stats are disabled.
+// commandStart is when the command started. Both are set by beforeCommand.
+var (
+	statsFile    string
+	commandStart time.Time
+)
+
+// readOnly is set by the --read-only flag. Database connections opened by commands then refuse every statement that writes.
+var readOnly bool
+
+// echoSQL and explainOnly are set by the --echo-sql and --explain-only flags. Database connections opened by
+// commands then print every statement, and with explainOnly, skip the statements that write.
+var echoSQL, explainOnly bool
+
 // rootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:   "grayv-lsm",
-	Short: "Grayv LSM (Lifecycle Management)",
-	Long:  ` grayv-lsm is a CLI tool for managing the lifecycle of Grayv App.  Grayv apps are lightweight backend components consising of a containerized database, a model/schema generator, and an orm system.`,
+	Use:               "grayv-lsm",
+	Short:             "Grayv LSM (Lifecycle Management)",
+	Long:              ` grayv-lsm is a CLI tool for managing the lifecycle of Grayv App.  Grayv apps are lightweight backend components consising of a containerized database, a model/schema generator, and an orm system.`,
+	PersistentPreRun:  beforeCommand,
+	PersistentPostRun: afterCommand,
 }
 
 func Execute() {
@@ -20,6 +49,133 @@ func Execute() {
 	}
 }
 
+// beforeCommand reads the configuration again, sets up tracing, and starts timing and profiling the command, as configured.
+func beforeCommand(cmd *cobra.Command, args []string) {
+	commandStart = time.Now()
+	startProfile(cmd)
+
+	loaded, err := config.LoadConfig()
+	if err != nil {
+		return
+	}
+	// Commands run one after another in a process, as by tests, use the configuration of the working directory
+	// they run in rather than the one read when the process started.
+	cfg, dbManager = loaded, lsm.NewDBLifecycleManager(loaded)
+	setupTracing(cmd, cfg.Tracing)
+	setupMessages(cfg.Messages)
+	setupNaming(cmd, cfg.Naming)
+	setupTypes(cmd)
+
+	if cfg.Stats.Enabled {
+		statsFile = cfg.Stats.File
+		if statsFile == "" {
+			statsFile = stats.DefaultFile
+		}
+	}
+}
+
+// afterCommand writes the profile and records the duration of the command, and flushes the traces.
+func afterCommand(cmd *cobra.Command, args []string) {
+	stopProfile()
+	recordStats(cmd)
+	flushTracing()
+}
+
+// setupNaming sets the naming convention of the tables and columns of models. An invalid convention is reported
+// and the command is not run, as it would read or write tables under the wrong names, unless it is a config
+// command, which can fix it.
+func setupNaming(cmd *cobra.Command, cfg config.NamingConfig) {
+	err := naming.SetConvention(naming.Convention{
+		Columns: naming.ColumnCase(cfg.Columns),
+		Tables:  naming.TableNumber(cfg.Tables),
+		Prefix:  cfg.Prefix,
+	})
+	if err != nil {
+		log.WithError(err).Error("Invalid naming settings; fix them with 'config set naming.<key> <value>'")
+		if !strings.HasPrefix(commandName(cmd), "config") {
+			os.Exit(1)
+		}
+	}
+}
+
+// setupTypes registers the custom field types of the workspace, read from model.TypesFile. Invalid types are
+// reported and the command is not run, as the models using them could not be read, unless it is a config command.
+func setupTypes(cmd *cobra.Command) {
+	types, err := model.LoadTypes(model.TypesFile)
+	if err == nil {
+		err = model.RegisterTypes(types)
+	}
+	if err != nil {
+		log.WithError(err).Errorf("Invalid custom field types; fix them in %s", model.TypesFile)
+		if !strings.HasPrefix(commandName(cmd), "config") {
+			os.Exit(1)
+		}
+	}
+}
+
+// setupMessages loads the message catalog of the configured locale. A catalog that cannot be loaded is
+// reported, and the English messages are used instead.
+func setupMessages(cfg config.MessagesConfig) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = config.DefaultMessagesDir
+	}
+	catalog, err := messages.Load(dir, cfg.Locale, messages.Format{
+		DateLayout:         cfg.DateFormat,
+		ThousandsSeparator: cfg.ThousandsSeparator,
+		DecimalSeparator:   cfg.DecimalSeparator,
+	})
+	if err != nil {
+		log.WithError(err).Warn("Error loading messages; using English")
+		return
+	}
+	messages.Use(catalog)
+}
+
+// setupTracing installs the OpenTelemetry exporter described by the Tracing section of the configuration.
+// Tracing problems are logged and never stop the command from running.
+func setupTracing(cmd *cobra.Command, cfg config.TracingConfig) {
+	if !cfg.Enabled {
+		return
+	}
+
+	var err error
+	shutdownTracing, err = tracing.Setup(cmd.Context(), cfg)
+	if err != nil {
+		log.WithError(err).Warn("Error setting up tracing")
+	}
+}
+
+// recordStats appends the duration of cmd to the stats file. The stats command itself is not recorded.
+func recordStats(cmd *cobra.Command) {
+	if statsFile == "" || cmd == statsCmd {
+		return
+	}
+
+	run := stats.Run{Command: commandName(cmd), Start: commandStart, Duration: time.Since(commandStart)}
+	if err := stats.Record(statsFile, run); err != nil {
+		log.WithError(err).Warn("Error recording command stats")
+	}
+}
+
+// commandName returns the path of cmd without the name of the program, such as "db migrate".
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// flushTracing exports the spans that are still buffered.
+func flushTracing() {
+	if shutdownTracing == nil {
+		return
+	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.WithError(err).Warn("Error flushing traces")
+	}
+}
+
 func init() {
+	RootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Refuse every database statement that writes (overrides database.readonly)")
+	RootCmd.PersistentFlags().BoolVar(&echoSQL, "echo-sql", false, "Print every database statement and its parameters before running it")
+	RootCmd.PersistentFlags().BoolVar(&explainOnly, "explain-only", false, "Print database statements that write instead of running them")
 	RootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
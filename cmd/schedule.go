@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/seed"
+	"github.com/ooyeku/grayv-lsm/internal/database/vars"
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/internal/schedule"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled tasks",
+	Long: `Define and run cron-like tasks. Tasks can be defined in the Scheduler section of config.json
+or stored in the database with 'schedule add'. Supported task types are sql, seed, backup, http, maintain,
+prune, and refresh-view.`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled tasks and their next run time",
+	Run:   runScheduleList,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add or replace a scheduled task stored in the database",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleAdd,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Remove a scheduled task stored in the database",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleRemove,
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the scheduler daemon",
+	Run:   runScheduleRun,
+}
+
+var scheduleTriggerCmd = &cobra.Command{
+	Use:   "trigger [name]",
+	Short: "Run a scheduled task once, immediately",
+	Args:  cobra.ExactArgs(1),
+	Run:   runScheduleTrigger,
+}
+
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "history [name]",
+	Short: "Show the run history of scheduled tasks",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runScheduleHistory,
+}
+
+func init() {
+	scheduleAddCmd.Flags().String("cron", "", "Cron expression, e.g. \"0 3 * * *\" or \"@every 10m\"")
+	scheduleAddCmd.Flags().String("type", "sql", "Task type (sql, seed, backup, http, maintain, prune, refresh-view)")
+	scheduleAddCmd.Flags().String("target", "", "SQL statement, backup directory, or URL for the task, maintenance options such as analyze,full:events, the tables to prune, or the views to refresh such as concurrently:author_stats")
+	scheduleAddCmd.MarkFlagRequired("cron")
+
+	scheduleHistoryCmd.Flags().Int("limit", 20, "Maximum number of runs to show")
+
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	scheduleCmd.AddCommand(scheduleTriggerCmd)
+	scheduleCmd.AddCommand(scheduleHistoryCmd)
+	RootCmd.AddCommand(scheduleCmd)
+}
+
+// newScheduler creates a scheduler with the seed and backup actions registered and all tasks loaded.
+func newScheduler(conn *orm.Connection, cfg *config.Config) (*schedule.Scheduler, error) {
+	scheduler := schedule.NewScheduler(conn.GetDB(), log)
+	scheduler.RegisterAction("seed", func(ctx context.Context, task *schedule.Task) error {
+		seeder := seed.NewSeeder(conn.GetDB())
+		seeder.SetBulkLoader(conn.BulkLoader())
+		seeder.Vars = vars.FromConfig(cfg.Variables)
+		if err := seeder.LoadSeeds(); err != nil {
+			return fmt.Errorf("error loading seeds: %w", err)
+		}
+		return seeder.Seed()
+	})
+	scheduler.RegisterAction("maintain", func(ctx context.Context, task *schedule.Task) error {
+		opts, err := orm.ParseMaintenanceOptions(task.Target)
+		if err != nil {
+			return err
+		}
+		return conn.Maintain(ctx, opts)
+	})
+	scheduler.RegisterAction("prune", func(ctx context.Context, task *schedule.Task) error {
+		return pruneTask(ctx, conn, cfg, task.Target)
+	})
+	scheduler.RegisterAction("refresh-view", func(ctx context.Context, task *schedule.Task) error {
+		refreshes, err := orm.ParseViewRefreshes(task.Target)
+		if err != nil {
+			return err
+		}
+		for _, refresh := range refreshes {
+			if err := conn.RefreshView(ctx, refresh); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	scheduler.RegisterAction("backup", func(ctx context.Context, task *schedule.Task) error {
+		if dbManager == nil {
+			return fmt.Errorf("database manager is not configured")
+		}
+		_, err := dbManager.Backup(task.Target)
+		return err
+	})
+
+	if err := scheduler.LoadTasks(cfg.Scheduler); err != nil {
+		return nil, fmt.Errorf("error loading scheduled tasks: %w", err)
+	}
+	return scheduler, nil
+}
+
+// withScheduler loads the config, connects to the database, and passes a ready scheduler to action.
+func withScheduler(action func(*schedule.Scheduler, *orm.Connection) error) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	return withDBConnection(func(conn *orm.Connection) error {
+		scheduler, err := newScheduler(conn, cfg)
+		if err != nil {
+			return err
+		}
+		return action(scheduler, conn)
+	})
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) {
+	err := withScheduler(func(scheduler *schedule.Scheduler, conn *orm.Connection) error {
+		tasks := scheduler.Tasks()
+		if len(tasks) == 0 {
+			log.Info("No scheduled tasks defined")
+			return nil
+		}
+
+		log.Info("Scheduled tasks:")
+		now := time.Now()
+		for _, task := range tasks {
+			log.Infof("- %s [%s] %s (%s), next run: %s", task.Name, task.Type, task.Cron, task.Source,
+				messages.Time(task.Cron.Next(now), time.RFC3339))
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error listing scheduled tasks")
+	}
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) {
+	cronExpr, _ := cmd.Flags().GetString("cron")
+	taskType, _ := cmd.Flags().GetString("type")
+	target, _ := cmd.Flags().GetString("target")
+
+	switch taskType {
+	case "sql", "seed", "backup", "http":
+	case "maintain":
+		if _, err := orm.ParseMaintenanceOptions(target); err != nil {
+			log.WithError(err).Errorf("Error adding scheduled task %s", args[0])
+			return
+		}
+	case "prune":
+		if _, err := orm.RetentionPolicies(cfg.Retention, pruneTables(target)...); err != nil {
+			log.WithError(err).Errorf("Error adding scheduled task %s", args[0])
+			return
+		}
+	case "refresh-view":
+		if _, err := orm.ParseViewRefreshes(target); err != nil {
+			log.WithError(err).Errorf("Error adding scheduled task %s", args[0])
+			return
+		}
+	default:
+		log.Errorf("Unknown task type %q", taskType)
+		return
+	}
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		return schedule.SaveTask(conn.GetDB(), args[0], cronExpr, taskType, target)
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Error adding scheduled task %s", args[0])
+	} else {
+		log.Infof("Scheduled task %s saved", args[0])
+	}
+}
+
+func runScheduleRemove(cmd *cobra.Command, args []string) {
+	err := withDBConnection(func(conn *orm.Connection) error {
+		return schedule.DeleteTask(conn.GetDB(), args[0])
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Error removing scheduled task %s", args[0])
+	} else {
+		log.Infof("Scheduled task %s removed", args[0])
+	}
+}
+
+func runScheduleRun(cmd *cobra.Command, args []string) {
+	if cfg == nil {
+		log.Error("Configuration is not loaded")
+		return
+	}
+
+	// The scheduler waits for the database to load its tasks, and skips the runs due while it is unreachable.
+	err := runUntilSignal(cfg, func(ctx context.Context) error {
+		return withDBConnection(func(conn *orm.Connection) error {
+			health := orm.NewHealthMonitor(conn.GetDB(), log)
+			if health.Wait(ctx) != nil {
+				// Interrupted before the database came up.
+				return nil
+			}
+			scheduler, err := newScheduler(conn, cfg)
+			if err != nil {
+				return err
+			}
+			scheduler.Ready = health.Err
+			go health.Run(ctx)
+			return scheduler.Run(ctx)
+		})
+	})
+	if err != nil {
+		log.WithError(err).Error("Error running scheduler")
+	}
+}
+
+func runScheduleTrigger(cmd *cobra.Command, args []string) {
+	err := withScheduler(func(scheduler *schedule.Scheduler, conn *orm.Connection) error {
+		task, err := scheduler.Task(args[0])
+		if err != nil {
+			return err
+		}
+		return scheduler.RunTask(context.Background(), task)
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Error running scheduled task %s", args[0])
+	}
+}
+
+func runScheduleHistory(cmd *cobra.Command, args []string) {
+	limit, _ := cmd.Flags().GetInt("limit")
+	taskName := ""
+	if len(args) > 0 {
+		taskName = args[0]
+	}
+
+	err := withScheduler(func(scheduler *schedule.Scheduler, conn *orm.Connection) error {
+		runs, err := scheduler.History(taskName, limit)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			log.Info("No task runs recorded")
+			return nil
+		}
+
+		log.Info("Task runs:")
+		for _, run := range runs {
+			duration := "-"
+			if run.FinishedAt.Valid {
+				duration = run.FinishedAt.Time.Sub(run.StartedAt).Round(time.Millisecond).String()
+			}
+			if run.Error != "" {
+				log.Infof("- %s %s [%s] %s: %s", messages.Time(run.StartedAt, time.RFC3339), run.TaskName, run.Status, duration, run.Error)
+			} else {
+				log.Infof("- %s %s [%s] %s", messages.Time(run.StartedAt, time.RFC3339), run.TaskName, run.Status, duration)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error reading task history")
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Work with the schema of the database",
+}
+
+var schemaDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump the schema of the database, without its data",
+	Long: `Write the statements creating the schema of the database, without its data, to --output or stdout.
+Objects are grouped by kind, in the order they can be created in, and sorted by name, so the same schema always
+gives the same dump: commit it to review schema changes with the migrations that make them. With --check, the
+dump is compared with --output instead, the differences are printed, and the command exits with status 1 if
+they differ, so a CI job can fail when the database drifts from the committed schema.`,
+	Args: cobra.NoArgs,
+	Run:  runSchemaDump,
+}
+
+func init() {
+	schemaDumpCmd.Flags().StringP("output", "o", "", "File the schema is written to (default stdout)")
+	schemaDumpCmd.Flags().Bool("check", false, "Compare the schema with --output instead of writing it, and exit with status 1 if they differ")
+	schemaCmd.AddCommand(schemaDumpCmd)
+	dbCmd.AddCommand(schemaCmd)
+}
+
+func runSchemaDump(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	check, _ := cmd.Flags().GetBool("check")
+	if check && output == "" {
+		log.Error("--check needs the committed schema file, given with --output")
+		os.Exit(1)
+	}
+
+	var schema *orm.Schema
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		schema, err = conn.DumpSchema()
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error dumping the schema")
+		os.Exit(1)
+	}
+	dump := []byte(schema.SQL())
+
+	switch {
+	case check:
+		committed, err := os.ReadFile(output)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.WithError(err).Errorf("Error reading %s", output)
+			os.Exit(1)
+		}
+		if string(committed) == string(dump) {
+			log.Infof("The schema matches %s", output)
+			return
+		}
+		if err := printDiff(output, committed, dump); err != nil {
+			log.WithError(err).Error("Error comparing the schema")
+		}
+		log.Errorf("The schema of the database differs from %s; run db schema dump -o %s to update it", output, output)
+		os.Exit(1)
+	case output == "":
+		fmt.Print(string(dump))
+	default:
+		if err := os.WriteFile(output, dump, 0644); err != nil {
+			log.WithError(err).Errorf("Error writing %s", output)
+			os.Exit(1)
+		}
+		log.Infof("Wrote the schema of %d objects to %s", len(schema.Objects), output)
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/seed"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var makeSeedCmd = &cobra.Command{
+	Use:   "make-seed [name]",
+	Short: "Generate a seed skeleton or fake data from a model definition",
+	Long: `Generate a seed file with the column list of a model and placeholder values of the right types, to be
+edited before running db seed. The seed is numbered after the existing seeds, so it runs last.
+Templates:
+  rows        sample rows for the model's table (default)
+  reference   reference data that skips rows that already exist, so it can run again
+  join        links between the rows of the model and the --join model in the join table [name]
+
+With --fake Model:N, the seed inserts N rows of fake data generated for the model instead, as db model bench
+does, written as literal values so the seed can be reviewed and committed. The same --seed writes the same rows.
+Foreign keys pick from the rows the referenced tables hold now. The name defaults to the model's table, and
+--out writes the seed to a given file rather than numbering it after the existing seeds:
+  grayv-lsm db make-seed --fake User:100 -o seeds/003_users.sql`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMakeSeed,
+}
+
+func init() {
+	makeSeedCmd.Flags().String("from-model", "", "Model whose columns are seeded")
+	makeSeedCmd.Flags().String("template", string(seed.TemplateRows), "Seed template: rows, reference, or join")
+	makeSeedCmd.Flags().String("join", "", "Second model of a join table, for the join template")
+	makeSeedCmd.Flags().Int("rows", seed.DefaultSkeletonRows, "Number of rows to insert")
+	makeSeedCmd.Flags().String("app", "", "Name of the Grayv app to write the seed to")
+	makeSeedCmd.Flags().String("fake", "", "Model and number of rows of fake data to insert, such as User:100")
+	makeSeedCmd.Flags().Int64("seed", 1, "Random seed of the fake data")
+	makeSeedCmd.Flags().String("tenant", "", "Tenant the fake rows belong to, for models scoped to tenants")
+	makeSeedCmd.Flags().StringP("out", "o", "", "File to write the seed to")
+	addGenerationFlags(makeSeedCmd)
+	dbCmd.AddCommand(makeSeedCmd)
+}
+
+func runMakeSeed(cmd *cobra.Command, args []string) {
+	fromModel, _ := cmd.Flags().GetString("from-model")
+	fake, _ := cmd.Flags().GetString("fake")
+	out, _ := cmd.Flags().GetString("out")
+	appName, _ := cmd.Flags().GetString("app")
+
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	var sql string
+	var err error
+	switch {
+	case (fromModel == "") == (fake == ""):
+		log.Error("Either --from-model or --fake is required")
+		return
+	case fake != "":
+		name, sql, err = generateFakeSeed(cmd, name, fake)
+	case name == "":
+		log.Error("A seed name is required with --from-model")
+		return
+	default:
+		sql, err = generateSkeletonSeed(cmd, name, fromModel)
+	}
+	if err != nil {
+		log.WithError(err).Error("Error generating seed")
+		return
+	}
+
+	path := out
+	if path == "" {
+		dir := "seeds"
+		if appName != "" {
+			target, err := appCreator.FindApp(appName)
+			if err != nil {
+				log.WithError(err).Error("Failed to find app")
+				return
+			}
+			dir = filepath.Join(target.Dir, "seeds")
+		}
+		if path, err = seed.NextSeedPath(dir, name); err != nil {
+			log.WithError(err).Error("Error generating seed")
+			return
+		}
+	}
+
+	w := generationWriter(cmd)
+	if err := w.WriteFile(path, []byte(sql)); err != nil {
+		log.WithError(err).Error("Error writing seed")
+		return
+	}
+	if !w.Preview() {
+		log.Infof("Wrote seed %s", path)
+	}
+}
+
+// generateSkeletonSeed returns the SQL of the seed skeleton name for the model fromModel, as configured by the
+// template flags of make-seed.
+func generateSkeletonSeed(cmd *cobra.Command, name, fromModel string) (string, error) {
+	joinModel, _ := cmd.Flags().GetString("join")
+	templateName, _ := cmd.Flags().GetString("template")
+	rows, _ := cmd.Flags().GetInt("rows")
+
+	template, err := seed.ParseTemplate(templateName)
+	if err != nil {
+		return "", err
+	}
+	if (joinModel != "") != (template == seed.TemplateJoin) {
+		return "", errors.New("--join is required by the join template and only used with it")
+	}
+
+	skeleton := seed.Skeleton{Template: template, Table: name, Rows: rows}
+	err = withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		if skeleton.Model, err = loadSeedModel(conn, fromModel); err != nil {
+			return err
+		}
+		if joinModel != "" {
+			skeleton.Join, err = loadSeedModel(conn, joinModel)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return seed.GenerateSkeleton(skeleton)
+}
+
+// fakeSince is the start of the year the timestamps of fake seeds fall in, fixed so the same --seed writes the
+// same seed.
+var fakeSince = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// generateFakeSeed returns the name and SQL of a seed of fake rows for fake, written as Model:N. The name
+// defaults to the table of the model.
+func generateFakeSeed(cmd *cobra.Command, name, fake string) (string, string, error) {
+	seedValue, _ := cmd.Flags().GetInt64("seed")
+	tenant, _ := cmd.Flags().GetString("tenant")
+
+	modelName, count, ok := strings.Cut(fake, ":")
+	if !ok {
+		return "", "", fmt.Errorf("--fake %q is not of the form Model:N", fake)
+	}
+	rows, err := parseRowCount(count)
+	if err != nil {
+		return "", "", err
+	}
+
+	var sql string
+	err = withDBConnection(func(conn *orm.Connection) error {
+		def, err := loadSeedModel(conn, modelName)
+		if err != nil {
+			return err
+		}
+		factory, err := newSeedFactory(conn, def, seedValue, tenant)
+		if err != nil {
+			return err
+		}
+		factory.SetSince(fakeSince)
+		if name == "" {
+			name = def.TableName()
+		}
+		sql = seed.GenerateFake(factory, def.TableName(), rows)
+		return nil
+	})
+	return name, sql, err
+}
+
+// newSeedFactory returns a factory of fake rows for def seeded with seedValue, picking foreign keys from the
+// rows the referenced tables hold and scoping rows to tenant. It fails if the rows cannot be generated, as for
+// view models, whose rows come from their query.
+func newSeedFactory(conn *orm.Connection, def *model.ModelDefinition, seedValue int64, tenant string) (*seed.Factory, error) {
+	if def.IsView() {
+		return nil, fmt.Errorf("model %s is backed by a view, whose rows come from its query", def.Name)
+	}
+	factory := seed.NewFactory(def, rand.New(rand.NewSource(seedValue)))
+	for _, field := range def.Fields {
+		if field.References == "" {
+			continue
+		}
+		ids, err := referenceIDs(conn, field)
+		if err != nil {
+			return nil, err
+		}
+		factory.SetReferences(field.Name, ids)
+	}
+	if tenant != "" {
+		if err := orm.ValidateTenantID(tenant); err != nil {
+			return nil, err
+		}
+		factory.SetTenant(tenant)
+	}
+	if err := factory.Check(); err != nil {
+		return nil, err
+	}
+	return factory, nil
+}
+
+// loadSeedModel returns the stored definition of the model name, with tenancy applied as configured.
+func loadSeedModel(conn *orm.Connection, name string) (*model.ModelDefinition, error) {
+	modelName, err := model.NormalizeModelName(name)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := loadModelDefinitions(conn, modelName)
+	if err != nil {
+		return nil, fmt.Errorf("error loading model %s: %w", modelName, err)
+	}
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("model %s not found", modelName)
+	}
+	applyTenancy(defs)
+	return defs[0], nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/internal/serve"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the registered models as a JSON REST API",
+	Long: `Start an HTTP server exposing CRUD endpoints under /api/{model} for every model in the models table.
+The host, port, CORS, CSRF, and rate limit settings are read from the Server section of the configuration.`,
+	Run: runServe,
+}
+
+func init() {
+	serveCmd.Flags().String("host", "", "Host to listen on (overrides server.host)")
+	serveCmd.Flags().Int("port", 0, "Port to listen on (overrides server.port)")
+	serveCmd.Flags().String("static", "", "Directory of a frontend bundle to serve alongside the API (overrides server.staticdir)")
+	serveCmd.Flags().Float64("rate-limit", 0, "Requests per second allowed per client; enables rate limiting (overrides server.ratelimit.rate)")
+	serveCmd.Flags().Int("rate-limit-burst", 0, "Requests a client may make at once (overrides server.ratelimit.burst)")
+	serveCmd.Flags().String("rate-limit-backend", "", "Where rate limit buckets are kept: memory or database (overrides server.ratelimit.backend)")
+	RootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.WithError(err).Error("Error loading config")
+		return
+	}
+
+	if host, _ := cmd.Flags().GetString("host"); host != "" {
+		cfg.Server.Host = host
+	}
+	if port, _ := cmd.Flags().GetInt("port"); port != 0 {
+		cfg.Server.Port = port
+	}
+	if static, _ := cmd.Flags().GetString("static"); static != "" {
+		cfg.Server.StaticDir = static
+	}
+	if rate, _ := cmd.Flags().GetFloat64("rate-limit"); rate > 0 {
+		cfg.Server.RateLimit.Enabled = true
+		cfg.Server.RateLimit.Rate = rate
+	}
+	if burst, _ := cmd.Flags().GetInt("rate-limit-burst"); burst > 0 {
+		cfg.Server.RateLimit.Burst = burst
+	}
+	if backend, _ := cmd.Flags().GetString("rate-limit-backend"); backend != "" {
+		cfg.Server.RateLimit.Backend = backend
+	}
+	serveAPI(cfg)
+}
+
+// serveAPI serves the registered models with the settings in cfg until the process is interrupted.
+// It waits for the database to be reachable before loading the models, so it can start along with the database.
+// Requests in flight then have the shutdown timeout to finish before the database connection is closed.
+func serveAPI(cfg *config.Config) {
+	conn, err := openConnection(cfg)
+	if err != nil {
+		log.WithError(err).Error("Error connecting to database")
+		return
+	}
+	defer conn.Close()
+
+	err = runUntilSignal(cfg, func(ctx context.Context) error {
+		if orm.NewHealthMonitor(conn.GetDB(), log).Wait(ctx) != nil {
+			// Interrupted before the database came up.
+			return nil
+		}
+		server, err := serve.NewServer(cfg, conn, log)
+		if err != nil {
+			return fmt.Errorf("error creating API server: %w", err)
+		}
+		return server.ListenAndServe(ctx)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error serving API")
+	}
+}
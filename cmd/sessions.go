@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Inspect and end database sessions",
+	Long:  `List the sessions connected to the database and end those running away, using pg_stat_activity.`,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the sessions connected to the database",
+	Run:   runSessionsList,
+}
+
+var sessionsKillCmd = &cobra.Command{
+	Use:   "kill [pid]",
+	Short: "End a database session",
+	Long:  `End the session with the given process ID with pg_terminate_backend. With --cancel, only its running query is cancelled.`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runSessionsKill,
+}
+
+func init() {
+	sessionsListCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	sessionsKillCmd.Flags().Bool("cancel", false, "Cancel the running query instead of ending the session")
+
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsKillCmd)
+	dbCmd.AddCommand(sessionsCmd)
+}
+
+func runSessionsList(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+
+	var sessions []orm.Session
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		sessions, err = conn.ListSessions()
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error listing sessions")
+		return
+	}
+
+	if output == "json" {
+		if sessions == nil {
+			sessions = []orm.Session{}
+		}
+		if err := printJSON(sessions); err != nil {
+			log.WithError(err).Error("Error printing sessions")
+		}
+		return
+	}
+
+	if len(sessions) == 0 {
+		log.Info("No other sessions are connected")
+		return
+	}
+	fmt.Printf("%-8s %-12s %-20s %-10s %-10s %s\n", "PID", "USER", "APPLICATION", "STATE", "DURATION", "QUERY")
+	for _, s := range sessions {
+		fmt.Printf("%-8d %-12s %-20s %-10s %-10s %s\n", s.PID, s.User, s.Application, s.State,
+			s.Duration.Round(time.Second), summarizeQuery(s.Query, 60))
+	}
+}
+
+func runSessionsKill(cmd *cobra.Command, args []string) {
+	cancel, _ := cmd.Flags().GetBool("cancel")
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		log.WithError(err).Error("Invalid process ID")
+		return
+	}
+
+	var found bool
+	err = withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		found, err = conn.KillSession(pid, cancel)
+		return err
+	})
+	switch {
+	case err != nil:
+		log.WithError(err).Error("Error ending session")
+	case !found:
+		log.Errorf("No session with process ID %d", pid)
+	case cancel:
+		log.Infof("Cancelled the query of session %d", pid)
+	default:
+		log.Infof("Ended session %d", pid)
+	}
+}
+
+// summarizeQuery collapses the whitespace of query and shortens it to at most width characters.
+func summarizeQuery(query string, width int) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > width {
+		return query[:width-3] + "..."
+	}
+	return query
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// runUntilSignal runs run with a context canceled on SIGINT or SIGTERM, upon which run stops taking new work,
+// finishes the work in flight, and releases its resources. Once interrupted, run has the shutdown timeout of
+// cfg to return; if it takes longer, or a second signal arrives, the process exits with status 1.
+func runUntilSignal(cfg *config.Config, run func(ctx context.Context) error) error {
+	timeout, err := cfg.Server.ShutdownDuration()
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case sig := <-signals:
+		log.Infof("Received %s, shutting down (waiting up to %s)", sig, timeout)
+		cancel()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		log.Errorf("Did not shut down within %s, exiting", timeout)
+	case <-signals:
+		log.Error("Interrupted again, exiting")
+	}
+	os.Exit(1)
+	return nil
+}
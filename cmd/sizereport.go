@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var sizeReportCmd = &cobra.Command{
+	Use:   "size-report",
+	Short: "Show how much storage the tables and indexes use",
+	Long: `List the tables of the database by size, with their estimated rows and the space of the rows, TOAST,
+and indexes, then the indexes by size. Indexes never scanned that enforce no uniqueness are marked unused; they
+slow down writes and can usually be dropped. Every run is saved to .grayv/sizes.json, or the file set by --file,
+so the next run shows how much each table grew in between. Nothing is sent anywhere.`,
+	Args: cobra.NoArgs,
+	Run:  runSizeReport,
+}
+
+func init() {
+	sizeReportCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	sizeReportCmd.Flags().Int("limit", 20, "Number of tables and of indexes to list; 0 lists them all")
+	sizeReportCmd.Flags().String("file", orm.DefaultSizeHistoryFile, "File keeping the previous report, to measure growth")
+	sizeReportCmd.Flags().Bool("no-save", false, "Do not save this report as the one to measure growth from")
+	dbCmd.AddCommand(sizeReportCmd)
+}
+
+func runSizeReport(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	limit, _ := cmd.Flags().GetInt("limit")
+	path, _ := cmd.Flags().GetString("file")
+	noSave, _ := cmd.Flags().GetBool("no-save")
+
+	var report *orm.SizeReport
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		report, err = conn.SizeReport()
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error measuring the database")
+		return
+	}
+
+	history, err := orm.LoadSizeHistory(path)
+	if err != nil {
+		log.WithError(err).Error("Error loading the previous report")
+		return
+	}
+	report.CompareWith(history[report.Database])
+	if !noSave {
+		history[report.Database] = report
+		if err := orm.SaveSizeHistory(path, history); err != nil {
+			log.WithError(err).Error("Error saving the report")
+		}
+	}
+
+	if output == "json" {
+		if err := printJSON(report); err != nil {
+			log.WithError(err).Error("Error printing the report")
+		}
+		return
+	}
+	printSizeReport(report, limit)
+}
+
+// printSizeReport prints the limit largest tables and indexes of report, all of them if limit is 0.
+func printSizeReport(report *orm.SizeReport, limit int) {
+	fmt.Printf("Database %s: %s", report.Database, formatBytes(report.DatabaseBytes))
+	if report.PreviousAt != nil {
+		fmt.Printf(" (%s since %s)", formatGrowth(report.DatabaseGrowth), messages.Time(*report.PreviousAt, "2006-01-02 15:04"))
+	}
+	fmt.Println()
+
+	if len(report.Tables) == 0 {
+		log.Info("No tables found in the database")
+		return
+	}
+	tables := report.Tables
+	if limit > 0 && len(tables) > limit {
+		tables = tables[:limit]
+	}
+	fmt.Printf("\n%-30s %12s %10s %10s %10s %10s %10s\n", "TABLE", "ROWS (EST)", "TABLE", "TOAST", "INDEXES", "TOTAL", "GROWTH")
+	for _, s := range tables {
+		fmt.Printf("%-30s %12d %10s %10s %10s %10s %10s\n", s.Table, s.RowEstimate, formatBytes(s.TableBytes),
+			formatBytes(s.ToastBytes), formatBytes(s.IndexBytes), formatBytes(s.TotalBytes), formatGrowth(s.Growth))
+	}
+
+	if len(report.Indexes) == 0 {
+		return
+	}
+	indexes := report.Indexes
+	if limit > 0 && len(indexes) > limit {
+		indexes = indexes[:limit]
+	}
+	unused := 0
+	fmt.Printf("\n%-40s %-30s %10s %12s\n", "INDEX", "TABLE", "SIZE", "SCANS")
+	for _, s := range indexes {
+		note := ""
+		if s.Unused() {
+			note = "  unused"
+			unused++
+		}
+		fmt.Printf("%-40s %-30s %10s %12d%s\n", s.Index, s.Table, formatBytes(s.Bytes), s.Scans, note)
+	}
+	if unused > 0 {
+		fmt.Printf("\n%d listed indexes were never scanned since the statistics were reset; consider dropping them.\n", unused)
+	}
+}
+
+// formatBytes writes n bytes with the largest binary unit that keeps it at least 1, like pg_size_pretty.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, units := float64(n)/unit, "kMGTP"
+	for i := 0; i < len(units)-1 && (value >= unit || value <= -unit); i++ {
+		value /= unit
+		units = units[1:]
+	}
+	return fmt.Sprintf("%.1f %cB", value, units[0])
+}
+
+// formatGrowth writes a change of size with its sign, or "-" if there is nothing to compare with.
+func formatGrowth(growth *int64) string {
+	switch {
+	case growth == nil:
+		return "-"
+	case *growth > 0:
+		return "+" + formatBytes(*growth)
+	default:
+		return formatBytes(*growth)
+	}
+}
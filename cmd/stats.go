@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/stats"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how long commands take",
+	Long: `Summarize the recorded durations of grayv-lsm commands, slowest in total first.
+Recording is opt-in and local: enable it with "grayv-lsm config set stats.enabled true".
+Durations are written to .grayv/stats.json, or the file set by stats.file, and never sent anywhere.`,
+	Run: runStats,
+}
+
+func init() {
+	statsCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	statsCmd.Flags().Bool("reset", false, "Delete the recorded stats")
+	RootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+	reset, _ := cmd.Flags().GetBool("reset")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.WithError(err).Error("Error loading config")
+		return
+	}
+	path := cfg.Stats.File
+	if path == "" {
+		path = stats.DefaultFile
+	}
+
+	if reset {
+		if err := stats.Save(path, &stats.File{}); err != nil {
+			log.WithError(err).Error("Error resetting stats")
+			return
+		}
+		log.Info("Stats reset")
+		return
+	}
+
+	file, err := stats.Load(path)
+	if err != nil {
+		log.WithError(err).Error("Error loading stats")
+		return
+	}
+	summaries := stats.Summarize(file.Runs)
+
+	if output == "json" {
+		if err := printJSON(summaries); err != nil {
+			log.WithError(err).Error("Error printing stats")
+		}
+		return
+	}
+
+	if len(summaries) == 0 {
+		if !cfg.Stats.Enabled {
+			log.Info(`No stats recorded. Enable recording with "grayv-lsm config set stats.enabled true"`)
+		} else {
+			log.Info("No stats recorded yet")
+		}
+		return
+	}
+	fmt.Printf("%-30s %6s %10s %10s %10s %10s\n", "COMMAND", "RUNS", "TOTAL", "AVERAGE", "MAX", "LAST")
+	for _, s := range summaries {
+		fmt.Printf("%-30s %6d %10s %10s %10s %10s\n", s.Command, s.Runs,
+			formatDuration(s.Total), formatDuration(s.Average), formatDuration(s.Max), formatDuration(s.Last))
+	}
+}
+
+// formatDuration rounds d for display, keeping more precision for short durations.
+func formatDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/spf13/cobra"
+)
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Manage the optional MinIO object storage container",
+	Long: `Start, stop, and inspect a MinIO container providing S3-compatible storage for file uploads.
+Its endpoint, bucket, credentials, and image are read from the Storage section of the configuration.`,
+}
+
+var storageStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the MinIO container and create the bucket",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		if err := lsm.NewStorageLifecycleManager(cfg).StartContainer(); err != nil {
+			log.WithError(err).Error("Error starting storage container")
+			return
+		}
+		log.Info("Run 'grayv-lsm config set storage.backend s3' to store uploads in MinIO")
+	},
+}
+
+var storageStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop and remove the MinIO container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		if err := lsm.NewStorageLifecycleManager(cfg).StopContainer(); err != nil {
+			log.WithError(err).Error("Error stopping storage container")
+		}
+	},
+}
+
+var storageStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check the status of the MinIO container",
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg == nil {
+			log.Error("Configuration is not loaded")
+			return
+		}
+		status, err := lsm.NewStorageLifecycleManager(cfg).GetStatus()
+		if err != nil {
+			log.WithError(err).Error("Error checking storage status")
+			return
+		}
+		log.Info(status)
+	},
+}
+
+func init() {
+	storageCmd.AddCommand(storageStartCmd, storageStopCmd, storageStatusCmd)
+	RootCmd.AddCommand(storageCmd)
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/messages"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var tenantCmd = &cobra.Command{
+	Use:   "tenant",
+	Short: "Manage the tenants of a multi-tenant database",
+	Long: `Create, list, and drop tenants when database.tenancy is set.
+With "column" tenancy, model tables have a tenant_id column and dropping a tenant deletes its rows.
+With "schema" tenancy, each tenant gets a schema holding the tables of every model, which is dropped with the tenant.`,
+}
+
+var tenantCreateCmd = &cobra.Command{
+	Use:   "create [id]",
+	Short: "Create a tenant",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTenantCreate,
+}
+
+var tenantListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tenants",
+	Args:  cobra.NoArgs,
+	Run:   runTenantList,
+}
+
+var tenantDropCmd = &cobra.Command{
+	Use:   "drop [id]",
+	Short: "Drop a tenant and all of its data",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTenantDrop,
+}
+
+func init() {
+	tenantCreateCmd.Flags().String("name", "", "Display name of the tenant")
+	tenantCmd.AddCommand(tenantCreateCmd, tenantListCmd, tenantDropCmd)
+	dbCmd.AddCommand(tenantCmd)
+}
+
+func runTenantCreate(cmd *cobra.Command, args []string) {
+	id := args[0]
+	if err := orm.ValidateTenantID(id); err != nil {
+		log.WithError(err).Error("Error creating tenant")
+		return
+	}
+	name, _ := cmd.Flags().GetString("name")
+
+	err := withTenancy("create tenants", func(conn *orm.Connection) error {
+		tx, err := conn.Begin(context.Background())
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		query, params := conn.NewQuery("tenants").Insert("id", "name").Values(id, name).Build()
+		if _, err := tx.Exec(query, params...); err != nil {
+			return fmt.Errorf("error registering tenant %s: %w", id, err)
+		}
+		if conn.Tenancy() == orm.TenancySchema {
+			if err := createTenantSchema(conn, tx, id); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		log.WithError(err).Error("Error creating tenant")
+		return
+	}
+	log.Infof("Tenant %s created", id)
+}
+
+// createTenantSchema creates the schema of tenant id and the tables of every model in it.
+// Foreign keys between models resolve to the tables in the same schema.
+func createTenantSchema(conn *orm.Connection, tx *orm.Tx, id string) error {
+	defs, err := loadModelDefinitions(conn)
+	if err != nil {
+		return fmt.Errorf("error loading models: %w", err)
+	}
+	if defs, err = model.SortByDependencies(defs); err != nil {
+		return err
+	}
+
+	schema := conn.Dialect().Quote(orm.TenantSchema(id))
+	if _, err := tx.Exec("CREATE SCHEMA " + schema); err != nil {
+		return fmt.Errorf("error creating schema for tenant %s: %w", id, err)
+	}
+	if _, err := tx.Exec("SET LOCAL search_path TO " + schema + ", public"); err != nil {
+		return err
+	}
+	mm := model.NewModelManager()
+	for _, def := range defs {
+		if _, err := tx.Exec(mm.GenerateMigration(def)); err != nil {
+			return fmt.Errorf("error creating table %s for tenant %s: %w", def.TableName(), id, err)
+		}
+	}
+	return nil
+}
+
+func runTenantList(cmd *cobra.Command, args []string) {
+	err := withDBConnection(func(conn *orm.Connection) error {
+		rows, err := conn.Query("SELECT id, name, created_at FROM tenants ORDER BY id")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		count := 0
+		for rows.Next() {
+			var id, name string
+			var createdAt time.Time
+			if err := rows.Scan(&id, &name, &createdAt); err != nil {
+				return err
+			}
+			if count == 0 {
+				log.Info("Tenants:")
+			}
+			count++
+			if name != "" {
+				log.Infof("- %s (%s), created %s", id, name, messages.Time(createdAt, time.DateTime))
+			} else {
+				log.Infof("- %s, created %s", id, messages.Time(createdAt, time.DateTime))
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if count == 0 {
+			log.Info("No tenants found")
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error listing tenants")
+	}
+}
+
+func runTenantDrop(cmd *cobra.Command, args []string) {
+	id := args[0]
+	if err := orm.ValidateTenantID(id); err != nil {
+		log.WithError(err).Error("Error dropping tenant")
+		return
+	}
+	err := withTenancy("drop tenants", func(conn *orm.Connection) error {
+		tx, err := conn.Begin(context.Background())
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		query, params := conn.NewQuery("tenants").Delete().Where("id = ?", id).Build()
+		result, err := tx.Exec(query, params...)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return fmt.Errorf("tenant %s not found", id)
+		}
+
+		if conn.Tenancy() == orm.TenancySchema {
+			if _, err := tx.Exec("DROP SCHEMA IF EXISTS " + conn.Dialect().Quote(orm.TenantSchema(id)) + " CASCADE"); err != nil {
+				return fmt.Errorf("error dropping schema of tenant %s: %w", id, err)
+			}
+			return tx.Commit()
+		}
+
+		defs, err := loadModelDefinitions(conn)
+		if err != nil {
+			return fmt.Errorf("error loading models: %w", err)
+		}
+		if defs, err = model.SortByDependencies(defs); err != nil {
+			return err
+		}
+		// Rows are deleted from dependent tables first, so foreign keys never point at a deleted row.
+		for i := len(defs) - 1; i >= 0; i-- {
+			query, params := conn.NewQuery(defs[i].TableName()).Delete().Tenant(orm.TenancyColumn, id).Build()
+			if _, err := tx.Exec(query, params...); err != nil {
+				return fmt.Errorf("error deleting rows of tenant %s from %s: %w", id, defs[i].TableName(), err)
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		log.WithError(err).Error("Error dropping tenant")
+		return
+	}
+	log.Infof("Tenant %s dropped", id)
+}
+
+// withTenancy runs action on a writable connection of a database with tenancy enabled.
+func withTenancy(action string, fn func(*orm.Connection) error) error {
+	return withDBConnection(func(conn *orm.Connection) error {
+		if conn.Tenancy() == orm.TenancyNone {
+			return errors.New("tenancy is disabled; set database.tenancy to column or schema")
+		}
+		if err := conn.CheckWritable(action); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// applyTenancy marks defs as tenant-scoped if the configuration keeps tenants apart by column,
+// so their migrations add the tenant_id column.
+func applyTenancy(defs []*model.ModelDefinition) {
+	if cfg == nil || cfg.Database.Tenancy != string(orm.TenancyColumn) {
+		return
+	}
+	for _, def := range defs {
+		def.Tenant = true
+	}
+}
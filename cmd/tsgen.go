@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var tsgenCmd = &cobra.Command{
+	Use:   "tsgen",
+	Short: "Generate TypeScript types for the models",
+	Long: `Generate a TypeScript module with an interface for every stored model, matching the JSON encoding of
+the generated Go structs, so frontends stay in sync with the backend. With --zod, the module also exports a
+zod schema of every model, such as PostSchema. Without --out, the module is printed. With --out, the usual
+generation flags apply.`,
+	Args: cobra.NoArgs,
+	Run:  runTSGen,
+}
+
+func init() {
+	tsgenCmd.Flags().String("out", "", "File to write the module to, such as web/types.ts")
+	tsgenCmd.Flags().Bool("zod", false, "Also export zod schemas of the models")
+	addGenerationFlags(tsgenCmd)
+	modelCmd.AddCommand(tsgenCmd)
+}
+
+func runTSGen(cmd *cobra.Command, args []string) {
+	out, _ := cmd.Flags().GetString("out")
+	zod, _ := cmd.Flags().GetBool("zod")
+
+	var defs []*model.ModelDefinition
+	err := withDBConnection(func(conn *orm.Connection) error {
+		var err error
+		if defs, err = loadModelDefinitions(conn); err != nil {
+			return fmt.Errorf("error loading models: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error generating TypeScript types")
+		return
+	}
+	if len(defs) == 0 {
+		log.Warn("No models found")
+		return
+	}
+
+	source := model.GenerateTypeScript(defs, zod)
+	if out == "" {
+		fmt.Print(source)
+		return
+	}
+	w := generationWriter(cmd)
+	if err := w.WriteFile(out, []byte(source)); err != nil {
+		log.WithError(err).Error("Error writing TypeScript types")
+		return
+	}
+	if !w.Preview() {
+		log.Infof("Wrote TypeScript types for %d models to %s", len(defs), out)
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ooyeku/grayv-lsm/internal/gdpr"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var purgeUserCmd = &cobra.Command{
+	Use:   "purge-user",
+	Short: "Delete or anonymize a user and all of their rows",
+	Long: `Delete a user together with every row referencing them, found by following the foreign keys of the
+database: rows referencing the user, rows referencing those rows, and so on. All rows are deleted in a single
+transaction, so either all of them go or none do.
+
+With --anonymize, the user's username, email, and password are replaced by placeholders and their API tokens
+deleted instead, keeping the rows they created. Use --scrub to also clear personal columns of those rows.`,
+	Run: runPurgeUser,
+}
+
+var exportUserCmd = &cobra.Command{
+	Use:   "export-user",
+	Short: "Export a user and all of their rows as JSON",
+	Long: `Export a user together with every row referencing them, found by following the foreign keys of the
+database, as a JSON object mapping table names to rows. Columns ending in _hash, such as password hashes,
+are left out.`,
+	Run: runExportUser,
+}
+
+func init() {
+	ormCmd.AddCommand(purgeUserCmd)
+	ormCmd.AddCommand(exportUserCmd)
+
+	purgeUserCmd.Flags().Int("id", 0, "ID of the user to purge")
+	purgeUserCmd.Flags().Bool("anonymize", false, "Anonymize the user and keep their rows instead of deleting them")
+	purgeUserCmd.Flags().StringSlice("scrub", nil, "Column to clear in the user's rows when anonymizing, as table.column (repeatable)")
+	purgeUserCmd.Flags().Bool("dry-run", false, "Report the rows that would change without changing them")
+	purgeUserCmd.MarkFlagRequired("id")
+
+	exportUserCmd.Flags().Int("id", 0, "ID of the user to export")
+	exportUserCmd.Flags().String("out", "", "File to write the export to (default stdout)")
+	exportUserCmd.MarkFlagRequired("id")
+	addProfileFlag(exportUserCmd)
+}
+
+func runPurgeUser(cmd *cobra.Command, args []string) {
+	id, _ := cmd.Flags().GetInt("id")
+	anonymize, _ := cmd.Flags().GetBool("anonymize")
+	scrub, _ := cmd.Flags().GetStringSlice("scrub")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if len(scrub) > 0 && !anonymize {
+		log.Error("--scrub is only used with --anonymize")
+		return
+	}
+
+	var counts map[string]int64
+	err := withDBConnection(func(conn *orm.Connection) error {
+		if err := conn.CheckWritable("purge users"); err != nil {
+			return err
+		}
+		ctx := context.Background()
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		plan, err := userPlan(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if anonymize {
+			counts, err = gdpr.Anonymize(ctx, tx, plan, id, scrub)
+		} else {
+			counts, err = gdpr.Delete(ctx, tx, plan, id)
+		}
+		if err != nil || dryRun {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		log.WithError(err).Error("Error purging user")
+		return
+	}
+
+	verb := "Deleted"
+	if anonymize {
+		verb = "Changed"
+	}
+	if dryRun {
+		verb = "Would change"
+		if !anonymize {
+			verb = "Would delete"
+		}
+	}
+	tables := make([]string, 0, len(counts))
+	for table := range counts {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		log.Infof("%s %d row(s) in %s", verb, counts[table], table)
+	}
+	switch {
+	case dryRun:
+		log.Info("Dry run: no changes were made")
+	case anonymize:
+		log.Infof("User %d anonymized", id)
+	default:
+		log.Infof("User %d purged", id)
+	}
+}
+
+func runExportUser(cmd *cobra.Command, args []string) {
+	id, _ := cmd.Flags().GetInt("id")
+	out, _ := cmd.Flags().GetString("out")
+
+	var export map[string][]map[string]interface{}
+	err := withDBConnection(func(conn *orm.Connection) error {
+		ctx := context.Background()
+		endPlan := phase("plan")
+		plan, err := userPlan(ctx, conn.GetDB(), id)
+		endPlan()
+		if err != nil {
+			return err
+		}
+		defer phase("export")()
+		export, err = gdpr.Export(ctx, conn.GetDB(), plan, id)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Error exporting user")
+		return
+	}
+
+	defer phase("write")()
+	if out == "" {
+		if err := printJSON(export); err != nil {
+			log.WithError(err).Error("Error writing export")
+		}
+		return
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		log.WithError(err).Error("Error encoding export")
+		return
+	}
+	if err := os.WriteFile(out, append(data, '\n'), 0600); err != nil {
+		log.WithError(err).Error("Error writing export")
+		return
+	}
+	log.Infof("Exported user %d to %s", id, out)
+}
+
+// userPlan checks that user id exists and returns the plan finding their rows from the foreign keys of the database.
+func userPlan(ctx context.Context, db gdpr.Querier, id int) (gdpr.Plan, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)", id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("user %d not found", id)
+	}
+	refs, err := gdpr.LoadReferences(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return gdpr.NewPlan(refs, "users", "id"), nil
+}
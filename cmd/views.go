@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/spf13/cobra"
+)
+
+var refreshViewCmd = &cobra.Command{
+	Use:   "refresh-view [name]",
+	Short: "Refresh the materialized view of a view model",
+	Long: `Run the query of a materialized view again and replace its rows with the result. The name is a view model,
+such as AuthorStat, or a view, such as author_stats. With --concurrently, readers are not locked out while the view
+refreshes; the view needs a unique index, as those of view models have. Schedule it with
+'schedule add <name> --type refresh-view --target concurrently:author_stats'.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRefreshView,
+}
+
+func init() {
+	refreshViewCmd.Flags().Bool("concurrently", false, "Refresh without locking out the readers of the view")
+	dbCmd.AddCommand(refreshViewCmd)
+}
+
+func runRefreshView(cmd *cobra.Command, args []string) {
+	concurrently, _ := cmd.Flags().GetBool("concurrently")
+
+	err := runUntilSignal(cfg, func(ctx context.Context) error {
+		return withDBConnection(func(conn *orm.Connection) error {
+			view, err := viewName(conn, args[0])
+			if err != nil {
+				return err
+			}
+			refresh := orm.ViewRefresh{View: view, Concurrently: concurrently}
+			start := time.Now()
+			if err := conn.RefreshView(ctx, refresh); err != nil {
+				return err
+			}
+			log.Infof("Refreshed %s in %s", refresh.View, time.Since(start).Round(time.Millisecond))
+			return nil
+		})
+	})
+	if err != nil {
+		log.WithError(err).Error("Error refreshing the view")
+	}
+}
+
+// viewName returns the materialized view of the view model name, or name itself if no view model has that name.
+// It returns an error for models backed by a plain view, which has nothing to refresh.
+func viewName(conn *orm.Connection, name string) (string, error) {
+	normalized, err := model.NormalizeModelName(name)
+	if err != nil {
+		return name, nil
+	}
+	defs, err := loadModelDefinitions(conn, normalized)
+	switch {
+	case err != nil || len(defs) == 0 || !defs[0].IsView():
+		return name, nil
+	case !defs[0].Materialized():
+		return "", fmt.Errorf("model %s is backed by a plain view, which is always current", defs[0].Name)
+	}
+	return defs[0].TableName(), nil
+}
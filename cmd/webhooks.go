@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/internal/serve"
+	"github.com/spf13/cobra"
+)
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Inspect webhook deliveries",
+	Long: `Inspect and retry the webhook payloads serve sent when rows changed, as kept in the webhook_deliveries table.
+Webhooks are configured in the Webhooks list of the Server section of the configuration.`,
+}
+
+var webhooksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List webhook deliveries",
+	Run:   runWebhooksList,
+}
+
+var webhooksRetryCmd = &cobra.Command{
+	Use:   "retry [id]",
+	Short: "Send a webhook delivery again",
+	Long:  `Move a webhook delivery back to the pending state with a fresh attempt count, so a running serve sends it again.`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runWebhooksRetry,
+}
+
+func init() {
+	webhooksListCmd.Flags().String("status", "", "Only list deliveries with this status (pending, delivered, failed)")
+	webhooksListCmd.Flags().Int("limit", 50, "Maximum number of deliveries to list")
+
+	webhooksCmd.AddCommand(webhooksListCmd)
+	webhooksCmd.AddCommand(webhooksRetryCmd)
+	RootCmd.AddCommand(webhooksCmd)
+}
+
+func runWebhooksList(cmd *cobra.Command, args []string) {
+	status, _ := cmd.Flags().GetString("status")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	err := withDBConnection(func(conn *orm.Connection) error {
+		list, err := serve.ListDeliveries(conn.GetDB(), status, limit)
+		if err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			log.Info("No webhook deliveries found")
+			return nil
+		}
+
+		log.Info("Webhook deliveries:")
+		for _, d := range list {
+			if d.LastError != "" {
+				log.Infof("- %d %s %s to %s [%s] attempts %d/%d, last error: %s",
+					d.ID, d.Model, d.Event, d.URL, d.Status, d.Attempts, d.MaxAttempts, d.LastError)
+			} else {
+				log.Infof("- %d %s %s to %s [%s] attempts %d/%d", d.ID, d.Model, d.Event, d.URL, d.Status, d.Attempts, d.MaxAttempts)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Error listing webhook deliveries")
+	}
+}
+
+func runWebhooksRetry(cmd *cobra.Command, args []string) {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		log.WithError(err).Error("Invalid delivery ID")
+		return
+	}
+
+	err = withDBConnection(func(conn *orm.Connection) error {
+		return serve.RetryDelivery(conn.GetDB(), id)
+	})
+	if err != nil {
+		log.WithError(err).Error("Error retrying webhook delivery")
+	} else {
+		log.Infof("Webhook delivery %d will be sent again", id)
+	}
+}
@@ -4,5 +4,5 @@ import (
 	"embed"
 )
 
-//go:embed Dockerfile config.json seeds migrations
+//go:embed Dockerfile Dockerfile.mysql config.json seeds migrations
 var EmbeddedFiles embed.FS
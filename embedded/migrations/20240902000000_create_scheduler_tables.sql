@@ -0,0 +1,27 @@
+-- Up
+-- Scheduled tasks defined through the CLI
+CREATE TABLE IF NOT EXISTS scheduled_tasks (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    cron VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Run history for every scheduled task, including tasks defined in config
+CREATE TABLE IF NOT EXISTS scheduled_task_runs (
+    id BIGSERIAL PRIMARY KEY,
+    task_name VARCHAR(100) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_task_runs_task ON scheduled_task_runs (task_name, started_at);
+
+-- Down
+DROP TABLE IF EXISTS scheduled_task_runs;
+DROP TABLE IF EXISTS scheduled_tasks;
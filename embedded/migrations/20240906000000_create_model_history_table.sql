@@ -0,0 +1,26 @@
+-- Up
+-- Every version of every model definition. Rows are only ever inserted, so the history cannot be rewritten.
+CREATE TABLE IF NOT EXISTS model_history (
+    id SERIAL PRIMARY KEY,
+    model_name VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL,
+    fields JSONB NOT NULL,
+    author VARCHAR(100) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (model_name, version)
+);
+
+CREATE OR REPLACE FUNCTION model_history_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'model_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER model_history_append_only
+    BEFORE UPDATE OR DELETE ON model_history
+    FOR EACH ROW EXECUTE FUNCTION model_history_append_only();
+
+-- Down
+DROP TABLE IF EXISTS model_history;
+DROP FUNCTION IF EXISTS model_history_append_only();
@@ -0,0 +1,10 @@
+-- Up
+-- The tenants managed by `grayv-lsm db tenant`, used when database.tenancy is set.
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(48) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,6 @@
+-- Up
+-- How the id column of each model's table gets its values: serial, identity, uuid, snowflake, or app.
+ALTER TABLE models ADD COLUMN IF NOT EXISTS id_strategy VARCHAR(20) NOT NULL DEFAULT 'serial';
+
+-- Down
+ALTER TABLE models DROP COLUMN IF EXISTS id_strategy;
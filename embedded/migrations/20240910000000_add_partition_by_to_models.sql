@@ -0,0 +1,6 @@
+-- Up
+-- How each model's table is partitioned, such as range(created_at), or empty if it is not.
+ALTER TABLE models ADD COLUMN IF NOT EXISTS partition_by VARCHAR(63) NOT NULL DEFAULT '';
+
+-- Down
+ALTER TABLE models DROP COLUMN IF EXISTS partition_by;
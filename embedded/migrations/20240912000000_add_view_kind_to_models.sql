@@ -0,0 +1,6 @@
+-- Up
+-- The kind of view backing a view model: materialized or plain, or empty if the model has a table.
+ALTER TABLE models ADD COLUMN IF NOT EXISTS view_kind VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Down
+ALTER TABLE models DROP COLUMN IF EXISTS view_kind;
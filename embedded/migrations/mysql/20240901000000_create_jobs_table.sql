@@ -0,0 +1,20 @@
+-- Up
+-- Jobs table backing the background job queue
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    queue VARCHAR(100) NOT NULL DEFAULT 'default',
+    type VARCHAR(100) NOT NULL,
+    payload JSON NOT NULL DEFAULT ('{}'),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT,
+    run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_jobs_fetch (queue, status, run_at)
+);
+
+-- Down
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,26 @@
+-- Up
+-- Scheduled tasks defined through the CLI
+CREATE TABLE IF NOT EXISTS scheduled_tasks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    cron VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    target TEXT NOT NULL DEFAULT (''),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Run history for every scheduled task, including tasks defined in config
+CREATE TABLE IF NOT EXISTS scheduled_task_runs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_name VARCHAR(100) NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    error TEXT,
+    INDEX idx_scheduled_task_runs_task (task_name, started_at)
+);
+
+-- Down
+DROP TABLE IF EXISTS scheduled_task_runs;
+DROP TABLE IF EXISTS scheduled_tasks;
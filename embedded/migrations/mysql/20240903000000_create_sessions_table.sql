@@ -0,0 +1,12 @@
+-- Up
+-- Sessions table; data holds the JSON of the session values
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    data JSON NOT NULL DEFAULT ('{}'),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_sessions_expires_at (expires_at)
+);
+
+-- Down
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,10 @@
+-- Up
+-- Token buckets of the database rate limiter; key is a reserved word in MySQL, so it is quoted
+CREATE TABLE IF NOT EXISTS rate_limits (
+    `key` VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down
+DROP TABLE IF EXISTS rate_limits;
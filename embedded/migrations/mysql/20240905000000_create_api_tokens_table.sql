@@ -0,0 +1,21 @@
+-- Up
+-- API tokens authenticating machine clients on behalf of a user. Only the SHA-256 hash of a token is stored.
+-- MySQL has no arrays, so scopes holds a JSON array, and it ignores REFERENCES on a column, so the foreign key is a
+-- table constraint.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    scopes JSON NOT NULL DEFAULT ('[]'),
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_api_tokens_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Down
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,23 @@
+-- Up
+-- Every version of every model definition. Rows are only ever inserted, so the history cannot be rewritten.
+CREATE TABLE IF NOT EXISTS model_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    model_name VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL,
+    fields JSON NOT NULL,
+    author VARCHAR(100) NOT NULL,
+    note TEXT NOT NULL DEFAULT (''),
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (model_name, version)
+);
+
+CREATE TRIGGER model_history_append_only_update
+    BEFORE UPDATE ON model_history
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'model_history is append-only';
+
+CREATE TRIGGER model_history_append_only_delete
+    BEFORE DELETE ON model_history
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'model_history is append-only';
+
+-- Down
+DROP TABLE IF EXISTS model_history;
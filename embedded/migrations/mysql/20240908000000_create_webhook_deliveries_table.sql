@@ -0,0 +1,23 @@
+-- Up
+-- The webhook payloads sent by `grayv-lsm serve` when rows change, kept for inspection and retries.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    model VARCHAR(255) NOT NULL,
+    event VARCHAR(20) NOT NULL,
+    url TEXT NOT NULL,
+    payload JSON NOT NULL,
+    signature VARCHAR(80) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    response_status INTEGER,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP NULL,
+    created_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at)
+);
+
+-- Down
+DROP TABLE IF EXISTS webhook_deliveries;
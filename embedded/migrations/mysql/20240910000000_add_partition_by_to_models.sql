@@ -0,0 +1,6 @@
+-- Up
+-- How each model's table is partitioned. Generated MySQL tables are not partitioned, so it stays empty.
+ALTER TABLE models ADD COLUMN partition_by VARCHAR(63) NOT NULL DEFAULT '';
+
+-- Down
+ALTER TABLE models DROP COLUMN partition_by;
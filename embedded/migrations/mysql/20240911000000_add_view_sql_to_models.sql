@@ -0,0 +1,6 @@
+-- Up
+-- The query of the view backing a read-only model, or empty if the model has a table.
+ALTER TABLE models ADD COLUMN view_sql TEXT NOT NULL DEFAULT ('');
+
+-- Down
+ALTER TABLE models DROP COLUMN view_sql;
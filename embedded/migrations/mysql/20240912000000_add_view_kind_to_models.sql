@@ -0,0 +1,6 @@
+-- Up
+-- The kind of view backing a view model, or empty if the model has a table. MySQL views are always plain.
+ALTER TABLE models ADD COLUMN view_kind VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Down
+ALTER TABLE models DROP COLUMN view_kind;
@@ -0,0 +1,27 @@
+-- Up
+-- Every version of every model definition. Rows are only ever inserted, so the history cannot be rewritten.
+CREATE TABLE IF NOT EXISTS model_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    model_name VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL,
+    fields TEXT NOT NULL,
+    author VARCHAR(100) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (model_name, version)
+);
+
+CREATE TRIGGER IF NOT EXISTS model_history_append_only_update
+    BEFORE UPDATE ON model_history
+BEGIN
+    SELECT RAISE(ABORT, 'model_history is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS model_history_append_only_delete
+    BEFORE DELETE ON model_history
+BEGIN
+    SELECT RAISE(ABORT, 'model_history is append-only');
+END;
+
+-- Down
+DROP TABLE IF EXISTS model_history;
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+)
+
+// CIProvider selects the CI service a pipeline is generated for.
+type CIProvider string
+
+const (
+	// CIGitHub generates a GitHub Actions workflow in .github/workflows/ci.yml.
+	CIGitHub CIProvider = "github"
+	// CIGitLab generates a GitLab CI/CD pipeline in .gitlab-ci.yml.
+	CIGitLab CIProvider = "gitlab"
+)
+
+// ParseCIProvider returns the CIProvider named by name: "github" or "gitlab".
+func ParseCIProvider(name string) (CIProvider, error) {
+	switch CIProvider(name) {
+	case CIGitHub, CIGitLab:
+		return CIProvider(name), nil
+	default:
+		return "", fmt.Errorf("unknown CI provider %q: use github or gitlab", name)
+	}
+}
+
+// ciDatabaseImage is the database service of generated pipelines: PostgreSQL with pgvector, like the image
+// built by db build.
+const ciDatabaseImage = "pgvector/pgvector:pg13"
+
+// ciData is passed to the pipeline templates. The database settings match the service of the pipeline and are
+// written to config.json by the pipeline before grayv-lsm runs.
+type ciData struct {
+	Image    string
+	Host     string
+	User     string
+	Password string
+	Database string
+}
+
+// githubWorkflowTemplate runs the tests of the app against a database service provisioned by grayv-lsm.
+const githubWorkflowTemplate = `name: CI
+
+on:
+  push:
+  pull_request:
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    services:
+      postgres:
+        image: [[.Image]]
+        env:
+          POSTGRES_USER: [[.User]]
+          POSTGRES_PASSWORD: [[.Password]]
+        ports:
+          - 5432:5432
+    env:
+      DATABASE_URL: postgres://[[.User]]:[[.Password]]@[[.Host]]:5432/[[.Database]]?sslmode=disable
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - name: Install grayv-lsm
+        run: go install github.com/ooyeku/grayv-lsm@latest
+      - name: Provision the database
+        run: |
+          grayv-lsm config set database.host [[.Host]]
+          grayv-lsm config set database.user [[.User]]
+          grayv-lsm config set database.password [[.Password]]
+          grayv-lsm config set database.name [[.Database]]
+          grayv-lsm db wait --timeout 60s
+          grayv-lsm db provision
+      - name: Test
+        run: go test ./...
+`
+
+// gitlabPipelineTemplate runs the tests of the app against a database service provisioned by grayv-lsm.
+// GitLab reaches services by their alias rather than localhost.
+const gitlabPipelineTemplate = `test:
+  image: golang:1
+  services:
+    - name: [[.Image]]
+      alias: [[.Host]]
+  variables:
+    POSTGRES_USER: [[.User]]
+    POSTGRES_PASSWORD: [[.Password]]
+    DATABASE_URL: postgres://[[.User]]:[[.Password]]@[[.Host]]:5432/[[.Database]]?sslmode=disable
+  before_script:
+    - go install github.com/ooyeku/grayv-lsm@latest
+    - grayv-lsm config set database.host [[.Host]]
+    - grayv-lsm config set database.user [[.User]]
+    - grayv-lsm config set database.password [[.Password]]
+    - grayv-lsm config set database.name [[.Database]]
+    - grayv-lsm db wait --timeout 60s
+    - grayv-lsm db provision
+  script:
+    - go test ./...
+`
+
+// CreateCI writes a CI pipeline for provider into the app with the given name. The pipeline starts a
+// PostgreSQL service, provisions the database with db wait and db provision, and runs go test, which can
+// reach the database through DATABASE_URL.
+func (ac *AppCreator) CreateCI(name string, provider CIProvider, w *codegen.Writer) error {
+	appName := name + "_grav"
+	if _, err := os.Stat(appName); err != nil && !w.Preview() {
+		return fmt.Errorf("app %s does not exist", appName)
+	}
+
+	data := ciData{Image: ciDatabaseImage, Host: "localhost", User: "postgres", Password: "postgres", Database: "grayv"}
+	var path, content string
+	switch provider {
+	case CIGitHub:
+		path, content = filepath.Join(".github", "workflows", "ci.yml"), githubWorkflowTemplate
+	case CIGitLab:
+		path, content = ".gitlab-ci.yml", gitlabPipelineTemplate
+		data.Host = "postgres"
+	default:
+		return fmt.Errorf("unknown CI provider %q", provider)
+	}
+	if err := writeScaffoldFile(filepath.Join(appName, path), content, data, w); err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCI(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	assert.NoError(t, os.MkdirAll("shop_grav", 0755))
+	creator := &AppCreator{}
+	assert.NoError(t, creator.CreateCI("shop", CIGitHub, nil))
+	assert.NoError(t, creator.CreateCI("shop", CIGitLab, nil))
+
+	workflow, err := os.ReadFile(filepath.Join("shop_grav", ".github", "workflows", "ci.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(workflow), "grayv-lsm db wait")
+	assert.Contains(t, string(workflow), "grayv-lsm config set database.host localhost")
+
+	pipeline, err := os.ReadFile(filepath.Join("shop_grav", ".gitlab-ci.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(pipeline), "grayv-lsm db provision")
+	assert.Contains(t, string(pipeline), "grayv-lsm config set database.host postgres", "GitLab services are reached by alias")
+
+	_, err = ParseCIProvider("jenkins")
+	assert.Error(t, err)
+}
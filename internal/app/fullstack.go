@@ -0,0 +1,637 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+)
+
+// Template selects the files scaffolded into a new app.
+type Template string
+
+const (
+	// TemplateAPI scaffolds a Go server only.
+	TemplateAPI Template = "api"
+	// TemplateFullstack adds a frontend in web/, served by the app next to the CRUD endpoints of grayv-lsm serve.
+	TemplateFullstack Template = "fullstack"
+)
+
+// Frontend selects the frontend scaffolded by TemplateFullstack.
+type Frontend string
+
+const (
+	// FrontendHTMX renders HTML fragments in the Go app and swaps them into a static page with htmx.
+	FrontendHTMX Frontend = "htmx"
+	// FrontendReact builds a single-page React application with Vite.
+	FrontendReact Frontend = "react"
+)
+
+// ParseTemplate returns the Template named by name: "api" or "fullstack". An empty name is TemplateAPI.
+func ParseTemplate(name string) (Template, error) {
+	switch Template(name) {
+	case "":
+		return TemplateAPI, nil
+	case TemplateAPI, TemplateFullstack:
+		return Template(name), nil
+	default:
+		return "", fmt.Errorf("unknown app template %q: use api or fullstack", name)
+	}
+}
+
+// ParseFrontend returns the Frontend named by name: "htmx" or "react". An empty name is FrontendHTMX.
+func ParseFrontend(name string) (Frontend, error) {
+	switch Frontend(name) {
+	case "":
+		return FrontendHTMX, nil
+	case FrontendHTMX, FrontendReact:
+		return Frontend(name), nil
+	default:
+		return "", fmt.Errorf("unknown frontend %q: use htmx or react", name)
+	}
+}
+
+// fullstackData is passed to the templates of TemplateFullstack.
+type fullstackData struct {
+	Name string
+	HTMX bool
+}
+
+// fullstackMainTemplate replaces the main.go of a fullstack app. The app serves the built frontend and
+// forwards /api/ to grayv-lsm serve, so the frontend talks to the CRUD endpoints through a single origin.
+const fullstackMainTemplate = `package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"syscall"
+	"time"
+[[- if .HTMX]]
+
+	"[[.Name]]/internal/handlers"
+[[- end]]
+)
+
+// shutdownTimeout bounds how long requests in flight may take to finish once the app is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	api, err := url.Parse(getenv("GRAYV_API_URL", "http://localhost:8080"))
+	if err != nil {
+		log.Fatalf("invalid GRAYV_API_URL: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	// The CRUD endpoints of every model are served by 'grayv-lsm serve'.
+	mux.Handle("/api/", httputil.NewSingleHostReverseProxy(api))
+[[- if .HTMX]]
+	ui := handlers.NewUI(api.String())
+	mux.HandleFunc("GET /ui/models", ui.Models)
+	mux.HandleFunc("GET /ui/{model}", ui.Rows)
+	mux.HandleFunc("POST /ui/{model}", ui.Create)
+	mux.HandleFunc("DELETE /ui/{model}/{id}", ui.Delete)
+[[- end]]
+	mux.Handle("/", spa(getenv("WEB_DIR", filepath.Join("web", "dist"))))
+	// /healthz reports that the process is up, /readyz that it can reach the API.
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.JoinPath("api", "models").String(), nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			http.Error(w, "API unavailable: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			http.Error(w, "API unavailable: "+resp.Status, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	server := &http.Server{Addr: ":" + getenv("PORT", "3000"), Handler: mux}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Printf("Starting [[.Name]] on %s with the API at %s", server.Addr, api)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+}
+
+// spa serves the files in dir. Paths without a file extension that match no file are answered with
+// index.html, so the frontend can handle its own routes.
+func spa(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		if _, err := os.Stat(name); os.IsNotExist(err) && path.Ext(r.URL.Path) == "" {
+			http.ServeFile(w, r, filepath.Join(dir, "index.html"))
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+func getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+`
+
+// htmxHandlersTemplate renders the HTML fragments of the htmx frontend from the JSON API.
+const htmxHandlersTemplate = `package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// UI renders the HTML fragments swapped into the page by htmx, from the JSON CRUD endpoints of
+// 'grayv-lsm serve' at API.
+type UI struct {
+	API    string
+	Client *http.Client
+}
+
+// NewUI creates the fragment handlers for the API at the given base URL.
+func NewUI(api string) *UI {
+	return &UI{API: strings.TrimSuffix(api, "/"), Client: http.DefaultClient}
+}
+
+type field struct {
+	Name string ` + "`json:\"name\"`" + `
+	Type string ` + "`json:\"type\"`" + `
+}
+
+type modelInfo struct {
+	Name   string  ` + "`json:\"name\"`" + `
+	Fields []field ` + "`json:\"fields\"`" + `
+}
+
+// Path returns the API path of the model.
+func (m modelInfo) Path() string {
+	return strings.ToLower(m.Name)
+}
+
+var templates = template.Must(template.New("models").Parse(` + "`" + `
+<ul>
+{{- range .}}
+  <li><a href="#" hx-get="/ui/{{.Path}}" hx-target="#rows">{{.Name}}</a></li>
+{{- else}}
+  <li>No models yet. Create one with grayv-lsm model create.</li>
+{{- end}}
+</ul>
+{{define "rows"}}
+<h2>{{.Model.Name}}</h2>
+<form hx-post="/ui/{{.Model.Path}}" hx-target="#rows">
+{{- range .Model.Fields}}
+  <label>{{.Name}}
+  {{- if eq .Type "bool"}} <input type="checkbox" name="{{.Name}}" value="true">
+  {{- else}} <input name="{{.Name}}">{{end}}</label>
+{{- end}}
+  <button type="submit">Add</button>
+</form>
+<table>
+  <thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}<th></th></tr></thead>
+  <tbody>
+  {{- range $row := .Rows}}
+    <tr>
+      {{- range $.Columns}}<td>{{index $row .}}</td>{{end}}
+      <td><button hx-delete="/ui/{{$.Model.Path}}/{{index $row "id"}}" hx-target="closest tr" hx-swap="outerHTML">Delete</button></td>
+    </tr>
+  {{- end}}
+  </tbody>
+</table>
+{{end}}` + "`" + `))
+
+// Models renders the list of models.
+func (u *UI) Models(w http.ResponseWriter, r *http.Request) {
+	models, err := u.models(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	templates.Execute(w, models)
+}
+
+// Rows renders the rows of a model, with a form adding a row.
+func (u *UI) Rows(w http.ResponseWriter, r *http.Request) {
+	models, err := u.models(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	var current *modelInfo
+	for i := range models {
+		if models[i].Path() == r.PathValue("model") {
+			current = &models[i]
+		}
+	}
+	if current == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var rows []map[string]interface{}
+	if err := u.call(r, http.MethodGet, "/api/"+current.Path(), nil, &rows); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	columns := []string{"id"}
+	for _, f := range current.Fields {
+		columns = append(columns, f.Name)
+	}
+	templates.ExecuteTemplate(w, "rows", map[string]interface{}{"Model": current, "Columns": columns, "Rows": rows})
+}
+
+// Create adds a row from the submitted form and renders the rows again.
+func (u *UI) Create(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	values := make(map[string]interface{})
+	for name := range r.PostForm {
+		values[name] = r.PostForm.Get(name)
+	}
+	if err := u.call(r, http.MethodPost, "/api/"+r.PathValue("model"), values, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	u.Rows(w, r)
+}
+
+// Delete deletes a row. The empty response replaces the row in the page.
+func (u *UI) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := u.call(r, http.MethodDelete, "/api/"+r.PathValue("model")+"/"+r.PathValue("id"), nil, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (u *UI) models(r *http.Request) ([]modelInfo, error) {
+	var models []modelInfo
+	err := u.call(r, http.MethodGet, "/api/models", nil, &models)
+	return models, err
+}
+
+// call sends a request with the JSON encoding of body to the API and decodes the JSON response into out.
+func (u *UI) call(r *http.Request, method, path string, body, out interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(r.Context(), method, u.API+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Error string ` + "`json:\"error\"`" + `
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("API error %d: %s", resp.StatusCode, apiErr.Error)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+`
+
+const htmxIndexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>[[.Name]]</title>
+  <link rel="stylesheet" href="/style.css">
+  <script src="/htmx.min.js" defer></script>
+</head>
+<body>
+  <header><h1>[[.Name]]</h1></header>
+  <main>
+    <nav hx-get="/ui/models" hx-trigger="load"></nav>
+    <section id="rows"><p>Pick a model to see its rows.</p></section>
+  </main>
+</body>
+</html>
+`
+
+const htmxPackageTemplate = `{
+  "name": "[[.Name]]-web",
+  "private": true,
+  "type": "module",
+  "scripts": {
+    "build": "node build.js"
+  },
+  "dependencies": {
+    "htmx.org": "^2.0.4"
+  }
+}
+`
+
+const htmxBuildTemplate = `// Copies the page, its styles, and htmx into dist/, which the Go app serves.
+import { cpSync, mkdirSync } from "node:fs";
+
+mkdirSync("dist", { recursive: true });
+for (const file of ["index.html", "style.css"]) {
+  cpSync(file, "dist/" + file);
+}
+cpSync("node_modules/htmx.org/dist/htmx.min.js", "dist/htmx.min.js");
+`
+
+const reactPackageTemplate = `{
+  "name": "[[.Name]]-web",
+  "private": true,
+  "type": "module",
+  "scripts": {
+    "dev": "vite",
+    "build": "vite build"
+  },
+  "dependencies": {
+    "react": "^18.3.1",
+    "react-dom": "^18.3.1"
+  },
+  "devDependencies": {
+    "@vitejs/plugin-react": "^4.3.4",
+    "vite": "^5.4.11"
+  }
+}
+`
+
+const reactViteConfigTemplate = `import { defineConfig } from "vite";
+import react from "@vitejs/plugin-react";
+
+// During development, 'npm run dev' serves the frontend with hot reloading and forwards /api/ to the Go app.
+export default defineConfig({
+  plugins: [react()],
+  server: {
+    proxy: { "/api": "http://localhost:3000" },
+  },
+});
+`
+
+const reactIndexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>[[.Name]]</title>
+  <link rel="stylesheet" href="/src/style.css">
+</head>
+<body>
+  <div id="root"></div>
+  <script type="module" src="/src/main.jsx"></script>
+</body>
+</html>
+`
+
+const reactMainTemplate = `import { StrictMode } from "react";
+import { createRoot } from "react-dom/client";
+import App from "./App.jsx";
+
+createRoot(document.getElementById("root")).render(
+  <StrictMode>
+    <App title="[[.Name]]" />
+  </StrictMode>
+);
+`
+
+const reactAppTemplate = `import { useEffect, useState } from "react";
+
+// api calls the CRUD endpoints of 'grayv-lsm serve', forwarded by the Go app.
+async function api(path, options = {}) {
+  const response = await fetch("/api/" + path, {
+    ...options,
+    headers: { "Content-Type": "application/json" },
+  });
+  if (!response.ok) {
+    const body = await response.json().catch(() => ({}));
+    throw new Error(body.error || response.statusText);
+  }
+  return response.status === 204 ? null : response.json();
+}
+
+// toValue converts a form value to the JSON type of the field.
+function toValue(field, value) {
+  switch (field.type) {
+    case "int":
+    case "float64":
+      return value === "" ? null : Number(value);
+    case "bool":
+      return value === "true";
+    default:
+      return value;
+  }
+}
+
+export default function App({ title }) {
+  const [models, setModels] = useState([]);
+  const [selected, setSelected] = useState(null);
+  const [rows, setRows] = useState([]);
+  const [error, setError] = useState("");
+
+  useEffect(() => {
+    api("models").then(setModels).catch((e) => setError(e.message));
+  }, []);
+
+  useEffect(() => {
+    if (selected) {
+      api(selected.name.toLowerCase()).then(setRows).catch((e) => setError(e.message));
+    }
+  }, [selected]);
+
+  async function create(event) {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    const values = {};
+    for (const field of selected.fields) {
+      values[field.name] = toValue(field, form.get(field.name) ?? "");
+    }
+    try {
+      const row = await api(selected.name.toLowerCase(), { method: "POST", body: JSON.stringify(values) });
+      setRows([...rows, row]);
+      event.target.reset();
+      setError("");
+    } catch (e) {
+      setError(e.message);
+    }
+  }
+
+  async function remove(id) {
+    try {
+      await api(selected.name.toLowerCase() + "/" + id, { method: "DELETE" });
+      setRows(rows.filter((row) => row.id !== id));
+    } catch (e) {
+      setError(e.message);
+    }
+  }
+
+  return (
+    <>
+      <header><h1>{title}</h1></header>
+      <main>
+        <nav>
+          <ul>
+            {models.map((m) => (
+              <li key={m.name}>
+                <a href="#" onClick={() => setSelected(m)}>{m.name}</a>
+              </li>
+            ))}
+          </ul>
+          {models.length === 0 && <p>No models yet. Create one with grayv-lsm model create.</p>}
+        </nav>
+        <section>
+          {error && <p className="error">{error}</p>}
+          {selected ? (
+            <>
+              <h2>{selected.name}</h2>
+              <form onSubmit={create}>
+                {selected.fields.map((f) => (
+                  <label key={f.name}>
+                    {f.name}{" "}
+                    {f.type === "bool" ? <input type="checkbox" name={f.name} value="true" /> : <input name={f.name} />}
+                  </label>
+                ))}
+                <button type="submit">Add</button>
+              </form>
+              <table>
+                <thead>
+                  <tr>
+                    <th>id</th>
+                    {selected.fields.map((f) => <th key={f.name}>{f.name}</th>)}
+                    <th></th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {rows.map((row) => (
+                    <tr key={row.id}>
+                      <td>{row.id}</td>
+                      {selected.fields.map((f) => <td key={f.name}>{String(row[f.name] ?? "")}</td>)}
+                      <td><button onClick={() => remove(row.id)}>Delete</button></td>
+                    </tr>
+                  ))}
+                </tbody>
+              </table>
+            </>
+          ) : (
+            <p>Pick a model to see its rows.</p>
+          )}
+        </section>
+      </main>
+    </>
+  );
+}
+`
+
+const styleTemplate = `body { font-family: system-ui, sans-serif; margin: 0; color: #222; }
+header { background: #2d3748; color: #fff; padding: 0.5rem 1.5rem; }
+main { display: flex; gap: 2rem; padding: 1.5rem; }
+nav { min-width: 12rem; }
+form { display: flex; flex-wrap: wrap; gap: 0.5rem; margin-bottom: 1rem; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 0.25rem 0.5rem; text-align: left; }
+.error { color: #c53030; }
+`
+
+// CreateFrontend scaffolds the frontend of TemplateFullstack into the app with the given name: web/ holds
+// the frontend and its build tooling, and cmd/main.go is replaced by a server for the built frontend that
+// forwards /api/ to grayv-lsm serve. HTMX frontends also get internal/handlers/ui.go rendering their fragments.
+func (ac *AppCreator) CreateFrontend(name string, frontend Frontend, w *codegen.Writer) error {
+	appName := name + "_grav"
+	if _, err := os.Stat(appName); err != nil && !w.Preview() {
+		return fmt.Errorf("app %s does not exist", appName)
+	}
+
+	files := [][2]string{{"cmd/main.go", fullstackMainTemplate}}
+	switch frontend {
+	case FrontendHTMX:
+		files = append(files,
+			[2]string{"internal/handlers/ui.go", htmxHandlersTemplate},
+			[2]string{"web/index.html", htmxIndexTemplate},
+			[2]string{"web/style.css", styleTemplate},
+			[2]string{"web/package.json", htmxPackageTemplate},
+			[2]string{"web/build.js", htmxBuildTemplate})
+	case FrontendReact:
+		files = append(files,
+			[2]string{"web/index.html", reactIndexTemplate},
+			[2]string{"web/package.json", reactPackageTemplate},
+			[2]string{"web/vite.config.js", reactViteConfigTemplate},
+			[2]string{"web/src/main.jsx", reactMainTemplate},
+			[2]string{"web/src/App.jsx", reactAppTemplate},
+			[2]string{"web/src/style.css", styleTemplate})
+	default:
+		return fmt.Errorf("unknown frontend %q", frontend)
+	}
+
+	data := fullstackData{Name: appName, HTMX: frontend == FrontendHTMX}
+	for _, file := range files {
+		path := filepath.Join(appName, filepath.FromSlash(file[0]))
+		if err := writeScaffoldFile(path, file[1], data, w); err != nil {
+			return fmt.Errorf("failed to create %s: %w", file[0], err)
+		}
+	}
+	return nil
+}
+
+// writeScaffoldFile renders content with data and writes it to path through w.
+// Templates use [[ ]] as delimiters, so the {{ }} of the Go and JSX templates they contain pass through.
+func writeScaffoldFile(path, content string, data interface{}, w *codegen.Writer) error {
+	tmpl, err := template.New(filepath.Base(path)).Delims("[[", "]]").Parse(content)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, ".go") {
+		return w.WriteGoFile(path, buf.Bytes())
+	}
+	return w.WriteFile(path, buf.Bytes())
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateFrontend(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	creator := &AppCreator{}
+	assert.Error(t, creator.CreateFrontend("shop", FrontendHTMX, nil), "the app must exist")
+
+	assert.NoError(t, os.MkdirAll("shop_grav", 0755))
+	assert.NoError(t, creator.CreateFrontend("shop", FrontendHTMX, nil))
+	main, err := os.ReadFile(filepath.Join("shop_grav", "cmd", "main.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(main), `"shop_grav/internal/handlers"`)
+	assert.FileExists(t, filepath.Join("shop_grav", "internal", "handlers", "ui.go"))
+	assert.FileExists(t, filepath.Join("shop_grav", "web", "build.js"))
+
+	assert.NoError(t, os.MkdirAll("blog_grav", 0755))
+	assert.NoError(t, creator.CreateFrontend("blog", FrontendReact, nil))
+	main, _ = os.ReadFile(filepath.Join("blog_grav", "cmd", "main.go"))
+	assert.NotContains(t, string(main), "internal/handlers")
+	assert.NoFileExists(t, filepath.Join("blog_grav", "internal", "handlers", "ui.go"))
+	assert.FileExists(t, filepath.Join("blog_grav", "web", "src", "App.jsx"))
+
+	var pkg map[string]interface{}
+	content, _ := os.ReadFile(filepath.Join("blog_grav", "web", "package.json"))
+	assert.NoError(t, json.Unmarshal(content, &pkg), "package.json is not stamped")
+	assert.Equal(t, "blog_grav-web", pkg["name"])
+}
+
+func TestParseFrontend(t *testing.T) {
+	frontend, err := ParseFrontend("")
+	assert.NoError(t, err)
+	assert.Equal(t, FrontendHTMX, frontend)
+
+	_, err = ParseFrontend("vue")
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// App describes a Grav app found in the current directory.
+type App struct {
+	// Name is the app directory name, including the "_grav" suffix.
+	Name string
+	// Dir is the path of the app directory.
+	Dir string
+	// ModulePath is the Go module path declared in the app's go.mod.
+	ModulePath string
+}
+
+// ModelsDir returns the directory generated model code is written to.
+func (a *App) ModelsDir() string {
+	return filepath.Join(a.Dir, "internal", "models")
+}
+
+// FindApp looks up the Grav app with the given name among the apps returned by ListApps.
+// The name may be given with or without the "_grav" suffix.
+func (ac *AppCreator) FindApp(name string) (*App, error) {
+	appName := name
+	if !strings.HasSuffix(appName, "_grav") {
+		appName += "_grav"
+	}
+
+	apps, err := ac.ListApps()
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range apps {
+		if candidate != appName {
+			continue
+		}
+
+		modulePath, err := readModulePath(filepath.Join(appName, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read module path of app %s: %w", appName, err)
+		}
+		return &App{Name: appName, Dir: appName, ModulePath: modulePath}, nil
+	}
+
+	return nil, fmt.Errorf("app %s not found; create it with `grayv-lsm app create %s`", appName, strings.TrimSuffix(appName, "_grav"))
+}
+
+// readModulePath returns the module path declared in a go.mod file.
+func readModulePath(goModPath string) (string, error) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no module directive in %s", goModPath)
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindApp(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join("shop_grav", "internal", "models"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join("shop_grav", "go.mod"), []byte("module example.com/shop\n\ngo 1.22\n"), 0644))
+
+	creator := &AppCreator{}
+	for _, name := range []string{"shop", "shop_grav"} {
+		found, err := creator.FindApp(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, "example.com/shop", found.ModulePath)
+			assert.Equal(t, filepath.Join("shop_grav", "internal", "models"), found.ModelsDir())
+		}
+	}
+
+	_, err := creator.FindApp("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+)
+
+// TaskRunner selects the format of the task file generated for an app.
+type TaskRunner string
+
+const (
+	// TaskRunnerMake generates a Makefile.
+	TaskRunnerMake TaskRunner = "make"
+	// TaskRunnerTask generates a Taskfile.yml for Task (taskfile.dev).
+	TaskRunnerTask TaskRunner = "task"
+)
+
+// ParseTaskRunner returns the TaskRunner named by name: "make" or "task".
+func ParseTaskRunner(name string) (TaskRunner, error) {
+	switch TaskRunner(name) {
+	case TaskRunnerMake, TaskRunnerTask:
+		return TaskRunner(name), nil
+	default:
+		return "", fmt.Errorf("unknown task runner %q: use make or task", name)
+	}
+}
+
+// projectTask is a command sequence of the generated task file. Dep runs first.
+type projectTask struct {
+	Name string
+	Desc string
+	Dep  string
+	Cmds []string
+}
+
+// projectTasks are the common grayv-lsm workflows of an app, the same for every task runner.
+var projectTasks = []projectTask{
+	{
+		Name: "provision",
+		Desc: "Start a fresh database container and run the migrations",
+		Cmds: []string{"grayv-lsm db build", "grayv-lsm db start", "grayv-lsm db wait", "grayv-lsm db provision"},
+	},
+	{
+		Name: "reset",
+		Desc: "Recreate the database and load the seeds in seeds/",
+		Dep:  "provision",
+		Cmds: []string{"[ ! -d seeds ] || grayv-lsm db seed --dir seeds"},
+	},
+	{Name: "migrate", Desc: "Run the pending migrations", Cmds: []string{"grayv-lsm db migrate"}},
+	{Name: "test", Desc: "Run the tests", Cmds: []string{"go test ./..."}},
+	{Name: "serve", Desc: "Serve the CRUD API of the models", Cmds: []string{"grayv-lsm serve"}},
+}
+
+const makefileTemplate = `# Common grayv-lsm workflows: run 'make <target>'.
+.PHONY:[[range .]] [[.Name]][[end]]
+.DEFAULT_GOAL := test
+[[range .]]
+# [[.Desc]]
+[[.Name]]:[[if .Dep]] [[.Dep]][[end]]
+[[- range .Cmds]]
+	[[.]]
+[[- end]]
+[[end]]`
+
+const taskfileTemplate = `# Common grayv-lsm workflows: run 'task <name>', or 'task --list' to list them.
+version: '3'
+
+tasks:
+  default:
+    cmds:
+      - task: test
+[[- range .]]
+
+  [[.Name]]:
+    desc: [[.Desc]]
+    cmds:
+[[- if .Dep]]
+      - task: [[.Dep]]
+[[- end]]
+[[- range .Cmds]]
+      - [[printf "%q" .]]
+[[- end]]
+[[- end]]
+`
+
+// CreateTaskFile writes a Makefile or Taskfile.yml into the app with the given name, with targets running the
+// common grayv-lsm command sequences: provision, reset, migrate, test, and serve.
+func (ac *AppCreator) CreateTaskFile(name string, runner TaskRunner, w *codegen.Writer) error {
+	appName := name + "_grav"
+	if _, err := os.Stat(appName); err != nil && !w.Preview() {
+		return fmt.Errorf("app %s does not exist", appName)
+	}
+
+	var path, content string
+	switch runner {
+	case TaskRunnerMake:
+		path, content = "Makefile", makefileTemplate
+	case TaskRunnerTask:
+		path, content = "Taskfile.yml", taskfileTemplate
+	default:
+		return fmt.Errorf("unknown task runner %q", runner)
+	}
+	if err := writeScaffoldFile(filepath.Join(appName, path), content, projectTasks, w); err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	return nil
+}
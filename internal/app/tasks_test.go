@@ -0,0 +1,34 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTaskFile(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	assert.NoError(t, os.MkdirAll("shop_grav", 0755))
+	creator := &AppCreator{}
+	assert.NoError(t, creator.CreateTaskFile("shop", TaskRunnerMake, nil))
+	assert.NoError(t, creator.CreateTaskFile("shop", TaskRunnerTask, nil))
+
+	makefile, err := os.ReadFile(filepath.Join("shop_grav", "Makefile"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(makefile), "reset: provision\n\t[ ! -d seeds ] || grayv-lsm db seed --dir seeds\n")
+	assert.Contains(t, string(makefile), "migrate:\n\tgrayv-lsm db migrate\n")
+
+	taskfile, err := os.ReadFile(filepath.Join("shop_grav", "Taskfile.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(taskfile), "  reset:\n    desc: Recreate the database and load the seeds in seeds/\n    cmds:\n      - task: provision\n")
+	assert.Contains(t, string(taskfile), `- "grayv-lsm serve"`)
+
+	_, err = ParseTaskRunner("just")
+	assert.Error(t, err)
+}
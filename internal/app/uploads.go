@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+)
+
+// uploadsTemplate is the upload handler scaffolded into apps created with --uploads.
+// It stores files through pkg/storage, so the same code works with the local directory
+// and the MinIO container started by `grayv-lsm storage start`.
+const uploadsTemplate = `package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ooyeku/grayv-lsm/pkg/storage"
+)
+
+// MaxUploadSize is the largest file accepted by Upload, in bytes.
+const MaxUploadSize = 32 << 20
+
+// Uploads handles file attachments of {{.}}. Register its handlers with:
+//
+//	cfg, _ := config.LoadConfig()
+//	store, _ := storage.FromConfig(cfg.Storage)
+//	uploads := handlers.NewUploads(store)
+//	http.HandleFunc("POST /uploads", uploads.Upload)
+//	http.HandleFunc("GET /uploads/{key...}", uploads.Download)
+//	http.HandleFunc("DELETE /uploads/{key...}", uploads.Delete)
+type Uploads struct {
+	Store storage.Storage
+}
+
+// NewUploads creates the upload handlers for the given storage.
+func NewUploads(store storage.Storage) *Uploads {
+	return &Uploads{Store: store}
+}
+
+// uploadResponse is returned by Upload. Store Key with the record the file belongs to.
+type uploadResponse struct {
+	Key         string ` + "`json:\"key\"`" + `
+	Size        int64  ` + "`json:\"size\"`" + `
+	ContentType string ` + "`json:\"content_type\"`" + `
+}
+
+// Upload stores the file sent in the "file" field of a multipart form under a new random key.
+func (u *Uploads) Upload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "a file is required in the \"file\" field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	key, err := storage.NewKey("uploads", header.Filename)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	contentType := header.Header.Get("Content-Type")
+	if err := u.Store.Put(r.Context(), key, file, header.Size, contentType); err != nil {
+		http.Error(w, "failed to store file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(uploadResponse{Key: key, Size: header.Size, ContentType: contentType})
+}
+
+// Download writes the file stored under the key in the path.
+func (u *Uploads) Download(w http.ResponseWriter, r *http.Request) {
+	body, object, err := u.Store.Get(r.Context(), r.PathValue("key"))
+	if errors.Is(err, storage.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	if object.ContentType != "" {
+		w.Header().Set("Content-Type", object.ContentType)
+	}
+	if object.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, body)
+}
+
+// Delete removes the file stored under the key in the path.
+func (u *Uploads) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := u.Store.Delete(r.Context(), r.PathValue("key")); err != nil {
+		http.Error(w, "failed to delete file", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+`
+
+// CreateUploadHandlers scaffolds internal/handlers/uploads.go in the app with the given name,
+// adding handlers that upload, download, and delete file attachments through pkg/storage.
+// The app must require github.com/ooyeku/grayv-lsm to build the generated file.
+func (ac *AppCreator) CreateUploadHandlers(name string, w *codegen.Writer) error {
+	appName := name + "_grav"
+	if _, err := os.Stat(appName); err != nil && !w.Preview() {
+		return fmt.Errorf("app %s does not exist", appName)
+	}
+
+	if !w.Preview() {
+		if err := os.MkdirAll(filepath.Join(appName, "internal", "handlers"), 0755); err != nil {
+			return fmt.Errorf("failed to create directory internal/handlers: %w", err)
+		}
+	}
+	return ac.createFileFromTemplate(filepath.Join(appName, "internal", "handlers", "uploads.go"), uploadsTemplate, appName, w)
+}
@@ -0,0 +1,22 @@
+package codegen
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/imports"
+)
+
+// FormatSource formats Go source the way goimports does: it applies gofmt, removes unused imports,
+// adds missing standard library imports, and groups the import block.
+// filename is only used to resolve imports relative to the file's location.
+func FormatSource(filename string, src []byte) ([]byte, error) {
+	formatted, err := imports.Process(filename, src, &imports.Options{
+		Comments:  true,
+		TabIndent: true,
+		TabWidth:  8,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", filename, err)
+	}
+	return formatted, nil
+}
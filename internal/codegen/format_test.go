@@ -0,0 +1,47 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSource(t *testing.T) {
+	src := []byte(`package models
+
+import (
+    "fmt"
+    "strings"
+)
+
+
+type Post struct {
+  Title string
+}
+
+func (p *Post) Upper() string { return strings.ToUpper(p.Title) }
+func (p *Post) Created() time.Time { return time.Time{} }
+`)
+
+	formatted, err := FormatSource("post.go", src)
+	assert.NoError(t, err)
+	assert.Equal(t, `package models
+
+import (
+	"strings"
+	"time"
+)
+
+type Post struct {
+	Title string
+}
+
+func (p *Post) Upper() string      { return strings.ToUpper(p.Title) }
+func (p *Post) Created() time.Time { return time.Time{} }
+`, string(formatted))
+}
+
+func TestFormatSource_SyntaxError(t *testing.T) {
+	_, err := FormatSource("broken.go", []byte("package models\nfunc {"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package codegen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/pkg/utils"
+)
+
+// Header is the comment on the first line of every file written by a Writer. It follows the Go
+// convention for generated files, so tools such as linters skip them.
+const Header = "Code generated by grayv-lsm. DO NOT EDIT."
+
+// checksumLabel starts the comment on the second line of a generated file, which records the SHA-256
+// checksum of the content below the header. A file whose content no longer matches has been edited by hand.
+const checksumLabel = "grayv-lsm checksum: "
+
+// ErrModified is returned when a file that would be overwritten was not generated by grayv-lsm
+// or has been edited since it was generated.
+var ErrModified = errors.New("file was modified since it was generated")
+
+// Action describes what a Writer does, or would do, with a file.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	ActionConflict  Action = "conflict"
+)
+
+// Writer writes generated files, stamping them with Header and a checksum so later runs can tell
+// whether a file was edited by hand. Modified files are never overwritten unless Force is set.
+// A nil *Writer writes files without reporting anything.
+type Writer struct {
+	// DryRun reports what would be written to Out without touching the filesystem.
+	DryRun bool
+	// Diff prints a unified diff of every change to Out. It implies DryRun.
+	Diff bool
+	// Force overwrites files even if they were modified since they were generated.
+	Force bool
+	// Out receives the report of a dry run or diff. It defaults to os.Stdout.
+	Out io.Writer
+}
+
+// Preview reports whether the writer only reports changes instead of writing them.
+func (w *Writer) Preview() bool {
+	return w != nil && (w.DryRun || w.Diff)
+}
+
+// WriteGoFile formats src with FormatSource and writes it to filename.
+// If the source cannot be formatted, it is written unformatted so the problem can be inspected,
+// and the formatting error is returned.
+func (w *Writer) WriteGoFile(filename string, src []byte) error {
+	formatted, formatErr := FormatSource(filename, src)
+	if formatErr != nil {
+		formatted = src
+	}
+
+	if err := w.WriteFile(filename, formatted); err != nil {
+		return err
+	}
+	return formatErr
+}
+
+// WriteFile stamps content with the generation header and writes it to filename, creating parent
+// directories as needed. It returns an error wrapping ErrModified if an existing file was edited
+// since it was generated and Force is not set; a dry run reports the conflict instead.
+func (w *Writer) WriteFile(filename string, content []byte) error {
+	stamped := Stamp(filename, content)
+
+	existing, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %w", filename, err)
+	}
+	exists := err == nil
+
+	action := ActionCreate
+	switch {
+	case exists && bytes.Equal(existing, stamped):
+		action = ActionUnchanged
+	case exists && Modified(filename, existing) && (w == nil || !w.Force):
+		action = ActionConflict
+	case exists:
+		action = ActionUpdate
+	}
+
+	if w.Preview() {
+		return w.report(filename, action, existing, stamped)
+	}
+	switch action {
+	case ActionUnchanged:
+		return nil
+	case ActionConflict:
+		return fmt.Errorf("refusing to overwrite %s: %w; use --force to overwrite it", filename, ErrModified)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("error creating directory for %s: %w", filename, err)
+	}
+	if err := os.WriteFile(filename, stamped, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Report prints a line describing action on filename, for files produced by other means than WriteFile.
+// It does nothing unless the writer is previewing.
+func (w *Writer) Report(filename string, action Action) {
+	if w.Preview() {
+		fmt.Fprintf(w.out(), "%-9s %s\n", action, filename)
+	}
+}
+
+// report prints the planned action and, in diff mode, the diff between the old and new content.
+func (w *Writer) report(filename string, action Action, old, new []byte) error {
+	out := w.out()
+	if action == ActionConflict {
+		fmt.Fprintf(out, "%-9s %s (modified since it was generated; use --force to overwrite)\n", action, filename)
+	} else {
+		w.Report(filename, action)
+	}
+
+	if !w.Diff || action == ActionUnchanged {
+		return nil
+	}
+	from := filename
+	if action == ActionCreate {
+		from = "/dev/null"
+	}
+	diff, err := utils.UnifiedDiff(from, filename, old, new)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, utils.ColorDiff(diff))
+	return err
+}
+
+func (w *Writer) out() io.Writer {
+	if w.Out == nil {
+		return os.Stdout
+	}
+	return w.Out
+}
+
+// Stamp prefixes content with Header and the checksum of content, written as comments in the syntax of filename.
+// Files in a format without comments, such as JSON, are returned unchanged.
+func Stamp(filename string, content []byte) []byte {
+	open, close, ok := commentSyntax(filename)
+	if !ok {
+		return content
+	}
+
+	var b bytes.Buffer
+	b.WriteString(open + Header + close + "\n")
+	b.WriteString(open + checksumLabel + checksum(content) + close + "\n\n")
+	b.Write(content)
+	return b.Bytes()
+}
+
+// Modified reports whether the content of filename lacks the generation header or no longer matches its checksum.
+// Files in a format without comments carry no header, so they always count as modified.
+func Modified(filename string, content []byte) bool {
+	open, close, ok := commentSyntax(filename)
+	if !ok {
+		return true
+	}
+
+	header, rest, ok := strings.Cut(string(content), "\n")
+	if !ok || header != open+Header+close {
+		return true
+	}
+	line, body, ok := strings.Cut(rest, "\n\n")
+	if !ok || !strings.HasPrefix(line, open+checksumLabel) || !strings.HasSuffix(line, close) {
+		return true
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(line, open+checksumLabel), close) != checksum([]byte(body))
+}
+
+// commentSyntax returns the markers opening and closing a one-line comment in the language of filename,
+// or false if the language has no comments.
+func commentSyntax(filename string) (open, close string, ok bool) {
+	switch ext := filepath.Ext(filename); {
+	case ext == ".sql":
+		return "-- ", "", true
+	case ext == ".html":
+		return "<!-- ", " -->", true
+	case ext == ".css":
+		return "/* ", " */", true
+	case ext == ".yml" || ext == ".yaml" || filepath.Base(filename) == "Makefile":
+		return "# ", "", true
+	case ext == ".json":
+		return "", "", false
+	default:
+		return "// ", "", true
+	}
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModified(t *testing.T) {
+	stamped := Stamp("post.go", []byte("package models\n"))
+	assert.False(t, Modified("post.go", stamped))
+	assert.True(t, Modified("post.go", append(stamped, "// edited\n"...)))
+	assert.True(t, Modified("post.go", []byte("package models\n")), "files without the header are owned by the user")
+
+	migration := Stamp("create_posts.sql", []byte("-- Up\n"))
+	assert.True(t, strings.HasPrefix(string(migration), "-- "+Header))
+	assert.False(t, Modified("create_posts.sql", migration))
+
+	page := Stamp("index.html", []byte("<!DOCTYPE html>\n"))
+	assert.True(t, strings.HasPrefix(string(page), "<!-- "+Header+" -->\n"))
+	assert.False(t, Modified("index.html", page))
+
+	manifest := []byte("{\"name\": \"web\"}\n")
+	assert.Equal(t, manifest, Stamp("package.json", manifest), "JSON has no comments to stamp")
+	assert.True(t, Modified("package.json", manifest))
+}
+
+func TestWriter_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models", "post.go")
+
+	var w *Writer
+	assert.NoError(t, w.WriteFile(path, []byte("package models\n")))
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, Stamp(path, []byte("package models\n")), content)
+
+	// Regenerating an untouched file overwrites it.
+	assert.NoError(t, w.WriteFile(path, []byte("package models\n\ntype Post struct{}\n")))
+
+	assert.NoError(t, os.WriteFile(path, []byte("package models\n\n// mine\n"), 0644))
+	err := w.WriteFile(path, []byte("package models\n"))
+	assert.True(t, errors.Is(err, ErrModified))
+
+	assert.NoError(t, (&Writer{Force: true}).WriteFile(path, []byte("package models\n")))
+	content, _ = os.ReadFile(path)
+	assert.Equal(t, Stamp(path, []byte("package models\n")), content)
+}
+
+func TestWriter_Preview(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "post.go")
+	assert.NoError(t, os.WriteFile(existing, Stamp(existing, []byte("package models\n")), 0644))
+
+	var out bytes.Buffer
+	w := &Writer{Diff: true, Out: &out}
+	assert.NoError(t, w.WriteFile(existing, []byte("package models\n\ntype Post struct{}\n")))
+	assert.NoError(t, w.WriteFile(filepath.Join(dir, "user.go"), []byte("package models\n")))
+
+	assert.Contains(t, out.String(), "update    "+existing)
+	assert.Contains(t, out.String(), "+type Post struct{}")
+	assert.Contains(t, out.String(), "--- /dev/null")
+
+	_, err := os.Stat(filepath.Join(dir, "user.go"))
+	assert.True(t, os.IsNotExist(err), "a preview must not write files")
+	content, _ := os.ReadFile(existing)
+	assert.Equal(t, Stamp(existing, []byte("package models\n")), content)
+}
@@ -0,0 +1,310 @@
+package lsm
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+)
+
+// Branch snapshots are databases copied from the development database with CREATE DATABASE ... TEMPLATE,
+// one per git branch. Their comment records the branch, and the comment of the development database records
+// the branch whose data it holds.
+const (
+	branchSnapshotComment = "grayv-branch-snapshot:"
+	branchCurrentComment  = "grayv-branch:"
+)
+
+// maintenanceDatabase is the database connected to while the development database is copied or replaced,
+// since PostgreSQL refuses to copy or drop a database with open connections.
+const maintenanceDatabase = "postgres"
+
+// BranchHookMarker identifies the git hooks installed by InstallBranchHook.
+const BranchHookMarker = "# Installed by grayv-lsm db branch install-hook"
+
+// branchHook is the post-checkout hook switching the database along with the branch. Git passes 1 as the
+// third argument for branch checkouts, as opposed to file checkouts.
+const branchHook = `#!/bin/sh
+` + BranchHookMarker + `: keeps a database snapshot per git branch.
+[ "$3" = "1" ] || exit 0
+branch=$(git rev-parse --abbrev-ref HEAD)
+[ "$branch" = "HEAD" ] && exit 0
+grayv-lsm db branch switch "$branch" --from "$(git rev-parse --abbrev-ref @{-1} 2>/dev/null)" || true
+`
+
+// BranchSnapshot is a saved copy of the development database for a git branch.
+type BranchSnapshot struct {
+	Branch   string `json:"branch"`
+	Database string `json:"database"`
+	Size     string `json:"size"`
+}
+
+// SnapshotDatabase returns the name of the database holding the snapshot of branch for the database named
+// database. Branch names are reduced to identifier characters and suffixed with a hash, so different branches
+// never share a snapshot, and the name fits in the PostgreSQL identifier limit.
+func SnapshotDatabase(database, branch string) string {
+	sum := sha256.Sum256([]byte(branch))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(branch) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := database + "__" + b.String()
+	if max := 63 - len(suffix); len(name) > max {
+		name = name[:max]
+	}
+	return name + suffix
+}
+
+// SaveBranch copies the development database to the snapshot of branch, replacing any previous snapshot.
+// Other connections to the database are closed, since PostgreSQL copies only databases nobody is using.
+func (dm *DBLifecycleManager) SaveBranch(branch string) error {
+	return dm.withMaintenanceDB(func(db *sql.DB) error {
+		return dm.saveBranch(db, branch)
+	})
+}
+
+// SwitchBranch saves the development database as the snapshot of the branch it holds, and restores the
+// snapshot of branch in its place. The branch the database holds is the one recorded by the last switch,
+// or from if none was. If branch has no snapshot yet, the database is kept as is, so a new branch starts
+// from the data of the branch it was created from. It reports whether a snapshot was restored.
+func (dm *DBLifecycleManager) SwitchBranch(branch, from string) (bool, error) {
+	restored := false
+	err := dm.withMaintenanceDB(func(db *sql.DB) error {
+		current, err := dm.currentBranch(db)
+		if err != nil {
+			return err
+		}
+		if current == "" {
+			current = from
+		}
+		if current != "" && current != branch {
+			if err := dm.saveBranch(db, current); err != nil {
+				return err
+			}
+		}
+
+		snapshot := SnapshotDatabase(dm.config.Database.Name, branch)
+		exists, err := databaseExists(db, snapshot)
+		if err != nil {
+			return err
+		}
+		if exists && current != branch {
+			if err := dm.restoreBranch(db, branch, snapshot); err != nil {
+				return err
+			}
+			restored = true
+		}
+		return commentOnDatabase(db, dm.config.Database.Name, branchCurrentComment+branch)
+	})
+	return restored, err
+}
+
+// ListBranches returns the branch snapshots of the development database.
+func (dm *DBLifecycleManager) ListBranches() ([]BranchSnapshot, error) {
+	var snapshots []BranchSnapshot
+	err := dm.withMaintenanceDB(func(db *sql.DB) error {
+		rows, err := db.Query(`SELECT d.datname, substr(s.description, $1), pg_size_pretty(pg_database_size(d.oid))
+FROM pg_database d JOIN pg_shdescription s ON s.objoid = d.oid AND s.classoid = 'pg_database'::regclass
+WHERE s.description LIKE $2 AND d.datname LIKE $3
+ORDER BY 2`, len(branchSnapshotComment)+1, branchSnapshotComment+"%", dm.config.Database.Name+"\\_\\_%")
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var snapshot BranchSnapshot
+			if err := rows.Scan(&snapshot.Database, &snapshot.Branch, &snapshot.Size); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return rows.Err()
+	})
+	return snapshots, err
+}
+
+// DropBranch deletes the snapshot of branch.
+func (dm *DBLifecycleManager) DropBranch(branch string) error {
+	return dm.withMaintenanceDB(func(db *sql.DB) error {
+		snapshot := SnapshotDatabase(dm.config.Database.Name, branch)
+		exists, err := databaseExists(db, snapshot)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("no snapshot of branch %s", branch)
+		}
+		if err := terminateConnections(db, snapshot); err != nil {
+			return err
+		}
+		if _, err := db.Exec("DROP DATABASE " + quoteIdentifier(snapshot)); err != nil {
+			return fmt.Errorf("failed to drop snapshot of branch %s: %w", branch, err)
+		}
+		return nil
+	})
+}
+
+// CurrentGitBranch returns the git branch checked out in the current directory.
+func CurrentGitBranch() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the current git branch: %w", err)
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return "", errors.New("no branch is checked out")
+	}
+	return branch, nil
+}
+
+// InstallBranchHook installs a git post-checkout hook in the repository of the current directory that runs
+// db branch switch whenever a branch is checked out, and returns its path. An existing hook that was not
+// installed by grayv-lsm is only replaced if force is set.
+func InstallBranchHook(force bool) (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git hooks directory: %w", err)
+	}
+	path := filepath.Join(strings.TrimSpace(string(output)), "post-checkout")
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err == nil && !strings.Contains(string(existing), BranchHookMarker) && !force {
+		return "", fmt.Errorf("%s already exists; use --force to replace it", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(branchHook), 0755); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// saveBranch copies the development database to the snapshot of branch using db, connected to the maintenance database.
+func (dm *DBLifecycleManager) saveBranch(db *sql.DB, branch string) error {
+	snapshot := SnapshotDatabase(dm.config.Database.Name, branch)
+	if err := terminateConnections(db, snapshot); err != nil {
+		return err
+	}
+	if _, err := db.Exec("DROP DATABASE IF EXISTS " + quoteIdentifier(snapshot)); err != nil {
+		return fmt.Errorf("failed to drop previous snapshot of branch %s: %w", branch, err)
+	}
+	if err := terminateConnections(db, dm.config.Database.Name); err != nil {
+		return err
+	}
+	if _, err := db.Exec(fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s",
+		quoteIdentifier(snapshot), quoteIdentifier(dm.config.Database.Name))); err != nil {
+		return fmt.Errorf("failed to snapshot branch %s: %w", branch, err)
+	}
+	return commentOnDatabase(db, snapshot, branchSnapshotComment+branch)
+}
+
+// restoreBranch replaces the development database by a copy of snapshot, the snapshot of branch. The copy is
+// made before the database is dropped, so a failure leaves the database in place.
+func (dm *DBLifecycleManager) restoreBranch(db *sql.DB, branch, snapshot string) error {
+	restoring := quoteIdentifier(dm.config.Database.Name + "__restoring")
+	if _, err := db.Exec("DROP DATABASE IF EXISTS " + restoring); err != nil {
+		return fmt.Errorf("failed to clean up a previous restore: %w", err)
+	}
+	if err := terminateConnections(db, snapshot); err != nil {
+		return err
+	}
+	if _, err := db.Exec(fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", restoring, quoteIdentifier(snapshot))); err != nil {
+		return fmt.Errorf("failed to restore snapshot of branch %s: %w", branch, err)
+	}
+
+	name := quoteIdentifier(dm.config.Database.Name)
+	if err := terminateConnections(db, dm.config.Database.Name); err != nil {
+		return err
+	}
+	if _, err := db.Exec("DROP DATABASE IF EXISTS " + name); err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", restoring, name)); err != nil {
+		return fmt.Errorf("failed to restore snapshot of branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// currentBranch returns the branch recorded in the comment of the development database, or "" if none is.
+func (dm *DBLifecycleManager) currentBranch(db *sql.DB) (string, error) {
+	var comment sql.NullString
+	err := db.QueryRow("SELECT shobj_description(oid, 'pg_database') FROM pg_database WHERE datname = $1",
+		dm.config.Database.Name).Scan(&comment)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("database %s does not exist", dm.config.Database.Name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the branch of the database: %w", err)
+	}
+	branch, ok := strings.CutPrefix(comment.String, branchCurrentComment)
+	if !ok {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// withMaintenanceDB runs fn with a connection to the maintenance database of the server. MySQL servers have
+// none, so their connection selects no database.
+func (dm *DBLifecycleManager) withMaintenanceDB(fn func(*sql.DB) error) error {
+	database := dm.config.Database
+	database.Name = maintenanceDatabase
+	if database.IsMySQL() {
+		database.Name = ""
+	}
+	database.Tenancy = ""
+	conn, err := orm.NewConnection(&database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the %s database: %w", maintenanceDatabase, err)
+	}
+	defer conn.Close()
+	return fn(conn.GetDB())
+}
+
+func databaseExists(db *sql.DB, name string) (bool, error) {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to look up database %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// terminateConnections closes the other connections to the database name.
+func terminateConnections(db *sql.DB, name string) error {
+	_, err := db.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()", name)
+	if err != nil {
+		return fmt.Errorf("failed to close connections to %s: %w", name, err)
+	}
+	return nil
+}
+
+// commentOnDatabase sets the comment of the database name. COMMENT takes no parameters, so comment is quoted here.
+func commentOnDatabase(db *sql.DB, name, comment string) error {
+	literal := "'" + strings.ReplaceAll(comment, "'", "''") + "'"
+	if _, err := db.Exec(fmt.Sprintf("COMMENT ON DATABASE %s IS %s", quoteIdentifier(name), literal)); err != nil {
+		return fmt.Errorf("failed to record branch on database %s: %w", name, err)
+	}
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return dialect.Postgres.Quote(name)
+}
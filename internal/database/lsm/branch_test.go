@@ -0,0 +1,19 @@
+package lsm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotDatabase(t *testing.T) {
+	name := SnapshotDatabase("grayv", "feature/Login-form")
+	assert.True(t, strings.HasPrefix(name, "grayv__feature_login_form_"), name)
+
+	assert.NotEqual(t, name, SnapshotDatabase("grayv", "feature-login-form"))
+	assert.Equal(t, name, SnapshotDatabase("grayv", "feature/Login-form"))
+
+	long := SnapshotDatabase("grayv", strings.Repeat("very-long-branch-name-", 5))
+	assert.Len(t, long, 63)
+}
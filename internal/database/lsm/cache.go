@@ -0,0 +1,116 @@
+package lsm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/cache"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// CacheLifecycleManager manages the optional Redis container used by the session store and query cache.
+// The container runs next to the database container and publishes Redis on the port in the Cache section
+// of the configuration, so the address clients use is always cfg.Cache.Addr().
+type CacheLifecycleManager struct {
+	config *config.Config
+}
+
+// NewCacheLifecycleManager creates a new CacheLifecycleManager for the given configuration.
+func NewCacheLifecycleManager(cfg *config.Config) *CacheLifecycleManager {
+	return &CacheLifecycleManager{config: cfg}
+}
+
+// StartContainer starts the Redis container, replacing an existing container of the same name.
+// The image is pulled by Docker if it is not available locally. When a password is configured,
+// Redis is started with --requirepass.
+func (cm *CacheLifecycleManager) StartContainer() error {
+	name := cm.containerName()
+	log.Infof("Starting the cache Docker container %s...", name)
+
+	_, port, _ := net.SplitHostPort(cm.config.Cache.Addr())
+	image := cm.config.Cache.Image
+	if image == "" {
+		image = "redis:7-alpine"
+	}
+	redis := sidecar{
+		name:    name,
+		image:   image,
+		network: cm.config.Docker.WithDefaults().Network,
+		service: CacheService,
+		ports:   map[int]string{6379: port},
+	}
+	if cm.config.Cache.Password != "" {
+		redis.cmd = []string{"redis-server", "--requirepass", cm.config.Cache.Password}
+	}
+	if err := startSidecar(redis); err != nil {
+		return err
+	}
+
+	if err := cm.waitReady(10 * time.Second); err != nil {
+		return err
+	}
+	log.Infof("Cache Docker container %s started successfully on %s.", name, cm.config.Cache.Addr())
+	return nil
+}
+
+// StopContainer stops and removes the Redis container. Cached data is not persisted.
+func (cm *CacheLifecycleManager) StopContainer() error {
+	name := cm.containerName()
+	log.Infof("Stopping the cache Docker container %s...", name)
+	if err := stopSidecar(name); err != nil {
+		return fmt.Errorf("failed to stop the cache Docker container: %w", err)
+	}
+	log.Infof("Cache Docker container %s stopped successfully.", name)
+	return nil
+}
+
+// GetStatus returns the status of the Redis container and whether Redis answers on the configured address.
+func (cm *CacheLifecycleManager) GetStatus() (string, error) {
+	name := cm.containerName()
+	output, err := containerStatus(name)
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		return fmt.Sprintf("Container %s does not exist", name), nil
+	}
+
+	client := cache.NewClient(cm.config.Cache.Addr(), cm.config.Cache.Password, cm.config.Cache.DB)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
+		return fmt.Sprintf("Container %s: %s. Redis is not reachable at %s: %v", name, output, client.Addr(), err), nil
+	}
+	return fmt.Sprintf("Container %s: %s. Redis is reachable at %s", name, output, client.Addr()), nil
+}
+
+// containerName returns the configured container name, "grayv-cache" by default.
+func (cm *CacheLifecycleManager) containerName() string {
+	if cm.config.Cache.ContainerName == "" {
+		return "grayv-cache"
+	}
+	return cm.config.Cache.ContainerName
+}
+
+// waitReady pings Redis until it answers or the timeout elapses.
+func (cm *CacheLifecycleManager) waitReady(timeout time.Duration) error {
+	client := cache.NewClient(cm.config.Cache.Addr(), cm.config.Cache.Password, cm.config.Cache.DB)
+	defer client.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := client.Ping(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("redis did not become ready at %s: %w", client.Addr(), err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
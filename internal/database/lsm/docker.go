@@ -0,0 +1,275 @@
+package lsm
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// The timeouts of the requests made to the Docker daemon. Builds pull the base image, so they get longer.
+const (
+	dockerTimeout = 30 * time.Second
+	buildTimeout  = 10 * time.Minute
+	// backupTimeout is how long a dump of the database may take.
+	backupTimeout = 10 * time.Minute
+	// stopTimeout is how long a container is given to shut down cleanly before it is killed.
+	stopTimeout = 10 * time.Second
+	// logTail is the number of lines of the logs of a container included in the errors about it.
+	logTail = "20"
+)
+
+// Errors returned by the Docker operations of the lifecycle managers, wrapped with the name of the image or
+// container and the error of the daemon. Test them with errors.Is.
+var (
+	// ErrDockerUnavailable is returned when the Docker daemon cannot be reached.
+	ErrDockerUnavailable = errors.New("the Docker daemon is not available")
+	// ErrImageNotFound is returned when the image of a container has not been built or pulled.
+	ErrImageNotFound = errors.New("docker image not found")
+	// ErrContainerNotFound is returned when a container does not exist.
+	ErrContainerNotFound = errors.New("docker container not found")
+	// ErrContainerNotRunning is returned when a container stopped right after it was started.
+	ErrContainerNotRunning = errors.New("docker container is not running")
+)
+
+// dockerClient returns a client of the Docker daemon configured by the DOCKER_HOST environment variables, or the
+// default socket of the platform, speaking the API version of the daemon.
+func dockerClient() (*client.Client, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	return cli, nil
+}
+
+// withDocker calls fn with a client of the Docker daemon and a context that is cancelled after timeout.
+func withDocker(timeout time.Duration, fn func(ctx context.Context, cli *client.Client) error) error {
+	cli, err := dockerClient()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := fn(ctx, cli); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("docker request timed out after %s: %w", timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// dockerError wraps err, returned by the daemon for the container or image named name, in ErrContainerNotFound or
+// notFound if the daemon did not find it, and in ErrDockerUnavailable if it could not be reached.
+func dockerError(name string, notFound, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case cerrdefs.IsNotFound(err):
+		return fmt.Errorf("%w: %s", notFound, name)
+	case client.IsErrConnectionFailed(err):
+		return fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	return err
+}
+
+// buildImage builds the image tag from the build context in dir, returning the output of the build with the error
+// if it fails.
+func buildImage(ctx context.Context, cli *client.Client, dir, tag string) error {
+	buildContext, err := tarBuildContext(dir)
+	if err != nil {
+		return err
+	}
+	response, err := cli.ImageBuild(ctx, buildContext, build.ImageBuildOptions{Tags: []string{tag}, Remove: true})
+	if err != nil {
+		return dockerError(tag, ErrImageNotFound, err)
+	}
+	defer response.Body.Close()
+
+	// The daemon streams the output of the build as JSON messages, the last one holding the error if it failed.
+	var output bytes.Buffer
+	if err := jsonmessage.DisplayJSONMessagesStream(response.Body, &output, 0, false, nil); err != nil {
+		return fmt.Errorf("%w\nOutput: %s", err, output.String())
+	}
+	return nil
+}
+
+// pullImage pulls the image ref unless it is available locally, as docker run does.
+func pullImage(ctx context.Context, cli *client.Client, ref string) error {
+	if _, err := cli.ImageInspect(ctx, ref); err == nil {
+		return nil
+	}
+	log.Infof("Pulling the image %s...", ref)
+	response, err := cli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull the image %s: %w", ref, dockerError(ref, ErrImageNotFound, err))
+	}
+	defer response.Close()
+
+	// As with builds, the daemon streams the progress as JSON messages, the last one holding the error if it failed.
+	var output bytes.Buffer
+	if err := jsonmessage.DisplayJSONMessagesStream(response, &output, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to pull the image %s: %w", ref, err)
+	}
+	return nil
+}
+
+// tarBuildContext returns the files under dir as the tar archive sent to the daemon as a build context.
+func tarBuildContext(dir string) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive the build context: %w", err)
+	}
+	return &buf, nil
+}
+
+// removeContainer force-removes the container name, stopping it if it runs. A container that does not exist is
+// not an error.
+func removeContainer(ctx context.Context, cli *client.Client, name string) error {
+	err := cli.ContainerRemove(ctx, name, container.RemoveOptions{Force: true})
+	if err != nil && !cerrdefs.IsNotFound(err) {
+		return dockerError(name, ErrContainerNotFound, err)
+	}
+	return nil
+}
+
+// containerLogs returns the last lines of the output of the container name, or "" if they cannot be read.
+func containerLogs(ctx context.Context, cli *client.Client, name string) string {
+	logs, err := cli.ContainerLogs(ctx, name, container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: logTail})
+	if err != nil {
+		return ""
+	}
+	defer logs.Close()
+	var output bytes.Buffer
+	// Containers without a TTY multiplex their stdout and stderr on one stream.
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output.String())
+}
+
+// execError is returned by execInContainer when the command exits with a status other than 0.
+type execError struct {
+	command  string
+	exitCode int
+	stderr   string
+}
+
+func (e *execError) Error() string {
+	return fmt.Sprintf("%s exited with status %d\nOutput: %s", e.command, e.exitCode, e.stderr)
+}
+
+// commandNotFound reports whether the command could not be run because the container has no such executable.
+func (e *execError) commandNotFound() bool {
+	return e.exitCode == 126 || e.exitCode == 127
+}
+
+// execInContainer runs cmd in the running container name with the environment variables env, as docker exec does,
+// and returns its standard output. The variables are sent to the daemon rather than put on a command line, so the
+// secrets among them do not show in the process list of the host. A command exiting with a status other than 0
+// returns an *execError holding its standard error.
+func execInContainer(ctx context.Context, cli *client.Client, name string, env, cmd []string) ([]byte, error) {
+	created, err := cli.ContainerExecCreate(ctx, name, container.ExecOptions{Env: env, Cmd: cmd, AttachStdout: true, AttachStderr: true})
+	if err != nil {
+		return nil, dockerError(name, ErrContainerNotFound, err)
+	}
+	attached, err := cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, dockerError(name, ErrContainerNotFound, err)
+	}
+	defer attached.Close()
+	// Reading the output does not watch ctx, so the connection is closed when ctx is done.
+	stop := context.AfterFunc(ctx, attached.Close)
+	defer stop()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attached.Reader); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read the output of %s: %w", cmd[0], err)
+	}
+	inspected, err := cli.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return nil, dockerError(name, ErrContainerNotFound, err)
+	}
+	if inspected.ExitCode != 0 {
+		return nil, &execError{command: cmd[0], exitCode: inspected.ExitCode, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.Bytes(), nil
+}
+
+// describeState describes the state of a container the way docker ps does, such as "running since
+// 2025-01-01T12:00:00Z" or "exited (1) at 2025-01-01T12:00:00Z".
+func describeState(state *container.State) string {
+	switch {
+	case state == nil:
+		return "unknown"
+	case state.Running:
+		return fmt.Sprintf("%s since %s", state.Status, state.StartedAt)
+	case state.Status == container.StateExited || state.Status == container.StateDead:
+		return fmt.Sprintf("%s (%d) at %s", state.Status, state.ExitCode, state.FinishedAt)
+	}
+	return state.Status
+}
+
+// hasEnv reports whether env, the environment of a container, sets a variable starting with prefix.
+func hasEnv(env []string, prefix string) bool {
+	for _, v := range env {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package lsm
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM postgres:13\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, initScriptsDir), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, initScriptsDir, "01-init.sql"), []byte("SELECT 1;"), 0755))
+
+	buildContext, err := tarBuildContext(dir)
+	assert.NoError(t, err)
+	files := map[string]string{}
+	tr := tar.NewReader(buildContext)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		"Dockerfile":                    "FROM postgres:13\n",
+		initScriptsDir:                  "",
+		initScriptsDir + "/01-init.sql": "SELECT 1;",
+	}, files)
+}
+
+func TestContainerConfig(t *testing.T) {
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Driver:        "postgres",
+		User:          "grayv",
+		Password:      `p'a$s "word"`,
+		Name:          "app",
+		Port:          5433,
+		Image:         "grayv-db",
+		ContainerName: "grayv-db",
+	}}
+	port := nat.Port("5432/tcp")
+
+	config, hostConfig, networking, err := NewDBLifecycleManager(cfg).containerConfig("grayv")
+	assert.NoError(t, err)
+	assert.Equal(t, "grayv-db", config.Image)
+	assert.Equal(t, []string{"POSTGRES_USER=grayv", `POSTGRES_PASSWORD=p'a$s "word"`, "POSTGRES_DB=app"}, config.Env,
+		"the password is passed as it is, without quoting")
+	assert.Contains(t, config.ExposedPorts, port)
+	assert.Equal(t, nat.PortMap{port: {{HostPort: "5433"}}}, hostConfig.PortBindings)
+	assert.Equal(t, []string{DatabaseService}, networking.EndpointsConfig["grayv"].Aliases)
+
+	cfg.Database.NoPublish = true
+	_, hostConfig, _, err = NewDBLifecycleManager(cfg).containerConfig("grayv")
+	assert.NoError(t, err)
+	assert.Empty(t, hostConfig.PortBindings)
+
+	cfg.Database.Driver, cfg.Database.NoPublish = "mysql", false
+	config, hostConfig, _, err = NewDBLifecycleManager(cfg).containerConfig("grayv")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MYSQL_USER=grayv", `MYSQL_PASSWORD=p'a$s "word"`, "MYSQL_DATABASE=app",
+		"MYSQL_RANDOM_ROOT_PASSWORD=yes"}, config.Env)
+	assert.Contains(t, hostConfig.PortBindings, nat.Port("3306/tcp"))
+
+	cfg.Database.User = "root"
+	_, _, _, err = NewDBLifecycleManager(cfg).containerConfig("grayv")
+	assert.Error(t, err)
+}
+
+func TestDescribeState(t *testing.T) {
+	assert.Equal(t, "running since 2025-01-01T12:00:00Z",
+		describeState(&container.State{Status: container.StateRunning, Running: true, StartedAt: "2025-01-01T12:00:00Z"}))
+	assert.Equal(t, "exited (1) at 2025-01-01T12:05:00Z",
+		describeState(&container.State{Status: container.StateExited, ExitCode: 1, FinishedAt: "2025-01-01T12:05:00Z"}))
+	assert.Equal(t, "created", describeState(&container.State{Status: container.StateCreated}))
+	assert.Equal(t, "unknown", describeState(nil))
+}
+
+// fakeExecDaemon serves the exec endpoints of the Docker API, answering each command with the standard output and
+// exit code returned by run, and points DOCKER_HOST at it. It returns the options of the execs it was asked for.
+func fakeExecDaemon(t *testing.T, run func(cmd []string) (string, int)) *[]container.ExecOptions {
+	var mu sync.Mutex
+	var execs []container.ExecOptions
+	exitCodes := map[string]int{}
+	execID := func(path string) string {
+		return strings.Split(path[strings.Index(path, "/exec/")+len("/exec/"):], "/")[0]
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/_ping"):
+			w.Header().Set("Api-Version", "1.47")
+			fmt.Fprint(w, "OK")
+		case strings.HasPrefix(path, "/v1.47/containers/") && strings.HasSuffix(path, "/exec"):
+			var options container.ExecOptions
+			if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			execs = append(execs, options)
+			fmt.Fprintf(w, `{"Id": "%d"}`, len(execs))
+		case strings.HasSuffix(path, "/start"):
+			id := execID(path)
+			index, _ := strconv.Atoi(id)
+			stdout, exitCode := run(execs[index-1].Cmd)
+			exitCodes[id] = exitCode
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+			if stdout != "" {
+				stdcopy.NewStdWriter(buf, stdcopy.Stdout).Write([]byte(stdout))
+			}
+			buf.Flush()
+		case strings.HasSuffix(path, "/json"):
+			fmt.Fprintf(w, `{"ExitCode": %d}`, exitCodes[execID(path)])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("DOCKER_HOST", "tcp://"+server.Listener.Addr().String())
+	return &execs
+}
+
+func TestBackup_Postgres(t *testing.T) {
+	execs := fakeExecDaemon(t, func(cmd []string) (string, int) { return "CREATE TABLE users ();\n", 0 })
+	dm := NewDBLifecycleManager(&config.Config{Database: config.DatabaseConfig{
+		Driver: "postgres", User: "grayv", Password: "secret", Name: "app", ContainerName: "grayv-db",
+	}})
+
+	path, err := dm.Backup(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	dump, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE users ();\n", string(dump))
+	if assert.Len(t, *execs, 1) {
+		assert.Equal(t, []string{"pg_dump", "-U", "grayv", "-d", "app"}, (*execs)[0].Cmd)
+	}
+}
+
+func TestBackup_MySQL(t *testing.T) {
+	execs := fakeExecDaemon(t, func(cmd []string) (string, int) {
+		if cmd[0] == "mariadb-dump" {
+			return "", 127
+		}
+		return "CREATE TABLE `users` ();\n", 0
+	})
+	dm := NewDBLifecycleManager(&config.Config{Database: config.DatabaseConfig{
+		Driver: "mysql", User: "grayv", Password: "secret", Name: "app", ContainerName: "grayv-db",
+	}})
+
+	path, err := dm.Backup(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	dump, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE `users` ();\n", string(dump))
+	if assert.Len(t, *execs, 2) {
+		// The password is in the environment of the exec, not in its arguments, and no shell is involved.
+		assert.Equal(t, []string{"mariadb-dump", "--single-transaction", "-u", "grayv", "app"}, (*execs)[0].Cmd)
+		assert.Equal(t, []string{"mysqldump", "--single-transaction", "-u", "grayv", "app"}, (*execs)[1].Cmd)
+		assert.Equal(t, []string{"MYSQL_PWD=secret"}, (*execs)[1].Env)
+	}
+}
@@ -0,0 +1,158 @@
+package lsm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/ooyeku/grayv-lsm/embedded"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// initScriptsDir is the directory of the build context holding the init scripts of the image.
+const initScriptsDir = "initdb"
+
+var (
+	// dockerWordPattern matches image names, tags, and package names, which end up unquoted in the Dockerfile.
+	dockerWordPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/:+-]*$`)
+	localePattern     = regexp.MustCompile(`^([A-Za-z]+(?:_[A-Za-z]+)?)(?:\.([A-Za-z0-9-]+))?$`)
+	majorVersion      = regexp.MustCompile(`^\d+`)
+)
+
+// dockerLocale is a locale such as de_DE.UTF-8, split into the name and the charset localedef compiles.
+type dockerLocale struct {
+	Name    string
+	Charset string
+}
+
+func (l dockerLocale) String() string {
+	return l.Name + "." + l.Charset
+}
+
+// dockerfileData is passed to the embedded Dockerfile template.
+type dockerfileData struct {
+	BaseImage string
+	Version   string
+	Packages  []string
+	Locale    *dockerLocale
+	// InitScripts are the file names of the init scripts in initScriptsDir.
+	InitScripts []string
+}
+
+// RenderDockerfile renders the embedded Dockerfile of the database image from the image settings,
+// with defaults applied to empty settings.
+func RenderDockerfile(settings config.DockerImageConfig) (string, error) {
+	data, err := newDockerfileData(settings.WithDefaults())
+	if err != nil {
+		return "", err
+	}
+	return renderDockerfile("Dockerfile", data)
+}
+
+// RenderDatabaseDockerfile renders the Dockerfile of the image of db: RenderMySQLDockerfile for a MySQL
+// database and RenderDockerfile for others.
+func RenderDatabaseDockerfile(db config.DatabaseConfig) (string, error) {
+	if db.IsMySQL() {
+		return RenderMySQLDockerfile(db.Docker)
+	}
+	return RenderDockerfile(db.Docker)
+}
+
+// renderDockerfile renders the embedded Dockerfile template name with data.
+func renderDockerfile(name string, data *dockerfileData) (string, error) {
+	content, err := embedded.EmbeddedFiles.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read embedded %s: %w", name, err)
+	}
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse embedded %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render Dockerfile: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func newDockerfileData(settings config.DockerImageConfig) (*dockerfileData, error) {
+	for _, word := range []string{settings.BaseImage, settings.Version} {
+		if !dockerWordPattern.MatchString(word) {
+			return nil, fmt.Errorf("invalid base image %s:%s", settings.BaseImage, settings.Version)
+		}
+	}
+	data := &dockerfileData{BaseImage: settings.BaseImage, Version: settings.Version}
+
+	if len(settings.Extensions) > 0 {
+		major := majorVersion.FindString(settings.Version)
+		if major == "" {
+			return nil, fmt.Errorf("cannot install extensions: version %q does not start with the PostgreSQL major version", settings.Version)
+		}
+		for _, extension := range settings.Extensions {
+			if !dockerWordPattern.MatchString(extension) {
+				return nil, fmt.Errorf("invalid extension package %q", extension)
+			}
+			data.Packages = append(data.Packages, fmt.Sprintf("postgresql-%s-%s", major, extension))
+		}
+	}
+
+	if settings.Locale != "" {
+		match := localePattern.FindStringSubmatch(settings.Locale)
+		if match == nil {
+			return nil, fmt.Errorf("invalid locale %q: use a locale such as de_DE.UTF-8", settings.Locale)
+		}
+		data.Locale = &dockerLocale{Name: match[1], Charset: match[2]}
+		if data.Locale.Charset == "" {
+			data.Locale.Charset = "UTF-8"
+		}
+	}
+
+	data.InitScripts = initScriptNames(settings.InitScripts)
+	return data, nil
+}
+
+// initScriptNames returns the names the init scripts at paths are copied to. The entrypoints of the PostgreSQL
+// and MySQL images run them in alphabetical order, so they are numbered in the order they are configured.
+func initScriptNames(paths []string) []string {
+	var names []string
+	for i, path := range paths {
+		names = append(names, fmt.Sprintf("%02d_%s", i+1, filepath.Base(path)))
+	}
+	return names
+}
+
+// writeBuildContext writes the rendered Dockerfile and the init scripts of the image of db to dir.
+func writeBuildContext(dir string, db config.DatabaseConfig) error {
+	dockerfile, err := RenderDatabaseDockerfile(db)
+	if err != nil {
+		return err
+	}
+	settings := db.Docker
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+
+	names := initScriptNames(settings.InitScripts)
+	for i, path := range settings.InitScripts {
+		switch {
+		case strings.HasSuffix(path, ".sql"), strings.HasSuffix(path, ".sql.gz"), strings.HasSuffix(path, ".sh"):
+		default:
+			return fmt.Errorf("init script %s must be a .sql, .sql.gz, or .sh file", path)
+		}
+		script, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read init script: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, initScriptsDir), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, initScriptsDir, names[i]), script, 0755); err != nil {
+			return fmt.Errorf("failed to copy init script %s: %w", path, err)
+		}
+	}
+	return nil
+}
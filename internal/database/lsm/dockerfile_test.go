@@ -0,0 +1,91 @@
+package lsm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderDockerfile_Defaults(t *testing.T) {
+	dockerfile, err := RenderDockerfile(config.DockerImageConfig{})
+	assert.NoError(t, err)
+	assert.Contains(t, dockerfile, "FROM postgres:13\n")
+	assert.Contains(t, dockerfile, "--no-install-recommends postgresql-13-pgvector \\\n")
+	assert.NotContains(t, dockerfile, "localedef")
+	assert.NotContains(t, dockerfile, "COPY")
+
+	dockerfile, err = RenderDockerfile(config.DockerImageConfig{Extensions: []string{}})
+	assert.NoError(t, err)
+	assert.NotContains(t, dockerfile, "apt-get", "an empty list installs no extensions")
+}
+
+func TestRenderDockerfile_Settings(t *testing.T) {
+	dockerfile, err := RenderDockerfile(config.DockerImageConfig{
+		Version:     "16-bookworm",
+		Locale:      "de_DE.UTF-8",
+		Extensions:  []string{"pgvector", "postgis-3"},
+		InitScripts: []string{"sql/extensions.sql"},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, dockerfile, "FROM postgres:16-bookworm\n")
+	assert.Contains(t, dockerfile, "postgresql-16-pgvector postgresql-16-postgis-3")
+	assert.Contains(t, dockerfile, "localedef -i de_DE -c -f UTF-8 -A /usr/share/locale/locale.alias de_DE.UTF-8\nENV LANG=de_DE.UTF-8\n")
+	assert.Contains(t, dockerfile, "COPY initdb/ /docker-entrypoint-initdb.d/\n")
+
+	for _, settings := range []config.DockerImageConfig{
+		{Version: "latest"},
+		{Version: "13; rm -rf /"},
+		{Locale: "de DE"},
+		{Extensions: []string{"pgvector && curl"}},
+	} {
+		_, err := RenderDockerfile(settings)
+		assert.Error(t, err, "%+v", settings)
+	}
+}
+
+func TestRenderMySQLDockerfile(t *testing.T) {
+	dockerfile, err := RenderDatabaseDockerfile(config.DatabaseConfig{Driver: "mysql",
+		Docker: config.DockerImageConfig{InitScripts: []string{"sql/users.sql"}}})
+	assert.NoError(t, err)
+	assert.Contains(t, dockerfile, "FROM mariadb:11\n")
+	assert.Contains(t, dockerfile, "character-set-server=utf8mb4")
+	assert.Contains(t, dockerfile, "COPY initdb/ /docker-entrypoint-initdb.d/\n")
+	assert.NotContains(t, dockerfile, "apt-get")
+
+	dockerfile, err = RenderMySQLDockerfile(config.DockerImageConfig{BaseImage: "mysql", Version: "8.4"})
+	assert.NoError(t, err)
+	assert.Contains(t, dockerfile, "FROM mysql:8.4\n")
+
+	for _, settings := range []config.DockerImageConfig{
+		{Locale: "de_DE.UTF-8"},
+		{Extensions: []string{"pgvector"}},
+		{Version: "11; rm -rf /"},
+	} {
+		_, err := RenderMySQLDockerfile(settings)
+		assert.Error(t, err, "%+v", settings)
+	}
+}
+
+func TestWriteBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	scripts := filepath.Join(dir, "scripts")
+	assert.NoError(t, os.MkdirAll(scripts, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(scripts, "users.sql"), []byte("CREATE ROLE app;"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(scripts, "setup.sh"), []byte("echo ok"), 0644))
+
+	build := filepath.Join(dir, "build")
+	assert.NoError(t, os.MkdirAll(build, 0755))
+	settings := config.DockerImageConfig{InitScripts: []string{filepath.Join(scripts, "users.sql"), filepath.Join(scripts, "setup.sh")}}
+	assert.NoError(t, writeBuildContext(build, config.DatabaseConfig{Docker: settings}))
+	assert.FileExists(t, filepath.Join(build, "Dockerfile"))
+	content, err := os.ReadFile(filepath.Join(build, initScriptsDir, "01_users.sql"))
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE ROLE app;", string(content))
+	assert.FileExists(t, filepath.Join(build, initScriptsDir, "02_setup.sh"))
+
+	settings.InitScripts = []string{filepath.Join(scripts, "notes.txt")}
+	assert.Error(t, writeBuildContext(build, config.DatabaseConfig{Docker: settings}))
+}
@@ -2,13 +2,17 @@ package lsm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
+	"strconv"
 	"time"
 
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 	"github.com/ooyeku/grayv-lsm/embedded"
 	"github.com/ooyeku/grayv-lsm/internal/orm"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
@@ -77,38 +81,14 @@ func (dm *DBLifecycleManager) fileExists(name string) bool {
 	return !os.IsNotExist(err)
 }
 
-// Update the runCommand method signature
-func (dm *DBLifecycleManager) runCommand(command string, args ...interface{}) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "sh", "-c", fmt.Sprintf(command, args...))
-	output, err := cmd.CombinedOutput()
-	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("command timed out")
-	}
-	return string(output), err
-}
-
-// BuildImage builds the Docker image for the database using the specified Dockerfile.
-// It sets the necessary environment variables, checks if the Dockerfile exists,
-// and runs the build command. If the build process fails, it logs the error and returns it.
-// Otherwise, it logs the successful build and returns nil.
+// BuildImage builds the Docker image for the database from the embedded Dockerfile, rendered with the
+// image settings of the configuration (see RenderDockerfile), in a temporary build context holding the
+// configured init scripts. If the build process fails, it returns the error with the output of the build.
+// A SQLite database has no image, so nothing is built.
 func (dm *DBLifecycleManager) BuildImage() error {
-	dockerfileContent, err := embedded.EmbeddedFiles.ReadFile("Dockerfile")
-	if err != nil {
-		return fmt.Errorf("failed to read embedded Dockerfile: %w", err)
+	if dm.config.Database.IsSQLite() {
+		return dm.sqliteNoContainer("build")
 	}
-
-	// Remove the COPY instruction
-	dockerfileLines := strings.Split(string(dockerfileContent), "\n")
-	var newDockerfileContent strings.Builder
-	for _, line := range dockerfileLines {
-		if !strings.HasPrefix(line, "COPY ./internal/database/init.sql") {
-			newDockerfileContent.WriteString(line + "\n")
-		}
-	}
-
 	tempDir, err := os.MkdirTemp("", "grayv-db-build")
 	if err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
@@ -119,14 +99,15 @@ func (dm *DBLifecycleManager) BuildImage() error {
 		}
 	}()
 
-	if err := os.WriteFile(filepath.Join(tempDir, "Dockerfile"), []byte(newDockerfileContent.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write Dockerfile to temp directory: %w", err)
+	if err := writeBuildContext(tempDir, dm.config.Database); err != nil {
+		return err
 	}
 
-	buildCommand := fmt.Sprintf("docker build -t %s %s", dm.config.Database.Image, tempDir)
-	output, err := dm.runCommand(buildCommand)
+	err = withDocker(buildTimeout, func(ctx context.Context, cli *client.Client) error {
+		return buildImage(ctx, cli, tempDir, dm.config.Database.Image)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to build the database docker image: %v\nOutput: %s", err, output)
+		return fmt.Errorf("failed to build the database docker image: %w", err)
 	}
 
 	log.Infof("Database Docker image %s built successfully.", dm.config.Database.Image)
@@ -134,120 +115,177 @@ func (dm *DBLifecycleManager) BuildImage() error {
 }
 
 // StartContainer starts the database Docker container.
-// It checks if the container already exists and removes it if it does.
-// It checks if the image exists locally and returns an error if it does not.
-// It starts the Docker container by running a command with necessary environment variables.
-// It verifies that the container is running and that the environment variables are set correctly inside the container.
-// Returns an error if any step fails.
+// It removes the container if it already exists, and returns ErrImageNotFound if the image has not been built.
+// The container is created with the credentials of the configuration in its environment, on the grayv network,
+// with its port published on the host unless disabled; see containerConfig.
+// It verifies that the container is running and that the environment variables are set inside the container,
+// returning ErrContainerNotRunning with the last lines of its logs if it stopped.
+// A SQLite database has no container; only the directory of its file is created.
 func (dm *DBLifecycleManager) StartContainer() error {
-	log.Infof("Starting the database Docker container %s...", dm.config.Database.ContainerName)
-
-	// Check if the container already exists
-	output, _ := dm.runCommand(fmt.Sprintf("docker ps -aq -f name=%s", dm.config.Database.ContainerName))
-	if output != "" {
-		log.Infof("Container %s already exists. Removing it...", dm.config.Database.ContainerName)
-		_, err := dm.runCommand(fmt.Sprintf("docker rm -f %s", dm.config.Database.ContainerName))
-		if err != nil {
-			return fmt.Errorf("failed to remove existing container: %v", err)
-		}
-	}
-
-	// Check if the image exists locally
-	output, _ = dm.runCommand(fmt.Sprintf("docker images -q %s", dm.config.Database.Image))
-	if output == "" {
-		return fmt.Errorf("docker image %s not found. Please build the image first", dm.config.Database.Image)
+	if dm.config.Database.IsSQLite() {
+		return dm.sqliteStart()
 	}
+	db := dm.config.Database
+	log.Infof("Starting the database Docker container %s...", db.ContainerName)
 
-	// Start the Docker container
-	startCommand := fmt.Sprintf("docker run -d --name %s -e POSTGRES_USER=%s -e POSTGRES_PASSWORD=%s -e POSTGRES_DB=%s -p 5432:5432 %s",
-		dm.config.Database.ContainerName, dm.config.Database.User, dm.config.Database.Password, dm.config.Database.Name, dm.config.Database.Image)
-	output, err := dm.runCommand(startCommand)
+	networkName := dm.config.Docker.WithDefaults().Network
+	config, hostConfig, networking, err := dm.containerConfig(networkName)
 	if err != nil {
-		return fmt.Errorf("failed to start the database docker container: %v\nOutput: %s", err, output)
+		return err
+	}
+	if err := ensureNetwork(networkName); err != nil {
+		return err
+	}
+	envPrefix := "POSTGRES_"
+	if db.IsMySQL() {
+		envPrefix = "MYSQL_"
 	}
 
-	log.Infof("Database Docker container %s started successfully.", dm.config.Database.ContainerName)
+	return withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		if _, err := cli.ContainerInspect(ctx, db.ContainerName); err == nil {
+			log.Infof("Container %s already exists. Removing it...", db.ContainerName)
+			if err := removeContainer(ctx, cli, db.ContainerName); err != nil {
+				return fmt.Errorf("failed to remove existing container: %w", err)
+			}
+		}
 
-	// Verify the container is running
-	output, err = dm.runCommand(fmt.Sprintf("docker ps -q -f name=%s", dm.config.Database.ContainerName))
-	if err != nil || output == "" {
-		return fmt.Errorf("database Docker container is not running")
-	}
+		if _, err := cli.ImageInspect(ctx, db.Image); err != nil {
+			return fmt.Errorf("%w. Please build the image first", dockerError(db.Image, ErrImageNotFound, err))
+		}
 
-	// Verify environment variables inside the container
-	output, err = dm.runCommand(fmt.Sprintf("docker exec %s env | grep POSTGRES", dm.config.Database.ContainerName))
-	if err != nil {
-		return fmt.Errorf("failed to verify environment variables in the container: %v\nOutput: %s", err, output)
+		created, err := cli.ContainerCreate(ctx, config, hostConfig, networking, nil, db.ContainerName)
+		if err != nil {
+			return fmt.Errorf("failed to create the database docker container: %w", dockerError(db.Image, ErrImageNotFound, err))
+		}
+		if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+			return fmt.Errorf("failed to start the database docker container: %w\nLogs: %s",
+				dockerError(db.ContainerName, ErrContainerNotFound, err), containerLogs(ctx, cli, created.ID))
+		}
+		log.Infof("Database Docker container %s started successfully.", db.ContainerName)
+
+		// Verify the container is running, with the environment variables set inside it
+		info, err := cli.ContainerInspect(ctx, created.ID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect the database docker container: %w", dockerError(db.ContainerName, ErrContainerNotFound, err))
+		}
+		if info.State == nil || !info.State.Running {
+			return fmt.Errorf("%w: %s is %s\nLogs: %s", ErrContainerNotRunning, db.ContainerName,
+				describeState(info.State), containerLogs(ctx, cli, created.ID))
+		}
+		if info.Config == nil || !hasEnv(info.Config.Env, envPrefix) {
+			return fmt.Errorf("the %s environment variables are not set in the container %s", envPrefix, db.ContainerName)
+		}
+		log.Infof("Environment variables are set correctly in the container %s.", db.ContainerName)
+		return nil
+	})
+}
+
+// containerConfig returns the configuration of the database container on the Docker network networkName,
+// where other containers reach it as DatabaseService. The credentials are passed in its environment as they are,
+// so they need no quoting. A MySQL container gets the MYSQL_ variables read by the MySQL and MariaDB images
+// instead of the POSTGRES_ ones.
+func (dm *DBLifecycleManager) containerConfig(networkName string) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	db := dm.config.Database
+	containerPort := 5432
+	env := []string{"POSTGRES_USER=" + db.User, "POSTGRES_PASSWORD=" + db.Password, "POSTGRES_DB=" + db.Name}
+	if db.IsMySQL() {
+		var err error
+		containerPort = mysqlPort
+		if env, err = dm.mysqlEnvironment(); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	log.Infof("Environment variables are set correctly in the container %s.", dm.config.Database.ContainerName)
-	return nil
+	port := nat.Port(fmt.Sprintf("%d/tcp", containerPort))
+	config := &container.Config{
+		Image:        db.Image,
+		Env:          env,
+		ExposedPorts: nat.PortSet{port: struct{}{}},
+	}
+	hostConfig := &container.HostConfig{}
+	if !db.NoPublish {
+		hostConfig.PortBindings = nat.PortMap{port: {{HostPort: strconv.Itoa(db.Port)}}}
+	}
+	networking := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+		networkName: {Aliases: []string{DatabaseService}},
+	}}
+	return config, hostConfig, networking, nil
 }
 
-// StopContainer stops the database Docker container by running the command "docker stop gravorm-db".
-// It returns an error if it fails to stop the container, along with the output of the command.
+// StopContainer stops the database Docker container, giving it stopTimeout to shut down before it is killed.
+// It returns ErrContainerNotFound if the container does not exist.
 // If the container is stopped successfully, it logs a success message and returns nil.
+// A SQLite database has no container to stop.
 func (dm *DBLifecycleManager) StopContainer() error {
+	if dm.config.Database.IsSQLite() {
+		return dm.sqliteNoContainer("stop")
+	}
 	log.Infof("Stopping the database Docker container %s...", dm.containerName)
-	output, err := dm.runCommand(fmt.Sprintf("docker stop %s", dm.containerName))
+	err := withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		seconds := int(stopTimeout / time.Second)
+		return dockerError(dm.containerName, ErrContainerNotFound,
+			cli.ContainerStop(ctx, dm.containerName, container.StopOptions{Timeout: &seconds}))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to stop the database Docker container: %v\nOutput: %s", err, output)
+		return fmt.Errorf("failed to stop the database Docker container: %w", err)
 	}
 	log.Infof("Database Docker container %s stopped successfully.", dm.containerName)
 	return nil
 }
 
-// RemoveContainer removes the database Docker container. It runs the "docker rm gravorm-db" command
-// to remove the container. If the command fails, it returns an error with the failure message.
-// Otherwise, it logs a success message and returns nil.
+// RemoveContainer removes the stopped database Docker container, returning ErrContainerNotFound if it does
+// not exist. Otherwise, it logs a success message and returns nil.
+// A SQLite database has no container to remove, and its file is kept.
 func (dm *DBLifecycleManager) RemoveContainer() error {
+	if dm.config.Database.IsSQLite() {
+		return dm.sqliteNoContainer("remove")
+	}
 	log.Infof("Removing the database Docker container %s...", dm.config.Database.ContainerName)
-	output, err := dm.runCommand(fmt.Sprintf("docker rm %s", dm.config.Database.ContainerName))
+	err := withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		return dockerError(dm.config.Database.ContainerName, ErrContainerNotFound,
+			cli.ContainerRemove(ctx, dm.config.Database.ContainerName, container.RemoveOptions{}))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to remove the database Docker container: %v\nOutput: %s", err, output)
+		return fmt.Errorf("failed to remove the database Docker container: %w", err)
 	}
 	log.Infof("Database Docker container %s removed successfully.", dm.config.Database.ContainerName)
 	return nil
 }
 
 // GetStatus returns the status of the database Docker container.
-// It checks if the container exists and if it is running.
+// It inspects the container to find out whether it exists and is running.
 // If the container does not exist, it returns "container does not exist".
 // If the container is running, it returns "Container is running. Status: <status>".
 // If the container is not running, it returns "Container is not running. Status: <status>".
+// The status is described by describeState.
 // It returns an error if there is any failure in getting the status of the container.
-// The function uses Docker CLI commands to check the status.
+// For a SQLite database, it reports whether the file exists and its size instead.
 func (dm *DBLifecycleManager) GetStatus() (string, error) {
-	// Check if the container exists
-	output, err := dm.runCommand(fmt.Sprintf("docker ps -a --filter name=%s --format '{{.Status}}'", dm.config.Database.ContainerName))
-	if err != nil {
-		log.WithError(err).Error("failed to get the status of the database Docker container")
-		return "", fmt.Errorf("failed to get the status of the database Docker container: %v", err)
+	if dm.config.Database.IsSQLite() {
+		return dm.sqliteStatus()
 	}
-
-	output = strings.TrimSpace(output)
-	if output == "" {
-		log.Infof("Container %s does not exist", dm.config.Database.ContainerName)
-		return fmt.Sprintf("container %s does not exist", dm.config.Database.ContainerName), nil
+	name := dm.config.Database.ContainerName
+	var info container.InspectResponse
+	err := withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		var err error
+		info, err = cli.ContainerInspect(ctx, name)
+		return dockerError(name, ErrContainerNotFound, err)
+	})
+	if errors.Is(err, ErrContainerNotFound) {
+		log.Infof("Container %s does not exist", name)
+		return fmt.Sprintf("container %s does not exist", name), nil
 	}
-
-	// Check if the container is running
-	isRunning, err := dm.runCommand(fmt.Sprintf("docker inspect -f '{{.State.Running}}' %s", dm.config.Database.ContainerName))
 	if err != nil {
 		log.WithError(err).Error("failed to inspect the database Docker container")
-		return "", fmt.Errorf("failed to inspect the database Docker container: %v", err)
+		return "", fmt.Errorf("failed to inspect the database Docker container: %w", err)
 	}
 
-	isRunning = strings.TrimSpace(isRunning)
-	if isRunning == "true" {
-		status := fmt.Sprintf("Container %s is running. Status: %s", dm.config.Database.ContainerName, output)
-		log.Info(status)
-		return status, nil
-	} else {
-		status := fmt.Sprintf("Container %s is not running. Status: %s", dm.config.Database.ContainerName, output)
-		log.Info(status)
-		return status, nil
+	status := fmt.Sprintf("Container %s is not running. Status: %s", name, describeState(info.State))
+	if info.State != nil && info.State.Running {
+		status = fmt.Sprintf("Container %s is running. Status: %s", name, describeState(info.State))
 	}
+	log.Info(status)
+	return status, nil
 }
 
 func (dm *DBLifecycleManager) InitializeDatabase() error {
@@ -270,3 +308,43 @@ func (dm *DBLifecycleManager) InitializeDatabase() error {
 	log.Info("Database initialized successfully")
 	return nil
 }
+
+// Backup writes a plain SQL dump of the database to a timestamped file in dir.
+// The dump is produced by running pg_dump inside the database container through the Docker API, so neither
+// the docker CLI nor local PostgreSQL client tools are required. It returns the path of the written file.
+// A MySQL database is dumped by mariadb-dump or mysqldump in its container; see mysqlDump.
+// A SQLite database is copied to a .db file instead; see sqliteBackup.
+func (dm *DBLifecycleManager) Backup(dir string) (string, error) {
+	if dir == "" {
+		dir = "backups"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if dm.config.Database.IsSQLite() {
+		return dm.sqliteBackup(dir)
+	}
+
+	var output []byte
+	err := withDocker(backupTimeout, func(ctx context.Context, cli *client.Client) error {
+		var err error
+		if dm.config.Database.IsMySQL() {
+			output, err = dm.mysqlDump(ctx, cli)
+			return err
+		}
+		output, err = execInContainer(ctx, cli, dm.config.Database.ContainerName, nil,
+			[]string{"pg_dump", "-U", dm.config.Database.User, "-d", dm.config.Database.Name})
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to dump database: %w", err)
+	}
+
+	fileName := filepath.Join(dir, fmt.Sprintf("%s-%s.sql", dm.config.Database.Name, time.Now().Format("20060102150405")))
+	if err := os.WriteFile(fileName, output, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	log.Infof("Database backup written to %s", fileName)
+	return fileName, nil
+}
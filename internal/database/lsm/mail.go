@@ -0,0 +1,101 @@
+package lsm
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// MailLifecycleManager manages the optional Mailpit container that catches outgoing email during development.
+// Messages sent to its SMTP port are never delivered; they can be read in the Mailpit web interface.
+type MailLifecycleManager struct {
+	config  config.MailConfig
+	network string
+}
+
+// NewMailLifecycleManager creates a new MailLifecycleManager for the given configuration.
+func NewMailLifecycleManager(cfg *config.Config) *MailLifecycleManager {
+	return &MailLifecycleManager{config: cfg.Mail.WithDefaults(), network: cfg.Docker.WithDefaults().Network}
+}
+
+// UIURL returns the address of the Mailpit web interface.
+func (mm *MailLifecycleManager) UIURL() string {
+	return fmt.Sprintf("http://localhost:%d", mm.config.UIPort)
+}
+
+// StartContainer starts the Mailpit container, replacing an existing container of the same name,
+// and waits until its SMTP port accepts connections.
+func (mm *MailLifecycleManager) StartContainer() error {
+	name := mm.config.ContainerName
+	log.Infof("Starting the mail Docker container %s...", name)
+
+	err := startSidecar(sidecar{
+		name:    name,
+		image:   mm.config.Image,
+		network: mm.network,
+		service: MailService,
+		ports:   map[int]string{1025: strconv.Itoa(mm.config.Port), 8025: strconv.Itoa(mm.config.UIPort)},
+	})
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		err := mm.reachable()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("mailpit did not become ready at %s: %w", mm.addr(), err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	log.Infof("Mail Docker container %s started successfully; SMTP on %s, inbox at %s.", name, mm.addr(), mm.UIURL())
+	return nil
+}
+
+// StopContainer stops and removes the Mailpit container. Caught messages are discarded with it.
+func (mm *MailLifecycleManager) StopContainer() error {
+	name := mm.config.ContainerName
+	log.Infof("Stopping the mail Docker container %s...", name)
+	if err := stopSidecar(name); err != nil {
+		return fmt.Errorf("failed to stop the mail Docker container: %w", err)
+	}
+	log.Infof("Mail Docker container %s stopped successfully.", name)
+	return nil
+}
+
+// GetStatus returns the status of the Mailpit container and whether its SMTP port accepts connections.
+func (mm *MailLifecycleManager) GetStatus() (string, error) {
+	name := mm.config.ContainerName
+	output, err := containerStatus(name)
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		return fmt.Sprintf("Container %s does not exist", name), nil
+	}
+	if err := mm.reachable(); err != nil {
+		return fmt.Sprintf("Container %s: %s. SMTP is not reachable at %s: %v", name, output, mm.addr(), err), nil
+	}
+	return fmt.Sprintf("Container %s: %s. SMTP is reachable at %s, inbox at %s", name, output, mm.addr(), mm.UIURL()), nil
+}
+
+// addr returns the address of the SMTP server.
+func (mm *MailLifecycleManager) addr() string {
+	return net.JoinHostPort(mm.config.Host, strconv.Itoa(mm.config.Port))
+}
+
+// reachable checks that the SMTP port accepts connections.
+func (mm *MailLifecycleManager) reachable() error {
+	conn, err := net.DialTimeout("tcp", mm.addr(), 2*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,55 @@
+package lsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/client"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// mysqlPort is the port MySQL and MariaDB listen on inside their containers.
+const mysqlPort = 3306
+
+// RenderMySQLDockerfile renders the embedded Dockerfile of the image of a MySQL database from the image
+// settings, with the defaults of WithMySQLDefaults applied to empty settings. The image stores text as utf8mb4.
+// Locales and extensions are PostgreSQL settings, so it returns an error if either is set.
+func RenderMySQLDockerfile(settings config.DockerImageConfig) (string, error) {
+	settings = settings.WithMySQLDefaults()
+	if settings.Locale != "" || len(settings.Extensions) > 0 {
+		return "", fmt.Errorf("the locale and extensions of database.docker only apply to PostgreSQL images")
+	}
+	data, err := newDockerfileData(settings)
+	if err != nil {
+		return "", err
+	}
+	return renderDockerfile("Dockerfile.mysql", data)
+}
+
+// mysqlEnvironment returns the environment variables creating the configured user and database when a MySQL or
+// MariaDB container starts with an empty data directory. Both images read the MYSQL_ variables. The root
+// account gets a random password, which nothing needs, so the user cannot be root.
+func (dm *DBLifecycleManager) mysqlEnvironment() ([]string, error) {
+	db := dm.config.Database
+	if db.User == "root" {
+		return nil, fmt.Errorf("the user of a MySQL database cannot be root: set database.user to another name")
+	}
+	return []string{"MYSQL_USER=" + db.User, "MYSQL_PASSWORD=" + db.Password, "MYSQL_DATABASE=" + db.Name,
+		"MYSQL_RANDOM_ROOT_PASSWORD=yes"}, nil
+}
+
+// mysqlDump dumps the database with mariadb-dump, or with mysqldump on MySQL images, inside the database
+// container, and returns the dump. The dump is a single transaction, so it is consistent without locking out
+// writers. The password is passed in the environment of the command, never on a command line.
+func (dm *DBLifecycleManager) mysqlDump(ctx context.Context, cli *client.Client) ([]byte, error) {
+	db := dm.config.Database
+	env := []string{"MYSQL_PWD=" + db.Password}
+	args := []string{"--single-transaction", "-u", db.User, db.Name}
+	output, err := execInContainer(ctx, cli, db.ContainerName, env, append([]string{"mariadb-dump"}, args...))
+	var failed *execError
+	if errors.As(err, &failed) && failed.commandNotFound() {
+		output, err = execInContainer(ctx, cli, db.ContainerName, env, append([]string{"mysqldump"}, args...))
+	}
+	return output, err
+}
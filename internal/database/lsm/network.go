@@ -0,0 +1,55 @@
+package lsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	dockernetwork "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+// Service names of the containers on the Docker network, which other containers on it connect to.
+const (
+	DatabaseService = "db"
+	CacheService    = "cache"
+	MailService     = "mail"
+	StorageService  = "storage"
+	PoolerService   = "pooler"
+)
+
+// ensureNetwork creates the Docker network with the given name if it does not exist.
+func ensureNetwork(name string) error {
+	return withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		_, err := cli.NetworkInspect(ctx, name, dockernetwork.InspectOptions{})
+		if err == nil {
+			return nil
+		}
+		if !cerrdefs.IsNotFound(err) {
+			return fmt.Errorf("failed to inspect the Docker network %s: %w", name, dockerError(name, nil, err))
+		}
+		// The network may have been created by another command since it was inspected.
+		if _, err := cli.NetworkCreate(ctx, name, dockernetwork.CreateOptions{}); err != nil && !cerrdefs.IsConflict(err) {
+			return fmt.Errorf("failed to create the Docker network %s: %w", name, dockerError(name, nil, err))
+		}
+		log.Infof("Created the Docker network %s", name)
+		return nil
+	})
+}
+
+// ConnectContainer attaches the running container with the given name, such as a dockerized app, to network,
+// where it reaches the database as DatabaseService.
+func ConnectContainer(network, container string) error {
+	if err := ensureNetwork(network); err != nil {
+		return err
+	}
+	return withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		err := cli.NetworkConnect(ctx, network, container, &dockernetwork.EndpointSettings{})
+		if err == nil || strings.Contains(err.Error(), "already exists") {
+			return nil
+		}
+		return fmt.Errorf("failed to connect %s to the Docker network %s: %w", container, network,
+			dockerError(container, ErrContainerNotFound, err))
+	})
+}
@@ -0,0 +1,130 @@
+package lsm
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// PoolerLifecycleManager manages the optional pgbouncer container that pools the connections to the database
+// container, as a pooler does in production. pgbouncer reaches the database over the Docker network as
+// DatabaseService, and is published on the host on the port of the Pooler section of the configuration.
+type PoolerLifecycleManager struct {
+	config   config.PoolerConfig
+	database config.DatabaseConfig
+	network  string
+}
+
+// NewPoolerLifecycleManager creates a new PoolerLifecycleManager for the given configuration.
+func NewPoolerLifecycleManager(cfg *config.Config) *PoolerLifecycleManager {
+	return &PoolerLifecycleManager{
+		config:   cfg.Pooler.WithDefaults(),
+		database: cfg.Database,
+		network:  cfg.Docker.WithDefaults().Network,
+	}
+}
+
+// StartContainer starts the pgbouncer container, replacing an existing container of the same name, and waits
+// until it accepts connections. The database container must be running on the Docker network.
+func (pm *PoolerLifecycleManager) StartContainer() error {
+	name := pm.config.ContainerName
+	log.Infof("Starting the pooler Docker container %s...", name)
+
+	if err := startSidecar(pm.sidecar()); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		err := pm.reachable()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pgbouncer did not become ready at %s: %w", pm.addr(), err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	log.Infof("Pooler Docker container %s started successfully on %s in %s pool mode.", name, pm.addr(), pm.config.PoolMode)
+	return nil
+}
+
+// StopContainer stops and removes the pgbouncer container. It keeps no state, so nothing is lost.
+func (pm *PoolerLifecycleManager) StopContainer() error {
+	name := pm.config.ContainerName
+	log.Infof("Stopping the pooler Docker container %s...", name)
+	if err := stopSidecar(name); err != nil {
+		return fmt.Errorf("failed to stop the pooler Docker container: %w", err)
+	}
+	log.Infof("Pooler Docker container %s stopped successfully.", name)
+	return nil
+}
+
+// GetStatus returns the status of the pgbouncer container and whether it accepts connections.
+func (pm *PoolerLifecycleManager) GetStatus() (string, error) {
+	name := pm.config.ContainerName
+	output, err := containerStatus(name)
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		return fmt.Sprintf("Container %s does not exist", name), nil
+	}
+	if err := pm.reachable(); err != nil {
+		return fmt.Sprintf("Container %s: %s. pgbouncer is not reachable at %s: %v", name, output, pm.addr(), err), nil
+	}
+	return fmt.Sprintf("Container %s: %s. pgbouncer is reachable at %s in %s pool mode", name, output, pm.addr(), pm.config.PoolMode), nil
+}
+
+// sidecar returns the container of pgbouncer.
+func (pm *PoolerLifecycleManager) sidecar() sidecar {
+	return sidecar{
+		name:    pm.config.ContainerName,
+		image:   pm.config.Image,
+		network: pm.network,
+		service: PoolerService,
+		ports:   map[int]string{5432: strconv.Itoa(pm.config.Port)},
+		env:     pm.environment(),
+	}
+}
+
+// environment returns the environment configuring pgbouncer to pool the connections to the database.
+func (pm *PoolerLifecycleManager) environment() []string {
+	settings := [][2]string{
+		{"DB_HOST", DatabaseService},
+		{"DB_PORT", "5432"},
+		{"DB_USER", pm.database.User},
+		{"DB_PASSWORD", pm.database.Password},
+		{"DB_NAME", pm.database.Name},
+		// The password is kept in plain text, so pgbouncer can authenticate to servers using SCRAM.
+		{"AUTH_TYPE", "scram-sha-256"},
+		{"POOL_MODE", pm.config.PoolMode},
+		{"DEFAULT_POOL_SIZE", strconv.Itoa(pm.config.PoolSize)},
+		{"MAX_CLIENT_CONN", strconv.Itoa(pm.config.MaxClientConn)},
+		// lib/pq sets extra_float_digits when connecting, which pgbouncer refuses unless told to ignore it.
+		{"IGNORE_STARTUP_PARAMETERS", "extra_float_digits"},
+	}
+	env := make([]string, len(settings))
+	for i, setting := range settings {
+		env[i] = setting[0] + "=" + setting[1]
+	}
+	return env
+}
+
+// addr returns the address pgbouncer is published on.
+func (pm *PoolerLifecycleManager) addr() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(pm.config.Port))
+}
+
+// reachable checks that pgbouncer accepts connections.
+func (pm *PoolerLifecycleManager) reachable() error {
+	conn, err := net.DialTimeout("tcp", pm.addr(), 2*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
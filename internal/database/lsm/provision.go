@@ -0,0 +1,57 @@
+package lsm
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// WaitReady connects to the database server until it accepts connections or the timeout elapses.
+// It connects to the maintenance database, so it also waits for servers the database is yet to be provisioned on.
+func (dm *DBLifecycleManager) WaitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := dm.withMaintenanceDB(func(db *sql.DB) error {
+			return db.Ping()
+		})
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("database server at %s:%d did not become ready: %w",
+				dm.config.Database.Host, dm.config.Database.Port, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// Provision creates the configured database on the server if it does not exist, for servers not started
+// by StartContainer, such as the database service of a CI pipeline. It reports whether the database was created.
+func (dm *DBLifecycleManager) Provision() (bool, error) {
+	name := dm.config.Database.Name
+	created := false
+	err := dm.withMaintenanceDB(func(db *sql.DB) error {
+		if dm.config.Database.IsMySQL() {
+			result, err := db.Exec("CREATE DATABASE IF NOT EXISTS " + dialect.MySQL.Quote(name))
+			if err != nil {
+				return fmt.Errorf("failed to create database %s: %w", name, err)
+			}
+			// The server reports one row affected if the database was created, and none if it existed.
+			affected, _ := result.RowsAffected()
+			created = affected > 0
+			return nil
+		}
+		exists, err := databaseExists(db, name)
+		if err != nil || exists {
+			return err
+		}
+		if _, err := db.Exec("CREATE DATABASE " + quoteIdentifier(name)); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", name, err)
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
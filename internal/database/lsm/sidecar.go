@@ -0,0 +1,96 @@
+package lsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// sidecar describes the container of a development service run next to the database, such as Redis or MinIO.
+// The container joins the Docker network, where the other containers reach it as service.
+type sidecar struct {
+	name    string
+	image   string
+	network string
+	service string
+	// ports maps the TCP ports of the container to the ports of the host they are published on.
+	ports map[int]string
+	// env and cmd are passed to the container as they are, so they need no quoting.
+	env []string
+	cmd []string
+}
+
+// containerConfig returns the configuration the container of s is created with.
+func (s sidecar) containerConfig() (*container.Config, *container.HostConfig, *network.NetworkingConfig) {
+	config := &container.Config{Image: s.image, Env: s.env, Cmd: s.cmd, ExposedPorts: nat.PortSet{}}
+	hostConfig := &container.HostConfig{PortBindings: nat.PortMap{}}
+	for containerPort, hostPort := range s.ports {
+		port := nat.Port(fmt.Sprintf("%d/tcp", containerPort))
+		config.ExposedPorts[port] = struct{}{}
+		hostConfig.PortBindings[port] = []nat.PortBinding{{HostPort: hostPort}}
+	}
+	networking := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+		s.network: {Aliases: []string{s.service}},
+	}}
+	return config, hostConfig, networking
+}
+
+// startSidecar starts the container of s, replacing an existing container of the same name so it runs with the
+// current configuration. The image is pulled if it is not available locally, which may take a while, so the
+// requests get buildTimeout.
+func startSidecar(s sidecar) error {
+	if err := ensureNetwork(s.network); err != nil {
+		return err
+	}
+	config, hostConfig, networking := s.containerConfig()
+	return withDocker(buildTimeout, func(ctx context.Context, cli *client.Client) error {
+		if _, err := cli.ContainerInspect(ctx, s.name); err == nil {
+			log.Infof("Container %s already exists. Removing it...", s.name)
+			if err := removeContainer(ctx, cli, s.name); err != nil {
+				return fmt.Errorf("failed to remove existing container %s: %w", s.name, err)
+			}
+		}
+		if err := pullImage(ctx, cli, s.image); err != nil {
+			return err
+		}
+		created, err := cli.ContainerCreate(ctx, config, hostConfig, networking, nil, s.name)
+		if err != nil {
+			return fmt.Errorf("failed to create the %s docker container: %w", s.service, dockerError(s.image, ErrImageNotFound, err))
+		}
+		if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+			return fmt.Errorf("failed to start the %s docker container: %w\nLogs: %s", s.service,
+				dockerError(s.name, ErrContainerNotFound, err), containerLogs(ctx, cli, created.ID))
+		}
+		return nil
+	})
+}
+
+// stopSidecar stops and removes the container name. A container that does not exist is not an error.
+func stopSidecar(name string) error {
+	return withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		return removeContainer(ctx, cli, name)
+	})
+}
+
+// containerStatus returns the state of the container with the given name as described by describeState, such as
+// "running since 2025-01-01T12:00:00Z", or "" if the container does not exist.
+func containerStatus(name string) (string, error) {
+	var info container.InspectResponse
+	err := withDocker(dockerTimeout, func(ctx context.Context, cli *client.Client) error {
+		var err error
+		info, err = cli.ContainerInspect(ctx, name)
+		return dockerError(name, ErrContainerNotFound, err)
+	})
+	if errors.Is(err, ErrContainerNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get the status of the Docker container %s: %w", name, err)
+	}
+	return describeState(info.State), nil
+}
@@ -0,0 +1,26 @@
+package lsm
+
+import (
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSidecarContainerConfig(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{User: "grayv", Password: `p'a$s "word"`, Name: "app"},
+		Pooler:   config.PoolerConfig{Port: 6433},
+	}
+	pooler := NewPoolerLifecycleManager(cfg).sidecar()
+
+	config, hostConfig, networking := pooler.containerConfig()
+	assert.Equal(t, pooler.image, config.Image)
+	assert.Contains(t, config.Env, `DB_PASSWORD=p'a$s "word"`, "the password is passed as it is, without quoting")
+	assert.Empty(t, config.Cmd)
+	port := nat.Port("5432/tcp")
+	assert.Contains(t, config.ExposedPorts, port)
+	assert.Equal(t, nat.PortMap{port: {{HostPort: "6433"}}}, hostConfig.PortBindings)
+	assert.Equal(t, []string{PoolerService}, networking.EndpointsConfig[pooler.network].Aliases)
+}
@@ -0,0 +1,62 @@
+package lsm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+)
+
+// A SQLite database is a file opened by the process itself, so the lifecycle of its container is skipped: there
+// is no image to build and nothing to start or stop.
+
+// sqliteStart creates the directory of the database file, where the file is created on the first connection.
+func (dm *DBLifecycleManager) sqliteStart() error {
+	path := dm.config.Database.FilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create the directory of %s: %w", path, err)
+	}
+	log.Infof("The SQLite database %s needs no container; it is opened by each command.", path)
+	return nil
+}
+
+// sqliteNoContainer logs that action does not apply to the SQLite database.
+func (dm *DBLifecycleManager) sqliteNoContainer(action string) error {
+	log.Infof("The SQLite database %s has no container to %s.", dm.config.Database.FilePath(), action)
+	return nil
+}
+
+// sqliteStatus reports whether the database file exists and its size.
+func (dm *DBLifecycleManager) sqliteStatus() (string, error) {
+	path := dm.config.Database.FilePath()
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Sprintf("SQLite database %s does not exist yet; it is created on the first connection", path), nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read the SQLite database %s: %w", path, err)
+	}
+	return fmt.Sprintf("SQLite database %s exists. Size: %d bytes", path, info.Size()), nil
+}
+
+// sqliteBackup writes a consistent copy of the database to a timestamped file in dir with VACUUM INTO, which
+// other connections may keep writing during, and returns its path.
+func (dm *DBLifecycleManager) sqliteBackup(dir string) (string, error) {
+	conn, err := orm.NewConnection(&dm.config.Database)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	name := strings.TrimSuffix(filepath.Base(dm.config.Database.FilePath()), filepath.Ext(dm.config.Database.FilePath()))
+	fileName := filepath.Join(dir, fmt.Sprintf("%s-%s.db", name, time.Now().Format("20060102150405")))
+	if _, err := conn.GetDB().Exec("VACUUM INTO ?", fileName); err != nil {
+		return "", fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	log.Infof("Database backup written to %s", fileName)
+	return fileName, nil
+}
@@ -0,0 +1,134 @@
+package lsm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/storage"
+)
+
+// StorageLifecycleManager manages the optional MinIO container that provides S3-compatible object storage
+// for file uploads during development. The container publishes the S3 API on the port of the configured
+// endpoint and the MinIO console on port 9001; its root user is the configured access key.
+type StorageLifecycleManager struct {
+	config  config.StorageConfig
+	network string
+}
+
+// NewStorageLifecycleManager creates a new StorageLifecycleManager for the given configuration.
+func NewStorageLifecycleManager(cfg *config.Config) *StorageLifecycleManager {
+	return &StorageLifecycleManager{config: cfg.Storage.WithDefaults(), network: cfg.Docker.WithDefaults().Network}
+}
+
+// StartContainer starts the MinIO container, replacing an existing container of the same name,
+// waits until it is ready, and creates the configured bucket.
+func (sm *StorageLifecycleManager) StartContainer() error {
+	name := sm.config.ContainerName
+	log.Infof("Starting the storage Docker container %s...", name)
+
+	_, port, err := net.SplitHostPort(sm.config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid storage endpoint %q: %w", sm.config.Endpoint, err)
+	}
+	err = startSidecar(sidecar{
+		name:    name,
+		image:   sm.config.Image,
+		network: sm.network,
+		service: StorageService,
+		ports:   map[int]string{9000: port, 9001: "9001"},
+		env:     []string{"MINIO_ROOT_USER=" + sm.config.AccessKey, "MINIO_ROOT_PASSWORD=" + sm.config.SecretKey},
+		cmd:     []string{"server", "/data", "--console-address", ":9001"},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sm.waitReady(30 * time.Second); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sm.store().CreateBucket(ctx); err != nil {
+		return err
+	}
+
+	log.Infof("Storage Docker container %s started successfully; bucket %s is available at %s.", name, sm.config.Bucket, sm.config.Endpoint)
+	return nil
+}
+
+// StopContainer stops and removes the MinIO container. Uploaded files are discarded with it.
+func (sm *StorageLifecycleManager) StopContainer() error {
+	name := sm.config.ContainerName
+	log.Infof("Stopping the storage Docker container %s...", name)
+	if err := stopSidecar(name); err != nil {
+		return fmt.Errorf("failed to stop the storage Docker container: %w", err)
+	}
+	log.Infof("Storage Docker container %s stopped successfully.", name)
+	return nil
+}
+
+// GetStatus returns the status of the MinIO container and whether MinIO answers on the configured endpoint.
+func (sm *StorageLifecycleManager) GetStatus() (string, error) {
+	name := sm.config.ContainerName
+	output, err := containerStatus(name)
+	if err != nil {
+		return "", err
+	}
+	if output == "" {
+		return fmt.Sprintf("Container %s does not exist", name), nil
+	}
+	if err := sm.live(); err != nil {
+		return fmt.Sprintf("Container %s: %s. MinIO is not reachable at %s: %v", name, output, sm.config.Endpoint, err), nil
+	}
+	return fmt.Sprintf("Container %s: %s. MinIO is reachable at %s (console on port 9001)", name, output, sm.config.Endpoint), nil
+}
+
+// store returns an S3Storage for the configured bucket.
+func (sm *StorageLifecycleManager) store() *storage.S3Storage {
+	return storage.NewS3Storage(storage.S3Options{
+		Endpoint:  sm.config.Endpoint,
+		Bucket:    sm.config.Bucket,
+		AccessKey: sm.config.AccessKey,
+		SecretKey: sm.config.SecretKey,
+		Region:    sm.config.Region,
+		Secure:    sm.config.Secure,
+	})
+}
+
+// live calls the liveness endpoint of MinIO.
+func (sm *StorageLifecycleManager) live() error {
+	scheme := "http"
+	if sm.config.Secure {
+		scheme = "https"
+	}
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(scheme + "://" + sm.config.Endpoint + "/minio/health/live")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// waitReady polls the liveness endpoint until MinIO answers or the timeout elapses.
+func (sm *StorageLifecycleManager) waitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := sm.live()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("minio did not become ready at %s: %w", sm.config.Endpoint, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+)
+
+// Baseline records the loaded migrations up to and including version as applied, without running them, so grayv
+// can take over a database whose schema was created by other means. Version must be that of a loaded migration.
+// Migrations already recorded are left as they are, and the later migrations stay pending. It returns the number
+// of migrations recorded.
+func (m *Migrator) Baseline(version int64) (int, error) {
+	applied, err := baselineVersions(m.migrations, version, clock.OrReal(m.Clock).Now())
+	if err != nil {
+		return 0, err
+	}
+	return m.recordApplied(m.migrations, applied)
+}
+
+// baselineVersions returns the versions of migrations up to and including version, stamped at, or an error if
+// no migration has version.
+func baselineVersions(migrations []*Migration, version int64, at time.Time) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+	found := false
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			applied[migration.Version] = at
+		}
+		found = found || migration.Version == version
+	}
+	if !found {
+		return nil, fmt.Errorf("no migration has version %d; run db migrate-status to list them", version)
+	}
+	return applied, nil
+}
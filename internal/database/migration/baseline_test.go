@@ -0,0 +1,20 @@
+package migration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaselineVersions(t *testing.T) {
+	at := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	migrations := []*Migration{{Version: 20240901000000}, {Version: 20240902000000}, {Version: 20240903000000}}
+
+	applied, err := baselineVersions(migrations, 20240902000000, at)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]time.Time{20240901000000: at, 20240902000000: at}, applied)
+
+	_, err = baselineVersions(migrations, 20240904000000, at)
+	assert.ErrorContains(t, err, "no migration has version 20240904000000")
+}
@@ -0,0 +1,234 @@
+package migration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrator_SQLite(t *testing.T) {
+	conn, err := orm.NewConnection(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "grayv.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m := NewMigratorFor(conn, logger)
+	m.migrations = []*Migration{
+		{Version: 1, Name: "1_notes.sql", UpSQL: "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)", DownSQL: "DROP TABLE notes"},
+		{Version: 2, Name: "2_seed.sql", UpSQL: "INSERT INTO notes (body) VALUES ('a'), ('b')", DownSQL: "DELETE FROM notes"},
+		{Version: 3, Name: "3_tags.sql", UpSQL: "CREATE TABLE tags (id INTEGER PRIMARY KEY)", DownSQL: "DROP TABLE tags"},
+	}
+
+	pending, err := m.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 3, "without a migrations table, every migration is pending")
+
+	// Recording the first migration without running it, then applying the others.
+	recorded, err := m.Baseline(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, recorded)
+	_, err = conn.GetDB().Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+	assert.NoError(t, err)
+	assert.NoError(t, m.Migrate())
+
+	statuses, err := m.Status()
+	if assert.NoError(t, err) && assert.Len(t, statuses, 3) {
+		for _, status := range statuses {
+			assert.True(t, status.Applied, status.Name)
+			assert.NotNil(t, status.AppliedAt, status.Name)
+		}
+		assert.Nil(t, statuses[0].RowsAffected, "baselined migrations have no row count")
+		if assert.NotNil(t, statuses[1].RowsAffected) {
+			assert.Equal(t, int64(2), *statuses[1].RowsAffected)
+		}
+		if assert.NotNil(t, statuses[2].RowsAffected) {
+			assert.Zero(t, *statuses[2].RowsAffected, "SQLite reports the rows of the last INSERT for a CREATE TABLE")
+		}
+	}
+
+	assert.NoError(t, m.Rollback(1))
+	pending, err = m.Pending()
+	if assert.NoError(t, err) && assert.Len(t, pending, 1) {
+		assert.Equal(t, int64(3), pending[0].Version)
+	}
+	recorded, err = m.Baseline(2)
+	assert.NoError(t, err)
+	assert.Zero(t, recorded, "migrations already recorded are left as they are")
+}
+
+func TestMigrator_SQLiteWorkers(t *testing.T) {
+	conn, err := orm.NewConnection(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "grayv.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	var output bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&output)
+	m := NewMigratorFor(conn, logger)
+	m.Workers = 4
+	for i := int64(1); i <= 8; i++ {
+		table := fmt.Sprintf("t%d", i)
+		m.migrations = append(m.migrations, &Migration{Version: i, Name: table + ".sql", Parallel: true,
+			UpSQL: fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY); INSERT INTO %s DEFAULT VALUES", table, table)})
+	}
+
+	// Independent migrations are applied one by one rather than contending for the lock of the database.
+	assert.NoError(t, m.Migrate())
+	assert.Contains(t, output.String(), "Applying migrations one by one")
+	pending, err := m.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigrationsTableSQL(t *testing.T) {
+	assert.Len(t, createMigrationsTableStatements(dialect.Postgres), 2, "PostgreSQL tables may predate the telemetry columns")
+	statements := createMigrationsTableStatements(dialect.SQLite)
+	if assert.Len(t, statements, 1) {
+		assert.Contains(t, statements[0], "applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP")
+	}
+	assert.Equal(t, "SELECT to_regclass($1) IS NOT NULL", orm.Rebind(tableExistsQuery(dialect.Postgres), orm.Dollar))
+}
+
+func TestMigrationsTableSQL_MySQL(t *testing.T) {
+	statements := createMigrationsTableStatements(dialect.MySQL)
+	if assert.Len(t, statements, 1, "MySQL cannot add a column only if it is missing") {
+		assert.Contains(t, statements[0], "applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP")
+	}
+	assert.Equal(t, "SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		tableExistsQuery(dialect.MySQL))
+	assert.Contains(t, columnExistsQuery(dialect.MySQL), "table_schema = DATABASE()")
+	assert.NotContains(t, columnExistsQuery(dialect.MySQL), "current_schema")
+
+	m := &Migrator{Dialect: dialect.MySQL}
+	assert.Equal(t, orm.Question, m.placeholders())
+	query, params := m.build(orm.NewQuery(migrationsTableName).Delete().Where("version = ?", int64(3)))
+	assert.Equal(t, "DELETE FROM migrations WHERE version = ?", query)
+	assert.Equal(t, []interface{}{int64(3)}, params)
+}
+
+func TestLoadMigrations_SQLite(t *testing.T) {
+	conn, err := orm.NewConnection(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "grayv.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m := NewMigratorFor(conn, logger)
+	if !assert.NoError(t, m.LoadMigrations()) {
+		return
+	}
+	postgres := NewMigrator(nil, logger)
+	assert.NoError(t, postgres.LoadMigrations())
+	assert.Equal(t, versions(postgres.Migrations()), versions(m.Migrations()), "every built-in migration has a SQLite version")
+
+	if !assert.NoError(t, m.Migrate()) {
+		return
+	}
+	db := conn.GetDB()
+	result, err := db.Exec("INSERT INTO users (username, email, password_hash) VALUES ('ada', 'ada@example.com', 'x')")
+	if assert.NoError(t, err) {
+		id, err := result.LastInsertId()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), id, "ids are assigned by SQLite")
+	}
+	var strategy string
+	_, err = db.Exec("INSERT INTO models (name, fields) VALUES ('Post', '[]')")
+	assert.NoError(t, err)
+	assert.NoError(t, db.QueryRow("SELECT id_strategy FROM models WHERE name = 'Post'").Scan(&strategy))
+	assert.Equal(t, "serial", strategy)
+
+	_, err = db.Exec("INSERT INTO model_history (model_name, version, fields, author) VALUES ('Post', 1, '[]', 'ada')")
+	assert.NoError(t, err)
+	_, err = db.Exec("UPDATE model_history SET note = 'rewritten'")
+	assert.ErrorContains(t, err, "append-only")
+	_, err = db.Exec("DELETE FROM model_history")
+	assert.ErrorContains(t, err, "append-only")
+
+	assert.NoError(t, m.Rollback(len(m.Migrations())))
+	pending, err := m.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, len(m.Migrations()), "every migration rolls back")
+}
+
+func TestLoadMigrations_MySQL(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m := NewMigrator(nil, logger)
+	m.Dialect = dialect.MySQL
+	if !assert.NoError(t, m.LoadMigrations()) {
+		return
+	}
+	postgres := NewMigrator(nil, logger)
+	assert.NoError(t, postgres.LoadMigrations())
+	assert.Equal(t, versions(postgres.Migrations()), versions(m.Migrations()), "every built-in migration has a MySQL version")
+
+	// The statements use none of the PostgreSQL syntax MySQL and MariaDB reject.
+	for _, migration := range m.Migrations() {
+		for _, statement := range append(orm.SplitStatements(migration.UpSQL), orm.SplitStatements(migration.DownSQL)...) {
+			for _, postgresOnly := range []string{"SERIAL", "JSONB", "WITH TIME ZONE", "TEXT[]", "$$", "ADD COLUMN IF NOT EXISTS", "DROP COLUMN IF EXISTS", "CREATE INDEX"} {
+				assert.NotContains(t, statement, postgresOnly, migration.Name)
+			}
+		}
+	}
+}
+
+func TestLoadMigrationDir(t *testing.T) {
+	conn, err := orm.NewConnection(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "grayv.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m := NewMigratorFor(conn, logger)
+	if !assert.NoError(t, m.LoadMigrations()) {
+		return
+	}
+	embedded := len(m.Migrations())
+
+	collision := writeFiles(t, map[string]string{
+		"20230601000000_notes.sql": "-- Up\nCREATE TABLE notes (id INTEGER PRIMARY KEY);\n-- Down\nDROP TABLE notes;\n",
+	})
+	assert.ErrorContains(t, m.LoadMigrationDir(collision), "renumber it first")
+	assert.Len(t, m.Migrations(), embedded, "a directory with a colliding version is not loaded")
+
+	// Imported migrations are applied, listed, and rolled back alongside the embedded ones.
+	out := writeFiles(t, map[string]string{
+		"29990101000000_notes.sql": "-- Up\nCREATE TABLE notes (id INTEGER PRIMARY KEY);\n-- Down\nDROP TABLE notes;\n",
+	})
+	if !assert.NoError(t, m.LoadMigrationDir(out)) || !assert.NoError(t, m.Migrate()) {
+		return
+	}
+	statuses, err := m.Status()
+	if assert.NoError(t, err) && assert.Len(t, statuses, embedded+1) {
+		assert.Equal(t, int64(29990101000000), statuses[embedded].Version)
+		assert.True(t, statuses[embedded].Applied)
+		assert.False(t, statuses[embedded].Missing)
+	}
+	assert.NoError(t, m.Rollback(1))
+	pending, err := m.Pending()
+	if assert.NoError(t, err) && assert.Len(t, pending, 1) {
+		assert.Equal(t, int64(29990101000000), pending[0].Version)
+	}
+}
+
+// versions returns the versions of migrations.
+func versions(migrations []*Migration) []int64 {
+	var v []int64
+	for _, migration := range migrations {
+		v = append(v, migration.Version)
+	}
+	return v
+}
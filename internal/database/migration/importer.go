@@ -0,0 +1,313 @@
+package migration
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+)
+
+// Tool is another migration tool whose migrations can be imported by ImportMigrations.
+type Tool string
+
+const (
+	// GolangMigrate is github.com/golang-migrate/migrate, whose migrations are pairs of files such as
+	// 000001_create_users.up.sql and 000001_create_users.down.sql.
+	GolangMigrate Tool = "golang-migrate"
+	// Goose is github.com/pressly/goose, whose migrations are files such as 20240901120000_create_users.sql
+	// holding both directions, marked by "-- +goose Up" and "-- +goose Down".
+	Goose Tool = "goose"
+)
+
+// ParseTool returns the Tool named name.
+func ParseTool(name string) (Tool, error) {
+	switch tool := Tool(name); tool {
+	case GolangMigrate, Goose:
+		return tool, nil
+	}
+	return "", fmt.Errorf("unknown migration tool %q: use %s or %s", name, GolangMigrate, Goose)
+}
+
+// Table returns the table in which t records the applied migrations by default.
+func (t Tool) Table() string {
+	if t == Goose {
+		return "goose_db_version"
+	}
+	return "schema_migrations"
+}
+
+var (
+	// golangMigrateFile matches the name of a golang-migrate migration file, capturing its version, title, and
+	// direction.
+	golangMigrateFile = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+	// gooseFile matches the name of a goose migration file, capturing its version, title, and extension.
+	gooseFile = regexp.MustCompile(`^([0-9]+)_(.+)\.(sql|go)$`)
+	// gooseAnnotation matches a goose annotation, capturing its name, such as Up or StatementBegin.
+	gooseAnnotation = regexp.MustCompile(`^--\s*\+goose\s+(.+?)\s*$`)
+)
+
+// ImportMigrations reads the migrations of tool in dir and returns them as grayv migrations, sorted by version,
+// along with warnings about what could not be carried over. Each migration keeps the version and title of its
+// files, so it is named like 000001_create_users.sql; see FormatMigration for its content. Files that are not
+// migrations of tool are ignored.
+func ImportMigrations(tool Tool, dir string) ([]*Migration, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var migrations []*Migration
+	var warnings []string
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		pattern := golangMigrateFile
+		if tool == Goose {
+			pattern = gooseFile
+		}
+		match := pattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid migration version %s: %w", entry.Name(), err)
+		}
+		if tool == Goose && match[3] == "go" {
+			warnings = append(warnings, fmt.Sprintf("%s is a Go migration and was not imported; rewrite it in SQL", entry.Name()))
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+
+		name := match[1] + "_" + match[2] + ".sql"
+		migration, ok := byVersion[version]
+		switch {
+		case !ok:
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+			migrations = append(migrations, migration)
+		case migration.Name != name || tool == Goose:
+			return nil, nil, fmt.Errorf("migrations %s and %s have the same version %d", migration.Name, name, version)
+		}
+
+		if tool == Goose {
+			notes, err := splitGoose(migration, string(content))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse migration file %s: %w", entry.Name(), err)
+			}
+			warnings = append(warnings, notes...)
+		} else if match[3] == "up" {
+			migration.UpSQL = strings.TrimSpace(string(content))
+		} else {
+			migration.DownSQL = strings.TrimSpace(string(content))
+		}
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for _, migration := range migrations {
+		if strings.Contains(migration.UpSQL, "-- Down") || strings.Contains(migration.DownSQL, "-- Down") {
+			return nil, nil, fmt.Errorf("migration %s contains \"-- Down\", which separates the directions of a grayv migration; reword that line first", migration.Name)
+		}
+		migration.Timestamp = clock.Real.Now()
+		parseDirectives(migration)
+	}
+	return migrations, warnings, nil
+}
+
+// splitGoose sets the up and down SQL of migration from content, a goose migration file, leaving out the goose
+// annotations. It returns warnings for the annotations grayv has no equivalent for.
+func splitGoose(migration *Migration, content string) ([]string, error) {
+	var up, down []string
+	var section *[]string
+	var warnings []string
+	for _, line := range strings.Split(content, "\n") {
+		match := gooseAnnotation.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			if section != nil {
+				*section = append(*section, line)
+			}
+			continue
+		}
+		switch annotation := match[1]; strings.ToUpper(annotation) {
+		case "UP":
+			section = &up
+		case "DOWN":
+			section = &down
+		case "STATEMENTBEGIN", "STATEMENTEND":
+			// Statements are split at the semicolons that end them, whatever their body holds.
+		case "NO TRANSACTION":
+			warnings = append(warnings, fmt.Sprintf("%s ran outside a transaction with goose, but grayv-lsm applies every migration in one", migration.Name))
+		case "ENVSUB ON":
+			warnings = append(warnings, fmt.Sprintf("%s substitutes environment variables with goose; grayv-lsm substitutes ${NAME} from the variables of the configuration instead", migration.Name))
+		case "ENVSUB OFF":
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: unknown goose annotation %q was left out", migration.Name, annotation))
+		}
+	}
+	if section == nil {
+		return nil, errors.New(`no "-- +goose Up" annotation`)
+	}
+	migration.UpSQL = strings.TrimSpace(strings.Join(up, "\n"))
+	migration.DownSQL = strings.TrimSpace(strings.Join(down, "\n"))
+	return warnings, nil
+}
+
+// FormatMigration returns the content of the grayv migration file of migration, read back by ReadMigrationDir:
+// its up SQL under "-- Up" and its down SQL under "-- Down".
+func FormatMigration(migration *Migration) string {
+	return "-- Up\n" + migration.UpSQL + "\n\n-- Down\n" + migration.DownSQL + "\n"
+}
+
+// gooseRecord is a row of the goose table, recording that a version was applied or rolled back.
+type gooseRecord struct {
+	version   int64
+	applied   bool
+	timestamp time.Time
+}
+
+// gooseApplied returns the versions applied according to records, oldest record first, with the time they were
+// last applied. The last record of a version tells whether it is applied, and version 0, which goose records
+// when it creates its table, is left out.
+func gooseApplied(records []gooseRecord) map[int64]time.Time {
+	applied := make(map[int64]time.Time)
+	for _, record := range records {
+		switch {
+		case record.version == 0:
+		case record.applied:
+			applied[record.version] = record.timestamp
+		default:
+			delete(applied, record.version)
+		}
+	}
+	return applied
+}
+
+// golangMigrateApplied returns the versions of migrations applied according to golang-migrate, which only
+// records the version the database is at: every migration up to it, stamped at. A dirty version failed
+// halfway and must be fixed first.
+func golangMigrateApplied(current int64, dirty bool, migrations []*Migration, at time.Time) (map[int64]time.Time, error) {
+	if dirty {
+		return nil, fmt.Errorf("migration %d failed and the database is dirty; fix it and run golang-migrate force first", current)
+	}
+	applied := make(map[int64]time.Time)
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			applied[migration.Version] = at
+		}
+	}
+	return applied, nil
+}
+
+// readApplied reads the versions tool recorded as applied in table of the database of m.
+func (m *Migrator) readApplied(tool Tool, table string, migrations []*Migration) (map[int64]time.Time, error) {
+	table = m.Dialect.Quote(table)
+
+	if tool == GolangMigrate {
+		var current int64
+		var dirty bool
+		err := m.db.QueryRow(fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", table)).Scan(&current, &dirty)
+		if errors.Is(err, sql.ErrNoRows) {
+			return map[int64]time.Time{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", table, err)
+		}
+		return golangMigrateApplied(current, dirty, migrations, clock.OrReal(m.Clock).Now())
+	}
+
+	rows, err := m.db.Query(fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id", table))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", table, err)
+	}
+	defer rows.Close()
+	var records []gooseRecord
+	now := clock.OrReal(m.Clock).Now()
+	for rows.Next() {
+		var record gooseRecord
+		var timestamp sql.NullTime
+		if err := rows.Scan(&record.version, &record.applied, &timestamp); err != nil {
+			return nil, fmt.Errorf("error scanning %s: %w", table, err)
+		}
+		record.timestamp = now
+		if timestamp.Valid {
+			record.timestamp = timestamp.Time
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", table, err)
+	}
+	return gooseApplied(records), nil
+}
+
+// Backfill records in the migrations table the migrations, imported by ImportMigrations, that tool applied
+// according to its table, such as schema_migrations, so they are not applied again. Migrations already recorded
+// are left as they are, and so are the versions tool applied that are not among migrations. It returns the
+// number of migrations recorded.
+func (m *Migrator) Backfill(tool Tool, table string, migrations []*Migration) (int, error) {
+	applied, err := m.readApplied(tool, table, migrations)
+	if err != nil {
+		return 0, err
+	}
+	return m.recordApplied(migrations, applied)
+}
+
+// recordApplied records in the migrations table the migrations whose version is in applied, as applied at the
+// time it maps them to, without running them. Migrations already recorded are left as they are. It returns the
+// number of migrations recorded.
+func (m *Migrator) recordApplied(migrations []*Migration, applied map[int64]time.Time) (int, error) {
+	if err := m.createMigrationsTable(); err != nil {
+		return 0, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	// The recorded versions are skipped rather than counted from the rows each insert affects, which MySQL also
+	// reports for rows it finds without inserting.
+	versions, err := m.getAppliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+	recorded := make(map[int64]bool, len(versions))
+	for _, version := range versions {
+		recorded[version] = true
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	count := 0
+	for _, migration := range migrations {
+		at, ok := applied[migration.Version]
+		if !ok || recorded[migration.Version] {
+			continue
+		}
+		insert, params := m.build(orm.NewQuery(migrationsTableName).Insert("version", "name", "applied_at").
+			Values(migration.Version, migration.Name, at))
+		if _, err := tx.Exec(insert, params...); err != nil {
+			return 0, fmt.Errorf("error recording migration %s: %w", migration.Name, err)
+		}
+		recorded[migration.Version] = true
+		count++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing migration records: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,115 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles writes files, by name, to a new directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestImportMigrations_GolangMigrate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"000002_add_bio.up.sql":        "ALTER TABLE users ADD COLUMN bio TEXT;\n",
+		"000001_create_users.up.sql":   "CREATE TABLE users (id SERIAL PRIMARY KEY);\n",
+		"000001_create_users.down.sql": "DROP TABLE users;\n",
+		"README.md":                    "not a migration",
+	})
+
+	migrations, warnings, err := ImportMigrations(GolangMigrate, dir)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	if assert.Len(t, migrations, 2) {
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "000001_create_users.sql", migrations[0].Name)
+		assert.Equal(t, "DROP TABLE users;", migrations[0].DownSQL)
+		assert.Equal(t, "000002_add_bio.sql", migrations[1].Name)
+		assert.Empty(t, migrations[1].DownSQL)
+	}
+
+	// The converted file reads back as the same migration.
+	out := writeFiles(t, map[string]string{migrations[0].Name: FormatMigration(migrations[0])})
+	m := NewMigrator(nil, logrus.New())
+	m.Clock = clock.NewFake(time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, m.LoadMigrationDir(out))
+	read := m.Migrations()
+	if assert.Len(t, read, 1) {
+		assert.Equal(t, int64(1), read[0].Version)
+		assert.Equal(t, time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC), read[0].Timestamp, "files are stamped by the clock of the migrator")
+		assert.Equal(t, "-- Up\nCREATE TABLE users (id SERIAL PRIMARY KEY);", read[0].UpSQL)
+		assert.Equal(t, "DROP TABLE users;", read[0].DownSQL)
+	}
+}
+
+func TestImportMigrations_Goose(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"20240901120000_create_users.sql": `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id SERIAL PRIMARY KEY);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE users;
+`,
+		"20240902120000_index_users.sql": `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY users_id ON users (id);
+`,
+		"20240903120000_backfill.go": "package migrations",
+	})
+
+	migrations, warnings, err := ImportMigrations(Goose, dir)
+	assert.NoError(t, err)
+	if assert.Len(t, migrations, 2) {
+		assert.Equal(t, "20240901120000_create_users.sql", migrations[0].Name)
+		assert.Equal(t, "CREATE TABLE users (id SERIAL PRIMARY KEY);", migrations[0].UpSQL)
+		assert.Equal(t, "DROP TABLE users;", migrations[0].DownSQL)
+	}
+	if assert.Len(t, warnings, 2) {
+		assert.Contains(t, warnings[0], "outside a transaction")
+		assert.Contains(t, warnings[1], "Go migration")
+	}
+
+	dir = writeFiles(t, map[string]string{"1_plain.sql": "CREATE TABLE t (id INT);"})
+	_, _, err = ImportMigrations(Goose, dir)
+	assert.ErrorContains(t, err, "-- +goose Up")
+}
+
+func TestImportMigrations_DownMarker(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"1_note.up.sql": "-- Downtime expected\nSELECT 1;"})
+	_, _, err := ImportMigrations(GolangMigrate, dir)
+	assert.ErrorContains(t, err, "1_note.sql contains \"-- Down\"")
+}
+
+func TestApplied(t *testing.T) {
+	at := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	migrations := []*Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	applied, err := golangMigrateApplied(2, false, migrations, at)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]time.Time{1: at, 2: at}, applied)
+	_, err = golangMigrateApplied(2, true, migrations, at)
+	assert.ErrorContains(t, err, "dirty")
+
+	later := at.Add(time.Hour)
+	assert.Equal(t, map[int64]time.Time{1: at}, gooseApplied([]gooseRecord{
+		{version: 0, applied: true, timestamp: at},
+		{version: 1, applied: true, timestamp: at},
+		{version: 2, applied: true, timestamp: at},
+		{version: 2, applied: false, timestamp: later},
+	}))
+}
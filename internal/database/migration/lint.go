@@ -0,0 +1,237 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+)
+
+// Lint rules, named in violations and in "-- lint:ignore" directives.
+const (
+	// RuleDropColumn flags a dropped column that the down migration does not add back, so rolling back
+	// cannot restore it.
+	RuleDropColumn = "drop-column-irreversible"
+	// RuleNotNullWithoutDefault flags a NOT NULL column added to an existing table without a default, which
+	// fails on a table with rows.
+	RuleNotNullWithoutDefault = "not-null-without-default"
+	// RuleVolatileDefault flags a column added to an existing table with a default that is computed per row,
+	// which rewrites the whole table while holding an exclusive lock.
+	RuleVolatileDefault = "volatile-default"
+	// RuleIndexNotConcurrent flags an index built without CONCURRENTLY on a big existing table, which blocks
+	// writes to the table until the index is built.
+	RuleIndexNotConcurrent = "index-not-concurrent"
+)
+
+// DefaultBigTableRows is the estimated number of rows from which a table is big enough for RuleIndexNotConcurrent.
+const DefaultBigTableRows = 100000
+
+// Violation is a dangerous statement found in a migration by a Linter.
+type Violation struct {
+	Migration string `json:"migration"`
+	Rule      string `json:"rule"`
+	Statement string `json:"statement"`
+	Message   string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s\n    %s", v.Migration, v.Rule, v.Message, v.Statement)
+}
+
+// Linter statically checks migrations for statements that are dangerous to run on a live database.
+//
+// A migration skips the rules listed in a "-- lint:ignore rule[, rule]" comment in its up SQL.
+type Linter struct {
+	// TableRows is the estimated number of rows of each existing table. If it is nil, the size of the tables
+	// is unknown and every existing table is treated as big.
+	TableRows map[string]int64
+	// BigTableRows is the estimated number of rows from which a table is big. Zero means DefaultBigTableRows.
+	BigTableRows int64
+}
+
+var (
+	lineCommentPattern = regexp.MustCompile(`--[^\n]*`)
+	ignorePattern      = regexp.MustCompile(`(?i)--\s*lint:ignore[ \t]+([\w \t,-]+)`)
+	createTablePattern = regexp.MustCompile(`(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	alterTablePattern  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+(.*)$`)
+	createIndexPattern = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:[\w"]+\s+)?ON\s+(?:ONLY\s+)?([\w."]+)`)
+	dropColumnPattern  = regexp.MustCompile(`(?is)^DROP\s+COLUMN\s+(?:IF\s+EXISTS\s+)?([\w"]+)`)
+	addColumnPattern   = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?([\w"]+)\s+(.*)$`)
+	notNullPattern     = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	defaultPattern     = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	volatilePattern    = regexp.MustCompile(`(?i)\b(random|clock_timestamp|gen_random_uuid|uuid_generate_v[14]\w*|nextval|timeofday)\s*\(`)
+	constraintPattern  = regexp.MustCompile(`(?i)^(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|EXCLUDE)\b`)
+)
+
+// Lint checks migrations, in the order they run, and returns the violations found. Tables created by one
+// of the migrations and absent from TableRows are new and empty, so the rules about existing tables skip them.
+func (l Linter) Lint(migrations []*Migration) []Violation {
+	bigTableRows := l.BigTableRows
+	if bigTableRows == 0 {
+		bigTableRows = DefaultBigTableRows
+	}
+
+	created := make(map[string]bool)
+	isNew := func(table string) bool {
+		_, exists := l.TableRows[table]
+		return created[table] && !exists
+	}
+	isBig := func(table string) bool {
+		if isNew(table) {
+			return false
+		}
+		rows, ok := l.TableRows[table]
+		return l.TableRows == nil || !ok || rows >= bigTableRows
+	}
+
+	var violations []Violation
+	for _, migration := range migrations {
+		ignored := ignoredRules(migration.UpSQL)
+		report := func(rule, statement, format string, args ...interface{}) {
+			if ignored[rule] {
+				return
+			}
+			violations = append(violations, Violation{
+				Migration: migration.Name,
+				Rule:      rule,
+				Statement: summarize(statement),
+				Message:   fmt.Sprintf(format, args...),
+			})
+		}
+
+		for _, statement := range orm.SplitStatements(migration.UpSQL) {
+			statement = strings.TrimSpace(lineCommentPattern.ReplaceAllString(statement, ""))
+
+			if match := createTablePattern.FindStringSubmatch(statement); match != nil {
+				created[tableName(match[1])] = true
+				continue
+			}
+
+			if match := createIndexPattern.FindStringSubmatch(statement); match != nil {
+				table := tableName(match[2])
+				if match[1] == "" && isBig(table) {
+					report(RuleIndexNotConcurrent, statement,
+						"building an index on %s blocks writes to it until the index is built; use CREATE INDEX CONCURRENTLY", table)
+				}
+				continue
+			}
+
+			match := alterTablePattern.FindStringSubmatch(statement)
+			if match == nil {
+				continue
+			}
+			table := tableName(match[1])
+			for _, action := range splitActions(match[2]) {
+				if drop := dropColumnPattern.FindStringSubmatch(action); drop != nil {
+					column := identifier(drop[1])
+					if !addsColumn(migration.DownSQL, column) {
+						report(RuleDropColumn, statement,
+							"column %s of %s is dropped, but the down migration does not add it back", column, table)
+					}
+					continue
+				}
+
+				add := addColumnPattern.FindStringSubmatch(action)
+				if add == nil || constraintPattern.MatchString(add[1]) || isNew(table) {
+					continue
+				}
+				column, definition := identifier(add[1]), add[2]
+				hasDefault := defaultPattern.MatchString(definition)
+				if notNullPattern.MatchString(definition) && !hasDefault {
+					report(RuleNotNullWithoutDefault, statement,
+						"column %s is added to %s as NOT NULL without a default, which fails if the table has rows; add a DEFAULT", column, table)
+				}
+				if hasDefault && volatilePattern.MatchString(definition) {
+					report(RuleVolatileDefault, statement,
+						"column %s is added to %s with a volatile default, which rewrites the table under an exclusive lock", column, table)
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// TableRows returns the estimated number of rows of each table in the current schema, from the planner statistics.
+func TableRows(db *sql.DB) (map[string]int64, error) {
+	rows, err := db.Query(`SELECT relname, GREATEST(reltuples, 0)::bigint FROM pg_class
+WHERE relkind IN ('r', 'p') AND relnamespace = current_schema()::regnamespace`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	tableRows := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, err
+		}
+		tableRows[name] = count
+	}
+	return tableRows, rows.Err()
+}
+
+// ignoredRules returns the rules listed in the "-- lint:ignore" comments of sql.
+func ignoredRules(sql string) map[string]bool {
+	ignored := make(map[string]bool)
+	for _, match := range ignorePattern.FindAllStringSubmatch(sql, -1) {
+		for _, rule := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			ignored[strings.ToLower(rule)] = true
+		}
+	}
+	return ignored
+}
+
+// addsColumn reports whether sql adds back column, as a down migration undoing a DROP COLUMN does.
+func addsColumn(sql, column string) bool {
+	pattern := `(?i)\bADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?"?` + regexp.QuoteMeta(column) + `"?(\s|$)`
+	return regexp.MustCompile(pattern).MatchString(lineCommentPattern.ReplaceAllString(sql, ""))
+}
+
+// splitActions splits the actions of an ALTER TABLE statement at the commas outside parentheses.
+func splitActions(actions string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range actions {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(actions[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(actions[start:]))
+}
+
+// tableName returns the unquoted, lowercase name of table, without its schema.
+func tableName(table string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	return identifier(table)
+}
+
+// identifier returns name unquoted, and lowercased unless it was quoted, as PostgreSQL folds it.
+func identifier(name string) string {
+	if unquoted := strings.Trim(name, `"`); unquoted != name {
+		return unquoted
+	}
+	return strings.ToLower(name)
+}
+
+// summarize returns statement on a single line, shortened to fit in a report.
+func summarize(statement string) string {
+	statement = strings.Join(strings.Fields(statement), " ")
+	if len(statement) > 100 {
+		statement = statement[:97] + "..."
+	}
+	return statement
+}
@@ -0,0 +1,73 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(violations []Violation) []string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestLint_DropColumn(t *testing.T) {
+	irreversible := &Migration{Name: "1_drop.sql", UpSQL: "ALTER TABLE users DROP COLUMN nickname;", DownSQL: ""}
+	reversible := &Migration{Name: "2_drop.sql", UpSQL: "ALTER TABLE users DROP COLUMN IF EXISTS bio;",
+		DownSQL: "ALTER TABLE users ADD COLUMN bio TEXT;"}
+
+	violations := Linter{}.Lint([]*Migration{irreversible, reversible})
+
+	assert.Equal(t, []string{RuleDropColumn}, rules(violations))
+	assert.Equal(t, "1_drop.sql", violations[0].Migration)
+	assert.Contains(t, violations[0].Message, "nickname")
+}
+
+func TestLint_AddColumn(t *testing.T) {
+	migrations := []*Migration{{Name: "1_add.sql", UpSQL: `
+ALTER TABLE users ADD COLUMN plan VARCHAR(20) NOT NULL;
+ALTER TABLE users ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active', ADD COLUMN score NUMERIC(10, 2);
+ALTER TABLE users ADD COLUMN token UUID DEFAULT gen_random_uuid();
+ALTER TABLE users ADD CONSTRAINT users_plan_check CHECK (plan <> '');
+`}}
+
+	violations := Linter{}.Lint(migrations)
+
+	assert.Equal(t, []string{RuleNotNullWithoutDefault, RuleVolatileDefault}, rules(violations))
+}
+
+func TestLint_Index(t *testing.T) {
+	migrations := []*Migration{
+		{Name: "1_posts.sql", UpSQL: `-- Up
+CREATE TABLE posts (id SERIAL PRIMARY KEY, title TEXT NOT NULL);
+CREATE INDEX idx_posts_title ON posts (title);
+ALTER TABLE posts ADD COLUMN slug TEXT NOT NULL;`},
+		{Name: "2_indexes.sql", UpSQL: `
+CREATE INDEX idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_key ON public.events (key);
+CREATE INDEX CONCURRENTLY idx_orders_user ON orders (user_id);
+CREATE INDEX idx_posts_slug ON posts (slug);`},
+	}
+
+	linter := Linter{TableRows: map[string]int64{"users": 500, "events": 2000000, "orders": 3000000}}
+	violations := linter.Lint(migrations)
+
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, RuleIndexNotConcurrent, violations[0].Rule)
+		assert.Contains(t, violations[0].Message, "events")
+	}
+
+	// Without table sizes, every existing table is big.
+	assert.Equal(t, []string{RuleIndexNotConcurrent, RuleIndexNotConcurrent, RuleIndexNotConcurrent}, rules(Linter{}.Lint(migrations[1:])))
+}
+
+func TestLint_Ignore(t *testing.T) {
+	migrations := []*Migration{{Name: "1_ignore.sql", UpSQL: `-- lint:ignore index-not-concurrent, drop-column-irreversible
+CREATE INDEX idx_users_email ON users (email);
+ALTER TABLE users DROP COLUMN nickname;`}}
+
+	assert.Empty(t, Linter{}.Lint(migrations))
+}
@@ -1,10 +1,21 @@
 package migration
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/ooyeku/grayv-lsm/embedded"
+	"github.com/ooyeku/grayv-lsm/internal/database/vars"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -12,6 +23,9 @@ import (
 	"time"
 )
 
+// tracerName is the instrumentation scope of the spans recorded for migrations.
+const tracerName = "github.com/ooyeku/grayv-lsm/internal/database/migration"
+
 // logger is a pointer to a logrus.Logger instance. It is used for logging messages and
 // configuring log settings.
 var logger *logrus.Logger
@@ -28,13 +42,17 @@ func init() {
 //   - Name: string - the name of the migration
 //   - UpSQL: string - the SQL code to apply the migration
 //   - DownSQL: string - the SQL code to rollback the migration
-//   - Timestamp: time.Time - the timestamp when the migration was created
+//   - Timestamp: time.Time - the time the migration was loaded, read from the Clock of the Migrator
+//   - Parallel: bool - whether a "-- migrate:parallel" directive marks it safe to apply concurrently
+//   - Objects: []string - the database objects a "-- migrate:objects" directive declares it touches
 type Migration struct {
 	Version   int64
 	Name      string
 	UpSQL     string
 	DownSQL   string
 	Timestamp time.Time
+	Parallel  bool
+	Objects   []string
 }
 
 // Migrator represents a database migrator that can apply and rollback migrations.
@@ -45,6 +63,11 @@ type Migration struct {
 // - db: The *sql.DB instance representing the database connection.
 // - migrations: A slice of *Migration instances representing the available migrations.
 // - logger: The *logrus.Logger instance used for logging migration events.
+// - ContinueOnError: Whether a failing statement is skipped rather than failing its migration.
+// - Workers: The number of independent migrations Migrate applies at once; 0 or 1 applies them one by one.
+// - Vars: The variables substituted for the ${NAME} placeholders of the SQL; nil leaves the SQL as it is.
+// - Clock: The clock stamping loaded and applied migrations and timing them; nil is the system clock.
+// - Dialect: The dialect the migrations table is kept in; the zero value is PostgreSQL. NewMigratorFor sets it.
 //
 // Usage:
 // - To create a new Migrator instance, use the NewMigrator function.
@@ -61,13 +84,19 @@ type Migration struct {
 //	err := migrator.Migrate()
 //	err = migrator.Rollback(1)
 type Migrator struct {
-	db         *sql.DB
-	migrations []*Migration
-	logger     *logrus.Logger
+	db              *sql.DB
+	migrations      []*Migration
+	logger          *logrus.Logger
+	ContinueOnError bool
+	Workers         int
+	Vars            vars.Lookup
+	Clock           clock.Clock
+	Dialect         dialect.Dialect
 }
 
 // NewMigrator creates a new instance of Migrator.
-// It accepts a *sql.DB database connection and a *logrus.Logger logger.
+// It accepts a *sql.DB database connection and a *logrus.Logger logger. The migrations table is kept in
+// PostgreSQL unless Dialect is set; NewMigratorFor sets it from a connection.
 // Returns a pointer to Migrator struct.
 // Example usage:
 //
@@ -76,14 +105,27 @@ func NewMigrator(db *sql.DB, logger *logrus.Logger) *Migrator {
 	return &Migrator{db: db, logger: logger}
 }
 
-// LoadMigrations reads and loads the embedded migration files from the "migrations" directory.
+// NewMigratorFor creates a new Migrator for the database of conn, keeping the migrations table in its dialect.
+// Example usage:
+//
+//	migrator := migration.NewMigratorFor(conn, log)
+func NewMigratorFor(conn *orm.Connection, logger *logrus.Logger) *Migrator {
+	m := NewMigrator(conn.GetDB(), logger)
+	m.Dialect = conn.Dialect()
+	return m
+}
+
+// LoadMigrations reads and loads the embedded migration files of the dialect of the Migrator: those of the
+// "migrations" directory for PostgreSQL, and those of its "sqlite" and "mysql" subdirectories, which create the
+// same tables with the same versions, for SQLite and MySQL.
 // It reads the files with the ".sql" extension,
 // parses each migration file,
 // sorts the migrations based on their version,
 // and appends them to the Migrator's migrations slice.
 // Returns an error if there is any issue reading, parsing, or sorting the migrations.
 func (m *Migrator) LoadMigrations() error {
-	entries, err := embedded.EmbeddedFiles.ReadDir("migrations")
+	dir := embeddedMigrationsDir(m.Dialect)
+	entries, err := embedded.EmbeddedFiles.ReadDir(dir)
 	if err != nil {
 		return fmt.Errorf("failed to read embedded migrations directory: %w", err)
 	}
@@ -91,7 +133,7 @@ func (m *Migrator) LoadMigrations() error {
 	var loadErrors []error
 	for _, entry := range entries {
 		if filepath.Ext(entry.Name()) == ".sql" {
-			migrationContent, err := embedded.EmbeddedFiles.ReadFile(filepath.Join("migrations", entry.Name()))
+			migrationContent, err := embedded.EmbeddedFiles.ReadFile(path.Join(dir, entry.Name()))
 			if err != nil {
 				loadErrors = append(loadErrors, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err))
 				continue
@@ -101,6 +143,7 @@ func (m *Migrator) LoadMigrations() error {
 				loadErrors = append(loadErrors, fmt.Errorf("failed to parse migration file %s: %w", entry.Name(), err))
 				continue
 			}
+			migration.Timestamp = clock.OrReal(m.Clock).Now()
 			m.migrations = append(m.migrations, migration)
 		}
 	}
@@ -116,6 +159,74 @@ func (m *Migrator) LoadMigrations() error {
 	return nil
 }
 
+// embeddedMigrationsDir returns the directory of the embedded migrations written for the dialect d.
+func embeddedMigrationsDir(d dialect.Dialect) string {
+	switch {
+	case d.IsSQLite():
+		return "migrations/sqlite"
+	case d.IsMySQL():
+		return "migrations/mysql"
+	default:
+		return "migrations"
+	}
+}
+
+// Migrations returns the loaded migrations, oldest first.
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// LoadMigrationDir reads and loads the ".sql" migration files in dir, such as the migrations generated for an
+// app or converted by db migrate import, alongside the migrations already loaded, stamping them with the Clock of
+// the Migrator. They are recorded in the same migrations table, so their versions must differ from those loaded.
+func (m *Migrator) LoadMigrationDir(dir string) error {
+	migrations, err := ReadMigrationDir(dir, m.Clock)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if existing := m.findMigration(migration.Version); existing != nil {
+			return fmt.Errorf("migration %s in %s has the version of the migration %s; renumber it first", migration.Name, dir, existing.Name)
+		}
+	}
+	m.migrations = append(m.migrations, migrations...)
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return nil
+}
+
+// ReadMigrationDir reads and parses the ".sql" migration files in dir, such as the migrations generated
+// for an app, and returns them sorted by version and stamped with clk, or the system clock if clk is nil.
+func ReadMigrationDir(dir string, clk clock.Clock) ([]*Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var migrations []*Migration
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+		migration, err := parseMigrationContent(entry.Name(), string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration file %s: %w", entry.Name(), err)
+		}
+		migration.Timestamp = clock.OrReal(clk).Now()
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
 // parseMigrationContent parses the content of a migration file and returns a *Migration object
 // containing the parsed information. The function splits the content into two parts, using "-- Down"
 // as the delimiter. If the content does not have exactly two parts, it returns an error. It then trims
@@ -138,19 +249,24 @@ func parseMigrationContent(filename, content string) (*Migration, error) {
 		return nil, fmt.Errorf("error parsing version from filename: %w", err)
 	}
 
-	return &Migration{
-		Version:   version,
-		Name:      filename,
-		UpSQL:     upSQL,
-		DownSQL:   downSQL,
-		Timestamp: time.Now(),
-	}, nil
+	migration := &Migration{
+		Version: version,
+		Name:    filename,
+		UpSQL:   upSQL,
+		DownSQL: downSQL,
+	}
+	parseDirectives(migration)
+	return migration, nil
 }
 
 // Migrate applies pending migrations to the database.
 // It creates the migrations table if it does not exist.
 // It retrieves the list of applied migrations from the database.
 // For each migration that has not been applied, it runs the migration.
+// With Workers above 1, independent migrations, those marked with a "-- migrate:parallel" directive or
+// declaring the objects they touch with "-- migrate:objects", are applied concurrently in batches planned by
+// planBatches; the others still run alone, after every migration before them. SQLite databases are always
+// migrated one by one.
 // Returns an error if any step fails.
 func (m *Migrator) Migrate() error {
 	if err := m.createMigrationsTable(); err != nil {
@@ -162,17 +278,178 @@ func (m *Migrator) Migrate() error {
 		return fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
+	var pending []*Migration
 	for _, migration := range m.migrations {
 		if !contains(appliedMigrations, migration.Version) {
+			pending = append(pending, migration)
+		}
+	}
+	if pending, err = m.expand(pending); err != nil {
+		return err
+	}
+
+	sequential := m.Workers <= 1
+	if !sequential && m.Dialect.IsSQLite() {
+		// SQLite takes one writer at a time, so migrations applied at once would fail with "database is locked".
+		m.logger.Warnf("Applying migrations one by one: SQLite cannot apply %d at once", m.Workers)
+		sequential = true
+	}
+	if sequential {
+		for _, migration := range pending {
 			if err := m.runMigration(migration); err != nil {
 				return fmt.Errorf("failed to run migration %s: %w", migration.Name, err)
 			}
 		}
+		return nil
+	}
+	for _, batch := range planBatches(pending) {
+		if err := m.runBatch(batch); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
+// expand returns copies of migrations with the placeholders of their SQL substituted by Vars, so an undefined
+// variable fails before any migration runs. Without Vars, the migrations are returned as they are.
+func (m *Migrator) expand(migrations []*Migration) ([]*Migration, error) {
+	if m.Vars == nil {
+		return migrations, nil
+	}
+	expanded := make([]*Migration, len(migrations))
+	for i, migration := range migrations {
+		up, err := vars.Expand(migration.UpSQL, m.Vars)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+		down, err := vars.Expand(migration.DownSQL, m.Vars)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+		copied := *migration
+		copied.UpSQL, copied.DownSQL = up, down
+		expanded[i] = &copied
+	}
+	return expanded, nil
+}
+
+// Pending returns the loaded migrations that have not been applied yet, oldest first.
+// Unlike Migrate, it does not create the migrations table; if the table does not exist, every migration is pending.
+func (m *Migrator) Pending() ([]*Migration, error) {
+	exists, err := m.migrationsTableExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for migrations table: %w", err)
+	}
+
+	var appliedMigrations []int64
+	if exists {
+		if appliedMigrations, err = m.getAppliedMigrations(); err != nil {
+			return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+	}
+
+	var pending []*Migration
+	for _, migration := range m.migrations {
+		if !contains(appliedMigrations, migration.Version) {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Status is the state of a migration, as shown by `grayv-lsm db migrate-status`.
+// Duration and RowsAffected are nil for migrations applied before they were recorded.
+type Status struct {
+	Version      int64          `json:"version"`
+	Name         string         `json:"name"`
+	Applied      bool           `json:"applied"`
+	AppliedAt    *time.Time     `json:"applied_at,omitempty"`
+	Duration     *time.Duration `json:"duration_ns,omitempty"`
+	RowsAffected *int64         `json:"rows_affected,omitempty"`
+	// Missing is set for applied migrations whose file is no longer among the loaded migrations.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Status returns the state of every loaded migration and of every applied one, oldest first.
+// Like Pending, it does not create the migrations table.
+func (m *Migrator) Status() ([]Status, error) {
+	exists, err := m.migrationsTableExists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for migrations table: %w", err)
+	}
+
+	applied := map[int64]Status{}
+	if exists {
+		if applied, err = m.getAppliedStatus(); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status, ok := applied[migration.Version]
+		if !ok {
+			status = Status{Version: migration.Version}
+		}
+		status.Name = migration.Name
+		statuses = append(statuses, status)
+		delete(applied, migration.Version)
+	}
+	for _, status := range applied {
+		status.Missing = true
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// getAppliedStatus returns the records of the migrations table, by version. It reads the duration and row
+// count columns only if the table has them, so databases migrated by older versions can be inspected without
+// changing their migrations table.
+func (m *Migrator) getAppliedStatus() (map[int64]Status, error) {
+	var telemetry bool
+	if err := m.db.QueryRow(m.rebind(columnExistsQuery(m.Dialect)), migrationsTableName, "duration_ms").Scan(&telemetry); err != nil {
+		return nil, fmt.Errorf("error inspecting migrations table: %w", err)
+	}
+	columns := "version, name, applied_at, NULL, NULL"
+	if telemetry {
+		columns = "version, name, applied_at, duration_ms, rows_affected"
+	}
+
+	rows, err := m.db.Query(fmt.Sprintf("SELECT %s FROM %s", columns, migrationsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("error querying migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]Status{}
+	for rows.Next() {
+		status := Status{Applied: true}
+		var appliedAt sql.NullTime
+		var durationMS, rowsAffected sql.NullInt64
+		if err := rows.Scan(&status.Version, &status.Name, &appliedAt, &durationMS, &rowsAffected); err != nil {
+			return nil, fmt.Errorf("error scanning migration row: %w", err)
+		}
+		if appliedAt.Valid {
+			status.AppliedAt = &appliedAt.Time
+		}
+		if durationMS.Valid {
+			duration := time.Duration(durationMS.Int64) * time.Millisecond
+			status.Duration = &duration
+		}
+		if rowsAffected.Valid {
+			status.RowsAffected = &rowsAffected.Int64
+		}
+		applied[status.Version] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over migration rows: %w", err)
+	}
+	return applied, nil
+}
+
 // Rollback rolls back a specified number of migrations by executing their corresponding down SQL statements.
 // It retrieves the list of applied migrations, finds the migration to be rolled back,
 // and then executes the rollback process by running the migration's down SQL statement.
@@ -190,11 +467,19 @@ func (m *Migrator) Rollback(steps int) error {
 		return fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
+	var migrations []*Migration
 	for i := 0; i < steps && i < len(appliedMigrations); i++ {
 		migration := m.findMigration(appliedMigrations[i])
 		if migration == nil {
-			return fmt.Errorf("migration with version %d not found", appliedMigrations[i])
+			return fmt.Errorf("migration with version %d not found; load the directory holding its file, as with db rollback --dir", appliedMigrations[i])
 		}
+		migrations = append(migrations, migration)
+	}
+	if migrations, err = m.expand(migrations); err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
 		if err := m.rollbackMigration(migration); err != nil {
 			return fmt.Errorf("failed to rollback migration %s: %w", migration.Name, err)
 		}
@@ -206,44 +491,129 @@ func (m *Migrator) Rollback(steps int) error {
 const migrationsTableName = "migrations"
 
 // createMigrationsTable creates a table called "migrations" in the database if it does not exist already.
-// The table has three columns: "version" of type BIGINT and primary key, "name" of type TEXT and not null,
-// and "applied_at" of type TIMESTAMP WITH TIME ZONE with a default value of the current timestamp.
+// The table has five columns: "version" of type BIGINT and primary key, "name" of type TEXT and not null,
+// "applied_at" of type TIMESTAMP WITH TIME ZONE with a default value of the current timestamp, and
+// "duration_ms" and "rows_affected" of type BIGINT, which record how long the migration took and how many
+// rows its statements changed. The last two are added to tables created before they existed, and are NULL
+// for the migrations applied then.
 // This method returns an error if there was a problem executing the SQL statement to create the table.
 func (m *Migrator) createMigrationsTable() error {
-	query := fmt.Sprintf(`
-        CREATE TABLE IF NOT EXISTS %s (
-            version BIGINT PRIMARY KEY,
-            name TEXT NOT NULL,
-            applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-        )
-    `, migrationsTableName)
-	_, err := m.db.Exec(query)
-	return err
+	for _, statement := range createMigrationsTableStatements(m.Dialect) {
+		if _, err := m.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createMigrationsTableStatements returns the statements of createMigrationsTable in the dialect d. Only
+// PostgreSQL databases can have a migrations table older than the duration_ms and rows_affected columns, so
+// only they get the columns added.
+func createMigrationsTableStatements(d dialect.Dialect) []string {
+	appliedAt := "TIMESTAMP WITH TIME ZONE"
+	if d.IsSQLite() || d.IsMySQL() {
+		appliedAt = "TIMESTAMP"
+	}
+	statements := []string{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at %s DEFAULT CURRENT_TIMESTAMP,
+    duration_ms BIGINT,
+    rows_affected BIGINT
+)`, migrationsTableName, appliedAt)}
+	// Neither SQLite nor MySQL can add a column only if it is missing; their tables never lacked these.
+	if d.IsSQLite() || d.IsMySQL() {
+		return statements
+	}
+	return append(statements, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS duration_ms BIGINT,
+    ADD COLUMN IF NOT EXISTS rows_affected BIGINT`, migrationsTableName))
+}
+
+// migrationsTableExists reports whether the migrations table exists.
+func (m *Migrator) migrationsTableExists() (bool, error) {
+	var exists bool
+	err := m.db.QueryRow(m.rebind(tableExistsQuery(m.Dialect)), migrationsTableName).Scan(&exists)
+	return exists, err
+}
+
+// tableExistsQuery returns the query in the dialect d telling whether the table named by its parameter exists.
+func tableExistsQuery(d dialect.Dialect) string {
+	if d.IsSQLite() {
+		return "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?"
+	}
+	if d.IsMySQL() {
+		return "SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+	return "SELECT to_regclass(?) IS NOT NULL"
+}
+
+// columnExistsQuery returns the query in the dialect d telling whether the table named by its first parameter
+// has the column named by its second.
+func columnExistsQuery(d dialect.Dialect) string {
+	if d.IsSQLite() {
+		return "SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?"
+	}
+	if d.IsMySQL() {
+		return `SELECT COUNT(*) > 0 FROM information_schema.columns
+		WHERE table_name = ? AND column_name = ? AND table_schema = DATABASE()`
+	}
+	return `SELECT EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_name = ? AND column_name = ? AND table_schema = current_schema())`
+}
+
+// placeholders returns the format of the bind parameters of the database.
+func (m *Migrator) placeholders() orm.PlaceholderFormat {
+	if m.Dialect.IsSQLite() || m.Dialect.IsMySQL() {
+		return orm.Question
+	}
+	return orm.Dollar
+}
+
+// rebind returns query, written with "?" placeholders, with the placeholders of the database.
+func (m *Migrator) rebind(query string) string {
+	return orm.Rebind(query, m.placeholders())
+}
+
+// build returns the statement of q on the migrations table and its parameters, for the database.
+func (m *Migrator) build(q *orm.Query) (string, []interface{}) {
+	return q.Dialect(m.Dialect).Placeholders(m.placeholders()).Build()
 }
 
 // runMigration applies a migration to the database using a transaction.
 // It executes the UpSQL statement of the migration and inserts a record
-// of the migration into the migrations table.
-// If an error occurs at any step, the transaction is rolled back.
+// of the migration into the migrations table, with the time the statements took
+// and the number of rows they changed.
+// If an error occurs at any step, the transaction is rolled back. With ContinueOnError
+// set, the statements run one at a time in nested transactions instead, and a failing
+// statement is rolled back to its savepoint and skipped.
 //
 // Parameters:
 // - migration: The migration to be applied.
 //
 // Returns:
 // - error: An error if any occurred during the migration process.
-func (m *Migrator) runMigration(migration *Migration) error {
-	tx, err := m.db.Begin()
+func (m *Migrator) runMigration(migration *Migration) (err error) {
+	ctx, span := startMigrationSpan("migration.apply", migration)
+	defer func() { endMigrationSpan(span, err) }()
+
+	tx, err := orm.BeginTx(ctx, m.db)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec(migration.UpSQL); err != nil {
+	clk := clock.OrReal(m.Clock)
+	start := clk.Now()
+	rows, err := m.applyStatements(ctx, tx, migration)
+	if err != nil {
 		return fmt.Errorf("error applying migration: %w", err)
 	}
+	duration := clk.Since(start)
+	span.SetAttributes(attribute.Int64("migration.rows_affected", rows))
 
-	if _, err := tx.Exec("INSERT INTO migrations (version, name) VALUES ($1, $2)",
-		migration.Version, migration.Name); err != nil {
+	insert, params := m.build(orm.NewQuery(migrationsTableName).Insert("version", "name", "applied_at", "duration_ms", "rows_affected").
+		Values(migration.Version, migration.Name, start, duration.Milliseconds(), rows))
+	if _, err := tx.ExecContext(ctx, insert, params...); err != nil {
 		return fmt.Errorf("error recording migration: %w", err)
 	}
 
@@ -251,26 +621,67 @@ func (m *Migrator) runMigration(migration *Migration) error {
 		return fmt.Errorf("error committing migration: %w", err)
 	}
 
-	m.logger.Infof("Applied migration: %s", migration.Name)
+	m.logger.Infof("Applied migration: %s (%s, %d rows)", migration.Name, duration.Round(time.Millisecond), rows)
 	return nil
 }
 
+// applyStatements executes the UpSQL of migration in tx, one statement at a time, and returns the number of
+// rows the statements changed. Only the counts of the statements changing rows are added up, as drivers report
+// meaningless counts for DDL. With ContinueOnError set, each statement runs in a nested transaction, and
+// the failing ones are logged and skipped.
+func (m *Migrator) applyStatements(ctx context.Context, tx *orm.Tx, migration *Migration) (int64, error) {
+	var total int64
+	exec := func(statement string) error {
+		result, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+		if !orm.IsDMLStatement(statement) {
+			return nil
+		}
+		if rows, err := result.RowsAffected(); err == nil {
+			total += rows
+		}
+		return nil
+	}
+
+	for i, statement := range orm.SplitStatements(migration.UpSQL) {
+		if !m.ContinueOnError {
+			if err := exec(statement); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		err := tx.Nested("migration_statement", func() error {
+			return exec(statement)
+		})
+		if err != nil {
+			m.logger.WithError(err).Warnf("Skipped statement %d of migration %s", i+1, migration.Name)
+		}
+	}
+	return total, nil
+}
+
 // rollbackMigration rolls back a migration by executing the DownSQL statement and removing the migration record from the database.
 // It starts a transaction, rolls it back in case of an error, and commits the rollback if successful.
 // It logs the name of the rolled-back migration.
 // It returns an error if any operation fails.
-func (m *Migrator) rollbackMigration(migration *Migration) error {
-	tx, err := m.db.Begin()
+func (m *Migrator) rollbackMigration(migration *Migration) (err error) {
+	ctx, span := startMigrationSpan("migration.rollback", migration)
+	defer func() { endMigrationSpan(span, err) }()
+
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec(migration.DownSQL); err != nil {
+	if _, err := tx.ExecContext(ctx, migration.DownSQL); err != nil {
 		return fmt.Errorf("error rolling back migration: %w", err)
 	}
 
-	if _, err := tx.Exec("DELETE FROM migrations WHERE version = $1", migration.Version); err != nil {
+	remove, params := m.build(orm.NewQuery(migrationsTableName).Delete().Where("version = ?", migration.Version))
+	if _, err := tx.ExecContext(ctx, remove, params...); err != nil {
 		return fmt.Errorf("error removing migration record: %w", err)
 	}
 
@@ -282,6 +693,24 @@ func (m *Migrator) rollbackMigration(migration *Migration) error {
 	return nil
 }
 
+// startMigrationSpan starts a span covering one migration. The statements of the migration
+// run with the returned context, so they are recorded as child spans.
+func startMigrationSpan(name string, migration *Migration) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(context.Background(), name, trace.WithAttributes(
+		attribute.Int64("migration.version", migration.Version),
+		attribute.String("migration.name", migration.Name),
+	))
+}
+
+// endMigrationSpan records the outcome of a migration on its span and ends it.
+func endMigrationSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 // getAppliedMigrations queries the migrations table in the database and retrieves
 // the versions of the applied migrations, ordered in descending order. It returns
 // a slice of int64 representing the versions and an error if there was any issue
@@ -0,0 +1,114 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	parallelPattern = regexp.MustCompile(`(?im)^[ \t]*--[ \t]*migrate:parallel[ \t\r]*$`)
+	objectsPattern  = regexp.MustCompile(`(?im)^[ \t]*--[ \t]*migrate:objects[ \t]+([\w \t,."-]+?)[ \t\r]*$`)
+)
+
+// parseDirectives sets the Parallel and Objects fields of migration from the directives in its up SQL:
+// "-- migrate:parallel" marks the migration safe to apply alongside any other independent migration, and
+// "-- migrate:objects users, posts" declares the only database objects it touches.
+func parseDirectives(migration *Migration) {
+	migration.Parallel = parallelPattern.MatchString(migration.UpSQL)
+	for _, match := range objectsPattern.FindAllStringSubmatch(migration.UpSQL, -1) {
+		for _, object := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			migration.Objects = append(migration.Objects, normalizeObject(object))
+		}
+	}
+}
+
+// normalizeObject returns the name of a database object in the form used to compare object sets:
+// unquoted and lowercase, as PostgreSQL folds unquoted names.
+func normalizeObject(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, `"`, ""))
+}
+
+// independent reports whether migration may be applied concurrently with other migrations.
+func (m *Migration) independent() bool {
+	return m.Parallel || len(m.Objects) > 0
+}
+
+// conflicts reports whether migration touches an object declared by one of the migrations of batch.
+// A migration marked parallel without declaring objects conflicts with none.
+func conflicts(batch []*Migration, migration *Migration) bool {
+	for _, other := range batch {
+		for _, a := range other.Objects {
+			for _, b := range migration.Objects {
+				if a == b {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// planBatches groups migrations, in the order they run, into batches whose migrations can be applied
+// concurrently. Consecutive independent migrations share a batch as long as their declared objects are
+// disjoint; every other migration is a batch of its own, so it runs after all the migrations before it and
+// before all the ones after it.
+func planBatches(migrations []*Migration) [][]*Migration {
+	var batches [][]*Migration
+	var batch []*Migration
+	for _, migration := range migrations {
+		switch {
+		case !migration.independent():
+			if len(batch) > 0 {
+				batches = append(batches, batch)
+				batch = nil
+			}
+			batches = append(batches, []*Migration{migration})
+		case conflicts(batch, migration):
+			batches = append(batches, batch)
+			batch = []*Migration{migration}
+		default:
+			batch = append(batch, migration)
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// runBatch applies the migrations of batch with up to Workers of them running at once, each in its own
+// transaction. The migrations that succeed stay applied if another fails; the error of the failing migration
+// that comes first is returned.
+func (m *Migrator) runBatch(batch []*Migration) error {
+	workers := m.Workers
+	if workers > len(batch) {
+		workers = len(batch)
+	}
+
+	errs := make([]error, len(batch))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = m.runMigration(batch[i])
+			}
+		}()
+	}
+	for i := range batch {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to run migration %s: %w", batch[i].Name, err)
+		}
+	}
+	return nil
+}
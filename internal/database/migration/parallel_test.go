@@ -0,0 +1,56 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirectives(t *testing.T) {
+	m, err := parseMigrationContent("001_users.sql", "-- migrate:objects users, \"Posts\"\nCREATE TABLE users (id INT);\n-- Down\nDROP TABLE users;")
+	assert.NoError(t, err)
+	assert.False(t, m.Parallel)
+	assert.Equal(t, []string{"users", "posts"}, m.Objects)
+
+	m, err = parseMigrationContent("002_seed.sql", "-- migrate:parallel\r\nINSERT INTO plans VALUES (1);\r\n-- Down\r\n")
+	assert.NoError(t, err)
+	assert.True(t, m.Parallel)
+	assert.Empty(t, m.Objects)
+
+	m, err = parseMigrationContent("003_plain.sql", "SELECT '-- migrate:parallel';\n-- Down\n")
+	assert.NoError(t, err)
+	assert.False(t, m.Parallel, "directives must be on a line of their own")
+}
+
+func TestPlanBatches(t *testing.T) {
+	users := &Migration{Name: "users", Objects: []string{"users"}}
+	posts := &Migration{Name: "posts", Objects: []string{"posts"}}
+	plans := &Migration{Name: "plans", Parallel: true}
+	fk := &Migration{Name: "fk"}
+	userIndex := &Migration{Name: "user_index", Objects: []string{"users"}}
+	tags := &Migration{Name: "tags", Objects: []string{"tags"}}
+
+	batches := planBatches([]*Migration{users, posts, plans, userIndex, fk, tags})
+	assert.Equal(t, [][]*Migration{
+		{users, posts, plans},
+		{userIndex},
+		{fk},
+		{tags},
+	}, batches)
+
+	assert.Empty(t, planBatches(nil))
+}
+
+func TestExpandVariables(t *testing.T) {
+	original := &Migration{Name: "admin", UpSQL: "INSERT INTO users (email) VALUES ('${ADMIN_EMAIL}');", DownSQL: "DELETE FROM users WHERE email = '${ADMIN_EMAIL}';"}
+	m := &Migrator{Vars: func(name string) (string, bool) { return "admin@example.com", name == "ADMIN_EMAIL" }}
+
+	expanded, err := m.expand([]*Migration{original})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (email) VALUES ('admin@example.com');", expanded[0].UpSQL)
+	assert.Equal(t, "DELETE FROM users WHERE email = 'admin@example.com';", expanded[0].DownSQL)
+	assert.Contains(t, original.UpSQL, "${ADMIN_EMAIL}", "the loaded migration must be left as it is")
+
+	_, err = m.expand([]*Migration{{Name: "region", UpSQL: "SELECT '${REGION}';"}})
+	assert.EqualError(t, err, "migration region: undefined variables: REGION")
+}
@@ -0,0 +1,205 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// declaredTablesPattern matches a "-- tables: users, posts" comment declaring the tables a seed touches.
+var declaredTablesPattern = regexp.MustCompile(`(?im)^\s*--\s*tables:\s*(.+)$`)
+
+// tableReferencePattern matches the table names following the SQL keywords that read or write a table.
+var tableReferencePattern = regexp.MustCompile(
+	`(?i)\b(?:INTO|UPDATE|FROM|JOIN|REFERENCES|COPY|TRUNCATE(?:\s+TABLE)?|TABLE(?:\s+IF(?:\s+NOT)?\s+EXISTS)?)\s+((?:"[^"]+"|[A-Za-z_]\w*)(?:\.(?:"[^"]+"|[A-Za-z_]\w*))?)`)
+
+// opaquePattern matches statements whose effects cannot be inferred from the table names they mention.
+var opaquePattern = regexp.MustCompile(`(?i)\$\$|\bCREATE\s+(?:OR\s+REPLACE\s+)?(?:FUNCTION|PROCEDURE|TRIGGER)\b|\bCALL\b|\bEXECUTE\b`)
+
+// seedTables returns the tables a seed touches: those declared in a "-- tables:" comment or, without one,
+// every table its statements mention. It returns nil if the tables cannot be inferred, such as for seeds
+// running procedural code, so they are never run alongside other seeds.
+func seedTables(sql string) []string {
+	if match := declaredTablesPattern.FindStringSubmatch(sql); match != nil {
+		return normalizeTables(strings.Split(match[1], ","))
+	}
+	if opaquePattern.MatchString(sql) {
+		return nil
+	}
+
+	var tables []string
+	for _, match := range tableReferencePattern.FindAllStringSubmatch(stripComments(sql), -1) {
+		tables = append(tables, match[1])
+	}
+	return normalizeTables(tables)
+}
+
+// stripComments removes "--" line comments, so words in comments are not mistaken for table names.
+func stripComments(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if index := strings.Index(line, "--"); index >= 0 {
+			lines[i] = line[:index]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeTables lowercases table names, strips quotes and the public schema, and removes duplicates.
+// It returns nil if no table is left.
+func normalizeTables(names []string) []string {
+	seen := make(map[string]bool)
+	var tables []string
+	for _, name := range names {
+		name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), `"`, ""))
+		name = strings.TrimPrefix(name, "public.")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// planBatches groups seeds, in order, into batches whose seeds can run concurrently. A seed joins the
+// current batch only if it touches none of the tables the batch touches, including the tables referenced
+// through foreign keys listed in references; otherwise it starts the next batch. Seeds with unknown tables
+// run in a batch of their own. The plan depends only on its input, so runs are reproducible.
+func planBatches(seeds []*Seed, references map[string][]string) [][]*Seed {
+	var batches [][]*Seed
+	var current []*Seed
+	used := make(map[string]bool)
+
+	flush := func() {
+		if len(current) > 0 {
+			batches = append(batches, current)
+			current = nil
+			used = make(map[string]bool)
+		}
+	}
+
+	for _, seed := range seeds {
+		if seed.Tables == nil {
+			flush()
+			batches = append(batches, []*Seed{seed})
+			continue
+		}
+
+		tables := withReferences(seed.Tables, references)
+		for _, table := range tables {
+			if used[table] {
+				flush()
+				break
+			}
+		}
+		current = append(current, seed)
+		for _, table := range tables {
+			used[table] = true
+		}
+	}
+	flush()
+
+	return batches
+}
+
+// withReferences returns tables together with the tables they reference through foreign keys, transitively.
+func withReferences(tables []string, references map[string][]string) []string {
+	seen := make(map[string]bool)
+	var all []string
+	var visit func(table string)
+	visit = func(table string) {
+		if seen[table] {
+			return
+		}
+		seen[table] = true
+		all = append(all, table)
+		for _, referenced := range references[table] {
+			visit(referenced)
+		}
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return all
+}
+
+// foreignKeys returns, for every table with foreign keys, the tables they reference.
+func (s *Seeder) foreignKeys() (map[string][]string, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT tc.table_name, ccu.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	references := make(map[string][]string)
+	for rows.Next() {
+		var table, referenced string
+		if err := rows.Scan(&table, &referenced); err != nil {
+			return nil, err
+		}
+		if table != referenced {
+			references[table] = append(references[table], referenced)
+		}
+	}
+	return references, rows.Err()
+}
+
+// SeedConcurrently executes the loaded seeds using up to jobs concurrent transactions. Seeds that touch
+// disjoint tables, as declared or inferred and including foreign key references, run at the same time;
+// all others run in file name order, as with Seed. With jobs of 1 or less, or if the foreign keys cannot
+// be read, it falls back to Seed. After a failure no further batch is started, and the errors of the
+// failed seeds are returned in file name order.
+func (s *Seeder) SeedConcurrently(jobs int) error {
+	if jobs <= 1 {
+		return s.Seed()
+	}
+
+	references, err := s.foreignKeys()
+	if err != nil {
+		logrus.WithError(err).Warn("Cannot read foreign keys; seeding serially")
+		return s.Seed()
+	}
+	seeds, err := s.expandedSeeds()
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range planBatches(seeds, references) {
+		if err := s.executeBatch(batch, jobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeBatch executes the seeds of a batch with up to jobs at a time and waits for all of them.
+func (s *Seeder) executeBatch(batch []*Seed, jobs int) error {
+	errs := make([]error, len(batch))
+	semaphore := make(chan struct{}, jobs)
+
+	var wg sync.WaitGroup
+	for i, seed := range batch {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, seed *Seed) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := s.executeSeed(seed); err != nil {
+				errs[i] = fmt.Errorf("seed %s: %w", seed.Name, err)
+			}
+		}(i, seed)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
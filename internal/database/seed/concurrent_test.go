@@ -0,0 +1,39 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedTables(t *testing.T) {
+	assert.Equal(t, []string{"posts", "users"}, seedTables(`
+-- Insert the users
+INSERT INTO users (name) VALUES ('a');
+INSERT INTO "public"."posts" (user_id) SELECT id FROM Users;`))
+
+	assert.Equal(t, []string{"posts", "users"}, seedTables("-- tables: Users, posts\nSELECT seed_everything();"))
+	assert.Nil(t, seedTables("DO $$ BEGIN PERFORM 1; END $$;"))
+	assert.Nil(t, seedTables("SELECT 1;"))
+}
+
+func TestPlanBatches(t *testing.T) {
+	users := &Seed{Name: "01_users.sql", Tables: []string{"users"}}
+	tags := &Seed{Name: "02_tags.sql", Tables: []string{"tags"}}
+	posts := &Seed{Name: "03_posts.sql", Tables: []string{"posts"}}
+	opaque := &Seed{Name: "04_opaque.sql"}
+	comments := &Seed{Name: "05_comments.sql", Tables: []string{"comments"}}
+	moreTags := &Seed{Name: "06_tags.sql", Tables: []string{"tags"}}
+
+	seeds := []*Seed{users, tags, posts, opaque, comments, moreTags}
+	references := map[string][]string{"posts": {"users"}}
+
+	assert.Equal(t, [][]*Seed{
+		{users, tags},
+		{posts},
+		{opaque},
+		{comments, moreTags},
+	}, planBatches(seeds, references), "posts references users, so it runs after it")
+
+	assert.Equal(t, [][]*Seed{{users, tags, posts}}, planBatches(seeds[:3], nil))
+}
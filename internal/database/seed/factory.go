@@ -0,0 +1,292 @@
+package seed
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+)
+
+// nullShare is the share of values left NULL in the nullable columns of generated rows.
+const nullShare = 0.05
+
+var (
+	factoryFirstNames = []string{"Ada", "Grace", "Linus", "Barbara", "Ken", "Margaret", "Dennis", "Frances", "Edsger", "Radia", "Alan", "Hedy", "Donald", "Katherine", "John"}
+	factoryLastNames  = []string{"Lovelace", "Hopper", "Torvalds", "Liskov", "Thompson", "Hamilton", "Ritchie", "Allen", "Dijkstra", "Perlman", "Turing", "Lamarr", "Knuth", "Johnson", "Backus"}
+	factoryWords      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua"}
+	factoryCities     = []string{"Lisbon", "Nairobi", "Osaka", "Toronto", "Berlin", "Lima", "Melbourne", "Oslo", "Austin", "Seoul"}
+	factoryCountries  = []string{"Portugal", "Kenya", "Japan", "Canada", "Germany", "Peru", "Australia", "Norway", "United States", "South Korea"}
+	factoryStatuses   = []string{"active", "pending", "inactive", "archived"}
+)
+
+// Factory generates fake rows for the table of a model, with values that look like real data: besides its type,
+// the name of a field picks the kind of value, so an email field gets email addresses and a price field gets
+// prices. Values that are usually unique, such as emails and primary keys, include the row number.
+type Factory struct {
+	def        *model.ModelDefinition
+	rng        *rand.Rand
+	since      time.Time
+	references map[string][]int64
+	tenant     string
+}
+
+// NewFactory returns a Factory generating rows for def with the random numbers of rng.
+func NewFactory(def *model.ModelDefinition, rng *rand.Rand) *Factory {
+	return &Factory{
+		def:        def,
+		rng:        rng,
+		since:      time.Now().UTC().AddDate(-1, 0, 0).Truncate(time.Second),
+		references: map[string][]int64{},
+	}
+}
+
+// SetReferences sets the ids of the rows the foreign key field may reference. Every field referencing
+// another model needs them.
+func (f *Factory) SetReferences(field string, ids []int64) {
+	f.references[field] = ids
+}
+
+// SetSince sets the start of the year the generated timestamps fall in, a year before NewFactory was
+// called by default. Setting it makes the rows depend on the random numbers only.
+func (f *Factory) SetSince(since time.Time) {
+	f.since = since.UTC().Truncate(time.Second)
+}
+
+// SetTenant sets the tenant generated rows belong to, for models whose definition has Tenant set.
+func (f *Factory) SetTenant(tenant string) {
+	f.tenant = tenant
+}
+
+// Columns returns the columns of the generated rows: the columns of the fields, and the tenant column if the
+// model has one. The id, created_at, and updated_at columns are left to their defaults, and generated columns
+// to the database. Computed fields have no column.
+func (f *Factory) Columns() []string {
+	var columns []string
+	for _, field := range f.def.Fields {
+		if field.Generated == "" && field.HasColumn() {
+			columns = append(columns, field.ColumnName())
+		}
+	}
+	if f.def.Tenant {
+		columns = append(columns, model.TenantColumn)
+	}
+	return columns
+}
+
+// Check returns an error if the factory lacks the references or tenant the rows of its model need.
+func (f *Factory) Check() error {
+	for _, field := range f.def.Fields {
+		if field.References != "" && len(f.references[field.Name]) == 0 && !field.IsNull {
+			return fmt.Errorf("field %s references %s, which has no rows", field.Name, field.References)
+		}
+	}
+	if f.def.Tenant && f.tenant == "" {
+		return errors.New("the model is scoped to tenants; a tenant is required")
+	}
+	return nil
+}
+
+// Row returns the values of row i, in the order of Columns.
+func (f *Factory) Row(i int) []interface{} {
+	row := make([]interface{}, 0, len(f.def.Fields)+1)
+	for _, field := range f.def.Fields {
+		if field.Generated == "" && field.HasColumn() {
+			row = append(row, f.value(field, i))
+		}
+	}
+	if f.def.Tenant {
+		row = append(row, f.tenant)
+	}
+	return row
+}
+
+// Source returns the first rows rows of the factory as a source of rows ending with io.EOF,
+// as read by orm.BulkLoader.
+func (f *Factory) Source(rows int) *FactorySource {
+	return &FactorySource{factory: f, rows: rows}
+}
+
+// FactorySource yields generated rows. See Factory.Source.
+type FactorySource struct {
+	factory *Factory
+	rows    int
+	next    int
+}
+
+// Next returns the next row, or io.EOF after the last one.
+func (s *FactorySource) Next() ([]interface{}, error) {
+	if s.next >= s.rows {
+		return nil, io.EOF
+	}
+	s.next++
+	return s.factory.Row(s.next), nil
+}
+
+// value returns the value of field in row i.
+func (f *Factory) value(field model.Field, i int) interface{} {
+	if field.References != "" {
+		ids := f.references[field.Name]
+		if len(ids) == 0 || (field.IsNull && f.rng.Float64() < nullShare) {
+			return nil
+		}
+		return ids[f.rng.Intn(len(ids))]
+	}
+	if field.IsNull && !field.IsPrimary && f.rng.Float64() < nullShare {
+		return nil
+	}
+	if dimensions, ok := model.VectorDimensions(field.Type); ok {
+		return f.vector(dimensions)
+	}
+	if precision, scale, ok := model.DecimalPrecision(field.Type); ok {
+		return f.decimal(precision, scale)
+	}
+
+	name := field.ColumnName()
+	switch model.BaseType(field.Type) {
+	case "int":
+		return f.intValue(name, field.IsPrimary, i)
+	case "float64":
+		return f.floatValue(name)
+	case "bool":
+		return f.rng.Intn(2) == 0
+	case "time.Time":
+		return f.since.Add(time.Duration(f.rng.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+	case "[]byte":
+		b := make([]byte, 16)
+		f.rng.Read(b)
+		return b
+	default:
+		s := f.stringValue(name, i)
+		if field.IsPrimary && !strings.Contains(s, strconv.Itoa(i)) {
+			s += "-" + strconv.Itoa(i)
+		}
+		return s
+	}
+}
+
+func (f *Factory) intValue(name string, primary bool, i int) int64 {
+	switch {
+	case primary:
+		return int64(i)
+	case has(name, "age"):
+		return int64(18 + f.rng.Intn(73))
+	case has(name, "year"):
+		return int64(1970 + f.rng.Intn(time.Now().Year()-1969))
+	case has(name, "count"), has(name, "quantity"), has(name, "stock"):
+		return int64(f.rng.Intn(1000))
+	case has(name, "rating"), has(name, "score"):
+		return int64(1 + f.rng.Intn(5))
+	default:
+		return int64(f.rng.Intn(100000))
+	}
+}
+
+func (f *Factory) floatValue(name string) float64 {
+	switch {
+	case has(name, "price"), has(name, "amount"), has(name, "cost"), has(name, "total"):
+		return math.Round((1+f.rng.Float64()*999)*100) / 100
+	case has(name, "lat"), has(name, "latitude"):
+		return -90 + f.rng.Float64()*180
+	case has(name, "lon"), has(name, "lng"), has(name, "longitude"):
+		return -180 + f.rng.Float64()*360
+	default:
+		return f.rng.Float64() * 1000
+	}
+}
+
+func (f *Factory) stringValue(name string, i int) string {
+	first := factoryFirstNames[f.rng.Intn(len(factoryFirstNames))]
+	last := factoryLastNames[f.rng.Intn(len(factoryLastNames))]
+	switch {
+	case has(name, "email"):
+		return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), i)
+	case has(name, "username"), has(name, "login"), has(name, "handle"):
+		return fmt.Sprintf("%s%s%d", strings.ToLower(first[:1]), strings.ToLower(last), i)
+	case has(name, "first") && has(name, "name"):
+		return first
+	case has(name, "last") && has(name, "name"):
+		return last
+	case has(name, "name"), has(name, "author"):
+		return first + " " + last
+	case has(name, "url"), has(name, "website"), has(name, "link"):
+		return fmt.Sprintf("https://example.com/%s-%d", f.words(2, "-"), i)
+	case has(name, "slug"):
+		return fmt.Sprintf("%s-%d", f.words(3, "-"), i)
+	case has(name, "phone"):
+		return fmt.Sprintf("+1-555-%03d-%04d", f.rng.Intn(1000), f.rng.Intn(10000))
+	case has(name, "city"):
+		return factoryCities[f.rng.Intn(len(factoryCities))]
+	case has(name, "country"):
+		return factoryCountries[f.rng.Intn(len(factoryCountries))]
+	case has(name, "status"), has(name, "state"):
+		return factoryStatuses[f.rng.Intn(len(factoryStatuses))]
+	case has(name, "password"), has(name, "hash"), has(name, "token"):
+		b := make([]byte, 16)
+		f.rng.Read(b)
+		return hex.EncodeToString(b)
+	case has(name, "title"), has(name, "subject"):
+		return sentence(f.words(3+f.rng.Intn(4), " "))
+	case has(name, "body"), has(name, "description"), has(name, "content"),
+		has(name, "bio"), has(name, "summary"), has(name, "text"):
+		return sentence(f.words(12+f.rng.Intn(30), " ")) + "."
+	default:
+		return f.words(2, " ")
+	}
+}
+
+// words returns n random words joined by sep.
+func (f *Factory) words(n int, sep string) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = factoryWords[f.rng.Intn(len(factoryWords))]
+	}
+	return strings.Join(words, sep)
+}
+
+// vector returns a random pgvector literal with the given number of dimensions.
+func (f *Factory) vector(dimensions int) string {
+	values := make([]string, dimensions)
+	for i := range values {
+		values[i] = strconv.FormatFloat(f.rng.Float64()*2-1, 'f', 4, 64)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// decimal returns the text of a decimal number with scale digits after the point that fits precision digits,
+// with up to 4 digits before the point.
+func (f *Factory) decimal(precision, scale int) string {
+	whole := strconv.Itoa(f.rng.Intn(int(math.Pow10(min(precision-scale, 4)))))
+	if scale == 0 {
+		return whole
+	}
+	fraction := make([]byte, scale)
+	for i := range fraction {
+		fraction[i] = byte('0' + f.rng.Intn(10))
+	}
+	return whole + "." + string(fraction)
+}
+
+// has reports whether word is one of the words of the snake_case column name, or the plural of one.
+func has(name, word string) bool {
+	for _, w := range strings.Split(name, "_") {
+		if w == word || w == word+"s" {
+			return true
+		}
+	}
+	return false
+}
+
+// sentence capitalizes the first letter of s.
+func sentence(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,74 @@
+package seed
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactory(t *testing.T) {
+	def := model.NewModelDefinition("Customer", []model.Field{
+		{Name: "Email", Type: "string"},
+		{Name: "FullName", Type: "string"},
+		{Name: "Age", Type: "int"},
+		{Name: "Price", Type: "float64"},
+		{Name: "Active", Type: "bool"},
+		{Name: "SignedUpAt", Type: "time.Time"},
+		{Name: "Embedding", Type: "vector(3)"},
+		{Name: "AuthorID", Type: "int", References: "Author"},
+	})
+	def.Tenant = true
+	f := NewFactory(def, rand.New(rand.NewSource(1)))
+
+	assert.Equal(t, []string{"email", "full_name", "age", "price", "active", "signed_up_at", "embedding", "author_id", "tenant_id"}, f.Columns())
+	assert.Error(t, f.Check(), "references and the tenant are missing")
+	f.SetReferences("AuthorID", []int64{7, 8})
+	f.SetTenant("acme")
+	assert.NoError(t, f.Check())
+
+	row := f.Row(42)
+	assert.Len(t, row, 9)
+	assert.True(t, strings.HasSuffix(row[0].(string), "42@example.com"), "emails are unique: %v", row[0])
+	assert.Contains(t, row[1], " ")
+	assert.GreaterOrEqual(t, row[2], int64(18))
+	assert.LessOrEqual(t, row[2], int64(90))
+	assert.IsType(t, float64(0), row[3])
+	assert.IsType(t, true, row[4])
+	assert.WithinDuration(t, time.Now(), row[5].(time.Time), 366*24*time.Hour)
+	assert.Regexp(t, `^\[-?\d\.\d{4},-?\d\.\d{4},-?\d\.\d{4}\]$`, row[6])
+	assert.Contains(t, []interface{}{int64(7), int64(8)}, row[7])
+	assert.Equal(t, "acme", row[8])
+}
+
+func TestFactory_SkipsGeneratedColumns(t *testing.T) {
+	def := model.NewModelDefinition("Line", []model.Field{
+		{Name: "Price", Type: "float64"},
+		{Name: "Total", Type: "float64", Generated: "price * 2"},
+		{Name: "Note", Type: "string"},
+	})
+	f := NewFactory(def, rand.New(rand.NewSource(1)))
+
+	assert.Equal(t, []string{"price", "note"}, f.Columns())
+	assert.Len(t, f.Row(1), 2)
+}
+
+func TestFactory_Source(t *testing.T) {
+	def := model.NewModelDefinition("Tag", []model.Field{{Name: "Label", Type: "string", IsPrimary: true}})
+	source := NewFactory(def, rand.New(rand.NewSource(1))).Source(3)
+
+	labels := map[interface{}]bool{}
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		labels[row[0]] = true
+	}
+	assert.Len(t, labels, 3, "primary keys are unique")
+}
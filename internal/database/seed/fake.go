@@ -0,0 +1,74 @@
+package seed
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fakeBatchRows is the number of rows inserted by each INSERT statement of a fake seed.
+const fakeBatchRows = 500
+
+// GenerateFake returns the SQL of a seed inserting the first rows rows generated by f into table, or into the
+// table of the factory's model if table is empty. The values are written as literals, so the seed can be
+// reviewed and committed, and runs the same every time. Like GenerateSkeleton, it declares the tables it
+// touches in a "-- tables:" comment.
+func GenerateFake(f *Factory, table string, rows int) string {
+	if table == "" {
+		table = f.def.TableName()
+	}
+	tables := []string{table}
+	for _, field := range f.def.Fields {
+		if field.References != "" {
+			tables = append(tables, referencedTable(field))
+		}
+	}
+	var columns []string
+	for _, column := range f.Columns() {
+		columns = append(columns, quote(column))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %d fake rows for %s, generated from the %s model.\n", rows, table, f.def.Name)
+	fmt.Fprintf(&b, "-- tables: %s\n", strings.Join(tables, ", "))
+	for start := 1; start <= rows; start += fakeBatchRows {
+		fmt.Fprintf(&b, "\nINSERT INTO %s (%s) VALUES\n", quote(table), strings.Join(columns, ", "))
+		for i := start; i < start+fakeBatchRows && i <= rows; i++ {
+			var values []string
+			for _, value := range f.Row(i) {
+				values = append(values, literal(value))
+			}
+			if i > start {
+				b.WriteString(",\n")
+			}
+			b.WriteString("  (" + strings.Join(values, ", ") + ")")
+		}
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// literal returns value, as generated by a Factory, as a PostgreSQL literal.
+func literal(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return "'" + v.UTC().Format("2006-01-02 15:04:05") + "+00'"
+	case []byte:
+		return `'\x` + hex.EncodeToString(v) + "'"
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+	}
+}
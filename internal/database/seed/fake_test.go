@@ -0,0 +1,41 @@
+package seed
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFake(t *testing.T) {
+	def := model.NewModelDefinition("Post", []model.Field{
+		{Name: "Title", Type: "string"},
+		{Name: "Views", Type: "int"},
+		{Name: "AuthorID", Type: "int", References: "Author"},
+	})
+	generate := func() string {
+		f := NewFactory(def, rand.New(rand.NewSource(7)))
+		f.SetReferences("AuthorID", []int64{3})
+		f.SetSince(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+		return GenerateFake(f, "", 501)
+	}
+
+	sql := generate()
+	assert.Equal(t, sql, generate(), "the same seed generates the same SQL")
+	assert.True(t, strings.HasPrefix(sql, "-- 501 fake rows for posts, generated from the Post model.\n-- tables: posts, authors\n"))
+	assert.Equal(t, 2, strings.Count(sql, "INSERT INTO posts (title, views, author_id) VALUES"), "rows are inserted in batches")
+	assert.Equal(t, 501, strings.Count(sql, ", 3)"))
+}
+
+func TestLiteral(t *testing.T) {
+	assert.Equal(t, "NULL", literal(nil))
+	assert.Equal(t, "TRUE", literal(true))
+	assert.Equal(t, "42", literal(int64(42)))
+	assert.Equal(t, "19.99", literal(19.99))
+	assert.Equal(t, "'O''Brien'", literal("O'Brien"))
+	assert.Equal(t, "'2024-03-01 12:30:00+00'", literal(time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)))
+	assert.Equal(t, `'\x0aff'`, literal([]byte{0x0a, 0xff}))
+}
@@ -1,29 +1,45 @@
 package seed
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/ooyeku/grayv-lsm/embedded"
+	"github.com/ooyeku/grayv-lsm/internal/database/vars"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
 	"github.com/sirupsen/logrus"
 )
 
 // Seed represents a database seed, which encapsulates the name and the SQL statements
-// to be executed.
+// to be executed. Tables lists the tables the seed touches, as declared or inferred by
+// seedTables; it is nil if they are unknown. CSV seeds hold their data in CSV instead
+// and name the table it is bulk loaded into in Table.
 type Seed struct {
-	Name string
-	SQL  string
+	Name   string
+	SQL    string
+	CSV    string
+	Table  string
+	Tables []string
 }
 
 // Seeder represents a struct for managing database seeding operations.
 //
-// It contains a database connection (db) and a set of seed objects (seeds).
+// It contains a database connection (db), the loader used for CSV seeds (loader) and a set of seed objects (seeds).
+// With ContinueOnError set, a failing statement of a SQL seed is rolled back to a savepoint, logged, and skipped,
+// and the other statements of the seed are kept. With Vars set, the ${NAME} placeholders of SQL seeds are
+// substituted before any seed runs, and an undefined variable fails the seeding.
 type Seeder struct {
-	db    *sql.DB
-	seeds []*Seed
+	db              *sql.DB
+	loader          *orm.BulkLoader
+	seeds           []*Seed
+	ContinueOnError bool
+	Vars            vars.Lookup
 }
 
 // NewSeeder creates a new instance of the Seeder struct which is used to seed the database with initial data.
@@ -31,33 +47,57 @@ type Seeder struct {
 // The sql.DB object is used to execute the SQL queries to seed the database.
 // Example usage: seeder := seed.NewSeeder(conn.GetDB())
 func NewSeeder(db *sql.DB) *Seeder {
-	return &Seeder{db: db}
+	return &Seeder{db: db, loader: orm.NewBulkLoader(db, "postgres")}
+}
+
+// SetBulkLoader sets the loader used for CSV seeds. By default they are loaded with COPY
+// through the lib/pq driver; use the loader of the connection with other drivers, such as pgx.
+// Example usage: seeder.SetBulkLoader(conn.BulkLoader())
+func (s *Seeder) SetBulkLoader(loader *orm.BulkLoader) {
+	s.loader = loader
 }
 
 // LoadSeeds loads the seed files from the embedded "seeds" directory and populates the Seeder's seeds slice.
-// Seed files must have a .sql extension. The seeds are sorted in alphabetical order by filename.
+// Seed files must have a .sql or .csv extension. A CSV seed is loaded into the table named by its filename
+// without the extension and any numeric prefix, so 02_users.csv fills users; its first record names the
+// columns. The seeds are sorted in alphabetical order by filename.
 // Returns an error if the embedded seeds directory cannot be read or if any seed file fails to be read.
 // This method is part of the Seeder type.
 func (s *Seeder) LoadSeeds() error {
-	entries, err := embedded.EmbeddedFiles.ReadDir("seeds")
+	return s.LoadSeedsFS(embedded.EmbeddedFiles, "seeds")
+}
+
+// LoadSeedsFS loads the seed files in the directory dir of fsys, like LoadSeeds does for the embedded seeds.
+// Example usage: seeder.LoadSeedsFS(os.DirFS("myapp_grav/seeds"), ".")
+func (s *Seeder) LoadSeedsFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
 	if err != nil {
-		return fmt.Errorf("failed to read embedded seeds directory: %w", err)
+		return fmt.Errorf("failed to read seeds directory: %w", err)
 	}
 
 	var loadErrors []error
 	for _, entry := range entries {
-		if filepath.Ext(entry.Name()) == ".sql" {
-			seedContent, err := embedded.EmbeddedFiles.ReadFile(filepath.Join("seeds", entry.Name()))
-			if err != nil {
-				loadErrors = append(loadErrors, fmt.Errorf("failed to read seed file %s: %w", entry.Name(), err))
-				continue
-			}
-			seed := &Seed{
-				Name: entry.Name(),
-				SQL:  string(seedContent),
-			}
-			s.seeds = append(s.seeds, seed)
+		ext := filepath.Ext(entry.Name())
+		if ext != ".sql" && ext != ".csv" {
+			continue
+		}
+
+		seedContent, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("failed to read seed file %s: %w", entry.Name(), err))
+			continue
+		}
+
+		seed := &Seed{Name: entry.Name()}
+		if ext == ".csv" {
+			seed.CSV = string(seedContent)
+			seed.Table = csvSeedTable(entry.Name())
+			seed.Tables = []string{seed.Table}
+		} else {
+			seed.SQL = string(seedContent)
+			seed.Tables = seedTables(seed.SQL)
 		}
+		s.seeds = append(s.seeds, seed)
 	}
 
 	sort.Slice(s.seeds, func(i, j int) bool {
@@ -73,7 +113,11 @@ func (s *Seeder) LoadSeeds() error {
 
 // Seed executes all the loaded seeds in the Seeder. Returns an error if any seed fails to execute.
 func (s *Seeder) Seed() error {
-	for _, seed := range s.seeds {
+	seeds, err := s.expandedSeeds()
+	if err != nil {
+		return err
+	}
+	for _, seed := range seeds {
 		if err := s.executeSeed(seed); err != nil {
 			return err
 		}
@@ -81,10 +125,31 @@ func (s *Seeder) Seed() error {
 	return nil
 }
 
+// expandedSeeds returns copies of the loaded seeds with the placeholders of their SQL substituted by Vars.
+// Without Vars, the seeds are returned as they are.
+func (s *Seeder) expandedSeeds() ([]*Seed, error) {
+	if s.Vars == nil {
+		return s.seeds, nil
+	}
+	seeds := make([]*Seed, len(s.seeds))
+	for i, seed := range s.seeds {
+		expanded := *seed
+		if seed.SQL != "" {
+			var err error
+			if expanded.SQL, err = vars.Expand(seed.SQL, s.Vars); err != nil {
+				return nil, fmt.Errorf("seed %s: %w", seed.Name, err)
+			}
+		}
+		seeds[i] = &expanded
+	}
+	return seeds, nil
+}
+
 // executeSeed executes the given seed by starting a transaction, executing the SQL statements,
 // and committing the transaction. If any error occurs during the process, the transaction
-// will be rolled back and the error will be returned. Otherwise, a log message will be printed
-// indicating the successful execution of the seed.
+// will be rolled back and the error will be returned, unless ContinueOnError is set: then each
+// statement runs in a nested transaction and only the failing statements are undone. Otherwise,
+// a log message will be printed indicating the successful execution of the seed.
 //
 // Parameters:
 // - seed: The seed to be executed.
@@ -92,25 +157,34 @@ func (s *Seeder) Seed() error {
 // Returns:
 // - An error if any error occurs during the execution of the seed, otherwise nil.
 func (s *Seeder) executeSeed(seed *Seed) error {
-	tx, err := s.db.Begin()
+	if seed.Table != "" {
+		return s.executeCSVSeed(seed)
+	}
+
+	tx, err := orm.BeginTx(context.Background(), s.db)
 	if err != nil {
 		logrus.WithError(err).Error("error starting transaction")
 		return err
 	}
 	defer tx.Rollback()
 
-	// Split the SQL into individual statements
-	statements := strings.Split(seed.SQL, ";")
-
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
+	skipped := 0
+	for i, stmt := range orm.SplitStatements(seed.SQL) {
+		if !s.ContinueOnError {
+			if _, err := tx.Exec(stmt); err != nil {
+				logrus.WithError(err).Errorf("error executing seed %s", seed.Name)
+				return err
+			}
 			continue
 		}
 
-		if _, err := tx.Exec(stmt); err != nil {
-			logrus.WithError(err).Errorf("error executing seed %s", seed.Name)
+		err := tx.Nested("seed_statement", func() error {
+			_, err := tx.Exec(stmt)
 			return err
+		})
+		if err != nil {
+			logrus.WithError(err).Warnf("Skipped statement %d of seed %s", i+1, seed.Name)
+			skipped++
 		}
 	}
 
@@ -119,6 +193,32 @@ func (s *Seeder) executeSeed(seed *Seed) error {
 		return err
 	}
 
-	logrus.Infof("Executed seed: %s", seed.Name)
+	if skipped > 0 {
+		logrus.Warnf("Executed seed: %s (%d failed statements skipped)", seed.Name, skipped)
+	} else {
+		logrus.Infof("Executed seed: %s", seed.Name)
+	}
+	return nil
+}
+
+// executeCSVSeed bulk loads the data of a CSV seed into its table.
+func (s *Seeder) executeCSVSeed(seed *Seed) error {
+	count, err := s.loader.LoadCSV(seed.Table, strings.NewReader(seed.CSV))
+	if err != nil {
+		logrus.WithError(err).Errorf("error executing seed %s", seed.Name)
+		return err
+	}
+
+	logrus.Infof("Executed seed: %s (%d rows)", seed.Name, count)
 	return nil
 }
+
+// csvSeedTable returns the table a CSV seed file is loaded into: its name without the
+// extension and any numeric prefix.
+func csvSeedTable(filename string) string {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if index := strings.Index(name, "_"); index > 0 && strings.Trim(name[:index], "0123456789") == "" {
+		name = name[index+1:]
+	}
+	return name
+}
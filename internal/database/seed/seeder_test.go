@@ -0,0 +1,12 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVSeedTable(t *testing.T) {
+	assert.Equal(t, "users", csvSeedTable("02_users.csv"))
+	assert.Equal(t, "user_roles", csvSeedTable("user_roles.csv"))
+}
@@ -0,0 +1,210 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+)
+
+// Template selects the kind of seed generated by GenerateSkeleton.
+type Template string
+
+const (
+	// TemplateRows inserts sample rows into the table of a model.
+	TemplateRows Template = "rows"
+	// TemplateReference inserts reference data, such as countries or plans, skipping the rows that already
+	// exist so the seed can run again.
+	TemplateReference Template = "reference"
+	// TemplateJoin links the rows of two models in a join table.
+	TemplateJoin Template = "join"
+)
+
+// DefaultSkeletonRows is the number of rows a skeleton inserts if none is given.
+const DefaultSkeletonRows = 3
+
+// ParseTemplate returns the Template named by name: "rows", "reference", or "join". An empty name is TemplateRows.
+func ParseTemplate(name string) (Template, error) {
+	switch Template(name) {
+	case "":
+		return TemplateRows, nil
+	case TemplateRows, TemplateReference, TemplateJoin:
+		return Template(name), nil
+	default:
+		return "", fmt.Errorf("unknown seed template %q: use rows, reference, or join", name)
+	}
+}
+
+// Skeleton describes a seed generated from model definitions by GenerateSkeleton.
+type Skeleton struct {
+	Template Template
+	// Table is the table seeded. It defaults to the table of Model, or for TemplateJoin to JoinTable.
+	Table string
+	// Model is the model whose columns are seeded, or the first side of a join.
+	Model *model.ModelDefinition
+	// Join is the second side of a join, for TemplateJoin.
+	Join *model.ModelDefinition
+	// Rows is the number of rows inserted. Zero means DefaultSkeletonRows.
+	Rows int
+}
+
+// JoinTable returns the conventional name of the table joining the rows of a and b, such as post_tags.
+func JoinTable(a, b *model.ModelDefinition) string {
+	return naming.Current().JoinTable(a.Name, b.Name)
+}
+
+// GenerateSkeleton returns the SQL of a seed for s, with the column lists of its models and placeholder
+// values of the right types, ready to be edited. It declares the tables it touches in a "-- tables:" comment,
+// so it can run concurrently with other seeds.
+func GenerateSkeleton(s Skeleton) (string, error) {
+	if s.Model == nil {
+		return "", errors.New("a model is required")
+	}
+	if s.Rows <= 0 {
+		s.Rows = DefaultSkeletonRows
+	}
+
+	switch s.Template {
+	case TemplateRows, TemplateReference:
+		if s.Table == "" {
+			s.Table = s.Model.TableName()
+		}
+		return insertSkeleton(s), nil
+	case TemplateJoin:
+		if s.Join == nil {
+			return "", fmt.Errorf("the join template needs a second model to join %s with", s.Model.Name)
+		}
+		if s.Table == "" {
+			s.Table = JoinTable(s.Model, s.Join)
+		}
+		return joinSkeleton(s), nil
+	default:
+		return "", fmt.Errorf("unknown seed template %q", s.Template)
+	}
+}
+
+// insertSkeleton returns an INSERT of s.Rows rows into every column of s.Model that is not filled in by the database.
+// Computed fields have no column.
+func insertSkeleton(s Skeleton) string {
+	var columns []string
+	if s.Model.Tenant {
+		columns = append(columns, model.TenantColumn)
+	}
+	for _, field := range s.Model.Fields {
+		if field.Generated == "" && field.HasColumn() {
+			columns = append(columns, quote(field.ColumnName()))
+		}
+	}
+
+	var rows []string
+	for i := 1; i <= s.Rows; i++ {
+		var values []string
+		if s.Model.Tenant {
+			values = append(values, "'main'")
+		}
+		for _, field := range s.Model.Fields {
+			if field.Generated == "" && field.HasColumn() {
+				values = append(values, placeholderValue(field, i))
+			}
+		}
+		rows = append(rows, "  ("+strings.Join(values, ", ")+")")
+	}
+
+	tables := []string{s.Table}
+	var b strings.Builder
+	if s.Template == TemplateReference {
+		fmt.Fprintf(&b, "-- Reference data for %s, generated from the %s model.\n", s.Table, s.Model.Name)
+		b.WriteString("-- Rows that already exist are skipped, so the seed can run again; this needs a unique constraint\n")
+		b.WriteString("-- on the column identifying each row.\n")
+	} else {
+		fmt.Fprintf(&b, "-- Sample rows for %s, generated from the %s model. Replace the placeholder values.\n", s.Table, s.Model.Name)
+	}
+	for _, field := range s.Model.Fields {
+		if field.References != "" {
+			tables = append(tables, referencedTable(field))
+		}
+	}
+	fmt.Fprintf(&b, "-- tables: %s\n", strings.Join(tables, ", "))
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES\n%s", quote(s.Table), strings.Join(columns, ", "), strings.Join(rows, ",\n"))
+	if s.Template == TemplateReference {
+		b.WriteString("\nON CONFLICT DO NOTHING")
+	}
+	b.WriteString(";\n")
+	return b.String()
+}
+
+// joinSkeleton returns an INSERT linking the first s.Rows pairs of rows of s.Model and s.Join.
+func joinSkeleton(s Skeleton) string {
+	left, right := s.Model.TableName(), s.Join.TableName()
+	leftColumn, rightColumn := naming.Column(s.Model.Name+"ID"), naming.Column(s.Join.Name+"ID")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Links between %s and %s in the join table %s. Add a WHERE clause to pick the pairs.\n", left, right, s.Table)
+	fmt.Fprintf(&b, "-- tables: %s, %s, %s\n", s.Table, left, right)
+	fmt.Fprintf(&b, "INSERT INTO %s (%s, %s)\n", quote(s.Table), quote(leftColumn), quote(rightColumn))
+	fmt.Fprintf(&b, "SELECT %s.%s, %s.%s\nFROM %s CROSS JOIN %s\nORDER BY 1, 2\nLIMIT %d\nON CONFLICT DO NOTHING;\n",
+		quote(left), quote(s.Model.PrimaryKey()), quote(right), quote(s.Join.PrimaryKey()), quote(left), quote(right), s.Rows)
+	return b.String()
+}
+
+// placeholderValue returns a SQL literal of the type of field for row i of a skeleton.
+// A foreign key picks the first row of the referenced table.
+func placeholderValue(field model.Field, i int) string {
+	if field.References != "" {
+		return fmt.Sprintf("(SELECT min(id) FROM %s)", quote(referencedTable(field)))
+	}
+	if dimensions, ok := model.VectorDimensions(field.Type); ok {
+		return "'[" + strings.TrimSuffix(strings.Repeat("0,", dimensions), ",") + "]'"
+	}
+	if _, _, ok := model.DecimalPrecision(field.Type); ok {
+		return "0"
+	}
+
+	switch model.BaseType(field.Type) {
+	case "int":
+		return strconv.Itoa(i)
+	case "float64":
+		return strconv.Itoa(i) + ".0"
+	case "bool":
+		return "false"
+	case "time.Time":
+		return "CURRENT_TIMESTAMP"
+	case "[]byte":
+		return "''"
+	default:
+		return fmt.Sprintf("'%s_%d'", field.ColumnName(), i)
+	}
+}
+
+// referencedTable returns the table of the model field references.
+func referencedTable(field model.Field) string {
+	return model.NewModelDefinition(field.References, nil).TableName()
+}
+
+// NextSeedPath returns the path of a new seed file for name in dir, numbered after the seeds already
+// there so it runs last, such as seeds/03_users.sql.
+func NextSeedPath(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	next := 1
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if n, err := strconv.Atoi(prefix); ok && err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("%02d_%s.sql", next, name)), nil
+}
+
+func quote(name string) string {
+	return dialect.Postgres.Quote(name)
+}
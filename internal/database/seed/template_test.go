@@ -0,0 +1,65 @@
+package seed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func postModel() *model.ModelDefinition {
+	return model.NewModelDefinition("Post", []model.Field{
+		{Name: "Title", Type: "string"},
+		{Name: "Views", Type: "int"},
+		{Name: "Published", Type: "bool"},
+		{Name: "Embedding", Type: "vector(3)"},
+		{Name: "AuthorID", Type: "int", References: "Author"},
+	})
+}
+
+func TestGenerateSkeleton_Rows(t *testing.T) {
+	sql, err := GenerateSkeleton(Skeleton{Template: TemplateRows, Model: postModel(), Rows: 2})
+	assert.NoError(t, err)
+	assert.Contains(t, sql, "-- tables: posts, authors\n")
+	assert.Contains(t, sql, "INSERT INTO posts (title, views, published, embedding, author_id) VALUES\n"+
+		"  ('title_1', 1, false, '[0,0,0]', (SELECT min(id) FROM authors)),\n"+
+		"  ('title_2', 2, false, '[0,0,0]', (SELECT min(id) FROM authors));\n")
+	assert.Equal(t, []string{"authors", "posts"}, seedTables(sql))
+}
+
+func TestGenerateSkeleton_Reference(t *testing.T) {
+	def := model.NewModelDefinition("Plan", []model.Field{{Name: "Code", Type: "string"}})
+	def.Tenant = true
+
+	sql, err := GenerateSkeleton(Skeleton{Template: TemplateReference, Model: def, Rows: 1})
+	assert.NoError(t, err)
+	assert.Contains(t, sql, "INSERT INTO plans (tenant_id, code) VALUES\n  ('main', 'code_1')\nON CONFLICT DO NOTHING;\n")
+}
+
+func TestGenerateSkeleton_Join(t *testing.T) {
+	tag := model.NewModelDefinition("Tag", []model.Field{{Name: "Name", Type: "string"}})
+
+	sql, err := GenerateSkeleton(Skeleton{Template: TemplateJoin, Model: postModel(), Join: tag})
+	assert.NoError(t, err)
+	assert.Contains(t, sql, "INSERT INTO post_tags (post_id, tag_id)\nSELECT posts.id, tags.id\nFROM posts CROSS JOIN tags\n")
+	assert.Contains(t, sql, "LIMIT 3\n")
+
+	_, err = GenerateSkeleton(Skeleton{Template: TemplateJoin, Model: postModel()})
+	assert.Error(t, err)
+}
+
+func TestNextSeedPath(t *testing.T) {
+	dir := t.TempDir()
+	path, err := NextSeedPath(filepath.Join(dir, "missing"), "users")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "missing", "01_users.sql"), path)
+
+	for _, name := range []string{"01_users.sql", "07_posts.csv", "notes.txt"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	path, err = NextSeedPath(dir, "tags")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "08_tags.sql"), path)
+}
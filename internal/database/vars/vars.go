@@ -0,0 +1,60 @@
+// Package vars substitutes ${NAME} placeholders in the SQL of migrations and seeds, so the same files work
+// across environments. A placeholder may give a default, as in ${NAME:-default}, and $${NAME} is written
+// as the literal text ${NAME}. Substitution is strict: a placeholder without a value or a default is an error.
+package vars
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lookup returns the value of the variable name, and false if it is undefined.
+type Lookup func(name string) (string, bool)
+
+var placeholderPattern = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// Expand replaces the placeholders in sql with the values returned by lookup. Values are inserted verbatim,
+// so a string value is quoted in the SQL, as in '${ADMIN_EMAIL}'. It returns an error naming every
+// placeholder that has neither a value nor a default.
+func Expand(sql string, lookup Lookup) (string, error) {
+	undefined := map[string]bool{}
+	expanded := placeholderPattern.ReplaceAllStringFunc(sql, func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		escaped, name, fallback := match[1] != "", match[2], match[3]
+		if escaped {
+			return placeholder[1:]
+		}
+		if value, ok := lookup(name); ok {
+			return value
+		}
+		if fallback != "" {
+			return strings.TrimPrefix(fallback, ":-")
+		}
+		undefined[name] = true
+		return placeholder
+	})
+
+	if len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("undefined variables: %s", strings.Join(names, ", "))
+	}
+	return expanded, nil
+}
+
+// FromConfig returns a Lookup finding variables in the Variables section of the configuration first,
+// then in the environment.
+func FromConfig(variables map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		if value, ok := variables[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+}
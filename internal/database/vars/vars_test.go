@@ -0,0 +1,42 @@
+package vars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		value, ok := map[string]string{"ADMIN_EMAIL": "admin@example.com", "EMPTY": ""}[name]
+		return value, ok
+	}
+
+	sql, err := Expand("INSERT INTO users (email, tenant, note) VALUES ('${ADMIN_EMAIL}', '${TENANT:-default}', '${EMPTY:-x}');", lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (email, tenant, note) VALUES ('admin@example.com', 'default', '');", sql)
+
+	sql, err = Expand("SELECT '$${ADMIN_EMAIL}', $1, $$body$$;", lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT '${ADMIN_EMAIL}', $1, $$body$$;", sql)
+
+	_, err = Expand("SELECT '${B}', '${A}', '${B}', '${ADMIN_EMAIL}';", lookup)
+	assert.EqualError(t, err, "undefined variables: A, B")
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv("GRAYV_TEST_REGION", "eu")
+	t.Setenv("GRAYV_TEST_NAME", "from-env")
+	lookup := FromConfig(map[string]string{"GRAYV_TEST_NAME": "from-config"})
+
+	value, ok := lookup("GRAYV_TEST_NAME")
+	assert.True(t, ok)
+	assert.Equal(t, "from-config", value)
+
+	value, ok = lookup("GRAYV_TEST_REGION")
+	assert.True(t, ok)
+	assert.Equal(t, "eu", value)
+
+	_, ok = lookup("GRAYV_TEST_UNDEFINED")
+	assert.False(t, ok)
+}
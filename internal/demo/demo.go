@@ -0,0 +1,163 @@
+// Package demo provides the models and fake data of the blog application created by `grayv-lsm demo`.
+package demo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest defines the models of the demo blog in the format read by `grayv-lsm model create --file`.
+// Authors write posts and comment on them. The author model is not called User because grayv-lsm
+// keeps its own accounts in the users table.
+const Manifest = `models:
+  - name: Author
+    fields: [name:string, email:string, bio:string]
+  - name: Post
+    fields: [title:string, body:string, published:bool]
+    belongs_to: [Author]
+  - name: Comment
+    fields: [body:string]
+    belongs_to: [Post, Author]
+`
+
+// Definitions returns the validated definitions of the demo models, in dependency order.
+func Definitions() ([]*model.ModelDefinition, error) {
+	var manifest model.Manifest
+	if err := yaml.Unmarshal([]byte(Manifest), &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing demo manifest: %w", err)
+	}
+	return manifest.Definitions()
+}
+
+// Size controls how much fake data Seed inserts.
+type Size struct {
+	Authors         int
+	PostsPerAuthor  int
+	CommentsPerPost int
+}
+
+// DefaultSize is the amount of fake data inserted by `grayv-lsm demo`.
+var DefaultSize = Size{Authors: 5, PostsPerAuthor: 4, CommentsPerPost: 3}
+
+var (
+	firstNames = []string{"Ada", "Grace", "Linus", "Barbara", "Ken", "Margaret", "Dennis", "Frances", "Edsger", "Radia"}
+	lastNames  = []string{"Lovelace", "Hopper", "Torvalds", "Liskov", "Thompson", "Hamilton", "Ritchie", "Allen", "Dijkstra", "Perlman"}
+	topics     = []string{"Go", "PostgreSQL", "migrations", "code generation", "testing", "indexes", "caching", "deployments"}
+	verbs      = []string{"Getting started with", "Ten tips for", "What I learned about", "A closer look at", "Rethinking"}
+	remarks    = []string{"Great post!", "Thanks, this helped a lot.", "I disagree with the second point.", "Could you share the code?", "Bookmarked."}
+)
+
+// Author is a fake author.
+type Author struct {
+	Name  string
+	Email string
+	Bio   string
+}
+
+// Post is a fake post written by the author at index Author of the generated authors.
+type Post struct {
+	Author    int
+	Title     string
+	Body      string
+	Published bool
+}
+
+// Comment is a fake comment on the post at index Post by the author at index Author.
+type Comment struct {
+	Post   int
+	Author int
+	Body   string
+}
+
+// Data is a generated set of fake blog content.
+type Data struct {
+	Authors  []Author
+	Posts    []Post
+	Comments []Comment
+}
+
+// Generate returns fake blog content of the given size. The same rng seed always yields the same data.
+func Generate(size Size, rng *rand.Rand) Data {
+	var data Data
+	for i := 0; i < size.Authors; i++ {
+		first, last := firstNames[i%len(firstNames)], lastNames[rng.Intn(len(lastNames))]
+		data.Authors = append(data.Authors, Author{
+			Name:  first + " " + last,
+			Email: fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), i+1),
+			Bio:   fmt.Sprintf("Writes about %s and %s.", topics[rng.Intn(len(topics))], topics[rng.Intn(len(topics))]),
+		})
+
+		for j := 0; j < size.PostsPerAuthor; j++ {
+			topic := topics[rng.Intn(len(topics))]
+			data.Posts = append(data.Posts, Post{
+				Author:    i,
+				Title:     verbs[rng.Intn(len(verbs))] + " " + topic,
+				Body:      fmt.Sprintf("Notes from %s on %s, with examples from a real project.", first, topic),
+				Published: rng.Intn(4) != 0,
+			})
+		}
+	}
+
+	for p := range data.Posts {
+		for k := 0; k < size.CommentsPerPost; k++ {
+			data.Comments = append(data.Comments, Comment{
+				Post:   p,
+				Author: rng.Intn(len(data.Authors)),
+				Body:   remarks[rng.Intn(len(remarks))],
+			})
+		}
+	}
+	return data
+}
+
+// Seed inserts data into the tables created for the demo models, in the transaction tx of conn.
+func Seed(ctx context.Context, conn *orm.Connection, tx *sql.Tx, data Data) error {
+	authorIDs := make([]int64, len(data.Authors))
+	for i, author := range data.Authors {
+		id, err := insert(ctx, conn, tx, "INSERT INTO authors (name, email, bio) VALUES (?, ?, ?)", author.Name, author.Email, author.Bio)
+		if err != nil {
+			return fmt.Errorf("error inserting author: %w", err)
+		}
+		authorIDs[i] = id
+	}
+
+	postIDs := make([]int64, len(data.Posts))
+	for i, post := range data.Posts {
+		id, err := insert(ctx, conn, tx, "INSERT INTO posts (title, body, published, author_id) VALUES (?, ?, ?, ?)",
+			post.Title, post.Body, post.Published, authorIDs[post.Author])
+		if err != nil {
+			return fmt.Errorf("error inserting post: %w", err)
+		}
+		postIDs[i] = id
+	}
+
+	for _, comment := range data.Comments {
+		if _, err := tx.ExecContext(ctx, conn.Rebind("INSERT INTO comments (body, post_id, author_id) VALUES (?, ?, ?)"),
+			comment.Body, postIDs[comment.Post], authorIDs[comment.Author]); err != nil {
+			return fmt.Errorf("error inserting comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// insert runs the INSERT statement query and returns the id of the row it inserted: through RETURNING on
+// PostgreSQL, whose driver has no last insert id, and from the result on MySQL, which has no RETURNING, and SQLite.
+func insert(ctx context.Context, conn *orm.Connection, tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	if d := conn.Dialect(); d.IsMySQL() || d.IsSQLite() {
+		result, err := tx.ExecContext(ctx, conn.Rebind(query), args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	}
+	var id int64
+	err := tx.QueryRowContext(ctx, conn.Rebind(query+" RETURNING id"), args...).Scan(&id)
+	return id, err
+}
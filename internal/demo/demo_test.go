@@ -0,0 +1,84 @@
+package demo
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinitions(t *testing.T) {
+	defs, err := Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var tables []string
+	for _, def := range defs {
+		tables = append(tables, def.TableName())
+	}
+	// Seed inserts into these tables, so they must match the generated migrations.
+	assert.Equal(t, []string{"authors", "posts", "comments"}, tables)
+	assert.Equal(t, "author_id", defs[1].Fields[len(defs[1].Fields)-1].ColumnName())
+}
+
+func TestGenerate(t *testing.T) {
+	size := Size{Authors: 3, PostsPerAuthor: 2, CommentsPerPost: 4}
+	data := Generate(size, rand.New(rand.NewSource(1)))
+
+	assert.Len(t, data.Authors, 3)
+	assert.Len(t, data.Posts, 6)
+	assert.Len(t, data.Comments, 24)
+
+	emails := map[string]bool{}
+	for _, author := range data.Authors {
+		emails[author.Email] = true
+	}
+	assert.Len(t, emails, 3, "emails must be unique")
+
+	for _, comment := range data.Comments {
+		assert.Less(t, comment.Post, len(data.Posts))
+		assert.Less(t, comment.Author, len(data.Authors))
+	}
+
+	assert.Equal(t, data, Generate(size, rand.New(rand.NewSource(1))), "the same seed must yield the same data")
+}
+
+func TestSeed_SQLite(t *testing.T) {
+	conn, err := orm.NewConnection(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "demo.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	defs, err := Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+	mm := model.ModelManager{Dialect: dialect.SQLite}
+	for _, def := range defs {
+		if _, err := conn.GetDB().Exec(mm.GenerateMigration(def)); !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	tx, err := conn.GetDB().Begin()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tx.Rollback()
+	data := Generate(Size{Authors: 2, PostsPerAuthor: 2, CommentsPerPost: 1}, rand.New(rand.NewSource(1)))
+	if !assert.NoError(t, Seed(context.Background(), conn, tx, data)) {
+		return
+	}
+	// Every comment references a post and an author that were inserted.
+	var orphans int
+	assert.NoError(t, tx.QueryRow(`SELECT COUNT(*) FROM comments
+		WHERE post_id NOT IN (SELECT id FROM posts) OR author_id NOT IN (SELECT id FROM authors)`).Scan(&orphans))
+	assert.Zero(t, orphans)
+}
@@ -0,0 +1,173 @@
+// Package dialect quotes and validates SQL identifiers, such as table and column names,
+// for the database drivers grayv-lsm supports.
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxIdentifierLength is the longest identifier PostgreSQL accepts without truncating it.
+const MaxIdentifierLength = 63
+
+// reservedWords are words that cannot be used as unquoted table or column names in PostgreSQL.
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true,
+	"asc": true, "asymmetric": true, "both": true, "case": true, "cast": true, "check": true, "collate": true,
+	"column": true, "constraint": true, "create": true, "current_catalog": true, "current_date": true,
+	"current_role": true, "current_time": true, "current_timestamp": true, "current_user": true,
+	"default": true, "deferrable": true, "desc": true, "distinct": true, "do": true, "else": true, "end": true,
+	"except": true, "false": true, "fetch": true, "for": true, "foreign": true, "from": true, "grant": true,
+	"group": true, "having": true, "in": true, "initially": true, "intersect": true, "into": true,
+	"lateral": true, "leading": true, "limit": true, "localtime": true, "localtimestamp": true, "not": true,
+	"null": true, "offset": true, "on": true, "only": true, "or": true, "order": true, "placing": true,
+	"primary": true, "references": true, "returning": true, "select": true, "session_user": true,
+	"some": true, "symmetric": true, "table": true, "then": true, "to": true, "trailing": true, "true": true,
+	"union": true, "unique": true, "user": true, "using": true, "variadic": true, "when": true, "where": true,
+	"window": true, "with": true,
+}
+
+// IsReserved reports whether word is a reserved SQL word, ignoring case.
+func IsReserved(word string) bool {
+	return reservedWords[strings.ToLower(word)]
+}
+
+// Dialect describes how a database quotes identifiers. The zero value quotes like PostgreSQL.
+type Dialect struct {
+	Name  string
+	quote byte
+}
+
+var (
+	// Postgres quotes identifiers with double quotes, as do SQLite and the SQL standard.
+	Postgres = Dialect{Name: "postgres", quote: '"'}
+	// MySQL quotes identifiers with backticks, as does MariaDB.
+	MySQL = Dialect{Name: "mysql", quote: '`'}
+	// SQLite quotes identifiers like PostgreSQL, but lacks its column types, schemas, row-level security,
+	// partitions, and materialized views.
+	SQLite = Dialect{Name: "sqlite", quote: '"'}
+)
+
+// ForDriver returns the dialect of the named database/sql driver: SQLite for sqlite and sqlite3, MySQL for
+// mysql, and Postgres for other drivers.
+func ForDriver(driver string) Dialect {
+	switch driver {
+	case "mysql":
+		return MySQL
+	case "sqlite", "sqlite3":
+		return SQLite
+	}
+	return Postgres
+}
+
+// IsSQLite reports whether d is the SQLite dialect.
+func (d Dialect) IsSQLite() bool {
+	return d.Name == SQLite.Name
+}
+
+// IsMySQL reports whether d is the MySQL dialect, which MariaDB also speaks.
+func (d Dialect) IsMySQL() bool {
+	return d.Name == MySQL.Name
+}
+
+// Quote returns name as an identifier that is safe to interpolate into SQL. Like PostgreSQL's quote_ident,
+// it only quotes names that need it: those that are not lowercase letters, digits, and underscores, or
+// that are reserved words. Qualified names such as "public.users" are quoted part by part, and a "*"
+// part is kept, so "t.*" stays as it is. Quote characters inside a name are doubled.
+func (d Dialect) Quote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part != "*" && needsQuotes(part) {
+			q := string(d.quoteChar())
+			parts[i] = q + strings.ReplaceAll(part, q, q+q) + q
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// QuoteAll quotes every name in names.
+func (d Dialect) QuoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.Quote(name)
+	}
+	return quoted
+}
+
+// QuoteExpr quotes expr if it is a plain, possibly qualified, identifier and returns any other
+// expression, such as "COUNT(*)" or "total AS sum", unchanged. Use it where callers may pass
+// either a column name or a raw SQL expression.
+func (d Dialect) QuoteExpr(expr string) string {
+	if !isName(expr) {
+		return expr
+	}
+	return d.Quote(expr)
+}
+
+func (d Dialect) quoteChar() byte {
+	if d.quote == 0 {
+		return '"'
+	}
+	return d.quote
+}
+
+// Validate returns an error unless name, or each part of a qualified name, starts with a letter or
+// underscore, consists of letters, digits, and underscores, and is at most MaxIdentifierLength bytes.
+// Use it on names that come from user input before quoting them.
+func Validate(name string) error {
+	for _, part := range strings.Split(name, ".") {
+		switch {
+		case part == "":
+			return fmt.Errorf("invalid identifier %q: name is empty", name)
+		case len(part) > MaxIdentifierLength:
+			return fmt.Errorf("invalid identifier %q: longer than %d bytes", name, MaxIdentifierLength)
+		case !isIdentifier(part):
+			return fmt.Errorf("invalid identifier %q: only letters, digits, and underscores are allowed, starting with a letter or underscore", name)
+		}
+	}
+	return nil
+}
+
+// needsQuotes reports whether part must be quoted to be read as written.
+func needsQuotes(part string) bool {
+	if part == "" || IsReserved(part) {
+		return true
+	}
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		switch {
+		case c >= 'a' && c <= 'z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// isName reports whether expr is an identifier or a qualified name made of identifiers.
+func isName(expr string) bool {
+	for _, part := range strings.Split(expr, ".") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIdentifier reports whether part consists of ASCII letters, digits, and underscores and does not start with a digit.
+func isIdentifier(part string) bool {
+	if part == "" {
+		return false
+	}
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
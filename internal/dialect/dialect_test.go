@@ -0,0 +1,46 @@
+package dialect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, "users", Postgres.Quote("users"))
+	assert.Equal(t, `"User"`, Postgres.Quote("User"))
+	assert.Equal(t, `"order"`, Postgres.Quote("order"))
+	assert.Equal(t, `public."Posts"`, Postgres.Quote("public.Posts"))
+	assert.Equal(t, "t.*", Postgres.Quote("t.*"))
+	assert.Equal(t, `"a""b"`, Postgres.Quote(`a"b`))
+	assert.Equal(t, "`select`", MySQL.Quote("select"))
+	assert.Equal(t, `"User"`, Dialect{}.Quote("User"))
+}
+
+func TestQuoteExpr(t *testing.T) {
+	assert.Equal(t, `"Email"`, Postgres.QuoteExpr("Email"))
+	assert.Equal(t, "COUNT(*)", Postgres.QuoteExpr("COUNT(*)"))
+	assert.Equal(t, "total AS sum", Postgres.QuoteExpr("total AS sum"))
+	assert.Equal(t, "*", Postgres.QuoteExpr("*"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("users"))
+	assert.NoError(t, Validate("public.User_Roles"))
+	assert.Error(t, Validate("users; DROP TABLE users"))
+	assert.Error(t, Validate("1users"))
+	assert.Error(t, Validate("public."))
+	assert.Error(t, Validate(string(make([]byte, 64))))
+}
+
+func TestForDriver(t *testing.T) {
+	assert.Equal(t, MySQL, ForDriver("mysql"))
+	assert.Equal(t, Postgres, ForDriver("postgres"))
+	assert.Equal(t, Postgres, ForDriver("pgx"))
+	assert.Equal(t, SQLite, ForDriver("sqlite"))
+	assert.Equal(t, SQLite, ForDriver("sqlite3"))
+	assert.True(t, ForDriver("sqlite3").IsSQLite())
+	assert.False(t, Postgres.IsSQLite())
+	assert.True(t, ForDriver("mysql").IsMySQL())
+	assert.False(t, SQLite.IsMySQL())
+}
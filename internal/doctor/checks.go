@@ -0,0 +1,314 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/ooyeku/grayv-lsm/embedded"
+	"github.com/ooyeku/grayv-lsm/internal/app"
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Docker checks that the Docker daemon is reachable through its API, since the database runs in a container. The
+// daemon is found the way lsm finds it: from the DOCKER_HOST environment variables, or at the default socket. A
+// SQLite database is a file rather than a container, so it passes without the daemon.
+func Docker(cfg *config.Config) Check {
+	return Check{Name: "Docker", Run: func(ctx context.Context) Result {
+		if cfg != nil && cfg.Database.IsSQLite() {
+			return ok("not needed, the SQLite database is a file")
+		}
+		const fix = "Install Docker from https://docs.docker.com/get-docker/ and start its daemon, and check that your user may access it"
+
+		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return fail(fix, "cannot create a Docker client: %v", err)
+		}
+		defer cli.Close()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		ping, err := cli.Ping(ctx)
+		if err != nil {
+			return fail(fix, "Docker daemon not reachable: %v", err)
+		}
+		return ok("Docker daemon is running, API version %s", ping.APIVersion)
+	}}
+}
+
+// Config checks the configuration loaded by config.LoadConfig. loadErr is the error LoadConfig returned, if any.
+func Config(cfg *config.Config, loadErr error) Check {
+	return Check{Name: "Configuration", Run: func(ctx context.Context) Result {
+		if loadErr != nil {
+			return fail("Fix or delete the configuration file; grayv-lsm recreates it from the defaults", "cannot load configuration: %v", loadErr)
+		}
+		if problems := validateConfig(cfg); len(problems) > 0 {
+			return fail("Correct the values with `grayv-lsm config set <key> <value>`", "%s", strings.Join(problems, "; "))
+		}
+		return ok("configuration is valid")
+	}}
+}
+
+// validateConfig returns a description of every invalid setting in cfg, naming the keys of `config set`.
+func validateConfig(cfg *config.Config) []string {
+	var problems []string
+	check := func(valid bool, format string, args ...interface{}) {
+		if !valid {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	switch cfg.Database.Driver {
+	case "", "postgres", "pgx", "mysql", "sqlite", "sqlite3":
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver %q must be postgres, pgx, mysql, or sqlite", cfg.Database.Driver))
+	}
+	if cfg.Database.IsSQLite() {
+		// A SQLite database is the file at database.path, or at database.name with .db appended.
+		check(cfg.Database.Path != "" || cfg.Database.Name != "", "database.path is empty")
+	} else {
+		check(cfg.Database.Host != "", "database.host is empty")
+		check(validPort(cfg.Database.Port), "database.port %d is not a valid port", cfg.Database.Port)
+		check(cfg.Database.User != "", "database.user is empty")
+		check(cfg.Database.Name != "", "database.name is empty")
+	}
+	check(validPort(cfg.Server.Port), "server.port %d is not a valid port", cfg.Server.Port)
+
+	if cfg.Database.StatementTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.Database.StatementTimeout)
+		check(err == nil && timeout >= 0, "database.statementtimeout %q is not a duration such as 30s", cfg.Database.StatementTimeout)
+	}
+	check(cfg.Database.SnowflakeNode >= 0 && cfg.Database.SnowflakeNode <= orm.MaxSnowflakeNode,
+		"database.snowflakenode %d must be between 0 and %d", cfg.Database.SnowflakeNode, orm.MaxSnowflakeNode)
+	if cfg.Logging.Level != "" {
+		_, err := logrus.ParseLevel(cfg.Logging.Level)
+		check(err == nil, "logging.level %q is not a log level", cfg.Logging.Level)
+	}
+	switch cfg.Server.RateLimit.Backend {
+	case "", "memory", "database":
+	default:
+		problems = append(problems, fmt.Sprintf("server.ratelimit.backend %q must be memory or database", cfg.Server.RateLimit.Backend))
+	}
+	switch cfg.Password.Algorithm {
+	case "", "bcrypt", "argon2id":
+	default:
+		problems = append(problems, fmt.Sprintf("password.algorithm %q must be bcrypt or argon2id", cfg.Password.Algorithm))
+	}
+	switch cfg.Pooler.PoolMode {
+	case "", "session", "transaction", "statement":
+	default:
+		problems = append(problems, fmt.Sprintf("pooler.poolmode %q must be one of %s", cfg.Pooler.PoolMode, strings.Join(config.PoolModes, ", ")))
+	}
+	check(!cfg.Server.CORS.AllowCredentials || !slices.Contains(cfg.Server.CORS.AllowedOrigins, "*"),
+		"server.cors.allowcredentials cannot be combined with the origin *")
+	check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, "tracing.sampleratio %v must be between 0 and 1", cfg.Tracing.SampleRatio)
+	if _, err := orm.RetentionPolicies(cfg.Retention); err != nil {
+		problems = append(problems, fmt.Sprintf("retention: %v", err))
+	}
+	check(cfg.Retention.BatchSize >= 0, "retention.batchsize %d must not be negative", cfg.Retention.BatchSize)
+	return problems
+}
+
+func validPort(port int) bool {
+	return port > 0 && port < 65536
+}
+
+// Apps checks that every Grav app in the current directory declares a module, and that the models generated
+// into it import the model package of that module, which breaks when an app's module is renamed.
+func Apps(creator *app.AppCreator) Check {
+	return Check{Name: "App module paths", Run: func(ctx context.Context) Result {
+		names, err := creator.ListApps()
+		if err != nil {
+			return fail("Run grayv-lsm from a readable directory", "cannot list apps: %v", err)
+		}
+		if len(names) == 0 {
+			return ok("no apps in the current directory")
+		}
+
+		var problems []string
+		for _, name := range names {
+			target, err := creator.FindApp(name)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			problems = append(problems, checkModelImports(target)...)
+		}
+		if len(problems) > 0 {
+			return fail("Make each app's go.mod declare its module, then regenerate its models with `grayv-lsm model generate --all --app <name> --force`",
+				"%s", strings.Join(problems, "; "))
+		}
+		return ok("module paths match in %s", strings.Join(names, ", "))
+	}}
+}
+
+// checkModelImports reports generated model files of target importing a model package from another module.
+func checkModelImports(target *app.App) []string {
+	files, _ := filepath.Glob(filepath.Join(target.ModelsDir(), "*.go"))
+	want := target.ModulePath + "/internal/model"
+
+	var problems []string
+	for _, file := range files {
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s does not parse: %v", file, err))
+			continue
+		}
+		for _, spec := range parsed.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if strings.HasSuffix(path, "/internal/model") && path != want {
+				problems = append(problems, fmt.Sprintf("%s imports %s instead of %s", file, path, want))
+			}
+		}
+	}
+	return problems
+}
+
+// EmbeddedAssets checks that the files built into the binary are present and parse: the database Dockerfile,
+// the default configuration, the seeds, and the migrations.
+func EmbeddedAssets() Check {
+	return Check{Name: "Embedded assets", Run: func(ctx context.Context) Result {
+		const fix = "Reinstall grayv-lsm, or rebuild it from a clean checkout"
+
+		if dockerfile, err := embedded.EmbeddedFiles.ReadFile("Dockerfile"); err != nil || len(dockerfile) == 0 {
+			return fail(fix, "the database Dockerfile is missing")
+		}
+		if _, err := lsm.RenderDockerfile(config.DockerImageConfig{}); err != nil {
+			return fail(fix, "the database Dockerfile does not render: %v", err)
+		}
+		if _, err := lsm.RenderMySQLDockerfile(config.DockerImageConfig{}); err != nil {
+			return fail(fix, "the MySQL database Dockerfile does not render: %v", err)
+		}
+
+		data, err := embedded.EmbeddedFiles.ReadFile("config.json")
+		if err != nil {
+			return fail(fix, "the default configuration is missing")
+		}
+		var defaults config.Config
+		if err := json.Unmarshal(data, &defaults); err != nil {
+			return fail(fix, "the default configuration does not parse: %v", err)
+		}
+
+		seeds, err := fs.Glob(embedded.EmbeddedFiles, "seeds/*.sql")
+		if err != nil || len(seeds) == 0 {
+			return fail(fix, "the database seeds are missing")
+		}
+
+		if err := migration.NewMigrator(nil, quietLogger()).LoadMigrations(); err != nil {
+			return fail(fix, "the migrations do not load: %v", err)
+		}
+		migrations, _ := fs.Glob(embedded.EmbeddedFiles, "migrations/*.sql")
+		return ok("%d migrations and %d seed files", len(migrations), len(seeds))
+	}}
+}
+
+// Port checks that the API server can listen on its configured address.
+func Port(cfg *config.Config) Check {
+	return Check{Name: "Server port", Run: func(ctx context.Context) Result {
+		if cfg == nil {
+			return skip("configuration not loaded")
+		}
+		address := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return warn("Stop the process using the port, or choose another with `grayv-lsm config set server.port <port>`",
+				"cannot listen on %s: %v", address, err)
+		}
+		listener.Close()
+		return ok("%s is free", address)
+	}}
+}
+
+// Database checks that the configured database accepts connections.
+func Database(cfg *config.Config) Check {
+	return Check{Name: "Database", Run: func(ctx context.Context) Result {
+		if cfg == nil {
+			return skip("configuration not loaded")
+		}
+		if err := withConnection(ctx, cfg, func(*orm.Connection) error { return nil }); err != nil {
+			if cfg.Database.IsSQLite() {
+				return fail("Check database.path, and that its directory exists and is writable", "%v", err)
+			}
+			return fail("Start the database with `grayv-lsm db start`, and check the database.* settings", "%v", err)
+		}
+		if cfg.Database.IsSQLite() {
+			return ok("opened %s", cfg.Database.FilePath())
+		}
+		return ok("connected to %s at %s:%d", cfg.Database.Name, cfg.Database.Host, cfg.Database.Port)
+	}}
+}
+
+// Migrations checks that every embedded migration has been applied to the database.
+func Migrations(cfg *config.Config) Check {
+	return Check{Name: "Migrations", Run: func(ctx context.Context) Result {
+		if cfg == nil {
+			return skip("configuration not loaded")
+		}
+
+		var pending []*migration.Migration
+		err := withConnection(ctx, cfg, func(conn *orm.Connection) error {
+			migrator := migration.NewMigratorFor(conn, quietLogger())
+			if err := migrator.LoadMigrations(); err != nil {
+				return err
+			}
+			var err error
+			pending, err = migrator.Pending()
+			return err
+		})
+		var unreachable *unreachableError
+		switch {
+		case errors.As(err, &unreachable):
+			return skip("database not reachable")
+		case err != nil:
+			return fail("Check that the database user may read the migrations table", "cannot read migrations: %v", err)
+		case len(pending) > 0:
+			return warn("Apply them with `grayv-lsm db migrate`", "%d migrations are pending, starting with %s", len(pending), pending[0].Name)
+		}
+		return ok("all migrations are applied")
+	}}
+}
+
+// unreachableError is returned by withConnection when the database cannot be reached.
+type unreachableError struct{ err error }
+
+func (e *unreachableError) Error() string { return "cannot reach the database: " + e.err.Error() }
+func (e *unreachableError) Unwrap() error { return e.err }
+
+// withConnection connects to the configured database, waiting at most five seconds for it to respond, and runs action.
+func withConnection(ctx context.Context, cfg *config.Config, action func(*orm.Connection) error) error {
+	conn, err := orm.NewConnection(&cfg.Database)
+	if err != nil {
+		return &unreachableError{err}
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := conn.GetDB().PingContext(ctx); err != nil {
+		return &unreachableError{err}
+	}
+	return action(conn)
+}
+
+// quietLogger returns a logger that drops its output, so checks do not interleave log lines with their results.
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
@@ -0,0 +1,91 @@
+// Package doctor diagnoses common problems with a grayv-lsm workspace and suggests how to fix them.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusSkip means the check could not run because something it depends on is broken.
+	StatusSkip Status = "skip"
+)
+
+// Result is the outcome of a check. Fix tells the user how to resolve a warning or failure.
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// Check is a single diagnostic. Checks are run in the order given, which is also their priority:
+// a problem found by an earlier check, such as an invalid configuration, often causes later ones.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// Run runs every check in order and returns their results.
+func Run(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		result := check.Run(ctx)
+		result.Name = check.Name
+		results = append(results, result)
+	}
+	return results
+}
+
+// Fixes returns the results that need attention, failures before warnings, each in check order.
+func Fixes(results []Result) []Result {
+	var fixes []Result
+	for _, status := range []Status{StatusFail, StatusWarn} {
+		for _, result := range results {
+			if result.Status == status {
+				fixes = append(fixes, result)
+			}
+		}
+	}
+	return fixes
+}
+
+// Print writes a line per result, followed by the numbered list of fixes, most important first.
+func Print(w io.Writer, results []Result) {
+	for _, result := range results {
+		fmt.Fprintf(w, "[%-4s] %-20s %s\n", result.Status, result.Name, result.Message)
+	}
+
+	fixes := Fixes(results)
+	if len(fixes) == 0 {
+		fmt.Fprintln(w, "\nNo problems found.")
+		return
+	}
+	fmt.Fprintln(w, "\nSuggested fixes, most important first:")
+	for i, result := range fixes {
+		fmt.Fprintf(w, "%2d. %s: %s\n", i+1, result.Name, result.Fix)
+	}
+}
+
+func ok(format string, args ...interface{}) Result {
+	return Result{Status: StatusOK, Message: fmt.Sprintf(format, args...)}
+}
+
+func warn(fix, format string, args ...interface{}) Result {
+	return Result{Status: StatusWarn, Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+func fail(fix, format string, args ...interface{}) Result {
+	return Result{Status: StatusFail, Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+func skip(format string, args ...interface{}) Result {
+	return Result{Status: StatusSkip, Message: fmt.Sprintf(format, args...)}
+}
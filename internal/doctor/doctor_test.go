@@ -0,0 +1,124 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/app"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixes_PrioritizesFailures(t *testing.T) {
+	results := []Result{
+		{Name: "a", Status: StatusWarn, Fix: "fix a"},
+		{Name: "b", Status: StatusOK},
+		{Name: "c", Status: StatusFail, Fix: "fix c"},
+		{Name: "d", Status: StatusSkip},
+		{Name: "e", Status: StatusFail, Fix: "fix e"},
+	}
+
+	var names []string
+	for _, result := range Fixes(results) {
+		names = append(names, result.Name)
+	}
+	assert.Equal(t, []string{"c", "e", "a"}, names)
+
+	var out bytes.Buffer
+	Print(&out, results)
+	assert.Contains(t, out.String(), " 1. c: fix c\n 2. e: fix e\n 3. a: fix a\n")
+}
+
+func TestConfig(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Host: "localhost", Port: 5432, User: "grayv", Name: "grayv"},
+		Server:   config.ServerConfig{Port: 8080},
+	}
+	assert.Equal(t, StatusOK, Config(cfg, nil).Run(context.Background()).Status)
+
+	cfg.Server.Port = 70000
+	cfg.Logging.Level = "loud"
+	cfg.Database.StatementTimeout = "30"
+	cfg.Retention.Rules = []config.RetentionRule{{Table: "events", MaxAge: "forever"}}
+	result := Config(cfg, nil).Run(context.Background())
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Contains(t, result.Message, "server.port 70000")
+	assert.Contains(t, result.Message, `logging.level "loud"`)
+	assert.Contains(t, result.Message, `database.statementtimeout "30"`)
+	assert.Contains(t, result.Message, `retention: invalid retention rule for events: max age "forever"`)
+}
+
+func TestConfig_MySQL(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Driver: "mysql", Host: "localhost", Port: 3306, User: "grayv", Name: "grayv"},
+		Server:   config.ServerConfig{Port: 8080},
+	}
+	result := Config(cfg, nil).Run(context.Background())
+	assert.Equal(t, StatusOK, result.Status, result.Message)
+
+	cfg.Database.Driver = "oracle"
+	result = Config(cfg, nil).Run(context.Background())
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Contains(t, result.Message, `database.driver "oracle" must be postgres, pgx, mysql, or sqlite`)
+}
+
+func TestConfig_SQLite(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "grayv.db")},
+		Server:   config.ServerConfig{Port: 8080},
+	}
+	result := Config(cfg, nil).Run(context.Background())
+	assert.Equal(t, StatusOK, result.Status, result.Message)
+	assert.Equal(t, StatusOK, Docker(cfg).Run(context.Background()).Status)
+
+	result = Database(cfg).Run(context.Background())
+	assert.Equal(t, StatusOK, result.Status, result.Message)
+	assert.Contains(t, result.Message, cfg.Database.Path)
+
+	cfg.Database.Driver = "sqlite3"
+	cfg.Database.Path = ""
+	result = Config(cfg, nil).Run(context.Background())
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Equal(t, "database.path is empty", result.Message)
+}
+
+func TestDocker(t *testing.T) {
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.47")
+		fmt.Fprint(w, "OK")
+	}))
+	t.Setenv("DOCKER_HOST", "tcp://"+daemon.Listener.Addr().String())
+	result := Docker(nil).Run(context.Background())
+	assert.Equal(t, StatusOK, result.Status, result.Message)
+	assert.Contains(t, result.Message, "1.47")
+
+	daemon.Close()
+	result = Docker(nil).Run(context.Background())
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Contains(t, result.Message, "Docker daemon not reachable")
+}
+
+func TestEmbeddedAssets(t *testing.T) {
+	result := EmbeddedAssets().Run(context.Background())
+	assert.Equal(t, StatusOK, result.Status, result.Message)
+}
+
+func TestCheckModelImports(t *testing.T) {
+	dir := t.TempDir()
+	target := &app.App{Name: "shop_grav", Dir: dir, ModulePath: "example.com/shop"}
+	assert.NoError(t, os.MkdirAll(target.ModelsDir(), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(target.ModelsDir(), "post.go"),
+		[]byte("package models\n\nimport \"example.com/shop/internal/model\"\n\ntype Post struct{ model.DefaultModel }\n"), 0644))
+	assert.Empty(t, checkModelImports(target))
+
+	target.ModulePath = "example.com/store"
+	problems := checkModelImports(target)
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "imports example.com/shop/internal/model instead of example.com/store/internal/model")
+}
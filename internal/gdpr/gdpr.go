@@ -0,0 +1,245 @@
+// Package gdpr finds every row that belongs to a user by following foreign keys, so the rows can be exported
+// for a data access request or deleted or anonymized for an erasure request.
+package gdpr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// Reference is a single-column foreign key: Column of Table references RefColumn of RefTable.
+type Reference struct {
+	Table     string
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// Scope is a table holding rows that belong to the user, and the condition selecting them.
+// The condition reads the user ID from the $1 parameter.
+type Scope struct {
+	Table     string
+	Condition string
+}
+
+// Plan lists the tables holding a user's rows, each after the tables its rows are found through,
+// starting with the users table itself.
+type Plan []Scope
+
+// Querier is implemented by *sql.DB and *sql.Tx.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// referencesQuery lists the single-column foreign keys between tables of the current schema.
+const referencesQuery = `
+SELECT child.relname, child_column.attname, parent.relname, parent_column.attname
+FROM pg_constraint con
+JOIN pg_class child ON child.oid = con.conrelid
+JOIN pg_class parent ON parent.oid = con.confrelid
+JOIN pg_namespace ns ON ns.oid = child.relnamespace
+JOIN pg_attribute child_column ON child_column.attrelid = con.conrelid AND child_column.attnum = con.conkey[1]
+JOIN pg_attribute parent_column ON parent_column.attrelid = con.confrelid AND parent_column.attnum = con.confkey[1]
+WHERE con.contype = 'f' AND array_length(con.conkey, 1) = 1 AND ns.nspname = current_schema()
+ORDER BY child.relname, child_column.attname`
+
+// LoadReferences reads the foreign keys of the current schema from the PostgreSQL catalog.
+func LoadReferences(ctx context.Context, db Querier) ([]Reference, error) {
+	rows, err := db.QueryContext(ctx, referencesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error reading foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []Reference
+	for rows.Next() {
+		var ref Reference
+		if err := rows.Scan(&ref.Table, &ref.Column, &ref.RefTable, &ref.RefColumn); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// NewPlan returns the plan finding the rows of the user whose rootColumn in rootTable is $1, and every row
+// that references them through refs, directly or through other such rows. Self-references and references
+// closing a cycle are not followed.
+func NewPlan(refs []Reference, rootTable, rootColumn string) Plan {
+	children := make(map[string][]Reference)
+	for _, ref := range refs {
+		if ref.Table != ref.RefTable {
+			children[ref.RefTable] = append(children[ref.RefTable], ref)
+		}
+	}
+
+	// Order the tables reachable from the root so every table comes after the tables it references,
+	// by appending each table once all of its paths have been explored, and reversing the result.
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order []string
+	var visit func(table string)
+	visit = func(table string) {
+		state[table] = visiting
+		for _, ref := range children[table] {
+			if state[ref.Table] == 0 {
+				visit(ref.Table)
+			}
+		}
+		state[table] = done
+		order = append(order, table)
+	}
+	visit(rootTable)
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	position := make(map[string]int, len(order))
+	for i, table := range order {
+		position[table] = i
+	}
+
+	conditions := map[string]string{rootTable: quote(rootColumn) + " = $1"}
+	plan := Plan{{Table: rootTable, Condition: conditions[rootTable]}}
+	for _, table := range order[1:] {
+		var terms []string
+		for _, ref := range refs {
+			parentPosition, ok := position[ref.RefTable]
+			if ref.Table != table || !ok || parentPosition >= position[table] {
+				continue
+			}
+			terms = append(terms, fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+				quote(ref.Column), quote(ref.RefColumn), quote(ref.RefTable), conditions[ref.RefTable]))
+		}
+		conditions[table] = strings.Join(terms, " OR ")
+		plan = append(plan, Scope{Table: table, Condition: conditions[table]})
+	}
+	return plan
+}
+
+// Export returns the rows of the user with the given ID in every table of the plan, by table.
+// Columns whose name ends in _hash, such as password hashes, are left out.
+func Export(ctx context.Context, db Querier, plan Plan, userID int) (map[string][]map[string]interface{}, error) {
+	export := make(map[string][]map[string]interface{}, len(plan))
+	for _, scope := range plan {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE %s", quote(scope.Table), scope.Condition), userID)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting %s: %w", scope.Table, err)
+		}
+		records, err := scanRecords(rows)
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error exporting %s: %w", scope.Table, err)
+		}
+		export[scope.Table] = records
+	}
+	return export, nil
+}
+
+// Delete deletes the rows of the user with the given ID from every table of the plan, referencing rows first,
+// and returns the number of rows deleted by table. Run it in a transaction, so a failure deletes nothing.
+func Delete(ctx context.Context, db Querier, plan Plan, userID int) (map[string]int64, error) {
+	deleted := make(map[string]int64, len(plan))
+	for i := len(plan) - 1; i >= 0; i-- {
+		scope := plan[i]
+		result, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", quote(scope.Table), scope.Condition), userID)
+		if err != nil {
+			return nil, fmt.Errorf("error deleting from %s: %w", scope.Table, err)
+		}
+		deleted[scope.Table], _ = result.RowsAffected()
+	}
+	return deleted, nil
+}
+
+// Anonymize replaces the username, email, and password hash of the user with the given ID by placeholders,
+// deletes the user's API tokens, and sets each column in scrub, written as table.column, to NULL in the
+// user's rows of that table. Other rows are kept, so content the user created survives without naming them.
+// It returns the number of rows changed by table.
+func Anonymize(ctx context.Context, db Querier, plan Plan, userID int, scrub []string) (map[string]int64, error) {
+	scopes := make(map[string]Scope, len(plan))
+	for _, scope := range plan {
+		scopes[scope.Table] = scope
+	}
+
+	changed := make(map[string]int64)
+	for _, column := range scrub {
+		table, name, ok := strings.Cut(column, ".")
+		if !ok {
+			return nil, fmt.Errorf("invalid column %q: use table.column", column)
+		}
+		scope, ok := scopes[table]
+		if !ok {
+			return nil, fmt.Errorf("table %s holds no rows of the user", table)
+		}
+		result, err := db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s", quote(table), quote(name), scope.Condition), userID)
+		if err != nil {
+			return nil, fmt.Errorf("error scrubbing %s: %w", column, err)
+		}
+		affected, _ := result.RowsAffected()
+		changed[table] += affected
+	}
+
+	if scope, ok := scopes["api_tokens"]; ok {
+		result, err := db.ExecContext(ctx, "DELETE FROM api_tokens WHERE "+scope.Condition, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error deleting API tokens: %w", err)
+		}
+		changed["api_tokens"], _ = result.RowsAffected()
+	}
+
+	placeholder := fmt.Sprintf("deleted_user_%d", userID)
+	result, err := db.ExecContext(ctx, `UPDATE users SET username = $2, email = $3, password_hash = '!', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1`, userID, placeholder, placeholder+"@anonymized.invalid")
+	if err != nil {
+		return nil, fmt.Errorf("error anonymizing user: %w", err)
+	}
+	changed["users"], _ = result.RowsAffected()
+	return changed, nil
+}
+
+// scanRecords reads rows into maps from column name to value, leaving out columns ending in _hash.
+func scanRecords(rows *sql.Rows) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if strings.HasSuffix(column, "_hash") {
+				continue
+			}
+			if b, ok := values[i].([]byte); ok {
+				record[column] = string(b)
+			} else {
+				record[column] = values[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func quote(name string) string {
+	return dialect.Postgres.Quote(name)
+}
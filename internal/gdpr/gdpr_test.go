@@ -0,0 +1,58 @@
+package gdpr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPlan(t *testing.T) {
+	refs := []Reference{
+		{Table: "api_tokens", Column: "user_id", RefTable: "users", RefColumn: "id"},
+		{Table: "comments", Column: "author_id", RefTable: "users", RefColumn: "id"},
+		{Table: "comments", Column: "post_id", RefTable: "posts", RefColumn: "id"},
+		{Table: "comments", Column: "parent_id", RefTable: "comments", RefColumn: "id"},
+		{Table: "posts", Column: "author_id", RefTable: "users", RefColumn: "id"},
+		{Table: "tags", Column: "category_id", RefTable: "categories", RefColumn: "id"},
+	}
+
+	plan := NewPlan(refs, "users", "id")
+
+	conditions := make(map[string]string)
+	var tables []string
+	for _, scope := range plan {
+		tables = append(tables, scope.Table)
+		conditions[scope.Table] = scope.Condition
+	}
+	assert.ElementsMatch(t, []string{"users", "api_tokens", "comments", "posts"}, tables)
+	assert.Equal(t, "users", tables[0])
+	assert.Less(t, indexOf(tables, "posts"), indexOf(tables, "comments"))
+
+	assert.Equal(t, "id = $1", conditions["users"])
+	assert.Equal(t, "user_id IN (SELECT id FROM users WHERE id = $1)", conditions["api_tokens"])
+	assert.Equal(t, "author_id IN (SELECT id FROM users WHERE id = $1) OR "+
+		"post_id IN (SELECT id FROM posts WHERE author_id IN (SELECT id FROM users WHERE id = $1))", conditions["comments"])
+}
+
+func TestNewPlan_Cycle(t *testing.T) {
+	refs := []Reference{
+		{Table: "teams", Column: "owner_id", RefTable: "users", RefColumn: "id"},
+		{Table: "users", Column: "team_id", RefTable: "teams", RefColumn: "id"},
+	}
+
+	plan := NewPlan(refs, "users", "id")
+
+	assert.Equal(t, Plan{
+		{Table: "users", Condition: "id = $1"},
+		{Table: "teams", Condition: "owner_id IN (SELECT id FROM users WHERE id = $1)"},
+	}, plan)
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,236 @@
+// Package messages holds the templates of the messages grayv-lsm prints, so teams can translate or rebrand its
+// output. The English templates are embedded; a catalog file for a locale overrides any of them, and the
+// templates left out keep their English text.
+package messages
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed en.json
+var defaultCatalog []byte
+
+// DefaultLocale is the locale of the embedded templates.
+const DefaultLocale = "en"
+
+// Format controls how the values in messages are written.
+type Format struct {
+	// DateLayout is the Go time layout of dates. Empty keeps the layout each message uses by default.
+	DateLayout string
+	// ThousandsSeparator groups the digits of numbers by thousands. Empty leaves numbers ungrouped.
+	ThousandsSeparator string
+	// DecimalSeparator separates the integer and fractional parts of decimals. It defaults to ".".
+	DecimalSeparator string
+}
+
+// Catalog renders messages from their templates. Templates are text/template templates with the
+// functions number, decimal, and date, which format values according to the Format of the catalog.
+type Catalog struct {
+	format    Format
+	templates map[string]*template.Template
+	fallback  map[string]*template.Template
+}
+
+// Defaults returns the embedded English templates, by message key.
+func Defaults() map[string]string {
+	var defaults map[string]string
+	if err := json.Unmarshal(defaultCatalog, &defaults); err != nil {
+		panic(fmt.Sprintf("invalid embedded message catalog: %v", err))
+	}
+	return defaults
+}
+
+// New returns a catalog of the English templates with overrides replacing some of them.
+// It returns an error if an override is not a valid template or overrides an unknown message.
+func New(overrides map[string]string, format Format) (*Catalog, error) {
+	c := &Catalog{format: format, templates: map[string]*template.Template{}, fallback: map[string]*template.Template{}}
+	for key, text := range Defaults() {
+		tmpl, err := c.parse(key, text)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded message %s: %v", key, err))
+		}
+		c.templates[key] = tmpl
+		c.fallback[key] = tmpl
+	}
+	for key, text := range overrides {
+		if _, ok := c.fallback[key]; !ok {
+			return nil, fmt.Errorf("unknown message %q", key)
+		}
+		tmpl, err := c.parse(key, text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message %q: %w", key, err)
+		}
+		c.templates[key] = tmpl
+	}
+	return c, nil
+}
+
+// Load returns the catalog of locale, whose templates are read from the file <locale>.json in dir.
+// The file holds a JSON object of templates by message key. For the default locale or an empty one,
+// the file is optional.
+func Load(dir, locale string, format Format) (*Catalog, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if strings.ContainsAny(locale, `/\`) || locale == "." || locale == ".." {
+		return nil, fmt.Errorf("invalid locale %q", locale)
+	}
+	path := filepath.Join(dir, locale+".json")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && locale == DefaultLocale {
+		return New(nil, format)
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading message catalog: %w", err)
+	}
+
+	var overrides map[string]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("error parsing message catalog %s: %w", path, err)
+	}
+	c, err := New(overrides, format)
+	if err != nil {
+		return nil, fmt.Errorf("error in message catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Text renders the message key with data. If an overriding template fails, the English template is used,
+// and an unknown key is returned as is.
+func (c *Catalog) Text(key string, data interface{}) string {
+	tmpl, ok := c.templates[key]
+	if !ok {
+		return key
+	}
+	text, err := execute(tmpl, data)
+	if err != nil {
+		if text, err = execute(c.fallback[key], data); err != nil {
+			return key
+		}
+	}
+	return text
+}
+
+// Number writes an integer with the digits grouped by the thousands separator.
+func (c *Catalog) Number(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + c.group(digits)
+}
+
+// Decimal writes f with the given number of decimal places, using the separators of the catalog.
+func (c *Catalog) Decimal(f float64, places int) string {
+	text := strconv.FormatFloat(f, 'f', places, 64)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(text, ".")
+	text = sign + c.group(whole)
+	if hasFraction {
+		separator := c.format.DecimalSeparator
+		if separator == "" {
+			separator = "."
+		}
+		text += separator + fraction
+	}
+	return text
+}
+
+// Time writes t with the date layout of the catalog, or with layout if the catalog has none.
+func (c *Catalog) Time(t time.Time, layout string) string {
+	if c.format.DateLayout != "" {
+		layout = c.format.DateLayout
+	}
+	return t.Format(layout)
+}
+
+func (c *Catalog) group(digits string) string {
+	if c.format.ThousandsSeparator == "" || len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(c.format.ThousandsSeparator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+func (c *Catalog) parse(key, text string) (*template.Template, error) {
+	return template.New(key).Option("missingkey=error").Funcs(template.FuncMap{
+		"number":  func(n interface{}) (string, error) { return c.number(n) },
+		"decimal": c.Decimal,
+		"date":    func(t time.Time) string { return c.Time(t, time.DateTime) },
+	}).Parse(text)
+}
+
+// number formats the integer types with Number.
+func (c *Catalog) number(n interface{}) (string, error) {
+	switch n := n.(type) {
+	case int:
+		return c.Number(int64(n)), nil
+	case int32:
+		return c.Number(int64(n)), nil
+	case int64:
+		return c.Number(n), nil
+	case uint:
+		return c.Number(int64(n)), nil
+	case uint32:
+		return c.Number(int64(n)), nil
+	case uint64:
+		return c.Number(int64(n)), nil
+	default:
+		return "", fmt.Errorf("number: %T is not an integer", n)
+	}
+}
+
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// current is the catalog used by the package-level functions.
+var current = mustDefault()
+
+func mustDefault() *Catalog {
+	c, err := New(nil, Format{})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Use makes c the catalog used by the package-level functions.
+func Use(c *Catalog) {
+	current = c
+}
+
+// Text renders the message key with data using the current catalog. See Catalog.Text.
+func Text(key string, data interface{}) string {
+	return current.Text(key, data)
+}
+
+// Time writes t with the date layout of the current catalog, or with layout if it has none.
+func Time(t time.Time, layout string) string {
+	return current.Time(t, layout)
+}
@@ -0,0 +1,74 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_Defaults(t *testing.T) {
+	c, err := New(nil, Format{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Database container started successfully", c.Text("db.start.done", nil))
+	assert.Equal(t, "Rolled back 12345 migration(s) successfully", c.Text("db.rollback.done", map[string]interface{}{"Steps": 12345}))
+	assert.Equal(t, "- Cache hit ratio: 99.50%", c.Text("db.metrics.cache_hit_ratio", map[string]interface{}{"CacheHitRatio": 99.5}))
+	assert.Equal(t, "no.such.message", c.Text("no.such.message", nil))
+}
+
+func TestCatalog_Overrides(t *testing.T) {
+	c, err := New(map[string]string{
+		"db.start.done":    "Datenbank gestartet",
+		"db.rollback.done": "{{number .Steps}} Migrationen zurückgesetzt",
+		"db.tables.item":   "* {{.Missing}}",
+	}, Format{ThousandsSeparator: ".", DecimalSeparator: ","})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Datenbank gestartet", c.Text("db.start.done", nil))
+	assert.Equal(t, "1.234.567 Migrationen zurückgesetzt", c.Text("db.rollback.done", map[string]interface{}{"Steps": 1234567}))
+	assert.Equal(t, "- Cache hit ratio: 1.234,50%", c.Text("db.metrics.cache_hit_ratio", map[string]interface{}{"CacheHitRatio": 1234.5}))
+	// A failing override falls back to the English template.
+	assert.Equal(t, "- users", c.Text("db.tables.item", "users"))
+	assert.Equal(t, "Database seeded successfully", c.Text("db.seed.done", nil))
+
+	_, err = New(map[string]string{"db.unknown": "x"}, Format{})
+	assert.Error(t, err)
+	_, err = New(map[string]string{"db.start.done": "{{"}, Format{})
+	assert.Error(t, err)
+}
+
+func TestCatalog_Format(t *testing.T) {
+	c, _ := New(nil, Format{ThousandsSeparator: ","})
+	assert.Equal(t, "0", c.Number(0))
+	assert.Equal(t, "999", c.Number(999))
+	assert.Equal(t, "1,000", c.Number(1000))
+	assert.Equal(t, "-12,345,678", c.Number(-12345678))
+	assert.Equal(t, "-1,234.57", c.Decimal(-1234.567, 2))
+
+	at := time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)
+	assert.Equal(t, "2024-03-09 14:05", c.Time(at, "2006-01-02 15:04"))
+	c, _ = New(nil, Format{DateLayout: "02.01.2006"})
+	assert.Equal(t, "09.03.2024", c.Time(at, "2006-01-02 15:04"))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := Load(dir, "", Format{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Database seeded successfully", c.Text("db.seed.done", nil))
+
+	_, err = Load(dir, "fr", Format{})
+	assert.Error(t, err, "a configured locale needs a catalog")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"db.seed.done": "Base de données alimentée"}`), 0644))
+	c, err = Load(dir, "fr", Format{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Base de données alimentée", c.Text("db.seed.done", nil))
+
+	_, err = Load(dir, "../fr", Format{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ComputedFields returns the expressions of the computed fields of m by column, or nil if it has none.
+func (m *ModelDefinition) ComputedFields() map[string]string {
+	var computed map[string]string
+	for _, field := range m.Fields {
+		if field.Computed == "" {
+			continue
+		}
+		if computed == nil {
+			computed = make(map[string]string)
+		}
+		computed[field.ColumnName()] = field.Computed
+	}
+	return computed
+}
+
+// SelectList returns the select list of the queries reading m: every column, followed by the computed fields of m
+// named after their column, as in "*, (first_name || ' ' || last_name) AS full_name".
+func (m *ModelDefinition) SelectList() string {
+	list := []string{"*"}
+	for _, field := range m.Fields {
+		if field.Computed != "" {
+			list = append(list, fmt.Sprintf("(%s) AS %s", field.Computed, quote(field.ColumnName())))
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// HasColumn reports whether the field is stored in a column of the table, that is, whether it is not computed.
+func (f Field) HasColumn() bool {
+	return f.Computed == ""
+}
+
+// storedFields returns the fields stored in columns, leaving out the computed ones.
+func storedFields(fields []Field) []Field {
+	stored := make([]Field, 0, len(fields))
+	for _, field := range fields {
+		if field.HasColumn() {
+			stored = append(stored, field)
+		}
+	}
+	return stored
+}
@@ -0,0 +1,346 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+)
+
+// MaxDecimalPrecision is the largest number of digits of a decimal field, the limit of NUMERIC columns in
+// PostgreSQL. MySQL allows DECIMAL columns of up to 65 digits, 30 of them after the point.
+const MaxDecimalPrecision = 1000
+
+// DecimalPrecision returns the precision, the number of digits, and the scale, the number of digits after the
+// point, of a "decimal(p,s)" field type, and whether fieldType is one.
+func DecimalPrecision(fieldType string) (precision, scale int, ok bool) {
+	inner, ok := strings.CutPrefix(fieldType, "decimal(")
+	if !ok {
+		return 0, 0, false
+	}
+	inner, ok = strings.CutSuffix(inner, ")")
+	if !ok {
+		return 0, 0, false
+	}
+	p, s, ok := strings.Cut(inner, ",")
+	if !ok {
+		return 0, 0, false
+	}
+	precision, err := strconv.Atoi(strings.TrimSpace(p))
+	if err != nil {
+		return 0, 0, false
+	}
+	scale, err = strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, 0, false
+	}
+	return precision, scale, true
+}
+
+// validateDecimal returns an error if the decimal field type fieldType has a precision out of 1 to
+// MaxDecimalPrecision, or a scale out of 0 to its precision.
+func validateDecimal(fieldType string, precision, scale int) error {
+	if precision < 1 || precision > MaxDecimalPrecision {
+		return fmt.Errorf("invalid field type: %s: a decimal has 1 to %d digits", fieldType, MaxDecimalPrecision)
+	}
+	if scale < 0 || scale > precision {
+		return fmt.Errorf("invalid field type: %s: a decimal has 0 to %d digits after the point", fieldType, precision)
+	}
+	return nil
+}
+
+// decimalPattern returns the regular expression matching the text of the values of the decimal field type
+// fieldType, such as ^-?\d{1,8}(\.\d{1,2})?$ for decimal(10,2), which are written in JSON as strings.
+func decimalPattern(fieldType string) string {
+	precision, scale, _ := DecimalPrecision(fieldType)
+	whole := "0"
+	if precision > scale {
+		whole = fmt.Sprintf(`\d{1,%d}`, precision-scale)
+	}
+	if scale == 0 {
+		return "^-?" + whole + "$"
+	}
+	return fmt.Sprintf(`^-?%s(\.\d{1,%d})?$`, whole, scale)
+}
+
+// usesDecimal reports whether any of fields is a decimal field.
+func usesDecimal(fields []Field) bool {
+	for _, field := range fields {
+		if _, _, ok := DecimalPrecision(field.Type); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesDecimal reports whether any of the models has a decimal field, so its app needs the Decimal type
+// written by GenerateDecimalFile.
+func UsesDecimal(defs ...*ModelDefinition) bool {
+	for _, def := range defs {
+		if usesDecimal(def.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// decimalTemplate is the file written next to the base model of an app, providing the Decimal type of decimal
+// fields. It is the same type as orm.Decimal.
+const decimalTemplate = `package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, such as an amount of money, stored in a NUMERIC or DECIMAL column. It is
+// an integer scaled by a number of decimal places, so 1.50 is 150 with 2 places, and adding, subtracting,
+// multiplying, and comparing decimals never rounds. The zero Decimal is 0. Decimals are written to the
+// database and to JSON as text, such as "1.50", so no digit is lost to a float64.
+type Decimal struct {
+	unscaled big.Int
+	scale    int
+}
+
+// maxDecimalScale bounds the exponent and the decimal places ParseDecimal accepts, so text such as "1e999999999"
+// cannot make it compute a number of a billion digits. It is the largest precision of a decimal field.
+const maxDecimalScale = 1000
+
+// ParseDecimal parses a decimal number such as "-12.345" or "1e3", keeping its digits as they are written.
+// Exponents and numbers of decimal places beyond 1000 are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	text := strings.TrimSpace(s)
+	exponent := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		text, exponent = text[:i], e
+	}
+	whole, fraction, _ := strings.Cut(text, ".")
+	digits := strings.TrimLeft(whole, "+-")
+	if digits+fraction == "" || strings.ContainsAny(digits+fraction, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	var d Decimal
+	if _, ok := d.unscaled.SetString(whole+fraction, 10); !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	if exponent < -maxDecimalScale || exponent > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: the exponent is out of range", s)
+	}
+	d.scale = len(fraction) - exponent
+	if d.scale > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: too many decimal places", s)
+	}
+	if d.scale < 0 {
+		d.unscaled.Mul(&d.unscaled, pow10(-d.scale))
+		d.scale = 0
+	}
+	return d, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a decimal number. It is meant for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimal returns unscaled divided by 10 to the power of scale, such as 1.50 for 150 and 2.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	var d Decimal
+	d.unscaled.SetInt64(unscaled)
+	d.scale = scale
+	if scale < 0 {
+		d.unscaled.Mul(&d.unscaled, pow10(-scale))
+		d.scale = 0
+	}
+	return d
+}
+
+// pow10 returns 10 to the power of n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Scale returns the number of decimal places of d.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0, or 1 if d is negative, zero, or positive.
+func (d Decimal) Sign() int {
+	return d.unscaled.Sign()
+}
+
+// rescale returns the unscaled value of d with scale decimal places, which must not be fewer than those of d.
+func (d Decimal) rescale(scale int) *big.Int {
+	return new(big.Int).Mul(&d.unscaled, pow10(scale-d.scale))
+}
+
+// Add returns d + e, with as many decimal places as the operand with most.
+func (d Decimal) Add(e Decimal) Decimal {
+	scale := max(d.scale, e.scale)
+	var sum Decimal
+	sum.unscaled.Add(d.rescale(scale), e.rescale(scale))
+	sum.scale = scale
+	return sum
+}
+
+// Sub returns d - e, with as many decimal places as the operand with most.
+func (d Decimal) Sub(e Decimal) Decimal {
+	return d.Add(e.Neg())
+}
+
+// Mul returns d * e, with the decimal places of both operands.
+func (d Decimal) Mul(e Decimal) Decimal {
+	var product Decimal
+	product.unscaled.Mul(&d.unscaled, &e.unscaled)
+	product.scale = d.scale + e.scale
+	return product
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	var negated Decimal
+	negated.unscaled.Neg(&d.unscaled)
+	negated.scale = d.scale
+	return negated
+}
+
+// Cmp returns -1, 0, or 1 if d is less than, equal to, or greater than e. Decimals equal whatever their
+// decimal places, so 1.5 and 1.50 compare equal.
+func (d Decimal) Cmp(e Decimal) int {
+	scale := max(d.scale, e.scale)
+	return d.rescale(scale).Cmp(e.rescale(scale))
+}
+
+// Round returns d rounded to scale decimal places, 0 or more, halves away from zero, as NUMERIC columns round.
+func (d Decimal) Round(scale int) Decimal {
+	if scale >= d.scale {
+		var rounded Decimal
+		rounded.unscaled.Set(d.rescale(scale))
+		rounded.scale = scale
+		return rounded
+	}
+	divisor := pow10(d.scale - scale)
+	var rounded Decimal
+	quotient, remainder := new(big.Int).QuoRem(&d.unscaled, divisor, new(big.Int))
+	if remainder.Abs(remainder).Lsh(remainder, 1).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(d.unscaled.Sign())))
+	}
+	rounded.unscaled.Set(quotient)
+	rounded.scale = scale
+	return rounded
+}
+
+// Fits reports whether d can be stored in a DECIMAL(precision, scale) column without rounding: it has at most
+// scale significant decimal places, and at most precision - scale digits before the point.
+func (d Decimal) Fits(precision, scale int) bool {
+	rounded := d.Round(scale)
+	if rounded.Cmp(d) != 0 {
+		return false
+	}
+	return new(big.Int).Abs(&rounded.unscaled).Cmp(pow10(precision)) < 0
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(&d.unscaled, pow10(d.scale)).Float64()
+	return f
+}
+
+// String returns d with all its decimal places, such as "1.50" or "-0.05".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(&d.unscaled).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Value implements driver.Valuer.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner. NULL scans as 0.
+func (d *Decimal) Scan(src interface{}) error {
+	var text string
+	switch src := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case string:
+		text = src
+	case []byte:
+		text = string(src)
+	case int64:
+		*d = NewDecimal(src, 0)
+		return nil
+	case float64:
+		text = strconv.FormatFloat(src, 'f', -1, 64)
+	default:
+		return fmt.Errorf("cannot scan %T into a Decimal", src)
+	}
+	parsed, err := ParseDecimal(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON writes d as a JSON string, such as "1.50".
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a decimal from a JSON string or number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	parsed, err := ParseDecimal(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+`
+
+// GenerateDecimalFile writes the Decimal type used by decimal fields to dir/decimal.go, unless it already exists.
+func GenerateDecimalFile(dir string, w *codegen.Writer) error {
+	fileName := filepath.Join(dir, "decimal.go")
+	if _, err := os.Stat(fileName); err == nil {
+		w.Report(fileName, codegen.ActionUnchanged)
+		return nil
+	}
+
+	if err := w.WriteGoFile(fileName, []byte(decimalTemplate)); err != nil {
+		return fmt.Errorf("error writing decimal file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalPrecision(t *testing.T) {
+	precision, scale, ok := DecimalPrecision("decimal(10,2)")
+	assert.True(t, ok)
+	assert.Equal(t, []int{10, 2}, []int{precision, scale})
+
+	for _, fieldType := range []string{"float64", "decimal", "decimal(10)", "decimal(x,2)", "decimal(10,2"} {
+		_, _, ok := DecimalPrecision(fieldType)
+		assert.False(t, ok, fieldType)
+	}
+
+	var mm ModelManager
+	assert.NoError(t, mm.ValidateField(Field{Name: "Price", Type: "decimal(10,2)"}))
+	assert.NoError(t, mm.ValidateField(Field{Name: "Rate", Type: "decimal(4,4)"}))
+	for _, fieldType := range []string{"decimal(0,0)", "decimal(1001,2)", "decimal(2,3)", "decimal(10,-1)"} {
+		assert.Error(t, mm.ValidateField(Field{Name: "Price", Type: fieldType}), fieldType)
+	}
+}
+
+func TestParseFields_Decimal(t *testing.T) {
+	fields, err := ParseFields([]string{"name:string", "price:decimal(10", "2):default=0", "tax:decimal(4,3)"})
+	assert.NoError(t, err)
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "decimal(10,2)", fields[1].Type)
+	assert.Equal(t, "0", fields[1].Default)
+	assert.Equal(t, "decimal(4,3)", fields[2].Type)
+}
+
+func TestGenerateMigration_Decimal(t *testing.T) {
+	def := NewModelDefinition("Product", []Field{{Name: "Price", Type: "decimal(10,2)"}})
+
+	assert.Contains(t, NewModelManager().GenerateMigration(def), "price NUMERIC(10,2) NOT NULL")
+	assert.Contains(t, (&ModelManager{Dialect: dialect.MySQL}).GenerateMigration(def), "price DECIMAL(10,2) NOT NULL")
+	assert.Contains(t, (&ModelManager{Dialect: dialect.SQLite}).GenerateMigration(def), "price TEXT NOT NULL")
+}
+
+func TestGenerateModelFile_Decimal(t *testing.T) {
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:       "Product",
+		Fields:     []Field{{Name: "Price", Type: "decimal(10,2)"}},
+		OutputDir:  dir,
+		ModulePath: "example.com/shop",
+	}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	assert.NoError(t, GenerateModelTestFile(def, nil))
+	assert.NoError(t, GenerateDecimalFile(dir, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "product.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Price model.Decimal `json:\"price\"`")
+
+	content, err = os.ReadFile(filepath.Join(dir, "product_test.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"example.com/shop/internal/model"`)
+	assert.Contains(t, string(content), `Price: model.MustParseDecimal("0.50"),`)
+
+	content, err = os.ReadFile(filepath.Join(dir, "decimal.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "func MustParseDecimal(s string) Decimal {")
+
+	orm, err := os.ReadFile(filepath.Join("..", "orm", "decimal.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(string(orm), "package orm", "package model", 1), decimalTemplate,
+		"the Decimal of apps is the Decimal of orm")
+}
+
+func TestJSONSchema_Decimal(t *testing.T) {
+	def := NewModelDefinition("Product", []Field{{Name: "Price", Type: "decimal(10,2)", Default: "'9.99'"}})
+	schema := JSONSchema(def)
+	price := schema.Properties["price"]
+	assert.Equal(t, "string", price.Type)
+	assert.Equal(t, `^-?\d{1,8}(\.\d{1,2})?$`, price.Pattern)
+	assert.Equal(t, "9.99", price.Default)
+	assert.Equal(t, "^-?0(\\.\\d{1,4})?$", decimalPattern("decimal(4,4)"))
+	assert.Equal(t, `^-?\d{1,5}$`, decimalPattern("decimal(5,0)"))
+}
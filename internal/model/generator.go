@@ -1,49 +1,185 @@
 package model
 
 import (
+	"bytes"
 	"fmt"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
 	"os"
 	"path/filepath"
-	"strings"
 	"text/template"
 )
 
 // modelTemplate is a constant that holds the template for generating a model file based on a `ModelDefinition`.
 // The template includes the necessary import statements and defines the struct fields using the provided `ModelDefinition` fields.
-// The `{{.Name}}` placeholder is replaced with the name of the model. The field names are transformed to title case using the `title` function.
-// The `json` struct tag is generated using the field name transformed to lowercase.
-// The `TableName` method is defined to return the lowercase plural form of the model name followed by "s".
+// The `{{.Name}}` placeholder is replaced with the name of the model. The field names are transformed to Go names using the `toCamel` function.
+// The `json` struct tag is generated using the column name of the field; see Field.StructTag for hidden
+// and read-only fields. Fields whose column has a default or is generated are commented with it; see Field.Comment.
+// The `TableName` method is defined to return the table name of the model; see ModelDefinition.TableName.
+// Models with an ID strategy other than serial get an `IDStrategy` method naming it, which orm.CRUD reads, and
+// uuid models shadow the uint ID of DefaultModel with a string. View models get a `ReadOnly` method, so orm.CRUD
+// refuses to write to their view. Models with computed fields get a `ComputedFields` method returning their
+// expressions, which orm.CRUD selects instead of columns and never writes.
+// When the definition has a ModulePath, the file imports the model package of that module, which provides DefaultModel
+// and the Vector and Decimal types of vector and decimal fields.
 const modelTemplate = `package models
-
+{{if .ModulePath}}
+import (
+{{- if usesTime .Fields}}
+	"time"
+{{end}}
+{{- range customImports .Fields}}
+	"{{.}}"
+{{end}}
+	"{{.ModulePath}}/internal/model"
+)
+{{end}}
 
 type {{.Name}} struct {
 	model.DefaultModel
+	{{- if eq .KeyStrategy "uuid"}}
+	ID string ` + "`json:\"id\"`" + `
+	{{- end}}
 	{{- range .Fields}}
-	{{.Name | title}} {{.Type}} ` + "`json:\"{{.Name | toLower}}\"`" + `
+	{{.Name | toCamel}} {{goType .Type}} ` + "`{{.StructTag}}`" + `{{with .Comment}} // {{.}}{{end}}
 	{{- end}}
 }
 
 func ({{.Name | firstLetter}} *{{.Name}}) TableName() string {
-	return "{{.Name | toLower}}s"
+	return "{{.TableName}}"
+}
+{{- if ne .KeyStrategy "serial"}}
+
+// IDStrategy returns how the ID of a new record is set.
+func ({{.Name | firstLetter}} *{{.Name}}) IDStrategy() string {
+	return "{{.KeyStrategy}}"
+}
+{{- end}}
+{{- if .View}}
+
+// ReadOnly reports that the model is read from a view, which cannot be written.
+func ({{.Name | firstLetter}} *{{.Name}}) ReadOnly() bool {
+	return true
+}
+{{- end}}
+{{- with .ComputedFields}}
+
+// ComputedFields returns the SQL expressions of the computed fields by column.
+func ({{$.Name | firstLetter}} *{{$.Name}}) ComputedFields() map[string]string {
+	return map[string]string{
+		{{- range $column, $expression := .}}
+		{{printf "%q" $column}}: {{printf "%q" $expression}},
+		{{- end}}
+	}
 }
+{{- end}}
 `
 
 // GenerateModelFile generates a model file based on the provided model definition.
 // The function uses a template to define the structure and fields of the model.
 // The template includes necessary import statements and generates the necessary struct tags for JSON serialization.
 // The generated model file is saved in the specified output directory, or in the default "models" directory if no output directory is provided.
+// The file is written through w, which may be nil; see codegen.Writer for dry runs and overwrite protection.
 // Returns an error if there is any issue parsing the template, creating the output directory, creating the file, executing the template, or any other related error.
-func GenerateModelFile(modelDef *ModelDefinition) error {
-	caser := cases.Title(language.English)
+func GenerateModelFile(modelDef *ModelDefinition, w *codegen.Writer) error {
+	return renderModelTemplate(modelDef, w, modelTemplate, naming.ToSnake(modelDef.Name)+".go")
+}
+
+// modelTestTemplate is the template for the unit tests generated next to a model by GenerateModelTestFile.
+// The tests check the table name and that every field but the hidden ones survives a JSON round trip.
+const modelTestTemplate = `package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	{{- if usesTime (visible .Fields)}}
+	"time"
+	{{- end}}
+	{{- range sampleImports (visible .Fields)}}
+	"{{.}}"
+	{{- end}}
+	{{- if and .ModulePath (or (usesVector (visible .Fields)) (usesDecimal (visible .Fields)))}}
+
+	"{{.ModulePath}}/internal/model"
+	{{- end}}
+)
+
+func Test{{.Name}}_TableName(t *testing.T) {
+	var m {{.Name}}
+	if got := m.TableName(); got != "{{.TableName}}" {
+		t.Errorf("TableName() = %q, want %q", got, "{{.TableName}}")
+	}
+}
+
+func Test{{.Name}}_JSONRoundTrip(t *testing.T) {
+	original := {{.Name}}{
+		{{- range .Fields}}
+		{{- if not .Hidden}}
+		{{.Name | toCamel}}: {{sampleValue .Type}},
+		{{- end}}
+		{{- end}}
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var decoded {{.Name}}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(original, decoded) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, original)
+	}
+}
+`
+
+// GenerateModelTestFile generates a _test.go file next to the model file produced by GenerateModelFile,
+// exercising the model's TableName method and the JSON encoding of its fields.
+func GenerateModelTestFile(modelDef *ModelDefinition, w *codegen.Writer) error {
+	return renderModelTemplate(modelDef, w, modelTestTemplate, naming.ToSnake(modelDef.Name)+"_test.go")
+}
+
+// renderModelTemplate executes a model template into fileName inside the model's output directory,
+// or the default "models" directory if no output directory is set. The output is formatted like goimports would.
+func renderModelTemplate(modelDef *ModelDefinition, w *codegen.Writer, content, fileName string) error {
 	tmpl, err := template.New("model").Funcs(template.FuncMap{
-		"toLower": strings.ToLower,
+		"toSnake": naming.ToSnake,
+		"toCamel": naming.ToCamel,
 		"firstLetter": func(s string) string {
-			return strings.ToLower(s[:1])
+			return string([]rune(naming.ToLowerCamel(s))[:1])
+		},
+		"usesTime": func(fields []Field) bool {
+			for _, f := range fields {
+				if goType(f.Type) == "time.Time" {
+					return true
+				}
+			}
+			return false
+		},
+		"customImports": func(fields []Field) []string {
+			return customImports(fields, false)
+		},
+		"sampleImports": func(fields []Field) []string {
+			return customImports(fields, true)
 		},
-		"title": caser.String,
-	}).Parse(modelTemplate)
+		"visible": func(fields []Field) []Field {
+			var visible []Field
+			for _, f := range fields {
+				if !f.Hidden {
+					visible = append(visible, f)
+				}
+			}
+			return visible
+		},
+		"sampleValue": sampleValue,
+		"goType":      goType,
+		"usesVector":  usesVector,
+		"usesDecimal": usesDecimal,
+	}).Parse(content)
 	if err != nil {
 		return fmt.Errorf("error parsing template: %w", err)
 	}
@@ -53,21 +189,109 @@ func GenerateModelFile(modelDef *ModelDefinition) error {
 		outputDir = "models"
 	}
 
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("error creating output directory: %w", err)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, modelDef); err != nil {
+		return fmt.Errorf("error executing template: %w", err)
 	}
 
-	fileName := filepath.Join(outputDir, strings.ToLower(modelDef.Name)+".go")
-	file, err := os.Create(fileName)
-	if err != nil {
-		return fmt.Errorf("error creating file: %w", err)
+	return w.WriteGoFile(filepath.Join(outputDir, fileName), buf.Bytes())
+}
+
+// sampleValue returns a Go literal of the given field type used as test data in generated tests.
+func sampleValue(goType string) string {
+	if _, ok := VectorDimensions(goType); ok {
+		return "model.Vector{0.5, 1.5}"
+	}
+	if _, _, ok := DecimalPrecision(goType); ok {
+		return `model.MustParseDecimal("0.50")`
+	}
+	if t, ok := lookupType(goType); ok {
+		return t.sample()
 	}
-	defer file.Close()
 
-	if err := tmpl.Execute(file, modelDef); err != nil {
-		return fmt.Errorf("error executing template: %w", err)
+	switch goType {
+	case "int":
+		return "42"
+	case "bool":
+		return "true"
+	case "float64":
+		return "1.5"
+	case "[]byte":
+		return `[]byte("data")`
+	case "time.Time":
+		return "time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)"
+	default:
+		return `"example"`
 	}
+}
+
+// baseModelTemplate is the model package written into an app, providing the DefaultModel embedded by generated models.
+const baseModelTemplate = `// Package model provides the base type embedded by the models generated by grayv-lsm.
+package model
+
+import "time"
+
+// Now returns the time records are stamped with. Tests replace it to get deterministic timestamps.
+var Now = time.Now
+
+// DefaultModel holds the columns every generated model has.
+type DefaultModel struct {
+	ID        uint      ` + "`json:\"id\"`" + `
+	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
+	UpdatedAt time.Time ` + "`json:\"updated_at\"`" + `
+}
+
+// PrimaryKey returns the name of the primary key field.
+func (m *DefaultModel) PrimaryKey() string {
+	return "ID"
+}
+
+// BeforeCreate sets the timestamps of a new record, in UTC.
+func (m *DefaultModel) BeforeCreate() error {
+	m.CreatedAt = Now().UTC()
+	m.UpdatedAt = m.CreatedAt
+	return nil
+}
 
+// AfterCreate is called after a record is created.
+func (m *DefaultModel) AfterCreate() error {
+	return nil
+}
+
+// BeforeUpdate refreshes the update timestamp of a record, in UTC.
+func (m *DefaultModel) BeforeUpdate() error {
+	m.UpdatedAt = Now().UTC()
+	return nil
+}
+
+// AfterUpdate is called after a record is updated.
+func (m *DefaultModel) AfterUpdate() error {
+	return nil
+}
+
+// BeforeDelete is called before a record is deleted.
+func (m *DefaultModel) BeforeDelete() error {
+	return nil
+}
+
+// AfterDelete is called after a record is deleted.
+func (m *DefaultModel) AfterDelete() error {
+	return nil
+}
+`
+
+// GenerateBaseModelFile writes the model package providing DefaultModel to dir/model.go, unless it already exists,
+// so models generated into an app compile without depending on grayv-lsm itself.
+func GenerateBaseModelFile(dir string, w *codegen.Writer) error {
+	fileName := filepath.Join(dir, "model.go")
+	if _, err := os.Stat(fileName); err == nil {
+		w.Report(fileName, codegen.ActionUnchanged)
+		return nil
+	}
+
+	if err := w.WriteFile(fileName, []byte(baseModelTemplate)); err != nil {
+		return fmt.Errorf("error writing base model file: %w", err)
+	}
 	return nil
 }
 
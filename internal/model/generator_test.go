@@ -0,0 +1,197 @@
+package model
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateModelFile_ImportsAppModelPackage(t *testing.T) {
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:       "Post",
+		Fields:     []Field{{Name: "Title", Type: "string"}, {Name: "Published", Type: "time.Time"}},
+		OutputDir:  dir,
+		ModulePath: "example.com/shop",
+	}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "post.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"example.com/shop/internal/model"`)
+	assert.Contains(t, string(content), `"time"`)
+
+	formatted, err := format.Source(content)
+	assert.NoError(t, err)
+	assert.Equal(t, string(formatted), string(content), "generated code should be gofmt-clean")
+}
+
+func TestGenerateBaseModelFile_KeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.go")
+	assert.NoError(t, os.WriteFile(path, []byte("package model\n"), 0644))
+
+	assert.NoError(t, GenerateBaseModelFile(dir, nil))
+
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "package model\n", string(content))
+}
+
+func TestGenerateModelTestFile(t *testing.T) {
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:      "Post",
+		Fields:    []Field{{Name: "Title", Type: "string"}, {Name: "Views", Type: "int"}},
+		OutputDir: dir,
+	}
+
+	assert.NoError(t, GenerateModelTestFile(def, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "post_test.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "func TestPost_TableName(t *testing.T)")
+	assert.Contains(t, string(content), "Views: 42,")
+}
+
+func TestGenerateModelFile_CasesNames(t *testing.T) {
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:      "BlogPost",
+		Fields:    []Field{{Name: "PublishedAt", Type: "time.Time"}, {Name: "AuthorID", Type: "int"}},
+		OutputDir: dir,
+	}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "blog_post.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "PublishedAt time.Time `json:\"published_at\"`")
+	assert.Contains(t, string(content), "AuthorID    int       `json:\"author_id\"`")
+	assert.Contains(t, string(content), `return "blog_posts"`)
+	assert.Equal(t, "blog_posts", def.TableName())
+}
+
+func TestGenerateModelFile_FieldAttributes(t *testing.T) {
+	dir := t.TempDir()
+	fields, err := ParseFields([]string{"email:string", "password_hash:string:hidden", "slug:string:readonly", "last_seen:time.Time:hidden"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	def := &ModelDefinition{Name: "User", Fields: fields, OutputDir: dir}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	assert.NoError(t, GenerateModelTestFile(def, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "user.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "PasswordHash string    `json:\"-\"`")
+	assert.Contains(t, string(content), "Slug         string    `json:\"slug\" grayv:\"readonly\"`")
+
+	// The hidden fields do not survive a JSON round trip, so the test leaves them out, time import included.
+	content, err = os.ReadFile(filepath.Join(dir, "user_test.go"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "PasswordHash:")
+	assert.NotContains(t, string(content), `"time"`)
+	assert.Contains(t, string(content), "Slug:")
+
+	_, err = ParseField("email:string:secret")
+	assert.ErrorContains(t, err, `unknown attribute "secret"`)
+	assert.Equal(t, "PasswordHash:string:hidden", fields[1].Spec())
+}
+
+func TestGenerateModelFile_Defaults(t *testing.T) {
+	dir := t.TempDir()
+	fields, err := ParseFields([]string{"status:string:default='draft'::character varying", "total:int:generated=price * quantity", "note:string"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "'draft'::character varying", fields[0].Default)
+	assert.Equal(t, "Status:string:default='draft'::character varying", fields[0].Spec())
+	assert.True(t, fields[1].ReadOnly, "generated fields are read-only")
+	assert.Equal(t, "Total:int:generated=price * quantity", fields[1].Spec())
+	_, err = ParseField("status:string:default=")
+	assert.ErrorContains(t, err, "needs an expression")
+
+	def := &ModelDefinition{Name: "Order", Fields: fields, OutputDir: dir}
+	var mm ModelManager
+	migration := mm.GenerateMigration(def)
+	assert.Contains(t, migration, "status VARCHAR(255) NOT NULL DEFAULT 'draft'::character varying,")
+	assert.Contains(t, migration, "total INTEGER GENERATED ALWAYS AS (price * quantity) STORED NOT NULL,")
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	content, err := os.ReadFile(filepath.Join(dir, "order.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Status string `json:\"status\"`                 // Defaults to 'draft'::character varying in the database.")
+	assert.Contains(t, string(content), "Total  int    `json:\"total\" grayv:\"readonly\"` // Generated by the database as price * quantity.")
+	assert.Contains(t, string(content), "Note   string `json:\"note\"`\n")
+}
+
+func TestGenerateModelFile_IDStrategy(t *testing.T) {
+	dir := t.TempDir()
+	fields := []Field{{Name: "Kind", Type: "string"}}
+
+	assert.NoError(t, GenerateModelFile(&ModelDefinition{Name: "Event", Fields: fields, OutputDir: dir, IDStrategy: IDUUID}, nil))
+	content, err := os.ReadFile(filepath.Join(dir, "event.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "ID   string `json:\"id\"`")
+	assert.Contains(t, string(content), "func (e *Event) IDStrategy() string {\n\treturn \"uuid\"\n}")
+
+	assert.NoError(t, GenerateModelFile(&ModelDefinition{Name: "Note", Fields: fields, OutputDir: dir}, nil))
+	content, err = os.ReadFile(filepath.Join(dir, "note.go"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "IDStrategy")
+	assert.NotContains(t, string(content), "ID ")
+}
+
+func TestGenerateModelFile_View(t *testing.T) {
+	dir := t.TempDir()
+	def := &ModelDefinition{Name: "AuthorStat", Fields: []Field{{Name: "Posts", Type: "int"}}, OutputDir: dir, View: "SELECT 1 AS id, 2 AS posts"}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	content, err := os.ReadFile(filepath.Join(dir, "author_stat.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "func (a *AuthorStat) ReadOnly() bool {\n\treturn true\n}")
+}
+
+func TestGenerateModelFile_ComputedFields(t *testing.T) {
+	dir := t.TempDir()
+	fullName, err := ParseField(`full_name:string:computed=first_name || ' ' || last_name`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	def := &ModelDefinition{Name: "Person", Fields: []Field{{Name: "FirstName", Type: "string"}, fullName}, OutputDir: dir}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	content, err := os.ReadFile(filepath.Join(dir, "person.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "FullName  string `json:\"full_name\" grayv:\"readonly\"` // Computed as first_name || ' ' || last_name when read.")
+	assert.Contains(t, string(content), "func (p *Person) ComputedFields() map[string]string {\n\treturn map[string]string{\n"+
+		"\t\t\"full_name\": \"first_name || ' ' || last_name\",\n\t}\n}")
+}
+
+func TestGenerateModelFile_NamingConvention(t *testing.T) {
+	assert.NoError(t, naming.SetConvention(naming.Convention{Columns: naming.CamelCase, Tables: naming.SingularTables}))
+	t.Cleanup(func() { naming.SetConvention(naming.Convention{}) })
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:      "BlogPost",
+		Fields:    []Field{{Name: "PublishedAt", Type: "time.Time"}},
+		OutputDir: dir,
+	}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	assert.NoError(t, GenerateModelTestFile(def, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "blog_post.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "PublishedAt time.Time `json:\"publishedAt\"`")
+	assert.Contains(t, string(content), `return "blog_post"`)
+	content, err = os.ReadFile(filepath.Join(dir, "blog_post_test.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `got != "blog_post"`)
+}
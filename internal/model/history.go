@@ -0,0 +1,135 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// Revision is a version of a model definition, as recorded in the model_history table.
+// Versions of a model are numbered from 1 in the order they were recorded.
+type Revision struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Fields    []Field   `json:"fields"`
+	Author    string    `json:"author"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// rowQuerier is implemented by *sql.DB and *sql.Tx, so revisions can be recorded in the transaction changing a model.
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// RecordRevision appends the fields of the model name to its history, in the database of d, and returns the new
+// version number. Two revisions recorded at once get the same number, and the unique version of a model rejects
+// the second.
+func RecordRevision(q rowQuerier, d dialect.Dialect, name string, fields []Field, author, note string) (int, error) {
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling fields: %w", err)
+	}
+
+	var version int
+	if err := q.QueryRow(bind(d, "SELECT COALESCE(MAX(version), 0) + 1 FROM model_history WHERE model_name = ?"), name).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading history of model %s: %w", name, err)
+	}
+	// The JSON is passed as text, since MySQL refuses to read JSON from binary strings.
+	if _, err := q.Exec(bind(d, "INSERT INTO model_history (model_name, version, fields, author, note) VALUES (?, ?, ?, ?, ?)"),
+		name, version, string(fieldsJSON), author, note); err != nil {
+		return 0, fmt.Errorf("error recording revision of model %s: %w", name, err)
+	}
+	return version, nil
+}
+
+// RecordBaseline records fields as the first version of the model name if its history is empty, which is
+// the case for models created before history was kept. It does nothing otherwise.
+func RecordBaseline(q rowQuerier, d dialect.Dialect, name string, fields []Field, author string) error {
+	var count int
+	if err := q.QueryRow(bind(d, "SELECT COUNT(*) FROM model_history WHERE model_name = ?"), name).Scan(&count); err != nil {
+		return fmt.Errorf("error reading history of model %s: %w", name, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := RecordRevision(q, d, name, fields, author, "baseline")
+	return err
+}
+
+// ListRevisions returns the history of the model name, read from the database of d, oldest version first.
+func ListRevisions(db *sql.DB, d dialect.Dialect, name string) ([]Revision, error) {
+	rows, err := db.Query(bind(d, `SELECT model_name, version, fields, author, note, created_at
+		FROM model_history WHERE model_name = ? ORDER BY version`), name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading history of model %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRevision returns the given version of the model name, read from the database of d. It returns sql.ErrNoRows
+// if there is no such version.
+func GetRevision(db *sql.DB, d dialect.Dialect, name string, version int) (*Revision, error) {
+	row := db.QueryRow(bind(d, `SELECT model_name, version, fields, author, note, created_at
+		FROM model_history WHERE model_name = ? AND version = ?`), name, version)
+	return scanRevision(row)
+}
+
+// bind writes the "?" placeholders of query, which has no string literals, as the database of d expects them:
+// $1, $2, and so on for PostgreSQL.
+func bind(d dialect.Dialect, query string) string {
+	if d.IsSQLite() || d.IsMySQL() {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "$%d", n)
+	}
+	return b.String()
+}
+
+// scanRevision reads a Revision from a row of model_history.
+func scanRevision(row interface{ Scan(...interface{}) error }) (*Revision, error) {
+	var revision Revision
+	var fieldsJSON []byte
+	if err := row.Scan(&revision.Name, &revision.Version, &fieldsJSON, &revision.Author, &revision.Note, &revision.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fieldsJSON, &revision.Fields); err != nil {
+		return nil, fmt.Errorf("error unmarshaling fields of %s version %d: %w", revision.Name, revision.Version, err)
+	}
+	return &revision, nil
+}
+
+// GenerateRevertMigration writes a migration to dir that changes the table of def from its current fields
+// to those of revision, with a down section restoring the current fields, and returns its path. The SQL is
+// written for the database of d.
+func GenerateRevertMigration(dir string, def *ModelDefinition, revision *Revision, d dialect.Dialect, w *codegen.Writer) (string, error) {
+	mm := ModelManager{Dialect: d}
+	up := mm.GenerateAlterMigration(def, def.Fields, revision.Fields)
+	down := mm.GenerateAlterMigration(def, revision.Fields, def.Fields)
+	name := fmt.Sprintf("revert_%s_to_v%d", def.TableName(), revision.Version)
+	return writeMigrationFile(dir, Clock.Now().UTC(), name, up, down, w)
+}
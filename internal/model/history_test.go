@@ -0,0 +1,131 @@
+package model
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestRecordRevision(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "history.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE model_history (model_name VARCHAR(50) NOT NULL, version INTEGER NOT NULL, fields TEXT NOT NULL,
+		author VARCHAR(100) NOT NULL, note TEXT NOT NULL DEFAULT '', created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, UNIQUE (model_name, version))`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fields := []Field{{Name: "Title", Type: "string"}}
+	assert.NoError(t, RecordBaseline(db, dialect.SQLite, "Post", fields, "ada"))
+	assert.NoError(t, RecordBaseline(db, dialect.SQLite, "Post", nil, "ada"), "a model with history gets no baseline")
+	version, err := RecordRevision(db, dialect.SQLite, "Post", append(fields, Field{Name: "Body", Type: "string"}), "ada", "added body")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	revisions, err := ListRevisions(db, dialect.SQLite, "Post")
+	if assert.NoError(t, err) && assert.Len(t, revisions, 2) {
+		assert.Equal(t, "baseline", revisions[0].Note)
+		assert.Equal(t, fields, revisions[0].Fields)
+	}
+	revision, err := GetRevision(db, dialect.SQLite, "Post", 2)
+	if assert.NoError(t, err) {
+		assert.Len(t, revision.Fields, 2)
+	}
+	_, err = GetRevision(db, dialect.SQLite, "Post", 3)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.Equal(t, "SELECT 1 FROM model_history WHERE model_name = $1 AND version = $2",
+		bind(dialect.Postgres, "SELECT 1 FROM model_history WHERE model_name = ? AND version = ?"))
+	assert.Equal(t, "SELECT 1 WHERE a = ?", bind(dialect.MySQL, "SELECT 1 WHERE a = ?"))
+}
+
+func TestGenerateAlterMigration(t *testing.T) {
+	var mm ModelManager
+	def := NewModelDefinition("Post", nil)
+	current := []Field{{Name: "Title", Type: "string"}, {Name: "Views", Type: "int"}}
+	previous := []Field{{Name: "Title", Type: "string"}, {Name: "Views", Type: "float64"}, {Name: "Summary", Type: "string"}}
+
+	assert.Equal(t, `ALTER TABLE posts ALTER COLUMN views TYPE DOUBLE PRECISION USING views::DOUBLE PRECISION;
+ALTER TABLE posts ADD COLUMN summary VARCHAR(255);
+`, mm.GenerateAlterMigration(def, current, previous))
+
+	assert.Equal(t, `ALTER TABLE posts ALTER COLUMN views TYPE INTEGER USING views::INTEGER;
+ALTER TABLE posts DROP COLUMN IF EXISTS summary;
+`, mm.GenerateAlterMigration(def, previous, current))
+}
+
+func TestGenerateAlterMigration_Defaults(t *testing.T) {
+	var mm ModelManager
+	def := NewModelDefinition("Order", nil)
+	previous := []Field{{Name: "Status", Type: "string"}, {Name: "Total", Type: "int", Generated: "price * quantity"}}
+	current := []Field{{Name: "Status", Type: "string", Default: "'new'"}, {Name: "Total", Type: "int", Generated: "price * quantity + 1"}}
+
+	assert.Equal(t, `ALTER TABLE orders ALTER COLUMN status SET DEFAULT 'new';
+ALTER TABLE orders DROP COLUMN IF EXISTS total;
+ALTER TABLE orders ADD COLUMN total INTEGER GENERATED ALWAYS AS (price * quantity + 1) STORED;
+`, mm.GenerateAlterMigration(def, previous, current))
+	assert.Contains(t, mm.GenerateAlterMigration(def, current, previous), "ALTER TABLE orders ALTER COLUMN status DROP DEFAULT;\n")
+}
+
+func TestGenerateAlterMigration_TZ(t *testing.T) {
+	def := NewModelDefinition("Event", nil)
+	previous := []Field{{Name: "StartsAt", Type: "time.Time"}}
+	current := []Field{{Name: "StartsAt", Type: "time.Time", TZ: true}}
+
+	var mm ModelManager
+	assert.Equal(t, "ALTER TABLE events ALTER COLUMN starts_at TYPE TIMESTAMPTZ USING starts_at::TIMESTAMPTZ;\n",
+		mm.GenerateAlterMigration(def, previous, current))
+	assert.Equal(t, "ALTER TABLE events ALTER COLUMN starts_at TYPE TIMESTAMP USING starts_at::TIMESTAMP;\n",
+		mm.GenerateAlterMigration(def, current, previous))
+	mm.Dialect = dialect.MySQL
+	assert.Equal(t, "ALTER TABLE events MODIFY COLUMN starts_at TIMESTAMP NOT NULL;\n", mm.GenerateAlterMigration(def, previous, current))
+	mm.Dialect = dialect.SQLite
+	assert.Contains(t, mm.GenerateAlterMigration(def, previous, current), "-- SQLite cannot change the type")
+}
+
+func TestGenerateRevertMigration(t *testing.T) {
+	dir := t.TempDir()
+	def := NewModelDefinition("Post", []Field{{Name: "Title", Type: "string"}})
+	revision := &Revision{Name: "Post", Version: 1, Fields: []Field{{Name: "Title", Type: "string"}, {Name: "Body", Type: "string"}}}
+
+	useFakeClock(t, time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC))
+	path, err := GenerateRevertMigration(dir, def, revision, dialect.Postgres, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20240315103000_revert_posts_to_v1.sql"), path)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "-- Up\nALTER TABLE posts ADD COLUMN body VARCHAR(255);\n\n-- Down\nALTER TABLE posts DROP COLUMN IF EXISTS body;\n")
+}
+
+func TestDefaultModel_Hooks(t *testing.T) {
+	c := useFakeClock(t, time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC))
+	var m DefaultModel
+
+	assert.NoError(t, m.BeforeCreate())
+	assert.Equal(t, c.Now(), m.CreatedAt)
+	assert.Equal(t, c.Now(), m.UpdatedAt)
+
+	c.Advance(time.Hour)
+	assert.NoError(t, m.BeforeUpdate())
+	assert.Equal(t, time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), m.CreatedAt)
+	assert.Equal(t, time.Date(2024, 3, 15, 11, 30, 0, 0, time.UTC), m.UpdatedAt)
+}
+
+// useFakeClock sets Clock to a fake clock at now until the test completes.
+func useFakeClock(t *testing.T, now time.Time) *clock.Fake {
+	c := clock.NewFake(now)
+	Clock = c
+	t.Cleanup(func() { Clock = clock.Real })
+	return c
+}
@@ -0,0 +1,67 @@
+package model
+
+import "fmt"
+
+// IDStrategy selects how the id column of a model's table gets its values.
+type IDStrategy string
+
+const (
+	// IDSerial stores ids in a SERIAL column numbered by the database. It is the default.
+	IDSerial IDStrategy = "serial"
+	// IDIdentity stores ids in a BIGINT identity column numbered by the database.
+	IDIdentity IDStrategy = "identity"
+	// IDUUID stores random UUIDs generated by the database with gen_random_uuid.
+	IDUUID IDStrategy = "uuid"
+	// IDSnowflake stores 64-bit, time-ordered ids generated by the application, such as by orm.Snowflake.
+	IDSnowflake IDStrategy = "snowflake"
+	// IDApp stores ids supplied by the application with every new record.
+	IDApp IDStrategy = "app"
+)
+
+// IDStrategies lists the accepted ID strategies.
+var IDStrategies = []IDStrategy{IDSerial, IDIdentity, IDUUID, IDSnowflake, IDApp}
+
+// ParseIDStrategy returns the IDStrategy named by strategy. An empty strategy is IDSerial.
+func ParseIDStrategy(strategy string) (IDStrategy, error) {
+	if strategy == "" {
+		return IDSerial, nil
+	}
+	for _, s := range IDStrategies {
+		if IDStrategy(strategy) == s {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ID strategy %q: use serial, identity, uuid, snowflake, or app", strategy)
+}
+
+// Generated reports whether the database generates the ids, which are then read back after inserting a record.
+func (s IDStrategy) Generated() bool {
+	switch s {
+	case IDSnowflake, IDApp:
+		return false
+	default:
+		return true
+	}
+}
+
+// columnDefinition returns the SQL definition of the id column.
+func (s IDStrategy) columnDefinition() string {
+	switch s {
+	case IDIdentity:
+		return "id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY"
+	case IDUUID:
+		return "id UUID PRIMARY KEY DEFAULT gen_random_uuid()"
+	case IDSnowflake, IDApp:
+		return "id BIGINT PRIMARY KEY"
+	default:
+		return "id SERIAL PRIMARY KEY"
+	}
+}
+
+// KeyStrategy returns the strategy of the id column of m, IDSerial if none is set.
+func (m *ModelDefinition) KeyStrategy() IDStrategy {
+	if m.IDStrategy == "" {
+		return IDSerial
+	}
+	return m.IDStrategy
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ColumnDefault is what the database reports of how it fills a column: the expression of its default, or
+// the expression of a generated column. Both are "" for a column the database does not fill.
+type ColumnDefault struct {
+	Default   string
+	Generated string
+}
+
+// IntrospectDefaults returns the defaults and generation expressions of the columns of table, by column name,
+// as PostgreSQL reports them, so 'draft' may read 'draft'::character varying. It returns an empty map if the
+// table does not exist.
+func IntrospectDefaults(db *sql.DB, table string) (map[string]ColumnDefault, error) {
+	rows, err := db.Query(`SELECT column_name, COALESCE(column_default, ''),
+			CASE WHEN is_generated = 'ALWAYS' THEN COALESCE(generation_expression, '') ELSE '' END
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1`, table)
+	if err != nil {
+		return nil, fmt.Errorf("error introspecting columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]ColumnDefault)
+	for rows.Next() {
+		var name string
+		var column ColumnDefault
+		if err := rows.Scan(&name, &column.Default, &column.Generated); err != nil {
+			return nil, fmt.Errorf("error introspecting columns of %s: %w", table, err)
+		}
+		columns[name] = column
+	}
+	return columns, rows.Err()
+}
+
+// ApplyDefaults sets the default and generation expression of every field of m to those of its column in
+// columns, as returned by IntrospectDefaults, so the code and schemas generated from m match the database
+// rather than the definition it was created from. Generated fields become read-only. Fields whose column is
+// missing, for instance because the migration creating it has not run, are left as defined.
+func (m *ModelDefinition) ApplyDefaults(columns map[string]ColumnDefault) {
+	for i, field := range m.Fields {
+		column, ok := columns[field.ColumnName()]
+		if !ok {
+			continue
+		}
+		field.Default, field.Generated = column.Default, column.Generated
+		if field.Generated != "" {
+			field.ReadOnly = true
+		}
+		field.Tag = field.StructTag()
+		m.Fields[i] = field
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelDefinition_ApplyDefaults(t *testing.T) {
+	def := NewModelDefinition("Order", []Field{
+		{Name: "Status", Type: "string", Default: "'draft'"},
+		{Name: "Total", Type: "int"},
+		{Name: "Note", Type: "string", Default: "''"},
+	})
+	def.ApplyDefaults(map[string]ColumnDefault{
+		"status": {Default: "'new'::character varying"},
+		"total":  {Generated: "(price * quantity)"},
+	})
+
+	assert.Equal(t, "'new'::character varying", def.Fields[0].Default)
+	assert.Equal(t, "(price * quantity)", def.Fields[1].Generated)
+	assert.True(t, def.Fields[1].ReadOnly)
+	assert.Equal(t, `json:"total" grayv:"readonly"`, def.Fields[1].Tag)
+	assert.Equal(t, "''", def.Fields[2].Default, "fields without a column are left as defined")
+}
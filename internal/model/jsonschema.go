@@ -0,0 +1,196 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the documents returned by JSONSchema and JSONSchemas.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document, or a schema nested in one. Type is a type name, or a list of type names
+// for nullable values.
+type Schema struct {
+	Dialect              string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema document describing the JSON encoding of the model generated from def.
+// Properties are named after the json tags of the generated struct, so hidden fields are left out. The id,
+// created_at, and updated_at columns of DefaultModel and the read-only fields are read-only, and the fields
+// that are not nullable are required. Generated fields are read-only, and fields whose column defaults to a
+// constant have it as their default.
+func JSONSchema(def *ModelDefinition) *Schema {
+	schema := modelSchema(def)
+	schema.Dialect = JSONSchemaDialect
+	return schema
+}
+
+// JSONSchemas returns a JSON Schema document defining the models of defs in its $defs, by model name.
+func JSONSchemas(defs []*ModelDefinition) *Schema {
+	schema := &Schema{Dialect: JSONSchemaDialect, Defs: make(map[string]*Schema, len(defs))}
+	for _, def := range defs {
+		schema.Defs[def.Name] = modelSchema(def)
+	}
+	return schema
+}
+
+// modelSchema returns the schema of the model generated from def.
+func modelSchema(def *ModelDefinition) *Schema {
+	closed := false
+	schema := &Schema{
+		Title: def.Name,
+		Type:  "object",
+		Properties: map[string]*Schema{
+			"id":         {Type: "integer", ReadOnly: true},
+			"created_at": {Type: "string", Format: "date-time", ReadOnly: true},
+			"updated_at": {Type: "string", Format: "date-time", ReadOnly: true},
+		},
+		AdditionalProperties: &closed,
+	}
+	switch def.KeyStrategy() {
+	case IDUUID:
+		schema.Properties["id"] = &Schema{Type: "string", Format: "uuid", ReadOnly: true}
+	case IDApp:
+		schema.Properties["id"].ReadOnly = false
+	}
+	for _, field := range def.Fields {
+		if field.Hidden {
+			continue
+		}
+		name := field.ColumnName()
+		schema.Properties[name] = fieldSchema(field)
+		if !field.IsNull {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of the values of field.
+func fieldSchema(field Field) *Schema {
+	var schema *Schema
+	if dimensions, ok := VectorDimensions(field.Type); ok {
+		schema = &Schema{Type: "array", Items: &Schema{Type: "number"}, MinItems: &dimensions, MaxItems: &dimensions}
+	} else if _, _, ok := DecimalPrecision(field.Type); ok {
+		schema = &Schema{Type: "string", Pattern: decimalPattern(field.Type)}
+	} else {
+		switch BaseType(field.Type) {
+		case "int":
+			schema = &Schema{Type: "integer"}
+		case "float64":
+			schema = &Schema{Type: "number"}
+		case "bool":
+			schema = &Schema{Type: "boolean"}
+		case "time.Time":
+			schema = &Schema{Type: "string", Format: "date-time"}
+		case "[]byte":
+			schema = &Schema{Type: "string", ContentEncoding: "base64"}
+		default:
+			schema = &Schema{Type: "string"}
+		}
+	}
+	var description []string
+	if field.References != "" {
+		description = append(description, "ID of the referenced "+field.References)
+	}
+	if value, ok := defaultValue(field); ok {
+		schema.Default = value
+	} else if comment := field.Comment(); comment != "" {
+		description = append(description, comment)
+	}
+	schema.Description = strings.Join(description, ". ")
+	schema.ReadOnly = field.ReadOnly || field.Generated != ""
+	if field.IsNull {
+		schema.Type = []string{schema.Type.(string), "null"}
+	}
+	return schema
+}
+
+// defaultValue returns the JSON value of the default of field if it is a constant of the type of field, such as
+// 'draft' or 'draft'::character varying for a string. Defaults computed by the database, such as now(), have none.
+func defaultValue(field Field) (interface{}, bool) {
+	text, ok := sqlConstant(field.Default)
+	if !ok {
+		return nil, false
+	}
+	if _, _, ok := DecimalPrecision(field.Type); ok {
+		return text, true
+	}
+	switch BaseType(field.Type) {
+	case "string":
+		return text, true
+	case "int":
+		value, err := strconv.Atoi(text)
+		return value, err == nil
+	case "float64":
+		value, err := strconv.ParseFloat(text, 64)
+		return value, err == nil
+	case "bool":
+		value, err := strconv.ParseBool(text)
+		return value, err == nil
+	default:
+		return nil, false
+	}
+}
+
+// sqlConstant returns the text of expression if it is a number or a quoted string, possibly cast to a type as
+// PostgreSQL reports defaults, as in '42'::integer.
+func sqlConstant(expression string) (string, bool) {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "'") {
+		if before, after, cast := strings.Cut(expression, "::"); cast && isCast("::"+after) {
+			expression = before
+		}
+		expression = strings.Trim(expression, "()")
+		if _, err := strconv.ParseFloat(expression, 64); err == nil || expression == "true" || expression == "false" {
+			return expression, true
+		}
+		return "", false
+	}
+	var text strings.Builder
+	for i := 1; i < len(expression); i++ {
+		if expression[i] != '\'' {
+			text.WriteByte(expression[i])
+			continue
+		}
+		if i+1 < len(expression) && expression[i+1] == '\'' {
+			text.WriteByte('\'')
+			i++
+			continue
+		}
+		rest := expression[i+1:]
+		if rest == "" || isCast(rest) {
+			return text.String(), true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// isCast reports whether s is a cast to a type, such as ::character varying or ::numeric(10,2).
+func isCast(s string) bool {
+	if !strings.HasPrefix(s, "::") {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("abcdefghijklmnopqrstuvwxyz0123456789_ :(),[]", r) {
+			return false
+		}
+	}
+	return true
+}
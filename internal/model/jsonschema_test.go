@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	def := NewModelDefinition("BlogPost", []Field{
+		{Name: "Title", Type: "string"},
+		{Name: "PublishedAt", Type: "time.Time", IsNull: true},
+		{Name: "Embedding", Type: "vector(3)"},
+		{Name: "AuthorID", Type: "int", References: "Author"},
+	})
+
+	data, err := json.Marshal(JSONSchema(def))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "BlogPost",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "readOnly": true},
+			"created_at": {"type": "string", "format": "date-time", "readOnly": true},
+			"updated_at": {"type": "string", "format": "date-time", "readOnly": true},
+			"title": {"type": "string"},
+			"published_at": {"type": ["string", "null"], "format": "date-time"},
+			"embedding": {"type": "array", "items": {"type": "number"}, "minItems": 3, "maxItems": 3},
+			"author_id": {"type": "integer", "description": "ID of the referenced Author"}
+		},
+		"required": ["title", "embedding", "author_id"],
+		"additionalProperties": false
+	}`, string(data))
+}
+
+func TestJSONSchemas(t *testing.T) {
+	schema := JSONSchemas([]*ModelDefinition{
+		NewModelDefinition("Author", []Field{{Name: "Name", Type: "string"}}),
+		NewModelDefinition("Tag", []Field{{Name: "Data", Type: "[]byte"}}),
+	})
+
+	assert.Equal(t, JSONSchemaDialect, schema.Dialect)
+	assert.Nil(t, schema.Type)
+	assert.Equal(t, "Author", schema.Defs["Author"].Title)
+	assert.Empty(t, schema.Defs["Author"].Dialect, "only the document names its dialect")
+	assert.Equal(t, "base64", schema.Defs["Tag"].Properties["data"].ContentEncoding)
+}
+
+func TestJSONSchema_FieldAttributes(t *testing.T) {
+	schema := JSONSchema(NewModelDefinition("User", []Field{
+		{Name: "Email", Type: "string"},
+		{Name: "PasswordHash", Type: "string", Hidden: true},
+		{Name: "Slug", Type: "string", ReadOnly: true},
+	}))
+
+	assert.NotContains(t, schema.Properties, "password_hash")
+	assert.True(t, schema.Properties["slug"].ReadOnly)
+	assert.Equal(t, []string{"email", "slug"}, schema.Required)
+}
+
+func TestJSONSchema_Defaults(t *testing.T) {
+	schema := JSONSchema(NewModelDefinition("Order", []Field{
+		{Name: "Status", Type: "string", Default: "'it''s new'::character varying"},
+		{Name: "Quantity", Type: "int", Default: "'-1'::integer"},
+		{Name: "Paid", Type: "bool", Default: "false"},
+		{Name: "PlacedAt", Type: "time.Time", Default: "now()"},
+		{Name: "Total", Type: "int", Generated: "price * quantity"},
+		{Name: "Label", Type: "string", Default: "'a'::text || 'b'::text"},
+	}))
+
+	assert.Equal(t, "it's new", schema.Properties["status"].Default)
+	assert.Equal(t, -1, schema.Properties["quantity"].Default)
+	assert.Equal(t, false, schema.Properties["paid"].Default)
+	assert.Nil(t, schema.Properties["placed_at"].Default)
+	assert.Equal(t, "Defaults to now() in the database.", schema.Properties["placed_at"].Description)
+	assert.True(t, schema.Properties["total"].ReadOnly)
+	assert.Equal(t, "Generated by the database as price * quantity.", schema.Properties["total"].Description)
+	assert.Nil(t, schema.Properties["label"].Default, "expressions are not constants")
+
+	data, err := json.Marshal(schema.Properties["paid"])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "boolean", "default": false}`, string(data))
+}
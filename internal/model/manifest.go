@@ -0,0 +1,310 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes several models at once, as read from a models.yaml file:
+//
+//	models:
+//	  - name: Author
+//	    fields: [name:string, email:string]
+//	  - name: Post
+//	    fields: [title:string, body:string]
+//	    belongs_to: [Author]
+//	    rls: tenant
+//	    id: uuid
+//	  - name: Event
+//	    fields: [kind:string]
+//	    partition_by: range(created_at)
+//	  - name: AuthorStat
+//	    fields: [posts:int]
+//	    view: SELECT author_id AS id, count(*) AS posts FROM posts GROUP BY author_id
+//	    view_kind: plain
+type Manifest struct {
+	Models []ManifestModel `yaml:"models"`
+}
+
+// ManifestModel is a model in a Manifest. Fields use the name:type format of the --fields flag.
+// Every model named in BelongsTo adds a foreign key field, so Post belonging to Author gets an AuthorID field.
+// RLS is "tenant" or "owner" to generate a row-level security policy; an owner policy matches rows by the
+// foreign key to Owner, which must be in BelongsTo and defaults to User.
+// ID is the ID strategy of the model, as accepted by ParseIDStrategy.
+// PartitionBy partitions the table of the model by month, as accepted by ParsePartitionBy.
+// View is the query of the view backing a read-only model, and ViewKind is "materialized", the default, or
+// "plain"; see ModelDefinition.SetView.
+type ManifestModel struct {
+	Name        string   `yaml:"name"`
+	Fields      []string `yaml:"fields"`
+	BelongsTo   []string `yaml:"belongs_to"`
+	RLS         string   `yaml:"rls"`
+	Owner       string   `yaml:"owner"`
+	ID          string   `yaml:"id"`
+	PartitionBy string   `yaml:"partition_by"`
+	View        string   `yaml:"view"`
+	ViewKind    string   `yaml:"view_kind"`
+}
+
+// LoadManifest reads and parses the manifest file at path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %w", path, err)
+	}
+	if len(manifest.Models) == 0 {
+		return nil, fmt.Errorf("manifest %s defines no models", path)
+	}
+	return &manifest, nil
+}
+
+// Definitions returns the validated definitions of the models in the manifest, ordered so every model
+// comes after the models it belongs to. Models may belong to models that are not in the manifest,
+// as long as they already exist when the migrations run, but not to partitioned models, whose id alone is not unique.
+// Only view models may belong to view models, which have no table to reference.
+func (m *Manifest) Definitions() ([]*ModelDefinition, error) {
+	var defs []*ModelDefinition
+	seen := make(map[string]bool)
+	for _, entry := range m.Models {
+		name, err := NormalizeModelName(entry.Name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("model %s is defined more than once", name)
+		}
+		seen[name] = true
+
+		fields, err := ParseFields(entry.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %w", name, err)
+		}
+		for _, parent := range entry.BelongsTo {
+			parentName, err := NormalizeModelName(parent)
+			if err != nil {
+				return nil, fmt.Errorf("model %s: %w", name, err)
+			}
+			fields = append(fields, ForeignKeyField(parentName))
+		}
+
+		if err := ValidateModel(name, fields); err != nil {
+			return nil, err
+		}
+		def := NewModelDefinition(name, fields)
+		if err := def.setRLS(entry.RLS, entry.Owner); err != nil {
+			return nil, err
+		}
+		if def.IDStrategy, err = ParseIDStrategy(entry.ID); err != nil {
+			return nil, fmt.Errorf("model %s: %w", name, err)
+		}
+		if err := def.SetPartitionBy(entry.PartitionBy); err != nil {
+			return nil, err
+		}
+		if err := def.SetView(entry.View, entry.ViewKind); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+
+	byName := make(map[string]*ModelDefinition, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+	for _, def := range defs {
+		for _, field := range def.Fields {
+			switch parent, ok := byName[field.References]; {
+			case !ok:
+			case parent.PartitionBy != "":
+				return nil, fmt.Errorf("model %s cannot belong to the partitioned model %s", def.Name, field.References)
+			case parent.IsView() && !def.IsView():
+				return nil, fmt.Errorf("model %s cannot belong to the view model %s", def.Name, field.References)
+			}
+		}
+	}
+
+	return SortByDependencies(defs)
+}
+
+// ParseField parses a field in the name:type format, e.g. "published_at:time.Time", optionally followed by
+// the attributes hidden, readonly, and tz, e.g. "password_hash:string:hidden" or "starts_at:time.Time:tz", and last by a default, generation, or
+// computation expression, e.g. "status:string:default='draft'", "total:int:generated=price * quantity", or
+// "full_name:string:computed=first_name || ' ' || last_name".
+// The name is normalized; the primary key is the id column inherited from DefaultModel, so fields are never primary.
+func ParseField(spec string) (Field, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 {
+		return Field{}, fmt.Errorf("invalid field format: %s", spec)
+	}
+	name, err := NormalizeFieldName(parts[0])
+	if err != nil {
+		return Field{}, err
+	}
+	if err := validateType(parts[1]); err != nil {
+		return Field{}, fmt.Errorf("field %s: %w", name, err)
+	}
+	field := NewField(name, parts[1], "", false, false)
+	for i, attr := range parts[2:] {
+		if key, expression, ok := strings.Cut(strings.Join(parts[2+i:], ":"), "="); ok && (key == AttrDefault || key == AttrGenerated || key == AttrComputed) {
+			if expression == "" {
+				return Field{}, fmt.Errorf("attribute %s of field %s needs an expression, as in %s=...", key, name, key)
+			}
+			switch key {
+			case AttrDefault:
+				field.Default = expression
+			case AttrGenerated:
+				field.Generated, field.ReadOnly = expression, true
+			default:
+				field.Computed, field.ReadOnly = expression, true
+			}
+			break
+		}
+		switch attr {
+		case AttrHidden:
+			field.Hidden = true
+		case AttrReadOnly:
+			field.ReadOnly = true
+		case AttrTZ:
+			field.TZ = true
+		default:
+			return Field{}, fmt.Errorf("unknown attribute %q of field %s: use %s, %s, %s, %s=..., %s=..., or %s=...",
+				attr, name, AttrHidden, AttrReadOnly, AttrTZ, AttrDefault, AttrGenerated, AttrComputed)
+		}
+	}
+	if err := field.validateTZ(); err != nil {
+		return Field{}, err
+	}
+	field.Tag = field.StructTag()
+	return field, nil
+}
+
+// joinSplitSpecs joins each spec of specs with an unclosed parenthesis with the specs after it, up to the one
+// closing it, separated by commas.
+func joinSplitSpecs(specs []string) []string {
+	var joined []string
+	open := 0
+	for _, spec := range specs {
+		if open > 0 {
+			joined[len(joined)-1] += "," + spec
+		} else {
+			joined = append(joined, spec)
+		}
+		open += strings.Count(spec, "(") - strings.Count(spec, ")")
+	}
+	return joined
+}
+
+// ParseFields parses every field in specs with ParseField. Lists of fields are split at commas, so specs that
+// were split at the comma of a type or an expression, such as "price:decimal(10" and "2)", are joined back first.
+func ParseFields(specs []string) ([]Field, error) {
+	var fields []Field
+	for _, spec := range joinSplitSpecs(specs) {
+		field, err := ParseField(spec)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ForeignKeyField returns the field referencing the model named parent, e.g. AuthorID for Author.
+func ForeignKeyField(parent string) Field {
+	name := parent + "ID"
+	field := NewField(name, "int", fmt.Sprintf(`json:"%s"`, naming.Column(name)), false, false)
+	field.References = parent
+	return field
+}
+
+// SortByDependencies orders defs so every model comes after the models its fields reference.
+// View models come after every table, in the order given, since their queries may read any of them.
+// References to models outside defs and to the model itself are ignored. It returns an error if
+// the references form a cycle.
+func SortByDependencies(defs []*ModelDefinition) ([]*ModelDefinition, error) {
+	byName := make(map[string]*ModelDefinition, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(defs))
+	sorted := make([]*ModelDefinition, 0, len(defs))
+
+	var visit func(def *ModelDefinition, path []string) error
+	visit = func(def *ModelDefinition, path []string) error {
+		switch state[def.Name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("models reference each other in a cycle: %s", strings.Join(append(path, def.Name), " -> "))
+		}
+		state[def.Name] = visiting
+		for _, field := range def.Fields {
+			if parent, ok := byName[field.References]; ok && parent != def {
+				if err := visit(parent, append(path, def.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[def.Name] = done
+		sorted = append(sorted, def)
+		return nil
+	}
+
+	for _, view := range []bool{false, true} {
+		for _, def := range defs {
+			if def.IsView() != view {
+				continue
+			}
+			if err := visit(def, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sorted, nil
+}
+
+// GenerateMigrationFiles writes a migration creating the table of every model in defs to dir, in the order given,
+// and returns the paths of the files. The files are numbered from the current time in the format of the
+// embedded migrations, one second apart, so they sort in the same order. The SQL is written for the database of d.
+func GenerateMigrationFiles(dir string, defs []*ModelDefinition, d dialect.Dialect, w *codegen.Writer) ([]string, error) {
+	mm := ModelManager{Dialect: d}
+	start := Clock.Now().UTC()
+
+	var paths []string
+	for i, def := range defs {
+		path, err := writeMigrationFile(dir, start.Add(time.Duration(i)*time.Second), "create_"+def.TableName()+"_table",
+			mm.GenerateMigration(def), mm.GenerateDownMigration(def), w)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeMigrationFile writes a migration named name with the given up and down SQL to dir, versioned by at,
+// and returns its path.
+func writeMigrationFile(dir string, at time.Time, name, up, down string, w *codegen.Writer) (string, error) {
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.sql", at.Format("20060102150405"), name))
+	content := "-- Up\n" + up + "\n-- Down\n" + down
+	if err := w.WriteFile(path, []byte(content)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
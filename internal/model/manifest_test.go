@@ -0,0 +1,287 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest_Definitions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+models:
+  - name: comment
+    fields: [body:string]
+    belongs_to: [post, author]
+  - name: post
+    fields: [title:string, published_at:time.Time]
+    belongs_to: [author]
+  - name: author
+    fields: [name:string]
+`), 0644))
+
+	manifest, err := LoadManifest(path)
+	assert.NoError(t, err)
+
+	defs, err := manifest.Definitions()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+	assert.Equal(t, []string{"Author", "Post", "Comment"}, names)
+	assert.Equal(t, Field{Name: "AuthorID", Type: "int", Tag: `json:"author_id"`, References: "Author"}, defs[1].Fields[2])
+}
+
+func TestManifest_Definitions_Cycle(t *testing.T) {
+	manifest := &Manifest{Models: []ManifestModel{
+		{Name: "Egg", BelongsTo: []string{"Chicken"}},
+		{Name: "Chicken", BelongsTo: []string{"Egg"}},
+	}}
+
+	_, err := manifest.Definitions()
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestGenerateMigration(t *testing.T) {
+	var mm ModelManager
+	def := NewModelDefinition("BlogPost", []Field{{Name: "Title", Type: "string"}, ForeignKeyField("Author")})
+
+	assert.Equal(t, `CREATE TABLE blog_posts (
+  id SERIAL PRIMARY KEY,
+  title VARCHAR(255) NOT NULL,
+  author_id INTEGER NOT NULL REFERENCES authors(id),
+  created_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC')
+);
+`, mm.GenerateMigration(def))
+}
+
+func TestParseField_TZ(t *testing.T) {
+	field, err := ParseField("starts_at:time.Time:tz:default=now()")
+	assert.NoError(t, err)
+	assert.True(t, field.TZ)
+	assert.Equal(t, "now()", field.Default)
+	assert.Equal(t, "StartsAt:time.Time:tz:default=now()", field.Spec())
+
+	_, err = ParseField("name:string:tz")
+	assert.ErrorContains(t, err, "only time.Time fields take the tz attribute")
+
+	def := NewModelDefinition("Event", []Field{field})
+	assert.Contains(t, NewModelManager().GenerateMigration(def), "starts_at TIMESTAMPTZ NOT NULL DEFAULT now()")
+	assert.Contains(t, (&ModelManager{Dialect: dialect.MySQL}).GenerateMigration(def), "starts_at TIMESTAMP NOT NULL")
+	assert.Contains(t, (&ModelManager{Dialect: dialect.SQLite}).GenerateMigration(def), "starts_at DATETIME NOT NULL")
+}
+
+func TestGenerateMigration_Tenant(t *testing.T) {
+	var mm ModelManager
+	def := &ModelDefinition{Name: "Note", Fields: []Field{{Name: "Body", Type: "string"}}, Tenant: true}
+
+	assert.Equal(t, `CREATE TABLE notes (
+  id SERIAL PRIMARY KEY,
+  tenant_id VARCHAR(63) NOT NULL,
+  body VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC')
+);
+CREATE INDEX notes_tenant_id_idx ON notes (tenant_id);
+`, mm.GenerateMigration(def))
+}
+
+func TestManifest_RLS(t *testing.T) {
+	manifest := &Manifest{Models: []ManifestModel{
+		{Name: "Note", Fields: []string{"body:string"}, RLS: "tenant"},
+		{Name: "Todo", Fields: []string{"title:string"}, BelongsTo: []string{"User"}, RLS: "owner"},
+	}}
+	defs, err := manifest.Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var mm ModelManager
+	assert.True(t, defs[0].Tenant)
+	assert.Contains(t, mm.GenerateMigration(defs[0]), `ALTER TABLE notes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE notes FORCE ROW LEVEL SECURITY;
+CREATE POLICY notes_tenant_policy ON notes
+  USING (tenant_id = current_setting('grayv.tenant_id', true))
+  WITH CHECK (tenant_id = current_setting('grayv.tenant_id', true));
+`)
+	assert.Contains(t, mm.GenerateMigration(defs[1]), "USING (user_id = NULLIF(current_setting('grayv.user_id', true), '')::integer)")
+
+	for _, entry := range []ManifestModel{
+		{Name: "Todo", Fields: []string{"title:string"}, RLS: "owner"},
+		{Name: "Todo", Fields: []string{"title:string"}, RLS: "everyone"},
+		{Name: "Todo", Fields: []string{"title:string"}, RLS: "tenant", Owner: "User"},
+	} {
+		_, err := (&Manifest{Models: []ManifestModel{entry}}).Definitions()
+		assert.Error(t, err, entry.RLS)
+	}
+}
+
+func TestGenerateMigration_QuotesReservedNames(t *testing.T) {
+	var mm ModelManager
+	// Definitions stored before names were validated may still use reserved words.
+	def := &ModelDefinition{Name: "Group", Fields: []Field{{Name: "Order", Type: "int", IsPrimary: true}}}
+
+	assert.Equal(t, "CREATE TABLE groups (\n  \"order\" INTEGER PRIMARY KEY NOT NULL\n);\n", mm.GenerateMigration(def))
+	assert.Equal(t, "ALTER TABLE groups DROP COLUMN IF EXISTS \"order\";\n", mm.GenerateAlterMigration(def, def.Fields, nil))
+}
+
+func TestManifest_IDStrategy(t *testing.T) {
+	manifest := &Manifest{Models: []ManifestModel{
+		{Name: "Note", Fields: []string{"body:string"}},
+		{Name: "Event", Fields: []string{"kind:string"}, ID: "uuid"},
+		{Name: "Order", Fields: []string{"total:int"}, ID: "snowflake"},
+	}}
+	defs, err := manifest.Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var mm ModelManager
+	assert.Equal(t, IDSerial, defs[0].KeyStrategy())
+	assert.Contains(t, mm.GenerateMigration(defs[0]), "id SERIAL PRIMARY KEY")
+	assert.Contains(t, mm.GenerateMigration(defs[1]), "id UUID PRIMARY KEY DEFAULT gen_random_uuid()")
+	assert.Contains(t, mm.GenerateMigration(defs[2]), "id BIGINT PRIMARY KEY,")
+	assert.Contains(t, mm.GenerateMigration(&ModelDefinition{Name: "Tag", IDStrategy: IDIdentity}),
+		"id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY")
+
+	_, err = (&Manifest{Models: []ManifestModel{{Name: "Note", Fields: []string{"body:string"}, ID: "random"}}}).Definitions()
+	assert.Error(t, err)
+}
+
+func TestManifest_PartitionBy(t *testing.T) {
+	defs, err := (&Manifest{Models: []ManifestModel{
+		{Name: "Event", Fields: []string{"kind:string"}, PartitionBy: "range(created_at)", ID: "uuid"},
+	}}).Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var mm ModelManager
+	assert.Equal(t, "CREATE TABLE events (\n"+
+		"  id UUID DEFAULT gen_random_uuid(),\n"+
+		"  kind VARCHAR(255) NOT NULL,\n"+
+		"  created_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC'),\n"+
+		"  updated_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC'),\n"+
+		"  PRIMARY KEY (id, created_at)\n"+
+		") PARTITION BY RANGE (created_at);\n"+
+		"CREATE TABLE events_default PARTITION OF events DEFAULT;\n", mm.GenerateMigration(defs[0]))
+
+	for _, models := range [][]ManifestModel{
+		{{Name: "Event", Fields: []string{"kind:string"}, PartitionBy: "list(kind)"}},
+		{{Name: "Event", Fields: []string{"kind:string"}, PartitionBy: "range(kind)"}},
+		{{Name: "Event", Fields: []string{"kind:string"}, PartitionBy: "range(occurred_at)"}},
+		{{Name: "Event", Fields: []string{"kind:string"}, PartitionBy: "range(created_at)", ID: "identity"}},
+		{
+			{Name: "Event", Fields: []string{"kind:string"}, PartitionBy: "range(created_at)"},
+			{Name: "Reaction", Fields: []string{"emoji:string"}, BelongsTo: []string{"Event"}},
+		},
+	} {
+		_, err := (&Manifest{Models: models}).Definitions()
+		assert.Error(t, err, models[0].PartitionBy)
+	}
+}
+
+func TestManifest_View(t *testing.T) {
+	defs, err := (&Manifest{Models: []ManifestModel{
+		{Name: "AuthorStat", Fields: []string{"posts:int"}, View: "SELECT author_id AS id, count(*) AS posts FROM posts GROUP BY author_id;\n"},
+		{Name: "Post", Fields: []string{"title:string"}},
+	}}).Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "Post", defs[0].Name, "views come after the tables they may read")
+	var mm ModelManager
+	assert.Equal(t, "CREATE MATERIALIZED VIEW author_stats AS\n"+
+		"SELECT author_id AS id, count(*) AS posts FROM posts GROUP BY author_id;\n"+
+		"CREATE UNIQUE INDEX author_stats_id_idx ON author_stats (id);\n", mm.GenerateMigration(defs[1]))
+	assert.Equal(t, "DROP MATERIALIZED VIEW IF EXISTS author_stats;\n", mm.GenerateDownMigration(defs[1]))
+
+	for _, models := range [][]ManifestModel{
+		{{Name: "AuthorStat", Fields: []string{"posts:int"}, View: "DELETE FROM posts"}},
+		{{Name: "AuthorStat", Fields: []string{"posts:int"}, View: "SELECT 1 AS id", RLS: "tenant"}},
+		{
+			{Name: "AuthorStat", Fields: []string{"posts:int"}, View: "SELECT 1 AS id, 2 AS posts"},
+			{Name: "Badge", Fields: []string{"label:string"}, BelongsTo: []string{"AuthorStat"}},
+		},
+	} {
+		_, err := (&Manifest{Models: models}).Definitions()
+		assert.Error(t, err, models[0].View)
+	}
+}
+
+func TestManifest_PlainView(t *testing.T) {
+	defs, err := (&Manifest{Models: []ManifestModel{
+		{Name: "ActiveUser", Fields: []string{"email:string"}, View: "SELECT id, email FROM users WHERE active", ViewKind: "plain"},
+	}}).Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var mm ModelManager
+	assert.False(t, defs[0].Materialized())
+	assert.Equal(t, "CREATE VIEW active_users AS\nSELECT id, email FROM users WHERE active;\n", mm.GenerateMigration(defs[0]))
+	assert.Equal(t, "DROP VIEW IF EXISTS active_users;\n", mm.GenerateDownMigration(defs[0]))
+
+	for _, entry := range []ManifestModel{
+		{Name: "ActiveUser", Fields: []string{"email:string"}, View: "SELECT id, email FROM users", ViewKind: "temporary"},
+		{Name: "ActiveUser", Fields: []string{"email:string"}, ViewKind: "plain"},
+	} {
+		_, err := (&Manifest{Models: []ManifestModel{entry}}).Definitions()
+		assert.Error(t, err, entry.ViewKind)
+	}
+}
+
+func TestManifest_ComputedField(t *testing.T) {
+	defs, err := (&Manifest{Models: []ManifestModel{
+		{Name: "Person", Fields: []string{"first_name:string", "last_name:string", "full_name:string:computed=first_name || ' ' || last_name"}},
+	}}).Definitions()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	def := defs[0]
+	field := def.Fields[2]
+	assert.Equal(t, "first_name || ' ' || last_name", field.Computed)
+	assert.True(t, field.ReadOnly)
+	assert.False(t, field.HasColumn())
+	assert.Equal(t, "FullName:string:computed=first_name || ' ' || last_name", field.Spec())
+	assert.Equal(t, map[string]string{"full_name": "first_name || ' ' || last_name"}, def.ComputedFields())
+	assert.Equal(t, "*, (first_name || ' ' || last_name) AS full_name", def.SelectList())
+	assert.NotContains(t, def.Columns(), "full_name")
+
+	var mm ModelManager
+	migration := mm.GenerateMigration(def)
+	assert.Contains(t, migration, "last_name VARCHAR(255) NOT NULL,\n  created_at")
+	assert.NotContains(t, migration, "full_name")
+	assert.Equal(t, "-- No changes.\n", mm.GenerateAlterMigration(def, def.Fields[:2], def.Fields))
+}
+
+func TestGenerateMigration_NamingConvention(t *testing.T) {
+	assert.NoError(t, naming.SetConvention(naming.Convention{Columns: naming.CamelCase, Tables: naming.SingularTables, Prefix: "app_"}))
+	t.Cleanup(func() { naming.SetConvention(naming.Convention{}) })
+	var mm ModelManager
+	def := NewModelDefinition("BlogPost", []Field{{Name: "Title", Type: "string"}, ForeignKeyField("Author")})
+
+	assert.Equal(t, "app_blog_post", def.TableName())
+	assert.Equal(t, `CREATE TABLE app_blog_post (
+  id SERIAL PRIMARY KEY,
+  title VARCHAR(255) NOT NULL,
+  "authorID" INTEGER NOT NULL REFERENCES app_author(id),
+  created_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC')
+);
+`, mm.GenerateMigration(def))
+	assert.Equal(t, `json:"authorID"`, ForeignKeyField("Author").Tag)
+
+	column, err := ParsePartitionBy("range(created_at)")
+	assert.NoError(t, err)
+	assert.Equal(t, "created_at", column, "the columns inherited from DefaultModel keep their names")
+}
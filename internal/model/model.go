@@ -3,8 +3,12 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
 	"github.com/sirupsen/logrus"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -15,6 +19,10 @@ import (
 // Example usage can be found in the loadModels() method of the ModelManager struct.
 var logger = logrus.New()
 
+// Clock is the clock the DefaultModel hooks stamp records with, and the time generated migrations are
+// versioned by. Tests set it to a clock.Fake to get deterministic timestamps.
+var Clock clock.Clock = clock.Real
+
 // Model represents a basic model structure for database entities.
 // It includes the following fields:
 //   - ID: The unique identifier for the model.
@@ -77,14 +85,14 @@ func (m *DefaultModel) PrimaryKey() string {
 
 // BeforeCreate is a method that is called before a new instance of DefaultModel is created.
 // This method is executed immediately before the model is saved to the database.
-// It sets the CreatedAt and UpdatedAt fields of the model to the current time.
+// It sets the CreatedAt and UpdatedAt fields of the model to the current time in UTC.
 // The method signature should be: func (m *DefaultModel) BeforeCreate() error.
 // This method does not return any error.
 // The BeforeCreate method can be overridden in custom models to define custom behavior
 // or perform any required actions before creating a new record.
 func (m *DefaultModel) BeforeCreate() error {
-	m.CreatedAt = time.Now()
-	m.UpdatedAt = time.Now()
+	m.CreatedAt = Clock.Now().UTC()
+	m.UpdatedAt = m.CreatedAt
 	return nil
 }
 
@@ -98,12 +106,12 @@ func (m *DefaultModel) AfterCreate() error {
 }
 
 // BeforeUpdate updates the 'UpdatedAt' field of the 'DefaultModel' instance
-// with the current time.
+// with the current time in UTC.
 // It is called automatically by the ORM before updating the model in the
 // database.
 // It returns an error if any error occurs during the update process.
 func (m *DefaultModel) BeforeUpdate() error {
-	m.UpdatedAt = time.Now()
+	m.UpdatedAt = Clock.Now().UTC()
 	return nil
 }
 
@@ -139,14 +147,41 @@ func (m *DefaultModel) AfterDelete() error {
 }
 
 // Field represents a database field in a model.
+// References is the name of the model a foreign key field points to, if any.
+// Hidden fields, such as password hashes, are never serialized: the generated struct tags them json:"-" and the
+// API neither returns nor accepts them. ReadOnly fields are serialized but ignored on input.
+// Default is the SQL expression the column defaults to, such as now(). Generated is the expression of a generated
+// column, computed by the database from the other columns; generated fields are read-only.
+// Computed is the expression of a computed field, which has no column but is evaluated by every query reading the
+// model; computed fields are read-only too.
 type Field struct {
-	Name      string
-	Type      string
-	Tag       string
-	IsNull    bool
-	IsPrimary bool
+	Name       string
+	Type       string
+	Tag        string
+	IsNull     bool
+	IsPrimary  bool
+	References string `json:",omitempty"`
+	Hidden     bool   `json:",omitempty"`
+	ReadOnly   bool   `json:",omitempty"`
+	TZ         bool   `json:",omitempty"`
+	Default    string `json:",omitempty"`
+	Generated  string `json:",omitempty"`
+	Computed   string `json:",omitempty"`
 }
 
+// Field attributes, as given after the type of a field in the name:type:attribute format.
+// AttrTZ stores the instants of a time.Time field in a column with a time zone, TIMESTAMPTZ in PostgreSQL.
+// AttrDefault, AttrGenerated, and AttrComputed take an SQL expression after an equals sign, which runs to the end
+// of the spec so it may contain colons, as in "status:string:default='draft'::text".
+const (
+	AttrHidden    = "hidden"
+	AttrReadOnly  = "readonly"
+	AttrTZ        = "tz"
+	AttrDefault   = "default"
+	AttrGenerated = "generated"
+	AttrComputed  = "computed"
+)
+
 // NewField creates a new instance of the Field struct with the provided name, fieldType, tag,
 // isNull, and isPrimary values. It returns the created Field.
 func NewField(name, fieldType, tag string, isNull, isPrimary bool) Field {
@@ -159,13 +194,94 @@ func NewField(name, fieldType, tag string, isNull, isPrimary bool) Field {
 	}
 }
 
+// ColumnName returns the name of the database column that stores the field, in the naming convention of the
+// process; see naming.Current.
+func (f Field) ColumnName() string {
+	return naming.Column(f.Name)
+}
+
+// StructTag returns the tag of the field in the generated struct. Hidden fields are left out of JSON, and
+// read-only fields are tagged grayv:"readonly" for code decoding input.
+func (f Field) StructTag() string {
+	if f.Hidden {
+		return `json:"-"`
+	}
+	tag := fmt.Sprintf(`json:"%s"`, f.ColumnName())
+	if f.ReadOnly {
+		tag += ` grayv:"readonly"`
+	}
+	return tag
+}
+
+// Spec returns the field in the name:type format parsed by ParseField, followed by its attributes.
+func (f Field) Spec() string {
+	spec := f.Name + ":" + f.Type
+	if f.Hidden {
+		spec += ":" + AttrHidden
+	}
+	if f.ReadOnly && f.Generated == "" && f.Computed == "" {
+		spec += ":" + AttrReadOnly
+	}
+	if f.TZ {
+		spec += ":" + AttrTZ
+	}
+	if f.Default != "" {
+		spec += ":" + AttrDefault + "=" + f.Default
+	}
+	if f.Generated != "" {
+		spec += ":" + AttrGenerated + "=" + f.Generated
+	}
+	if f.Computed != "" {
+		spec += ":" + AttrComputed + "=" + f.Computed
+	}
+	return spec
+}
+
+// Comment returns the comment of the field in the generated struct, telling how the database fills the column,
+// or "" if it does not.
+func (f Field) Comment() string {
+	switch {
+	case f.Generated != "":
+		return "Generated by the database as " + f.Generated + "."
+	case f.Computed != "":
+		return "Computed as " + f.Computed + " when read."
+	case f.Default != "":
+		return "Defaults to " + f.Default + " in the database."
+	default:
+		return ""
+	}
+}
+
+// Writable reports whether the field is read from input, that is neither hidden nor read-only.
+func (f Field) Writable() bool {
+	return !f.Hidden && !f.ReadOnly
+}
+
 // ModelDefinition represents the definition of a model with its name, fields, and output directory.
+// ModulePath is the Go module the generated code belongs to; it is used to import the module's model package.
+// Tenant gives the table a tenant_id column, for databases that scope rows to tenants by column.
+// RLS adds a row-level security policy to the table; Owner names the model owning the rows of an RLSOwner policy.
+// IDStrategy selects how the id column inherited from DefaultModel gets its values; see KeyStrategy.
+// PartitionBy partitions the table by month on a timestamp column, as in "range(created_at)"; see SetPartitionBy.
+// View is the query of the view backing a read-only model, which then has no table, and ViewKind the kind of
+// the view; see SetView.
 type ModelDefinition struct {
-	Name      string
-	Fields    []Field
-	OutputDir string
+	Name        string
+	Fields      []Field
+	OutputDir   string
+	ModulePath  string
+	Tenant      bool
+	RLS         RLSPolicy
+	Owner       string
+	IDStrategy  IDStrategy
+	PartitionBy string
+	View        string
+	ViewKind    ViewKind
 }
 
+// TenantColumn is the column added to the tables of models whose definition has Tenant set.
+const TenantColumn = "tenant_id"
+
 // NewModelDefinition creates a new instance of ModelDefinition with the specified name and fields.
 // It returns a pointer to the newly created ModelDefinition.
 func NewModelDefinition(name string, fields []Field) *ModelDefinition {
@@ -175,12 +291,47 @@ func NewModelDefinition(name string, fields []Field) *ModelDefinition {
 	}
 }
 
+// TableName returns the name of the database table that stores the model.
+// It matches the TableName method of the code produced by GenerateModelFile, and follows the naming convention
+// of the process; see naming.Current.
+func (m *ModelDefinition) TableName() string {
+	return naming.Table(m.Name)
+}
+
+// PrimaryKey returns the column name of the model's primary key. It is the first field marked
+// as primary, or "id" if no field is.
+func (m *ModelDefinition) PrimaryKey() string {
+	for _, field := range m.Fields {
+		if field.IsPrimary {
+			return field.ColumnName()
+		}
+	}
+	return "id"
+}
+
+// Columns returns the columns of the table of m: the id, created_at, and updated_at columns of DefaultModel,
+// unless a field is the primary key, followed by the columns of the fields. Computed fields have no column.
+func (m *ModelDefinition) Columns() []string {
+	var columns []string
+	hasPrimary := false
+	for _, field := range storedFields(m.Fields) {
+		hasPrimary = hasPrimary || field.IsPrimary
+		columns = append(columns, field.ColumnName())
+	}
+	if hasPrimary {
+		return columns
+	}
+	return append([]string{"id", "created_at", "updated_at"}, columns...)
+}
+
 // ModelManager is responsible for managing model definitions. It provides functionalities to create, update, delete,
 // retrieve, and list models. It also supports field validation and generating SQL migration scripts based on a model's
 // definition. The manager uses a map to store the models, where the key is the model's name and the value is a pointer
 // to a ModelDefinition struct. The manager can save and load models from a JSON file.
+// Dialect is the database the migrations are generated for; the zero value generates them for PostgreSQL.
 type ModelManager struct {
-	models map[string]*ModelDefinition
+	models  map[string]*ModelDefinition
+	Dialect dialect.Dialect
 }
 
 // NewModelManager returns a new instance of ModelManager. It initializes the models map and loads the models from storage.
@@ -201,8 +352,12 @@ func NewModelManager() *ModelManager {
 // - fields: The fields of the model as an array of Field structs.
 //
 // Returns:
-// - error: An error if the model already exists or there is an error saving the models to the storage file.
+// - error: An error if the model or one of its fields has an invalid name, the model already exists,
+// or there is an error saving the models to the storage file.
 func (mm *ModelManager) CreateModel(name string, fields []Field) error {
+	if err := ValidateModel(name, fields); err != nil {
+		return err
+	}
 	if _, exists := mm.models[name]; exists {
 		return fmt.Errorf("model %s already exists", name)
 	}
@@ -213,11 +368,14 @@ func (mm *ModelManager) CreateModel(name string, fields []Field) error {
 
 // UpdateModel updates the fields of an existing model. It first checks if the model exists in the model manager's
 // models map. If the model does not exist, an error is returned. Otherwise, the model's fields are updated with the
-// provided fields.
+// provided fields. An error is also returned if one of the fields has an invalid name.
 func (mm *ModelManager) UpdateModel(name string, fields []Field) error {
 	if _, exists := mm.models[name]; !exists {
 		return fmt.Errorf("model %s does not exist", name)
 	}
+	if err := ValidateModel(name, fields); err != nil {
+		return err
+	}
 
 	mm.models[name] = NewModelDefinition(name, fields)
 	return nil
@@ -259,54 +417,288 @@ func (mm *ModelManager) ListModels() []string {
 }
 
 // ValidateField validates the type of a field.
-// It checks if the field type is one of the valid types: string, int, bool, time.Time, float64, []byte.
+// It checks if the field type is one of the valid types: string, int, bool, time.Time, float64, []byte,
+// vector(n) with 1 to MaxVectorDimensions dimensions, or a custom type registered by RegisterTypes, and that
+// only time.Time fields have a time zone.
 // If the field type is not valid, it returns an error indicating the invalid field type.
 func (mm *ModelManager) ValidateField(field Field) error {
-	validTypes := map[string]bool{
-		"string": true, "int": true, "bool": true, "time.Time": true,
-		"float64": true, "[]byte": true,
+	if err := validateType(field.Type); err != nil {
+		return err
 	}
+	return field.validateTZ()
+}
 
-	if !validTypes[field.Type] {
-		return fmt.Errorf("invalid field type: %s", field.Type)
+// validateTZ returns an error if f has a time zone but is not a time.Time field.
+func (f Field) validateTZ() error {
+	if f.TZ && f.Type != "time.Time" {
+		return fmt.Errorf("field %s: only time.Time fields take the %s attribute, not %s fields", f.Name, AttrTZ, f.Type)
 	}
-
 	return nil
 }
 
 // GenerateMigration generates a SQL migration statement for creating a table based on a given ModelDefinition.
 // The generated migration includes the table name, field names, data types, and any additional constraints (e.g., primary key, not null).
+// Unless a field is marked primary, the table gets the id, created_at, and updated_at columns of DefaultModel,
+// with the id column defined by the ID strategy of the model.
+// Fields that reference another model become foreign keys to that model's table.
+// If the model has Tenant set, the table also gets an indexed tenant_id column, and if it has an RLS policy,
+// row-level security is enabled with that policy.
+// A partitioned table is partitioned by range on its partition column, which joins id in the primary key as
+// PostgreSQL requires, and gets a default partition; MonthlyPartitions returns the partitions to add.
+// A view model gets its view instead of a table.
+// For SQLite and MySQL, columns get the types of that database, and row-level security and partitions are left
+// out with a comment saying so. MySQL foreign keys are table constraints, since it ignores those of columns.
 // The resulting migration statement is returned as a string.
 func (mm *ModelManager) GenerateMigration(model *ModelDefinition) string {
-	var migration strings.Builder
+	if mm.Dialect.IsSQLite() || mm.Dialect.IsMySQL() {
+		notes := unsupportedFeatures(mm.Dialect, model)
+		if model.IsView() {
+			return notes + fmt.Sprintf("CREATE VIEW %s AS\n%s;\n", mm.Dialect.Quote(model.TableName()), model.View)
+		}
+		return notes + mm.tableMigration(model, "")
+	}
+	if model.IsView() {
+		return viewMigration(model)
+	}
+	return fieldExtensions(model.Fields) + mm.tableMigration(model, model.PartitionColumn()) + rlsMigration(model)
+}
 
-	migration.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", strings.ToLower(model.Name)))
+// tableMigration returns the statements creating the table of model, partitioned by partitionColumn unless it
+// is empty, and its indexes.
+func (mm *ModelManager) tableMigration(model *ModelDefinition, partitionColumn string) string {
+	var columns []string
 
+	hasPrimary := false
 	for _, field := range model.Fields {
-		migration.WriteString(fmt.Sprintf("  %s %s", strings.ToLower(field.Name), getSQLType(field.Type)))
-		if field.IsPrimary {
-			migration.WriteString(" PRIMARY KEY")
+		hasPrimary = hasPrimary || field.IsPrimary
+	}
+	if !hasPrimary {
+		id := model.KeyStrategy().columnDefinition()
+		switch {
+		case mm.Dialect.IsSQLite():
+			id = model.KeyStrategy().sqliteIDColumn()
+		case mm.Dialect.IsMySQL():
+			id = model.KeyStrategy().mysqlIDColumn()
+		}
+		if partitionColumn != "" {
+			id = strings.Replace(id, " PRIMARY KEY", "", 1)
+		}
+		columns = append(columns, id)
+	}
+	if model.Tenant {
+		columns = append(columns, TenantColumn+" VARCHAR(63) NOT NULL")
+	}
+
+	for _, field := range storedFields(model.Fields) {
+		columns = append(columns, columnDefinition(mm.Dialect, field, field.IsNull))
+	}
+
+	if !hasPrimary {
+		timestamp := columnType(mm.Dialect, "time.Time") + " NOT NULL DEFAULT " + currentTimestamp(mm.Dialect)
+		columns = append(columns, "created_at "+timestamp, "updated_at "+timestamp)
+	}
+	if mm.Dialect.IsMySQL() {
+		for _, field := range storedFields(model.Fields) {
+			if field.References != "" {
+				columns = append(columns, mysqlForeignKey(field))
+			}
+		}
+	}
+
+	partitioning := ""
+	if partitionColumn != "" {
+		if !hasPrimary {
+			columns = append(columns, fmt.Sprintf("PRIMARY KEY (id, %s)", quote(partitionColumn)))
+		}
+		partitioning = fmt.Sprintf(" PARTITION BY RANGE (%s)", quote(partitionColumn))
+	}
+
+	table := mm.Dialect.Quote(model.TableName())
+	migration := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)%s;\n", table, strings.Join(columns, ",\n  "), partitioning)
+	if partitionColumn != "" {
+		migration += partitionMigration(model)
+	}
+	if model.Tenant {
+		migration += fmt.Sprintf("CREATE INDEX %s ON %s (%s);\n",
+			mm.Dialect.Quote(model.TableName()+"_"+TenantColumn+"_idx"), table, TenantColumn)
+	}
+	return migration
+}
+
+// columnDefinition returns the SQL definition of the column storing field in the database of d. The column is
+// NOT NULL unless nullable is set. MySQL foreign keys are left to mysqlForeignKey.
+func columnDefinition(d dialect.Dialect, field Field, nullable bool) string {
+	column := fmt.Sprintf("%s %s", d.Quote(field.ColumnName()), field.columnType(d))
+	if field.Generated != "" {
+		column += fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", field.Generated)
+	}
+	if field.IsPrimary {
+		column += " PRIMARY KEY"
+	}
+	if !nullable {
+		column += " NOT NULL"
+	}
+	if field.Default != "" {
+		column += " DEFAULT " + field.Default
+	}
+	if t, ok := lookupType(field.Type); ok {
+		column += t.check(d, field.ColumnName())
+	}
+	if field.References != "" && !d.IsMySQL() {
+		column += fmt.Sprintf(" REFERENCES %s(id)", d.Quote(NewModelDefinition(field.References, nil).TableName()))
+	}
+	return column
+}
+
+// currentTimestamp returns the default of the created_at and updated_at columns in the database of d. PostgreSQL
+// gives the current time of the session's time zone to a TIMESTAMP column, so it is converted to UTC, in which
+// CRUD writes the timestamps of DefaultModel; SQLite gives UTC, and MySQL writes times in the session's zone.
+func currentTimestamp(d dialect.Dialect) string {
+	if d.IsSQLite() || d.IsMySQL() {
+		return "CURRENT_TIMESTAMP"
+	}
+	return "(CURRENT_TIMESTAMP AT TIME ZONE 'UTC')"
+}
+
+// GenerateAlterMigration generates the SQL statements changing the table of a model from the fields in from
+// to the fields in to: columns are dropped, added, or change type or default. Added columns are nullable, so the
+// statements also work on tables that already hold rows. The expression of a generated column cannot be altered,
+// so a column whose expression changes is dropped and added again.
+// SQLite cannot change the type or default of a column, so for SQLite those changes are left as comments, and
+// generated columns are added as VIRTUAL, computed when read, since it cannot add STORED ones to a table.
+// MySQL changes the type of a column by redefining it with MODIFY COLUMN, which also sets its default.
+// Computed fields have no column, so they are left out.
+func (mm *ModelManager) GenerateAlterMigration(model *ModelDefinition, from, to []Field) string {
+	from, to = storedFields(from), storedFields(to)
+	old := make(map[string]Field, len(from))
+	for _, field := range from {
+		old[field.ColumnName()] = field
+	}
+	kept := make(map[string]bool, len(to))
+
+	sqlite, mysql := mm.Dialect.IsSQLite(), mm.Dialect.IsMySQL()
+	quote := mm.Dialect.Quote
+	table := quote(model.TableName())
+	addColumn := func(field Field) string {
+		definition := columnDefinition(mm.Dialect, field, true)
+		if sqlite && field.Generated != "" {
+			definition = strings.Replace(definition, ") STORED", ") VIRTUAL", 1)
 		}
-		if !field.IsNull {
-			migration.WriteString(" NOT NULL")
+		statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", table, definition)
+		if mysql && field.References != "" {
+			statement += fmt.Sprintf("\nALTER TABLE %s ADD %s;", table, mysqlForeignKey(field))
 		}
-		migration.WriteString(",\n")
+		return statement
+	}
+	dropColumn := "ALTER TABLE %s DROP COLUMN IF EXISTS %s;"
+	if sqlite || mysql {
+		dropColumn = "ALTER TABLE %s DROP COLUMN %s;"
 	}
 
-	migration.WriteString(");\n")
+	var statements []string
+	for _, field := range to {
+		column := field.ColumnName()
+		kept[column] = true
+		previous, ok := old[column]
+		switch {
+		case !ok:
+			statements = append(statements, addColumn(field))
+			continue
+		case previous.Generated != field.Generated:
+			statements = append(statements, fmt.Sprintf(dropColumn, table, quote(column)), addColumn(field))
+			continue
+		case sqlite && (previous.Type != field.Type || previous.TZ != field.TZ || previous.Default != field.Default):
+			statements = append(statements, fmt.Sprintf("-- SQLite cannot change the type or default of %s.%s; copy the table into a new one to change them.",
+				model.TableName(), column))
+			continue
+		case mysql && (previous.Type != field.Type || previous.TZ != field.TZ):
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;",
+				table, columnDefinition(mm.Dialect, field, field.IsNull)))
+			continue
+		case previous.Type != field.Type || previous.TZ != field.TZ:
+			sqlType := field.columnType(mm.Dialect)
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;",
+				table, quote(column), sqlType, quote(column), sqlType))
+		}
+		switch {
+		case previous.Default == field.Default:
+		case field.Default == "":
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, quote(column)))
+		default:
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, quote(column), field.Default))
+		}
+	}
+	for _, field := range from {
+		if column := field.ColumnName(); !kept[column] {
+			statements = append(statements, fmt.Sprintf(dropColumn, table, quote(column)))
+		}
+	}
 
-	return migration.String()
+	if len(statements) == 0 {
+		return "-- No changes.\n"
+	}
+	if sqlite || mysql {
+		return strings.Join(statements, "\n") + "\n"
+	}
+	return fieldExtensions(to) + strings.Join(statements, "\n") + "\n"
 }
 
-// getSQLType returns the SQL data type corresponding to a given Go type. It maps the following Go types to their SQL equivalents:
+// GenerateDownMigration generates the SQL statement undoing the migration produced by GenerateMigration.
+func (mm *ModelManager) GenerateDownMigration(model *ModelDefinition) string {
+	table := mm.Dialect.Quote(model.TableName())
+	switch {
+	case model.Materialized() && !mm.Dialect.IsSQLite() && !mm.Dialect.IsMySQL():
+		return fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s;\n", table)
+	case model.IsView():
+		return fmt.Sprintf("DROP VIEW IF EXISTS %s;\n", table)
+	}
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", table)
+}
+
+// fieldExtensions returns the statements enabling the PostgreSQL extensions the columns of fields need: pgvector
+// if any of fields is a vector field, and the extensions of their custom types.
+func fieldExtensions(fields []Field) string {
+	var extensions []string
+	if usesVector(fields) {
+		extensions = append(extensions, "vector")
+	}
+	for _, field := range fields {
+		if t, ok := lookupType(field.Type); ok && t.Extension != "" && !slices.Contains(extensions, t.Extension) {
+			extensions = append(extensions, t.Extension)
+		}
+	}
+	var b strings.Builder
+	for _, extension := range extensions {
+		fmt.Fprintf(&b, "CREATE EXTENSION IF NOT EXISTS %s;\n", quote(extension))
+	}
+	return b.String()
+}
+
+// quote quotes a table or column name for the PostgreSQL migrations, should it need quoting.
+func quote(name string) string {
+	return dialect.Postgres.Quote(name)
+}
+
+// getSQLType returns the PostgreSQL data type corresponding to a given Go type; see mysqlType and sqliteType for the
+// other databases. It maps the following Go types to their SQL equivalents:
 // - string: VARCHAR(255)
 // - int: INTEGER
 // - bool: BOOLEAN
 // - time.Time: TIMESTAMP
 // - float64: DOUBLE PRECISION
 // - []byte: BYTEA
+// - vector(n): VECTOR(n), provided by the pgvector extension
+// - decimal(p,s): NUMERIC(p,s)
 // If the given Go type does not match any of the above, it returns "VARCHAR(255)" as the default SQL type.
+// The types of custom types are chosen by columnType instead.
 func getSQLType(goType string) string {
+	if dimensions, ok := VectorDimensions(goType); ok {
+		return fmt.Sprintf("VECTOR(%d)", dimensions)
+	}
+	if precision, scale, ok := DecimalPrecision(goType); ok {
+		return fmt.Sprintf("NUMERIC(%d,%d)", precision, scale)
+	}
+
 	switch goType {
 	case "string":
 		return "VARCHAR(255)"
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// mysqlType returns the MySQL column type of a Go type, which MariaDB shares. Times are DATETIME, which unlike
+// TIMESTAMP is not converted to the time zone of the session, and vectors are stored as BLOBs since neither
+// server has pgvector.
+func mysqlType(goType string) string {
+	if _, ok := VectorDimensions(goType); ok {
+		return "BLOB"
+	}
+	if precision, scale, ok := DecimalPrecision(goType); ok {
+		return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+	}
+
+	switch goType {
+	case "int":
+		return "INT"
+	case "bool":
+		return "BOOLEAN"
+	case "time.Time":
+		return "DATETIME"
+	case "float64":
+		return "DOUBLE"
+	case "[]byte":
+		return "LONGBLOB"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+// mysqlIDColumn returns the MySQL definition of the id column of strategy s. Serial and identity ids are
+// AUTO_INCREMENT columns, and uuids are generated by the server as text, as MySQL has no UUID type.
+func (s IDStrategy) mysqlIDColumn() string {
+	switch s {
+	case IDUUID:
+		return "id CHAR(36) PRIMARY KEY DEFAULT (UUID())"
+	case IDSnowflake, IDApp:
+		return "id BIGINT PRIMARY KEY"
+	case IDIdentity:
+		return "id BIGINT AUTO_INCREMENT PRIMARY KEY"
+	default:
+		return "id INT AUTO_INCREMENT PRIMARY KEY"
+	}
+}
+
+// mysqlForeignKey returns the constraint making the column of field reference its model. MySQL parses the
+// REFERENCES clause of a column definition but ignores it, so foreign keys are declared as constraints.
+func mysqlForeignKey(field Field) string {
+	return fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(id)", dialect.MySQL.Quote(field.ColumnName()),
+		dialect.MySQL.Quote(NewModelDefinition(field.References, nil).TableName()))
+}
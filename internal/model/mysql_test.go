@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateMigration_MySQL(t *testing.T) {
+	mm := ModelManager{Dialect: dialect.MySQL}
+	def := NewModelDefinition("Order", []Field{
+		{Name: "Desc", Type: "string"},
+		{Name: "Total", Type: "float64", IsNull: true},
+		{Name: "Receipt", Type: "[]byte", IsNull: true},
+		{Name: "Paid", Type: "bool", Default: "false"},
+		{Name: "CustomerID", Type: "int", References: "Customer"},
+	})
+	assert.Equal(t, "CREATE TABLE orders (\n"+
+		"  id INT AUTO_INCREMENT PRIMARY KEY,\n"+
+		"  `desc` VARCHAR(255) NOT NULL,\n"+
+		"  total DOUBLE,\n"+
+		"  receipt LONGBLOB,\n"+
+		"  paid BOOLEAN NOT NULL DEFAULT false,\n"+
+		"  customer_id INT NOT NULL,\n"+
+		"  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,\n"+
+		"  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,\n"+
+		"  FOREIGN KEY (customer_id) REFERENCES customers(id)\n"+
+		");\n", mm.GenerateMigration(def))
+	assert.Equal(t, "DROP TABLE IF EXISTS orders;\n", mm.GenerateDownMigration(def))
+
+	def.IDStrategy = IDUUID
+	assert.Contains(t, mm.GenerateMigration(def), "id CHAR(36) PRIMARY KEY DEFAULT (UUID())")
+
+	event := NewModelDefinition("Event", []Field{{Name: "Kind", Type: "string"}})
+	event.RLS = RLSTenant
+	event.PartitionBy = "range(created_at)"
+	migration := mm.GenerateMigration(event)
+	assert.Contains(t, migration, "-- MySQL has no row-level security; the tenant policy of events is not created.\n")
+	assert.Contains(t, migration, "-- MySQL has no default partitions; events is a single table.\n")
+	assert.NotContains(t, migration, "PARTITION BY")
+}
+
+func TestGenerateAlterMigration_MySQL(t *testing.T) {
+	mm := ModelManager{Dialect: dialect.MySQL}
+	def := NewModelDefinition("Post", nil)
+	from := []Field{{Name: "Title", Type: "string"}, {Name: "Views", Type: "int"}, {Name: "Body", Type: "string"}}
+	to := []Field{
+		{Name: "Title", Type: "string", Default: "''"},
+		{Name: "Views", Type: "float64", Default: "0"},
+		{Name: "AuthorID", Type: "int", References: "User"},
+	}
+	assert.Equal(t, "ALTER TABLE posts ALTER COLUMN title SET DEFAULT '';\n"+
+		"ALTER TABLE posts MODIFY COLUMN views DOUBLE NOT NULL DEFAULT 0;\n"+
+		"ALTER TABLE posts ADD COLUMN author_id INT;\n"+
+		"ALTER TABLE posts ADD FOREIGN KEY (author_id) REFERENCES users(id);\n"+
+		"ALTER TABLE posts DROP COLUMN body;\n", mm.GenerateAlterMigration(def, from, to))
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+)
+
+// NormalizeModelName converts a model name to the Go type name used for the model, e.g. "blog-post" to "BlogPost".
+// It returns an error if the name cannot form a valid identifier or its table name is a reserved SQL word.
+func NormalizeModelName(name string) (string, error) {
+	normalized, err := normalizeIdentifier("model", name)
+	if err != nil {
+		return "", err
+	}
+	if table := naming.Table(normalized); dialect.IsReserved(table) {
+		return "", fmt.Errorf("invalid model name %q: table name %q is a reserved SQL word", name, table)
+	}
+	return normalized, nil
+}
+
+// NormalizeFieldName converts a field name to the Go field name used in generated code, e.g. "created-by" to "CreatedBy".
+// It returns an error if the name cannot form a valid identifier or its column name is a reserved SQL word.
+func NormalizeFieldName(name string) (string, error) {
+	normalized, err := normalizeIdentifier("field", name)
+	if err != nil {
+		return "", err
+	}
+	if column := naming.Column(normalized); dialect.IsReserved(column) {
+		return "", fmt.Errorf("invalid field name %q: column name %q is a reserved SQL word", name, column)
+	}
+	return normalized, nil
+}
+
+// normalizeIdentifier converts name to CamelCase and checks that the result is a usable Go identifier.
+func normalizeIdentifier(kind, name string) (string, error) {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != ' ' {
+			return "", fmt.Errorf("invalid %s name %q: only letters, digits, spaces, hyphens, and underscores are allowed", kind, name)
+		}
+	}
+
+	normalized := naming.ToCamel(name)
+	switch {
+	case normalized == "":
+		return "", fmt.Errorf("invalid %s name %q: name is empty", kind, name)
+	case unicode.IsDigit([]rune(normalized)[0]):
+		return "", fmt.Errorf("invalid %s name %q: name must start with a letter", kind, name)
+	case token.IsKeyword(strings.ToLower(normalized)):
+		return "", fmt.Errorf("invalid %s name %q: %q is a Go keyword", kind, name, strings.ToLower(normalized))
+	}
+	return normalized, nil
+}
+
+// reservedColumns are the columns every generated model inherits from DefaultModel. They are snake_case whatever
+// the naming convention.
+var reservedColumns = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// ValidateModel checks the names of a model and its fields, reporting the first problem found:
+// an invalid name, a field that redefines a column inherited from DefaultModel, or two fields that
+// map to the same column.
+func ValidateModel(name string, fields []Field) error {
+	if _, err := NormalizeModelName(name); err != nil {
+		return err
+	}
+
+	columns := make(map[string]string)
+	for _, field := range fields {
+		if _, err := NormalizeFieldName(field.Name); err != nil {
+			return err
+		}
+
+		if column := naming.ToSnake(field.Name); reservedColumns[column] {
+			return fmt.Errorf("field %q conflicts with the %s column every model inherits from DefaultModel; remove it", field.Name, column)
+		}
+		column := naming.Column(field.Name)
+		if other, ok := columns[column]; ok {
+			return fmt.Errorf("fields %q and %q both map to the column %s", other, field.Name, column)
+		}
+		columns[column] = field.Name
+	}
+	return nil
+}
+
+// columnOf returns the column named by name, which is a column inherited from DefaultModel or the field or column
+// of a field, such as PublishedAt or published_at.
+func columnOf(name string) string {
+	if column := naming.ToSnake(name); reservedColumns[column] {
+		return column
+	}
+	return naming.Column(name)
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeModelName(t *testing.T) {
+	name, err := NormalizeModelName("blog-post")
+	assert.NoError(t, err)
+	assert.Equal(t, "BlogPost", name)
+
+	for _, invalid := range []string{"", "---", "2fast", "func", "blog.post", "drop table;"} {
+		_, err := NormalizeModelName(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNormalizeFieldName(t *testing.T) {
+	name, err := NormalizeFieldName("created_by")
+	assert.NoError(t, err)
+	assert.Equal(t, "CreatedBy", name)
+
+	_, err = NormalizeFieldName("order")
+	assert.ErrorContains(t, err, "reserved SQL word")
+}
+
+func TestValidateModel_Conflicts(t *testing.T) {
+	assert.NoError(t, ValidateModel("Post", []Field{{Name: "Title"}, {Name: "AuthorID"}}))
+
+	err := ValidateModel("Post", []Field{{Name: "CreatedAt"}})
+	assert.ErrorContains(t, err, "created_at")
+
+	err = ValidateModel("Post", []Field{{Name: "ID"}})
+	assert.ErrorContains(t, err, "DefaultModel")
+
+	err = ValidateModel("Post", []Field{{Name: "userName"}, {Name: "user_name"}})
+	assert.ErrorContains(t, err, "both map to the column user_name")
+}
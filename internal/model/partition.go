@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultPartitionSuffix names the partition of a partitioned table that holds the rows no monthly partition covers.
+const DefaultPartitionSuffix = "_default"
+
+// ParsePartitionBy parses the partitioning of a model's table, "range(column)", and returns the column. Tables are
+// partitioned by range only, one calendar month per partition; see MonthlyPartitions.
+func ParsePartitionBy(spec string) (string, error) {
+	method, rest, ok := strings.Cut(strings.TrimSpace(spec), "(")
+	column, closed := strings.CutSuffix(strings.TrimSpace(rest), ")")
+	if !ok || !closed || !strings.EqualFold(strings.TrimSpace(method), "range") || strings.TrimSpace(column) == "" {
+		return "", fmt.Errorf("invalid partitioning %q: use range(column), such as range(created_at)", spec)
+	}
+	return columnOf(strings.TrimSpace(column)), nil
+}
+
+// SetPartitionBy partitions the table of m as described by spec, as accepted by ParsePartitionBy. The column must
+// be created_at, updated_at, or a time.Time field that is not generated. An empty spec leaves the table unpartitioned.
+func (m *ModelDefinition) SetPartitionBy(spec string) error {
+	if spec == "" {
+		m.PartitionBy = ""
+		return nil
+	}
+	column, err := ParsePartitionBy(spec)
+	if err != nil {
+		return fmt.Errorf("model %s: %w", m.Name, err)
+	}
+	if m.KeyStrategy() == IDIdentity {
+		return fmt.Errorf("model %s: identity columns cannot be used in partitioned tables; use the serial, uuid, or snowflake ID strategy", m.Name)
+	}
+
+	found := column == "created_at" || column == "updated_at"
+	for _, field := range m.Fields {
+		switch {
+		case field.IsPrimary:
+			return fmt.Errorf("model %s: the primary key of a partitioned table is id and the partition column", m.Name)
+		case field.ColumnName() != column:
+		case field.Type != "time.Time" || field.Generated != "" || field.Computed != "":
+			return fmt.Errorf("model %s: partition column %s must be a time.Time field that is not generated or computed", m.Name, column)
+		default:
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("model %s has no column %s to partition by", m.Name, column)
+	}
+	m.PartitionBy = fmt.Sprintf("range(%s)", column)
+	return nil
+}
+
+// PartitionColumn returns the column the table of m is partitioned by, or "" if it is not partitioned.
+func (m *ModelDefinition) PartitionColumn() string {
+	column, err := ParsePartitionBy(m.PartitionBy)
+	if err != nil {
+		return ""
+	}
+	return column
+}
+
+// partitionMigration returns the statement creating the default partition of the table of m, which keeps inserts
+// working for months without a partition, or "" if m is not partitioned.
+func partitionMigration(m *ModelDefinition) string {
+	if m.PartitionColumn() == "" {
+		return ""
+	}
+	return fmt.Sprintf("CREATE TABLE %s PARTITION OF %s DEFAULT;\n",
+		quote(m.TableName()+DefaultPartitionSuffix), quote(m.TableName()))
+}
+
+// Partition is a partition of the table of a model holding the rows whose partition column is in [From, To).
+type Partition struct {
+	Table  string    `json:"table"`
+	Parent string    `json:"parent"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// Statement returns the statement creating p, unless it exists.
+func (p Partition) Statement() string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s');",
+		quote(p.Table), quote(p.Parent), p.From.Format(time.DateOnly), p.To.Format(time.DateOnly))
+}
+
+// MonthlyPartitions returns the partitions of the table of m for every calendar month from the month of from
+// through the month of through, named after the table and the month, as in events_2025_01. It returns none if m
+// is not partitioned or through is before from.
+func MonthlyPartitions(m *ModelDefinition, from, through time.Time) []Partition {
+	if m.PartitionColumn() == "" {
+		return nil
+	}
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(through.Year(), through.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var partitions []Partition
+	for ; !month.After(last); month = month.AddDate(0, 1, 0) {
+		partitions = append(partitions, Partition{
+			Table:  fmt.Sprintf("%s_%04d_%02d", m.TableName(), month.Year(), month.Month()),
+			Parent: m.TableName(),
+			From:   month,
+			To:     month.AddDate(0, 1, 0),
+		})
+	}
+	return partitions
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePartitionBy(t *testing.T) {
+	column, err := ParsePartitionBy("range(created_at)")
+	assert.NoError(t, err)
+	assert.Equal(t, "created_at", column)
+
+	column, err = ParsePartitionBy(" RANGE ( OccurredAt ) ")
+	assert.NoError(t, err)
+	assert.Equal(t, "occurred_at", column)
+
+	for _, spec := range []string{"", "created_at", "range()", "range(created_at", "hash(id)"} {
+		_, err := ParsePartitionBy(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMonthlyPartitions(t *testing.T) {
+	def := &ModelDefinition{Name: "Event"}
+	from := time.Date(2024, 11, 17, 8, 0, 0, 0, time.UTC)
+	through := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Empty(t, MonthlyPartitions(def, from, through), "the table is not partitioned")
+
+	assert.NoError(t, def.SetPartitionBy("range(created_at)"))
+	partitions := MonthlyPartitions(def, from, through)
+	if !assert.Len(t, partitions, 3) {
+		return
+	}
+	assert.Equal(t, "events_2024_11", partitions[0].Table)
+	assert.Equal(t, "events_2025_01", partitions[2].Table)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS events_2024_12 PARTITION OF events "+
+		"FOR VALUES FROM ('2024-12-01') TO ('2025-01-01');", partitions[1].Statement())
+
+	assert.Empty(t, MonthlyPartitions(def, through, from))
+}
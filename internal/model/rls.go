@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RLSPolicy selects the PostgreSQL row-level security policy generated for a model's table.
+type RLSPolicy string
+
+const (
+	// RLSNone generates no policy.
+	RLSNone RLSPolicy = ""
+	// RLSTenant limits rows to the tenant in the TenantSetting session variable. The table gets a tenant_id column.
+	RLSTenant RLSPolicy = "tenant"
+	// RLSOwner limits rows to the user in the UserSetting session variable, matched against the
+	// foreign key to the model's Owner.
+	RLSOwner RLSPolicy = "owner"
+)
+
+// Session variables read by the generated policies. Set them per transaction with set_config(name, value, true).
+const (
+	TenantSetting = "grayv.tenant_id"
+	UserSetting   = "grayv.user_id"
+)
+
+// DefaultOwner is the model owning the rows of a model with an RLSOwner policy if none is given.
+const DefaultOwner = "User"
+
+// ParseRLSPolicy returns the RLSPolicy named by policy: "", "tenant", or "owner".
+func ParseRLSPolicy(policy string) (RLSPolicy, error) {
+	switch RLSPolicy(policy) {
+	case RLSNone, RLSTenant, RLSOwner:
+		return RLSPolicy(policy), nil
+	default:
+		return RLSNone, fmt.Errorf("unknown row-level security policy %q: use tenant or owner", policy)
+	}
+}
+
+// setRLS sets the row-level security policy of m from the rls and owner options of a manifest.
+// A tenant policy needs the tenant_id column, so it sets Tenant too.
+func (m *ModelDefinition) setRLS(rls, owner string) error {
+	policy, err := ParseRLSPolicy(rls)
+	if err != nil {
+		return fmt.Errorf("model %s: %w", m.Name, err)
+	}
+	m.RLS = policy
+	if owner != "" {
+		if policy != RLSOwner {
+			return fmt.Errorf("model %s: owner is only used with rls: owner", m.Name)
+		}
+		if m.Owner, err = NormalizeModelName(owner); err != nil {
+			return fmt.Errorf("model %s: %w", m.Name, err)
+		}
+	}
+	switch policy {
+	case RLSTenant:
+		m.Tenant = true
+	case RLSOwner:
+		_, err = m.ownerColumn()
+	}
+	return err
+}
+
+// ownerColumn returns the column of m holding the ID of the user owning a row, or an error if m has no
+// foreign key to its owner model.
+func (m *ModelDefinition) ownerColumn() (string, error) {
+	owner := m.Owner
+	if owner == "" {
+		owner = DefaultOwner
+	}
+	for _, field := range m.Fields {
+		if field.References == owner {
+			return field.ColumnName(), nil
+		}
+	}
+	return "", fmt.Errorf("model %s has an owner policy but does not belong to %s", m.Name, owner)
+}
+
+// rlsMigration returns the statements enabling row-level security on the table of m and creating its policy,
+// or "" if m has no policy. FORCE applies the policy to the table owner too; superusers and roles with
+// BYPASSRLS still see every row.
+func rlsMigration(m *ModelDefinition) string {
+	var condition string
+	switch m.RLS {
+	case RLSTenant:
+		condition = fmt.Sprintf("%s = current_setting('%s', true)", TenantColumn, TenantSetting)
+	case RLSOwner:
+		column, err := m.ownerColumn()
+		if err != nil {
+			// Manifest definitions are checked by setRLS, so this only guards against hand-made ones.
+			return fmt.Sprintf("-- %s\n", err)
+		}
+		condition = fmt.Sprintf("%s = NULLIF(current_setting('%s', true), '')::integer", quote(column), UserSetting)
+	default:
+		return ""
+	}
+
+	table := quote(m.TableName())
+	statements := []string{
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY;", table),
+		fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY;", table),
+		fmt.Sprintf("CREATE POLICY %s ON %s\n  USING (%s)\n  WITH CHECK (%s);",
+			quote(m.TableName()+"_"+string(m.RLS)+"_policy"), table, condition, condition),
+	}
+	return strings.Join(statements, "\n") + "\n"
+}
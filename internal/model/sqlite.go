@@ -0,0 +1,96 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// sqliteType returns the SQLite column type of a Go type. SQLite stores values by type affinity, so lengths
+// and precisions are left out, and vectors are stored as BLOBs since there is no vector extension. Decimals are
+// stored as TEXT, as columns of NUMERIC affinity would round them to floating point numbers.
+func sqliteType(goType string) string {
+	if _, ok := VectorDimensions(goType); ok {
+		return "BLOB"
+	}
+	if _, _, ok := DecimalPrecision(goType); ok {
+		return "TEXT"
+	}
+
+	switch goType {
+	case "int":
+		return "INTEGER"
+	case "bool":
+		return "BOOLEAN"
+	case "time.Time":
+		return "DATETIME"
+	case "float64":
+		return "REAL"
+	case "[]byte":
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+// columnType returns the type of the column storing a field of goType in the database of d. Custom types have
+// the column types they are registered with.
+func columnType(d dialect.Dialect, goType string) string {
+	if t, ok := lookupType(goType); ok {
+		return t.columnType(d)
+	}
+	switch {
+	case d.IsSQLite():
+		return sqliteType(goType)
+	case d.IsMySQL():
+		return mysqlType(goType)
+	}
+	return getSQLType(goType)
+}
+
+// columnType returns the type of the column storing f in the database of d: that of its type, except for fields
+// with a time zone, which are TIMESTAMPTZ in PostgreSQL and TIMESTAMP in MySQL. SQLite has no such type, and
+// its times are stored in UTC either way.
+func (f Field) columnType(d dialect.Dialect) string {
+	switch {
+	case !f.TZ || d.IsSQLite():
+		return columnType(d, f.Type)
+	case d.IsMySQL():
+		return "TIMESTAMP"
+	}
+	return "TIMESTAMPTZ"
+}
+
+// sqliteIDColumn returns the SQLite definition of the id column of strategy s. Serial and identity ids are
+// the rowid of the table, and uuids are 32 random hex digits, as SQLite has no UUID type.
+func (s IDStrategy) sqliteIDColumn() string {
+	switch s {
+	case IDUUID:
+		return "id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16))))"
+	case IDSnowflake, IDApp:
+		return "id INTEGER PRIMARY KEY"
+	default:
+		return "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+}
+
+// unsupportedFeatures returns the comments noting the features of m that the database of d, SQLite or MySQL,
+// lacks and the migration leaves out: row-level security and partitions. Materialized views become plain views.
+// MySQL does partition tables, but has no default partition for the months without one.
+func unsupportedFeatures(d dialect.Dialect, m *ModelDefinition) string {
+	database, partitions := "SQLite", "-- SQLite has no partitions; %s is a single table.\n"
+	if d.IsMySQL() {
+		database, partitions = "MySQL", "-- MySQL has no default partitions; %s is a single table.\n"
+	}
+	var notes string
+	if m.RLS != RLSNone {
+		notes += fmt.Sprintf("-- %s has no row-level security; the %s policy of %s is not created.\n", database, m.RLS, m.TableName())
+	}
+	if m.PartitionBy != "" {
+		notes += fmt.Sprintf(partitions, m.TableName())
+	}
+	if m.Materialized() {
+		notes += fmt.Sprintf("-- %s has no materialized views; %s is a plain view.\n", database, m.TableName())
+	}
+	return notes
+}
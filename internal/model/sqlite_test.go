@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateMigration_SQLite(t *testing.T) {
+	mm := ModelManager{Dialect: dialect.SQLite}
+	def := NewModelDefinition("Post", []Field{
+		{Name: "Title", Type: "string"},
+		{Name: "Score", Type: "float64", IsNull: true},
+		{Name: "Cover", Type: "[]byte", IsNull: true},
+		{Name: "Published", Type: "bool", Default: "false"},
+		{Name: "AuthorID", Type: "int", References: "User"},
+	})
+	assert.Equal(t, `CREATE TABLE posts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  title TEXT NOT NULL,
+  score REAL,
+  cover BLOB,
+  published BOOLEAN NOT NULL DEFAULT false,
+  author_id INTEGER NOT NULL REFERENCES users(id),
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`, mm.GenerateMigration(def))
+
+	def.IDStrategy = IDUUID
+	assert.Contains(t, mm.GenerateMigration(def), "id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16))))")
+
+	event := NewModelDefinition("Event", []Field{{Name: "Kind", Type: "string"}})
+	event.RLS = RLSTenant
+	event.PartitionBy = "range(created_at)"
+	migration := mm.GenerateMigration(event)
+	assert.Contains(t, migration, "-- SQLite has no row-level security; the tenant policy of events is not created.\n")
+	assert.Contains(t, migration, "-- SQLite has no partitions; events is a single table.\n")
+	assert.NotContains(t, migration, "PARTITION")
+	assert.NotContains(t, migration, "POLICY")
+}
+
+func TestGenerateMigration_SQLiteView(t *testing.T) {
+	mm := ModelManager{Dialect: dialect.SQLite}
+	def := NewModelDefinition("AuthorStat", []Field{{Name: "Posts", Type: "int"}})
+	assert.NoError(t, def.SetView("SELECT author_id AS id, COUNT(*) AS posts FROM posts GROUP BY author_id", ""))
+	assert.Equal(t, "-- SQLite has no materialized views; author_stats is a plain view.\n"+
+		"CREATE VIEW author_stats AS\nSELECT author_id AS id, COUNT(*) AS posts FROM posts GROUP BY author_id;\n",
+		mm.GenerateMigration(def))
+	assert.Equal(t, "DROP VIEW IF EXISTS author_stats;\n", mm.GenerateDownMigration(def))
+}
+
+func TestGenerateAlterMigration_SQLite(t *testing.T) {
+	mm := ModelManager{Dialect: dialect.SQLite}
+	def := NewModelDefinition("Post", nil)
+	from := []Field{{Name: "Title", Type: "string"}, {Name: "Views", Type: "int"}, {Name: "Body", Type: "string"}}
+	to := []Field{
+		{Name: "Title", Type: "string"},
+		{Name: "Views", Type: "float64"},
+		{Name: "Slug", Type: "string", Generated: "lower(title)"},
+	}
+	assert.Equal(t, "-- SQLite cannot change the type or default of posts.views; copy the table into a new one to change them.\n"+
+		"ALTER TABLE posts ADD COLUMN slug TEXT GENERATED ALWAYS AS (lower(title)) VIRTUAL;\n"+
+		"ALTER TABLE posts DROP COLUMN body;\n", mm.GenerateAlterMigration(def, from, to))
+}
@@ -0,0 +1,227 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"gopkg.in/yaml.v3"
+)
+
+// TypesFile is the file of the workspace registering custom field types, read by LoadTypes.
+const TypesFile = "types.yaml"
+
+// builtinTypes are the field types every workspace has, besides vector(n).
+var builtinTypes = map[string]bool{
+	"string": true, "int": true, "bool": true, "time.Time": true, "float64": true, "[]byte": true,
+}
+
+// CustomType is a field type registered in TypesFile, such as money, citext, or inet, which fields use like the
+// built-in types, as in "price:money".
+type CustomType struct {
+	// Name is the type of the fields, lowercase letters, digits, and underscores.
+	Name string `yaml:"name"`
+	// Go is the Go type of the fields in generated code, such as int64 or netip.Addr, and Import the package it
+	// needs, such as net/netip.
+	Go     string `yaml:"go"`
+	Import string `yaml:"import"`
+	// SQL is the column type by database: postgres, mysql, and sqlite. A database left out gets the column type of
+	// the Go type, or of string if it is not a built-in type.
+	SQL map[string]string `yaml:"sql"`
+	// Extension is the PostgreSQL extension providing the column type, such as citext, created by the migrations.
+	Extension string `yaml:"extension"`
+	// Zero is the Go literal of a value used as test data, such as 0. Empty means the zero value of the Go type.
+	Zero string `yaml:"zero"`
+	// Check is the SQL condition every value must meet, with {column} standing for the column, such as
+	// "{column} >= 0". It becomes a CHECK constraint of the column.
+	Check string `yaml:"check"`
+}
+
+// typesFile is the format of TypesFile.
+type typesFile struct {
+	Types []CustomType `yaml:"types"`
+}
+
+// typeName is the format of the names of custom types.
+var typeName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// customTypes holds the custom types registered by RegisterTypes, by name.
+var customTypes atomic.Pointer[map[string]CustomType]
+
+// LoadTypes reads the custom types registered in the file at path, usually TypesFile. A missing file
+// registers none.
+func LoadTypes(path string) ([]CustomType, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading custom types: %w", err)
+	}
+	var file typesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing custom types %s: %w", path, err)
+	}
+	return file.Types, nil
+}
+
+// RegisterTypes makes types the custom types of the process, replacing those registered before, after
+// validating them. The grayv-lsm commands register the types of TypesFile before they run.
+func RegisterTypes(types []CustomType) error {
+	registered := make(map[string]CustomType, len(types))
+	for _, t := range types {
+		if err := t.validate(); err != nil {
+			return err
+		}
+		if _, ok := registered[t.Name]; ok {
+			return fmt.Errorf("custom type %s is registered more than once", t.Name)
+		}
+		registered[t.Name] = t
+	}
+	customTypes.Store(&registered)
+	return nil
+}
+
+// CustomTypes returns the registered custom types, ordered by name.
+func CustomTypes() []CustomType {
+	var types []CustomType
+	if registered := customTypes.Load(); registered != nil {
+		for _, t := range *registered {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// lookupType returns the custom type named name, if it is registered.
+func lookupType(name string) (CustomType, bool) {
+	registered := customTypes.Load()
+	if registered == nil {
+		return CustomType{}, false
+	}
+	t, ok := (*registered)[name]
+	return t, ok
+}
+
+// validate returns an error if t has an invalid name or Go type, or a column type for an unknown database.
+func (t CustomType) validate() error {
+	switch {
+	case !typeName.MatchString(t.Name):
+		return fmt.Errorf("invalid custom type name %q: use lowercase letters, digits, and underscores", t.Name)
+	case builtinTypes[t.Name]:
+		return fmt.Errorf("custom type %s would replace a built-in type", t.Name)
+	case t.Go == "":
+		return fmt.Errorf("custom type %s needs the Go type of its fields", t.Name)
+	}
+	if _, err := parser.ParseExpr(t.Go); err != nil {
+		return fmt.Errorf("custom type %s: invalid Go type %q", t.Name, t.Go)
+	}
+	if t.Import != "" && !strings.Contains(t.Go, ".") {
+		return fmt.Errorf("custom type %s imports %s, but its Go type %s is not from a package", t.Name, t.Import, t.Go)
+	}
+	for database := range t.SQL {
+		if database != dialect.Postgres.Name && database != dialect.MySQL.Name && database != dialect.SQLite.Name {
+			return fmt.Errorf("custom type %s: unknown database %q: use postgres, mysql, or sqlite", t.Name, database)
+		}
+	}
+	if t.Check != "" && !strings.Contains(t.Check, "{column}") {
+		return fmt.Errorf("custom type %s: the check %q does not mention {column}", t.Name, t.Check)
+	}
+	return nil
+}
+
+// columnType returns the type of the column storing the values of t in the database of d.
+func (t CustomType) columnType(d dialect.Dialect) string {
+	name := d.Name
+	if name == "" {
+		name = dialect.Postgres.Name
+	}
+	if sqlType := t.SQL[name]; sqlType != "" {
+		return sqlType
+	}
+	return columnType(d, BaseType(t.Name))
+}
+
+// check returns the CHECK constraint of a column of t named column, quoted for d, or "" if t has none.
+func (t CustomType) check(d dialect.Dialect, column string) string {
+	if t.Check == "" {
+		return ""
+	}
+	return fmt.Sprintf(" CHECK (%s)", strings.ReplaceAll(t.Check, "{column}", d.Quote(column)))
+}
+
+// sample returns the Go literal of the test data of t.
+func (t CustomType) sample() string {
+	if t.Zero != "" {
+		return t.Zero
+	}
+	return "*new(" + t.Go + ")"
+}
+
+// qualifier returns the name the package of the Go type of t is referred to by, such as netip for netip.Addr.
+func (t CustomType) qualifier() string {
+	qualifier, _, _ := strings.Cut(strings.TrimLeft(t.Go, "[]*"), ".")
+	return qualifier
+}
+
+// customImports returns the packages the Go types of the custom types of fields are imported from, in order.
+// With sampled set, only the packages the test data of the types refers to are returned.
+func customImports(fields []Field, sampled bool) []string {
+	var imports []string
+	for _, f := range fields {
+		t, ok := lookupType(f.Type)
+		switch {
+		case !ok || t.Import == "" || slices.Contains(imports, t.Import):
+		case sampled && !strings.Contains(t.sample(), t.qualifier()+"."):
+		default:
+			imports = append(imports, t.Import)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// validateType returns an error if fieldType is not a built-in type, a vector with 1 to MaxVectorDimensions
+// dimensions, a decimal of valid precision and scale, or a registered custom type.
+func validateType(fieldType string) error {
+	if dimensions, ok := VectorDimensions(fieldType); ok {
+		if dimensions < 1 || dimensions > MaxVectorDimensions {
+			return fmt.Errorf("invalid field type: %s: a vector has 1 to %d dimensions", fieldType, MaxVectorDimensions)
+		}
+		return nil
+	}
+	if precision, scale, ok := DecimalPrecision(fieldType); ok {
+		return validateDecimal(fieldType, precision, scale)
+	}
+	if _, ok := lookupType(fieldType); !builtinTypes[fieldType] && !ok {
+		return fmt.Errorf("invalid field type: %s: register custom types in %s", fieldType, TypesFile)
+	}
+	return nil
+}
+
+// BaseType returns the built-in type the values of a field type are read and written as, in JSON, seeds, and
+// API clients: the type itself for built-in types, and for custom types, int, float64, bool, time.Time, or []byte
+// if their Go type is a number, a boolean, a time, or bytes, and string otherwise.
+func BaseType(fieldType string) string {
+	t, ok := lookupType(fieldType)
+	if !ok {
+		return fieldType
+	}
+	switch t.Go {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "int"
+	case "float32", "float64":
+		return "float64"
+	case "bool", "time.Time", "[]byte":
+		return t.Go
+	}
+	return "string"
+}
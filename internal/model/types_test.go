@@ -0,0 +1,121 @@
+package model
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+// registerTestTypes registers money, citext, and inet for the duration of the test.
+func registerTestTypes(t *testing.T) {
+	t.Cleanup(func() { RegisterTypes(nil) })
+	assert.NoError(t, RegisterTypes([]CustomType{
+		{Name: "money", Go: "int64", SQL: map[string]string{"postgres": "BIGINT"}, Check: "{column} >= 0"},
+		{Name: "citext", Go: "string", SQL: map[string]string{"postgres": "CITEXT"}, Extension: "citext"},
+		{Name: "inet", Go: "netip.Addr", Import: "net/netip", SQL: map[string]string{"postgres": "INET", "mysql": "VARCHAR(45)"},
+			Zero: `netip.MustParseAddr("127.0.0.1")`},
+	}))
+}
+
+func TestLoadTypes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TypesFile)
+	types, err := LoadTypes(path)
+	assert.NoError(t, err)
+	assert.Empty(t, types, "a missing file registers no types")
+
+	assert.NoError(t, os.WriteFile(path, []byte(`types:
+  - name: money
+    go: int64
+    sql: {postgres: BIGINT}
+    check: "{column} >= 0"
+`), 0644))
+	types, err = LoadTypes(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []CustomType{{Name: "money", Go: "int64", SQL: map[string]string{"postgres": "BIGINT"},
+		Check: "{column} >= 0"}}, types)
+
+	assert.NoError(t, os.WriteFile(path, []byte("types: [\n"), 0644))
+	_, err = LoadTypes(path)
+	assert.Error(t, err)
+}
+
+func TestRegisterTypes_Validates(t *testing.T) {
+	t.Cleanup(func() { RegisterTypes(nil) })
+	for _, types := range [][]CustomType{
+		{{Name: "Money", Go: "int64"}},
+		{{Name: "string", Go: "string"}},
+		{{Name: "money"}},
+		{{Name: "money", Go: "int64 +"}},
+		{{Name: "money", Go: "int64", Import: "math/big"}},
+		{{Name: "money", Go: "int64", SQL: map[string]string{"oracle": "NUMBER"}}},
+		{{Name: "money", Go: "int64", Check: "value >= 0"}},
+		{{Name: "money", Go: "int64"}, {Name: "money", Go: "int32"}},
+	} {
+		assert.Error(t, RegisterTypes(types), "%+v", types)
+	}
+	assert.Empty(t, CustomTypes(), "invalid types are not registered")
+}
+
+func TestCustomTypes_Migration(t *testing.T) {
+	registerTestTypes(t)
+	def := NewModelDefinition("Account", []Field{
+		{Name: "Balance", Type: "money"},
+		{Name: "Email", Type: "citext"},
+		{Name: "LastIP", Type: "inet", IsNull: true},
+	})
+
+	migration := NewModelManager().GenerateMigration(def)
+	assert.Contains(t, migration, "CREATE EXTENSION IF NOT EXISTS citext;\n")
+	assert.Contains(t, migration, "  balance BIGINT NOT NULL CHECK (balance >= 0),\n")
+	assert.Contains(t, migration, "  email CITEXT NOT NULL,\n")
+	assert.Contains(t, migration, "  last_ip INET,\n")
+
+	mysql := (&ModelManager{Dialect: dialect.MySQL}).GenerateMigration(def)
+	assert.NotContains(t, mysql, "EXTENSION")
+	assert.Contains(t, mysql, "  balance INT NOT NULL CHECK (balance >= 0),\n", "the Go type picks the column type")
+	assert.Contains(t, mysql, "  email VARCHAR(255) NOT NULL,\n")
+	assert.Contains(t, mysql, "  last_ip VARCHAR(45),\n")
+}
+
+func TestCustomTypes_ParseField(t *testing.T) {
+	_, err := ParseField("Balance:money")
+	assert.Error(t, err, "types are only known once registered")
+
+	registerTestTypes(t)
+	field, err := ParseField("Balance:money")
+	assert.NoError(t, err)
+	assert.Equal(t, "money", field.Type)
+	assert.Equal(t, "int", BaseType("money"))
+	assert.Equal(t, "string", BaseType("inet"))
+	assert.Equal(t, "float64", BaseType("float64"))
+}
+
+func TestCustomTypes_GenerateModelFile(t *testing.T) {
+	registerTestTypes(t)
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:       "Account",
+		Fields:     []Field{{Name: "Balance", Type: "money"}, {Name: "LastIP", Type: "inet"}},
+		OutputDir:  dir,
+		ModulePath: "example.com/shop",
+	}
+	assert.NoError(t, GenerateModelFile(def, nil))
+	assert.NoError(t, GenerateModelTestFile(def, nil))
+
+	for _, name := range []string{"account.go", "account_test.go"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"net/netip"`, name)
+		formatted, err := format.Source(content)
+		assert.NoError(t, err)
+		assert.Equal(t, string(formatted), string(content), "%s should be gofmt-clean", name)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "account.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `Balance\s+int64`, string(content))
+	assert.Regexp(t, `LastIP\s+netip.Addr`, string(content))
+}
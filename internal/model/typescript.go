@@ -0,0 +1,96 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tsProperty is a property of the TypeScript interface of a model.
+type tsProperty struct {
+	name     string
+	tsType   string
+	zodType  string
+	comment  string
+	readonly bool
+}
+
+// GenerateTypeScript returns a TypeScript module exporting an interface for each model of defs, with the
+// properties of the JSON encoding of the generated struct. With zod set, the module also exports a zod schema
+// of each model, named after the model with a Schema suffix and typed by its interface so they cannot drift apart.
+func GenerateTypeScript(defs []*ModelDefinition, zod bool) string {
+	var b strings.Builder
+	if zod {
+		b.WriteString("import { z } from \"zod\";\n")
+	}
+	for _, def := range defs {
+		properties := tsProperties(def)
+
+		fmt.Fprintf(&b, "\nexport interface %s {\n", def.Name)
+		for _, p := range properties {
+			if p.comment != "" {
+				fmt.Fprintf(&b, "  /** %s */\n", p.comment)
+			}
+			if p.readonly {
+				fmt.Fprintf(&b, "  readonly %s: %s;\n", p.name, p.tsType)
+			} else {
+				fmt.Fprintf(&b, "  %s: %s;\n", p.name, p.tsType)
+			}
+		}
+		b.WriteString("}\n")
+
+		if zod {
+			fmt.Fprintf(&b, "\nexport const %sSchema: z.ZodType<%s> = z.object({\n", def.Name, def.Name)
+			for _, p := range properties {
+				fmt.Fprintf(&b, "  %s: %s,\n", p.name, p.zodType)
+			}
+			b.WriteString("});\n")
+		}
+	}
+	return b.String()
+}
+
+// tsProperties returns the properties of the interface of def: the id, created_at, and updated_at of
+// DefaultModel, the id being a string with the uuid ID strategy, then the fields of def that are not hidden. Read-only fields are readonly properties.
+func tsProperties(def *ModelDefinition) []tsProperty {
+	timestamp := "z.string().datetime({ offset: true })"
+	properties := []tsProperty{
+		{name: "id", tsType: "number", zodType: "z.number().int()"},
+		{name: "created_at", tsType: "string", zodType: timestamp},
+		{name: "updated_at", tsType: "string", zodType: timestamp},
+	}
+	if def.KeyStrategy() == IDUUID {
+		properties[0].tsType, properties[0].zodType = "string", "z.string().uuid()"
+	}
+	for _, field := range def.Fields {
+		if field.Hidden {
+			continue
+		}
+		p := tsProperty{name: field.ColumnName(), readonly: field.ReadOnly}
+		if dimensions, ok := VectorDimensions(field.Type); ok {
+			p.tsType, p.zodType = "number[]", fmt.Sprintf("z.array(z.number()).length(%d)", dimensions)
+		} else if _, _, ok := DecimalPrecision(field.Type); ok {
+			p.tsType, p.zodType = "string", fmt.Sprintf("z.string().regex(/%s/)", decimalPattern(field.Type))
+			p.comment = "Exact decimal number, such as 1.50."
+		} else {
+			switch BaseType(field.Type) {
+			case "int":
+				p.tsType, p.zodType = "number", "z.number().int()"
+			case "float64":
+				p.tsType, p.zodType = "number", "z.number()"
+			case "bool":
+				p.tsType, p.zodType = "boolean", "z.boolean()"
+			case "time.Time":
+				p.tsType, p.zodType = "string", timestamp
+			case "[]byte":
+				p.tsType, p.zodType, p.comment = "string", "z.string()", "Base64-encoded bytes."
+			default:
+				p.tsType, p.zodType = "string", "z.string()"
+			}
+		}
+		if field.References != "" {
+			p.comment = "ID of the referenced " + field.References + "."
+		}
+		properties = append(properties, p)
+	}
+	return properties
+}
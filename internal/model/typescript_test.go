@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	defs := []*ModelDefinition{
+		NewModelDefinition("Author", []Field{{Name: "Name", Type: "string"}}),
+		NewModelDefinition("BlogPost", []Field{
+			{Name: "Views", Type: "int"},
+			{Name: "PublishedAt", Type: "time.Time", IsNull: true},
+			{Name: "Embedding", Type: "vector(3)"},
+			{Name: "AuthorID", Type: "int", References: "Author"},
+		}),
+	}
+
+	ts := GenerateTypeScript(defs, false)
+	assert.NotContains(t, ts, "zod")
+	assert.Contains(t, ts, "\nexport interface Author {\n  id: number;\n  created_at: string;\n  updated_at: string;\n  name: string;\n}\n")
+	assert.Contains(t, ts, "  views: number;\n  published_at: string;\n  embedding: number[];\n"+
+		"  /** ID of the referenced Author. */\n  author_id: number;\n}\n")
+
+	ts = GenerateTypeScript(defs, true)
+	assert.Contains(t, ts, "import { z } from \"zod\";\n")
+	assert.Contains(t, ts, "\nexport const BlogPostSchema: z.ZodType<BlogPost> = z.object({\n  id: z.number().int(),\n")
+	assert.Contains(t, ts, "  published_at: z.string().datetime({ offset: true }),\n  embedding: z.array(z.number()).length(3),\n")
+}
+
+func TestGenerateTypeScript_FieldAttributes(t *testing.T) {
+	ts := GenerateTypeScript([]*ModelDefinition{NewModelDefinition("User", []Field{
+		{Name: "PasswordHash", Type: "string", Hidden: true},
+		{Name: "Slug", Type: "string", ReadOnly: true},
+	})}, true)
+
+	assert.NotContains(t, ts, "password_hash")
+	assert.Contains(t, ts, "  readonly slug: string;\n")
+	assert.Contains(t, ts, "  slug: z.string(),\n")
+}
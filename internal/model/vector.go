@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/codegen"
+)
+
+// MaxVectorDimensions is the largest number of dimensions pgvector can index.
+const MaxVectorDimensions = 16000
+
+// VectorDimensions returns the number of dimensions of a "vector(n)" field type, and whether fieldType is one.
+func VectorDimensions(fieldType string) (int, bool) {
+	inner, ok := strings.CutPrefix(fieldType, "vector(")
+	if !ok {
+		return 0, false
+	}
+	inner, ok = strings.CutSuffix(inner, ")")
+	if !ok {
+		return 0, false
+	}
+	dimensions, err := strconv.Atoi(inner)
+	if err != nil {
+		return 0, false
+	}
+	return dimensions, true
+}
+
+// usesVector reports whether any of fields is a vector field.
+func usesVector(fields []Field) bool {
+	for _, field := range fields {
+		if _, ok := VectorDimensions(field.Type); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesVector reports whether any of the models has a vector field, so its app needs the Vector type
+// written by GenerateVectorFile and its database the pgvector extension.
+func UsesVector(defs ...*ModelDefinition) bool {
+	for _, def := range defs {
+		if usesVector(def.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// goType returns the Go type generated for a field type: vector fields become model.Vector, decimal fields
+// model.Decimal, custom types become their Go type, and every other type is kept.
+func goType(fieldType string) string {
+	if _, ok := VectorDimensions(fieldType); ok {
+		return "model.Vector"
+	}
+	if _, _, ok := DecimalPrecision(fieldType); ok {
+		return "model.Decimal"
+	}
+	if t, ok := lookupType(fieldType); ok {
+		return t.Go
+	}
+	return fieldType
+}
+
+// vectorTemplate is the file written next to the base model of an app, providing the Vector type of vector fields.
+const vectorTemplate = `package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Vector is a pgvector embedding, stored in a VECTOR column.
+type Vector []float32
+
+// Value writes the vector in the text format of pgvector, such as "[1,2,3]".
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = strconv.FormatFloat(float64(x), 'g', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]", nil
+}
+
+// Scan reads a vector in the text format of pgvector.
+func (v *Vector) Scan(src interface{}) error {
+	var text string
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		text = src
+	case []byte:
+		text = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into a Vector", src)
+	}
+
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+	if text == "" {
+		*v = Vector{}
+		return nil
+	}
+	parts := strings.Split(text, ",")
+	vector := make(Vector, len(parts))
+	for i, part := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector element %q: %w", part, err)
+		}
+		vector[i] = float32(x)
+	}
+	*v = vector
+	return nil
+}
+`
+
+// GenerateVectorFile writes the Vector type used by vector fields to dir/vector.go, unless it already exists.
+func GenerateVectorFile(dir string, w *codegen.Writer) error {
+	fileName := filepath.Join(dir, "vector.go")
+	if _, err := os.Stat(fileName); err == nil {
+		w.Report(fileName, codegen.ActionUnchanged)
+		return nil
+	}
+
+	if err := w.WriteGoFile(fileName, []byte(vectorTemplate)); err != nil {
+		return fmt.Errorf("error writing vector file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectorDimensions(t *testing.T) {
+	dimensions, ok := VectorDimensions("vector(1536)")
+	assert.True(t, ok)
+	assert.Equal(t, 1536, dimensions)
+
+	for _, fieldType := range []string{"string", "vector", "vector()", "vector(x)"} {
+		_, ok := VectorDimensions(fieldType)
+		assert.False(t, ok, fieldType)
+	}
+
+	var mm ModelManager
+	assert.NoError(t, mm.ValidateField(Field{Name: "Embedding", Type: "vector(3)"}))
+	assert.Error(t, mm.ValidateField(Field{Name: "Embedding", Type: "vector(0)"}))
+}
+
+func TestGenerateMigration_Vector(t *testing.T) {
+	var mm ModelManager
+	def := NewModelDefinition("Document", []Field{{Name: "Embedding", Type: "vector(3)"}})
+
+	migration := mm.GenerateMigration(def)
+	assert.Contains(t, migration, "CREATE EXTENSION IF NOT EXISTS vector;\nCREATE TABLE documents (")
+	assert.Contains(t, migration, "embedding VECTOR(3) NOT NULL")
+
+	assert.Equal(t, "CREATE EXTENSION IF NOT EXISTS vector;\nALTER TABLE documents ADD COLUMN embedding VECTOR(3);\n",
+		mm.GenerateAlterMigration(def, nil, def.Fields))
+}
+
+func TestGenerateModelFile_Vector(t *testing.T) {
+	dir := t.TempDir()
+	def := &ModelDefinition{
+		Name:       "Document",
+		Fields:     []Field{{Name: "Embedding", Type: "vector(3)"}},
+		OutputDir:  dir,
+		ModulePath: "example.com/shop",
+	}
+
+	assert.NoError(t, GenerateModelFile(def, nil))
+	assert.NoError(t, GenerateModelTestFile(def, nil))
+	assert.NoError(t, GenerateVectorFile(dir, nil))
+
+	content, err := os.ReadFile(filepath.Join(dir, "document.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Embedding model.Vector `json:\"embedding\"`")
+
+	content, err = os.ReadFile(filepath.Join(dir, "document_test.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"example.com/shop/internal/model"`)
+	assert.Contains(t, string(content), "Embedding: model.Vector{0.5, 1.5},")
+
+	content, err = os.ReadFile(filepath.Join(dir, "vector.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "type Vector []float32")
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ViewKind selects the kind of view backing a view model.
+type ViewKind string
+
+const (
+	// ViewMaterialized stores the rows of the query, which are read quickly until the view is refreshed. It is
+	// the default.
+	ViewMaterialized ViewKind = "materialized"
+	// ViewPlain runs the query every time the view is read, so its rows are always current.
+	ViewPlain ViewKind = "plain"
+)
+
+// ParseViewKind returns the ViewKind named by kind. An empty kind is ViewMaterialized.
+func ParseViewKind(kind string) (ViewKind, error) {
+	switch ViewKind(kind) {
+	case "", ViewMaterialized:
+		return ViewMaterialized, nil
+	case ViewPlain:
+		return ViewPlain, nil
+	default:
+		return "", fmt.Errorf("unknown view kind %q: use materialized or plain", kind)
+	}
+}
+
+// viewKeywords are the words a query stored as a view may start with.
+var viewKeywords = []string{"SELECT", "WITH", "VALUES", "TABLE"}
+
+// SetView makes m a read-only model backed by a view of query instead of a table, of the given kind as accepted
+// by ParseViewKind. The view is named like the table would be, and its fields describe the columns of query, which
+// must include the primary key so a materialized view can be refreshed concurrently. A trailing semicolon is
+// dropped. Views have no tenant column, row-level security, or partitions. An empty query makes m a table again.
+func (m *ModelDefinition) SetView(query, kind string) error {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if query == "" {
+		if kind != "" {
+			return fmt.Errorf("model %s: a view kind is only used with a view query", m.Name)
+		}
+		m.View, m.ViewKind = "", ""
+		return nil
+	}
+	viewKind, err := ParseViewKind(kind)
+	if err != nil {
+		return fmt.Errorf("model %s: %w", m.Name, err)
+	}
+	keyword, _, _ := strings.Cut(strings.Fields(query)[0], "(")
+	valid := false
+	for _, k := range viewKeywords {
+		valid = valid || strings.EqualFold(keyword, k)
+	}
+	if !valid {
+		return fmt.Errorf("model %s: the query of a view must be a SELECT statement", m.Name)
+	}
+	if m.Tenant || m.RLS != RLSNone || m.PartitionBy != "" {
+		return fmt.Errorf("model %s: a view cannot have a tenant column, row-level security, or partitions", m.Name)
+	}
+	m.View, m.ViewKind = query, viewKind
+	return nil
+}
+
+// IsView reports whether m is backed by a view.
+func (m *ModelDefinition) IsView() bool {
+	return m.View != ""
+}
+
+// Materialized reports whether m is backed by a materialized view. Views without a kind are materialized.
+func (m *ModelDefinition) Materialized() bool {
+	return m.IsView() && m.ViewKind != ViewPlain
+}
+
+// viewMigration returns the statement creating the plain view of m, or the statements creating its materialized
+// view, filled with the rows of its query, and the unique index on its primary key that
+// REFRESH MATERIALIZED VIEW CONCURRENTLY requires.
+func viewMigration(m *ModelDefinition) string {
+	view := quote(m.TableName())
+	if !m.Materialized() {
+		return fmt.Sprintf("CREATE VIEW %s AS\n%s;\n", view, m.View)
+	}
+	return fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS\n%s;\nCREATE UNIQUE INDEX %s ON %s (%s);\n",
+		view, m.View, quote(m.TableName()+"_"+m.PrimaryKey()+"_idx"), view, quote(m.PrimaryKey()))
+}
@@ -0,0 +1,116 @@
+package naming
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unicode"
+)
+
+// ColumnCase selects how the columns of model fields are written.
+type ColumnCase string
+
+const (
+	// SnakeCase writes the column of PublishedAt as published_at. It is the default.
+	SnakeCase ColumnCase = "snake"
+	// CamelCase writes the column of PublishedAt as publishedAt.
+	CamelCase ColumnCase = "camel"
+)
+
+// TableNumber selects whether the table of a model is named in the plural or the singular.
+type TableNumber string
+
+const (
+	// PluralTables names the table of BlogPost blog_posts. It is the default.
+	PluralTables TableNumber = "plural"
+	// SingularTables names the table of BlogPost blog_post.
+	SingularTables TableNumber = "singular"
+)
+
+// Convention is the house style of the names of the tables and columns of models, so they can match an existing
+// database. The zero Convention is grayv's own style: snake_case columns and plural tables without a prefix.
+// Tables are always snake_case, and plurals are formed by appending "s", as in categorys.
+type Convention struct {
+	Columns ColumnCase
+	Tables  TableNumber
+	// Prefix starts the name of every table, such as "app_" for app_blog_posts.
+	Prefix string
+}
+
+// Validate returns an error if c has an unknown column case or table number, or a prefix that is not made of
+// letters, digits, and underscores.
+func (c Convention) Validate() error {
+	switch c.Columns {
+	case "", SnakeCase, CamelCase:
+	default:
+		return fmt.Errorf("unknown column case %q: use snake or camel", c.Columns)
+	}
+	switch c.Tables {
+	case "", PluralTables, SingularTables:
+	default:
+		return fmt.Errorf("unknown table number %q: use plural or singular", c.Tables)
+	}
+	for i, r := range c.Prefix {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return fmt.Errorf("invalid table prefix %q: use letters, digits, and underscores, starting with a letter or underscore", c.Prefix)
+		}
+	}
+	return nil
+}
+
+// Column returns the column of the field name, such as published_at or publishedAt for PublishedAt. Converting
+// a column gives back the same column.
+func (c Convention) Column(name string) string {
+	if c.Columns == CamelCase {
+		return ToLowerCamel(name)
+	}
+	return ToSnake(name)
+}
+
+// Table returns the table of the model name, such as app_blog_posts for BlogPost.
+func (c Convention) Table(name string) string {
+	return c.Prefix + c.unprefixed(name)
+}
+
+// JoinTable returns the table joining the rows of the models a and b, such as app_post_tags for Post and Tag.
+func (c Convention) JoinTable(a, b string) string {
+	return c.Prefix + ToSnake(a) + "_" + c.unprefixed(b)
+}
+
+// unprefixed returns the table of the model name without the prefix.
+func (c Convention) unprefixed(name string) string {
+	if c.Tables == SingularTables {
+		return ToSnake(name)
+	}
+	return ToSnake(name) + "s"
+}
+
+// convention holds the Convention returned by Current.
+var convention atomic.Pointer[Convention]
+
+// SetConvention makes c the convention of the process, used by Column and Table. The grayv-lsm commands set it
+// from the naming section of the configuration before they run. It returns an error if c is invalid.
+func SetConvention(c Convention) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	convention.Store(&c)
+	return nil
+}
+
+// Current returns the convention set by SetConvention, or the zero Convention if none was set.
+func Current() Convention {
+	if c := convention.Load(); c != nil {
+		return *c
+	}
+	return Convention{}
+}
+
+// Column returns the column of the field name in the current convention.
+func Column(name string) string {
+	return Current().Column(name)
+}
+
+// Table returns the table of the model name in the current convention.
+func Table(name string) string {
+	return Current().Table(name)
+}
@@ -0,0 +1,53 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvention(t *testing.T) {
+	tests := []struct {
+		convention Convention
+		column     string
+		table      string
+		joinTable  string
+	}{
+		{Convention{}, "published_at", "blog_posts", "blog_post_tags"},
+		{Convention{Columns: CamelCase}, "publishedAt", "blog_posts", "blog_post_tags"},
+		{Convention{Tables: SingularTables}, "published_at", "blog_post", "blog_post_tag"},
+		{Convention{Columns: SnakeCase, Tables: PluralTables, Prefix: "app_"}, "published_at", "app_blog_posts", "app_blog_post_tags"},
+	}
+
+	for _, tt := range tests {
+		assert.NoError(t, tt.convention.Validate())
+		assert.Equal(t, tt.column, tt.convention.Column("PublishedAt"), "%+v", tt.convention)
+		assert.Equal(t, tt.column, tt.convention.Column(tt.column), "converting a column gives it back")
+		assert.Equal(t, tt.table, tt.convention.Table("BlogPost"), "%+v", tt.convention)
+		assert.Equal(t, tt.joinTable, tt.convention.JoinTable("BlogPost", "Tag"), "%+v", tt.convention)
+	}
+	assert.Equal(t, "id", Convention{Columns: CamelCase}.Column("ID"))
+	assert.Equal(t, "authorID", Convention{Columns: CamelCase}.Column("AuthorID"))
+}
+
+func TestConvention_Validate(t *testing.T) {
+	assert.ErrorContains(t, Convention{Columns: "kebab"}.Validate(), `unknown column case "kebab"`)
+	assert.ErrorContains(t, Convention{Tables: "plurals"}.Validate(), `unknown table number "plurals"`)
+	assert.ErrorContains(t, Convention{Prefix: "app-"}.Validate(), `invalid table prefix "app-"`)
+	assert.ErrorContains(t, Convention{Prefix: "1app_"}.Validate(), `invalid table prefix "1app_"`)
+	assert.NoError(t, Convention{Prefix: "_app2_"}.Validate())
+}
+
+func TestSetConvention(t *testing.T) {
+	t.Cleanup(func() { SetConvention(Convention{}) })
+
+	assert.Equal(t, Convention{}, Current())
+	assert.Equal(t, "blog_posts", Table("BlogPost"))
+
+	assert.NoError(t, SetConvention(Convention{Columns: CamelCase, Tables: SingularTables, Prefix: "app_"}))
+	assert.Equal(t, "app_blog_post", Table("BlogPost"))
+	assert.Equal(t, "publishedAt", Column("PublishedAt"))
+
+	assert.Error(t, SetConvention(Convention{Columns: "kebab"}))
+	assert.Equal(t, CamelCase, Current().Columns, "an invalid convention is not set")
+}
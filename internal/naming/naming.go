@@ -0,0 +1,91 @@
+// Package naming converts identifiers between the casing styles used in generated Go code and SQL.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialisms are words written in all capitals in Go identifiers, following the Go naming conventions.
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "HTML": true,
+	"HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "URI": true,
+	"URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// Words splits an identifier written in any common style (camelCase, PascalCase, snake_case,
+// kebab-case, or space separated) into its words.
+func Words(s string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(current) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// Split "userID" before "I" and "HTTPServer" before "S", but keep "ID" together.
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// ToCamel converts an identifier to an exported Go name, e.g. "blog_post" to "BlogPost" and "user id" to "UserID".
+func ToCamel(s string) string {
+	var b strings.Builder
+	for _, word := range Words(s) {
+		b.WriteString(capitalize(word))
+	}
+	return b.String()
+}
+
+// ToLowerCamel converts an identifier to an unexported Go name, e.g. "blog_post" to "blogPost" and "ID" to "id".
+func ToLowerCamel(s string) string {
+	var b strings.Builder
+	for i, word := range Words(s) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+			continue
+		}
+		b.WriteString(capitalize(word))
+	}
+	return b.String()
+}
+
+// ToSnake converts an identifier to a SQL name, e.g. "BlogPost" to "blog_post" and "UserID" to "user_id".
+func ToSnake(s string) string {
+	words := Words(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// capitalize writes an initialism in capitals and any other word with only its first letter in upper case.
+func capitalize(word string) string {
+	if upper := strings.ToUpper(word); initialisms[upper] {
+		return upper
+	}
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
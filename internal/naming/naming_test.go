@@ -0,0 +1,35 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCasing(t *testing.T) {
+	tests := []struct {
+		in         string
+		camel      string
+		lowerCamel string
+		snake      string
+	}{
+		{"blog_post", "BlogPost", "blogPost", "blog_post"},
+		{"blog-post", "BlogPost", "blogPost", "blog_post"},
+		{"blog post", "BlogPost", "blogPost", "blog_post"},
+		{"BlogPost", "BlogPost", "blogPost", "blog_post"},
+		{"PublishedAt", "PublishedAt", "publishedAt", "published_at"},
+		{"userID", "UserID", "userID", "user_id"},
+		{"id", "ID", "id", "id"},
+		{"HTTPServer", "HTTPServer", "httpServer", "http_server"},
+		{"address2", "Address2", "address2", "address2"},
+		{"straße_nummer", "StraßeNummer", "straßeNummer", "straße_nummer"},
+		{"ÉcoleName", "ÉcoleName", "écoleName", "école_name"},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.camel, ToCamel(tt.in), tt.in)
+		assert.Equal(t, tt.lowerCamel, ToLowerCamel(tt.in), tt.in)
+		assert.Equal(t, tt.snake, ToSnake(tt.in), tt.in)
+	}
+}
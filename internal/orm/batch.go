@@ -0,0 +1,154 @@
+package orm
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+)
+
+// CreateBatch inserts models, which must all be of the same type, as Create does, but with multi-row INSERT
+// statements of batchSize records each, or DefaultBatchSize if batchSize is 0 or less, and in one transaction:
+// either every record is inserted or, on error, none is. On PostgreSQL and SQLite every column is read back with
+// RETURNING, so the models get their ids, timestamps, and defaults as with Create; on MySQL, ids generated by the
+// database are not set. Models whose ids are not generated by the database, such as those with the snowflake or
+// app strategy, are streamed with COPY FROM instead when the driver supports it, and nothing is read back.
+func (c *CRUD) CreateBatch(models []model.ModelInterface, batchSize int) error {
+	if len(models) == 0 {
+		return nil
+	}
+	queries := make([]*Query, len(models))
+	targets := make([][]interface{}, len(models))
+	for i, m := range models {
+		if reflect.TypeOf(m) != reflect.TypeOf(models[0]) {
+			return fmt.Errorf("CreateBatch needs models of one type, not %T and %T", models[0], m)
+		}
+		q, t, _, err := c.insertQuery(m)
+		if err != nil {
+			return err
+		}
+		queries[i], targets[i] = q, t
+	}
+
+	strategy, err := idStrategyOf(models[0])
+	if err != nil {
+		return err
+	}
+	if loader := c.conn.BulkLoader(); loader.UsesCopy() && !strategy.Generated() {
+		return c.copyBatch(loader, models[0], queries)
+	}
+
+	tx, err := c.conn.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	table := models[0].TableName()
+	size := c.batchSize(queries[0], batchSize)
+	for batch, q := range batchQueries(queries, size) {
+		start := batch * size
+		end := start + len(q.rows) + 1
+		query, params := q.Placeholders(c.conn.placeholders()).Build()
+		if len(targets[start]) == 0 {
+			if _, err := tx.Exec(query, params...); err != nil {
+				return fmt.Errorf("error inserting records %d to %d into %s: %w", start+1, end, table, TranslateError(err))
+			}
+			continue
+		}
+
+		// The rows are returned in the order of VALUES, which is the order PostgreSQL and SQLite insert them in.
+		rows, err := tx.Query(query, params...)
+		if err != nil {
+			return fmt.Errorf("error inserting records %d to %d into %s: %w", start+1, end, table, TranslateError(err))
+		}
+		i := start
+		for ; rows.Next() && i < end; i++ {
+			if err := rows.Scan(targets[i]...); err != nil {
+				rows.Close()
+				return TranslateError(err)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return TranslateError(err)
+		}
+		if i != end {
+			return fmt.Errorf("inserting records %d to %d into %s returned %d rows", start+1, end, table, i-start)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing batch: %w", err)
+	}
+	return nil
+}
+
+// batchSize returns the number of records inserted by each statement of CreateBatch: size, or DefaultBatchSize
+// if size is 0 or less, lowered so a statement has at most maxParameters parameters. Q is the query inserting
+// one of the records.
+func (c *CRUD) batchSize(q *Query, size int) int {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	perRow := len(q.values)
+	if c.conn.tenancy == TenancyColumn {
+		perRow++
+	}
+	if perRow > 0 && size*perRow > maxParameters {
+		size = maxParameters / perRow
+	}
+	return size
+}
+
+// batchQueries merges queries, each inserting one record, into queries inserting size records each, the last
+// one inserting the rest. The first query of each batch is reused, with the values of the others added as rows.
+func batchQueries(queries []*Query, size int) []*Query {
+	var batches []*Query
+	for start := 0; start < len(queries); start += size {
+		q := queries[start]
+		for _, other := range queries[start+1 : min(start+size, len(queries))] {
+			q.AddRow(other.values...)
+		}
+		batches = append(batches, q)
+	}
+	return batches
+}
+
+// copyBatch streams the records inserted by queries, which insert records of the type of m, with the loader.
+func (c *CRUD) copyBatch(loader *BulkLoader, m model.ModelInterface, queries []*Query) error {
+	table, columns := m.TableName(), slices.Clip(queries[0].fields)
+	rows := make([][]interface{}, len(queries))
+	for i, q := range queries {
+		rows[i] = q.values
+	}
+	switch c.conn.tenancy {
+	case TenancySchema:
+		table = TenantSchema(c.tenant) + "." + table
+	case TenancyColumn:
+		columns = append(columns, TenantColumn)
+		for i, row := range rows {
+			rows[i] = append(slices.Clip(row), c.tenant)
+		}
+	}
+	source := sliceSource(rows)
+	if _, err := loader.Load(table, columns, &source); err != nil {
+		return TranslateError(err)
+	}
+	return nil
+}
+
+// sliceSource is a RowSource over rows held in memory.
+type sliceSource [][]interface{}
+
+// Next returns the next row.
+func (s *sliceSource) Next() ([]interface{}, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	row := (*s)[0]
+	*s = (*s)[1:]
+	return row, nil
+}
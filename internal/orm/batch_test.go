@@ -0,0 +1,54 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchQueries(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	var queries []*Query
+	for _, body := range []string{"a", "b", "c"} {
+		q, _, _, err := crud.insertQuery(&note{Body: body})
+		if !assert.NoError(t, err) {
+			return
+		}
+		queries = append(queries, q)
+	}
+
+	batches := batchQueries(queries, 2)
+	if assert.Len(t, batches, 2) {
+		query, params := batches[0].Placeholders(Dollar).Build()
+		assert.Equal(t, "INSERT INTO notes (body) VALUES ($1), ($2) RETURNING id, body", query)
+		assert.Equal(t, []interface{}{"a", "b"}, params)
+		query, params = batches[1].Build()
+		assert.Equal(t, "INSERT INTO notes (body) VALUES (?) RETURNING id, body", query)
+		assert.Equal(t, []interface{}{"c"}, params)
+	}
+}
+
+func TestQuery_AddRowTenantColumn(t *testing.T) {
+	query, params := NewQuery("notes").Insert("body").Values("a").AddRow("b").Tenant(TenancyColumn, "acme").Build()
+	assert.Equal(t, "INSERT INTO notes (body, tenant_id) VALUES (?, ?), (?, ?)", query)
+	assert.Equal(t, []interface{}{"a", "acme", "b", "acme"}, params)
+}
+
+func TestCRUD_BatchSize(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	q := NewQuery("notes").Insert("a", "b", "c").Values(1, 2, 3)
+	assert.Equal(t, DefaultBatchSize, crud.batchSize(q, 0))
+	assert.Equal(t, 100, crud.batchSize(q, 100))
+	assert.Equal(t, maxParameters/3, crud.batchSize(q, 100000), "a statement has at most maxParameters parameters")
+
+	crud = NewCRUD(&Connection{driver: "postgres", tenancy: TenancyColumn})
+	assert.Equal(t, maxParameters/4, crud.batchSize(q, 100000), "the tenant column is a parameter of every row")
+}
+
+func TestCRUD_CreateBatch_MixedTypes(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	err := crud.CreateBatch([]model.ModelInterface{&note{Body: "a"}, &tag{Label: "go"}}, 10)
+	assert.ErrorContains(t, err, "models of one type")
+	assert.NoError(t, crud.CreateBatch(nil, 10))
+}
@@ -0,0 +1,216 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchTable is the table the benchmark workload runs against. It is created for every run and dropped afterwards.
+const BenchTable = "grayv_bench"
+
+// BenchOptions configures a benchmark run. Zero values select the defaults.
+type BenchOptions struct {
+	// Clients is the number of concurrent clients, each with a connection of its own. It defaults to 10.
+	Clients int
+	// Duration is how long the workload runs. It defaults to 30 seconds.
+	Duration time.Duration
+	// ReadRatio is the share of transactions that read a row, between 0 and 1; the others update one.
+	ReadRatio float64
+	// Rows is the number of rows the table is filled with before the run. It defaults to 10000.
+	Rows int
+}
+
+// WithDefaults returns a copy of o with its zero values replaced by the defaults.
+func (o BenchOptions) WithDefaults() BenchOptions {
+	if o.Clients == 0 {
+		o.Clients = 10
+	}
+	if o.Duration == 0 {
+		o.Duration = 30 * time.Second
+	}
+	if o.Rows == 0 {
+		o.Rows = 10000
+	}
+	return o
+}
+
+func (o BenchOptions) validate() error {
+	switch {
+	case o.Clients < 1:
+		return fmt.Errorf("invalid number of clients %d: must be at least 1", o.Clients)
+	case o.Duration < 0:
+		return fmt.Errorf("invalid duration %s: must be positive", o.Duration)
+	case o.ReadRatio < 0 || o.ReadRatio > 1:
+		return fmt.Errorf("invalid read ratio %g: must be between 0 and 1", o.ReadRatio)
+	case o.Rows < 1:
+		return fmt.Errorf("invalid number of rows %d: must be at least 1", o.Rows)
+	}
+	return nil
+}
+
+// BenchResult is the outcome of a benchmark run. Latencies are those of successful transactions.
+type BenchResult struct {
+	Clients      int           `json:"clients"`
+	Elapsed      time.Duration `json:"elapsed_ns"`
+	Transactions int64         `json:"transactions"`
+	Reads        int64         `json:"reads"`
+	Writes       int64         `json:"writes"`
+	Errors       int64         `json:"errors"`
+	TPS          float64       `json:"tps"`
+	Mean         time.Duration `json:"mean_ns"`
+	P50          time.Duration `json:"p50_ns"`
+	P90          time.Duration `json:"p90_ns"`
+	P95          time.Duration `json:"p95_ns"`
+	P99          time.Duration `json:"p99_ns"`
+	Max          time.Duration `json:"max_ns"`
+}
+
+// Bench runs a read/write workload against BenchTable from opts.Clients concurrent clients for opts.Duration,
+// or until ctx is canceled, and reports the throughput and latency. Reads select a random row by primary key
+// and writes update one. Failed transactions are counted and the run goes on; Bench only fails if the table
+// cannot be set up or no transaction succeeded. The workload uses PostgreSQL syntax.
+func (c *Connection) Bench(ctx context.Context, opts BenchOptions) (*BenchResult, error) {
+	opts = opts.WithDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := c.CheckWritable("run the benchmark"); err != nil {
+		return nil, err
+	}
+	if err := c.setupBench(opts.Rows); err != nil {
+		return nil, err
+	}
+	defer c.db.Exec("DROP TABLE IF EXISTS " + BenchTable)
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+		result    = &BenchResult{Clients: opts.Clients}
+		firstErr  error
+	)
+	start := time.Now()
+	for i := 0; i < opts.Clients; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			stats := c.benchClient(ctx, opts, rand.New(rand.NewSource(seed)))
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, stats.latencies...)
+			result.Reads += stats.reads
+			result.Writes += stats.writes
+			result.Errors += stats.errors
+			if firstErr == nil {
+				firstErr = stats.err
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+
+	result.Transactions = result.Reads + result.Writes
+	if result.Transactions == 0 && firstErr != nil {
+		return nil, fmt.Errorf("every transaction failed: %w", firstErr)
+	}
+	result.TPS = float64(result.Transactions) / result.Elapsed.Seconds()
+	result.summarize(latencies)
+	return result, nil
+}
+
+// setupBench creates BenchTable and fills it with rows rows.
+func (c *Connection) setupBench(rows int) error {
+	statements := []string{
+		"DROP TABLE IF EXISTS " + BenchTable,
+		"CREATE TABLE " + BenchTable + " (id INTEGER PRIMARY KEY, counter INTEGER NOT NULL DEFAULT 0, payload TEXT NOT NULL, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+		fmt.Sprintf("INSERT INTO %s (id, payload) SELECT n, md5(n::text) FROM generate_series(1, %d) AS n", BenchTable, rows),
+		"ANALYZE " + BenchTable,
+	}
+	for _, statement := range statements {
+		if _, err := c.db.Exec(statement); err != nil {
+			return fmt.Errorf("error setting up the benchmark table: %w", err)
+		}
+	}
+	return nil
+}
+
+// benchStats is what a single benchmark client measured.
+type benchStats struct {
+	latencies             []time.Duration
+	reads, writes, errors int64
+	err                   error
+}
+
+// benchClient runs transactions until ctx is done.
+func (c *Connection) benchClient(ctx context.Context, opts BenchOptions, rnd *rand.Rand) benchStats {
+	var stats benchStats
+	query := "SELECT counter, payload FROM " + BenchTable + " WHERE id = $1"
+	update := "UPDATE " + BenchTable + " SET counter = counter + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1"
+	for ctx.Err() == nil {
+		id := rnd.Intn(opts.Rows) + 1
+		read := rnd.Float64() < opts.ReadRatio
+
+		begin := time.Now()
+		var err error
+		if read {
+			var counter int
+			var payload string
+			err = c.db.QueryRowContext(ctx, query, id).Scan(&counter, &payload)
+		} else {
+			_, err = c.db.ExecContext(ctx, update, id)
+		}
+		elapsed := time.Since(begin)
+
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// The run ended while the transaction was in flight.
+		case err != nil:
+			stats.errors++
+			if stats.err == nil {
+				stats.err = err
+			}
+		case read:
+			stats.reads++
+			stats.latencies = append(stats.latencies, elapsed)
+		default:
+			stats.writes++
+			stats.latencies = append(stats.latencies, elapsed)
+		}
+	}
+	return stats
+}
+
+// summarize sets the mean and percentile latencies of r from latencies.
+func (r *BenchResult) summarize(latencies []time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	r.Mean = total / time.Duration(len(latencies))
+	r.P50 = percentile(latencies, 0.50)
+	r.P90 = percentile(latencies, 0.90)
+	r.P95 = percentile(latencies, 0.95)
+	r.P99 = percentile(latencies, 0.99)
+	r.Max = latencies[len(latencies)-1]
+}
+
+// percentile returns the p-th percentile of the sorted latencies, by the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,35 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBenchOptions(t *testing.T) {
+	opts := BenchOptions{ReadRatio: 0.5}.WithDefaults()
+	assert.Equal(t, BenchOptions{Clients: 10, Duration: 30 * time.Second, ReadRatio: 0.5, Rows: 10000}, opts)
+	assert.NoError(t, opts.validate())
+
+	assert.Error(t, BenchOptions{Clients: -1, Duration: time.Second, Rows: 1}.validate())
+	assert.Error(t, BenchOptions{Clients: 1, Duration: time.Second, ReadRatio: 1.5, Rows: 1}.validate())
+	assert.Error(t, BenchOptions{Clients: 1, Duration: -time.Second, Rows: 1}.validate())
+}
+
+func TestBenchResult_Summarize(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	var r BenchResult
+	r.summarize(latencies)
+	assert.Equal(t, 50500*time.Microsecond, r.Mean)
+	assert.Equal(t, 50*time.Millisecond, r.P50)
+	assert.Equal(t, 90*time.Millisecond, r.P90)
+	assert.Equal(t, 95*time.Millisecond, r.P95)
+	assert.Equal(t, 99*time.Millisecond, r.P99)
+	assert.Equal(t, 100*time.Millisecond, r.Max)
+
+	assert.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 0.99))
+}
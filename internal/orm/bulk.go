@@ -0,0 +1,260 @@
+package orm
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// DefaultBatchSize is the number of rows inserted per statement when COPY is not available.
+const DefaultBatchSize = 500
+
+// maxParameters is the largest number of bind parameters a single PostgreSQL statement accepts.
+const maxParameters = 65535
+
+// RowSource yields the rows of a bulk load. Next returns io.EOF after the last row.
+type RowSource interface {
+	Next() ([]interface{}, error)
+}
+
+// BulkLoader loads large numbers of rows into a table in one transaction. With the lib/pq and pgx
+// drivers it streams the rows with COPY FROM STDIN; with other drivers, and on connections echoing
+// their statements, it falls back to multi-row INSERT statements of BatchSize rows each.
+type BulkLoader struct {
+	db           *sql.DB
+	copy         bool
+	pgx          bool
+	placeholders PlaceholderFormat
+	dialect      dialect.Dialect
+	BatchSize    int
+}
+
+// NewBulkLoader creates a new BulkLoader for db, which was opened with the named driver.
+// Example usage: loader := orm.NewBulkLoader(db, "postgres")
+func NewBulkLoader(db *sql.DB, driver string) *BulkLoader {
+	loader := &BulkLoader{db: db, dialect: dialect.ForDriver(driver), BatchSize: DefaultBatchSize}
+	switch driver {
+	case "postgres":
+		loader.copy = true
+		loader.placeholders = Dollar
+	case "pgx":
+		loader.pgx = true
+		loader.placeholders = Dollar
+	}
+	return loader
+}
+
+// BulkLoader returns a BulkLoader for the connection.
+func (c *Connection) BulkLoader() *BulkLoader {
+	return NewBulkLoader(c.db, c.driver)
+}
+
+// UsesCopy reports whether rows are loaded with COPY rather than INSERT statements.
+func (b *BulkLoader) UsesCopy() bool {
+	return b.copy || b.pgx
+}
+
+// Load inserts every row of rows into the given columns of table and returns the number of rows loaded.
+// Either all rows are loaded or, on error, none are. The table and column names are validated, as they
+// often come from user input such as a CSV header.
+func (b *BulkLoader) Load(table string, columns []string, rows RowSource) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("no columns to load")
+	}
+	if err := dialect.Validate(table); err != nil {
+		return 0, err
+	}
+	for _, column := range columns {
+		if err := dialect.Validate(column); err != nil {
+			return 0, err
+		}
+	}
+
+	if b.pgx {
+		if count, err := b.copyRowsPgx(table, columns, rows); err != errNoCopy {
+			return count, err
+		}
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int64
+	if b.copy {
+		count, err = copyRows(tx, table, columns, rows)
+	} else {
+		count, err = b.insertRows(tx, table, columns, rows)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing bulk load: %w", err)
+	}
+	return count, nil
+}
+
+// LoadCSV loads CSV data into table. The first record names the columns; empty fields are loaded as NULL.
+func (b *BulkLoader) LoadCSV(table string, r io.Reader) (int64, error) {
+	source, columns, err := NewCSVSource(r)
+	if err != nil {
+		return 0, err
+	}
+	return b.Load(table, columns, source)
+}
+
+// copyRows streams rows to the server with COPY FROM STDIN.
+func copyRows(tx *sql.Tx, table string, columns []string, rows RowSource) (int64, error) {
+	statement := pq.CopyIn(table, columns...)
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		statement = pq.CopyInSchema(schema, name, columns...)
+	}
+
+	stmt, err := tx.Prepare(statement)
+	if err != nil {
+		return 0, fmt.Errorf("error starting copy into %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	var count int64
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := stmt.Exec(row...); err != nil {
+			return 0, fmt.Errorf("error copying row %d into %s: %w", count+1, table, err)
+		}
+		count++
+	}
+
+	// Executing the statement without arguments flushes the buffered rows and reports any error.
+	if _, err := stmt.Exec(); err != nil {
+		return 0, fmt.Errorf("error copying into %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// insertRows inserts rows with multi-row INSERT statements.
+func (b *BulkLoader) insertRows(tx *sql.Tx, table string, columns []string, rows RowSource) (int64, error) {
+	size := b.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if size*len(columns) > maxParameters {
+		size = maxParameters / len(columns)
+	}
+
+	var count int64
+	batch := make([][]interface{}, 0, size)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		query, params := insertStatement(b.dialect.Quote(table), b.dialect.QuoteAll(columns), batch)
+		if _, err := tx.Exec(Rebind(query, b.placeholders), params...); err != nil {
+			return fmt.Errorf("error inserting rows %d to %d into %s: %w", count+1, count+int64(len(batch)), table, err)
+		}
+		count += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("row %d has %d values, want %d", count+int64(len(batch))+1, len(row), len(columns))
+		}
+		batch = append(batch, row)
+		if len(batch) == size {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// insertStatement builds a single INSERT statement for rows, with "?" placeholders. The table and
+// columns must already be quoted.
+func insertStatement(table string, columns []string, rows [][]interface{}) (string, []interface{}) {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	var query strings.Builder
+	query.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", ")))
+
+	params := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString(placeholders)
+		params = append(params, row...)
+	}
+	return query.String(), params
+}
+
+// CSVSource is a RowSource reading CSV records. Empty fields are returned as nil, so they are loaded as NULL.
+type CSVSource struct {
+	reader *csv.Reader
+}
+
+// NewCSVSource creates a new CSVSource reading from r and returns it together with the column
+// names in the header record.
+func NewCSVSource(r io.Reader) (*CSVSource, []string, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV data has no header record")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.TrimSpace(column)
+	}
+	return &CSVSource{reader: reader}, columns, nil
+}
+
+// Next returns the next CSV record.
+func (s *CSVSource) Next() ([]interface{}, error) {
+	record, err := s.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV: %w", err)
+	}
+
+	row := make([]interface{}, len(record))
+	for i, value := range record {
+		if value != "" {
+			row[i] = value
+		}
+	}
+	return row, nil
+}
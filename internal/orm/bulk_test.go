@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertStatement(t *testing.T) {
+	query, params := insertStatement("users", []string{"name", "email"}, [][]interface{}{
+		{"a", "a@example.com"},
+		{"b", nil},
+	})
+	assert.Equal(t, "INSERT INTO users (name, email) VALUES (?, ?), (?, ?)", query)
+	assert.Equal(t, []interface{}{"a", "a@example.com", "b", nil}, params)
+	assert.Equal(t, "INSERT INTO users (name, email) VALUES ($1, $2), ($3, $4)", Rebind(query, Dollar))
+}
+
+func TestCSVSource(t *testing.T) {
+	source, columns, err := NewCSVSource(strings.NewReader("name, email\na,a@example.com\nb,\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name", "email"}, columns)
+
+	row, err := source.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "a@example.com"}, row)
+
+	row, err = source.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"b", nil}, row, "empty fields are NULL")
+
+	_, err = source.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, _, err = NewCSVSource(strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestNewBulkLoader(t *testing.T) {
+	assert.True(t, NewBulkLoader(nil, "postgres").UsesCopy())
+	assert.True(t, NewBulkLoader(nil, "pgx").UsesCopy())
+	assert.False(t, NewBulkLoader(nil, "sqlite3").UsesCopy())
+	assert.Equal(t, Dollar, NewBulkLoader(nil, "pgx").placeholders)
+	assert.Equal(t, Question, NewBulkLoader(nil, "sqlite3").placeholders)
+}
@@ -3,25 +3,121 @@ package orm
 import (
 	"database/sql"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/XSAM/otelsql"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/lib/pq"
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 type Connection struct {
-	db *sql.DB
+	db       *sql.DB
+	driver   string
+	readOnly bool
+	tenancy  TenancyMode
+	ids      *Snowflake
+	idsOnce  sync.Once
 }
 
 func NewConnection(cfg *config.DatabaseConfig) (*Connection, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+	tenancy, err := ParseTenancyMode(cfg.Tenancy)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := NewSnowflake(int64(cfg.SnowflakeNode))
+	if err != nil {
+		return nil, err
+	}
+	dsn, system := cfg.DSN(), semconv.DBSystemPostgreSQL
+	switch {
+	case cfg.IsSQLite():
+		system = semconv.DBSystemSqlite
+	case cfg.IsMySQL():
+		system = semconv.DBSystemMySQL
+		if _, err := time.LoadLocation(cfg.SessionTimeZone()); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", cfg.SessionTimeZone(), err)
+		}
+	default:
+		if dsn, err = postgresDSN(cfg, dsn); err != nil {
+			return nil, err
+		}
+	}
 
-	db, err := sql.Open(cfg.Driver, dsn)
+	// The driver is wrapped so every query made with a context emits an OpenTelemetry span.
+	// Without a configured tracer provider the spans are no-ops.
+	options := []otelsql.Option{
+		otelsql.WithAttributes(system, semconv.DBNamespace(cfg.Name)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	}
+	var db *sql.DB
+	if cfg.EchoSQL || cfg.ExplainOnly {
+		db, err = openEcho(cfg.DriverName(), dsn, cfg.ExplainOnly, options...)
+	} else {
+		db, err = otelsql.Open(cfg.DriverName(), dsn, options...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	return &Connection{db: db}, nil
+	return &Connection{db: db, driver: cfg.Driver, readOnly: cfg.ReadOnly, tenancy: tenancy, ids: ids}, nil
+}
+
+// postgresDSN returns dsn with the settings of cfg that PostgreSQL applies on the server: the time zone, the
+// statement timeout, read-only transactions, and, through pgbouncer, unnamed prepared statements. Pgbouncer
+// passes the time zone on, as it keeps it for each client, but refuses the parameters setting the statement
+// timeout and read-only transactions, so a pooled connection asking for either is refused rather than opened
+// without them.
+func postgresDSN(cfg *config.DatabaseConfig, dsn string) (string, error) {
+	zone := cfg.SessionTimeZone()
+	if _, err := time.LoadLocation(zone); err != nil {
+		return "", fmt.Errorf("invalid time zone %q: %w", zone, err)
+	}
+	dsn += " timezone=" + zone
+	if cfg.StatementTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.StatementTimeout)
+		if err != nil {
+			return "", fmt.Errorf("invalid statement timeout %q: %w", cfg.StatementTimeout, err)
+		}
+		if cfg.Pooled {
+			return "", fmt.Errorf("the statement timeout of %s cannot be enforced through pgbouncer: unset database.statementtimeout or disable the pooler", cfg.StatementTimeout)
+		}
+		dsn += fmt.Sprintf(" statement_timeout=%d", timeout.Milliseconds())
+	}
+	if cfg.ReadOnly {
+		if cfg.Pooled {
+			return "", fmt.Errorf("%w cannot be enforced by the server through pgbouncer: disable the pooler to connect read-only", ErrReadOnly)
+		}
+		// The server then refuses every statement that writes, whatever the client checks miss.
+		dsn += " default_transaction_read_only=on"
+	}
+	if cfg.Pooled && cfg.Driver == "pgx" {
+		// pgbouncer hands each transaction to any server connection, where the statements pgx prepares and
+		// caches by name may not exist, so statements are prepared unnamed instead.
+		dsn += " default_query_exec_mode=exec"
+	}
+	return dsn, nil
+}
+
+// openEcho opens a database whose connections echo every statement to EchoOutput, and with explainOnly set,
+// only execute the statements that read.
+func openEcho(driverName, dsn string, explainOnly bool, options ...otelsql.Option) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+	return otelsql.OpenDB(&echoConnector{dsn: dsn, driver: d, explainOnly: explainOnly}, options...), nil
 }
 
 func (c *Connection) Close() error {
@@ -40,6 +136,59 @@ func (c *Connection) GetDB() *sql.DB {
 	return c.db
 }
 
+// Tenancy returns how the rows of models are scoped to tenants on this connection.
+func (c *Connection) Tenancy() TenancyMode {
+	return c.tenancy
+}
+
+// Snowflake returns the generator of the snowflake ids of records created on this connection, whose node is
+// the SnowflakeNode of the database configuration, or 0 for connections not made by NewConnection.
+func (c *Connection) Snowflake() *Snowflake {
+	c.idsOnce.Do(func() {
+		if c.ids == nil {
+			c.ids, _ = NewSnowflake(0)
+		}
+	})
+	return c.ids
+}
+
+// NewQuery returns a query on table whose Build quotes identifiers and writes placeholders for the driver of the
+// connection, such as backticks and "?" for MySQL.
+func (c *Connection) NewQuery(table string) *Query {
+	return NewQuery(table).Dialect(c.Dialect()).Placeholders(c.placeholders())
+}
+
+// Rebind rewrites the "?" placeholders in query into the format of the driver of the connection, for queries
+// the Query builder cannot write.
+func (c *Connection) Rebind(query string) string {
+	return Rebind(query, c.placeholders())
+}
+
+// placeholders returns the bind parameter format of the driver of the connection.
+func (c *Connection) placeholders() PlaceholderFormat {
+	switch c.driver {
+	case "postgres", "pgx":
+		return Dollar
+	default:
+		return Question
+	}
+}
+
+// supportsReturning reports whether the driver of the connection supports RETURNING clauses.
+func (c *Connection) supportsReturning() bool {
+	switch c.driver {
+	case "postgres", "pgx", "sqlite", "sqlite3":
+		return true
+	default:
+		return false
+	}
+}
+
+// Dialect returns how identifiers are quoted for the driver of the connection.
+func (c *Connection) Dialect() dialect.Dialect {
+	return dialect.ForDriver(c.driver)
+}
+
 func (c *Connection) ListTables() ([]string, error) {
 	rows, err := c.db.Query(`
 		SELECT table_name 
@@ -81,6 +230,7 @@ type DatabaseMetrics struct {
 	Rollbacks         int
 	CacheHitRatio     float64
 	SlowQueryCount    int
+	DeadTuples        int64
 }
 
 func (c *Connection) GetDatabaseMetrics() (*DatabaseMetrics, error) {
@@ -135,5 +285,11 @@ func (c *Connection) GetDatabaseMetrics() (*DatabaseMetrics, error) {
 		return nil, fmt.Errorf("error counting slow queries: %w", err)
 	}
 
+	// Fetch dead tuples, which build up until the tables are vacuumed
+	err = c.db.QueryRow("SELECT COALESCE(sum(n_dead_tup), 0) FROM pg_stat_user_tables").Scan(&metrics.DeadTuples)
+	if err != nil {
+		return nil, fmt.Errorf("error counting dead tuples: %w", err)
+	}
+
 	return metrics, nil
 }
@@ -1,16 +1,29 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
 )
 
-// CRUD provides basic CRUD operations for models
+// CRUD provides basic CRUD operations for models.
+// Struct fields map to the columns of the naming convention of the process, so a PublishedAt field is stored in
+// published_at unless the convention has camelCase columns; see naming.Current.
+// When the connection has tenancy enabled, every operation is scoped to the tenant set by ForTenant,
+// and fails with ErrNoTenant if none was set. Query and Exec run raw SQL and are never scoped.
+// Errors of the database are returned translated by TranslateError, so a missing record is ErrNotFound and
+// a duplicate value matches ErrUniqueViolation, whichever driver is used.
 type CRUD struct {
-	conn *Connection
+	conn   *Connection
+	tenant string
 }
 
 // NewCRUD creates a new CRUD instance
@@ -18,85 +31,505 @@ func NewCRUD(conn *Connection) *CRUD {
 	return &CRUD{conn: conn}
 }
 
-// Create inserts a new record into the database
+// ForTenant returns a copy of c whose operations are scoped to the tenant ctx was scoped to by WithTenant.
+// It returns ErrNoTenant if ctx has no tenant.
+func (c *CRUD) ForTenant(ctx context.Context) (*CRUD, error) {
+	id, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	if err := ValidateTenantID(id); err != nil {
+		return nil, err
+	}
+	return &CRUD{conn: c.conn, tenant: id}, nil
+}
+
+// newQuery returns a query on the table of m, scoped to the tenant of c if tenancy is enabled.
+func (c *CRUD) newQuery(m model.ModelInterface) (*Query, error) {
+	q := NewQuery(m.TableName()).Dialect(c.conn.Dialect())
+	if c.conn.tenancy == TenancyNone {
+		return q, nil
+	}
+	if c.tenant == "" {
+		return nil, ErrNoTenant
+	}
+	return q.Tenant(c.conn.tenancy, c.tenant), nil
+}
+
+// idStrategist is implemented by models whose primary key is not numbered by a serial column, such as the
+// models generated with an ID strategy other than serial. IDStrategy returns the name of a model.IDStrategy.
+type idStrategist interface {
+	IDStrategy() string
+}
+
+// idStrategyOf returns the ID strategy of m, which is serial unless m implements idStrategist.
+func idStrategyOf(m model.ModelInterface) (model.IDStrategy, error) {
+	if s, ok := m.(idStrategist); ok {
+		return model.ParseIDStrategy(s.IDStrategy())
+	}
+	return model.IDSerial, nil
+}
+
+// ErrReadOnlyModel is returned by the writes of CRUD to a read-only model, such as one backed by a view.
+var ErrReadOnlyModel = errors.New("read-only model")
+
+// readOnlyModel is implemented by models that cannot be written, such as the models generated for views, whose
+// ReadOnly method returns true.
+type readOnlyModel interface {
+	ReadOnly() bool
+}
+
+// checkWritableModel returns an error wrapping ErrReadOnlyModel if m is read-only.
+func checkWritableModel(m model.ModelInterface) error {
+	if r, ok := m.(readOnlyModel); ok && r.ReadOnly() {
+		return fmt.Errorf("%w: %s cannot be written", ErrReadOnlyModel, m.TableName())
+	}
+	return nil
+}
+
+// computedModel is implemented by models with computed fields, which have no column but are evaluated by the
+// queries reading the model, such as the models generated with computed fields. ComputedFields returns the SQL
+// expression of each computed field by column.
+type computedModel interface {
+	ComputedFields() map[string]string
+}
+
+// computedFields returns the expressions of the computed fields of m by column, or nil if it has none.
+func computedFields(m model.ModelInterface) map[string]string {
+	if c, ok := m.(computedModel); ok {
+		return c.ComputedFields()
+	}
+	return nil
+}
+
+// storedColumns returns the columns of the fields of m other than the primary key and the fields, leaving out
+// the computed fields, which are never written. The columns are shared and must not be modified.
+func storedColumns(m model.ModelInterface) ([]string, []reflect.Value) {
+	info := modelInfoFor(m)
+	return info.stored, fieldsOf(reflect.ValueOf(m).Elem(), info.storedFields)
+}
+
+// selectedColumns returns columns with the computed fields of m replaced by their expression, named after their
+// column, as in "(first_name || ' ' || last_name) AS full_name".
+func (c *CRUD) selectedColumns(m model.ModelInterface, columns []string) []string {
+	computed := modelInfoFor(m).computed
+	if len(computed) == 0 {
+		return columns
+	}
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		selected[i] = column
+		if expression, ok := computed[column]; ok {
+			selected[i] = fmt.Sprintf("(%s) AS %s", expression, c.conn.Dialect().Quote(column))
+		}
+	}
+	return selected
+}
+
+// Create inserts a new record into the database and sets the primary key field of m to the id of the record.
+// On PostgreSQL every column is read back with RETURNING, so the fields of m also get the timestamps and
+// defaults set by the database; with other drivers, ids generated by the database are read with LastInsertId.
+// Models with the snowflake strategy get an id from the Snowflake of the connection unless one is set, and
+// models with the app strategy must have one set.
 func (c *CRUD) Create(m model.ModelInterface) error {
-	v := reflect.ValueOf(m).Elem()
-	t := v.Type()
+	q, targets, key, err := c.insertQuery(m)
+	if err != nil {
+		return err
+	}
+	query, params := q.Placeholders(c.conn.placeholders()).Build()
+
+	if len(targets) > 0 {
+		return TranslateError(c.conn.db.QueryRow(query, params...).Scan(targets...))
+	}
+	result, err := c.conn.db.Exec(query, params...)
+	if err != nil || !key.IsValid() {
+		return TranslateError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return setID(key, id)
+}
 
-	var fields []string
-	var values []interface{}
+// insertQuery returns the query inserting m, with the pointers the columns it returns are scanned into if the
+// connection supports RETURNING. Otherwise it returns the primary key field of m if its id is generated by
+// the database. A snowflake id is generated into the primary key field if it is zero.
+func (c *CRUD) insertQuery(m model.ModelInterface) (*Query, []interface{}, reflect.Value, error) {
+	if err := checkWritableModel(m); err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+	v := reflect.ValueOf(m).Elem()
+	strategy, err := idStrategyOf(m)
+	if err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+	info := modelInfoFor(m)
+	key, err := info.keyField(v)
+	if err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		if field.Name != "Model" {
-			fields = append(fields, field.Name)
-			values = append(values, v.Field(i).Interface())
+	// The columns are clipped, so appending the primary key copies them rather than changing the cached ones.
+	inserted, values := slices.Clip(info.stored), info.storedValues(v)
+	column := info.keyColumn
+	switch strategy {
+	case model.IDSnowflake:
+		if key.IsZero() {
+			if err := setID(key, c.conn.Snowflake().Next()); err != nil {
+				return nil, nil, reflect.Value{}, err
+			}
 		}
+		inserted, values = append(inserted, column), append(values, key.Interface())
+	case model.IDApp:
+		if key.IsZero() {
+			return nil, nil, reflect.Value{}, fmt.Errorf("model %s uses app-supplied ids but %s is not set", v.Type().Name(), m.PrimaryKey())
+		}
+		inserted, values = append(inserted, column), append(values, key.Interface())
 	}
 
-	q := NewQuery(m.TableName()).Insert(fields...)
-	query, _ := q.Build()
+	q, err := c.newQuery(m)
+	if err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+	q.Insert(inserted...).Values(values...)
+	if c.conn.supportsReturning() {
+		return q.Returning(c.selectedColumns(m, info.returned)...), info.targets(v), reflect.Value{}, nil
+	}
+	if !strategy.Generated() {
+		return q, nil, reflect.Value{}, nil
+	}
+	return q, nil, key, nil
+}
 
-	_, err := c.conn.db.Exec(query, values...)
-	return err
+// Save inserts m, or updates the record with its primary key if there is one, as Create and Update do. It can be
+// run again with the same records, such as those of a seed, without failing on their primary keys. Models whose
+// primary key is zero are inserted by Create. The update sets every column of m, so a record of another tenant is
+// never updated; with column tenancy, Save then returns ErrNotFound on PostgreSQL and SQLite, and does nothing on
+// MySQL.
+func (c *CRUD) Save(m model.ModelInterface) error {
+	q, targets, key, err := c.saveQuery(m)
+	if err != nil {
+		return err
+	}
+	query, params := q.Placeholders(c.conn.placeholders()).Build()
+
+	if len(targets) > 0 {
+		return TranslateError(c.conn.db.QueryRow(query, params...).Scan(targets...))
+	}
+	result, err := c.conn.db.Exec(query, params...)
+	if err != nil || !key.IsValid() {
+		return TranslateError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return setID(key, id)
 }
 
-// Read retrieves a record from the database
+// saveQuery returns the query of Save: the query of insertQuery, inserting the primary key of m if it is set and
+// updating the other columns on a conflict with it.
+func (c *CRUD) saveQuery(m model.ModelInterface) (*Query, []interface{}, reflect.Value, error) {
+	key, err := primaryKeyField(m)
+	if err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+	if key.IsZero() {
+		return c.insertQuery(m)
+	}
+	q, targets, _, err := c.insertQuery(m)
+	if err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+	info := modelInfoFor(m)
+	if !slices.Contains(q.fields, info.keyColumn) {
+		q.Insert(append(slices.Clip(q.fields), info.keyColumn)...).Values(append(slices.Clip(q.values), key.Interface())...)
+	}
+	return q.Upsert([]string{info.keyColumn}, info.stored), targets, reflect.Value{}, nil
+}
+
+// Read retrieves a record from the database. The primary key and the columns of the fields of m are selected by
+// name, including the fields of embedded structs, and the computed fields of models that have them are read as
+// their expression. To read records by other conditions, use Find.
 func (c *CRUD) Read(m model.ModelInterface, id interface{}) error {
-	q := NewQuery(m.TableName()).Where(fmt.Sprintf("%s = ?", m.PrimaryKey()), id)
-	query, params := q.Build()
+	q, err := c.readQuery(m, id)
+	if err != nil {
+		return err
+	}
+	query, params := q.Placeholders(c.conn.placeholders()).Build()
+	return TranslateError(c.conn.db.QueryRow(query, params...).Scan(modelInfoFor(m).targets(reflect.ValueOf(m).Elem())...))
+}
+
+// readQuery returns the query of Read, reading the primary key and the columns of m.
+func (c *CRUD) readQuery(m model.ModelInterface, id interface{}) (*Query, error) {
+	if _, err := primaryKeyField(m); err != nil {
+		return nil, err
+	}
+	q, err := c.newQuery(m)
+	if err != nil {
+		return nil, err
+	}
+	return q.Select(c.selectedColumns(m, modelInfoFor(m).returned)...).Where(c.primaryKeyCondition(m), id), nil
+}
 
-	row := c.conn.db.QueryRow(query, params...)
+// List reads a page of the records of a table into dest, a pointer to a slice of pointers to models such as
+// *[]*Post, ordered by primary key. A limit of 0 reads every record after offset. List only reads, so it also
+// works on read-only models such as those backed by views.
+func (c *CRUD) List(dest interface{}, limit, offset int) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice || slice.Elem().Type().Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("List needs a pointer to a slice of pointers to models, not %T", dest)
+	}
+	records := slice.Elem()
+	recordType := records.Type().Elem().Elem()
+	m, ok := reflect.New(recordType).Interface().(model.ModelInterface)
+	if !ok {
+		return fmt.Errorf("%s is not a model", recordType)
+	}
 
-	v := reflect.ValueOf(m).Elem()
-	fields := make([]interface{}, v.NumField())
-	for i := 0; i < v.NumField(); i++ {
-		fields[i] = v.Field(i).Addr().Interface()
+	q, err := c.listQuery(m, limit, offset)
+	if err != nil {
+		return err
+	}
+	query, params := q.Placeholders(c.conn.placeholders()).Build()
+	rows, err := c.conn.db.Query(query, params...)
+	if err != nil {
+		return TranslateError(err)
+	}
+	defer rows.Close()
+
+	info := modelInfoFor(m)
+	list := reflect.MakeSlice(records.Type(), 0, 0)
+	for rows.Next() {
+		record := reflect.New(recordType)
+		if err := rows.Scan(info.targets(record.Elem())...); err != nil {
+			return TranslateError(err)
+		}
+		list = reflect.Append(list, record)
 	}
+	if err := rows.Err(); err != nil {
+		return TranslateError(err)
+	}
+	records.Set(list)
+	return nil
+}
 
-	return row.Scan(fields...)
+// listQuery returns the query of List, reading the primary key and the columns of m.
+func (c *CRUD) listQuery(m model.ModelInterface, limit, offset int) (*Query, error) {
+	if _, err := primaryKeyField(m); err != nil {
+		return nil, err
+	}
+	q, err := c.newQuery(m)
+	if err != nil {
+		return nil, err
+	}
+	info := modelInfoFor(m)
+	return q.Select(c.selectedColumns(m, info.returned)...).OrderBy(info.keyColumn, "ASC").Limit(limit).Offset(offset), nil
 }
 
-// Update updates a record in the database
+// Update updates a record in the database, found by the primary key field of m, and returns ErrNotFound if
+// no record has the primary key. On PostgreSQL every column is read back with RETURNING, so the fields of m
+// also get the values set by triggers and defaults.
 func (c *CRUD) Update(m model.ModelInterface) error {
-	v := reflect.ValueOf(m).Elem()
-	t := v.Type()
+	q, targets, err := c.updateQuery(m)
+	if err != nil {
+		return err
+	}
+	query, params := q.Placeholders(c.conn.placeholders()).Build()
+
+	if len(targets) > 0 {
+		return TranslateError(c.conn.db.QueryRow(query, params...).Scan(targets...))
+	}
+	return c.expectOne(query, params...)
+}
 
-	var fields []string
-	var values []interface{}
+// updateQuery returns the query updating m, with the pointers the columns it returns are scanned into if
+// the connection supports RETURNING.
+func (c *CRUD) updateQuery(m model.ModelInterface) (*Query, []interface{}, error) {
+	if err := checkWritableModel(m); err != nil {
+		return nil, nil, err
+	}
+	v := reflect.ValueOf(m).Elem()
+	info := modelInfoFor(m)
+	key, err := info.keyField(v)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		if field.Name != "Model" && field.Name != m.PrimaryKey() {
-			fields = append(fields, field.Name)
-			values = append(values, v.Field(i).Interface())
-		}
+	q, err := c.newQuery(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	q.Update(info.stored...).Values(info.storedValues(v)...).Where(c.primaryKeyCondition(m), key.Interface())
+	if !c.conn.supportsReturning() {
+		return q, nil, nil
 	}
+	return q.Returning(c.selectedColumns(m, info.returned)...), info.targets(v), nil
+}
+
+// primaryKeyField returns the primary key field of m.
+func primaryKeyField(m model.ModelInterface) (reflect.Value, error) {
+	return modelInfoFor(m).keyField(reflect.ValueOf(m).Elem())
+}
 
-	id := v.FieldByName(m.PrimaryKey()).Interface()
-	q := NewQuery(m.TableName()).Update(fields...).Where(fmt.Sprintf("%s = ?", m.PrimaryKey()), id)
-	query, _ := q.Build()
+// fieldValues returns the values of fields.
+func fieldValues(fields []reflect.Value) []interface{} {
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		values[i] = field.Interface()
+	}
+	return values
+}
 
-	values = append(values, id)
-	_, err := c.conn.db.Exec(query, values...)
-	return err
+// setID stores an id generated by the database or the Snowflake of the connection in the integer primary key
+// field key.
+func setID(key reflect.Value, id int64) error {
+	switch key.Kind() {
+	case reflect.Int, reflect.Int64:
+		key.SetInt(id)
+	case reflect.Uint, reflect.Uint64:
+		key.SetUint(uint64(id))
+	default:
+		return fmt.Errorf("generated ids need a 64-bit integer primary key, not %s", key.Type())
+	}
+	return nil
 }
 
-// Delete removes a record from the database
+// Delete removes a record from the database, and returns ErrNotFound if no record has the primary key id.
 func (c *CRUD) Delete(m model.ModelInterface, id interface{}) error {
-	q := NewQuery(m.TableName()).Delete().Where(fmt.Sprintf("%s = ?", m.PrimaryKey()), id)
-	query, params := q.Build()
+	if err := checkWritableModel(m); err != nil {
+		return err
+	}
+	q, err := c.newQuery(m)
+	if err != nil {
+		return err
+	}
+	query, params := q.Delete().Where(c.primaryKeyCondition(m), id).Placeholders(c.conn.placeholders()).Build()
+	return c.expectOne(query, params...)
+}
+
+// expectOne executes query, and returns ErrNotFound if it changed no row.
+func (c *CRUD) expectOne(query string, args ...interface{}) error {
+	count, err := c.rowsAffected(query, args...)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ErrNoCondition is returned by UpdateWhere and DeleteWhere without a condition, so a forgotten condition
+// cannot change every record by accident. Pass "TRUE" to match every record on purpose.
+var ErrNoCondition = errors.New("a condition is required; use TRUE to match every record")
+
+// columnLister is implemented by models that list the columns of their table themselves, rather than
+// having them derived from their struct fields.
+type columnLister interface {
+	Columns() []string
+}
+
+// UpdateWhere sets the columns in set to their values in every record of the table of m matching cond,
+// and returns the number of records updated. Cond is written with "?" placeholders for args, like Query.Where.
+// The columns of set must be columns of m other than the primary key.
+func (c *CRUD) UpdateWhere(m model.ModelInterface, set map[string]interface{}, cond string, args ...interface{}) (int64, error) {
+	q, err := c.updateWhereQuery(m, set, cond, args...)
+	if err != nil {
+		return 0, err
+	}
+	query, params := q.Placeholders(c.conn.placeholders()).Build()
+	return c.rowsAffected(query, params...)
+}
+
+// updateWhereQuery returns the query of UpdateWhere, setting the columns in the order of their names.
+func (c *CRUD) updateWhereQuery(m model.ModelInterface, set map[string]interface{}, cond string, args ...interface{}) (*Query, error) {
+	if err := checkWritableModel(m); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(cond) == "" {
+		return nil, ErrNoCondition
+	}
+	if len(set) == 0 {
+		return nil, errors.New("no columns to set")
+	}
+	columns := make([]string, 0, len(set))
+	for column := range set {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	allowed := tableColumns(m)
+	if err := CheckColumns(columns, allowed[1:]...); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = set[column]
+	}
+
+	q, err := c.newQuery(m)
+	if err != nil {
+		return nil, err
+	}
+	return q.Update(columns...).Values(values...).Where(cond, args...), nil
+}
+
+// DeleteWhere deletes every record of the table of m matching cond, and returns the number of records deleted.
+// Cond is written with "?" placeholders for args, like Query.Where.
+func (c *CRUD) DeleteWhere(m model.ModelInterface, cond string, args ...interface{}) (int64, error) {
+	if err := checkWritableModel(m); err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(cond) == "" {
+		return 0, ErrNoCondition
+	}
+	q, err := c.newQuery(m)
+	if err != nil {
+		return 0, err
+	}
+	query, params := q.Delete().Where(cond, args...).Placeholders(c.conn.placeholders()).Build()
+	return c.rowsAffected(query, params...)
+}
+
+// rowsAffected executes query and returns the number of rows it changed.
+func (c *CRUD) rowsAffected(query string, args ...interface{}) (int64, error) {
+	result, err := c.conn.db.Exec(query, args...)
+	if err != nil {
+		return 0, TranslateError(err)
+	}
+	return result.RowsAffected()
+}
+
+// tableColumns returns the columns of the table of m, the primary key first. They are the struct fields of m,
+// unless m lists them with a Columns method.
+func tableColumns(m model.ModelInterface) []string {
+	key := naming.Column(m.PrimaryKey())
+	if l, ok := m.(columnLister); ok {
+		columns := []string{key}
+		for _, column := range l.Columns() {
+			if column != key {
+				columns = append(columns, column)
+			}
+		}
+		return columns
+	}
+	return append([]string{key}, modelInfoFor(m).stored...)
+}
 
-	_, err := c.conn.db.Exec(query, params...)
-	return err
+// primaryKeyCondition returns the condition matching the primary key column of m, quoted as needed.
+func (c *CRUD) primaryKeyCondition(m model.ModelInterface) string {
+	return fmt.Sprintf("%s = ?", c.conn.Dialect().Quote(naming.Column(m.PrimaryKey())))
 }
 
 // Query executes a custom query and returns the rows
 func (c *CRUD) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return c.conn.db.Query(query, args...)
+	rows, err := c.conn.db.Query(query, args...)
+	return rows, TranslateError(err)
 }
 
 // Exec executes a custom query without returning any rows
 func (c *CRUD) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return c.conn.db.Exec(query, args...)
+	result, err := c.conn.db.Exec(query, args...)
+	return result, TranslateError(err)
 }
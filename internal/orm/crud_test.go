@@ -0,0 +1,287 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	model.DefaultModel
+	ID   string
+	Kind string
+}
+
+func (e *event) TableName() string  { return "events" }
+func (e *event) IDStrategy() string { return "uuid" }
+
+type order struct {
+	model.DefaultModel
+	Total int
+}
+
+func (o *order) TableName() string  { return "orders" }
+func (o *order) IDStrategy() string { return "snowflake" }
+
+type tag struct {
+	model.DefaultModel
+	Label string
+}
+
+func (g *tag) TableName() string  { return "tags" }
+func (g *tag) IDStrategy() string { return "app" }
+
+func TestCRUD_InsertQuery(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+
+	n := &note{Body: "hi"}
+	q, targets, _, err := crud.insertQuery(n)
+	if assert.NoError(t, err) {
+		query, params := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "INSERT INTO notes (body) VALUES ($1) RETURNING id, body", query)
+		assert.Equal(t, []interface{}{"hi"}, params)
+		assert.Equal(t, []interface{}{&n.ID, &n.Body}, targets)
+	}
+
+	e := &event{Kind: "signup"}
+	q, targets, _, err = crud.insertQuery(e)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "INSERT INTO events (kind) VALUES (?) RETURNING id, kind", query)
+		assert.Equal(t, []interface{}{&e.ID, &e.Kind}, targets)
+	}
+
+	o := &order{Total: 5}
+	q, _, _, err = crud.insertQuery(o)
+	if assert.NoError(t, err) {
+		query, params := q.Build()
+		assert.Equal(t, "INSERT INTO orders (total, id) VALUES (?, ?) RETURNING id, total", query)
+		assert.NotZero(t, o.ID)
+		assert.Equal(t, []interface{}{5, o.ID}, params)
+	}
+
+	_, _, _, err = crud.insertQuery(&tag{Label: "go"})
+	assert.ErrorContains(t, err, "app-supplied ids")
+	g := &tag{Label: "go"}
+	g.ID = 7
+	q, _, _, err = crud.insertQuery(g)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "INSERT INTO tags (label, id) VALUES (?, ?) RETURNING id, label", query)
+	}
+}
+
+func TestCRUD_InsertQuery_LastInsertID(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "mysql"})
+
+	n := &note{Body: "hi"}
+	q, targets, key, err := crud.insertQuery(n)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "INSERT INTO notes (body) VALUES (?)", query)
+		assert.Empty(t, targets)
+		assert.NoError(t, setID(key, 12))
+		assert.Equal(t, uint(12), n.ID)
+	}
+
+	// Application ids are known before inserting, so there is nothing to read back.
+	_, _, key, err = crud.insertQuery(&order{Total: 5})
+	assert.NoError(t, err)
+	assert.False(t, key.IsValid())
+}
+
+func TestCRUD_SaveQuery(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+
+	// Without a primary key, the record is inserted as by Create.
+	n := &note{Body: "hi"}
+	q, _, _, err := crud.saveQuery(n)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "INSERT INTO notes (body) VALUES (?) RETURNING id, body", query)
+	}
+
+	n.ID = 3
+	q, targets, _, err := crud.saveQuery(n)
+	if assert.NoError(t, err) {
+		query, params := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "INSERT INTO notes (body, id) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET body = EXCLUDED.body RETURNING id, body", query)
+		assert.Equal(t, []interface{}{"hi", uint(3)}, params)
+		assert.Equal(t, []interface{}{&n.ID, &n.Body}, targets)
+	}
+
+	g := &tag{Label: "go"}
+	g.ID = 7
+	q, _, _, err = NewCRUD(&Connection{driver: "mysql"}).saveQuery(g)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "INSERT INTO tags (label, id) VALUES (?, ?) ON DUPLICATE KEY UPDATE label = VALUES(label)", query)
+	}
+}
+
+func TestCRUD_UpdateQuery(t *testing.T) {
+	n := &note{Body: "hi"}
+	n.ID = 3
+
+	q, targets, err := NewCRUD(&Connection{driver: "postgres"}).updateQuery(n)
+	if assert.NoError(t, err) {
+		query, params := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "UPDATE notes SET body = $1 WHERE id = $2 RETURNING id, body", query)
+		assert.Equal(t, []interface{}{"hi", uint(3)}, params)
+		assert.Equal(t, []interface{}{&n.ID, &n.Body}, targets)
+	}
+
+	q, targets, err = NewCRUD(&Connection{driver: "mysql"}).updateQuery(n)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "UPDATE notes SET body = ? WHERE id = ?", query)
+		assert.Empty(t, targets)
+	}
+}
+
+func TestCRUD_UpdateWhereQuery(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+
+	q, err := crud.updateWhereQuery(&note{}, map[string]interface{}{"body": "archived"}, "body LIKE ? OR id < ?", "old%", 10)
+	if assert.NoError(t, err) {
+		query, params := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "UPDATE notes SET body = $1 WHERE body LIKE $2 OR id < $3", query)
+		assert.Equal(t, []interface{}{"archived", "old%", 10}, params)
+	}
+
+	_, err = crud.updateWhereQuery(&note{}, map[string]interface{}{"body": "x"}, " ")
+	assert.ErrorIs(t, err, ErrNoCondition)
+	_, err = crud.updateWhereQuery(&note{}, map[string]interface{}{"id": 1}, "TRUE")
+	assert.ErrorContains(t, err, `column "id" is not allowed`)
+	_, err = crud.updateWhereQuery(&note{}, map[string]interface{}{"body = body; --": 1}, "TRUE")
+	assert.Error(t, err)
+
+	_, err = crud.DeleteWhere(&note{}, "")
+	assert.ErrorIs(t, err, ErrNoCondition)
+
+	// Conditions are grouped under the tenant scope, so an OR cannot reach the rows of other tenants.
+	scoped, err := NewCRUD(&Connection{driver: "postgres", tenancy: TenancyColumn}).ForTenant(WithTenant(context.Background(), "acme"))
+	if assert.NoError(t, err) {
+		q, err := scoped.updateWhereQuery(&note{}, map[string]interface{}{"body": "x"}, "a = ? OR b = ?", 1, 2)
+		if assert.NoError(t, err) {
+			query, _ := q.Build()
+			assert.Equal(t, "UPDATE notes SET body = ? WHERE (a = ? OR b = ?) AND tenant_id = ?", query)
+		}
+	}
+}
+
+func TestCRUD_ListQuery(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+
+	q, err := crud.listQuery(&note{}, 20, 40)
+	if assert.NoError(t, err) {
+		query, _ := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "SELECT id, body FROM notes ORDER BY id ASC LIMIT 20 OFFSET 40", query)
+	}
+
+	var notes []note
+	assert.ErrorContains(t, crud.List(&notes, 0, 0), "slice of pointers to models")
+}
+
+func TestCRUD_Read(t *testing.T) {
+	crud, connector := rowsCRUD(t, []string{"id", "body"}, []driver.Value{int64(3), "hi"})
+
+	n := &note{}
+	assert.NoError(t, crud.Read(n, 3))
+	assert.Equal(t, []string{"SELECT id, body FROM notes WHERE id = $1"}, connector.queries,
+		"the columns are selected by name, so the embedded DefaultModel is read like the other fields")
+	assert.Equal(t, uint(3), n.ID)
+	assert.Equal(t, "hi", n.Body)
+}
+
+type person struct {
+	model.DefaultModel
+	FirstName string
+	LastName  string
+	FullName  string
+}
+
+func (p *person) TableName() string { return "people" }
+func (p *person) ComputedFields() map[string]string {
+	return map[string]string{"full_name": "first_name || ' ' || last_name"}
+}
+
+func TestCRUD_ComputedFields(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	p := &person{FirstName: "Ada", LastName: "Lovelace"}
+
+	q, targets, _, err := crud.insertQuery(p)
+	if assert.NoError(t, err) {
+		query, params := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "INSERT INTO people (first_name, last_name) VALUES ($1, $2) "+
+			"RETURNING id, first_name, last_name, (first_name || ' ' || last_name) AS full_name", query)
+		assert.Equal(t, []interface{}{"Ada", "Lovelace"}, params)
+		assert.Equal(t, []interface{}{&p.ID, &p.FirstName, &p.LastName, &p.FullName}, targets)
+	}
+
+	q, _, err = crud.updateQuery(p)
+	if assert.NoError(t, err) {
+		query, _ := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "UPDATE people SET first_name = $1, last_name = $2 WHERE id = $3 "+
+			"RETURNING id, first_name, last_name, (first_name || ' ' || last_name) AS full_name", query)
+	}
+
+	q, err = crud.listQuery(p, 0, 0)
+	if assert.NoError(t, err) {
+		query, _ := q.Build()
+		assert.Equal(t, "SELECT id, first_name, last_name, (first_name || ' ' || last_name) AS full_name FROM people ORDER BY id ASC", query)
+	}
+
+	_, err = crud.updateWhereQuery(p, map[string]interface{}{"full_name": "x"}, "TRUE")
+	assert.ErrorContains(t, err, `column "full_name" is not allowed`)
+}
+
+// post is a model of the size CRUD is typically used with, for the benchmarks.
+type post struct {
+	model.DefaultModel
+	Title     string
+	Slug      string
+	Body      string
+	Views     int
+	Published bool
+	AuthorID  int
+}
+
+func (p *post) TableName() string { return "posts" }
+
+func BenchmarkCRUD_InsertQuery(b *testing.B) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	p := &post{Title: "Hello", Slug: "hello", Body: "...", Views: 1, AuthorID: 7}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := crud.insertQuery(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCRUD_UpdateQuery(b *testing.B) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	p := &post{Title: "Hello", Slug: "hello", Body: "...", Views: 1, AuthorID: 7}
+	p.ID = 1
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := crud.updateQuery(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCRUD_ListRow(b *testing.B) {
+	p := &post{}
+	info := modelInfoFor(p)
+	v := reflect.ValueOf(p).Elem()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = info.targets(v)
+	}
+}
@@ -0,0 +1,104 @@
+package orm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for text, want := range map[string]string{
+		"1.50":      "1.50",
+		"-0.05":     "-0.05",
+		"+7":        "7",
+		".5":        "0.5",
+		"-.5":       "-0.5",
+		"1.":        "1",
+		"1.5e2":     "150",
+		"12.345E-1": "1.2345",
+		"00012.10":  "12.10",
+	} {
+		d, err := ParseDecimal(text)
+		assert.NoError(t, err, text)
+		assert.Equal(t, want, d.String(), text)
+	}
+	for _, text := range []string{"", ".", "-", "1.2.3", "1.-5", "--5", "abc", "1e", "0x10"} {
+		_, err := ParseDecimal(text)
+		assert.Error(t, err, text)
+	}
+	assert.Equal(t, "0", Decimal{}.String())
+
+	// Huge exponents would take ages and gigabytes to expand, so they are refused.
+	for _, text := range []string{"1e999999999", "1e-999999999", "1e1001", "0." + strings.Repeat("1", 1001)} {
+		_, err := ParseDecimal(text)
+		assert.Error(t, err, text)
+	}
+	assert.Equal(t, model.MaxDecimalPrecision, maxDecimalScale)
+	assert.Panics(t, func() { MustParseDecimal("x") })
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a, b := MustParseDecimal("0.1"), MustParseDecimal("0.20")
+	assert.Equal(t, "0.30", a.Add(b).String(), "0.1 + 0.2 is exact")
+	assert.Equal(t, "-0.10", a.Sub(b).String())
+	assert.Equal(t, "0.020", a.Mul(b).String())
+	assert.Equal(t, "19.99", NewDecimal(1999, 2).String())
+	assert.Equal(t, "1200", NewDecimal(12, -2).String())
+
+	assert.Equal(t, 0, MustParseDecimal("1.5").Cmp(MustParseDecimal("1.50")))
+	assert.Equal(t, -1, a.Cmp(b))
+	assert.Equal(t, 1, b.Cmp(a))
+	assert.Equal(t, -1, a.Neg().Sign())
+
+	assert.Equal(t, "1.24", MustParseDecimal("1.235").Round(2).String())
+	assert.Equal(t, "-1.24", MustParseDecimal("-1.235").Round(2).String())
+	assert.Equal(t, "1.23", MustParseDecimal("1.2349").Round(2).String())
+	assert.Equal(t, "1.500", MustParseDecimal("1.5").Round(3).String())
+	assert.Equal(t, 2, MustParseDecimal("1.235").Round(2).Scale())
+	assert.Equal(t, 1.5, MustParseDecimal("1.50").Float64())
+}
+
+func TestDecimal_ValueAndScan(t *testing.T) {
+	value, err := MustParseDecimal("12345678901234567890.12").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901234567890.12", value)
+
+	var d Decimal
+	assert.NoError(t, d.Scan([]byte("19.99")))
+	assert.Equal(t, "19.99", d.String())
+	assert.NoError(t, d.Scan("0.10"))
+	assert.Equal(t, "0.10", d.String())
+	assert.NoError(t, d.Scan(int64(42)))
+	assert.Equal(t, "42", d.String())
+	assert.NoError(t, d.Scan(2.5))
+	assert.Equal(t, "2.5", d.String())
+	assert.NoError(t, d.Scan(nil))
+	assert.Equal(t, 0, d.Sign())
+	assert.Error(t, d.Scan(true))
+	assert.Error(t, d.Scan("ten"))
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Price Decimal }{MustParseDecimal("1.50")})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Price": "1.50"}`, string(data))
+
+	var v struct{ Price, Total Decimal }
+	assert.NoError(t, json.Unmarshal([]byte(`{"Price": "1.50", "Total": 12.345}`), &v))
+	assert.Equal(t, "1.50", v.Price.String())
+	assert.Equal(t, "12.345", v.Total.String(), "numbers keep their digits")
+	assert.Error(t, json.Unmarshal([]byte(`{"Price": true}`), &v))
+}
+
+func TestDecimal_Fits(t *testing.T) {
+	assert.True(t, MustParseDecimal("99.99").Fits(4, 2))
+	assert.True(t, MustParseDecimal("-1.5000").Fits(4, 2), "trailing zeros are not significant")
+	assert.False(t, MustParseDecimal("100").Fits(4, 2))
+	assert.False(t, MustParseDecimal("1.005").Fits(4, 2))
+	assert.True(t, MustParseDecimal("1e3").Fits(4, 0))
+	assert.False(t, MustParseDecimal("1e4").Fits(4, 0))
+}
@@ -0,0 +1,316 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EchoOutput is where connections opened with EchoSQL or ExplainOnly write the statements they run.
+var EchoOutput io.Writer = os.Stderr
+
+// echoMu keeps the statements echoed by concurrent connections from interleaving.
+var echoMu sync.Mutex
+
+// Redacted replaces the values bound to sensitive columns in echoed statements.
+const Redacted = "[REDACTED]"
+
+// sensitiveColumnPattern matches the names of columns whose values are never echoed.
+var sensitiveColumnPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|hash|api_?key|salt|credential)`)
+
+// comparedParamPattern matches a column compared with or assigned a bind parameter, as in "email = $2".
+var comparedParamPattern = regexp.MustCompile(`(?i)"?([a-z_][a-z0-9_]*)"?\s*(?:<>|!=|<=|>=|=|<|>|\s+i?like)\s*\$(\d+)`)
+
+// insertPattern matches the column list and the VALUES tuples of an INSERT statement.
+var insertPattern = regexp.MustCompile(`(?is)\bINSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\s*(.*)`)
+
+// tuplePattern matches a parenthesized list without nested parentheses, such as a VALUES tuple.
+var tuplePattern = regexp.MustCompile(`\(([^()]*)\)`)
+
+// sensitiveParams returns the numbers of the "$n" bind parameters of query that are compared with,
+// assigned to, or inserted into a sensitive column.
+func sensitiveParams(query string) map[int]bool {
+	sensitive := make(map[int]bool)
+	mark := func(column, param string) {
+		column = strings.Trim(strings.TrimSpace(column), `"`)
+		param = strings.TrimSpace(param)
+		if !sensitiveColumnPattern.MatchString(column) || !strings.HasPrefix(param, "$") {
+			return
+		}
+		if n, err := strconv.Atoi(param[1:]); err == nil {
+			sensitive[n] = true
+		}
+	}
+
+	for _, match := range comparedParamPattern.FindAllStringSubmatch(query, -1) {
+		mark(match[1], "$"+match[2])
+	}
+	if match := insertPattern.FindStringSubmatch(query); match != nil {
+		columns := strings.Split(match[1], ",")
+		for _, tuple := range tuplePattern.FindAllStringSubmatch(match[2], -1) {
+			for i, param := range strings.Split(tuple[1], ",") {
+				if i < len(columns) {
+					mark(columns[i], param)
+				}
+			}
+		}
+	}
+	return sensitive
+}
+
+// echoStatement writes query and its args to EchoOutput, with the values bound to sensitive columns
+// replaced by Redacted. Statements that were not executed are marked as such.
+func echoStatement(query string, args []driver.NamedValue, executed bool) {
+	var b strings.Builder
+	if executed {
+		b.WriteString("-- SQL\n")
+	} else {
+		b.WriteString("-- SQL (not executed)\n")
+	}
+	b.WriteString(strings.TrimSpace(query))
+	b.WriteString("\n")
+
+	if len(args) > 0 {
+		sensitive := sensitiveParams(query)
+		sorted := append([]driver.NamedValue{}, args...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Ordinal < sorted[j].Ordinal })
+		params := make([]string, len(sorted))
+		for i, arg := range sorted {
+			value := Redacted
+			if !sensitive[arg.Ordinal] {
+				value = formatValue(arg.Value)
+			}
+			params[i] = fmt.Sprintf("$%d = %s", arg.Ordinal, value)
+		}
+		b.WriteString("-- " + strings.Join(params, ", ") + "\n")
+	}
+
+	echoMu.Lock()
+	defer echoMu.Unlock()
+	io.WriteString(EchoOutput, b.String())
+}
+
+// formatValue writes a bound value the way it would appear in SQL.
+func formatValue(value driver.Value) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(v))
+	case time.Time:
+		return "'" + v.Format(time.RFC3339Nano) + "'"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// echoConnector opens connections that echo their statements, and with explainOnly set, skip the
+// statements that write.
+type echoConnector struct {
+	dsn         string
+	driver      driver.Driver
+	explainOnly bool
+}
+
+func (c *echoConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		var connector driver.Connector
+		if connector, err = dc.OpenConnector(c.dsn); err != nil {
+			return nil, err
+		}
+		conn, err = connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &echoConn{Conn: conn, explainOnly: c.explainOnly}, nil
+}
+
+func (c *echoConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// echoConn is a driver connection echoing the statements it runs.
+type echoConn struct {
+	driver.Conn
+	explainOnly bool
+}
+
+// skip reports whether query is only echoed, not executed.
+func (c *echoConn) skip(query string) bool {
+	return c.explainOnly && !IsReadOnlyStatement(query)
+}
+
+func (c *echoConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *echoConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if c.skip(query) {
+		return skippedStmt{query: query}, nil
+	}
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &echoStmt{Stmt: stmt, query: query}, nil
+}
+
+// ExecContext echoes query unless the driver cannot execute it directly, in which case database/sql
+// prepares it and the statement echoes it instead.
+func (c *echoConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.skip(query) {
+		echoStatement(query, args, false)
+		return driver.RowsAffected(0), nil
+	}
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	echoStatement(query, args, true)
+	return e.ExecContext(ctx, query, args)
+}
+
+// QueryContext echoes query like ExecContext. Skipped queries return no rows.
+func (c *echoConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.skip(query) {
+		echoStatement(query, args, false)
+		return emptyRows{}, nil
+	}
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	echoStatement(query, args, true)
+	return q.QueryContext(ctx, query, args)
+}
+
+// CheckNamedValue lets the driver convert the arguments it supports, such as the slices pgx accepts.
+func (c *echoConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *echoConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *echoConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *echoConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *echoConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// echoStmt is a prepared statement echoing its query every time it runs.
+type echoStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *echoStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	echoStatement(s.query, args, true)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	return s.Stmt.Exec(namedValues(args))
+}
+
+func (s *echoStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	echoStatement(s.query, args, true)
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	return s.Stmt.Query(namedValues(args))
+}
+
+// namedValues returns the values of args, for drivers without context support.
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// skippedStmt is a statement that writes, prepared in explain-only mode. It is echoed every time it
+// would run, but never sent to the database.
+type skippedStmt struct {
+	query string
+}
+
+func (s skippedStmt) Close() error  { return nil }
+func (s skippedStmt) NumInput() int { return -1 }
+
+func (s skippedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), toNamedValues(args))
+}
+
+func (s skippedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), toNamedValues(args))
+}
+
+func (s skippedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	echoStatement(s.query, args, false)
+	return driver.RowsAffected(0), nil
+}
+
+func (s skippedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	echoStatement(s.query, args, false)
+	return emptyRows{}, nil
+}
+
+// toNamedValues numbers values from 1, as database/sql does.
+func toNamedValues(values []driver.Value) []driver.NamedValue {
+	args := make([]driver.NamedValue, len(values))
+	for i, value := range values {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+	}
+	return args
+}
+
+// emptyRows is the result of a query skipped in explain-only mode.
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
@@ -0,0 +1,98 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingDriver is a driver whose connections record the statements they execute.
+type recordingDriver struct {
+	executed []string
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.executed = append(c.driver.executed, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.executed = append(c.driver.executed, query)
+	return emptyRows{}, nil
+}
+
+func withEchoOutput(t *testing.T) *bytes.Buffer {
+	var out bytes.Buffer
+	previous := EchoOutput
+	EchoOutput = &out
+	t.Cleanup(func() { EchoOutput = previous })
+	return &out
+}
+
+func TestEcho_RedactsSensitiveColumns(t *testing.T) {
+	out := withEchoOutput(t)
+	d := &recordingDriver{}
+	db := sql.OpenDB(&echoConnector{driver: d})
+	defer db.Close()
+
+	_, err := db.Exec("INSERT INTO users (username, password_hash) VALUES ($1, $2), ($3, $4)", "bob", "x", "o'neil", "y")
+	assert.NoError(t, err)
+	_, err = db.Exec(`UPDATE api_tokens SET "token_hash" = $1 WHERE id = $2`, "z", 7)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `-- SQL
+INSERT INTO users (username, password_hash) VALUES ($1, $2), ($3, $4)
+-- $1 = 'bob', $2 = [REDACTED], $3 = 'o''neil', $4 = [REDACTED]
+-- SQL
+UPDATE api_tokens SET "token_hash" = $1 WHERE id = $2
+-- $1 = [REDACTED], $2 = 7
+`, out.String())
+	assert.Len(t, d.executed, 2)
+}
+
+func TestEcho_ExplainOnly(t *testing.T) {
+	out := withEchoOutput(t)
+	d := &recordingDriver{}
+	db := sql.OpenDB(&echoConnector{driver: d, explainOnly: true})
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM posts WHERE status = $1", "spam")
+	if assert.NoError(t, err) {
+		affected, _ := result.RowsAffected()
+		assert.Zero(t, affected)
+	}
+	err = db.QueryRow("INSERT INTO posts (title) VALUES ($1) RETURNING id", "hi").Scan(new(int))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	rows, err := db.Query("SELECT * FROM posts")
+	if assert.NoError(t, err) {
+		rows.Close()
+	}
+
+	// Only the statement that reads reaches the database.
+	assert.Equal(t, []string{"SELECT * FROM posts"}, d.executed)
+	assert.Contains(t, out.String(), "-- SQL (not executed)\nDELETE FROM posts WHERE status = $1\n-- $1 = 'spam'\n")
+	assert.Contains(t, out.String(), "-- SQL\nSELECT * FROM posts\n")
+}
+
+func TestSensitiveParams(t *testing.T) {
+	assert.Equal(t, map[int]bool{2: true}, sensitiveParams("SELECT * FROM users WHERE email = $1 AND reset_token ILIKE $2"))
+	assert.Empty(t, sensitiveParams("SELECT * FROM users WHERE id = $1"))
+	assert.Equal(t, "<3 bytes>", formatValue([]byte("abc")))
+	assert.Equal(t, "NULL", formatValue(nil))
+}
@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned when no record matches, such as by Read, Update, and Delete for a primary key no
+// record has. It wraps sql.ErrNoRows, so errors.Is(err, sql.ErrNoRows) also matches it.
+var ErrNotFound = fmt.Errorf("record not found: %w", sql.ErrNoRows)
+
+// The errors of the constraints of the database. errors.Is matches a ConstraintError with the one of its Kind.
+var (
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrNotNullViolation    = errors.New("not-null violation")
+	ErrCheckViolation      = errors.New("check violation")
+)
+
+// ErrSerialization matches the errors of transactions aborted by a concurrent one, through a serialization
+// failure or a deadlock. Running the transaction again usually succeeds.
+var ErrSerialization = errors.New("serialization failure")
+
+// ConstraintError is a statement refused by a constraint of the database, such as a duplicate value of a
+// unique column. errors.Is matches it with its Kind, and errors.As with the error of the driver, such as a
+// *pq.Error. Its message names the constraint but not the values, which may be sensitive.
+type ConstraintError struct {
+	// Kind is ErrUniqueViolation, ErrForeignKeyViolation, ErrNotNullViolation, or ErrCheckViolation.
+	Kind       error
+	Constraint string
+	Table      string
+	// Column is only reported for not-null violations.
+	Column string
+	// Detail is the explanation of the server, such as "Key (email)=(a@example.com) already exists.".
+	Detail string
+	// Err is the error of the driver.
+	Err error
+}
+
+func (e *ConstraintError) Error() string {
+	switch {
+	case e.Column != "":
+		return fmt.Sprintf("%s on column %s of %s", e.Kind, e.Column, e.Table)
+	case e.Constraint != "":
+		return fmt.Sprintf("%s on constraint %s", e.Kind, e.Constraint)
+	default:
+		return e.Kind.Error()
+	}
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// constraintKinds maps the SQLSTATE codes of constraint violations to their errors.
+var constraintKinds = map[string]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23502": ErrNotNullViolation,
+	"23514": ErrCheckViolation,
+}
+
+// TranslateError maps an error of the database driver to the errors of this package: sql.ErrNoRows to
+// ErrNotFound, constraint violations to a *ConstraintError, and serialization failures and deadlocks to an
+// error matching ErrSerialization. Errors of the lib/pq and pgx drivers are recognized; other errors, and nil,
+// are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var constraint *ConstraintError
+	if errors.As(err, &constraint) {
+		return err
+	}
+	var code string
+	var pqErr *pq.Error
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pqErr):
+		code = string(pqErr.Code)
+		constraint = &ConstraintError{Constraint: pqErr.Constraint, Table: pqErr.Table, Column: pqErr.Column, Detail: pqErr.Detail}
+	case errors.As(err, &pgErr):
+		code = pgErr.Code
+		constraint = &ConstraintError{Constraint: pgErr.ConstraintName, Table: pgErr.TableName, Column: pgErr.ColumnName, Detail: pgErr.Detail}
+	default:
+		return err
+	}
+
+	switch code {
+	case "40001", "40P01":
+		return fmt.Errorf("%w: %w", ErrSerialization, err)
+	}
+	kind, ok := constraintKinds[code]
+	if !ok {
+		return err
+	}
+	constraint.Kind, constraint.Err = kind, err
+	return constraint
+}
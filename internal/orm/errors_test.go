@@ -0,0 +1,48 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	assert.Nil(t, TranslateError(nil))
+	assert.Equal(t, ErrNotFound, TranslateError(sql.ErrNoRows))
+	assert.ErrorIs(t, TranslateError(fmt.Errorf("reading user: %w", sql.ErrNoRows)), sql.ErrNoRows, "ErrNotFound wraps sql.ErrNoRows")
+
+	other := errors.New("boom")
+	assert.Equal(t, other, TranslateError(other))
+	syntax := &pq.Error{Code: "42601", Message: "syntax error"}
+	assert.Equal(t, error(syntax), TranslateError(syntax))
+
+	unique := &pq.Error{Code: "23505", Constraint: "users_email_key", Table: "users", Detail: "Key (email)=(a@example.com) already exists."}
+	err := TranslateError(fmt.Errorf("creating user: %w", unique))
+	assert.ErrorIs(t, err, ErrUniqueViolation)
+	assert.NotErrorIs(t, err, ErrForeignKeyViolation)
+	assert.EqualError(t, err, "unique violation on constraint users_email_key")
+	var constraint *ConstraintError
+	if assert.ErrorAs(t, err, &constraint) {
+		assert.Equal(t, "users", constraint.Table)
+		assert.Contains(t, constraint.Detail, "already exists")
+	}
+	var driverErr *pq.Error
+	assert.ErrorAs(t, err, &driverErr, "the driver error stays reachable")
+	assert.Equal(t, err, TranslateError(err), "translating twice changes nothing")
+
+	notNull := TranslateError(&pgconn.PgError{Code: "23502", TableName: "posts", ColumnName: "title"})
+	assert.ErrorIs(t, notNull, ErrNotNullViolation)
+	assert.EqualError(t, notNull, "not-null violation on column title of posts")
+	assert.ErrorIs(t, TranslateError(&pgconn.PgError{Code: "23503", ConstraintName: "posts_user_id_fkey"}), ErrForeignKeyViolation)
+	assert.ErrorIs(t, TranslateError(&pq.Error{Code: "23514"}), ErrCheckViolation)
+
+	for _, code := range []string{"40001", "40P01"} {
+		err := TranslateError(&pgconn.PgError{Code: code})
+		assert.ErrorIs(t, err, ErrSerialization, code)
+	}
+}
@@ -0,0 +1,192 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of a HealthMonitor.
+const (
+	// DefaultHealthInterval is how often a healthy database is pinged.
+	DefaultHealthInterval = 5 * time.Second
+	// DefaultMinBackoff is the delay before the first retry after a ping fails. It doubles with every failure.
+	DefaultMinBackoff = 500 * time.Millisecond
+	// DefaultMaxBackoff is the longest delay between retries.
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// pingTimeout bounds every ping, so a database that stopped answering counts as unreachable.
+const pingTimeout = 5 * time.Second
+
+// defaultMaxIdleConns is the number of idle connections database/sql keeps when none is configured.
+const defaultMaxIdleConns = 2
+
+// HealthStatus is the health of a database as last checked by a HealthMonitor.
+type HealthStatus struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+	// Since is when the database became healthy or unhealthy.
+	Since time.Time `json:"since"`
+	// CheckedAt is when the database was last pinged; it is zero before the first check.
+	CheckedAt time.Time `json:"checked_at"`
+	// Reconnects is the number of times the database came back after being unreachable.
+	Reconnects int `json:"reconnects"`
+}
+
+// HealthMonitor pings a database in the background of long-running commands, such as serve and the scheduler,
+// so they notice when the database goes away, for instance while its container restarts, and reconnect when
+// it comes back without a restart of the process. While the database is unreachable it is pinged with an
+// exponential backoff, and the idle connections of the pool, which do not survive a restart of the server,
+// are closed so statements run after the reconnect open new ones.
+//
+// Example usage:
+//
+//	health := orm.NewHealthMonitor(conn.GetDB(), log)
+//	go health.Run(ctx)
+//	if err := health.Err(); err != nil {
+//	    // the database is unreachable
+//	}
+type HealthMonitor struct {
+	db     *sql.DB
+	logger *logrus.Logger
+
+	Interval   time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Clock times the checks; nil is the system clock.
+	Clock clock.Clock
+
+	mu      sync.RWMutex
+	status  HealthStatus
+	backoff time.Duration
+}
+
+// NewHealthMonitor creates a HealthMonitor for db with the default interval and backoff, logging the changes
+// of health to logger. The database counts as unhealthy until it is first checked.
+func NewHealthMonitor(db *sql.DB, logger *logrus.Logger) *HealthMonitor {
+	return &HealthMonitor{
+		db:         db,
+		logger:     logger,
+		Interval:   DefaultHealthInterval,
+		MinBackoff: DefaultMinBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+		status:     HealthStatus{Error: "not checked yet"},
+	}
+}
+
+// Status returns the health of the database as of the last check.
+func (m *HealthMonitor) Status() HealthStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Err returns nil if the database was reachable at the last check, and the error of the check otherwise.
+func (m *HealthMonitor) Err() error {
+	status := m.Status()
+	if status.Healthy {
+		return nil
+	}
+	return errors.New("database is unreachable: " + status.Error)
+}
+
+// Check pings the database once, updates the status, and returns the error of the ping.
+func (m *HealthMonitor) Check(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	err := m.db.PingContext(pingCtx)
+	cancel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clk := clock.OrReal(m.Clock)
+	now := clk.Now()
+	switch {
+	case err == nil && !m.status.Healthy:
+		if !m.status.CheckedAt.IsZero() {
+			m.status.Reconnects++
+			m.logf(logrus.InfoLevel, "Reconnected to the database after %s", now.Sub(m.status.Since).Round(time.Millisecond))
+			m.discardIdle()
+		}
+		m.status.Healthy, m.status.Error, m.status.Since = true, "", now
+		m.backoff = 0
+	case err != nil && (m.status.Healthy || m.status.CheckedAt.IsZero()):
+		m.logf(logrus.WarnLevel, "Database is unreachable, reconnecting: %v", err)
+		m.discardIdle()
+		m.status.Healthy, m.status.Error, m.status.Since = false, err.Error(), now
+	case err != nil:
+		m.status.Error = err.Error()
+	}
+	m.status.CheckedAt = now
+	return err
+}
+
+// Run checks the database every Interval while it is healthy, and with a backoff while it is not, until ctx is done.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	for {
+		delay := m.Interval
+		if err := m.Check(ctx); err != nil {
+			delay = m.nextBackoff()
+		}
+		if !m.sleep(ctx, delay) {
+			return
+		}
+	}
+}
+
+// Wait checks the database with a backoff until it is healthy, and returns the error of ctx if ctx is done first.
+// Long-running commands wait for the database before loading what they need from it.
+func (m *HealthMonitor) Wait(ctx context.Context) error {
+	for {
+		if err := m.Check(ctx); err == nil {
+			return nil
+		}
+		if !m.sleep(ctx, m.nextBackoff()) {
+			return ctx.Err()
+		}
+	}
+}
+
+// sleep waits for d and reports whether ctx is still running.
+func (m *HealthMonitor) sleep(ctx context.Context, d time.Duration) bool {
+	timer := clock.OrReal(m.Clock).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// nextBackoff returns the delay before the next retry, doubling the previous one up to MaxBackoff.
+func (m *HealthMonitor) nextBackoff() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.backoff == 0 {
+		m.backoff = m.MinBackoff
+	} else {
+		m.backoff *= 2
+	}
+	if m.backoff > m.MaxBackoff {
+		m.backoff = m.MaxBackoff
+	}
+	return m.backoff
+}
+
+// discardIdle closes the idle connections of the pool, keeping the limit of idle connections.
+func (m *HealthMonitor) discardIdle() {
+	m.db.SetMaxIdleConns(0)
+	m.db.SetMaxIdleConns(defaultMaxIdleConns)
+}
+
+func (m *HealthMonitor) logf(level logrus.Level, format string, args ...interface{}) {
+	if m.logger != nil {
+		m.logger.Logf(level, format, args...)
+	}
+}
@@ -0,0 +1,92 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyDriver is a driver whose database can be taken down and brought back, like a restarted container.
+type flakyDriver struct {
+	down   atomic.Bool
+	opened atomic.Int32
+}
+
+func (d *flakyDriver) Open(name string) (driver.Conn, error) {
+	if d.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	d.opened.Add(1)
+	return &flakyConn{driver: d}, nil
+}
+
+type flakyConn struct {
+	driver *flakyDriver
+}
+
+func (c *flakyConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *flakyConn) Close() error                              { return nil }
+func (c *flakyConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c *flakyConn) Ping(ctx context.Context) error {
+	if c.driver.down.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func TestHealthMonitor_Reconnects(t *testing.T) {
+	d := &flakyDriver{}
+	db := sql.OpenDB(&echoConnector{driver: d})
+	defer db.Close()
+	m := NewHealthMonitor(db, nil)
+	assert.Error(t, m.Err(), "unhealthy until checked")
+
+	assert.NoError(t, m.Check(context.Background()))
+	assert.NoError(t, m.Err())
+	assert.Equal(t, 0, m.Status().Reconnects)
+
+	d.down.Store(true)
+	assert.Error(t, m.Check(context.Background()))
+	status := m.Status()
+	assert.False(t, status.Healthy)
+	assert.NotEmpty(t, status.Error)
+	assert.ErrorContains(t, m.Err(), "database is unreachable")
+
+	d.down.Store(false)
+	opened := d.opened.Load()
+	assert.NoError(t, m.Check(context.Background()))
+	status = m.Status()
+	assert.True(t, status.Healthy)
+	assert.Equal(t, 1, status.Reconnects)
+	assert.Greater(t, d.opened.Load(), opened, "a new connection is opened after the reconnect")
+}
+
+func TestHealthMonitor_Backoff(t *testing.T) {
+	m := NewHealthMonitor(nil, nil)
+	m.MinBackoff, m.MaxBackoff = time.Second, 5*time.Second
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		delays = append(delays, m.nextBackoff())
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+}
+
+func TestHealthMonitor_WaitStopsWithContext(t *testing.T) {
+	d := &flakyDriver{}
+	d.down.Store(true)
+	db := sql.OpenDB(&echoConnector{driver: d})
+	defer db.Close()
+	m := NewHealthMonitor(db, nil)
+	m.MinBackoff, m.MaxBackoff = time.Millisecond, time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m.Wait(ctx), context.DeadlineExceeded)
+}
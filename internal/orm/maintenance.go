@@ -0,0 +1,142 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// TableStats is the tuple statistics of a table, as listed in pg_stat_user_tables. Dead tuples are row versions
+// left behind by updates and deletes; until a vacuum reclaims them they bloat the table and slow down scans.
+type TableStats struct {
+	Table          string     `json:"table"`
+	LiveTuples     int64      `json:"live_tuples"`
+	DeadTuples     int64      `json:"dead_tuples"`
+	LastVacuum     *time.Time `json:"last_vacuum,omitempty"`
+	LastAutovacuum *time.Time `json:"last_autovacuum,omitempty"`
+	LastAnalyze    *time.Time `json:"last_analyze,omitempty"`
+}
+
+// DeadRatio returns the share of the tuples of the table that are dead, between 0 and 1.
+func (s TableStats) DeadRatio() float64 {
+	if s.LiveTuples+s.DeadTuples == 0 {
+		return 0
+	}
+	return float64(s.DeadTuples) / float64(s.LiveTuples+s.DeadTuples)
+}
+
+// LastVacuumed returns when the table was last vacuumed, by hand or by autovacuum, or nil if it never was.
+func (s TableStats) LastVacuumed() *time.Time {
+	if s.LastVacuum == nil || (s.LastAutovacuum != nil && s.LastAutovacuum.After(*s.LastVacuum)) {
+		return s.LastAutovacuum
+	}
+	return s.LastVacuum
+}
+
+// ListTableStats returns the tuple statistics of the tables of the current database, most dead tuples first.
+func (c *Connection) ListTableStats() ([]TableStats, error) {
+	rows, err := c.db.Query(`
+		SELECT relname, n_live_tup, n_dead_tup, last_vacuum, last_autovacuum, GREATEST(last_analyze, last_autoanalyze)
+		FROM pg_stat_user_tables
+		ORDER BY n_dead_tup DESC, relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table statistics: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []TableStats
+	for rows.Next() {
+		var s TableStats
+		var vacuum, autovacuum, analyze sql.NullTime
+		if err := rows.Scan(&s.Table, &s.LiveTuples, &s.DeadTuples, &vacuum, &autovacuum, &analyze); err != nil {
+			return nil, fmt.Errorf("failed to scan table statistics: %w", err)
+		}
+		s.LastVacuum, s.LastAutovacuum, s.LastAnalyze = nullTime(vacuum), nullTime(autovacuum), nullTime(analyze)
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// MaintenanceOptions selects the work done by Maintain.
+type MaintenanceOptions struct {
+	// Analyze also refreshes the planner statistics of every table.
+	Analyze bool
+	// VacuumFull lists tables rewritten with VACUUM FULL, which returns their unused space to the operating
+	// system but locks each table exclusively while it runs.
+	VacuumFull []string
+}
+
+// ParseMaintenanceOptions parses the target of a scheduled maintenance task: a comma-separated list of
+// "analyze" and "full:<table>" items, such as "analyze,full:events". An empty target is a plain vacuum.
+func ParseMaintenanceOptions(target string) (MaintenanceOptions, error) {
+	var opts MaintenanceOptions
+	for _, item := range strings.Split(target, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case item == "analyze":
+			opts.Analyze = true
+		case strings.HasPrefix(item, "full:"):
+			opts.VacuumFull = append(opts.VacuumFull, strings.TrimPrefix(item, "full:"))
+		default:
+			return opts, fmt.Errorf("unknown maintenance option %q: use analyze or full:<table>", item)
+		}
+	}
+	return opts, nil
+}
+
+// String returns opts in the format read by ParseMaintenanceOptions.
+func (o MaintenanceOptions) String() string {
+	var items []string
+	if o.Analyze {
+		items = append(items, "analyze")
+	}
+	for _, table := range o.VacuumFull {
+		items = append(items, "full:"+table)
+	}
+	return strings.Join(items, ",")
+}
+
+// Maintain vacuums the current database, reclaiming the space of dead tuples, and analyzes it if opts says so.
+// The tables in opts.VacuumFull are then rewritten with VACUUM FULL. The statements run outside of a
+// transaction, as PostgreSQL requires.
+func (c *Connection) Maintain(ctx context.Context, opts MaintenanceOptions) error {
+	if err := c.CheckWritable("vacuum the database"); err != nil {
+		return err
+	}
+	for _, table := range opts.VacuumFull {
+		if err := dialect.Validate(table); err != nil {
+			return err
+		}
+	}
+
+	statement := "VACUUM"
+	if opts.Analyze {
+		statement = "VACUUM (ANALYZE)"
+	}
+	if _, err := c.db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("error running %s: %w", statement, err)
+	}
+	for _, table := range opts.VacuumFull {
+		statement := "VACUUM FULL " + c.Dialect().Quote(table)
+		if opts.Analyze {
+			statement = "VACUUM (FULL, ANALYZE) " + c.Dialect().Quote(table)
+		}
+		if _, err := c.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("error running VACUUM FULL on %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMaintenanceOptions(t *testing.T) {
+	opts, err := ParseMaintenanceOptions("analyze, full:events,full:audit_log")
+	assert.NoError(t, err)
+	assert.Equal(t, MaintenanceOptions{Analyze: true, VacuumFull: []string{"events", "audit_log"}}, opts)
+	assert.Equal(t, "analyze,full:events,full:audit_log", opts.String())
+
+	opts, err = ParseMaintenanceOptions("")
+	assert.NoError(t, err)
+	assert.Equal(t, MaintenanceOptions{}, opts)
+
+	_, err = ParseMaintenanceOptions("reindex")
+	assert.Error(t, err)
+}
+
+func TestTableStats(t *testing.T) {
+	manual := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	auto := manual.Add(time.Hour)
+
+	s := TableStats{LiveTuples: 75, DeadTuples: 25}
+	assert.Equal(t, 0.25, s.DeadRatio())
+	assert.Nil(t, s.LastVacuumed())
+
+	s.LastVacuum = &manual
+	assert.Equal(t, &manual, s.LastVacuumed())
+	s.LastAutovacuum = &auto
+	assert.Equal(t, &auto, s.LastVacuumed())
+
+	assert.Equal(t, 0.0, TableStats{}.DeadRatio())
+}
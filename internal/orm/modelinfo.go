@@ -0,0 +1,127 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+)
+
+// modelInfo is what CRUD needs to know of a model type: where its primary key field is, and the columns of its
+// other fields and where those are. It is found by reflecting over the type once and cached by modelInfoFor, so
+// reading and writing records only follows the indexes. Its slices are shared and must not be modified.
+type modelInfo struct {
+	name string
+	// key is the index of the primary key field keyName, or nil if the type has none.
+	key       []int
+	keyName   string
+	keyColumn string
+	// columns are the columns of the fields other than the primary key, at the indexes fields.
+	columns []string
+	fields  [][]int
+	// stored are the columns of the fields that are not computed, at the indexes storedFields.
+	stored       []string
+	storedFields [][]int
+	// returned are the columns read back into a record: the primary key followed by columns.
+	returned []string
+	// computed are the expressions of the computed fields by column, as returned by ComputedFields.
+	computed map[string]string
+}
+
+// modelInfoKey identifies a cached modelInfo. The primary key is part of it, as it is returned by a method of
+// the model rather than declared by its type, and so is the naming convention the columns are named by.
+type modelInfoKey struct {
+	t          reflect.Type
+	key        string
+	convention naming.Convention
+}
+
+// modelInfos caches the modelInfo of every model type CRUD has seen, by modelInfoKey.
+var modelInfos sync.Map
+
+// modelInfoFor returns the modelInfo of the type of m. The computed fields of a model are taken to be the same
+// for every record of its type, as they are for generated models.
+func modelInfoFor(m model.ModelInterface) *modelInfo {
+	k := modelInfoKey{t: reflect.TypeOf(m).Elem(), key: m.PrimaryKey(), convention: naming.Current()}
+	if info, ok := modelInfos.Load(k); ok {
+		return info.(*modelInfo)
+	}
+	info, _ := modelInfos.LoadOrStore(k, newModelInfo(k.t, k.key, computedFields(m)))
+	return info.(*modelInfo)
+}
+
+// newModelInfo reflects over the struct type t of a model whose primary key field is named key.
+func newModelInfo(t reflect.Type, key string, computed map[string]string) *modelInfo {
+	info := &modelInfo{name: t.Name(), keyName: key, keyColumn: naming.Column(key), computed: computed}
+	if field, ok := t.FieldByName(key); ok {
+		info.key = field.Index
+	}
+	info.columns, info.fields = modelColumns(t, key, nil, nil, nil)
+	for i, column := range info.columns {
+		if _, ok := computed[column]; !ok {
+			info.stored = append(info.stored, column)
+			info.storedFields = append(info.storedFields, info.fields[i])
+		}
+	}
+	info.returned = append([]string{info.keyColumn}, info.columns...)
+	return info
+}
+
+// modelColumns appends the columns of the fields of t, and the indexes of the fields, to columns and fields,
+// descending into embedded structs. Index is the index of t in the model. The Model struct embedded by
+// DefaultModel and the fields named key are skipped.
+func modelColumns(t reflect.Type, key string, index []int, columns []string, fields [][]int) ([]string, [][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.Name == "Model" || field.Name == key || !field.IsExported():
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			columns, fields = modelColumns(field.Type, key, append(slices.Clip(index), i), columns, fields)
+		default:
+			columns = append(columns, naming.Column(field.Name))
+			fields = append(fields, append(slices.Clip(index), i))
+		}
+	}
+	return columns, fields
+}
+
+// keyField returns the primary key field of v, a model of the type of info.
+func (info *modelInfo) keyField(v reflect.Value) (reflect.Value, error) {
+	if info.key == nil {
+		return reflect.Value{}, fmt.Errorf("model %s has no primary key field %s", info.name, info.keyName)
+	}
+	return v.FieldByIndex(info.key), nil
+}
+
+// fieldsOf returns the fields of v at indexes.
+func fieldsOf(v reflect.Value, indexes [][]int) []reflect.Value {
+	fields := make([]reflect.Value, len(indexes))
+	for i, index := range indexes {
+		fields[i] = v.FieldByIndex(index)
+	}
+	return fields
+}
+
+// storedValues returns the values of the fields of v that are stored, in the order of info.stored, with times
+// in UTC.
+func (info *modelInfo) storedValues(v reflect.Value) []interface{} {
+	values := make([]interface{}, len(info.storedFields))
+	for i, index := range info.storedFields {
+		values[i] = storedValue(v.FieldByIndex(index))
+	}
+	return values
+}
+
+// targets returns the pointers the columns of info.returned are scanned into: the primary key field of v, then
+// its other fields, with times read in UTC. V must have a primary key field.
+func (info *modelInfo) targets(v reflect.Value) []interface{} {
+	targets := make([]interface{}, 0, len(info.returned))
+	targets = append(targets, v.FieldByIndex(info.key).Addr().Interface())
+	for _, index := range info.fields {
+		targets = append(targets, scanTarget(v.FieldByIndex(index)))
+	}
+	return targets
+}
@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+// Audit is embedded by taggedPost, whose columns then include its fields.
+type Audit struct {
+	Editor string
+	Reason string
+}
+
+type taggedPost struct {
+	model.DefaultModel
+	Title string
+	Audit
+	Label string
+}
+
+func (p *taggedPost) TableName() string { return "tagged_posts" }
+
+func TestModelInfoFor(t *testing.T) {
+	info := modelInfoFor(&taggedPost{})
+	assert.Same(t, info, modelInfoFor(&taggedPost{}), "the info of a type is reflected once")
+	assert.Equal(t, "id", info.keyColumn)
+	assert.Equal(t, []string{"title", "editor", "reason", "label"}, info.columns)
+	assert.Equal(t, info.columns, info.stored)
+	assert.Equal(t, []string{"id", "title", "editor", "reason", "label"}, info.returned)
+
+	p := &taggedPost{Title: "Hello", Label: "go"}
+	p.ID = 3
+	key, err := primaryKeyField(p)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(3), key.Interface())
+	}
+	assert.Equal(t, []interface{}{&p.ID, &p.Title, &p.Editor, &p.Reason, &p.Label}, info.targets(reflect.ValueOf(p).Elem()))
+
+	computed := modelInfoFor(&person{})
+	assert.Equal(t, []string{"first_name", "last_name", "full_name"}, computed.columns)
+	assert.Equal(t, []string{"first_name", "last_name"}, computed.stored)
+}
+
+func TestModelInfoFor_NamingConvention(t *testing.T) {
+	assert.NoError(t, naming.SetConvention(naming.Convention{Columns: naming.CamelCase}))
+	t.Cleanup(func() { naming.SetConvention(naming.Convention{}) })
+
+	info := modelInfoFor(&person{})
+	assert.Equal(t, []string{"firstName", "lastName", "fullName"}, info.columns)
+	assert.Equal(t, "id", info.keyColumn)
+}
+
+type keyless struct {
+	model.DefaultModel
+	Name string
+}
+
+func (k *keyless) TableName() string  { return "keyless" }
+func (k *keyless) PrimaryKey() string { return "Code" }
+
+func TestModelInfoFor_NoPrimaryKey(t *testing.T) {
+	_, err := primaryKeyField(&keyless{})
+	assert.ErrorContains(t, err, "model keyless has no primary key field Code")
+}
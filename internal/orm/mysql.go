@@ -0,0 +1,4 @@
+package orm
+
+// The driver of github.com/go-sql-driver/mysql, which also connects to MariaDB, registers itself as "mysql".
+import _ "github.com/go-sql-driver/mysql"
@@ -0,0 +1,25 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConnection_MySQL(t *testing.T) {
+	// Opening the database does not connect to the server.
+	conn, err := NewConnection(&config.DatabaseConfig{Driver: "mysql", Host: "localhost", Port: 3306, Name: "grayv"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, dialect.MySQL, conn.Dialect())
+	assert.Equal(t, Question, conn.placeholders())
+	assert.False(t, conn.supportsReturning())
+
+	query, params := conn.NewQuery("order").Select("id", "desc").Where("id = ?", 7).Build()
+	assert.Equal(t, "SELECT id, `desc` FROM `order` WHERE id = ?", query)
+	assert.Equal(t, []interface{}{7}, params)
+}
@@ -0,0 +1,142 @@
+package orm
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// errNoCopy is returned by copyRowsPgx, before it reads any row, when the connection cannot stream rows with COPY.
+var errNoCopy = errors.New("COPY is not available on this connection")
+
+// pgxConn returns the pgx connection underneath the driver connection dc, unwrapping the connections of
+// otelsql. It reports false for connections of other drivers and for echoing connections, whose statements
+// must go through database/sql to be echoed or skipped.
+func pgxConn(dc interface{}) (*pgx.Conn, bool) {
+	for {
+		switch c := dc.(type) {
+		case *stdlib.Conn:
+			return c.Conn(), true
+		case *echoConn:
+			return nil, false
+		case interface{ Raw() driver.Conn }:
+			dc = c.Raw()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// copyRowsPgx streams rows to the server with COPY FROM STDIN over the native protocol of pgx. The rows are
+// sent in the text format, like lib/pq does, so strings such as CSV fields load into columns of any type.
+// COPY is a single statement, so either every row is loaded or none is.
+func (b *BulkLoader) copyRowsPgx(table string, columns []string, rows RowSource) (int64, error) {
+	ctx := context.Background()
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	statement := fmt.Sprintf("COPY %s (%s) FROM STDIN", b.dialect.Quote(table), strings.Join(b.dialect.QuoteAll(columns), ", "))
+	var count int64
+	err = conn.Raw(func(dc interface{}) error {
+		pc, ok := pgxConn(dc)
+		if !ok {
+			return errNoCopy
+		}
+
+		r, w := io.Pipe()
+		written := make(chan error, 1)
+		go func() {
+			err := writeCopyRows(w, len(columns), rows)
+			w.CloseWithError(err)
+			written <- err
+		}()
+		tag, copyErr := pc.PgConn().CopyFrom(ctx, r, statement)
+		// Closing the reader unblocks the writer if the server stopped the copy early.
+		r.Close()
+		if err := <-written; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			return err
+		}
+		if copyErr != nil {
+			return fmt.Errorf("error copying into %s: %w", table, copyErr)
+		}
+		count = tag.RowsAffected()
+		return nil
+	})
+	return count, err
+}
+
+// writeCopyRows writes rows to w in the text format of COPY, one line per row with tab-separated values.
+func writeCopyRows(w io.Writer, columns int, rows RowSource) error {
+	buf := bufio.NewWriterSize(w, 64*1024)
+	var line []byte
+	for n := 1; ; n++ {
+		row, err := rows.Next()
+		if err == io.EOF {
+			return buf.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if len(row) != columns {
+			return fmt.Errorf("row %d has %d values, want %d", n, len(row), columns)
+		}
+
+		line = line[:0]
+		for i, value := range row {
+			if i > 0 {
+				line = append(line, '\t')
+			}
+			if line, err = appendCopyValue(line, value); err != nil {
+				return fmt.Errorf("row %d: %w", n, err)
+			}
+		}
+		line = append(line, '\n')
+		if _, err := buf.Write(line); err != nil {
+			return err
+		}
+	}
+}
+
+// copyEscaper escapes the characters with a meaning in the text format of COPY.
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// appendCopyValue appends value to b in the text format of COPY. Values are first converted like database/sql
+// converts query arguments, so driver.Valuer implementations such as Vector are supported.
+func appendCopyValue(b []byte, value interface{}) ([]byte, error) {
+	value, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return append(b, `\N`...), nil
+	case string:
+		return append(b, copyEscaper.Replace(v)...), nil
+	case []byte:
+		// bytea accepts its hex format, whose leading backslash must itself be escaped.
+		return append(append(b, `\\x`...), hex.EncodeToString(v)...), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case float64:
+		return strconv.AppendFloat(b, v, 'g', -1, 64), nil
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case time.Time:
+		return v.AppendFormat(b, time.RFC3339Nano), nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
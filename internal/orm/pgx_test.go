@@ -0,0 +1,40 @@
+package orm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCopyRows(t *testing.T) {
+	created := time.Date(2024, 9, 1, 12, 30, 0, 0, time.UTC)
+	rows := sliceSource{
+		{"a\tb", int64(7), nil},
+		{`back\slash`, 1.5, []byte{0xde, 0xad}},
+		{"two\nlines", true, created},
+		{Vector{1, 2}, "x", "y"},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, writeCopyRows(&out, 3, &rows))
+	assert.Equal(t, strings.Join([]string{
+		`a\tb	7	\N`,
+		`back\\slash	1.5	\\xdead`,
+		`two\nlines	true	2024-09-01T12:30:00Z`,
+		`[1,2]	x	y`,
+	}, "\n")+"\n", out.String())
+
+	rows = sliceSource{{"a"}}
+	assert.EqualError(t, writeCopyRows(&out, 2, &rows), "row 1 has 1 values, want 2")
+	rows = sliceSource{{struct{}{}}}
+	assert.ErrorContains(t, writeCopyRows(&out, 1, &rows), "row 1:")
+}
+
+func TestPgxConn(t *testing.T) {
+	_, ok := pgxConn(&recordingConn{})
+	assert.False(t, ok)
+	_, ok = pgxConn(&echoConn{Conn: &recordingConn{}})
+	assert.False(t, ok, "echoing connections load rows with statements that can be echoed")
+}
@@ -2,18 +2,60 @@ package orm
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// PlaceholderFormat selects how Build writes bind parameters.
+type PlaceholderFormat int
+
+const (
+	// Question writes parameters as "?", as used by MySQL and SQLite.
+	Question PlaceholderFormat = iota
+	// Dollar writes parameters as "$1", "$2", ..., as required by PostgreSQL.
+	Dollar
 )
 
 // Query represents a database query
 type Query struct {
-	table     string
-	operation string
-	fields    []string
-	where     []string
-	params    []interface{}
-	limit     int
-	offset    int
+	table        string
+	source       *Query
+	alias        string
+	operation    string
+	distinct     bool
+	fields       []string
+	values       []interface{}
+	rows         [][]interface{}
+	where        []string
+	params       []interface{}
+	groupBy      []string
+	having       []string
+	havingParams []interface{}
+	orderBy      []ordering
+	limit        int
+	offset       int
+	returning    []string
+	upsert       *upsert
+	placeholders PlaceholderFormat
+	dialect      dialect.Dialect
+	tenant       tenantScope
+}
+
+// ordering is a column of the ORDER BY clause. With an operator, the rows are ordered by the result of
+// applying it to the column and param instead.
+type ordering struct {
+	field    string
+	desc     bool
+	operator string
+	param    interface{}
+}
+
+// upsert is the conflict handling of an INSERT query set by Upsert.
+type upsert struct {
+	conflict []string
+	update   []string
 }
 
 // NewQuery creates a new Query instance
@@ -38,6 +80,52 @@ func (q *Query) Where(condition string, params ...interface{}) *Query {
 	return q
 }
 
+// WhereRaw adds a raw SQL condition, such as "a = ? OR b IS NULL". The condition is wrapped in
+// parentheses, so an OR inside it does not combine with the other conditions.
+// Never build the condition from user input; pass values as "?" parameters instead.
+func (q *Query) WhereRaw(condition string, params ...interface{}) *Query {
+	return q.Where("("+condition+")", params...)
+}
+
+// WhereIn adds a "field IN (...)" condition. values is either a *Query, whose SELECT becomes a subquery,
+// or a slice of values, which are passed as parameters. An empty slice matches no rows.
+func (q *Query) WhereIn(field string, values interface{}) *Query {
+	if sub, ok := values.(*Query); ok {
+		query, params := sub.build()
+		return q.Where(fmt.Sprintf("%s IN (%s)", field, query), params...)
+	}
+
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return q.Where(fmt.Sprintf("%s IN (?)", field), values)
+	}
+	if v.Len() == 0 {
+		return q.Where("1 = 0")
+	}
+
+	placeholders := make([]string, v.Len())
+	params := make([]interface{}, v.Len())
+	for i := range placeholders {
+		placeholders[i] = "?"
+		params[i] = v.Index(i).Interface()
+	}
+	return q.Where(fmt.Sprintf("%s IN (%s)", field, strings.Join(placeholders, ", ")), params...)
+}
+
+// As names the query when it is used as a subquery in From.
+func (q *Query) As(alias string) *Query {
+	q.alias = alias
+	return q
+}
+
+// From selects from the result of a subquery instead of a table. The subquery is named by As,
+// or "sub" if it has no name, and its parameters come ahead of those of the query.
+// Example usage: NewQuery("").From(NewQuery("orders").Select("user_id", "total").As("t")).Where("t.total > ?", 100)
+func (q *Query) From(source *Query) *Query {
+	q.source = source
+	return q
+}
+
 // Limit sets the LIMIT clause
 func (q *Query) Limit(limit int) *Query {
 	q.limit = limit
@@ -50,6 +138,57 @@ func (q *Query) Offset(offset int) *Query {
 	return q
 }
 
+// Distinct makes a SELECT return each distinct row once.
+func (q *Query) Distinct() *Query {
+	q.distinct = true
+	return q
+}
+
+// GroupBy adds columns or expressions to the GROUP BY clause of a SELECT.
+// Example usage: NewQuery("posts").Select("author_id", "COUNT(*) AS posts").GroupBy("author_id")
+func (q *Query) GroupBy(fields ...string) *Query {
+	q.groupBy = append(q.groupBy, fields...)
+	return q
+}
+
+// Having adds a condition on the groups of a SELECT to the HAVING clause, combined with the other conditions
+// using AND. Its parameters follow those of the WHERE clause.
+// Example usage: query.GroupBy("author_id").Having("COUNT(*) > ?", 10)
+func (q *Query) Having(condition string, params ...interface{}) *Query {
+	q.having = append(q.having, condition)
+	q.havingParams = append(q.havingParams, params...)
+	return q
+}
+
+// OrderBy adds a column to the ORDER BY clause. direction is "ASC" or "DESC"; anything else sorts ascending.
+func (q *Query) OrderBy(field, direction string) *Query {
+	q.orderBy = append(q.orderBy, ordering{field: field, desc: strings.EqualFold(direction, "DESC")})
+	return q
+}
+
+// OrderBySimilarity orders the rows by the cosine distance between the pgvector column field and
+// embedding, most similar first. Combine it with Limit for nearest-neighbour searches.
+// Example usage: NewQuery("documents").OrderBySimilarity("embedding", embedding).Limit(5)
+func (q *Query) OrderBySimilarity(field string, embedding []float32) *Query {
+	q.orderBy = append(q.orderBy, ordering{field: field, operator: "<=>", param: Vector(embedding)})
+	return q
+}
+
+// Placeholders sets the bind parameter format. Conditions are always written with "?",
+// and Build rewrites them into the chosen format.
+func (q *Query) Placeholders(format PlaceholderFormat) *Query {
+	q.placeholders = format
+	return q
+}
+
+// Dialect sets how Build quotes the table and the field names that need quoting, such as mixed-case
+// names and reserved words. Fields that are expressions, such as "COUNT(*)", and conditions are written
+// as given. The default quotes like PostgreSQL.
+func (q *Query) Dialect(d dialect.Dialect) *Query {
+	q.dialect = d
+	return q
+}
+
 // Insert prepares an INSERT query
 func (q *Query) Insert(fields ...string) *Query {
 	q.operation = "INSERT"
@@ -64,43 +203,158 @@ func (q *Query) Update(fields ...string) *Query {
 	return q
 }
 
+// Values sets the values written by an INSERT or UPDATE query, in the order of its fields.
+// Build returns them ahead of the WHERE parameters.
+func (q *Query) Values(values ...interface{}) *Query {
+	q.values = values
+	return q
+}
+
+// AddRow adds a row of values to an INSERT query after those of Values, so several rows are inserted by one
+// statement. Build returns the values row by row.
+func (q *Query) AddRow(values ...interface{}) *Query {
+	q.rows = append(q.rows, values)
+	return q
+}
+
 // Delete prepares a DELETE query
 func (q *Query) Delete() *Query {
 	q.operation = "DELETE"
 	return q
 }
 
+// Upsert turns an INSERT query into an insert-or-update: a row that conflicts with an existing one on the unique
+// conflict columns updates the update columns of the existing row to their inserted values instead, and without
+// update columns it is skipped. PostgreSQL and SQLite write ON CONFLICT, and need the conflict columns when there
+// are update columns. MySQL writes ON DUPLICATE KEY UPDATE, which applies to any unique key of the table, so the
+// conflict columns are only used to skip rows.
+// With column tenancy, a row of another tenant is left as it is.
+func (q *Query) Upsert(conflictColumns []string, updateColumns []string) *Query {
+	q.upsert = &upsert{conflict: conflictColumns, update: updateColumns}
+	return q
+}
+
+// Returning adds a RETURNING clause to an INSERT, UPDATE, or DELETE query, as supported by PostgreSQL and SQLite.
+// Like the fields of Select, the fields may be columns or expressions, which are kept as they are.
+func (q *Query) Returning(fields ...string) *Query {
+	q.returning = fields
+	return q
+}
+
 // Build constructs the SQL query
 func (q *Query) Build() (string, []interface{}) {
+	query, params := q.build()
+	return Rebind(query, q.placeholders), params
+}
+
+// build constructs the SQL query with "?" placeholders, so it can be embedded in another query.
+func (q *Query) build() (string, []interface{}) {
 	var query strings.Builder
-	var params []interface{}
+	params := append([]interface{}{}, q.values...)
+	table := q.dialect.QuoteExpr(q.table)
+	fields := make([]string, len(q.fields))
+	for i, field := range q.fields {
+		fields[i] = q.dialect.QuoteExpr(field)
+	}
+	where, whereParams := q.where, q.params
+
+	switch q.tenant.mode {
+	case TenancySchema:
+		table = q.dialect.Quote(TenantSchema(q.tenant.id)) + "." + table
+	case TenancyColumn:
+		if q.operation == "INSERT" {
+			fields = append(fields, q.dialect.Quote(TenantColumn))
+			params = append(params, q.tenant.id)
+		} else {
+			// The other conditions are grouped, so an OR among them cannot reach rows of other tenants.
+			where = []string{q.dialect.Quote(TenantColumn) + " = ?"}
+			if len(q.where) > 0 {
+				where = []string{"(" + strings.Join(q.where, " AND ") + ")", where[0]}
+			}
+			whereParams = append(append([]interface{}{}, whereParams...), q.tenant.id)
+		}
+	}
 
 	switch q.operation {
-	case "SELECT":
-		query.WriteString(fmt.Sprintf("SELECT %s FROM %s", strings.Join(q.fields, ", "), q.table))
+	case "SELECT", "":
+		if q.source != nil {
+			sub, subParams := q.source.build()
+			alias := q.source.alias
+			if alias == "" {
+				alias = "sub"
+			}
+			table = fmt.Sprintf("(%s) AS %s", sub, q.dialect.Quote(alias))
+			params = append(params, subParams...)
+		}
+		selectClause := "SELECT "
+		if q.distinct {
+			selectClause += "DISTINCT "
+		}
+		query.WriteString(fmt.Sprintf("%s%s FROM %s", selectClause, strings.Join(fields, ", "), table))
 	case "INSERT":
-		placeholders := make([]string, len(q.fields))
-		for i := range placeholders {
-			placeholders[i] = "?"
+		placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ")"
+		rows := []string{placeholders}
+		for _, row := range q.rows {
+			rows = append(rows, placeholders)
+			params = append(params, row...)
+			if q.tenant.mode == TenancyColumn {
+				params = append(params, q.tenant.id)
+			}
+		}
+		query.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+			table, strings.Join(fields, ", "), strings.Join(rows, ", ")))
+		if q.upsert != nil {
+			query.WriteString(q.upsertClause(table, fields))
 		}
-		query.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-			q.table, strings.Join(q.fields, ", "), strings.Join(placeholders, ", ")))
 	case "UPDATE":
-		query.WriteString(fmt.Sprintf("UPDATE %s SET ", q.table))
-		for i, field := range q.fields {
+		query.WriteString(fmt.Sprintf("UPDATE %s SET ", table))
+		for i, field := range fields {
 			if i > 0 {
 				query.WriteString(", ")
 			}
 			query.WriteString(fmt.Sprintf("%s = ?", field))
 		}
 	case "DELETE":
-		query.WriteString(fmt.Sprintf("DELETE FROM %s", q.table))
+		query.WriteString(fmt.Sprintf("DELETE FROM %s", table))
 	}
 
-	if len(q.where) > 0 {
+	if len(where) > 0 {
 		query.WriteString(" WHERE ")
-		query.WriteString(strings.Join(q.where, " AND "))
-		params = append(params, q.params...)
+		query.WriteString(strings.Join(where, " AND "))
+		params = append(params, whereParams...)
+	}
+
+	selecting := q.operation == "SELECT" || q.operation == ""
+	if len(q.groupBy) > 0 && selecting {
+		groupBy := make([]string, len(q.groupBy))
+		for i, field := range q.groupBy {
+			groupBy[i] = q.dialect.QuoteExpr(field)
+		}
+		query.WriteString(" GROUP BY " + strings.Join(groupBy, ", "))
+	}
+
+	if len(q.having) > 0 && selecting {
+		query.WriteString(" HAVING " + strings.Join(q.having, " AND "))
+		params = append(params, q.havingParams...)
+	}
+
+	if len(q.orderBy) > 0 && selecting {
+		query.WriteString(" ORDER BY ")
+		for i, order := range q.orderBy {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(q.dialect.QuoteExpr(order.field))
+			if order.operator != "" {
+				query.WriteString(" " + order.operator + " ?")
+				params = append(params, order.param)
+			}
+			if order.desc {
+				query.WriteString(" DESC")
+			} else {
+				query.WriteString(" ASC")
+			}
+		}
 	}
 
 	if q.limit > 0 {
@@ -111,5 +365,101 @@ func (q *Query) Build() (string, []interface{}) {
 		query.WriteString(fmt.Sprintf(" OFFSET %d", q.offset))
 	}
 
+	if len(q.returning) > 0 && q.operation != "SELECT" && q.operation != "" {
+		returning := make([]string, len(q.returning))
+		for i, field := range q.returning {
+			returning[i] = q.dialect.QuoteExpr(field)
+		}
+		query.WriteString(" RETURNING " + strings.Join(returning, ", "))
+	}
+
 	return query.String(), params
 }
+
+// upsertClause returns the clause of an INSERT query into table handling the conflicts set by Upsert, where
+// inserted are the quoted columns it inserts.
+func (q *Query) upsertClause(table string, inserted []string) string {
+	updates := q.dialect.QuoteAll(q.upsert.update)
+	tenant := q.dialect.Quote(TenantColumn)
+	scoped := q.tenant.mode == TenancyColumn
+
+	if q.dialect.IsMySQL() {
+		// MySQL has no DO NOTHING, but a column set to itself leaves the row as it is.
+		if len(updates) == 0 {
+			column := inserted[0]
+			if len(q.upsert.conflict) > 0 {
+				column = q.dialect.Quote(q.upsert.conflict[0])
+			}
+			return fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", column, column)
+		}
+		// VALUES() rather than a row alias, which MariaDB lacks.
+		set := make([]string, len(updates))
+		for i, column := range updates {
+			value := fmt.Sprintf("VALUES(%s)", column)
+			if scoped {
+				value = fmt.Sprintf("IF(%s = VALUES(%s), %s, %s)", tenant, tenant, value, column)
+			}
+			set[i] = fmt.Sprintf("%s = %s", column, value)
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	}
+
+	clause := " ON CONFLICT"
+	if len(q.upsert.conflict) > 0 {
+		clause += " (" + strings.Join(q.dialect.QuoteAll(q.upsert.conflict), ", ") + ")"
+	}
+	if len(updates) == 0 {
+		return clause + " DO NOTHING"
+	}
+	set := make([]string, len(updates))
+	for i, column := range updates {
+		set[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+	}
+	clause += " DO UPDATE SET " + strings.Join(set, ", ")
+	if scoped {
+		clause += fmt.Sprintf(" WHERE %s.%s = EXCLUDED.%s", table, tenant, tenant)
+	}
+	return clause
+}
+
+// CheckColumns returns an error if any of fields is not one of the allowed column names.
+// Use it before building a query from field names that came from user input.
+func CheckColumns(fields []string, allowed ...string) error {
+	for _, field := range fields {
+		ok := false
+		for _, a := range allowed {
+			if field == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("column %q is not allowed", field)
+		}
+	}
+	return nil
+}
+
+// Rebind rewrites the "?" placeholders in query into the given format.
+// Question marks inside single-quoted string literals are left untouched.
+func Rebind(query string, format PlaceholderFormat) string {
+	if format == Question {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteString(fmt.Sprintf("$%d", n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
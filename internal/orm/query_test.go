@@ -0,0 +1,161 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery_BuildSelect(t *testing.T) {
+	query, params := NewQuery("users").
+		Select("id", "email").
+		Where("email ILIKE ?", "%a%").
+		Where("id > ?", 3).
+		OrderBy("id", "desc").
+		Limit(10).
+		Offset(20).
+		Placeholders(Dollar).
+		Build()
+
+	assert.Equal(t, "SELECT id, email FROM users WHERE email ILIKE $1 AND id > $2 ORDER BY id DESC LIMIT 10 OFFSET 20", query)
+	assert.Equal(t, []interface{}{"%a%", 3}, params)
+}
+
+func TestQuery_BuildDefaultsToSelect(t *testing.T) {
+	query, _ := NewQuery("users").Where("id = ?", 1).Build()
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?", query)
+}
+
+func TestRebind(t *testing.T) {
+	assert.Equal(t, "a = $1 AND b = '?' AND c = $2", Rebind("a = ? AND b = '?' AND c = ?", Dollar))
+	assert.Equal(t, "a = ?", Rebind("a = ?", Question))
+}
+
+func TestQuery_BuildUpdate(t *testing.T) {
+	query, params := NewQuery("users").
+		Update("username", "email").
+		Values("ada", "ada@example.com").
+		Where("id = ?", 7).
+		Placeholders(Dollar).
+		Build()
+
+	assert.Equal(t, "UPDATE users SET username = $1, email = $2 WHERE id = $3", query)
+	assert.Equal(t, []interface{}{"ada", "ada@example.com", 7}, params)
+}
+
+func TestCheckColumns(t *testing.T) {
+	assert.NoError(t, CheckColumns([]string{"email"}, "username", "email"))
+	assert.Error(t, CheckColumns([]string{"email; DROP TABLE users"}, "username", "email"))
+}
+
+func TestQuery_WhereRawAndWhereIn(t *testing.T) {
+	query, params := NewQuery("posts").
+		WhereRaw("published = ? OR author_id = ?", true, 1).
+		WhereIn("id", []int{4, 5}).
+		WhereIn("author_id", NewQuery("users").Select("id").Where("active = ?", true)).
+		Placeholders(Dollar).
+		Build()
+
+	assert.Equal(t, "SELECT * FROM posts WHERE (published = $1 OR author_id = $2) AND id IN ($3, $4) "+
+		"AND author_id IN (SELECT id FROM users WHERE active = $5)", query)
+	assert.Equal(t, []interface{}{true, 1, 4, 5, true}, params)
+
+	query, params = NewQuery("posts").WhereIn("id", []int{}).Build()
+	assert.Equal(t, "SELECT * FROM posts WHERE 1 = 0", query)
+	assert.Empty(t, params)
+}
+
+func TestQuery_From(t *testing.T) {
+	totals := NewQuery("orders").
+		Select("user_id", "total * 1.2 AS total").
+		Where("status = ?", "paid").
+		As("t")
+
+	query, params := NewQuery("").
+		Select("t.user_id", "t.total").
+		From(totals).
+		Where("t.total > ?", 100).
+		OrderBy("t.total", "desc").
+		Placeholders(Dollar).
+		Build()
+
+	assert.Equal(t, "SELECT t.user_id, t.total FROM (SELECT user_id, total * 1.2 AS total FROM orders WHERE status = $1) AS t "+
+		"WHERE t.total > $2 ORDER BY t.total DESC", query)
+	assert.Equal(t, []interface{}{"paid", 100}, params)
+}
+
+func TestQuery_GroupByHaving(t *testing.T) {
+	query, params := NewQuery("posts").
+		Select("author_id", "COUNT(*) AS posts").
+		Where("published = ?", true).
+		GroupBy("author_id").
+		Having("COUNT(*) > ?", 10).
+		Having("MAX(views) < ?", 1000).
+		OrderBy("posts", "desc").
+		Limit(5).
+		Placeholders(Dollar).
+		Build()
+
+	assert.Equal(t, "SELECT author_id, COUNT(*) AS posts FROM posts WHERE published = $1 GROUP BY author_id "+
+		"HAVING COUNT(*) > $2 AND MAX(views) < $3 ORDER BY posts DESC LIMIT 5", query)
+	assert.Equal(t, []interface{}{true, 10, 1000}, params)
+
+	query, _ = NewQuery("posts").Select("user").GroupBy("user", "date_trunc('day', created_at)").Build()
+	assert.Equal(t, `SELECT "user" FROM posts GROUP BY "user", date_trunc('day', created_at)`, query)
+}
+
+func TestQuery_Distinct(t *testing.T) {
+	query, _ := NewQuery("posts").Select("author_id").Distinct().OrderBy("author_id", "asc").Build()
+	assert.Equal(t, "SELECT DISTINCT author_id FROM posts ORDER BY author_id ASC", query)
+
+	query, _ = NewQuery("posts").Distinct().GroupBy("id").Having("id > ?", 1).Delete().Build()
+	assert.Equal(t, "DELETE FROM posts", query, "only a SELECT is distinct or grouped")
+}
+
+func TestQuery_BuildQuotesIdentifiers(t *testing.T) {
+	query, _ := NewQuery("Users").
+		Select("id", "user", "COUNT(*)").
+		OrderBy("createdAt", "desc").
+		Build()
+	assert.Equal(t, `SELECT id, "user", COUNT(*) FROM "Users" ORDER BY "createdAt" DESC`, query)
+
+	query, _ = NewQuery("order").Update("desc").Dialect(dialect.MySQL).Where("id = ?", 1).Build()
+	assert.Equal(t, "UPDATE `order` SET `desc` = ? WHERE id = ?", query)
+}
+
+func TestQuery_Returning(t *testing.T) {
+	query, params := NewQuery("notes").Insert("body").Values("hi").Returning("id").Placeholders(Dollar).Build()
+	assert.Equal(t, "INSERT INTO notes (body) VALUES ($1) RETURNING id", query)
+	assert.Equal(t, []interface{}{"hi"}, params)
+
+	query, _ = NewQuery("notes").Returning("id").Build()
+	assert.Equal(t, "SELECT * FROM notes", query)
+}
+
+func TestQuery_Upsert(t *testing.T) {
+	query, params := NewQuery("tags").Insert("slug", "label").Values("go", "Go").
+		Upsert([]string{"slug"}, []string{"label"}).Returning("id").Placeholders(Dollar).Build()
+	assert.Equal(t, "INSERT INTO tags (slug, label) VALUES ($1, $2) ON CONFLICT (slug) DO UPDATE SET label = EXCLUDED.label RETURNING id", query)
+	assert.Equal(t, []interface{}{"go", "Go"}, params)
+
+	query, _ = NewQuery("tags").Insert("slug", "label").Upsert([]string{"slug"}, nil).Build()
+	assert.Equal(t, "INSERT INTO tags (slug, label) VALUES (?, ?) ON CONFLICT (slug) DO NOTHING", query)
+
+	query, _ = NewQuery("tags").Insert("slug", "order").Upsert([]string{"slug"}, []string{"order"}).Dialect(dialect.MySQL).Build()
+	assert.Equal(t, "INSERT INTO tags (slug, `order`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `order` = VALUES(`order`)", query)
+
+	query, _ = NewQuery("tags").Insert("slug", "label").Upsert([]string{"slug"}, nil).Dialect(dialect.MySQL).Build()
+	assert.Equal(t, "INSERT INTO tags (slug, label) VALUES (?, ?) ON DUPLICATE KEY UPDATE slug = slug", query)
+}
+
+func TestQuery_UpsertTenantColumn(t *testing.T) {
+	query, params := NewQuery("tags").Insert("slug", "label").Values("go", "Go").
+		Upsert([]string{"slug"}, []string{"label"}).Tenant(TenancyColumn, "acme").Build()
+	assert.Equal(t, "INSERT INTO tags (slug, label, tenant_id) VALUES (?, ?, ?) ON CONFLICT (slug) DO UPDATE SET label = EXCLUDED.label WHERE tags.tenant_id = EXCLUDED.tenant_id", query)
+	assert.Equal(t, []interface{}{"go", "Go", "acme"}, params)
+
+	query, _ = NewQuery("tags").Insert("slug", "label").Upsert([]string{"slug"}, []string{"label"}).
+		Tenant(TenancyColumn, "acme").Dialect(dialect.MySQL).Build()
+	assert.Equal(t, "INSERT INTO tags (slug, label, tenant_id) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE label = IF(tenant_id = VALUES(tenant_id), VALUES(label), label)", query)
+}
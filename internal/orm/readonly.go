@@ -0,0 +1,110 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrReadOnly is returned for statements that would write to a database opened in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// readOnlyKeywords are the keywords a statement that only reads may start with.
+var readOnlyKeywords = map[string]bool{
+	"select": true, "with": true, "show": true, "explain": true, "values": true, "table": true,
+}
+
+// writeKeywordPattern matches keywords that make an otherwise reading statement write, such as the
+// INSERT in a WITH clause or SELECT ... INTO, which creates a table.
+var writeKeywordPattern = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|INTO|TRUNCATE|CREATE|ALTER|DROP|GRANT|REVOKE|COPY|CALL|LOCK|NEXTVAL|SETVAL)\b`)
+
+// literalPattern matches single-quoted string literals, so words inside them are not mistaken for keywords.
+var literalPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// IsReadOnlyStatement reports whether every statement of query only reads. It errs on the side of
+// caution: a statement mentioning a writing keyword outside a string literal counts as writing.
+func IsReadOnlyStatement(query string) bool {
+	statements := SplitStatements(query)
+	if len(statements) == 0 {
+		return true
+	}
+
+	for _, statement := range statements {
+		statement = literalPattern.ReplaceAllString(stripLeadingComments(statement), "''")
+		fields := strings.Fields(strings.TrimLeft(statement, "( "))
+		if len(fields) == 0 {
+			continue
+		}
+		if !readOnlyKeywords[strings.ToLower(fields[0])] || writeKeywordPattern.MatchString(statement) {
+			return false
+		}
+	}
+	return true
+}
+
+// dmlKeywords are the keywords a statement changing rows starts with.
+var dmlKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "merge": true, "replace": true,
+}
+
+// dmlInWithPattern matches the keywords that make a WITH statement change rows.
+var dmlInWithPattern = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE)\b`)
+
+// IsDMLStatement reports whether statement changes rows, such as an INSERT, an UPDATE, or a WITH clause holding
+// a DELETE, so the count of rows its driver reports is meaningful. Drivers report arbitrary counts for DDL: MySQL
+// and SQLite may count the rows a CREATE TABLE ... AS or ALTER TABLE copied, or those changed by earlier statements.
+func IsDMLStatement(statement string) bool {
+	statement = literalPattern.ReplaceAllString(stripLeadingComments(statement), "''")
+	fields := strings.Fields(strings.TrimLeft(statement, "( "))
+	if len(fields) == 0 {
+		return false
+	}
+	keyword := strings.ToLower(fields[0])
+	return dmlKeywords[keyword] || keyword == "with" && dmlInWithPattern.MatchString(statement)
+}
+
+// stripLeadingComments removes the "--" and "/* */" comments a statement starts with.
+func stripLeadingComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			end := strings.IndexByte(statement, '\n')
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+1:]
+		case strings.HasPrefix(statement, "/*"):
+			end := strings.Index(statement, "*/")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+2:]
+		default:
+			return statement
+		}
+	}
+}
+
+// ReadOnly reports whether the connection was opened in read-only mode.
+func (c *Connection) ReadOnly() bool {
+	return c.readOnly
+}
+
+// CheckWritable returns an error wrapping ErrReadOnly if the connection is read-only.
+// action describes what would write, as in "refusing to run migrations".
+func (c *Connection) CheckWritable(action string) error {
+	if c.readOnly {
+		return fmt.Errorf("%w: refusing to %s", ErrReadOnly, action)
+	}
+	return nil
+}
+
+// CheckStatement returns an error wrapping ErrReadOnly if the connection is read-only and query may write.
+func (c *Connection) CheckStatement(query string) error {
+	if c.readOnly && !IsReadOnlyStatement(query) {
+		return fmt.Errorf("%w: refusing to run a statement that writes", ErrReadOnly)
+	}
+	return nil
+}
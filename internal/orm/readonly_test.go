@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReadOnlyStatement(t *testing.T) {
+	for _, query := range []string{
+		"SELECT * FROM users",
+		"-- count\nselect count(*) from users; SHOW server_version",
+		"(SELECT 1) UNION (SELECT 2)",
+		"WITH t AS (SELECT 1) SELECT * FROM t",
+		"SELECT 'delete me' AS note",
+		"EXPLAIN SELECT * FROM users",
+		"",
+	} {
+		assert.True(t, IsReadOnlyStatement(query), query)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM users",
+		"SELECT 1; DROP TABLE users",
+		"WITH gone AS (DELETE FROM users RETURNING *) SELECT * FROM gone",
+		"SELECT * INTO backup FROM users",
+		"EXPLAIN ANALYZE UPDATE users SET name = 'x'",
+		"SET default_transaction_read_only = off",
+		"SELECT nextval('users_id_seq')",
+	} {
+		assert.False(t, IsReadOnlyStatement(query), query)
+	}
+}
+
+func TestIsDMLStatement(t *testing.T) {
+	for _, statement := range []string{
+		"INSERT INTO users (name) VALUES ('a')",
+		"-- backfill\nUPDATE users SET active = true",
+		"WITH gone AS (DELETE FROM users RETURNING *) SELECT * FROM gone",
+		"REPLACE INTO settings VALUES (1)",
+	} {
+		assert.True(t, IsDMLStatement(statement), statement)
+	}
+	for _, statement := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+		"ALTER TABLE users ADD COLUMN note TEXT DEFAULT 'update me'",
+		"CREATE TABLE archive AS SELECT * FROM users",
+		"SELECT 1",
+		"",
+	} {
+		assert.False(t, IsDMLStatement(statement), statement)
+	}
+}
+
+func TestConnection_CheckStatement(t *testing.T) {
+	conn := &Connection{readOnly: true}
+	assert.NoError(t, conn.CheckStatement("SELECT 1"))
+	assert.True(t, errors.Is(conn.CheckStatement("DELETE FROM users"), ErrReadOnly))
+	assert.True(t, errors.Is(conn.CheckWritable("run migrations"), ErrReadOnly))
+
+	conn.readOnly = false
+	assert.NoError(t, conn.CheckStatement("DELETE FROM users"))
+	assert.NoError(t, conn.CheckWritable("run migrations"))
+}
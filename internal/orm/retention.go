@@ -0,0 +1,172 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// DefaultPruneBatchSize is the number of rows each statement of Prune deletes when no batch size is configured.
+const DefaultPruneBatchSize = 1000
+
+// RetentionPolicy is a validated retention rule: the rows of Table whose Column is older than MaxAge expire,
+// and are moved into Archive, or deleted if Archive is empty.
+type RetentionPolicy struct {
+	Table   string        `json:"table"`
+	Column  string        `json:"column"`
+	MaxAge  time.Duration `json:"max_age_ns"`
+	Archive string        `json:"archive,omitempty"`
+}
+
+// RetentionPolicies returns the policies of the rules of cfg for the given tables, or of every rule if no table
+// is given. It returns an error if a rule is invalid or a table has no rule.
+func RetentionPolicies(cfg config.RetentionConfig, tables ...string) ([]RetentionPolicy, error) {
+	byTable := make(map[string]RetentionPolicy, len(cfg.Rules))
+	var policies []RetentionPolicy
+	for _, rule := range cfg.Rules {
+		policy, err := ParseRetentionRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byTable[policy.Table]; ok {
+			return nil, fmt.Errorf("table %s has more than one retention rule", policy.Table)
+		}
+		byTable[policy.Table] = policy
+		policies = append(policies, policy)
+	}
+	if len(tables) == 0 {
+		return policies, nil
+	}
+
+	policies = nil
+	for _, table := range tables {
+		policy, ok := byTable[table]
+		if !ok {
+			return nil, fmt.Errorf("table %s has no retention rule", table)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// ParseRetentionRule validates rule and returns its policy. The column defaults to created_at.
+func ParseRetentionRule(rule config.RetentionRule) (RetentionPolicy, error) {
+	policy := RetentionPolicy{Table: rule.Table, Column: rule.Column, Archive: rule.Archive}
+	if policy.Column == "" {
+		policy.Column = "created_at"
+	}
+	for _, name := range []string{policy.Table, policy.Column} {
+		if err := dialect.Validate(name); err != nil {
+			return policy, fmt.Errorf("invalid retention rule for %q: %w", rule.Table, err)
+		}
+	}
+	if policy.Archive != "" {
+		if err := dialect.Validate(policy.Archive); err != nil {
+			return policy, fmt.Errorf("invalid retention rule for %s: %w", rule.Table, err)
+		}
+		if policy.Archive == policy.Table {
+			return policy, fmt.Errorf("invalid retention rule for %s: rows cannot be archived into their own table", rule.Table)
+		}
+	}
+	maxAge, err := ParseMaxAge(rule.MaxAge)
+	if err != nil {
+		return policy, fmt.Errorf("invalid retention rule for %s: %w", rule.Table, err)
+	}
+	policy.MaxAge = maxAge
+	return policy, nil
+}
+
+// ParseMaxAge parses the maximum age of a retention rule: a duration such as "720h", or a number of days such
+// as "30d". It must be positive.
+func ParseMaxAge(value string) (time.Duration, error) {
+	var age time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		age, err = time.ParseDuration(value)
+	}
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("max age %q must be a positive duration such as 720h or a number of days such as 30d", value)
+	}
+	return age, nil
+}
+
+// PruneResult is what Prune did to the rows of a table.
+type PruneResult struct {
+	Table string `json:"table"`
+	// Rows is the number of rows deleted or archived, or in a dry run the number of rows that would be.
+	Rows     int64 `json:"rows"`
+	Batches  int   `json:"batches"`
+	Archived bool  `json:"archived"`
+	DryRun   bool  `json:"dry_run,omitempty"`
+}
+
+// CountExpired returns the number of rows of the table of policy that are older than its maximum age.
+func (c *Connection) CountExpired(ctx context.Context, policy RetentionPolicy) (PruneResult, error) {
+	result := PruneResult{Table: policy.Table, Archived: policy.Archive != "", DryRun: true}
+	query := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", c.Dialect().Quote(policy.Table), c.expiredCondition(policy))
+	if err := c.db.QueryRowContext(ctx, query, policy.MaxAge.Seconds()).Scan(&result.Rows); err != nil {
+		return result, fmt.Errorf("error counting expired rows of %s: %w", policy.Table, TranslateError(err))
+	}
+	return result, nil
+}
+
+// Prune deletes the rows of the table of policy that are older than its maximum age, or moves them into its
+// archive table, batchSize rows at a time until none is left. Every batch is a single statement, so an
+// interrupted prune keeps the batches done before. The age is measured against the clock of the database.
+func (c *Connection) Prune(ctx context.Context, policy RetentionPolicy, batchSize int) (PruneResult, error) {
+	result := PruneResult{Table: policy.Table, Archived: policy.Archive != ""}
+	if err := c.CheckWritable("prune " + policy.Table); err != nil {
+		return result, err
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultPruneBatchSize
+	}
+
+	query := c.pruneQuery(policy)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		res, err := c.db.ExecContext(ctx, query, policy.MaxAge.Seconds(), batchSize)
+		if err != nil {
+			return result, fmt.Errorf("error pruning %s: %w", policy.Table, TranslateError(err))
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return result, err
+		}
+		result.Rows += n
+		result.Batches++
+		if n < int64(batchSize) {
+			return result, nil
+		}
+	}
+}
+
+// pruneQuery returns the statement deleting, or archiving, a batch of expired rows of the table of policy.
+// Rows are picked by tableoid and ctid, which identify them even in tables without a primary key and across
+// the partitions of a partitioned table.
+func (c *Connection) pruneQuery(policy RetentionPolicy) string {
+	table := c.Dialect().Quote(policy.Table)
+	deleteBatch := fmt.Sprintf("DELETE FROM %s WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM %s WHERE %s LIMIT $2)",
+		table, table, c.expiredCondition(policy))
+	if policy.Archive == "" {
+		return deleteBatch
+	}
+	return fmt.Sprintf("WITH expired AS (%s RETURNING *) INSERT INTO %s SELECT * FROM expired",
+		deleteBatch, c.Dialect().Quote(policy.Archive))
+}
+
+// expiredCondition returns the condition matching the expired rows of policy, given the maximum age in seconds as $1.
+func (c *Connection) expiredCondition(policy RetentionPolicy) string {
+	return fmt.Sprintf("%s < now() - make_interval(secs => $1)", c.Dialect().Quote(policy.Column))
+}
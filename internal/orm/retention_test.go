@@ -0,0 +1,88 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMaxAge(t *testing.T) {
+	age, err := ParseMaxAge("30d")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, age)
+
+	age, err = ParseMaxAge("36h")
+	assert.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, age)
+
+	for _, value := range []string{"", "0d", "-1h", "soon", "1.5d"} {
+		_, err := ParseMaxAge(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRetentionPolicies(t *testing.T) {
+	cfg := config.RetentionConfig{Rules: []config.RetentionRule{
+		{Table: "audit_logs", MaxAge: "90d"},
+		{Table: "events", Column: "occurred_at", MaxAge: "720h", Archive: "events_archive"},
+	}}
+
+	policies, err := RetentionPolicies(cfg)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []RetentionPolicy{
+			{Table: "audit_logs", Column: "created_at", MaxAge: 90 * 24 * time.Hour},
+			{Table: "events", Column: "occurred_at", MaxAge: 720 * time.Hour, Archive: "events_archive"},
+		}, policies)
+	}
+
+	policies, err = RetentionPolicies(cfg, "events")
+	if assert.NoError(t, err) {
+		assert.Len(t, policies, 1)
+		assert.Equal(t, "events", policies[0].Table)
+	}
+	_, err = RetentionPolicies(cfg, "users")
+	assert.ErrorContains(t, err, "table users has no retention rule")
+
+	cfg.Rules = append(cfg.Rules, config.RetentionRule{Table: "events", MaxAge: "1d"})
+	_, err = RetentionPolicies(cfg)
+	assert.ErrorContains(t, err, "more than one retention rule")
+
+	_, err = ParseRetentionRule(config.RetentionRule{Table: "events; DROP TABLE users", MaxAge: "1d"})
+	assert.Error(t, err)
+	_, err = ParseRetentionRule(config.RetentionRule{Table: "events", MaxAge: "1d", Archive: "events"})
+	assert.ErrorContains(t, err, "own table")
+}
+
+func TestConnection_PruneQuery(t *testing.T) {
+	conn := &Connection{driver: "postgres"}
+	policy := RetentionPolicy{Table: "events", Column: "occurred_at", MaxAge: time.Hour}
+	assert.Equal(t, "DELETE FROM events WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM events "+
+		"WHERE occurred_at < now() - make_interval(secs => $1) LIMIT $2)", conn.pruneQuery(policy))
+
+	policy.Archive = "events_archive"
+	assert.Equal(t, "WITH expired AS (DELETE FROM events WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM events "+
+		"WHERE occurred_at < now() - make_interval(secs => $1) LIMIT $2) RETURNING *) INSERT INTO events_archive SELECT * FROM expired",
+		conn.pruneQuery(policy))
+}
+
+func TestConnection_Prune(t *testing.T) {
+	d := &txDriver{affected: 3}
+	db := sql.OpenDB(&echoConnector{driver: d})
+	defer db.Close()
+	conn := &Connection{db: db, driver: "postgres"}
+	policy := RetentionPolicy{Table: "events", Column: "created_at", MaxAge: time.Hour}
+
+	result, err := conn.Prune(context.Background(), policy, 5)
+	if assert.NoError(t, err) {
+		assert.Equal(t, PruneResult{Table: "events", Rows: 3, Batches: 1}, result, "a short batch is the last")
+	}
+	assert.Len(t, d.executed, 1)
+
+	conn.readOnly = true
+	_, err = conn.Prune(context.Background(), policy, 5)
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+)
+
+// RowSecurity holds the session variables read by the row-level security policies generated for models
+// with an rls option. A zero field is left unset, and the policies reading it then match no rows.
+type RowSecurity struct {
+	TenantID string
+	UserID   int
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx acting on behalf of the user id, for the owner policies of RowSecurityFromContext.
+func WithUser(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, userKey{}, id)
+}
+
+// UserFromContext returns the user set on ctx by WithUser.
+func UserFromContext(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(userKey{}).(int)
+	return id, ok
+}
+
+// RowSecurityFromContext returns the RowSecurity for the tenant set by WithTenant and the user set by WithUser.
+func RowSecurityFromContext(ctx context.Context) RowSecurity {
+	var rs RowSecurity
+	rs.TenantID, _ = TenantFromContext(ctx)
+	rs.UserID, _ = UserFromContext(ctx)
+	return rs
+}
+
+// SetRowSecurity sets the session variables of rs until the end of the transaction. They are set per
+// transaction rather than per connection, so they never leak to other users of a pooled connection.
+func (t *Tx) SetRowSecurity(rs RowSecurity) error {
+	if rs.TenantID != "" {
+		if _, err := t.ExecContext(t.ctx, "SELECT set_config($1, $2, true)", model.TenantSetting, rs.TenantID); err != nil {
+			return err
+		}
+	}
+	if rs.UserID != 0 {
+		if _, err := t.ExecContext(t.ctx, "SELECT set_config($1, $2, true)", model.UserSetting, strconv.Itoa(rs.UserID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeginWithRowSecurity starts a transaction in which the row-level security policies see the tenant and
+// user of ctx, as returned by RowSecurityFromContext.
+// Example usage: tx, err := conn.BeginWithRowSecurity(orm.WithUser(ctx, userID))
+func (c *Connection) BeginWithRowSecurity(ctx context.Context) (*Tx, error) {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.SetRowSecurity(RowSecurityFromContext(ctx)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,204 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+)
+
+// MissingColumnError is returned by Find and FindAll when a column returned by the query has no field in the
+// struct the rows are read into.
+type MissingColumnError struct {
+	Column string
+	// Type is the struct type, such as models.Post.
+	Type string
+}
+
+func (e *MissingColumnError) Error() string {
+	return fmt.Sprintf("column %s has no field in %s; select it under the name of a field or add a db tag", e.Column, e.Type)
+}
+
+// structFieldsKey identifies the cached struct fields of a type, which depend on the naming convention of the
+// columns of the fields without a tag.
+type structFieldsKey struct {
+	t          reflect.Type
+	convention naming.Convention
+}
+
+// structFieldsCache caches the fields of the struct types read by Find and FindAll, by structFieldsKey.
+var structFieldsCache sync.Map
+
+// structFields returns the indexes of the fields of the struct type t by column. The column of a field is the
+// name of its db tag, or else of its json tag, or else its name in the naming convention of the process. Fields
+// tagged "-" are skipped. Embedded structs, such as DefaultModel, are descended into, and their fields are
+// shadowed by the fields of the outer struct with the same column. Fields holding structs, such as time.Time
+// and sql.NullString, are scanned as a whole.
+func structFields(t reflect.Type) map[string][]int {
+	k := structFieldsKey{t: t, convention: naming.Current()}
+	if fields, ok := structFieldsCache.Load(k); ok {
+		return fields.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	addStructFields(t, nil, fields)
+	cached, _ := structFieldsCache.LoadOrStore(k, fields)
+	return cached.(map[string][]int)
+}
+
+// scannerType is the type of the sql.Scanner interface.
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// addStructFields adds the fields of t, whose index is index, to fields, before those of its embedded structs.
+func addStructFields(t reflect.Type, index []int, fields map[string][]int) {
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		column, tagged := fieldColumn(field)
+		switch {
+		case column == "-" || !field.IsExported():
+		case field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct && field.Type != timeType &&
+			!reflect.PointerTo(field.Type).Implements(scannerType):
+			embedded = append(embedded, field)
+		default:
+			if _, ok := fields[column]; !ok {
+				fields[column] = append(append([]int{}, index...), i)
+			}
+		}
+	}
+	for _, field := range embedded {
+		addStructFields(field.Type, append(append([]int{}, index...), field.Index...), fields)
+	}
+}
+
+// fieldColumn returns the column of field, and whether it was named by a tag.
+func fieldColumn(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"db", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" {
+			return name, true
+		}
+	}
+	return naming.Column(field.Name), false
+}
+
+// rowTargets returns the pointers the columns of rows are scanned into for the fields of the struct record, with
+// times read in UTC, or a *MissingColumnError if a column has no field.
+func rowTargets(record reflect.Value, columns []string) ([]interface{}, error) {
+	fields := structFields(record.Type())
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := fields[column]
+		if !ok {
+			return nil, &MissingColumnError{Column: column, Type: record.Type().String()}
+		}
+		targets[i] = scanTarget(record.FieldByIndex(index))
+	}
+	return targets, nil
+}
+
+// scopedQuery returns q bound for the connection of c and scoped to the tenant of c if tenancy is enabled.
+func (c *CRUD) scopedQuery(q *Query) (*Query, error) {
+	q.Dialect(c.conn.Dialect()).Placeholders(c.conn.placeholders())
+	if c.conn.tenancy == TenancyNone {
+		return q, nil
+	}
+	if c.tenant == "" {
+		return nil, ErrNoTenant
+	}
+	return q.Tenant(c.conn.tenancy, c.tenant), nil
+}
+
+// Find reads the first row of query into dest, a pointer to a struct such as *Post, and returns ErrNotFound if
+// there is none. Columns are read into the fields named by their db or json tag, or else by the naming
+// convention, including the fields of embedded structs such as DefaultModel; see structFields. Columns that may
+// be NULL need a field that can hold it, such as a sql.NullString or a pointer. A column without a field fails
+// with a *MissingColumnError, while fields without a column are left as they are.
+// Example usage: crud.Find(&post, NewQuery("posts").Where("slug = ?", slug).Limit(1))
+func (c *CRUD) Find(dest interface{}, query *Query) error {
+	record := reflect.ValueOf(dest)
+	if record.Kind() != reflect.Pointer || record.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Find needs a pointer to a struct, not %T", dest)
+	}
+	rows, err := c.findRows(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return TranslateError(err)
+		}
+		return ErrNotFound
+	}
+	return scanRow(rows, record.Elem())
+}
+
+// FindAll reads every row of query into dest, a pointer to a slice of structs or of pointers to structs, such
+// as *[]Post or *[]*Post, replacing its elements. Columns are read into fields like Find does. No row leaves
+// dest empty rather than failing.
+func (c *CRUD) FindAll(dest interface{}, query *Query) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("FindAll needs a pointer to a slice of structs, not %T", dest)
+	}
+	records := slice.Elem()
+	elemType := records.Type().Elem()
+	recordType, pointers := elemType, elemType.Kind() == reflect.Pointer
+	if pointers {
+		recordType = elemType.Elem()
+	}
+	if recordType.Kind() != reflect.Struct {
+		return fmt.Errorf("FindAll needs a pointer to a slice of structs, not %T", dest)
+	}
+
+	rows, err := c.findRows(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	list := reflect.MakeSlice(records.Type(), 0, 0)
+	for rows.Next() {
+		record := reflect.New(recordType)
+		if err := scanRow(rows, record.Elem()); err != nil {
+			return err
+		}
+		if pointers {
+			list = reflect.Append(list, record)
+		} else {
+			list = reflect.Append(list, record.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return TranslateError(err)
+	}
+	records.Set(list)
+	return nil
+}
+
+// findRows runs query for Find and FindAll.
+func (c *CRUD) findRows(query *Query) (*sql.Rows, error) {
+	q, err := c.scopedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	statement, params := q.Build()
+	rows, err := c.conn.db.Query(statement, params...)
+	return rows, TranslateError(err)
+}
+
+// scanRow scans the current row of rows into the fields of the struct record.
+func scanRow(rows *sql.Rows, record reflect.Value) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	targets, err := rowTargets(record, columns)
+	if err != nil {
+		return err
+	}
+	return TranslateError(rows.Scan(targets...))
+}
@@ -0,0 +1,153 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+// rowsConnector opens connections whose queries return columns and rows, recording the queries.
+type rowsConnector struct {
+	columns []string
+	rows    [][]driver.Value
+	queries []string
+}
+
+func (c *rowsConnector) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *rowsConnector) Driver() driver.Driver                            { return nil }
+func (c *rowsConnector) Prepare(query string) (driver.Stmt, error)        { return nil, driver.ErrSkip }
+func (c *rowsConnector) Close() error                                     { return nil }
+func (c *rowsConnector) Begin() (driver.Tx, error)                        { return nil, driver.ErrSkip }
+
+func (c *rowsConnector) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.queries = append(c.queries, query)
+	return &fixedRows{columns: c.columns, rows: c.rows}, nil
+}
+
+type fixedRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fixedRows) Columns() []string { return r.columns }
+func (r *fixedRows) Close() error      { return nil }
+
+func (r *fixedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// rowsCRUD returns a CRUD on a PostgreSQL connection whose queries return columns and rows.
+func rowsCRUD(t *testing.T, columns []string, rows ...[]driver.Value) (*CRUD, *rowsConnector) {
+	connector := &rowsConnector{columns: columns, rows: rows}
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+	return NewCRUD(&Connection{db: db, driver: "postgres"}), connector
+}
+
+type article struct {
+	model.DefaultModel
+	Title    string
+	Subtitle sql.NullString
+	Price    Decimal `db:"price_cents"`
+	Draft    bool    `json:"is_draft"`
+	Secret   string  `db:"-"`
+}
+
+func TestCRUD_Find(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	crud, connector := rowsCRUD(t, []string{"id", "created_at", "title", "subtitle", "price_cents", "is_draft"},
+		[]driver.Value{int64(7), created, "Hello", nil, []byte("19.99"), true})
+
+	var a article
+	assert.NoError(t, crud.Find(&a, NewQuery("articles").Where("title = ?", "Hello").Limit(1)))
+	assert.Equal(t, []string{"SELECT * FROM articles WHERE title = $1 LIMIT 1"}, connector.queries)
+	assert.Equal(t, uint(7), a.ID)
+	assert.Equal(t, created, a.CreatedAt)
+	assert.Equal(t, "Hello", a.Title)
+	assert.False(t, a.Subtitle.Valid)
+	assert.Equal(t, "19.99", a.Price.String())
+	assert.True(t, a.Draft)
+
+	assert.ErrorContains(t, crud.Find(a, NewQuery("articles")), "pointer to a struct")
+}
+
+func TestCRUD_Find_MissingColumn(t *testing.T) {
+	crud, _ := rowsCRUD(t, []string{"id", "secret"})
+	assert.ErrorIs(t, crud.Find(&article{}, NewQuery("articles")), ErrNotFound)
+
+	crud, _ = rowsCRUD(t, []string{"id", "secret"}, []driver.Value{int64(1), "x"})
+
+	var missing *MissingColumnError
+	err := crud.Find(&article{}, NewQuery("articles"))
+	if assert.True(t, errors.As(err, &missing)) {
+		assert.Equal(t, "secret", missing.Column)
+		assert.Equal(t, "orm.article", missing.Type)
+	}
+}
+
+func TestCRUD_FindAll(t *testing.T) {
+	columns := []string{"id", "title"}
+	crud, _ := rowsCRUD(t, columns, []driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"})
+	var articles []article
+	assert.NoError(t, crud.FindAll(&articles, NewQuery("articles").Select("id", "title")))
+	if assert.Len(t, articles, 2) {
+		assert.Equal(t, "b", articles[1].Title)
+		assert.Equal(t, uint(2), articles[1].ID)
+	}
+
+	crud, _ = rowsCRUD(t, columns, []driver.Value{int64(3), "c"})
+	var pointers []*article
+	assert.NoError(t, crud.FindAll(&pointers, NewQuery("articles")))
+	if assert.Len(t, pointers, 1) {
+		assert.Equal(t, "c", pointers[0].Title)
+	}
+
+	crud, _ = rowsCRUD(t, columns)
+	assert.NoError(t, crud.FindAll(&articles, NewQuery("articles")))
+	assert.Empty(t, articles)
+	assert.ErrorContains(t, crud.FindAll(&[]int{}, NewQuery("articles")), "slice of structs")
+}
+
+func TestCRUD_Find_Tenant(t *testing.T) {
+	crud, connector := rowsCRUD(t, []string{"id"})
+	crud.conn.tenancy = TenancyColumn
+	assert.ErrorIs(t, crud.FindAll(&[]article{}, NewQuery("articles")), ErrNoTenant)
+
+	tenant, err := crud.ForTenant(WithTenant(context.Background(), "acme"))
+	if assert.NoError(t, err) {
+		assert.NoError(t, tenant.FindAll(&[]article{}, NewQuery("articles")))
+		assert.Equal(t, []string{"SELECT * FROM articles WHERE tenant_id = $1"}, connector.queries)
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	type shadowed struct {
+		model.DefaultModel
+		ID        string `json:"id"`
+		UpdatedAt time.Time
+	}
+	fields := structFields(reflect.TypeOf(shadowed{}))
+	assert.Equal(t, []int{1}, fields["id"], "the outer field shadows the embedded one")
+	assert.Equal(t, []int{2}, fields["updated_at"])
+	assert.Equal(t, []int{0, 0, 1}, fields["created_at"])
+
+	t.Cleanup(func() { naming.SetConvention(naming.Convention{}) })
+	assert.NoError(t, naming.SetConvention(naming.Convention{Columns: naming.CamelCase}))
+	fields = structFields(reflect.TypeOf(shadowed{}))
+	assert.Equal(t, []int{2}, fields["updatedAt"])
+	assert.Equal(t, []int{0, 0, 1}, fields["created_at"], "tags are kept")
+}
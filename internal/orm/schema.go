@@ -0,0 +1,401 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// SchemaKind is the kind of a database object in a schema dump. The kinds are ordered so every object comes after
+// the objects it usually depends on.
+type SchemaKind int
+
+const (
+	SchemaExtension SchemaKind = iota
+	SchemaType
+	SchemaSequence
+	SchemaFunction
+	SchemaTable
+	SchemaView
+	SchemaMaterializedView
+	SchemaForeignKey
+	SchemaIndex
+	SchemaTrigger
+	SchemaRowSecurity
+	SchemaPolicy
+)
+
+// schemaSections are the headings of the sections of a schema dump, by kind.
+var schemaSections = map[SchemaKind]string{
+	SchemaExtension:        "Extensions",
+	SchemaType:             "Types",
+	SchemaSequence:         "Sequences",
+	SchemaFunction:         "Functions",
+	SchemaTable:            "Tables",
+	SchemaView:             "Views",
+	SchemaMaterializedView: "Materialized views",
+	SchemaForeignKey:       "Foreign keys",
+	SchemaIndex:            "Indexes",
+	SchemaTrigger:          "Triggers",
+	SchemaRowSecurity:      "Row-level security",
+	SchemaPolicy:           "Policies",
+}
+
+// SchemaObject is a database object of a schema dump, and the statement creating it, without its semicolon.
+type SchemaObject struct {
+	Kind SchemaKind
+	// Name identifies the object among those of its kind, such as posts or posts.author_fk.
+	Name      string
+	Statement string
+}
+
+// Schema is the schema of a database, without its data, as returned by DumpSchema.
+type Schema struct {
+	Objects []SchemaObject
+}
+
+// SQL returns the statements creating the objects of s, by kind and then by name, so the same schema always gives
+// the same text whatever order its objects were created in. Each kind of object is headed by a comment.
+func (s *Schema) SQL() string {
+	objects := append([]SchemaObject{}, s.Objects...)
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return objects[i].Kind < objects[j].Kind
+		}
+		return objects[i].Name < objects[j].Name
+	})
+
+	var b strings.Builder
+	b.WriteString("-- Schema dumped by grayv-lsm db schema dump. It holds no data.\n")
+	for i, object := range objects {
+		if i == 0 || objects[i-1].Kind != object.Kind {
+			fmt.Fprintf(&b, "\n-- %s\n\n", schemaSections[object.Kind])
+		}
+		b.WriteString(strings.TrimSpace(object.Statement) + ";\n")
+		if i+1 < len(objects) && objects[i+1].Kind == object.Kind && strings.Contains(object.Statement, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// DumpSchema reads the schema of the database: for PostgreSQL, the extensions, enum types, sequences, functions,
+// tables, views, indexes, triggers, and row-level security policies of the current schema, rebuilt from the
+// catalogs; for MySQL, the statements SHOW CREATE returns for its tables and views; and for SQLite, the statements
+// the database keeps for its objects. The values of sequences and AUTO_INCREMENT counters are left out, as they
+// depend on the data.
+func (c *Connection) DumpSchema() (*Schema, error) {
+	switch d := c.Dialect(); {
+	case d.IsSQLite():
+		return dumpSQLiteSchema(c.db)
+	case d.IsMySQL():
+		return dumpMySQLSchema(c.db)
+	}
+	return dumpPostgresSchema(c.db)
+}
+
+// dumpSQLiteSchema reads the statements SQLite keeps in sqlite_master, leaving out its internal objects.
+func dumpSQLiteSchema(db *sql.DB) (*Schema, error) {
+	kinds := map[string]SchemaKind{"table": SchemaTable, "view": SchemaView, "index": SchemaIndex, "trigger": SchemaTrigger}
+	schema := &Schema{}
+	err := queryRows(db, `SELECT type, name, sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'`,
+		func(rows *sql.Rows) error {
+			var kind, name, statement string
+			if err := rows.Scan(&kind, &name, &statement); err != nil {
+				return err
+			}
+			schema.Objects = append(schema.Objects, SchemaObject{Kind: kinds[kind], Name: name, Statement: statement})
+			return nil
+		})
+	return schema, err
+}
+
+// autoIncrement matches the AUTO_INCREMENT counter MySQL shows in the options of a table.
+var autoIncrement = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// dumpMySQLSchema reads the statements SHOW CREATE returns for the tables and views of the current database.
+func dumpMySQLSchema(db *sql.DB) (*Schema, error) {
+	type relation struct {
+		name string
+		view bool
+	}
+	var relations []relation
+	err := queryRows(db, `SELECT table_name, table_type = 'VIEW' FROM information_schema.tables WHERE table_schema = DATABASE()`,
+		func(rows *sql.Rows) error {
+			var r relation
+			if err := rows.Scan(&r.name, &r.view); err != nil {
+				return err
+			}
+			relations = append(relations, r)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{}
+	for _, r := range relations {
+		// SHOW CREATE VIEW returns more columns than SHOW CREATE TABLE, of which the statement is the second.
+		statement := "SHOW CREATE TABLE "
+		if r.view {
+			statement = "SHOW CREATE VIEW "
+		}
+		rows, err := db.Query(statement + dialect.MySQL.Quote(r.name))
+		if err != nil {
+			return nil, fmt.Errorf("error dumping %s: %w", r.name, err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		values := make([]sql.NullString, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if rows.Next() {
+			err = rows.Scan(targets...)
+		}
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error dumping %s: %w", r.name, err)
+		}
+		object := SchemaObject{Kind: SchemaTable, Name: r.name, Statement: autoIncrement.ReplaceAllString(values[1].String, "")}
+		if r.view {
+			object.Kind = SchemaView
+		}
+		schema.Objects = append(schema.Objects, object)
+	}
+	return schema, nil
+}
+
+// schemaColumn is a column of a PostgreSQL table.
+type schemaColumn struct {
+	name     string
+	dataType string
+	notNull  bool
+	// fill is the expression of the default, or of the generated column if generated is set.
+	fill      string
+	generated bool
+	// identity is 'a' for GENERATED ALWAYS AS IDENTITY columns, 'd' for GENERATED BY DEFAULT ones, and "" otherwise.
+	identity string
+}
+
+// definition returns the definition of c in CREATE TABLE.
+func (c schemaColumn) definition() string {
+	definition := dialect.Postgres.Quote(c.name) + " " + c.dataType
+	switch {
+	case c.generated:
+		definition += " GENERATED ALWAYS AS (" + c.fill + ") STORED"
+	case c.identity == "a":
+		definition += " GENERATED ALWAYS AS IDENTITY"
+	case c.identity == "d":
+		definition += " GENERATED BY DEFAULT AS IDENTITY"
+	case c.fill != "":
+		definition += " DEFAULT " + c.fill
+	}
+	if c.notNull {
+		definition += " NOT NULL"
+	}
+	return definition
+}
+
+// schemaTable is a PostgreSQL table, with its columns in order and its constraints other than foreign keys.
+type schemaTable struct {
+	name        string
+	columns     []schemaColumn
+	constraints []string
+	// partitionKey is the partitioning of a partitioned table, such as RANGE (created_at).
+	partitionKey string
+	// parent is the table a partition belongs to, and bound the values it holds, such as
+	// FOR VALUES FROM ('2024-01-01') TO ('2024-02-01').
+	parent string
+	bound  string
+}
+
+// statement returns the CREATE TABLE statement of t. A partition only names its parent and bound, as it has the
+// columns and constraints of its parent.
+func (t schemaTable) statement() string {
+	name := dialect.Postgres.Quote(t.name)
+	if t.parent != "" {
+		return fmt.Sprintf("CREATE TABLE %s PARTITION OF %s %s", name, dialect.Postgres.Quote(t.parent), t.bound)
+	}
+	var lines []string
+	for _, column := range t.columns {
+		lines = append(lines, "  "+column.definition())
+	}
+	for _, constraint := range t.constraints {
+		lines = append(lines, "  "+constraint)
+	}
+	statement := fmt.Sprintf("CREATE TABLE %s (\n%s\n)", name, strings.Join(lines, ",\n"))
+	if t.partitionKey != "" {
+		statement += " PARTITION BY " + t.partitionKey
+	}
+	return statement
+}
+
+// notExtension is the condition leaving out the objects created by extensions, such as the functions of pgvector,
+// whose oid is o.
+const notExtension = "NOT EXISTS (SELECT 1 FROM pg_depend dep WHERE dep.objid = %s AND dep.deptype = 'e')"
+
+// dumpPostgresSchema rebuilds the statements creating the objects of the current schema from the catalogs.
+func dumpPostgresSchema(db *sql.DB) (*Schema, error) {
+	schema := &Schema{}
+	add := func(kind SchemaKind, query string) error {
+		return queryRows(db, query, func(rows *sql.Rows) error {
+			var object SchemaObject
+			if err := rows.Scan(&object.Name, &object.Statement); err != nil {
+				return err
+			}
+			object.Kind = kind
+			schema.Objects = append(schema.Objects, object)
+			return nil
+		})
+	}
+
+	queries := []struct {
+		kind  SchemaKind
+		query string
+	}{
+		{SchemaExtension, `SELECT extname, format('CREATE EXTENSION IF NOT EXISTS %I', extname)
+			FROM pg_extension WHERE extname <> 'plpgsql'`},
+		{SchemaType, `SELECT t.typname, format('CREATE TYPE %I AS ENUM (%s)', t.typname,
+				string_agg(quote_literal(e.enumlabel), ', ' ORDER BY e.enumsortorder))
+			FROM pg_type t JOIN pg_enum e ON e.enumtypid = t.oid JOIN pg_namespace n ON n.oid = t.typnamespace
+			WHERE n.nspname = current_schema() AND ` + fmt.Sprintf(notExtension, "t.oid") + `
+			GROUP BY t.typname`},
+		// Sequences owned by identity columns are created with their column.
+		{SchemaSequence, `SELECT c.relname, format('CREATE SEQUENCE %I AS %s INCREMENT BY %s MINVALUE %s MAXVALUE %s START WITH %s%s',
+				c.relname, format_type(s.seqtypid, NULL), s.seqincrement, s.seqmin, s.seqmax, s.seqstart,
+				CASE WHEN s.seqcycle THEN ' CYCLE' ELSE '' END)
+			FROM pg_sequence s JOIN pg_class c ON c.oid = s.seqrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = current_schema()
+			AND NOT EXISTS (SELECT 1 FROM pg_depend dep WHERE dep.objid = c.oid AND dep.deptype IN ('i', 'e'))`},
+		{SchemaFunction, `SELECT p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')', pg_get_functiondef(p.oid)
+			FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname = current_schema() AND p.prokind IN ('f', 'p') AND ` + fmt.Sprintf(notExtension, "p.oid")},
+		{SchemaView, `SELECT c.relname, format(E'CREATE VIEW %I AS\n%s', c.relname, rtrim(pg_get_viewdef(c.oid), ';'))
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind = 'v' AND n.nspname = current_schema() AND ` + fmt.Sprintf(notExtension, "c.oid")},
+		// The views are created without rows, as the dump holds no data.
+		{SchemaMaterializedView, `SELECT c.relname, format(E'CREATE MATERIALIZED VIEW %I AS\n%s\nWITH NO DATA', c.relname, rtrim(pg_get_viewdef(c.oid), ';'))
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind = 'm' AND n.nspname = current_schema()`},
+		{SchemaForeignKey, `SELECT c.relname || '.' || con.conname, format('ALTER TABLE %I ADD CONSTRAINT %I %s', c.relname, con.conname, pg_get_constraintdef(con.oid))
+			FROM pg_constraint con JOIN pg_class c ON c.oid = con.conrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE con.contype = 'f' AND con.conislocal AND n.nspname = current_schema()`},
+		// Indexes backing constraints are created with their constraint, and those of partitions with the index of
+		// their parent.
+		{SchemaIndex, `SELECT i.relname, pg_get_indexdef(i.oid)
+			FROM pg_index x JOIN pg_class i ON i.oid = x.indexrelid JOIN pg_namespace n ON n.oid = i.relnamespace
+			WHERE n.nspname = current_schema() AND NOT i.relispartition AND ` + fmt.Sprintf(notExtension, "x.indrelid") + `
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = x.indexrelid AND con.contype IN ('p', 'u', 'x'))`},
+		{SchemaTrigger, `SELECT c.relname || '.' || t.tgname, pg_get_triggerdef(t.oid)
+			FROM pg_trigger t JOIN pg_class c ON c.oid = t.tgrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE NOT t.tgisinternal AND NOT c.relispartition AND n.nspname = current_schema()`},
+		{SchemaRowSecurity, `SELECT c.relname, format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', c.relname)
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relrowsecurity AND n.nspname = current_schema()`},
+		{SchemaPolicy, `SELECT tablename || '.' || policyname, format('CREATE POLICY %I ON %I AS %s FOR %s TO %s%s%s',
+				policyname, tablename, permissive, cmd, array_to_string(roles, ', '),
+				COALESCE(' USING (' || qual || ')', ''), COALESCE(' WITH CHECK (' || with_check || ')', ''))
+			FROM pg_policies WHERE schemaname = current_schema()`},
+	}
+	for _, q := range queries {
+		if err := add(q.kind, q.query); err != nil {
+			return nil, fmt.Errorf("error dumping %s: %w", strings.ToLower(schemaSections[q.kind]), err)
+		}
+	}
+
+	tables, err := postgresTables(db)
+	if err != nil {
+		return nil, fmt.Errorf("error dumping tables: %w", err)
+	}
+	for _, t := range tables {
+		schema.Objects = append(schema.Objects, SchemaObject{Kind: SchemaTable, Name: t.name, Statement: t.statement()})
+	}
+	return schema, nil
+}
+
+// postgresTables reads the tables of the current schema, with their columns in order and their constraints other
+// than foreign keys, by name.
+func postgresTables(db *sql.DB) ([]*schemaTable, error) {
+	var tables []*schemaTable
+	byName := make(map[string]*schemaTable)
+	err := queryRows(db, `SELECT c.relname, CASE WHEN c.relkind = 'p' THEN pg_get_partkeydef(c.oid) ELSE '' END,
+			COALESCE(parent.relname, ''), COALESCE(pg_get_expr(c.relpartbound, c.oid), '')
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_inherits i ON i.inhrelid = c.oid AND c.relispartition
+		LEFT JOIN pg_class parent ON parent.oid = i.inhparent
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = current_schema() AND `+fmt.Sprintf(notExtension, "c.oid"),
+		func(rows *sql.Rows) error {
+			t := &schemaTable{}
+			if err := rows.Scan(&t.name, &t.partitionKey, &t.parent, &t.bound); err != nil {
+				return err
+			}
+			tables = append(tables, t)
+			byName[t.name] = t
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(db, `SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attgenerated = 's', a.attidentity::text
+		FROM pg_attribute a JOIN pg_class c ON c.oid = a.attrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attnum > 0 AND NOT a.attisdropped AND c.relkind IN ('r', 'p') AND n.nspname = current_schema()
+		ORDER BY c.relname, a.attnum`,
+		func(rows *sql.Rows) error {
+			var table string
+			var column schemaColumn
+			if err := rows.Scan(&table, &column.name, &column.dataType, &column.notNull, &column.fill, &column.generated, &column.identity); err != nil {
+				return err
+			}
+			if t, ok := byName[table]; ok {
+				t.columns = append(t.columns, column)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// Primary keys come first, then the other constraints by name.
+	err = queryRows(db, `SELECT c.relname, format('CONSTRAINT %I %s', con.conname, pg_get_constraintdef(con.oid))
+		FROM pg_constraint con JOIN pg_class c ON c.oid = con.conrelid JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE con.contype IN ('p', 'u', 'c', 'x') AND con.conislocal AND n.nspname = current_schema()
+		ORDER BY c.relname, con.contype <> 'p', con.conname`,
+		func(rows *sql.Rows) error {
+			var table, constraint string
+			if err := rows.Scan(&table, &constraint); err != nil {
+				return err
+			}
+			if t, ok := byName[table]; ok {
+				t.constraints = append(t.constraints, constraint)
+			}
+			return nil
+		})
+	return tables, err
+}
+
+// queryRows runs query on db and calls scan for each of its rows.
+func queryRows(db *sql.DB, query string, scan func(*sql.Rows) error) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,71 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaTable_Statement(t *testing.T) {
+	table := schemaTable{
+		name: "posts",
+		columns: []schemaColumn{
+			{name: "id", dataType: "bigint", notNull: true, identity: "d"},
+			{name: "title", dataType: "character varying(200)", notNull: true},
+			{name: "status", dataType: "text", fill: "'draft'::text"},
+			{name: "slug", dataType: "text", fill: "lower(title)", generated: true},
+			{name: "order", dataType: "integer"},
+		},
+		constraints: []string{"CONSTRAINT posts_pkey PRIMARY KEY (id)", "CONSTRAINT posts_title_key UNIQUE (title)"},
+	}
+	assert.Equal(t, `CREATE TABLE posts (
+  id bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL,
+  title character varying(200) NOT NULL,
+  status text DEFAULT 'draft'::text,
+  slug text GENERATED ALWAYS AS (lower(title)) STORED,
+  "order" integer,
+  CONSTRAINT posts_pkey PRIMARY KEY (id),
+  CONSTRAINT posts_title_key UNIQUE (title)
+)`, table.statement())
+
+	table.partitionKey = "RANGE (id)"
+	assert.Contains(t, table.statement(), "\n) PARTITION BY RANGE (id)")
+
+	partition := schemaTable{name: "posts_1", parent: "posts", bound: "FOR VALUES FROM (0) TO (1000)"}
+	assert.Equal(t, "CREATE TABLE posts_1 PARTITION OF posts FOR VALUES FROM (0) TO (1000)", partition.statement())
+}
+
+func TestSchema_SQL(t *testing.T) {
+	schema := &Schema{Objects: []SchemaObject{
+		{Kind: SchemaIndex, Name: "users_email", Statement: "CREATE INDEX users_email ON users (email)"},
+		{Kind: SchemaTable, Name: "users", Statement: "CREATE TABLE users (\n  id integer\n)"},
+		{Kind: SchemaTable, Name: "posts", Statement: "CREATE TABLE posts (\n  id integer\n)"},
+		{Kind: SchemaExtension, Name: "pgcrypto", Statement: "CREATE EXTENSION IF NOT EXISTS pgcrypto"},
+	}}
+	expected := `-- Schema dumped by grayv-lsm db schema dump. It holds no data.
+
+-- Extensions
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+-- Tables
+
+CREATE TABLE posts (
+  id integer
+);
+
+CREATE TABLE users (
+  id integer
+);
+
+-- Indexes
+
+CREATE INDEX users_email ON users (email);
+`
+	assert.Equal(t, expected, schema.SQL())
+
+	// The order the objects were read in does not change the dump.
+	objects := schema.Objects
+	schema.Objects = []SchemaObject{objects[3], objects[2], objects[1], objects[0]}
+	assert.Equal(t, expected, schema.SQL())
+}
@@ -0,0 +1,64 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Session is a server process connected to the current database, as listed in pg_stat_activity.
+type Session struct {
+	PID         int           `json:"pid"`
+	User        string        `json:"user"`
+	Application string        `json:"application"`
+	ClientAddr  string        `json:"client_addr"`
+	State       string        `json:"state"`
+	Duration    time.Duration `json:"duration"`
+	Query       string        `json:"query"`
+}
+
+// ListSessions returns the other sessions connected to the current database, longest running query first.
+// Duration is how long the current or last query has been running.
+func (c *Connection) ListSessions() ([]Session, error) {
+	rows, err := c.db.Query(`
+		SELECT pid, COALESCE(usename, ''), COALESCE(application_name, ''), COALESCE(client_addr::text, ''),
+			COALESCE(state, ''), COALESCE(EXTRACT(EPOCH FROM now() - query_start), 0), COALESCE(query, '')
+		FROM pg_stat_activity
+		WHERE datname = current_database() AND pid <> pg_backend_pid()
+		ORDER BY query_start NULLS LAST
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		var seconds float64
+		if err := rows.Scan(&s.PID, &s.User, &s.Application, &s.ClientAddr, &s.State, &seconds, &s.Query); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		s.Duration = time.Duration(seconds * float64(time.Second))
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// KillSession ends the session with the given process ID. With cancel set, only its running query is
+// cancelled and the session stays connected. It returns false if no such session exists.
+func (c *Connection) KillSession(pid int, cancel bool) (bool, error) {
+	function := "pg_terminate_backend"
+	if cancel {
+		function = "pg_cancel_backend"
+	}
+
+	var signalled sql.NullBool
+	if err := c.db.QueryRow(fmt.Sprintf("SELECT %s(pid) FROM pg_stat_activity WHERE pid = $1", function), pid).Scan(&signalled); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to signal session %d: %w", pid, err)
+	}
+	return signalled.Bool, nil
+}
@@ -0,0 +1,170 @@
+package orm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultSizeHistoryFile is where the last size report of every database is kept, so the next report shows how
+// much the tables grew. It is local, like the command statistics, and never sent anywhere.
+const DefaultSizeHistoryFile = ".grayv/sizes.json"
+
+// TableSize is the storage used by a table. TOAST is where PostgreSQL moves large values, such as long text
+// and JSON, out of the rows; a large TOAST size points at columns worth compressing or moving elsewhere.
+type TableSize struct {
+	Table string `json:"table"`
+	// RowEstimate is the number of rows as of the last analyze, which is cheap to read but approximate.
+	RowEstimate int64 `json:"row_estimate"`
+	TableBytes  int64 `json:"table_bytes"`
+	ToastBytes  int64 `json:"toast_bytes"`
+	IndexBytes  int64 `json:"index_bytes"`
+	TotalBytes  int64 `json:"total_bytes"`
+	// Growth is the change of TotalBytes since the previous report, nil if the table was not in it.
+	Growth *int64 `json:"growth,omitempty"`
+}
+
+// IndexSize is the storage used by an index, and how often it was scanned since the statistics were reset.
+// An index that is never scanned and enforces no uniqueness only slows down writes, and can usually be dropped.
+type IndexSize struct {
+	Table  string `json:"table"`
+	Index  string `json:"index"`
+	Bytes  int64  `json:"bytes"`
+	Scans  int64  `json:"scans"`
+	Unique bool   `json:"unique"`
+}
+
+// Unused reports whether the index was never scanned and is not needed for uniqueness.
+func (s IndexSize) Unused() bool {
+	return s.Scans == 0 && !s.Unique
+}
+
+// SizeReport is the storage used by the tables and indexes of the current schema, largest first.
+type SizeReport struct {
+	Database      string      `json:"database"`
+	TakenAt       time.Time   `json:"taken_at"`
+	DatabaseBytes int64       `json:"database_bytes"`
+	Tables        []TableSize `json:"tables"`
+	Indexes       []IndexSize `json:"indexes"`
+	// PreviousAt is when the report growth is measured from was taken, nil if there was none.
+	PreviousAt *time.Time `json:"previous_at,omitempty"`
+	// DatabaseGrowth is the change of DatabaseBytes since the previous report, nil if there was none.
+	DatabaseGrowth *int64 `json:"database_growth,omitempty"`
+}
+
+// SizeReport measures the storage used by the database, its tables, and their indexes.
+func (c *Connection) SizeReport() (*SizeReport, error) {
+	report := &SizeReport{}
+	err := c.db.QueryRow("SELECT current_database(), pg_database_size(current_database()), now()").
+		Scan(&report.Database, &report.DatabaseBytes, &report.TakenAt)
+	if err != nil {
+		return nil, fmt.Errorf("error getting database size: %w", err)
+	}
+
+	rows, err := c.db.Query(`
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint, pg_relation_size(c.oid),
+			COALESCE(pg_total_relation_size(NULLIF(c.reltoastrelid, 0)), 0), pg_indexes_size(c.oid), pg_total_relation_size(c.oid)
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'm') AND n.nspname = current_schema()
+		ORDER BY pg_total_relation_size(c.oid) DESC, c.relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table sizes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s TableSize
+		if err := rows.Scan(&s.Table, &s.RowEstimate, &s.TableBytes, &s.ToastBytes, &s.IndexBytes, &s.TotalBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan table size: %w", err)
+		}
+		report.Tables = append(report.Tables, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = c.db.Query(`
+		SELECT s.relname, s.indexrelname, pg_relation_size(s.indexrelid), s.idx_scan, i.indisunique
+		FROM pg_stat_user_indexes s JOIN pg_index i ON i.indexrelid = s.indexrelid
+		WHERE s.schemaname = current_schema()
+		ORDER BY pg_relation_size(s.indexrelid) DESC, s.indexrelname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index sizes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s IndexSize
+		if err := rows.Scan(&s.Table, &s.Index, &s.Bytes, &s.Scans, &s.Unique); err != nil {
+			return nil, fmt.Errorf("failed to scan index size: %w", err)
+		}
+		report.Indexes = append(report.Indexes, s)
+	}
+	return report, rows.Err()
+}
+
+// CompareWith sets the growth of the database and its tables since previous, a report of the same database.
+// Nothing is set if previous is nil.
+func (r *SizeReport) CompareWith(previous *SizeReport) {
+	if previous == nil {
+		return
+	}
+	at, growth := previous.TakenAt, r.DatabaseBytes-previous.DatabaseBytes
+	r.PreviousAt, r.DatabaseGrowth = &at, &growth
+
+	before := make(map[string]int64, len(previous.Tables))
+	for _, s := range previous.Tables {
+		before[s.Table] = s.TotalBytes
+	}
+	for i, s := range r.Tables {
+		if total, ok := before[s.Table]; ok {
+			growth := s.TotalBytes - total
+			r.Tables[i].Growth = &growth
+		}
+	}
+}
+
+// LoadSizeHistory reads the last size report of every database, by database name, from the file at path.
+// A missing file holds no reports.
+func LoadSizeHistory(path string) (map[string]*SizeReport, error) {
+	history := make(map[string]*SizeReport)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading size history: %w", err)
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("error parsing size history %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// SaveSizeHistory writes history to the file at path, creating its directory if needed. The growth of the
+// reports is left out, since it is measured again from the reports themselves.
+func SaveSizeHistory(path string, history map[string]*SizeReport) error {
+	stored := make(map[string]*SizeReport, len(history))
+	for name, report := range history {
+		r := *report
+		r.PreviousAt, r.DatabaseGrowth = nil, nil
+		r.Tables = make([]TableSize, len(report.Tables))
+		for i, s := range report.Tables {
+			s.Growth = nil
+			r.Tables[i] = s
+		}
+		stored[name] = &r
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling size history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating size history directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing size history: %w", err)
+	}
+	return nil
+}
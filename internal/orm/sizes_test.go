@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeReport_CompareWith(t *testing.T) {
+	before := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	previous := &SizeReport{Database: "app", TakenAt: before, DatabaseBytes: 1000, Tables: []TableSize{
+		{Table: "posts", TotalBytes: 400},
+		{Table: "dropped", TotalBytes: 100},
+	}}
+	report := &SizeReport{Database: "app", DatabaseBytes: 1500, Tables: []TableSize{
+		{Table: "posts", TotalBytes: 900},
+		{Table: "comments", TotalBytes: 200},
+	}}
+
+	report.CompareWith(nil)
+	assert.Nil(t, report.PreviousAt)
+	assert.Nil(t, report.Tables[0].Growth)
+
+	report.CompareWith(previous)
+	assert.Equal(t, before, *report.PreviousAt)
+	assert.Equal(t, int64(500), *report.DatabaseGrowth)
+	assert.Equal(t, int64(500), *report.Tables[0].Growth)
+	assert.Nil(t, report.Tables[1].Growth, "new tables have no growth")
+}
+
+func TestSizeHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".grayv", "sizes.json")
+	history, err := LoadSizeHistory(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, history)
+
+	report := &SizeReport{Database: "app", DatabaseBytes: 1500, Tables: []TableSize{{Table: "posts", TotalBytes: 900}}}
+	report.CompareWith(&SizeReport{DatabaseBytes: 1000, Tables: []TableSize{{Table: "posts", TotalBytes: 400}}})
+	history["app"] = report
+	assert.NoError(t, SaveSizeHistory(path, history))
+	assert.NotNil(t, report.Tables[0].Growth, "the report saved is left unchanged")
+
+	history, err = LoadSizeHistory(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(900), history["app"].Tables[0].TotalBytes)
+		assert.Nil(t, history["app"].Tables[0].Growth)
+		assert.Nil(t, history["app"].DatabaseGrowth)
+	}
+}
+
+func TestIndexSize_Unused(t *testing.T) {
+	assert.True(t, IndexSize{Scans: 0}.Unused())
+	assert.False(t, IndexSize{Scans: 3}.Unused())
+	assert.False(t, IndexSize{Scans: 0, Unique: true}.Unused(), "unique indexes enforce constraints")
+}
@@ -0,0 +1,36 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnowflake_Next(t *testing.T) {
+	fake := clock.NewFake(SnowflakeEpoch.Add(time.Second))
+	ids, err := NewSnowflake(3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ids.clock = fake
+
+	first := ids.Next()
+	assert.Equal(t, int64(1000)<<22|3<<12, first)
+	assert.Equal(t, first+1, ids.Next())
+
+	fake.Advance(time.Millisecond)
+	assert.Equal(t, int64(1001)<<22|3<<12, ids.Next())
+
+	// A clock going back never makes ids repeat.
+	fake.Advance(-time.Second)
+	assert.Equal(t, int64(1001)<<22|3<<12|1, ids.Next())
+
+	// An exhausted sequence moves on to the next millisecond.
+	ids.seq = maxSnowflakeSeq
+	assert.Equal(t, int64(1002)<<22|3<<12, ids.Next())
+
+	_, err = NewSnowflake(MaxSnowflakeNode + 1)
+	assert.Error(t, err)
+}
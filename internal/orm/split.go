@@ -0,0 +1,136 @@
+package orm
+
+import "strings"
+
+// SplitStatements splits a SQL script into its statements at the semicolons that end them.
+// Semicolons inside string literals, quoted identifiers, dollar-quoted bodies such as those of
+// DO blocks and functions, the BEGIN ... END bodies of SQLite triggers, and comments do not end a
+// statement. Empty statements are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	start := 0
+	inTriggerBody := false
+
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case isWordByte(c) && (i == 0 || !isWordByte(script[i-1])):
+			end := i
+			for end < len(script) && isWordByte(script[end]) {
+				end++
+			}
+			if !inTriggerBody && strings.EqualFold(script[i:end], "BEGIN") && isCreateTrigger(script[start:i]) {
+				inTriggerBody = true
+			}
+			i = end - 1
+		case c == '\'' || c == '"':
+			i = skipQuoted(script, i, c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case c == '$':
+			if tag := dollarTag(script[i:]); tag != "" {
+				if end := strings.Index(script[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		case c == ';':
+			if inTriggerBody && !endsWithWord(script[start:i], "END") {
+				continue
+			}
+			inTriggerBody = false
+			statements = appendStatement(statements, script[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(script) {
+		statements = appendStatement(statements, script[start:])
+	}
+	return statements
+}
+
+// isWordByte reports whether c may be part of a keyword or an unquoted identifier.
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isCreateTrigger reports whether statement starts a CREATE TRIGGER statement, such as
+// "CREATE TEMP TRIGGER" or "CREATE TRIGGER IF NOT EXISTS".
+func isCreateTrigger(statement string) bool {
+	fields := strings.Fields(stripLeadingComments(statement))
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "CREATE") {
+		return false
+	}
+	for _, field := range fields[1:min(len(fields), 3)] {
+		if strings.EqualFold(field, "TRIGGER") {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithWord reports whether s, ignoring trailing spaces, ends with the keyword word.
+func endsWithWord(s, word string) bool {
+	s = strings.TrimRight(s, " \t\r\n")
+	if len(s) < len(word) || !strings.EqualFold(s[len(s)-len(word):], word) {
+		return false
+	}
+	return len(s) == len(word) || !isWordByte(s[len(s)-len(word)-1])
+}
+
+// skipQuoted returns the index of the quote closing the literal or identifier opened at start.
+// Doubled quotes inside it are escapes.
+func skipQuoted(script string, start int, quote byte) int {
+	for i := start + 1; i < len(script); i++ {
+		if script[i] == quote {
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(script)
+}
+
+// dollarTag returns the dollar-quote tag, such as "$$" or "$body$", that s starts with, or "" if it starts with none.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (c >= '0' && c <= '9' && i > 1):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func appendStatement(statements []string, statement string) []string {
+	if statement = strings.TrimSpace(statement); statement != "" && !onlyComments(statement) {
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// onlyComments reports whether statement consists of "--" comments alone.
+func onlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Users
+INSERT INTO users (name) VALUES ('a;b');
+INSERT INTO "odd;name" (x) VALUES (1); /* a ; comment */
+DO $$ BEGIN PERFORM 1; END $$;
+CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;
+SELECT 'it''s; fine'
+-- trailing comment;
+`
+	assert.Equal(t, []string{
+		"-- Users\nINSERT INTO users (name) VALUES ('a;b')",
+		`INSERT INTO "odd;name" (x) VALUES (1)`,
+		"/* a ; comment */\nDO $$ BEGIN PERFORM 1; END $$",
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+		"SELECT 'it''s; fine'\n-- trailing comment;",
+	}, SplitStatements(script))
+
+	trigger := `CREATE TRIGGER no_update BEFORE UPDATE ON history
+BEGIN
+    SELECT RAISE(ABORT, 'append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS no_delete BEFORE DELETE ON history BEGIN SELECT RAISE(ABORT, 'append-only'); END;
+BEGIN;
+DELETE FROM history;
+COMMIT`
+	assert.Equal(t, []string{
+		"CREATE TRIGGER no_update BEFORE UPDATE ON history\nBEGIN\n    SELECT RAISE(ABORT, 'append-only');\nEND",
+		"CREATE TRIGGER IF NOT EXISTS no_delete BEFORE DELETE ON history BEGIN SELECT RAISE(ABORT, 'append-only'); END",
+		"BEGIN",
+		"DELETE FROM history",
+		"COMMIT",
+	}, SplitStatements(trigger))
+
+	assert.Empty(t, SplitStatements("  ;\n-- nothing\n"))
+	assert.Equal(t, []string{"SELECT $1"}, SplitStatements("SELECT $1"))
+}
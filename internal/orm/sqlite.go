@@ -0,0 +1,5 @@
+package orm
+
+// The pure-Go driver of modernc.org/sqlite registers itself as "sqlite". It needs no cgo, so it is compiled into
+// every build.
+import _ "modernc.org/sqlite"
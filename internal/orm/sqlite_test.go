@@ -0,0 +1,43 @@
+package orm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConnection_SQLite(t *testing.T) {
+	conn, err := NewConnection(&config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "grayv.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, dialect.SQLite, conn.Dialect())
+	assert.Equal(t, Question, conn.placeholders())
+	assert.True(t, conn.supportsReturning())
+
+	_, err = conn.GetDB().Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT)")
+	assert.NoError(t, err)
+	crud := NewCRUD(conn)
+	n := &note{Body: "hi"}
+	if assert.NoError(t, crud.Create(n)) {
+		assert.Equal(t, uint(1), n.ID)
+	}
+	read := &note{}
+	if assert.NoError(t, crud.Read(read, n.ID)) {
+		assert.Equal(t, "hi", read.Body)
+	}
+}
+
+func TestNewConnection_SQLite3Alias(t *testing.T) {
+	conn, err := NewConnection(&config.DatabaseConfig{Driver: "sqlite3", Path: filepath.Join(t.TempDir(), "grayv.db")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, dialect.SQLite, conn.Dialect())
+	assert.NoError(t, conn.Ping(), "sqlite3 opens the file with the sqlite driver")
+}
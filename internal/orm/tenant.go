@@ -0,0 +1,94 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+)
+
+// TenancyMode selects how rows are kept apart between tenants.
+type TenancyMode string
+
+const (
+	// TenancyNone disables tenancy: every query sees every row.
+	TenancyNone TenancyMode = ""
+	// TenancyColumn keeps the rows of all tenants in the same tables, told apart by their tenant_id column.
+	TenancyColumn TenancyMode = "column"
+	// TenancySchema keeps the tables of each tenant in a schema of their own, named by TenantSchema.
+	TenancySchema TenancyMode = "schema"
+)
+
+// TenantColumn is the column holding the tenant of a row in TenancyColumn mode.
+const TenantColumn = model.TenantColumn
+
+// maxTenantIDLength keeps the schema names made by TenantSchema within the PostgreSQL identifier limit.
+const maxTenantIDLength = 48
+
+// ErrNoTenant is returned when tenancy is enabled and an operation has no tenant to scope it to.
+var ErrNoTenant = errors.New("tenancy is enabled but no tenant is set; use WithTenant")
+
+// ParseTenancyMode returns the TenancyMode named by mode: "", "none", "column", or "schema".
+func ParseTenancyMode(mode string) (TenancyMode, error) {
+	switch mode {
+	case "", "none":
+		return TenancyNone, nil
+	case string(TenancyColumn), string(TenancySchema):
+		return TenancyMode(mode), nil
+	default:
+		return TenancyNone, fmt.Errorf("unknown tenancy mode %q: use column or schema", mode)
+	}
+}
+
+// ValidateTenantID returns an error unless id is a valid tenant ID: 1 to 48 lowercase letters, digits, and
+// underscores, starting with a letter. Tenant IDs are used in schema names, so they are kept to safe identifiers.
+func ValidateTenantID(id string) error {
+	if id == "" || len(id) > maxTenantIDLength {
+		return fmt.Errorf("invalid tenant ID %q: must be 1 to %d characters", id, maxTenantIDLength)
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+		case (c >= '0' && c <= '9' || c == '_') && i > 0:
+		default:
+			return fmt.Errorf("invalid tenant ID %q: use lowercase letters, digits, and underscores, starting with a letter", id)
+		}
+	}
+	return nil
+}
+
+// TenantSchema returns the name of the schema holding the tables of tenant id in TenancySchema mode.
+func TenantSchema(id string) string {
+	return "tenant_" + id
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx scoped to the tenant id.
+// Example usage: crud, err := orm.NewCRUD(conn).ForTenant(orm.WithTenant(ctx, "acme"))
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// TenantFromContext returns the tenant ctx is scoped to by WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// tenantScope is the tenant a Query is restricted to.
+type tenantScope struct {
+	mode TenancyMode
+	id   string
+}
+
+// Tenant restricts the query to the rows of tenant id. In TenancyColumn mode, SELECT, UPDATE, and DELETE
+// queries only match rows whose tenant_id is id, and INSERT queries set it. In TenancySchema mode, the
+// table is looked up in the schema of the tenant. TenancyNone leaves the query unchanged.
+// Example usage: NewQuery("posts").Tenant(TenancyColumn, "acme").Where("published = ?", true)
+func (q *Query) Tenant(mode TenancyMode, id string) *Query {
+	q.tenant = tenantScope{mode: mode, id: id}
+	return q
+}
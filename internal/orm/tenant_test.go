@@ -0,0 +1,60 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type note struct {
+	model.DefaultModel
+	Body string
+}
+
+func (n *note) TableName() string { return "notes" }
+
+func TestQuery_TenantColumn(t *testing.T) {
+	query, params := NewQuery("notes").Where("a = ? OR b = ?", 1, 2).Tenant(TenancyColumn, "acme").Placeholders(Dollar).Build()
+	assert.Equal(t, "SELECT * FROM notes WHERE (a = $1 OR b = $2) AND tenant_id = $3", query)
+	assert.Equal(t, []interface{}{1, 2, "acme"}, params)
+
+	query, params = NewQuery("notes").Insert("body").Values("hi").Tenant(TenancyColumn, "acme").Build()
+	assert.Equal(t, "INSERT INTO notes (body, tenant_id) VALUES (?, ?)", query)
+	assert.Equal(t, []interface{}{"hi", "acme"}, params)
+
+	query, params = NewQuery("notes").Update("body").Values("hi").Where("id = ?", 7).Tenant(TenancyColumn, "acme").Build()
+	assert.Equal(t, "UPDATE notes SET body = ? WHERE (id = ?) AND tenant_id = ?", query)
+	assert.Equal(t, []interface{}{"hi", 7, "acme"}, params)
+}
+
+func TestQuery_TenantSchema(t *testing.T) {
+	query, _ := NewQuery("notes").Delete().Where("id = ?", 7).Tenant(TenancySchema, "acme").Build()
+	assert.Equal(t, "DELETE FROM tenant_acme.notes WHERE id = ?", query)
+}
+
+func TestValidateTenantID(t *testing.T) {
+	for _, id := range []string{"acme", "a1", "big_co"} {
+		assert.NoError(t, ValidateTenantID(id), id)
+	}
+	for _, id := range []string{"", "Acme", "1st", "_x", "a-b", "a; DROP TABLE notes"} {
+		assert.Error(t, ValidateTenantID(id), id)
+	}
+}
+
+func TestCRUD_RequiresTenant(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres", tenancy: TenancyColumn})
+	assert.ErrorIs(t, crud.Delete(&note{}, 1), ErrNoTenant)
+
+	_, err := crud.ForTenant(context.Background())
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	scoped, err := crud.ForTenant(WithTenant(context.Background(), "acme"))
+	if assert.NoError(t, err) {
+		q, err := scoped.newQuery(&note{})
+		assert.NoError(t, err)
+		query, _ := q.Delete().Build()
+		assert.Equal(t, "DELETE FROM notes WHERE tenant_id = ?", query)
+	}
+}
@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// Tx is a database transaction that supports savepoints, so part of the work done in it can be
+// undone without abandoning the whole transaction. It embeds *sql.Tx, so the usual Exec, Query,
+// Commit, and Rollback methods are available.
+type Tx struct {
+	*sql.Tx
+	ctx context.Context
+}
+
+// BeginTx starts a transaction on db. The savepoint statements run with ctx.
+// Example usage: tx, err := orm.BeginTx(ctx, conn.GetDB())
+func BeginTx(ctx context.Context, db *sql.DB) (*Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, ctx: ctx}, nil
+}
+
+// Begin starts a transaction on the connection.
+func (c *Connection) Begin(ctx context.Context) (*Tx, error) {
+	return BeginTx(ctx, c.db)
+}
+
+// Savepoint sets a savepoint called name. Setting a savepoint with the name of an existing one hides
+// the older one until the newer one is released.
+func (t *Tx) Savepoint(name string) error {
+	return t.savepointStatement("SAVEPOINT", name)
+}
+
+// RollbackTo undoes everything done since the savepoint called name was set. The savepoint is kept,
+// so it can be rolled back to again. After a failed statement, PostgreSQL refuses further statements
+// in the transaction until it is rolled back to a savepoint set before the failure.
+func (t *Tx) RollbackTo(name string) error {
+	return t.savepointStatement("ROLLBACK TO SAVEPOINT", name)
+}
+
+// Release forgets the savepoint called name, keeping the work done since it was set.
+func (t *Tx) Release(name string) error {
+	return t.savepointStatement("RELEASE SAVEPOINT", name)
+}
+
+// Nested runs fn as a nested transaction: if fn returns an error, the work it did is rolled back
+// while the rest of the transaction is kept. The error of fn is returned.
+func (t *Tx) Nested(name string, fn func() error) error {
+	if err := t.Savepoint(name); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if rollbackErr := t.RollbackTo(name); rollbackErr != nil {
+			return fmt.Errorf("%w (and rolling back failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	return t.Release(name)
+}
+
+func (t *Tx) savepointStatement(statement, name string) error {
+	if err := dialect.Validate(name); err != nil {
+		return fmt.Errorf("invalid savepoint name: %w", err)
+	}
+	if _, err := t.ExecContext(t.ctx, statement+" "+dialect.Postgres.Quote(name)); err != nil {
+		return fmt.Errorf("error executing %s %s: %w", statement, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+)
+
+// UnitOfWork tracks models loaded through it and writes back only what changed. Commit updates, in one
+// transaction, the columns whose fields differ from when the model was loaded, rather than every column as
+// CRUD.Update does, so saving a record whose title was edited does not rewrite its body. Models whose fields
+// did not change are not written at all.
+//
+// Changes are detected by comparing the fields with a copy taken when the model was loaded. A []byte field
+// is copied, so changing its bytes in place is detected; the elements of other slices and of maps are not,
+// so assign a new slice or map to a field to change it. A UnitOfWork is not safe for concurrent use.
+//
+// Example usage:
+//
+//	uow := crud.UnitOfWork()
+//	post := &Post{}
+//	if err := uow.Load(post, id); err != nil {
+//	    return err
+//	}
+//	post.Title = "Edited"
+//	err := uow.Commit(ctx) // UPDATE posts SET title = $1 WHERE id = $2
+type UnitOfWork struct {
+	crud    *CRUD
+	tracked []*trackedModel
+}
+
+// trackedModel is a model tracked by a UnitOfWork, with the value of its primary key and of its fields when
+// it was loaded or last committed.
+type trackedModel struct {
+	model    model.ModelInterface
+	key      interface{}
+	columns  []string
+	snapshot []interface{}
+}
+
+// UnitOfWork returns a new UnitOfWork writing through c, so its updates are scoped to the tenant of c.
+func (c *CRUD) UnitOfWork() *UnitOfWork {
+	return &UnitOfWork{crud: c}
+}
+
+// Load reads the record with the primary key id into m, like CRUD.Read, and tracks m.
+func (u *UnitOfWork) Load(m model.ModelInterface, id interface{}) error {
+	if err := u.crud.Read(m, id); err != nil {
+		return err
+	}
+	return u.Track(m)
+}
+
+// Track tracks m, a model already read from the database, taking its current fields as unchanged.
+// Tracking a model again takes its fields as unchanged again. Read-only models cannot be tracked.
+func (u *UnitOfWork) Track(m model.ModelInterface) error {
+	if err := checkWritableModel(m); err != nil {
+		return err
+	}
+	key, err := primaryKeyField(m)
+	if err != nil {
+		return err
+	}
+	columns, fields := storedColumns(m)
+	tracked := &trackedModel{model: m, key: key.Interface(), columns: columns, snapshot: snapshotValues(fields)}
+	for i, t := range u.tracked {
+		if t.model == m {
+			u.tracked[i] = tracked
+			return nil
+		}
+	}
+	u.tracked = append(u.tracked, tracked)
+	return nil
+}
+
+// Forget stops tracking m, so Commit does not write its changes.
+func (u *UnitOfWork) Forget(m model.ModelInterface) {
+	for i, t := range u.tracked {
+		if t.model == m {
+			u.tracked = append(u.tracked[:i], u.tracked[i+1:]...)
+			return
+		}
+	}
+}
+
+// Dirty returns the columns of m whose fields changed since m was loaded, in the order of the fields.
+// It returns nil if m is unchanged or not tracked.
+func (u *UnitOfWork) Dirty(m model.ModelInterface) []string {
+	for _, t := range u.tracked {
+		if t.model == m {
+			columns, _ := t.dirty()
+			return columns
+		}
+	}
+	return nil
+}
+
+// Commit updates the changed columns of every tracked model in one transaction, in the order the models were
+// tracked. If a record was deleted since it was loaded, Commit rolls back and returns an error matching
+// ErrNotFound. After a successful Commit the models stay tracked, their current fields taken as unchanged.
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	var queries []*Query
+	var committed []*trackedModel
+	for _, t := range u.tracked {
+		q, err := u.updateQuery(t)
+		if err != nil {
+			return err
+		}
+		if q != nil {
+			queries = append(queries, q)
+			committed = append(committed, t)
+		}
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	tx, err := u.crud.conn.Begin(ctx)
+	if err != nil {
+		return TranslateError(err)
+	}
+	for i, q := range queries {
+		query, params := q.Placeholders(u.crud.conn.placeholders()).Build()
+		result, err := tx.ExecContext(ctx, query, params...)
+		if err == nil {
+			var count int64
+			if count, err = result.RowsAffected(); err == nil && count == 0 {
+				t := committed[i]
+				err = fmt.Errorf("%s %v: %w", t.model.TableName(), t.key, ErrNotFound)
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return TranslateError(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return TranslateError(err)
+	}
+
+	for _, t := range committed {
+		_, fields := storedColumns(t.model)
+		t.snapshot = snapshotValues(fields)
+	}
+	return nil
+}
+
+// updateQuery returns the query updating the changed columns of t, or nil if none changed.
+func (u *UnitOfWork) updateQuery(t *trackedModel) (*Query, error) {
+	columns, values := t.dirty()
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	q, err := u.crud.newQuery(t.model)
+	if err != nil {
+		return nil, err
+	}
+	return q.Update(columns...).Values(values...).Where(u.crud.primaryKeyCondition(t.model), t.key), nil
+}
+
+// dirty returns the columns of t whose fields changed since the snapshot, and their current values.
+func (t *trackedModel) dirty() ([]string, []interface{}) {
+	_, fields := storedColumns(t.model)
+	var columns []string
+	var values []interface{}
+	for i, field := range fields {
+		if value := field.Interface(); !sameValue(t.snapshot[i], value) {
+			columns = append(columns, t.columns[i])
+			values = append(values, storedValue(field))
+		}
+	}
+	return columns, values
+}
+
+// snapshotValues returns the values of fields, with []byte values copied so changes made in place are detected.
+func snapshotValues(fields []reflect.Value) []interface{} {
+	values := fieldValues(fields)
+	for i, value := range values {
+		if b, ok := value.([]byte); ok && b != nil {
+			values[i] = bytes.Clone(b)
+		}
+	}
+	return values
+}
+
+// sameValue reports whether a field still has the value it had in the snapshot.
+func sameValue(before, after interface{}) bool {
+	if b, ok := before.([]byte); ok {
+		a, ok := after.([]byte)
+		return ok && (a == nil) == (b == nil) && bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(before, after)
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// txDriver is a driver whose connections record the statements they execute, in transactions, each
+// changing affected rows.
+type txDriver struct {
+	executed []string
+	affected int64
+}
+
+func (d *txDriver) Open(name string) (driver.Conn, error) {
+	return &txConn{driver: d}, nil
+}
+
+type txConn struct {
+	driver *txDriver
+}
+
+func (c *txConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *txConn) Close() error                              { return nil }
+
+func (c *txConn) Begin() (driver.Tx, error) {
+	c.driver.executed = append(c.driver.executed, "BEGIN")
+	return c, nil
+}
+
+func (c *txConn) Commit() error {
+	c.driver.executed = append(c.driver.executed, "COMMIT")
+	return nil
+}
+
+func (c *txConn) Rollback() error {
+	c.driver.executed = append(c.driver.executed, "ROLLBACK")
+	return nil
+}
+
+func (c *txConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.executed = append(c.driver.executed, query)
+	return driver.RowsAffected(c.driver.affected), nil
+}
+
+type draft struct {
+	ID    int
+	Title string
+	Body  string
+	Data  []byte
+}
+
+func (d *draft) TableName() string   { return "drafts" }
+func (d *draft) PrimaryKey() string  { return "ID" }
+func (d *draft) BeforeCreate() error { return nil }
+func (d *draft) AfterCreate() error  { return nil }
+func (d *draft) BeforeUpdate() error { return nil }
+func (d *draft) AfterUpdate() error  { return nil }
+func (d *draft) BeforeDelete() error { return nil }
+func (d *draft) AfterDelete() error  { return nil }
+
+func TestUnitOfWork_Dirty(t *testing.T) {
+	uow := NewCRUD(&Connection{driver: "postgres"}).UnitOfWork()
+	d := &draft{ID: 1, Title: "a", Body: "b", Data: []byte("x")}
+	assert.NoError(t, uow.Track(d))
+	assert.Empty(t, uow.Dirty(d))
+
+	d.Title = "edited"
+	d.Data[0] = 'y'
+	assert.Equal(t, []string{"title", "data"}, uow.Dirty(d))
+
+	d.Title = "a"
+	assert.Equal(t, []string{"data"}, uow.Dirty(d))
+	assert.Empty(t, uow.Dirty(&draft{}), "untracked")
+
+	uow.Forget(d)
+	assert.Empty(t, uow.Dirty(d))
+}
+
+func TestUnitOfWork_UpdateQuery(t *testing.T) {
+	uow := NewCRUD(&Connection{driver: "postgres"}).UnitOfWork()
+	d := &draft{ID: 7, Title: "a", Body: "b"}
+	assert.NoError(t, uow.Track(d))
+	d.ID = 8
+	d.Body = "edited"
+
+	q, err := uow.updateQuery(uow.tracked[0])
+	if assert.NoError(t, err) {
+		query, params := q.Placeholders(Dollar).Build()
+		assert.Equal(t, "UPDATE drafts SET body = $1 WHERE id = $2", query)
+		assert.Equal(t, []interface{}{"edited", 7}, params, "the record is found by the primary key it was loaded with")
+	}
+}
+
+func TestUnitOfWork_Commit(t *testing.T) {
+	d := &txDriver{affected: 1}
+	db := sql.OpenDB(&echoConnector{driver: d})
+	defer db.Close()
+	uow := NewCRUD(&Connection{db: db, driver: "postgres"}).UnitOfWork()
+
+	first, second, untouched := &draft{ID: 1}, &draft{ID: 2}, &draft{ID: 3}
+	for _, m := range []*draft{first, second, untouched} {
+		assert.NoError(t, uow.Track(m))
+	}
+	assert.NoError(t, uow.Commit(context.Background()))
+	assert.Empty(t, d.executed, "nothing changed, nothing written")
+
+	first.Title, second.Body = "t", "b"
+	assert.NoError(t, uow.Commit(context.Background()))
+	assert.Equal(t, []string{
+		"BEGIN",
+		"UPDATE drafts SET title = $1 WHERE id = $2",
+		"UPDATE drafts SET body = $1 WHERE id = $2",
+		"COMMIT",
+	}, d.executed)
+	assert.Empty(t, uow.Dirty(first), "committed changes are taken as unchanged")
+
+	d.executed, d.affected = nil, 0
+	first.Title = "deleted meanwhile"
+	err := uow.Commit(context.Background())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorContains(t, err, "drafts 1")
+	assert.Equal(t, []string{"BEGIN", "UPDATE drafts SET title = $1 WHERE id = $2", "ROLLBACK"}, d.executed)
+	assert.Equal(t, []string{"title"}, uow.Dirty(first), "changes are kept after a rollback")
+}
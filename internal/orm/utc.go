@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+// timeType is the type of time.Time fields, which CRUD reads and writes in UTC.
+var timeType = reflect.TypeOf(time.Time{})
+
+// utcTime scans a column into the time.Time it points to, in UTC, so the times of records are the same whatever
+// the time zone of the session and the driver they are read with.
+type utcTime struct {
+	t *time.Time
+}
+
+// Scan implements sql.Scanner.
+func (u utcTime) Scan(src interface{}) error {
+	var t sql.NullTime
+	if err := t.Scan(src); err != nil {
+		return err
+	}
+	*u.t = t.Time.UTC()
+	return nil
+}
+
+// scanTarget returns the pointer a column is scanned into for field: a utcTime for a time.Time field, and the
+// address of the field otherwise.
+func scanTarget(field reflect.Value) interface{} {
+	if field.Type() == timeType {
+		return utcTime{t: field.Addr().Interface().(*time.Time)}
+	}
+	return field.Addr().Interface()
+}
+
+// storedValue returns the value written to the column of field: a time.Time field in UTC, so columns without a
+// time zone hold UTC rather than the local time of the process, and the value of the field otherwise.
+func storedValue(field reflect.Value) interface{} {
+	if field.Type() == timeType {
+		return field.Interface().(time.Time).UTC()
+	}
+	return field.Interface()
+}
@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanTarget(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if !assert.NoError(t, err) {
+		return
+	}
+	local := time.Date(2025, 6, 1, 14, 0, 0, 0, paris)
+	var record struct {
+		At    time.Time
+		Count int
+	}
+	v := reflect.ValueOf(&record).Elem()
+
+	target := scanTarget(v.Field(0))
+	if assert.IsType(t, utcTime{}, target) {
+		assert.NoError(t, target.(utcTime).Scan(local))
+		assert.Equal(t, time.UTC, record.At.Location())
+		assert.True(t, record.At.Equal(local))
+		assert.NoError(t, target.(utcTime).Scan(nil))
+		assert.True(t, record.At.IsZero())
+	}
+	assert.Equal(t, &record.Count, scanTarget(v.Field(1)))
+
+	record.At = local
+	assert.Equal(t, time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), storedValue(v.Field(0)))
+	assert.Equal(t, 0, storedValue(v.Field(1)))
+}
+
+func TestCRUD_Find_UTC(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*3600))
+	crud, _ := rowsCRUD(t, []string{"id", "created_at"}, []driver.Value{int64(1), created})
+
+	var a article
+	assert.NoError(t, crud.Find(&a, NewQuery("articles")))
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 4, 5, 0, time.UTC), a.CreatedAt)
+}
+
+func TestPostgresDSN_TimeZone(t *testing.T) {
+	cfg := &config.DatabaseConfig{}
+	dsn, err := postgresDSN(cfg, "host=localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "host=localhost timezone=UTC", dsn)
+
+	cfg.TimeZone = "America/New_York"
+	dsn, err = postgresDSN(cfg, "host=localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "host=localhost timezone=America/New_York", dsn)
+
+	cfg.TimeZone = "Mars/Olympus"
+	_, err = postgresDSN(cfg, "host=localhost")
+	assert.ErrorContains(t, err, "invalid time zone")
+}
+
+func TestPostgresDSN_Pooled(t *testing.T) {
+	cfg := &config.DatabaseConfig{StatementTimeout: "30s", ReadOnly: true}
+	dsn, err := postgresDSN(cfg, "host=localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "host=localhost timezone=UTC statement_timeout=30000 default_transaction_read_only=on", dsn)
+
+	cfg.Pooled = true
+	_, err = postgresDSN(cfg, "host=localhost")
+	assert.ErrorContains(t, err, "statement timeout", "pgbouncer would drop the statement timeout")
+
+	cfg.StatementTimeout = ""
+	_, err = postgresDSN(cfg, "host=localhost")
+	assert.ErrorIs(t, err, ErrReadOnly, "pgbouncer would drop the server-side read-only mode")
+
+	cfg.ReadOnly = false
+	dsn, err = postgresDSN(cfg, "host=localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "host=localhost timezone=UTC", dsn)
+}
@@ -0,0 +1,34 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVector_ValueAndScan(t *testing.T) {
+	value, err := Vector{1, 0.5, -2}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,0.5,-2]", value)
+
+	var v Vector
+	assert.NoError(t, v.Scan([]byte("[1,0.5,-2]")))
+	assert.Equal(t, Vector{1, 0.5, -2}, v)
+
+	assert.NoError(t, v.Scan(nil))
+	assert.Nil(t, v)
+	assert.Error(t, v.Scan("[1,a]"))
+}
+
+func TestQuery_OrderBySimilarity(t *testing.T) {
+	query, params := NewQuery("documents").
+		Select("id", "title").
+		Where("published = ?", true).
+		OrderBySimilarity("embedding", []float32{0.1, 0.2}).
+		Limit(5).
+		Placeholders(Dollar).
+		Build()
+
+	assert.Equal(t, "SELECT id, title FROM documents WHERE published = $1 ORDER BY embedding <=> $2 ASC LIMIT 5", query)
+	assert.Equal(t, []interface{}{true, Vector{0.1, 0.2}}, params)
+}
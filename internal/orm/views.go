@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+)
+
+// ViewRefresh is a materialized view to refresh, and whether to refresh it concurrently.
+type ViewRefresh struct {
+	View string
+	// Concurrently refreshes the view without locking out its readers. The view needs a unique index, as the
+	// views of view models have.
+	Concurrently bool
+}
+
+// ParseViewRefreshes parses the target of a scheduled refresh-view task: a comma-separated list of views, each
+// prefixed with "concurrently:" to refresh it concurrently, such as "author_stats,concurrently:daily_sales".
+func ParseViewRefreshes(target string) ([]ViewRefresh, error) {
+	var refreshes []ViewRefresh
+	for _, item := range strings.Split(target, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		view, concurrently := strings.CutPrefix(item, "concurrently:")
+		if err := dialect.Validate(view); err != nil {
+			return nil, fmt.Errorf("invalid view %q: %w", view, err)
+		}
+		refreshes = append(refreshes, ViewRefresh{View: view, Concurrently: concurrently})
+	}
+	if len(refreshes) == 0 {
+		return nil, fmt.Errorf("no view to refresh: list views such as author_stats or concurrently:author_stats")
+	}
+	return refreshes, nil
+}
+
+// String returns r in the format read by ParseViewRefreshes.
+func (r ViewRefresh) String() string {
+	if r.Concurrently {
+		return "concurrently:" + r.View
+	}
+	return r.View
+}
+
+// RefreshView runs the query of the materialized view of r again and replaces its rows with the result.
+func (c *Connection) RefreshView(ctx context.Context, r ViewRefresh) error {
+	if err := c.CheckWritable("refresh " + r.View); err != nil {
+		return err
+	}
+	if err := dialect.Validate(r.View); err != nil {
+		return err
+	}
+	if _, err := c.db.ExecContext(ctx, refreshViewQuery(c.Dialect(), r)); err != nil {
+		return fmt.Errorf("error refreshing %s: %w", r.View, TranslateError(err))
+	}
+	return nil
+}
+
+// refreshViewQuery returns the statement refreshing the view of r.
+func refreshViewQuery(d dialect.Dialect, r ViewRefresh) string {
+	if r.Concurrently {
+		return "REFRESH MATERIALIZED VIEW CONCURRENTLY " + d.Quote(r.View)
+	}
+	return "REFRESH MATERIALIZED VIEW " + d.Quote(r.View)
+}
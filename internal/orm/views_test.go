@@ -0,0 +1,48 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseViewRefreshes(t *testing.T) {
+	refreshes, err := ParseViewRefreshes("author_stats, concurrently:daily_sales")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ViewRefresh{{View: "author_stats"}, {View: "daily_sales", Concurrently: true}}, refreshes)
+		assert.Equal(t, "concurrently:daily_sales", refreshes[1].String())
+	}
+
+	for _, target := range []string{"", " , ", "stats; DROP TABLE users", "concurrently:"} {
+		_, err := ParseViewRefreshes(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestRefreshViewQuery(t *testing.T) {
+	assert.Equal(t, "REFRESH MATERIALIZED VIEW author_stats", refreshViewQuery(dialect.Postgres, ViewRefresh{View: "author_stats"}))
+	assert.Equal(t, "REFRESH MATERIALIZED VIEW CONCURRENTLY author_stats",
+		refreshViewQuery(dialect.Postgres, ViewRefresh{View: "author_stats", Concurrently: true}))
+}
+
+type authorStat struct {
+	model.DefaultModel
+	Posts int
+}
+
+func (a *authorStat) TableName() string { return "author_stats" }
+func (a *authorStat) ReadOnly() bool    { return true }
+
+func TestCRUD_ReadOnlyModel(t *testing.T) {
+	crud := NewCRUD(&Connection{driver: "postgres"})
+	stat := &authorStat{Posts: 3}
+
+	assert.ErrorIs(t, crud.Create(stat), ErrReadOnlyModel)
+	assert.ErrorIs(t, crud.Update(stat), ErrReadOnlyModel)
+	assert.ErrorIs(t, crud.Delete(stat, 1), ErrReadOnlyModel)
+	_, err := crud.DeleteWhere(stat, "posts = ?", 0)
+	assert.ErrorIs(t, err, ErrReadOnlyModel)
+	assert.ErrorIs(t, crud.UnitOfWork().Track(stat), ErrReadOnlyModel)
+}
@@ -0,0 +1,150 @@
+// Package profile records CPU and heap profiles and the time spent in each phase of a grayv-lsm command in a
+// local directory, so users can attach them when reporting a slow command. Nothing is ever sent over the network.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDir is where profiles are written when no directory is given.
+const DefaultDir = ".grayv/profiles"
+
+// The files written to the directory of a run. The profiles are read with go tool pprof.
+const (
+	CPUFile     = "cpu.pprof"
+	HeapFile    = "heap.pprof"
+	TimingsFile = "timings.json"
+)
+
+// Phase is a named part of a command and how long it took. Start is the offset from the start of the command.
+type Phase struct {
+	Name     string        `json:"name"`
+	Start    time.Duration `json:"start_ns"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Timings are the timings of a run, with the platform it ran on, as written to TimingsFile.
+type Timings struct {
+	Command   string        `json:"command"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration_ns"`
+	Phases    []Phase       `json:"phases"`
+	GoVersion string        `json:"go_version"`
+	OS        string        `json:"os"`
+	Arch      string        `json:"arch"`
+	CPUs      int           `json:"cpus"`
+}
+
+// Profiler records a profile of one run of a command. The methods of a nil Profiler do nothing, so commands
+// can mark their phases whether or not they are profiled.
+type Profiler struct {
+	dir     string
+	cpu     *os.File
+	mu      sync.Mutex
+	timings Timings
+}
+
+// Start starts profiling command, such as "db migrate", into a new directory under dir named after the command
+// and the time, as in db-migrate-20250101T120000. The CPU is sampled until Stop is called.
+func Start(dir, command string) (*Profiler, error) {
+	start := time.Now()
+	name := strings.ReplaceAll(command, " ", "-") + "-" + start.Format("20060102T150405")
+	p := &Profiler{
+		dir: filepath.Join(dir, name),
+		timings: Timings{
+			Command:   command,
+			Start:     start,
+			GoVersion: runtime.Version(),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			CPUs:      runtime.NumCPU(),
+		},
+	}
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating profile directory: %w", err)
+	}
+
+	cpu, err := os.Create(filepath.Join(p.dir, CPUFile))
+	if err != nil {
+		return nil, fmt.Errorf("error creating CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, fmt.Errorf("error starting CPU profile: %w", err)
+	}
+	p.cpu = cpu
+	return p, nil
+}
+
+// Dir returns the directory the profile is written to.
+func (p *Profiler) Dir() string {
+	if p == nil {
+		return ""
+	}
+	return p.dir
+}
+
+// Phase starts timing the phase name and returns the function ending it. Phases may overlap.
+//
+// Example usage: defer profiler.Phase("load")()
+func (p *Profiler) Phase(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.timings.Phases = append(p.timings.Phases, Phase{
+				Name:     name,
+				Start:    start.Sub(p.timings.Start),
+				Duration: time.Since(start),
+			})
+		})
+	}
+}
+
+// Stop stops sampling the CPU and writes the heap profile and the timings, returning them.
+func (p *Profiler) Stop() (*Timings, error) {
+	if p == nil {
+		return nil, nil
+	}
+	pprof.StopCPUProfile()
+	if err := p.cpu.Close(); err != nil {
+		return nil, fmt.Errorf("error writing CPU profile: %w", err)
+	}
+
+	heap, err := os.Create(filepath.Join(p.dir, HeapFile))
+	if err != nil {
+		return nil, fmt.Errorf("error creating heap profile: %w", err)
+	}
+	defer heap.Close()
+	// Collect garbage first, so the profile shows the memory still in use at the end of the command.
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(heap); err != nil {
+		return nil, fmt.Errorf("error writing heap profile: %w", err)
+	}
+
+	p.mu.Lock()
+	timings := p.timings
+	p.mu.Unlock()
+	timings.Duration = time.Since(timings.Start)
+	data, err := json.MarshalIndent(timings, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling timings: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(p.dir, TimingsFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing timings: %w", err)
+	}
+	return &timings, nil
+}
@@ -0,0 +1,54 @@
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfiler(t *testing.T) {
+	dir := t.TempDir()
+	p, err := Start(dir, "db migrate")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(filepath.Base(p.Dir()), "db-migrate-"))
+
+	endLoad := p.Phase("load")
+	endLoad()
+	endLoad()
+	p.Phase("migrate")()
+
+	timings, err := p.Stop()
+	assert.NoError(t, err)
+	assert.Equal(t, "db migrate", timings.Command)
+	if assert.Len(t, timings.Phases, 2, "ending a phase twice records it once") {
+		assert.Equal(t, "load", timings.Phases[0].Name)
+		assert.Equal(t, "migrate", timings.Phases[1].Name)
+	}
+
+	for _, name := range []string{CPUFile, HeapFile} {
+		info, err := os.Stat(filepath.Join(p.Dir(), name))
+		if assert.NoError(t, err, name) {
+			assert.NotZero(t, info.Size(), name)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir(), TimingsFile))
+	assert.NoError(t, err)
+	var written Timings
+	assert.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, timings.Phases, written.Phases)
+}
+
+func TestProfiler_Nil(t *testing.T) {
+	var p *Profiler
+	p.Phase("load")()
+	timings, err := p.Stop()
+	assert.NoError(t, err)
+	assert.Nil(t, timings)
+	assert.Empty(t, p.Dir())
+}
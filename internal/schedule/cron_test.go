@@ -0,0 +1,65 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"5-1 * * * *",
+		"@every 10x",
+	}
+
+	for _, expr := range cases {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, "expected error for %q", expr)
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	base := time.Date(2024, 3, 15, 10, 30, 45, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 3, 16, 3, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 3, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 3, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,20 * *", time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+
+	for _, tc := range cases {
+		cron, err := ParseCron(tc.expr)
+		if !assert.NoError(t, err, tc.expr) {
+			continue
+		}
+		assert.Equal(t, tc.want, cron.Next(base), tc.expr)
+	}
+}
+
+func TestCron_NextDayOfMonthOrWeek(t *testing.T) {
+	// With both day fields restricted, either may match: the 1st of the month or any Friday.
+	cron, err := ParseCron("0 0 1 * 5")
+	assert.NoError(t, err)
+
+	base := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC), cron.Next(base))
+}
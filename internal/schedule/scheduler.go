@@ -0,0 +1,379 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Task sources.
+const (
+	SourceConfig   = "config"
+	SourceDatabase = "database"
+)
+
+// Run statuses stored in the scheduled_task_runs table.
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+)
+
+// Task is a unit of work run by the Scheduler whenever its cron expression matches.
+type Task struct {
+	Name   string
+	Cron   *Cron
+	Type   string
+	Target string
+	Source string
+}
+
+// TaskRun is a single entry in a task's run history.
+type TaskRun struct {
+	ID         int64
+	TaskName   string
+	StartedAt  time.Time
+	FinishedAt sql.NullTime
+	Status     string
+	Error      string
+}
+
+// ActionFunc performs the work of a task. The task type selects which ActionFunc is used.
+type ActionFunc func(ctx context.Context, task *Task) error
+
+// Scheduler runs tasks on cron schedules and records every run in the database.
+// SQL and HTTP tasks are supported out of the box; other task types can be added with RegisterAction.
+// Clock decides when tasks are due and stamps their runs; nil is the system clock.
+//
+// Example usage:
+//
+//	scheduler := schedule.NewScheduler(conn.GetDB(), log)
+//	scheduler.RegisterAction("seed", runSeeds)
+//	if err := scheduler.LoadTasks(cfg.Scheduler); err != nil {
+//	    // handle error
+//	}
+//	err := scheduler.Run(ctx)
+type Scheduler struct {
+	db      *sql.DB
+	logger  *logrus.Logger
+	tasks   []*Task
+	actions map[string]ActionFunc
+	Clock   clock.Clock
+	// Ready, if set, is called before every due run, which is skipped while it returns an error, such as while
+	// the database is unreachable. Runs then resume at the next match of their cron expression.
+	Ready func() error
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewScheduler creates a new Scheduler with the built-in "sql" and "http" actions registered.
+func NewScheduler(db *sql.DB, logger *logrus.Logger) *Scheduler {
+	s := &Scheduler{
+		db:      db,
+		logger:  logger,
+		actions: make(map[string]ActionFunc),
+		running: make(map[string]bool),
+	}
+	s.RegisterAction("sql", s.runSQL)
+	s.RegisterAction("http", runHTTP)
+	return s
+}
+
+// RegisterAction sets the action used for tasks of the given type.
+func (s *Scheduler) RegisterAction(taskType string, action ActionFunc) {
+	s.actions[taskType] = action
+}
+
+// AddTask validates a task definition and adds it to the scheduler.
+// It returns an error if the cron expression is invalid, the type has no registered action,
+// or a task with the same name already exists.
+func (s *Scheduler) AddTask(name, cronExpr, taskType, target, source string) error {
+	if name == "" {
+		return fmt.Errorf("task name is required")
+	}
+	if _, ok := s.actions[taskType]; !ok {
+		return fmt.Errorf("task %s has unknown type %q", name, taskType)
+	}
+	for _, task := range s.tasks {
+		if task.Name == name {
+			return fmt.Errorf("task %s is defined more than once", name)
+		}
+	}
+
+	cron, err := ParseCron(cronExpr)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+
+	s.tasks = append(s.tasks, &Task{Name: name, Cron: cron, Type: taskType, Target: target, Source: source})
+	return nil
+}
+
+// LoadTasks adds the tasks defined in the configuration and the enabled tasks stored in the scheduled_tasks table.
+func (s *Scheduler) LoadTasks(cfg config.SchedulerConfig) error {
+	for _, task := range cfg.Tasks {
+		if err := s.AddTask(task.Name, task.Cron, task.Type, task.Target, SourceConfig); err != nil {
+			return err
+		}
+	}
+
+	rows, err := s.db.Query("SELECT name, cron, type, target FROM scheduled_tasks WHERE enabled ORDER BY name")
+	if err != nil {
+		return fmt.Errorf("failed to query scheduled tasks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, cronExpr, taskType, target string
+		if err := rows.Scan(&name, &cronExpr, &taskType, &target); err != nil {
+			return fmt.Errorf("failed to scan scheduled task: %w", err)
+		}
+		if err := s.AddTask(name, cronExpr, taskType, target, SourceDatabase); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Tasks returns the loaded tasks sorted by name.
+func (s *Scheduler) Tasks() []*Task {
+	tasks := make([]*Task, len(s.tasks))
+	copy(tasks, s.tasks)
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+	return tasks
+}
+
+// Task returns the loaded task with the given name.
+func (s *Scheduler) Task(name string) (*Task, error) {
+	for _, task := range s.tasks {
+		if task.Name == name {
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("task %s does not exist", name)
+}
+
+// Run starts the scheduler loop and blocks until the context is cancelled.
+// Due tasks are started in their own goroutine; a task is skipped if its previous run is still in progress.
+// When the context is cancelled, Run waits for running tasks to finish before returning; running tasks are not
+// cancelled along with it, so a caller bounding the shutdown must stop waiting on its own.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.tasks) == 0 {
+		return fmt.Errorf("no scheduled tasks defined")
+	}
+
+	clk := clock.OrReal(s.Clock)
+	next := make(map[*Task]time.Time, len(s.tasks))
+	now := clk.Now()
+	for _, task := range s.tasks {
+		next[task] = task.Cron.Next(now)
+		s.logger.Infof("Scheduled task %s (%s), next run at %s", task.Name, task.Cron, next[task].Format(time.RFC3339))
+	}
+
+	var wg sync.WaitGroup
+	for {
+		var earliest time.Time
+		for _, at := range next {
+			if !at.IsZero() && (earliest.IsZero() || at.Before(earliest)) {
+				earliest = at
+			}
+		}
+		if earliest.IsZero() {
+			wg.Wait()
+			return fmt.Errorf("no scheduled task has an upcoming run time")
+		}
+
+		timer := clk.NewTimer(earliest.Sub(clk.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.logger.Info("Scheduler stopping, waiting for running tasks to finish")
+			wg.Wait()
+			return nil
+		case <-timer.C():
+		}
+
+		now = clk.Now()
+		for task, at := range next {
+			if at.IsZero() || at.After(now) {
+				continue
+			}
+			next[task] = task.Cron.Next(now)
+
+			if s.Ready != nil {
+				if err := s.Ready(); err != nil {
+					s.logger.Warnf("Skipping task %s: %v", task.Name, err)
+					continue
+				}
+			}
+			if !s.markRunning(task.Name) {
+				s.logger.Warnf("Skipping task %s: previous run is still in progress", task.Name)
+				continue
+			}
+
+			wg.Add(1)
+			go func(task *Task) {
+				defer wg.Done()
+				defer s.markDone(task.Name)
+				if err := s.RunTask(context.WithoutCancel(ctx), task); err != nil {
+					s.logger.WithError(err).Errorf("Scheduled task %s failed", task.Name)
+				}
+			}(task)
+		}
+	}
+}
+
+// RunTask runs a single task immediately and records the run in the task history.
+func (s *Scheduler) RunTask(ctx context.Context, task *Task) error {
+	action, ok := s.actions[task.Type]
+	if !ok {
+		return fmt.Errorf("task %s has unknown type %q", task.Name, task.Type)
+	}
+
+	clk := clock.OrReal(s.Clock)
+	var runID int64
+	err := s.db.QueryRow("INSERT INTO scheduled_task_runs (task_name, status, started_at) VALUES ($1, $2, $3) RETURNING id",
+		task.Name, RunStatusRunning, clk.Now()).Scan(&runID)
+	if err != nil {
+		return fmt.Errorf("failed to record task run: %w", err)
+	}
+
+	s.logger.Infof("Running scheduled task %s", task.Name)
+	taskErr := action(ctx, task)
+
+	status := RunStatusSuccess
+	var errMsg sql.NullString
+	if taskErr != nil {
+		status = RunStatusFailed
+		errMsg = sql.NullString{String: taskErr.Error(), Valid: true}
+	}
+
+	if _, err := s.db.Exec("UPDATE scheduled_task_runs SET finished_at = $1, status = $2, error = $3 WHERE id = $4",
+		clk.Now(), status, errMsg, runID); err != nil {
+		return fmt.Errorf("failed to record task result: %w", err)
+	}
+
+	if taskErr != nil {
+		return taskErr
+	}
+	s.logger.Infof("Scheduled task %s completed", task.Name)
+	return nil
+}
+
+// History returns the most recent runs, newest first. An empty task name returns runs of every task.
+func (s *Scheduler) History(taskName string, limit int) ([]*TaskRun, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	rows, err := s.db.Query(`SELECT id, task_name, started_at, finished_at, status, COALESCE(error, '')
+		FROM scheduled_task_runs WHERE ($1 = '' OR task_name = $1) ORDER BY started_at DESC, id DESC LIMIT $2`,
+		taskName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task history: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*TaskRun
+	for rows.Next() {
+		run := &TaskRun{}
+		if err := rows.Scan(&run.ID, &run.TaskName, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan task run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// SaveTask stores a task definition in the scheduled_tasks table, replacing any task with the same name.
+func SaveTask(db *sql.DB, name, cronExpr, taskType, target string) error {
+	if _, err := ParseCron(cronExpr); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`INSERT INTO scheduled_tasks (name, cron, type, target) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET cron = EXCLUDED.cron, type = EXCLUDED.type, target = EXCLUDED.target, enabled = TRUE`,
+		name, cronExpr, taskType, target)
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteTask removes a task definition from the scheduled_tasks table.
+func DeleteTask(db *sql.DB, name string) error {
+	result, err := db.Exec("DELETE FROM scheduled_tasks WHERE name = $1", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete task %s: %w", name, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("task %s does not exist", name)
+	}
+	return nil
+}
+
+func (s *Scheduler) markRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *Scheduler) markDone(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+// runSQL executes the task target as a SQL statement.
+func (s *Scheduler) runSQL(ctx context.Context, task *Task) error {
+	if task.Target == "" {
+		return fmt.Errorf("sql task %s has no statement", task.Name)
+	}
+	_, err := s.db.ExecContext(ctx, task.Target)
+	return err
+}
+
+// runHTTP sends a GET request to the task target and fails on any non-2xx response.
+func runHTTP(ctx context.Context, task *Task) error {
+	if task.Target == "" {
+		return fmt.Errorf("http task %s has no URL", task.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, task.Target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", task.Target, resp.StatusCode)
+	}
+	return nil
+}
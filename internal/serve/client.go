@@ -0,0 +1,419 @@
+package serve
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+)
+
+// ClientLanguages lists the languages GenerateClient writes clients in.
+var ClientLanguages = []string{"go", "ts"}
+
+// DefaultClientPackage is the package name of generated Go clients if none is given.
+const DefaultClientPackage = "client"
+
+// clientModel describes the endpoints of a model to the client templates. Read-only models, backed by views,
+// only have the list and get endpoints.
+type clientModel struct {
+	Name     string
+	Plural   string
+	Path     string
+	ReadOnly bool
+	Key      clientField
+	Fields   []clientField
+	Inputs   []clientField
+}
+
+// clientField is a property of the records of a model, as returned and accepted by the API.
+type clientField struct {
+	JSON        string
+	GoName      string
+	GoType      string
+	GoInputType string
+	TSType      string
+	TSInputType string
+	Comment     string
+}
+
+// GenerateClient returns the source of a typed HTTP client for the CRUD endpoints the server exposes for defs,
+// in lang: "go" for a Go package named pkg, or "ts" for a TypeScript module using fetch.
+func GenerateClient(defs []*model.ModelDefinition, lang, pkg string) ([]byte, error) {
+	var tmpl *template.Template
+	switch lang {
+	case "go":
+		tmpl = goClientTemplate
+	case "ts":
+		tmpl = tsClientTemplate
+	default:
+		return nil, fmt.Errorf("unknown client language %q: use %s", lang, strings.Join(ClientLanguages, " or "))
+	}
+	if pkg == "" {
+		pkg = DefaultClientPackage
+	}
+
+	models := make([]clientModel, len(defs))
+	usesTime := false
+	for i, def := range defs {
+		models[i] = newClientModel(def)
+		for _, field := range models[i].Fields {
+			usesTime = usesTime || strings.Contains(field.GoType, "time.Time")
+		}
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, map[string]interface{}{"Package": pkg, "Models": models, "UsesTime": usesTime})
+	if err != nil {
+		return nil, fmt.Errorf("error executing client template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newClientModel returns the description of the endpoints of def. Rows have the id, created_at, and
+// updated_at columns of DefaultModel unless a field is the primary key; the id is a string with the uuid
+// ID strategy, and part of the input with the app strategy. Hidden fields are left out, and read-only fields
+// are not part of the input.
+func newClientModel(def *model.ModelDefinition) clientModel {
+	m := clientModel{
+		Name:     def.Name,
+		Plural:   naming.ToCamel(def.Name) + "s",
+		Path:     "/api/" + strings.ToLower(def.Name),
+		ReadOnly: def.IsView(),
+	}
+	hasKey := false
+	for _, field := range def.Fields {
+		if field.IsPrimary {
+			hasKey = true
+		}
+	}
+	if !hasKey {
+		m.Key = clientField{JSON: "id", GoName: "ID", GoType: "int64", TSType: "number"}
+		if def.KeyStrategy() == model.IDUUID {
+			m.Key.GoType, m.Key.TSType = "string", "string"
+		}
+		if def.KeyStrategy() == model.IDApp {
+			m.Inputs = append(m.Inputs, clientField{JSON: "id", GoName: "ID", GoInputType: "*int64", TSInputType: "number"})
+		}
+		m.Fields = append(m.Fields, m.Key,
+			clientField{JSON: "created_at", GoName: "CreatedAt", GoType: "time.Time", TSType: "string"},
+			clientField{JSON: "updated_at", GoName: "UpdatedAt", GoType: "time.Time", TSType: "string"})
+	}
+
+	for _, field := range def.Fields {
+		if field.Hidden {
+			continue
+		}
+		f := newClientField(field)
+		m.Fields = append(m.Fields, f)
+		if field.IsPrimary {
+			m.Key = f
+			continue
+		}
+		if field.Writable() {
+			m.Inputs = append(m.Inputs, f)
+		}
+	}
+	return m
+}
+
+// newClientField returns the description of field. Values read from the API are pointers in Go, and may be
+// null in TypeScript, if the field is nullable. Vectors are read in the text format of pgvector, such as
+// "[1,2,3]", and written as arrays of numbers. Decimals are read and written as text, such as "1.50", so no
+// digit is lost.
+func newClientField(field model.Field) clientField {
+	f := clientField{JSON: field.ColumnName(), GoName: naming.ToCamel(field.Name)}
+	if _, ok := model.VectorDimensions(field.Type); ok {
+		f.GoType, f.GoInputType, f.TSType, f.TSInputType = "string", "[]float32", "string", "number[]"
+		f.Comment = "Vector in the text format of pgvector, such as [1,2,3]"
+	} else if _, _, ok := model.DecimalPrecision(field.Type); ok {
+		f.GoType, f.GoInputType, f.TSType, f.TSInputType = "string", "*string", "string", "string"
+		f.Comment = "Exact decimal number as text, such as 1.50"
+	} else {
+		switch model.BaseType(field.Type) {
+		case "int":
+			f.GoType, f.TSType = "int64", "number"
+		case "float64":
+			f.GoType, f.TSType = "float64", "number"
+		case "bool":
+			f.GoType, f.TSType = "bool", "boolean"
+		case "time.Time":
+			f.GoType, f.TSType = "time.Time", "string"
+		default:
+			f.GoType, f.TSType = "string", "string"
+		}
+		f.GoInputType, f.TSInputType = "*"+f.GoType, f.TSType
+	}
+	if field.References != "" {
+		f.Comment = "ID of the referenced " + field.References
+	}
+	if field.IsNull {
+		f.GoType = "*" + f.GoType
+		f.TSType += " | null"
+		f.TSInputType += " | null"
+	}
+	return f
+}
+
+var goClientTemplate = template.Must(template.New("go").Parse(`// Package {{.Package}} is a client for the REST API served by grayv-lsm serve.
+package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+{{- if .UsesTime}}
+	"time"
+{{- end}}
+)
+
+// Client calls the API of a grayv-lsm server.
+type Client struct {
+	// BaseURL is the URL of the server, such as http://localhost:8080.
+	BaseURL string
+	// HTTPClient sends the requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// New returns a Client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is returned for responses with an error status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// ListOptions pages through the rows of a list request.
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+func (o ListOptions) query() string {
+	values := url.Values{}
+	if o.Limit > 0 {
+		values.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		values.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// do sends a request with body encoded as JSON, unless it is nil, and decodes the response into out, unless it is nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var payload struct {
+			Error string ` + "`json:\"error\"`" + `
+		}
+		json.NewDecoder(resp.Body).Decode(&payload)
+		return &Error{StatusCode: resp.StatusCode, Message: payload.Error}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+{{range .Models}}{{$m := .}}
+// {{.Name}} is a row of the {{.Name}} model.
+type {{.Name}} struct {
+{{- range .Fields}}
+{{- if .Comment}}
+	// {{.Comment}}.
+{{- end}}
+	{{.GoName}} {{.GoType}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+
+{{- if not .ReadOnly}}
+
+// {{.Name}}Input holds the fields of a {{.Name}} to create or update. Nil fields are left out.
+type {{.Name}}Input struct {
+{{- range .Inputs}}
+	{{.GoName}} {{.GoInputType}} ` + "`json:\"{{.JSON}},omitempty\"`" + `
+{{- end}}
+}
+{{- end}}
+
+// List{{.Plural}} returns a page of {{.Name}} rows.
+func (c *Client) List{{.Plural}}(ctx context.Context, opts ListOptions) ([]{{.Name}}, error) {
+	var rows []{{.Name}}
+	err := c.do(ctx, http.MethodGet, "{{.Path}}"+opts.query(), nil, &rows)
+	return rows, err
+}
+
+// Get{{.Name}} returns the {{.Name}} with the given {{.Key.JSON}}.
+func (c *Client) Get{{.Name}}(ctx context.Context, {{.Key.JSON}} {{.Key.GoType}}) (*{{.Name}}, error) {
+	var row {{.Name}}
+	if err := c.do(ctx, http.MethodGet, "{{.Path}}/"+url.PathEscape(fmt.Sprint({{.Key.JSON}})), nil, &row); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+{{- if not .ReadOnly}}
+
+// Create{{.Name}} inserts a {{.Name}} and returns the stored row.
+func (c *Client) Create{{.Name}}(ctx context.Context, input {{.Name}}Input) (*{{.Name}}, error) {
+	var row {{.Name}}
+	if err := c.do(ctx, http.MethodPost, "{{.Path}}", input, &row); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// Update{{.Name}} sets the given fields of the {{.Name}} with the given {{.Key.JSON}} and returns the stored row.
+func (c *Client) Update{{.Name}}(ctx context.Context, {{.Key.JSON}} {{.Key.GoType}}, input {{.Name}}Input) (*{{.Name}}, error) {
+	var row {{.Name}}
+	if err := c.do(ctx, http.MethodPut, "{{.Path}}/"+url.PathEscape(fmt.Sprint({{.Key.JSON}})), input, &row); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// Delete{{.Name}} deletes the {{.Name}} with the given {{.Key.JSON}}.
+func (c *Client) Delete{{.Name}}(ctx context.Context, {{.Key.JSON}} {{.Key.GoType}}) error {
+	return c.do(ctx, http.MethodDelete, "{{.Path}}/"+url.PathEscape(fmt.Sprint({{.Key.JSON}})), nil, nil)
+}
+{{- end}}
+{{end}}`))
+
+var tsClientTemplate = template.Must(template.New("ts").Parse(`// A client for the REST API served by grayv-lsm serve.
+
+/** ApiError is thrown for responses with an error status. */
+export class ApiError extends Error {
+  constructor(public readonly status: number, message: string) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+/** ListOptions pages through the rows of a list request. */
+export interface ListOptions {
+  limit?: number;
+  offset?: number;
+}
+{{range .Models}}
+/** A row of the {{.Name}} model. */
+export interface {{.Name}} {
+{{- range .Fields}}
+{{- if .Comment}}
+  /** {{.Comment}}. */
+{{- end}}
+  {{.JSON}}: {{.TSType}};
+{{- end}}
+}
+{{- if not .ReadOnly}}
+
+/** The fields of a {{.Name}} to create or update. */
+export interface {{.Name}}Input {
+{{- range .Inputs}}
+  {{.JSON}}?: {{.TSInputType}};
+{{- end}}
+}
+{{- end}}
+{{end}}
+/** Client calls the API of a grayv-lsm server. */
+export class Client {
+  constructor(
+    private readonly baseUrl: string,
+    private readonly fetchFn: typeof fetch = fetch,
+  ) {
+    this.baseUrl = baseUrl.replace(/\/$/, "");
+  }
+
+  private async request<T>(method: string, path: string, body?: unknown): Promise<T> {
+    const headers: Record<string, string> = { Accept: "application/json" };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    const response = await this.fetchFn(this.baseUrl + path, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const payload = await response.json().catch(() => ({}));
+      throw new ApiError(response.status, payload.error ?? response.statusText);
+    }
+    if (response.status === 204) {
+      return undefined as T;
+    }
+    return (await response.json()) as T;
+  }
+
+  private static query(options: ListOptions = {}): string {
+    const params = new URLSearchParams();
+    if (options.limit) params.set("limit", String(options.limit));
+    if (options.offset) params.set("offset", String(options.offset));
+    const query = params.toString();
+    return query ? "?" + query : "";
+  }
+{{range .Models}}
+  list{{.Plural}}(options?: ListOptions): Promise<{{.Name}}[]> {
+    return this.request("GET", "{{.Path}}" + Client.query(options));
+  }
+
+  get{{.Name}}({{.Key.JSON}}: {{.Key.TSType}}): Promise<{{.Name}}> {
+    return this.request("GET", "{{.Path}}/" + encodeURIComponent(String({{.Key.JSON}})));
+  }
+{{- if not .ReadOnly}}
+
+  create{{.Name}}(input: {{.Name}}Input): Promise<{{.Name}}> {
+    return this.request("POST", "{{.Path}}", input);
+  }
+
+  update{{.Name}}({{.Key.JSON}}: {{.Key.TSType}}, input: {{.Name}}Input): Promise<{{.Name}}> {
+    return this.request("PUT", "{{.Path}}/" + encodeURIComponent(String({{.Key.JSON}})), input);
+  }
+
+  delete{{.Name}}({{.Key.JSON}}: {{.Key.TSType}}): Promise<void> {
+    return this.request("DELETE", "{{.Path}}/" + encodeURIComponent(String({{.Key.JSON}})));
+  }
+{{- end}}
+{{end}}}
+`))
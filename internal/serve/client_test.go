@@ -0,0 +1,130 @@
+package serve
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func clientTestModels() []*model.ModelDefinition {
+	return []*model.ModelDefinition{
+		model.NewModelDefinition("Author", []model.Field{{Name: "Name", Type: "string"}}),
+		model.NewModelDefinition("BlogPost", []model.Field{
+			{Name: "Views", Type: "int"},
+			{Name: "PublishedAt", Type: "time.Time", IsNull: true},
+			{Name: "Embedding", Type: "vector(3)"},
+			{Name: "AuthorID", Type: "int", References: "Author"},
+		}),
+	}
+}
+
+func TestGenerateClient_Go(t *testing.T) {
+	source, err := GenerateClient(clientTestModels(), "go", "blogclient")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", source, 0)
+	if !assert.NoError(t, err, string(source)) {
+		return
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("blogclient", fset, []*ast.File{file}, nil)
+	if !assert.NoError(t, err, string(source)) {
+		return
+	}
+
+	code := string(source)
+	assert.Contains(t, code, "package blogclient\n")
+	assert.Contains(t, code, "\tPublishedAt *time.Time `json:\"published_at\"`\n")
+	assert.Contains(t, code, "\tEmbedding []float32 `json:\"embedding,omitempty\"`\n")
+	assert.Contains(t, code, "func (c *Client) ListBlogPosts(ctx context.Context, opts ListOptions) ([]BlogPost, error) {")
+	assert.Contains(t, code, "func (c *Client) UpdateAuthor(ctx context.Context, id int64, input AuthorInput) (*Author, error) {")
+	assert.Contains(t, code, `"/api/blogpost/"+url.PathEscape(fmt.Sprint(id))`)
+}
+
+func TestGenerateClient_TypeScript(t *testing.T) {
+	source, err := GenerateClient(clientTestModels(), "ts", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	code := string(source)
+	assert.Contains(t, code, "\nexport interface BlogPostInput {\n  views?: number;\n  published_at?: string | null;\n  embedding?: number[];\n")
+	assert.Contains(t, code, "  /** ID of the referenced Author. */\n  author_id: number;\n")
+	assert.Contains(t, code, "  listAuthors(options?: ListOptions): Promise<Author[]> {\n")
+	assert.Contains(t, code, "  deleteBlogPost(id: number): Promise<void> {\n")
+}
+
+func TestGenerateClient_UnknownLanguage(t *testing.T) {
+	_, err := GenerateClient(clientTestModels(), "python", "")
+	assert.ErrorContains(t, err, `unknown client language "python"`)
+}
+
+func TestGenerateClient_IDStrategy(t *testing.T) {
+	events := model.NewModelDefinition("Event", []model.Field{{Name: "Kind", Type: "string"}})
+	events.IDStrategy = model.IDUUID
+	tags := model.NewModelDefinition("Tag", []model.Field{{Name: "Label", Type: "string"}})
+	tags.IDStrategy = model.IDApp
+	defs := []*model.ModelDefinition{events, tags}
+
+	source, err := GenerateClient(defs, "go", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", source, 0)
+	if !assert.NoError(t, err, string(source)) {
+		return
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("client", fset, []*ast.File{file}, nil)
+	assert.NoError(t, err, string(source))
+
+	code := string(source)
+	assert.Contains(t, code, "func (c *Client) GetEvent(ctx context.Context, id string) (*Event, error) {")
+	assert.Contains(t, code, "type TagInput struct {\n\tID *int64 `json:\"id,omitempty\"`\n")
+
+	source, err = GenerateClient(defs, "ts", "")
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(source), "export interface Event {\n  id: string;\n")
+	}
+}
+
+func TestGenerateClient_View(t *testing.T) {
+	stats := model.NewModelDefinition("AuthorStat", []model.Field{{Name: "Posts", Type: "int"}})
+	assert.NoError(t, stats.SetView("SELECT author_id AS id, count(*) AS posts FROM posts GROUP BY author_id", "plain"))
+	defs := append(clientTestModels(), stats)
+
+	source, err := GenerateClient(defs, "go", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", source, 0)
+	if !assert.NoError(t, err, string(source)) {
+		return
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("client", fset, []*ast.File{file}, nil)
+	assert.NoError(t, err, string(source))
+
+	code := string(source)
+	assert.Contains(t, code, "func (c *Client) GetAuthorStat(ctx context.Context, id int64) (*AuthorStat, error) {")
+	assert.NotContains(t, code, "AuthorStatInput")
+	assert.NotContains(t, code, "CreateAuthorStat")
+	assert.Contains(t, code, "CreateAuthor(", "tables keep their writes")
+
+	source, err = GenerateClient(defs, "ts", "")
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(source), "  listAuthorStats(options?: ListOptions): Promise<AuthorStat[]> {\n")
+		assert.NotContains(t, string(source), "deleteAuthorStat")
+	}
+}
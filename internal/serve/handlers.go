@@ -0,0 +1,565 @@
+package serve
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/dialect"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+)
+
+// routes registers the API handlers on the router.
+func (s *Server) routes() {
+	s.router.HandleFunc("GET /healthz", s.handleHealth)
+	s.router.HandleFunc("GET /readyz", s.handleReady)
+	s.router.HandleFunc("GET /metrics", s.handleMetrics)
+	s.router.HandleFunc("GET /api/models", s.handleListModels)
+	s.router.HandleFunc("GET /api/{model}", s.handleList)
+	s.router.HandleFunc("GET /api/{model}/{id}", s.handleGet)
+	s.router.HandleFunc("POST /api/{model}", s.handleCreate)
+	s.router.HandleFunc("PUT /api/{model}/{id}", s.handleUpdate)
+	s.router.HandleFunc("DELETE /api/{model}/{id}", s.handleDelete)
+
+	// The literal version segment makes these routes take precedence over /api/{model}/{id}.
+	for _, v := range s.versions {
+		prefix := "/api/" + v.name
+		s.router.HandleFunc("GET "+prefix+"/models", withVersion(v, s.handleListModels))
+		s.router.HandleFunc("GET "+prefix+"/{model}", withVersion(v, s.handleList))
+		s.router.HandleFunc("GET "+prefix+"/{model}/{id}", withVersion(v, s.handleGet))
+		s.router.HandleFunc("POST "+prefix+"/{model}", withVersion(v, s.handleCreate))
+		s.router.HandleFunc("PUT "+prefix+"/{model}/{id}", withVersion(v, s.handleUpdate))
+		s.router.HandleFunc("DELETE "+prefix+"/{model}/{id}", withVersion(v, s.handleDelete))
+	}
+
+	if s.cfg.Server.StaticDir != "" {
+		// Unmatched API paths must not fall through to the frontend's index page.
+		s.router.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+			mvc.WriteError(w, http.StatusNotFound, "not found")
+		})
+		s.router.Handle("/", mvc.StaticDir(s.cfg.Server.StaticDir, mvc.StaticOptions{SPA: true}))
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	mvc.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports whether the server can answer API requests, which needs the database to be reachable.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	status := s.health.Status()
+	if !status.Healthy {
+		mvc.WriteJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "unavailable", "database": status})
+		return
+	}
+	mvc.WriteJSON(w, http.StatusOK, map[string]interface{}{"status": "ready", "database": status})
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	mvc.WriteJSON(w, http.StatusOK, map[string]interface{}{"rate_limit": s.metrics.Snapshot(), "database": s.health.Status()})
+}
+
+func (s *Server) handleListModels(w http.ResponseWriter, r *http.Request) {
+	type fieldInfo struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	type modelInfo struct {
+		Name   string      `json:"name"`
+		Table  string      `json:"table"`
+		View   bool        `json:"view,omitempty"`
+		Fields []fieldInfo `json:"fields"`
+	}
+
+	list := []modelInfo{}
+	for _, def := range s.models {
+		rules, ok := requestVersion(r).rules(def.Name)
+		if !ok {
+			continue
+		}
+		info := modelInfo{Name: def.Name, Table: def.TableName(), View: def.IsView(), Fields: []fieldInfo{}}
+		for _, field := range def.Fields {
+			if name, ok := rules.name(field.ColumnName()); ok {
+				info.Fields = append(info.Fields, fieldInfo{Name: name, Type: field.Type})
+			}
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	mvc.WriteJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	def, rules, ok := s.model(r)
+	if !ok {
+		mvc.WriteError(w, http.StatusNotFound, "model not found")
+		return
+	}
+
+	limit, err := queryInt(r, "limit", 100)
+	if err != nil {
+		mvc.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		mvc.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s LIMIT $1 OFFSET $2", def.SelectList(), quote(def.TableName()), quote(def.PrimaryKey()))
+	args := []interface{}{limit, offset}
+
+	if s.cache != nil {
+		var cached []map[string]interface{}
+		found, err := s.cache.Get(r.Context(), def.TableName(), query, args, &cached)
+		if err != nil {
+			s.logger.WithError(err).Warn("Error reading query cache")
+		} else if found {
+			mvc.WriteJSON(w, http.StatusOK, rules.records(cached))
+			return
+		}
+	}
+
+	rows, err := s.conn.GetDB().QueryContext(r.Context(), query, args...)
+	if err != nil {
+		s.writeDBError(w, err)
+		return
+	}
+	defer rows.Close()
+
+	records, err := scanRecords(rows, def)
+	if err != nil {
+		s.writeDBError(w, err)
+		return
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Set(r.Context(), def.TableName(), query, args, records); err != nil {
+			s.logger.WithError(err).Warn("Error writing query cache")
+		}
+	}
+	mvc.WriteJSON(w, http.StatusOK, rules.records(records))
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	def, rules, ok := s.model(r)
+	if !ok {
+		mvc.WriteError(w, http.StatusNotFound, "model not found")
+		return
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", def.SelectList(), quote(def.TableName()), quote(def.PrimaryKey()))
+	s.writeSingle(w, r, def, rules, http.StatusOK, query, r.PathValue("id"))
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	def, rules, ok := s.writableModel(w, r)
+	if !ok {
+		return
+	}
+
+	strategy := def.KeyStrategy()
+	columns, values, err := decodeBody(r, def, rules, strategy == model.IDApp)
+	if err != nil {
+		mvc.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch strategy {
+	case model.IDApp:
+		if !slices.Contains(columns, def.PrimaryKey()) {
+			name, _ := rules.name(def.PrimaryKey())
+			mvc.WriteError(w, http.StatusBadRequest, fmt.Sprintf("field %s is required", name))
+			return
+		}
+	case model.IDSnowflake:
+		columns = append(columns, def.PrimaryKey())
+		values = append(values, s.conn.Snowflake().Next())
+	}
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+		quote(def.TableName()), strings.Join(dialect.Postgres.QuoteAll(columns), ", "), strings.Join(placeholders, ", "), def.SelectList())
+	record := s.writeSingle(w, r, def, rules, http.StatusCreated, query, values...)
+	s.invalidate(r, def)
+	s.publish(r, def, rules, EventCreated, record)
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	def, rules, ok := s.writableModel(w, r)
+	if !ok {
+		return
+	}
+
+	columns, values, err := decodeBody(r, def, rules, false)
+	if err != nil {
+		mvc.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", quote(column), i+1)
+	}
+	values = append(values, r.PathValue("id"))
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = $%d RETURNING %s",
+		quote(def.TableName()), strings.Join(assignments, ", "), quote(def.PrimaryKey()), len(values), def.SelectList())
+	record := s.writeSingle(w, r, def, rules, http.StatusOK, query, values...)
+	s.invalidate(r, def)
+	s.publish(r, def, rules, EventUpdated, record)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	def, rules, ok := s.writableModel(w, r)
+	if !ok {
+		return
+	}
+
+	// The deleted row is returned for the payload of webhooks.
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 RETURNING %s", quote(def.TableName()), quote(def.PrimaryKey()), def.SelectList())
+	rows, err := s.conn.GetDB().QueryContext(r.Context(), query, r.PathValue("id"))
+	if err != nil {
+		s.writeDBError(w, err)
+		return
+	}
+	records, err := scanRecords(rows, def)
+	rows.Close()
+	if err != nil {
+		s.writeDBError(w, err)
+		return
+	}
+	if len(records) == 0 {
+		mvc.WriteError(w, http.StatusNotFound, "record not found")
+		return
+	}
+	s.invalidate(r, def)
+	w.WriteHeader(http.StatusNoContent)
+	s.publish(r, def, rules, EventDeleted, records[0])
+}
+
+// publish stores the deliveries of the webhooks called for event on a row of def. The row is exposed by rules, as
+// in the response to the request that wrote it. A nil record, from a write that failed, publishes nothing.
+func (s *Server) publish(r *http.Request, def *model.ModelDefinition, rules *fieldRules, event string, record map[string]interface{}) {
+	if s.webhooks == nil || record == nil {
+		return
+	}
+	s.webhooks.publish(r.Context(), def, event, rules.record(record))
+}
+
+// invalidate discards the cached list responses of a model after one of its rows was written.
+func (s *Server) invalidate(r *http.Request, def *model.ModelDefinition) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Invalidate(r.Context(), def.TableName()); err != nil {
+		s.logger.WithError(err).Warn("Error invalidating query cache")
+	}
+}
+
+// writeSingle runs a query expected to return one row of def and writes it as JSON, as exposed by rules.
+// It returns the row, or nil if an error response was written instead.
+func (s *Server) writeSingle(w http.ResponseWriter, r *http.Request, def *model.ModelDefinition, rules *fieldRules, status int, query string, args ...interface{}) map[string]interface{} {
+	rows, err := s.conn.GetDB().QueryContext(r.Context(), query, args...)
+	if err != nil {
+		s.writeDBError(w, err)
+		return nil
+	}
+	defer rows.Close()
+
+	records, err := scanRecords(rows, def)
+	if err != nil {
+		s.writeDBError(w, err)
+		return nil
+	}
+	if len(records) == 0 {
+		mvc.WriteError(w, http.StatusNotFound, "record not found")
+		return nil
+	}
+	mvc.WriteJSON(w, status, rules.record(records[0]))
+	return records[0]
+}
+
+// writeDBError writes the response of a database error. Records that are missing, conflict with others, or
+// break a constraint are client errors naming the constraint or column; other errors are logged and answered
+// with a generic error, so driver details and the values of other records do not leak to clients.
+func (s *Server) writeDBError(w http.ResponseWriter, err error) {
+	err = orm.TranslateError(err)
+	var constraint *orm.ConstraintError
+	switch {
+	case errors.Is(err, orm.ErrNotFound):
+		mvc.WriteError(w, http.StatusNotFound, "record not found")
+	case errors.As(err, &constraint):
+		mvc.WriteError(w, constraintStatus(constraint), constraintMessage(constraint))
+	case errors.Is(err, orm.ErrSerialization):
+		mvc.WriteError(w, http.StatusConflict, "the record was changed by a concurrent request; retry the request")
+	default:
+		s.logger.WithError(err).Error("Database error while serving request")
+		mvc.WriteError(w, http.StatusInternalServerError, "database error")
+	}
+}
+
+// constraintStatus returns the status of the response to a request refused by a constraint: 409 Conflict if
+// the record conflicts with other records, and 422 Unprocessable Entity if it is invalid by itself.
+func constraintStatus(err *orm.ConstraintError) int {
+	switch err.Kind {
+	case orm.ErrUniqueViolation, orm.ErrForeignKeyViolation:
+		return http.StatusConflict
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// constraintMessage describes a constraint violation to clients, by the name of the constraint or column.
+func constraintMessage(err *orm.ConstraintError) string {
+	switch err.Kind {
+	case orm.ErrUniqueViolation:
+		return fmt.Sprintf("a record with the same values already exists (constraint %s)", err.Constraint)
+	case orm.ErrForeignKeyViolation:
+		return fmt.Sprintf("the record references a missing record or is still referenced (constraint %s)", err.Constraint)
+	case orm.ErrNotNullViolation:
+		return fmt.Sprintf("field %s is required", err.Column)
+	default:
+		return fmt.Sprintf("the record is invalid (constraint %s)", err.Constraint)
+	}
+}
+
+// decodeBody reads a JSON object from the request body and returns the columns and values of the
+// model fields it contains, sorted by column name. Unknown fields, fields rules does not expose, and the
+// primary key, unless withKey is set, are rejected; hidden and read-only fields are ignored. Vector fields are
+// given as arrays of numbers, decimal fields as strings or numbers, which are read without rounding, and time
+// fields as RFC 3339 strings, which are written in UTC.
+func decodeBody(r *http.Request, def *model.ModelDefinition, rules *fieldRules, withKey bool) ([]string, []interface{}, error) {
+	var body map[string]interface{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON body: %w", err)
+	}
+
+	known := make(map[string]bool, len(def.Fields)+1)
+	known[def.PrimaryKey()] = withKey
+	ignored := make(map[string]bool)
+	vectors := make(map[string]bool)
+	decimals := make(map[string]string)
+	times := make(map[string]bool)
+	for _, field := range def.Fields {
+		known[field.ColumnName()] = true
+		if !field.Writable() {
+			ignored[field.ColumnName()] = true
+		}
+		if _, ok := model.VectorDimensions(field.Type); ok {
+			vectors[field.ColumnName()] = true
+		}
+		if _, _, ok := model.DecimalPrecision(field.Type); ok {
+			decimals[field.ColumnName()] = field.Type
+		}
+		if field.Type == "time.Time" {
+			times[field.ColumnName()] = true
+		}
+	}
+
+	var columns []string
+	keys := make(map[string]string, len(body))
+	for key := range body {
+		column, exposed := rules.column(key)
+		if exposed && column == def.PrimaryKey() && !withKey {
+			return nil, nil, fmt.Errorf("field %s cannot be set", key)
+		}
+		if !exposed || !known[column] {
+			return nil, nil, fmt.Errorf("unknown field %s", key)
+		}
+		if ignored[column] {
+			continue
+		}
+		if _, ok := keys[column]; ok {
+			return nil, nil, fmt.Errorf("field %s is given more than once", key)
+		}
+		keys[column] = key
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("no fields given")
+	}
+	sort.Strings(columns)
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		key := keys[column]
+		value := body[key]
+		switch {
+		case value == nil:
+		case decimals[column] != "":
+			decimal, err := toDecimal(value, decimals[column])
+			if err != nil {
+				return nil, nil, fmt.Errorf("field %s: %w", key, err)
+			}
+			value = decimal
+		case times[column]:
+			t, err := toTime(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("field %s: %w", key, err)
+			}
+			value = t
+		default:
+			value = withFloats(value)
+			if vectors[column] {
+				vector, err := toVector(value)
+				if err != nil {
+					return nil, nil, fmt.Errorf("field %s: %w", key, err)
+				}
+				value = vector
+			}
+		}
+		values[i] = value
+	}
+	return columns, values, nil
+}
+
+// toVector converts a decoded JSON array of numbers to an orm.Vector.
+func toVector(value interface{}) (orm.Vector, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("must be an array of numbers")
+	}
+	vector := make(orm.Vector, len(items))
+	for i, item := range items {
+		x, ok := item.(float64)
+		if !ok {
+			return nil, errors.New("must be an array of numbers")
+		}
+		vector[i] = float32(x)
+	}
+	return vector, nil
+}
+
+// toDecimal converts a decoded JSON string or number to an orm.Decimal, which must fit the decimal(p,s) field type
+// fieldType without rounding.
+func toDecimal(value interface{}, fieldType string) (orm.Decimal, error) {
+	var text string
+	switch value := value.(type) {
+	case json.Number:
+		text = value.String()
+	case string:
+		text = value
+	default:
+		return orm.Decimal{}, errors.New("must be a decimal number")
+	}
+	decimal, err := orm.ParseDecimal(text)
+	if err != nil {
+		return orm.Decimal{}, errors.New("must be a decimal number")
+	}
+	if precision, scale, _ := model.DecimalPrecision(fieldType); !decimal.Fits(precision, scale) {
+		return orm.Decimal{}, fmt.Errorf("must have at most %d digits before the point and %d after it", precision-scale, scale)
+	}
+	return decimal, nil
+}
+
+// toTime converts a decoded JSON string in the RFC 3339 format to a time in UTC, so columns without a time zone
+// hold UTC whatever the offset the time was given with.
+func toTime(value interface{}) (time.Time, error) {
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, errors.New("must be a time such as 2024-09-01T12:30:00Z")
+	}
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, errors.New("must be a time such as 2024-09-01T12:30:00Z")
+	}
+	return t.UTC(), nil
+}
+
+// withFloats returns value, decoded from JSON with numbers kept as json.Number, with its numbers converted to
+// float64, as they are decoded by default.
+func withFloats(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		f, _ := value.Float64()
+		return f
+	case []interface{}:
+		for i, item := range value {
+			value[i] = withFloats(item)
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = withFloats(item)
+		}
+	}
+	return value
+}
+
+// scanRecords reads all rows of def into maps keyed by column name. Byte slices are converted to strings, and
+// times to UTC, so they are written to JSON the same way whatever the time zone of the session. The columns of
+// hidden fields are left out.
+func scanRecords(rows *sql.Rows, def *model.ModelDefinition) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool)
+	for _, field := range def.Fields {
+		if field.Hidden {
+			hidden[field.ColumnName()] = true
+		}
+	}
+
+	records := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if hidden[column] {
+				continue
+			}
+			switch value := values[i].(type) {
+			case []byte:
+				record[column] = string(value)
+			case time.Time:
+				record[column] = value.UTC()
+			default:
+				record[column] = value
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// queryInt parses a non-negative integer query parameter, returning def when it is absent.
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+	return value, nil
+}
+
+// quote quotes a table or column name, should it need quoting. The API always talks to PostgreSQL.
+func quote(name string) string {
+	return dialect.Postgres.Quote(name)
+}
@@ -0,0 +1,147 @@
+package serve
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBody_FieldAttributes(t *testing.T) {
+	def := model.NewModelDefinition("User", []model.Field{
+		{Name: "Email", Type: "string"},
+		{Name: "PasswordHash", Type: "string", Hidden: true},
+		{Name: "Slug", Type: "string", ReadOnly: true},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user", strings.NewReader(`{"email": "a@example.com", "password_hash": "x", "slug": "a"}`))
+	columns, values, err := decodeBody(req, def, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"email"}, columns)
+	assert.Equal(t, []interface{}{"a@example.com"}, values)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/user", strings.NewReader(`{"slug": "a"}`))
+	_, _, err = decodeBody(req, def, nil, false)
+	assert.ErrorContains(t, err, "no fields given")
+}
+
+func TestDecodeBody_WithKey(t *testing.T) {
+	def := model.NewModelDefinition("Tag", []model.Field{{Name: "Label", Type: "string"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tag", strings.NewReader(`{"id": 7, "label": "go"}`))
+	_, _, err := decodeBody(req, def, nil, false)
+	assert.ErrorContains(t, err, "field id cannot be set")
+
+	req = httptest.NewRequest(http.MethodPost, "/api/tag", strings.NewReader(`{"id": 7, "label": "go"}`))
+	columns, values, err := decodeBody(req, def, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "label"}, columns)
+	assert.Equal(t, []interface{}{float64(7), "go"}, values)
+}
+
+func TestDecodeBody_Decimal(t *testing.T) {
+	def := model.NewModelDefinition("Product", []model.Field{
+		{Name: "Price", Type: "decimal(30,20)"},
+		{Name: "Tax", Type: "decimal(4,2)", IsNull: true},
+		{Name: "Stock", Type: "int"},
+		{Name: "Embedding", Type: "vector(2)"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/product",
+		strings.NewReader(`{"price": 0.12345678901234567891, "tax": "1.50", "stock": 3, "embedding": [0.5, 1]}`))
+	columns, values, err := decodeBody(req, def, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"embedding", "price", "stock", "tax"}, columns)
+	assert.Equal(t, []interface{}{orm.Vector{0.5, 1}, orm.MustParseDecimal("0.12345678901234567891"), float64(3),
+		orm.MustParseDecimal("1.50")}, values, "decimals keep every digit, other numbers are float64")
+
+	req = httptest.NewRequest(http.MethodPost, "/api/product", strings.NewReader(`{"tax": null}`))
+	_, values, err = decodeBody(req, def, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{nil}, values)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/product", strings.NewReader(`{"price": "cheap"}`))
+	_, _, err = decodeBody(req, def, nil, false)
+	assert.ErrorContains(t, err, "field price: must be a decimal number")
+
+	for _, body := range []string{`{"price": "1e999999999"}`, `{"price": "1e-999999999"}`} {
+		req = httptest.NewRequest(http.MethodPost, "/api/product", strings.NewReader(body))
+		_, _, err = decodeBody(req, def, nil, false)
+		assert.ErrorContains(t, err, "field price: must be a decimal number", body)
+	}
+	for _, tax := range []string{`"100"`, `1.005`} {
+		req = httptest.NewRequest(http.MethodPost, "/api/product", strings.NewReader(`{"tax": `+tax+`}`))
+		_, _, err = decodeBody(req, def, nil, false)
+		assert.ErrorContains(t, err, "field tax: must have at most 2 digits before the point and 2 after it", tax)
+	}
+}
+
+func TestDecodeBody_Time(t *testing.T) {
+	def := model.NewModelDefinition("Event", []model.Field{
+		{Name: "StartsAt", Type: "time.Time", TZ: true},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/event", strings.NewReader(`{"starts_at": "2024-09-01T14:30:00+02:00"}`))
+	_, values, err := decodeBody(req, def, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{time.Date(2024, 9, 1, 12, 30, 0, 0, time.UTC)}, values)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/event", strings.NewReader(`{"starts_at": "tomorrow"}`))
+	_, _, err = decodeBody(req, def, nil, false)
+	assert.ErrorContains(t, err, "field starts_at: must be a time")
+}
+
+func TestHandleReady_DatabaseNotChecked(t *testing.T) {
+	s := &Server{health: orm.NewHealthMonitor(nil, nil)}
+	rec := httptest.NewRecorder()
+	s.handleReady(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"unavailable"`)
+}
+
+func TestWriteDBError(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := &Server{logger: logger}
+	for _, test := range []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{sql.ErrNoRows, http.StatusNotFound, "record not found"},
+		{&pq.Error{Code: "23505", Constraint: "users_email_key", Detail: "Key (email)=(a@example.com) already exists."},
+			http.StatusConflict, "constraint users_email_key"},
+		{&pq.Error{Code: "23502", Column: "title"}, http.StatusUnprocessableEntity, "field title is required"},
+		{&pq.Error{Code: "40001"}, http.StatusConflict, "retry the request"},
+		{&pq.Error{Code: "42P01", Message: `relation "users" does not exist`}, http.StatusInternalServerError, "database error"},
+	} {
+		rec := httptest.NewRecorder()
+		s.writeDBError(rec, test.err)
+		assert.Equal(t, test.status, rec.Code, test.err.Error())
+		assert.Contains(t, rec.Body.String(), test.body)
+		assert.NotContains(t, rec.Body.String(), "a@example.com")
+	}
+}
+
+func TestHandleDelete_View(t *testing.T) {
+	def := model.NewModelDefinition("AuthorStat", []model.Field{{Name: "Posts", Type: "int"}})
+	def.View = "SELECT author_id AS id, count(*) AS posts FROM posts GROUP BY author_id"
+	s := &Server{models: map[string]*model.ModelDefinition{"authorstat": def}}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/authorstat/1", nil)
+	req.SetPathValue("model", "authorstat")
+	rec := httptest.NewRecorder()
+	s.handleDelete(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodGet, rec.Header().Get("Allow"))
+	assert.Contains(t, rec.Body.String(), "read-only view")
+}
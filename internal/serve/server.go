@@ -0,0 +1,317 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/cache"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+	"github.com/ooyeku/grayv-lsm/pkg/ratelimit"
+	"github.com/ooyeku/grayv-lsm/pkg/session"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultRateLimit is the number of requests per second allowed when rate limiting is enabled without a rate.
+const defaultRateLimit = 10
+
+// Server exposes the models registered in the database as a JSON REST API.
+// For every model it serves:
+//   - GET    /api/{model}       list rows (supports ?limit= and ?offset=)
+//   - GET    /api/{model}/{id}  fetch a row by primary key
+//   - POST   /api/{model}       insert a row
+//   - PUT    /api/{model}/{id}  update a row
+//   - DELETE /api/{model}/{id}  delete a row
+//
+// CORS, CSRF, and rate limiting are applied according to the Server section of the configuration.
+// If StaticDir is set, the files in it are served for every other path, with single-page application fallback.
+// GET /metrics reports the number of allowed and throttled requests and the health of the database.
+// GET /healthz answers as long as the process runs; GET /readyz answers 503 while the database is unreachable,
+// which the server notices within seconds and recovers from without a restart.
+// If the Cache section of the configuration is enabled, list responses are cached in Redis
+// and invalidated whenever a row of the model is written through the API.
+// The webhooks of the Server section are called when rows are created, updated, or deleted through the API;
+// their payloads are kept in the webhook_deliveries table.
+//
+// Every version of the Server section is served under /api/{version} with the same endpoints, such as
+// GET /api/v1/{model}. A version may serve some of the models only, and hide or rename their columns, so
+// consumers of an older version keep working as the models evolve.
+type Server struct {
+	cfg    *config.Config
+	conn   *orm.Connection
+	logger *logrus.Logger
+	router *mvc.Router
+	models map[string]*model.ModelDefinition
+	// versions are the versions of the API, in the order of the configuration.
+	versions []*apiVersion
+	metrics  *ratelimit.Metrics
+	health   *orm.HealthMonitor
+	cache    *cache.QueryCache
+	// webhooks is nil if no webhooks are configured.
+	webhooks *webhookDispatcher
+	// sessions holds the sessions of the apps sharing the database, read to rate limit requests by user.
+	sessions session.Store
+	// shutdownTimeout is how long the server waits for in-flight requests when shutting down.
+	shutdownTimeout time.Duration
+}
+
+// NewServer creates a new Server, loading the model definitions from the models table and
+// installing the middleware enabled in the configuration.
+func NewServer(cfg *config.Config, conn *orm.Connection, logger *logrus.Logger) (*Server, error) {
+	// The handlers query model tables directly, so serving a multi-tenant database would mix the tenants' rows.
+	if conn.Tenancy() != orm.TenancyNone {
+		return nil, errors.New("serving a database with tenancy enabled is not supported; unset database.tenancy")
+	}
+	// The handlers and the models table they are loaded from use PostgreSQL SQL: $n parameters, RETURNING, and JSONB.
+	if d := conn.Dialect(); d.IsSQLite() || d.IsMySQL() {
+		return nil, fmt.Errorf("serving a %s database is not supported; serve needs PostgreSQL", cfg.Database.Driver)
+	}
+	shutdownTimeout, err := cfg.Server.ShutdownDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cfg:      cfg,
+		conn:     conn,
+		logger:   logger,
+		router:   mvc.NewRouter(),
+		metrics:  &ratelimit.Metrics{},
+		health:   orm.NewHealthMonitor(conn.GetDB(), logger),
+		sessions: session.NewPostgresStore(conn.GetDB()),
+
+		shutdownTimeout: shutdownTimeout,
+	}
+	s.router.Use(mvc.Tracing(), mvc.Logging(logger))
+
+	if err := s.loadModels(); err != nil {
+		return nil, err
+	}
+	if s.versions, err = newAPIVersions(cfg.Server.Versions, s.models); err != nil {
+		return nil, err
+	}
+	if len(cfg.Server.Webhooks) > 0 {
+		if err := ValidateWebhooks(cfg.Server.Webhooks, s.models); err != nil {
+			return nil, err
+		}
+		s.webhooks = newWebhookDispatcher(conn.GetDB(), cfg.Server.Webhooks, logger)
+		logger.Infof("Calling %d webhooks on changes to rows", len(cfg.Server.Webhooks))
+	}
+
+	if cfg.Server.CORS.Enabled {
+		if cfg.Server.CORS.AllowCredentials && slices.Contains(cfg.Server.CORS.AllowedOrigins, "*") {
+			return nil, errors.New("server.cors.allowcredentials cannot be combined with the origin *; list the allowed origins instead")
+		}
+		s.router.Use(mvc.CORS(mvc.CORSOptions{
+			AllowedOrigins:   cfg.Server.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.Server.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.Server.CORS.AllowedHeaders,
+			AllowCredentials: cfg.Server.CORS.AllowCredentials,
+			MaxAge:           cfg.Server.CORS.MaxAge,
+		}))
+	}
+	if cfg.Server.CSRF.Enabled {
+		s.router.Use(mvc.CSRF(mvc.CSRFOptions{
+			CookieName: cfg.Server.CSRF.CookieName,
+			HeaderName: cfg.Server.CSRF.HeaderName,
+			Secure:     cfg.Server.CSRF.Secure,
+		}))
+	}
+
+	if cfg.Cache.Enabled {
+		ttl, err := cache.ParseTTL(cfg.Cache.TTL)
+		if err != nil {
+			return nil, err
+		}
+		s.cache = cache.NewQueryCache(cache.NewClient(cfg.Cache.Addr(), cfg.Cache.Password, cfg.Cache.DB), ttl)
+		logger.Infof("Caching list queries in Redis at %s for %s", cfg.Cache.Addr(), ttl)
+	}
+
+	if cfg.Server.RateLimit.Enabled {
+		middleware, err := s.rateLimit(cfg.Server.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		s.router.Use(middleware)
+	}
+
+	s.routes()
+	return s, nil
+}
+
+// rateLimit builds the rate limiting middleware described by the configuration.
+func (s *Server) rateLimit(rl config.RateLimitConfig) (mvc.Middleware, error) {
+	rate, burst := rl.Rate, rl.Burst
+	if rate <= 0 {
+		rate = defaultRateLimit
+	}
+	if burst <= 0 {
+		burst = int(rate) * 2
+	}
+	if rl.Backend == "" {
+		rl.Backend = "memory"
+	}
+	if rl.KeyBy == "" {
+		rl.KeyBy = "ip"
+	}
+
+	var limiter ratelimit.Limiter
+	switch rl.Backend {
+	case "memory":
+		limiter = ratelimit.NewMemoryLimiter(rate, burst)
+	case "database":
+		limiter = ratelimit.NewPostgresLimiter(s.conn.GetDB(), rate, burst)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", rl.Backend)
+	}
+
+	var keyFunc ratelimit.KeyFunc
+	switch rl.KeyBy {
+	case "ip":
+		keyFunc = ratelimit.KeyByIP
+	case "user":
+		keyFunc = ratelimit.KeyBySessionValue("user_id")
+	default:
+		return nil, fmt.Errorf("unknown rate limit key: %s", rl.KeyBy)
+	}
+
+	s.logger.Infof("Rate limiting requests by %s to %g/s (burst %d) using the %s backend", rl.KeyBy, rate, burst, rl.Backend)
+	limit := ratelimit.Middleware(limiter, keyFunc, s.metrics)
+	if rl.KeyBy != "user" {
+		return limit, nil
+	}
+	// The user of a request is the user_id value of the session its grayv_session cookie refers to, stored by an
+	// app logging users in with session.Manager. The server only reads sessions, so it never sets the cookie.
+	sessions := session.NewManager(s.sessions).Middleware()
+	return func(next http.Handler) http.Handler {
+		return sessions(limit(next))
+	}, nil
+}
+
+// Router returns the router used by the server, so callers can add middleware or routes.
+func (s *Server) Router() *mvc.Router {
+	return s.router
+}
+
+// Models returns the names of the models served by the API.
+func (s *Server) Models() []string {
+	var names []string
+	for _, def := range s.models {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// ListenAndServe serves the API on the configured host and port until the context is cancelled,
+// then shuts the server down gracefully.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	addr := net.JoinHostPort(s.cfg.Server.Host, strconv.Itoa(s.cfg.Server.Port))
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Infof("Serving API on http://%s", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+	go s.health.Run(healthCtx)
+
+	if s.webhooks != nil {
+		// The webhook dispatcher stops along with the server, after the delivery in progress.
+		webhooksCtx, stopWebhooks := context.WithCancel(ctx)
+		webhooksDone := make(chan struct{})
+		go func() {
+			defer close(webhooksDone)
+			s.webhooks.run(webhooksCtx)
+		}()
+		defer func() {
+			stopWebhooks()
+			<-webhooksDone
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Shutting down API server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// loadModels reads the model definitions from the models table.
+func (s *Server) loadModels() error {
+	rows, err := s.conn.Query("SELECT name, fields, id_strategy, view_sql, view_kind FROM models")
+	if err != nil {
+		return fmt.Errorf("failed to query models: %w", err)
+	}
+	defer rows.Close()
+
+	s.models = make(map[string]*model.ModelDefinition)
+	for rows.Next() {
+		var name, idStrategy, view, viewKind string
+		var fieldsJSON []byte
+		if err := rows.Scan(&name, &fieldsJSON, &idStrategy, &view, &viewKind); err != nil {
+			return fmt.Errorf("failed to scan model: %w", err)
+		}
+
+		var fields []model.Field
+		if err := json.Unmarshal(fieldsJSON, &fields); err != nil {
+			return fmt.Errorf("failed to unmarshal fields of model %s: %w", name, err)
+		}
+		def := model.NewModelDefinition(name, fields)
+		def.IDStrategy = model.IDStrategy(idStrategy)
+		def.View, def.ViewKind = view, model.ViewKind(viewKind)
+		s.models[strings.ToLower(name)] = def
+	}
+
+	return rows.Err()
+}
+
+// model returns the model definition named in the request path, and the rules of the version of the API
+// the request was routed to. It reports false if the model does not exist or the version does not serve it.
+func (s *Server) model(r *http.Request) (*model.ModelDefinition, *fieldRules, bool) {
+	def, ok := s.models[strings.ToLower(r.PathValue("model"))]
+	if !ok {
+		return nil, nil, false
+	}
+	rules, ok := requestVersion(r).rules(def.Name)
+	return def, rules, ok
+}
+
+// writableModel is model for the requests writing records. It writes the error response and reports false if the
+// model does not exist or is a view model, whose view cannot be written.
+func (s *Server) writableModel(w http.ResponseWriter, r *http.Request) (*model.ModelDefinition, *fieldRules, bool) {
+	def, rules, ok := s.model(r)
+	switch {
+	case !ok:
+		mvc.WriteError(w, http.StatusNotFound, "model not found")
+	case def.IsView():
+		w.Header().Set("Allow", http.MethodGet)
+		mvc.WriteError(w, http.StatusMethodNotAllowed, fmt.Sprintf("model %s is a read-only view", def.Name))
+		return nil, nil, false
+	}
+	return def, rules, ok
+}
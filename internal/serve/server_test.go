@@ -0,0 +1,48 @@
+package serve
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/ratelimit"
+	"github.com/ooyeku/grayv-lsm/pkg/session"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_ByUser(t *testing.T) {
+	store := session.NewMemoryStore()
+	expires := time.Now().Add(time.Hour)
+	for id, userID := range map[string]int{"alice": 1, "bob": 2} {
+		err := store.Save(context.Background(), &session.Session{ID: id, Values: map[string]interface{}{"user_id": userID}, ExpiresAt: expires})
+		assert.NoError(t, err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := &Server{logger: logger, metrics: &ratelimit.Metrics{}, sessions: store}
+
+	middleware, err := s.rateLimit(config.RateLimitConfig{Rate: 1, Burst: 1, KeyBy: "user"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	get := func(sessionID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/user", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		req.AddCookie(&http.Cookie{Name: session.DefaultCookieName, Value: sessionID})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Both users are behind the same address, but each has a bucket of their own.
+	assert.Equal(t, http.StatusOK, get("alice"))
+	assert.Equal(t, http.StatusOK, get("bob"))
+	assert.Equal(t, http.StatusTooManyRequests, get("alice"))
+	assert.Equal(t, http.StatusTooManyRequests, get("bob"))
+}
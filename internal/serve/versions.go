@@ -0,0 +1,204 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/internal/naming"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// versionNamePattern matches the names of API versions, such as v1.
+var versionNamePattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// apiVersion is a version of the API, served under /api/{name}. Models holds the rules of the models it
+// serves, by lowercase model name; if it is nil, the version serves every model with all of its fields.
+type apiVersion struct {
+	name   string
+	models map[string]*fieldRules
+}
+
+// fieldRules hides and renames the columns of a model in a version of the API. A nil *fieldRules exposes
+// every column under its own name, as the unversioned routes do.
+type fieldRules struct {
+	hidden  map[string]bool
+	toAPI   map[string]string
+	fromAPI map[string]string
+}
+
+// versionKey is the context key of the version of the API a request was routed to.
+type versionKey struct{}
+
+// withVersion routes the requests of handler to version v.
+func withVersion(v *apiVersion, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v)))
+	}
+}
+
+// requestVersion returns the version of the API a request was routed to, or nil for the unversioned routes.
+func requestVersion(r *http.Request) *apiVersion {
+	v, _ := r.Context().Value(versionKey{}).(*apiVersion)
+	return v
+}
+
+// rules returns the rules of the model named name in v, and whether v serves the model.
+func (v *apiVersion) rules(name string) (*fieldRules, bool) {
+	if v == nil || v.models == nil {
+		return nil, true
+	}
+	rules, ok := v.models[strings.ToLower(name)]
+	return rules, ok
+}
+
+// column returns the column a key of a request body sets, and false if the version does not expose it.
+// Renamed columns are only set by their new name.
+func (f *fieldRules) column(key string) (string, bool) {
+	if f == nil {
+		return naming.Column(key), true
+	}
+	if column, ok := f.fromAPI[key]; ok {
+		return column, true
+	}
+	column := naming.Column(key)
+	if f.hidden[column] || f.toAPI[column] != "" {
+		return "", false
+	}
+	return column, true
+}
+
+// name returns the name a column is exposed as, and false if it is hidden.
+func (f *fieldRules) name(column string) (string, bool) {
+	if f == nil {
+		return column, true
+	}
+	if f.hidden[column] {
+		return "", false
+	}
+	if name, ok := f.toAPI[column]; ok {
+		return name, true
+	}
+	return column, true
+}
+
+// record returns a copy of a row with the columns hidden or renamed. A nil *fieldRules returns the row itself.
+func (f *fieldRules) record(record map[string]interface{}) map[string]interface{} {
+	if f == nil || record == nil {
+		return record
+	}
+	exposed := make(map[string]interface{}, len(record))
+	for column, value := range record {
+		if name, ok := f.name(column); ok {
+			exposed[name] = value
+		}
+	}
+	return exposed
+}
+
+// records applies record to every row.
+func (f *fieldRules) records(records []map[string]interface{}) []map[string]interface{} {
+	if f == nil {
+		return records
+	}
+	exposed := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		exposed[i] = f.record(record)
+	}
+	return exposed
+}
+
+// newAPIVersions checks the versions of the configuration against the served models and returns their rules.
+func newAPIVersions(versions []config.APIVersionConfig, models map[string]*model.ModelDefinition) ([]*apiVersion, error) {
+	var list []*apiVersion
+	seen := make(map[string]bool)
+	for _, vc := range versions {
+		if !versionNamePattern.MatchString(vc.Name) {
+			return nil, fmt.Errorf("invalid API version name %q: use v followed by a number, such as v1", vc.Name)
+		}
+		if seen[vc.Name] {
+			return nil, fmt.Errorf("API version %s is defined more than once", vc.Name)
+		}
+		seen[vc.Name] = true
+		if _, ok := models[vc.Name]; ok {
+			return nil, fmt.Errorf("API version %s has the name of a model", vc.Name)
+		}
+
+		v := &apiVersion{name: vc.Name}
+		if len(vc.Models) > 0 {
+			v.models = make(map[string]*fieldRules, len(vc.Models))
+		}
+		for name, mc := range vc.Models {
+			def, ok := models[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("API version %s: unknown model %q", vc.Name, name)
+			}
+			rules, err := newFieldRules(def, mc)
+			if err != nil {
+				return nil, fmt.Errorf("API version %s: model %s: %w", vc.Name, def.Name, err)
+			}
+			v.models[strings.ToLower(def.Name)] = rules
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// exposedColumns returns the columns of def followed by its computed fields, the keys of the records the API returns.
+func exposedColumns(def *model.ModelDefinition) []string {
+	columns := def.Columns()
+	for _, field := range def.Fields {
+		if !field.HasColumn() {
+			columns = append(columns, field.ColumnName())
+		}
+	}
+	return columns
+}
+
+// newFieldRules checks the hidden and renamed columns of mc against the columns of def.
+func newFieldRules(def *model.ModelDefinition, mc config.APIModelConfig) (*fieldRules, error) {
+	columns := make(map[string]bool)
+	for _, column := range exposedColumns(def) {
+		columns[column] = true
+	}
+
+	rules := &fieldRules{hidden: make(map[string]bool), toAPI: make(map[string]string), fromAPI: make(map[string]string)}
+	for _, column := range mc.Hidden {
+		if !columns[column] {
+			return nil, fmt.Errorf("cannot hide unknown column %q", column)
+		}
+		if column == def.PrimaryKey() {
+			return nil, fmt.Errorf("cannot hide the primary key %s", column)
+		}
+		rules.hidden[column] = true
+	}
+	for column, name := range mc.Renamed {
+		if !columns[column] {
+			return nil, fmt.Errorf("cannot rename unknown column %q", column)
+		}
+		if rules.hidden[column] {
+			return nil, fmt.Errorf("column %s is both hidden and renamed", column)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("column %s is renamed to an empty name", column)
+		}
+		rules.toAPI[column] = name
+		rules.fromAPI[name] = column
+	}
+
+	exposed := make(map[string]string)
+	for _, column := range exposedColumns(def) {
+		name, ok := rules.name(column)
+		if !ok {
+			continue
+		}
+		if other, ok := exposed[name]; ok {
+			return nil, fmt.Errorf("columns %s and %s are both exposed as %s", other, column, name)
+		}
+		exposed[name] = column
+	}
+	return rules, nil
+}
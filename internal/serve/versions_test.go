@@ -0,0 +1,127 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+	"github.com/stretchr/testify/assert"
+)
+
+func versionTestModels() map[string]*model.ModelDefinition {
+	return map[string]*model.ModelDefinition{
+		"post": model.NewModelDefinition("Post", []model.Field{
+			{Name: "Title", Type: "string"},
+			{Name: "Body", Type: "string"},
+			{Name: "Secret", Type: "string"},
+		}),
+		"comment": model.NewModelDefinition("Comment", []model.Field{{Name: "Text", Type: "string"}}),
+	}
+}
+
+func TestNewAPIVersions(t *testing.T) {
+	models := versionTestModels()
+
+	versions, err := newAPIVersions([]config.APIVersionConfig{
+		{Name: "v1", Models: map[string]config.APIModelConfig{
+			"Post": {Hidden: []string{"secret"}, Renamed: map[string]string{"title": "headline"}},
+		}},
+		{Name: "v2"},
+	}, models)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, versions, 2)
+	_, ok := versions[0].rules("Comment")
+	assert.False(t, ok)
+	rules, ok := versions[1].rules("Comment")
+	assert.True(t, ok)
+	assert.Nil(t, rules)
+
+	for _, tc := range []struct {
+		version config.APIVersionConfig
+		err     string
+	}{
+		{config.APIVersionConfig{Name: "latest"}, `invalid API version name "latest"`},
+		{config.APIVersionConfig{Name: "v1", Models: map[string]config.APIModelConfig{"Tag": {}}}, `unknown model "Tag"`},
+		{config.APIVersionConfig{Name: "v1", Models: map[string]config.APIModelConfig{"Post": {Hidden: []string{"id"}}}}, "cannot hide the primary key"},
+		{config.APIVersionConfig{Name: "v1", Models: map[string]config.APIModelConfig{"Post": {Hidden: []string{"nope"}}}}, `cannot hide unknown column "nope"`},
+		{config.APIVersionConfig{Name: "v1", Models: map[string]config.APIModelConfig{
+			"Post": {Renamed: map[string]string{"title": "body"}}}}, "are both exposed as body"},
+	} {
+		_, err := newAPIVersions([]config.APIVersionConfig{tc.version}, models)
+		assert.ErrorContains(t, err, tc.err)
+	}
+}
+
+func TestFieldRules(t *testing.T) {
+	rules, err := newFieldRules(versionTestModels()["post"], config.APIModelConfig{
+		Hidden:  []string{"secret"},
+		Renamed: map[string]string{"title": "headline"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string]interface{}{"id": 1, "headline": "Hello", "body": "..."},
+		rules.record(map[string]interface{}{"id": 1, "title": "Hello", "body": "...", "secret": "x"}))
+
+	column, ok := rules.column("headline")
+	assert.True(t, ok)
+	assert.Equal(t, "title", column)
+	_, ok = rules.column("title")
+	assert.False(t, ok)
+	_, ok = rules.column("secret")
+	assert.False(t, ok)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/post", strings.NewReader(`{"headline": "Hi", "body": "Text"}`))
+	columns, values, err := decodeBody(req, versionTestModels()["post"], rules, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"body", "title"}, columns)
+	assert.Equal(t, []interface{}{"Text", "Hi"}, values)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/post", strings.NewReader(`{"secret": "x"}`))
+	_, _, err = decodeBody(req, versionTestModels()["post"], rules, false)
+	assert.ErrorContains(t, err, "unknown field secret")
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	s := &Server{cfg: &config.Config{}, router: mvc.NewRouter(), models: versionTestModels()}
+	var err error
+	s.versions, err = newAPIVersions([]config.APIVersionConfig{{Name: "v1", Models: map[string]config.APIModelConfig{
+		"Post": {Hidden: []string{"secret"}, Renamed: map[string]string{"title": "headline"}},
+	}}}, s.models)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.routes()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/models", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var list []struct {
+		Name   string
+		Fields []struct{ Name string }
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "Post", list[0].Name)
+		assert.Len(t, list[0].Fields, 2)
+		assert.Equal(t, "headline", list[0].Fields[0].Name)
+	}
+
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/comment/1", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "model not found")
+
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/models", nil))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Len(t, list, 2)
+}
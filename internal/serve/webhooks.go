@@ -0,0 +1,372 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/pkg/clock"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/jobs"
+	"github.com/sirupsen/logrus"
+)
+
+// Webhook events, named after the change made to a row.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// Delivery statuses stored in the webhook_deliveries table.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Headers of the requests sending webhook payloads. SignatureHeader holds "sha256=" followed by the hex-encoded
+// HMAC-SHA256 of the request body, keyed with the secret of the webhook.
+const (
+	EventHeader     = "X-Grayv-Event"
+	DeliveryHeader  = "X-Grayv-Delivery"
+	SignatureHeader = "X-Grayv-Signature"
+)
+
+// DefaultWebhookAttempts is the number of times a payload is sent before its delivery fails, unless the webhook
+// sets MaxAttempts.
+const DefaultWebhookAttempts = 5
+
+// deliveryLease is how long a claimed delivery is hidden from other servers while it is being sent.
+// It covers servers that stopped in the middle of a delivery.
+const deliveryLease = 5 * time.Minute
+
+// ErrDeliveryNotFound is returned when a delivery with the requested ID does not exist.
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// WebhookPayload is the JSON body posted to webhooks. Data is the row as returned by the API; for deleted
+// events it is the row as it was before the deletion.
+type WebhookPayload struct {
+	Event      string                 `json:"event"`
+	Model      string                 `json:"model"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Delivery is a webhook payload stored in the webhook_deliveries table, with the outcome of its attempts.
+type Delivery struct {
+	ID             int64
+	Model          string
+	Event          string
+	URL            string
+	Payload        json.RawMessage
+	Signature      string
+	Status         string
+	Attempts       int
+	MaxAttempts    int
+	ResponseStatus int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+}
+
+// Sign returns the value of SignatureHeader for body, keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature, the value of SignatureHeader, is the signature of body with
+// secret. Receivers of webhooks use it to check payloads came from the server.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// ValidateWebhooks checks the webhooks of the configuration against the served models.
+func ValidateWebhooks(hooks []config.WebhookConfig, models map[string]*model.ModelDefinition) error {
+	for i, hook := range hooks {
+		if hook.Model != "*" {
+			if _, ok := models[strings.ToLower(hook.Model)]; !ok {
+				return fmt.Errorf("webhook %d: unknown model %q", i+1, hook.Model)
+			}
+		}
+		for _, event := range hook.Events {
+			if event != EventCreated && event != EventUpdated && event != EventDeleted {
+				return fmt.Errorf("webhook %d: unknown event %q: use %s, %s, or %s", i+1, event, EventCreated, EventUpdated, EventDeleted)
+			}
+		}
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %d: invalid URL %q: must be an http or https URL", i+1, hook.URL)
+		}
+		if hook.MaxAttempts < 0 {
+			return fmt.Errorf("webhook %d: max attempts must not be negative", i+1)
+		}
+	}
+	return nil
+}
+
+// webhookMatches reports whether hook is called for event on the rows of the model named name.
+func webhookMatches(hook config.WebhookConfig, name, event string) bool {
+	if hook.Model != "*" && !strings.EqualFold(hook.Model, name) {
+		return false
+	}
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookDispatcher stores the payloads of the configured webhooks in the webhook_deliveries table and sends
+// them. Failed attempts are retried with the backoff of background jobs, until a delivery has used up its
+// attempts. Since deliveries are claimed from the table, several servers may share it.
+type webhookDispatcher struct {
+	db           *sql.DB
+	hooks        []config.WebhookConfig
+	client       *http.Client
+	clock        clock.Clock
+	logger       *logrus.Logger
+	pollInterval time.Duration
+	// wake is signalled when a delivery is enqueued, so it is sent without waiting for the next poll.
+	wake chan struct{}
+}
+
+func newWebhookDispatcher(db *sql.DB, hooks []config.WebhookConfig, logger *logrus.Logger) *webhookDispatcher {
+	return &webhookDispatcher{
+		db:           db,
+		hooks:        hooks,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		clock:        clock.Real,
+		logger:       logger,
+		pollInterval: time.Second,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// publish stores a delivery of the change to record for every webhook matching event on def.
+// Errors are logged rather than returned, since the change itself has already been made.
+func (d *webhookDispatcher) publish(ctx context.Context, def *model.ModelDefinition, event string, record map[string]interface{}) {
+	payload := WebhookPayload{Event: event, Model: def.Name, OccurredAt: d.clock.Now().UTC(), Data: record}
+	var body []byte
+	for _, hook := range d.hooks {
+		if !webhookMatches(hook, def.Name, event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(payload); err != nil {
+				d.logger.WithError(err).Errorf("Error encoding %s webhook payload of %s", event, def.Name)
+				return
+			}
+		}
+		maxAttempts := hook.MaxAttempts
+		if maxAttempts == 0 {
+			maxAttempts = DefaultWebhookAttempts
+		}
+		_, err := d.db.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (model, event, url, payload, signature, max_attempts, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			def.Name, event, hook.URL, body, Sign(hook.Secret, body), maxAttempts, d.clock.Now())
+		if err != nil {
+			d.logger.WithError(err).Errorf("Error storing %s webhook delivery of %s to %s", event, def.Name, hook.URL)
+			continue
+		}
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run sends due deliveries until ctx is cancelled, polling the table every pollInterval.
+func (d *webhookDispatcher) run(ctx context.Context) {
+	for {
+		for {
+			sent, err := d.sendNext(context.WithoutCancel(ctx))
+			if err != nil {
+				d.logger.WithError(err).Error("Error sending webhook")
+			}
+			if !sent || err != nil || ctx.Err() != nil {
+				break
+			}
+		}
+
+		timer := d.clock.NewTimer(d.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+			timer.Stop()
+		case <-timer.C():
+		}
+	}
+}
+
+// sendNext claims the next due delivery, sends it, and records the outcome. It reports whether a delivery
+// was found. A failing webhook is not an error of sendNext; the failure is recorded on the delivery instead.
+func (d *webhookDispatcher) sendNext(ctx context.Context) (bool, error) {
+	now := d.clock.Now()
+	row := d.db.QueryRowContext(ctx,
+		`UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = $1, updated_at = $2
+		WHERE id = (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $3 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING `+deliveryColumns,
+		now.Add(deliveryLease), now, DeliveryPending)
+	delivery, err := scanDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+
+	status, sendErr := d.send(ctx, delivery)
+	if sendErr == nil {
+		_, err = d.db.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = $1, response_status = $2, last_error = NULL, delivered_at = $3, updated_at = $3
+			WHERE id = $4`,
+			DeliveryDelivered, status, d.clock.Now(), delivery.ID)
+		if err != nil {
+			return true, fmt.Errorf("failed to record webhook delivery %d: %w", delivery.ID, err)
+		}
+		d.logger.Infof("Webhook delivery %d (%s %s) sent to %s", delivery.ID, delivery.Model, delivery.Event, delivery.URL)
+		return true, nil
+	}
+
+	next, deliveryStatus := d.clock.Now().Add(jobs.Backoff(delivery.Attempts)), DeliveryPending
+	if delivery.Attempts >= delivery.MaxAttempts {
+		deliveryStatus = DeliveryFailed
+	}
+	var responseStatus interface{}
+	if status != 0 {
+		responseStatus = status
+	}
+	_, err = d.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = $1, response_status = $2, last_error = $3, next_attempt_at = $4, updated_at = $5
+		WHERE id = $6`,
+		deliveryStatus, responseStatus, sendErr.Error(), next, d.clock.Now(), delivery.ID)
+	if err != nil {
+		return true, fmt.Errorf("failed to record failure of webhook delivery %d: %w", delivery.ID, err)
+	}
+	if deliveryStatus == DeliveryFailed {
+		d.logger.WithError(sendErr).Errorf("Webhook delivery %d to %s failed after %d attempts", delivery.ID, delivery.URL, delivery.Attempts)
+	} else {
+		d.logger.WithError(sendErr).Warnf("Webhook delivery %d to %s failed on attempt %d, retrying", delivery.ID, delivery.URL, delivery.Attempts)
+	}
+	return true, nil
+}
+
+// send posts the payload of delivery to its URL and returns the status of the response, or 0 if there was none.
+// Any non-2xx response is an error, so the delivery is retried.
+func (d *webhookDispatcher) send(ctx context.Context, delivery *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, fmt.Sprint(delivery.ID))
+	req.Header.Set(SignatureHeader, delivery.Signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// ListDeliveries returns up to limit webhook deliveries, newest first. An empty status returns deliveries in
+// every state.
+func ListDeliveries(db *sql.DB, status string, limit int) ([]*Delivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE ($1 = '' OR status = $1) ORDER BY id DESC LIMIT $2`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RetryDelivery moves a delivery back to the pending state with a fresh attempt count, so a running server
+// sends it again. Delivered payloads may be sent again too, such as to a receiver that lost them.
+func RetryDelivery(db *sql.DB, id int64) error {
+	result, err := db.Exec(
+		`UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = NOW(), delivered_at = NULL, updated_at = NOW()
+		WHERE id = $2`,
+		DeliveryPending, id)
+	if err != nil {
+		return fmt.Errorf("failed to retry webhook delivery %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retry webhook delivery %d: %w", id, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("delivery %d: %w", id, ErrDeliveryNotFound)
+	}
+	return nil
+}
+
+// deliveryColumns are the columns read by scanDelivery.
+const deliveryColumns = `id, model, event, url, payload, signature, status, attempts, max_attempts, COALESCE(response_status, 0),
+	COALESCE(last_error, ''), next_attempt_at, created_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	delivery := &Delivery{}
+	var payload []byte
+	err := row.Scan(&delivery.ID, &delivery.Model, &delivery.Event, &delivery.URL, &payload, &delivery.Signature, &delivery.Status,
+		&delivery.Attempts, &delivery.MaxAttempts, &delivery.ResponseStatus, &delivery.LastError,
+		&delivery.NextAttemptAt, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = json.RawMessage(payload)
+	return delivery, nil
+}
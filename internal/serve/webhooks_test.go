@@ -0,0 +1,111 @@
+package serve
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/model"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"created"}`)
+	signature := Sign("s3cret", body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, VerifySignature("s3cret", body, signature))
+	assert.False(t, VerifySignature("other", body, signature))
+	assert.False(t, VerifySignature("s3cret", []byte(`{"event":"deleted"}`), signature))
+}
+
+func TestValidateWebhooks(t *testing.T) {
+	models := map[string]*model.ModelDefinition{"post": model.NewModelDefinition("Post", nil)}
+
+	assert.NoError(t, ValidateWebhooks([]config.WebhookConfig{
+		{Model: "Post", Events: []string{EventCreated, EventDeleted}, URL: "https://example.com/hooks"},
+		{Model: "*", URL: "http://localhost:9000"},
+	}, models))
+	assert.ErrorContains(t, ValidateWebhooks([]config.WebhookConfig{{Model: "Comment", URL: "https://example.com"}}, models),
+		`unknown model "Comment"`)
+	assert.ErrorContains(t, ValidateWebhooks([]config.WebhookConfig{{Model: "Post", Events: []string{"saved"}, URL: "https://example.com"}}, models),
+		`unknown event "saved"`)
+	assert.ErrorContains(t, ValidateWebhooks([]config.WebhookConfig{{Model: "Post", URL: "example.com"}}, models),
+		"invalid URL")
+}
+
+func TestWebhookMatches(t *testing.T) {
+	hook := config.WebhookConfig{Model: "Post", Events: []string{EventCreated}}
+	assert.True(t, webhookMatches(hook, "Post", EventCreated))
+	assert.False(t, webhookMatches(hook, "Post", EventDeleted))
+	assert.False(t, webhookMatches(hook, "Comment", EventCreated))
+	assert.True(t, webhookMatches(config.WebhookConfig{Model: "*"}, "Comment", EventUpdated))
+}
+
+func TestWebhookDispatcher_Send(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	body, _ := json.Marshal(WebhookPayload{Event: EventCreated, Model: "Post", Data: map[string]interface{}{"id": 1}})
+	delivery := &Delivery{ID: 7, Event: EventCreated, URL: server.URL, Payload: body, Signature: Sign("s3cret", body)}
+	d := newWebhookDispatcher(nil, nil, logrus.New())
+
+	code, err := d.send(context.Background(), delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, body, gotBody)
+	assert.Equal(t, EventCreated, got.Header.Get(EventHeader))
+	assert.Equal(t, "7", got.Header.Get(DeliveryHeader))
+	assert.True(t, VerifySignature("s3cret", gotBody, got.Header.Get(SignatureHeader)))
+
+	status = http.StatusServiceUnavailable
+	code, err = d.send(context.Background(), delivery)
+	assert.ErrorContains(t, err, "status 503")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestPublish_VersionRules(t *testing.T) {
+	// The deliveries are stored in SQLite, which accepts the $n parameters of the insert.
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "webhooks.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE webhook_deliveries (model TEXT, event TEXT, url TEXT, payload BLOB, signature TEXT,
+		max_attempts INTEGER, next_attempt_at TIMESTAMP)`)
+	assert.NoError(t, err)
+
+	models := versionTestModels()
+	versions, err := newAPIVersions([]config.APIVersionConfig{{Name: "v1", Models: map[string]config.APIModelConfig{
+		"Post": {Hidden: []string{"secret"}, Renamed: map[string]string{"title": "headline"}},
+	}}}, models)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rules, _ := versions[0].rules("Post")
+	s := &Server{webhooks: newWebhookDispatcher(db, []config.WebhookConfig{{Model: "*", URL: "https://example.com/hook"}}, logrus.New())}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/post", nil)
+	s.publish(req, models["post"], rules, EventCreated, map[string]interface{}{"id": 1, "title": "Hello", "body": "Hi", "secret": "x"})
+
+	var body []byte
+	assert.NoError(t, db.QueryRow("SELECT payload FROM webhook_deliveries").Scan(&body))
+	var payload WebhookPayload
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "headline": "Hello", "body": "Hi"}, payload.Data)
+}
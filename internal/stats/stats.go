@@ -0,0 +1,131 @@
+// Package stats records how long grayv-lsm commands take in a local file, so users can spot the slow parts
+// of their workflow. Nothing is ever sent over the network.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultFile is where statistics are kept when no file is configured.
+const DefaultFile = ".grayv/stats.json"
+
+// MaxRuns is the number of most recent runs kept in the file.
+const MaxRuns = 1000
+
+// Run is a single recorded command.
+type Run struct {
+	Command  string        `json:"command"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// File is the content of the statistics file.
+type File struct {
+	Runs []Run `json:"runs"`
+}
+
+// Load reads the statistics file at path. A missing file holds no runs.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading stats file: %w", err)
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing stats file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// Record appends run to the statistics file at path, keeping only the MaxRuns most recent runs.
+// The file is replaced atomically, so an interrupted write never corrupts it.
+func Record(path string, run Run) error {
+	file, err := Load(path)
+	if err != nil {
+		return err
+	}
+	file.Runs = append(file.Runs, run)
+	if len(file.Runs) > MaxRuns {
+		file.Runs = file.Runs[len(file.Runs)-MaxRuns:]
+	}
+	return Save(path, file)
+}
+
+// Save writes file to path, creating its directory if needed.
+func Save(path string, file *File) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling stats: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating stats directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".stats-*.json")
+	if err != nil {
+		return fmt.Errorf("error writing stats file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing stats file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing stats file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Summary aggregates the runs of one command.
+type Summary struct {
+	Command string        `json:"command"`
+	Runs    int           `json:"runs"`
+	Total   time.Duration `json:"total_ns"`
+	Average time.Duration `json:"average_ns"`
+	Max     time.Duration `json:"max_ns"`
+	Last    time.Duration `json:"last_ns"`
+	LastRun time.Time     `json:"last_run"`
+}
+
+// Summarize aggregates runs by command. The commands taking the most time in total come first.
+func Summarize(runs []Run) []Summary {
+	byCommand := make(map[string]*Summary)
+	for _, run := range runs {
+		s, ok := byCommand[run.Command]
+		if !ok {
+			s = &Summary{Command: run.Command}
+			byCommand[run.Command] = s
+		}
+		s.Runs++
+		s.Total += run.Duration
+		if run.Duration > s.Max {
+			s.Max = run.Duration
+		}
+		if !run.Start.Before(s.LastRun) {
+			s.LastRun = run.Start
+			s.Last = run.Duration
+		}
+	}
+
+	summaries := make([]Summary, 0, len(byCommand))
+	for _, s := range byCommand {
+		s.Average = s.Total / time.Duration(s.Runs)
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Total != summaries[j].Total {
+			return summaries[i].Total > summaries[j].Total
+		}
+		return summaries[i].Command < summaries[j].Command
+	})
+	return summaries
+}
@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".grayv", "stats.json")
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for i := 0; i < MaxRuns+2; i++ {
+		assert.NoError(t, Record(path, Run{Command: "db migrate", Start: start.Add(time.Duration(i) * time.Minute), Duration: time.Second}))
+	}
+
+	file, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, file.Runs, MaxRuns)
+	assert.Equal(t, start.Add(2*time.Minute), file.Runs[0].Start, "the oldest runs are dropped")
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	file, err := Load(filepath.Join(t.TempDir(), "stats.json"))
+	assert.NoError(t, err)
+	assert.Empty(t, file.Runs)
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	summaries := Summarize([]Run{
+		{Command: "model list", Start: start, Duration: 100 * time.Millisecond},
+		{Command: "db build", Start: start, Duration: 40 * time.Second},
+		{Command: "db build", Start: start.Add(time.Hour), Duration: 20 * time.Second},
+	})
+
+	assert.Equal(t, []Summary{
+		{Command: "db build", Runs: 2, Total: time.Minute, Average: 30 * time.Second, Max: 40 * time.Second, Last: 20 * time.Second, LastRun: start.Add(time.Hour)},
+		{Command: "model list", Runs: 1, Total: 100 * time.Millisecond, Average: 100 * time.Millisecond, Max: 100 * time.Millisecond, Last: 100 * time.Millisecond, LastRun: start},
+	}, summaries)
+}
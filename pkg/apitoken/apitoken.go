@@ -0,0 +1,181 @@
+package apitoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TokenPrefix starts every generated token, so leaked tokens are easy to recognize in logs and secret scanners.
+const TokenPrefix = "grv_"
+
+// ScopeAll grants every scope.
+const ScopeAll = "*"
+
+// ErrInvalidToken is returned when a token is unknown, revoked, or expired.
+// The cases are deliberately not distinguished so callers cannot probe for valid tokens.
+var ErrInvalidToken = errors.New("invalid API token")
+
+// Token is an API token as stored in the api_tokens table. The plaintext token is only known when it is created.
+type Token struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// HasScope reports whether the token grants scope.
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate returns a new random plaintext token and the hash under which it is stored.
+func Generate() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := TokenPrefix + hex.EncodeToString(secret)
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex-encoded SHA-256 hash of a plaintext token. Tokens carry enough entropy
+// that a fast hash is sufficient, which keeps verification cheap on every request.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store manages API tokens in the api_tokens table.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new Store.
+// Example usage: tokens := apitoken.NewStore(conn.GetDB())
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Create issues a new token for the user with the given name and scopes. A ttl of zero creates a token
+// that never expires. It returns the plaintext token, which cannot be recovered later, and the stored token.
+func (s *Store) Create(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (string, *Token, error) {
+	plaintext, hash, err := Generate()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := &Token{
+		UserID: userID,
+		Name:   name,
+		Prefix: plaintext[:len(TokenPrefix)+8],
+		Scopes: scopes,
+	}
+	if ttl > 0 {
+		expires := time.Now().Add(ttl)
+		token.ExpiresAt = &expires
+	}
+
+	err = s.db.QueryRowContext(ctx,
+		`INSERT INTO api_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		token.UserID, token.Name, hash, token.Prefix, pq.Array(token.Scopes), token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return plaintext, token, nil
+}
+
+// Verify looks up a plaintext token and returns it if it is valid, recording when it was last used.
+// It returns ErrInvalidToken for unknown, revoked, or expired tokens.
+func (s *Store) Verify(ctx context.Context, plaintext string) (*Token, error) {
+	if !strings.HasPrefix(plaintext, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := scanToken(s.db.QueryRowContext(ctx,
+		`UPDATE api_tokens SET last_used_at = NOW()
+		 WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		 RETURNING `+tokenColumns, Hash(plaintext)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
+	}
+	return token, nil
+}
+
+// List returns the tokens of a user, newest first. A userID of zero lists the tokens of every user.
+func (s *Store) List(ctx context.Context, userID int) ([]*Token, error) {
+	query := "SELECT " + tokenColumns + " FROM api_tokens"
+	var args []interface{}
+	if userID != 0 {
+		query += " WHERE user_id = $1"
+		args = append(args, userID)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*Token
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke marks a token as revoked so it no longer verifies.
+func (s *Store) Revoke(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE api_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token %d: %w", id, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("token %d not found or already revoked", id)
+	}
+	return nil
+}
+
+const tokenColumns = "id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanToken(row rowScanner) (*Token, error) {
+	var t Token
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, pq.Array(&t.Scopes),
+		&t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
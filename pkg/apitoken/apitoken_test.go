@@ -0,0 +1,71 @@
+package apitoken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	token, hash, err := Generate()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, TokenPrefix))
+	assert.Equal(t, Hash(token), hash)
+	assert.Len(t, hash, 64)
+
+	other, _, _ := Generate()
+	assert.NotEqual(t, token, other)
+}
+
+func TestToken_HasScope(t *testing.T) {
+	token := &Token{Scopes: []string{"read"}}
+	assert.True(t, token.HasScope("read"))
+	assert.False(t, token.HasScope("write"))
+
+	admin := &Token{Scopes: []string{ScopeAll}}
+	assert.True(t, admin.HasScope("write"))
+}
+
+type fakeVerifier map[string]*Token
+
+func (f fakeVerifier) Verify(ctx context.Context, plaintext string) (*Token, error) {
+	if token, ok := f[plaintext]; ok {
+		return token, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier := fakeVerifier{"grv_reader": {ID: 1, Scopes: []string{"read"}}}
+	handler := Middleware(verifier, "read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 1, FromContext(r.Context()).ID)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	writeHandler := Middleware(verifier, "write")(http.NotFoundHandler())
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		header  string
+		status  int
+	}{
+		{"valid token", handler, "Bearer grv_reader", http.StatusNoContent},
+		{"missing header", handler, "", http.StatusUnauthorized},
+		{"unknown token", handler, "Bearer grv_nope", http.StatusUnauthorized},
+		{"missing scope", writeHandler, "Bearer grv_reader", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rec, req)
+		assert.Equal(t, tt.status, rec.Code, tt.name)
+	}
+}
@@ -0,0 +1,71 @@
+package apitoken
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+)
+
+type contextKey struct{}
+
+// Verifier checks plaintext tokens. *Store implements it.
+type Verifier interface {
+	Verify(ctx context.Context, plaintext string) (*Token, error)
+}
+
+// FromContext returns the token that authenticated the request, or nil if there is none.
+func FromContext(ctx context.Context) *Token {
+	token, _ := ctx.Value(contextKey{}).(*Token)
+	return token
+}
+
+// Middleware returns an mvc.Middleware that requires an "Authorization: Bearer <token>" header carrying a valid
+// token with the given scope. Requests without a valid token receive 401 Unauthorized, and requests whose token
+// lacks the scope receive 403 Forbidden. An empty scope accepts any valid token.
+//
+// Example usage:
+//
+//	tokens := apitoken.NewStore(conn.GetDB())
+//	router.Handle("GET /api/reports", apitoken.Middleware(tokens, "read")(reportsHandler))
+func Middleware(verifier Verifier, scope string) mvc.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plaintext, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				mvc.WriteError(w, http.StatusUnauthorized, "missing API token")
+				return
+			}
+
+			token, err := verifier.Verify(r.Context(), plaintext)
+			if errors.Is(err, ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				mvc.WriteError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			if err != nil {
+				mvc.WriteError(w, http.StatusInternalServerError, "failed to verify API token")
+				return
+			}
+
+			if scope != "" && !token.HasScope(scope) {
+				mvc.WriteError(w, http.StatusForbidden, "API token lacks scope "+scope)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, token)))
+		})
+	}
+}
+
+// bearerToken extracts the token from the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis serves the commands used by Client from an in-memory map.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	data     map[string]string
+	password string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{listener: listener, data: make(map[string]string), password: password}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		f.mu.Lock()
+		var response string
+		switch {
+		case args[0] == "AUTH":
+			authenticated = args[1] == f.password
+			response = "+OK\r\n"
+			if !authenticated {
+				response = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			response = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "PING":
+			response = "+PONG\r\n"
+		case args[0] == "GET":
+			if value, ok := f.data[args[1]]; ok {
+				response = "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+			} else {
+				response = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.data[args[1]] = args[2]
+			response = "+OK\r\n"
+		case args[0] == "DEL":
+			for _, key := range args[1:] {
+				delete(f.data, key)
+			}
+			response = ":" + strconv.Itoa(len(args)-1) + "\r\n"
+		case args[0] == "INCR":
+			n, _ := strconv.Atoi(f.data[args[1]])
+			f.data[args[1]] = strconv.Itoa(n + 1)
+			response = ":" + f.data[args[1]] + "\r\n"
+		default:
+			response = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+
+		if _, err := conn.Write([]byte(response)); err != nil {
+			return
+		}
+	}
+}
+
+func TestWriteCommand(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeCommand(&buf, []string{"SET", "key", "a b"}))
+	assert.Equal(t, "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$3\r\na b\r\n", buf.String())
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"+OK\r\n", "OK"},
+		{":42\r\n", int64(42)},
+		{"$5\r\nhello\r\n", []byte("hello")},
+		{"$-1\r\n", nil},
+		{"*2\r\n:1\r\n$1\r\na\r\n", []interface{}{int64(1), []byte("a")}},
+	}
+	for _, tt := range tests {
+		got, err := readReply(bufio.NewReader(bytes.NewBufferString(tt.input)))
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	_, err := readReply(bufio.NewReader(bytes.NewBufferString("-ERR boom\r\n")))
+	assert.EqualError(t, err, "redis: ERR boom")
+}
+
+func TestClient(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	ctx := context.Background()
+
+	client := NewClient(server.listener.Addr().String(), "secret", 0)
+	defer client.Close()
+
+	assert.NoError(t, client.Ping(ctx))
+
+	_, err := client.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	assert.NoError(t, client.Set(ctx, "key", []byte("value"), time.Minute))
+	value, err := client.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+
+	n, err := client.Incr(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.NoError(t, client.Delete(ctx, "key"))
+	_, err = client.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	wrong := NewClient(server.listener.Addr().String(), "wrong", 0)
+	defer wrong.Close()
+	assert.Error(t, wrong.Ping(ctx))
+}
+
+func TestQueryCache(t *testing.T) {
+	server := newFakeRedis(t, "")
+	ctx := context.Background()
+	client := NewClient(server.listener.Addr().String(), "", 0)
+	defer client.Close()
+	qc := NewQueryCache(client, 0)
+
+	query, args := "SELECT * FROM users LIMIT $1", []interface{}{10}
+	var rows []map[string]interface{}
+	found, err := qc.Get(ctx, "users", query, args, &rows)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, qc.Set(ctx, "users", query, args, []map[string]interface{}{{"id": 1}}))
+	found, err = qc.Get(ctx, "users", query, args, &rows)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []map[string]interface{}{{"id": float64(1)}}, rows)
+
+	found, _ = qc.Get(ctx, "users", query, []interface{}{20}, &rows)
+	assert.False(t, found, "different arguments must not share a cache entry")
+
+	assert.NoError(t, qc.Invalidate(ctx, "users"))
+	found, _ = qc.Get(ctx, "users", query, args, &rows)
+	assert.False(t, found, "invalidated results must not be returned")
+}
+
+func TestParseTTL(t *testing.T) {
+	ttl, err := ParseTTL("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultTTL, ttl)
+
+	ttl, err = ParseTTL("30s")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	_, err = ParseTTL("-1s")
+	assert.Error(t, err)
+	_, err = ParseTTL("soon")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultTTL is how long query results are cached when no TTL is given.
+const DefaultTTL = time.Minute
+
+// keyPrefix namespaces every key written by grayv-lsm.
+const keyPrefix = "grayv:"
+
+// QueryCache caches query results in Redis, keyed by the table they were read from.
+// Every table has a generation counter that is part of the keys of its cached results;
+// Invalidate bumps the counter, so all cached results of a table go stale at once without
+// scanning for keys. Stale entries expire on their own after the TTL.
+type QueryCache struct {
+	client *Client
+	ttl    time.Duration
+}
+
+// NewQueryCache creates a new QueryCache storing results in client for ttl, or DefaultTTL if ttl is not positive.
+func NewQueryCache(client *Client, ttl time.Duration) *QueryCache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &QueryCache{client: client, ttl: ttl}
+}
+
+// Get decodes the cached result of query against table into dest. It reports whether a result was found.
+func (q *QueryCache) Get(ctx context.Context, table, query string, args []interface{}, dest interface{}) (bool, error) {
+	key, err := q.key(ctx, table, query, args)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := q.client.Get(ctx, key)
+	if errors.Is(err, ErrMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("failed to decode cached result: %w", err)
+	}
+	return true, nil
+}
+
+// Set caches the result of query against table. The result must be JSON serializable.
+func (q *QueryCache) Set(ctx context.Context, table, query string, args []interface{}, result interface{}) error {
+	key, err := q.key(ctx, table, query, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	return q.client.Set(ctx, key, data, q.ttl)
+}
+
+// Invalidate discards every cached result of table. Call it after writing to the table.
+func (q *QueryCache) Invalidate(ctx context.Context, table string) error {
+	_, err := q.client.Incr(ctx, generationKey(table))
+	return err
+}
+
+// key returns the cache key of query against the current generation of table.
+func (q *QueryCache) key(ctx context.Context, table, query string, args []interface{}) (string, error) {
+	generation := "0"
+	data, err := q.client.Get(ctx, generationKey(table))
+	switch {
+	case err == nil:
+		generation = string(data)
+	case !errors.Is(err, ErrMiss):
+		return "", err
+	}
+
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode query arguments: %w", err)
+	}
+	sum := sha256.Sum256([]byte(query + "\x00" + string(encodedArgs)))
+	return keyPrefix + "query:" + table + ":" + generation + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// generationKey returns the key of the generation counter of table.
+func generationKey(table string) string {
+	return keyPrefix + "generation:" + table
+}
+
+// ParseTTL parses a configured TTL such as "30s". Empty values yield DefaultTTL.
+func ParseTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache TTL %q: %w", value, err)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("invalid cache TTL %q: must be positive", value)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,154 @@
+// Package clock abstracts the current time, so code reading it can be tested with a Fake clock that only moves
+// when the test advances it.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and makes timers.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer made by a Clock. Like time.Timer, it sends the time on C once it expires.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Real is the Clock of the system, backed by the time package.
+var Real Clock = realClock{}
+
+// OrReal returns c, or Real if c is nil, so a nil Clock field means the system clock.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) NewTimer(d time.Duration) Timer  { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.timer.C }
+func (t realTimer) Stop() bool          { return t.timer.Stop() }
+
+// Fake is a Clock whose time only changes with Advance and Set. Its timers expire when the time reaches them.
+// It is safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{}
+}
+
+// NewFake returns a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+// Now returns the time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the time elapsed on the clock since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// NewTimer returns a timer expiring once the clock is advanced by d. A timer of d <= 0 expires at once.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, at: f.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- f.now
+		return t
+	}
+	f.timers = append(f.timers, t)
+	f.notify()
+	return t
+}
+
+// Advance moves the clock forward by d, expiring the timers it reaches, earliest first.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set sets the time of the clock, expiring the timers it reaches, earliest first.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+	sort.Slice(f.timers, func(i, j int) bool { return f.timers[i].at.Before(f.timers[j].at) })
+	var waiting []*fakeTimer
+	for _, t := range f.timers {
+		if t.at.After(now) {
+			waiting = append(waiting, t)
+			continue
+		}
+		t.c <- now
+	}
+	f.timers = waiting
+	f.notify()
+}
+
+// BlockUntil waits until n timers of the clock are waiting to expire, such as the timer of a loop the test
+// is about to advance.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		waiting, changed := len(f.timers), f.changed
+		f.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// notify wakes the callers of BlockUntil. f.mu must be held.
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+type fakeTimer struct {
+	clock *Fake
+	at    time.Time
+	c     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop prevents the timer from expiring. It returns false if the timer already expired or was stopped.
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, waiting := range f.timers {
+		if waiting == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			f.notify()
+			return true
+		}
+	}
+	return false
+}
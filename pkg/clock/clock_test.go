@@ -0,0 +1,58 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	late := c.NewTimer(time.Hour)
+	early := c.NewTimer(time.Minute)
+	stopped := c.NewTimer(time.Minute)
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	c.Advance(30 * time.Minute)
+	assert.Equal(t, 30*time.Minute, c.Since(start))
+	select {
+	case at := <-early.C():
+		assert.Equal(t, start.Add(30*time.Minute), at)
+	default:
+		t.Fatal("the timer reached by the clock did not expire")
+	}
+	select {
+	case <-late.C():
+		t.Fatal("the timer expired early")
+	case <-stopped.C():
+		t.Fatal("the stopped timer expired")
+	default:
+	}
+	assert.False(t, early.Stop(), "an expired timer cannot be stopped")
+
+	immediate := c.NewTimer(0)
+	assert.Equal(t, c.Now(), <-immediate.C())
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC))
+	fired := make(chan time.Time)
+	go func() {
+		fired <- <-c.NewTimer(time.Second).C()
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	assert.Equal(t, time.Date(2024, 3, 15, 10, 0, 1, 0, time.UTC), <-fired)
+}
+
+func TestOrReal(t *testing.T) {
+	assert.Equal(t, Real, OrReal(nil))
+	fake := NewFake(time.Time{})
+	assert.Equal(t, Clock(fake), OrReal(fake))
+}
@@ -3,37 +3,369 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/ooyeku/grayv-lsm/embedded"
 )
 
 // Config represents the configuration settings for the application.
-// It contains settings for the database, server, and logging.
+// It contains settings for the database, server, logging, and scheduled tasks.
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Logging  LoggingConfig
+	Database  DatabaseConfig
+	Server    ServerConfig
+	Logging   LoggingConfig
+	Scheduler SchedulerConfig
+	Retention RetentionConfig
+	Tracing   TracingConfig
+	Password  PasswordConfig
+	Stats     StatsConfig
+	Cache     CacheConfig
+	Storage   StorageConfig
+	Mail      MailConfig
+	Pooler    PoolerConfig
+	Docker    DockerConfig
+	Messages  MessagesConfig
+	Naming    NamingConfig
+
+	// CredentialStore selects where values of the form "secret:<alias>" are looked up:
+	// "keychain" (the default) or "env". See SecretPrefix.
+	CredentialStore string
+
+	// Variables holds the values substituted for ${NAME} placeholders in migration and seed SQL.
+	// Names not found here are looked up in the environment.
+	Variables map[string]string
+
+	// secrets holds the secret references resolved by LoadConfig, by config key.
+	secrets map[string]secretRef
 }
 
 // DatabaseConfig represents the configuration for connecting to a database.
 // It contains the driver, host, port, user, password, database name, and SSL mode.
+// Driver is "postgres" for lib/pq or "pgx" for the native protocol of pgx, which is faster at bulk loads and
+// at reading many rows. With "sqlite", or its alias "sqlite3", the database is the file at Path, or Name with the
+// .db extension, opened by the driver of modernc.org/sqlite, and no container is run; the host, port, user, password, and SSL mode are then unused. With "mysql",
+// the database is a MySQL or MariaDB server, whose container runs the image of Docker.
+// ReadOnly opens connections in read-only mode, refusing every statement that writes.
+// StatementTimeout, a duration such as "30s", aborts statements running longer; empty means no limit.
+// TimeZone is the time zone of the sessions, such as UTC or Europe/Paris, in which the server reads and writes the
+// times of columns without a time zone and shows those of columns with one; empty means UTC.
+// Tenancy scopes model rows to tenants: "column" adds a tenant_id column to model tables, and "schema"
+// keeps each tenant's tables in a schema of its own. Empty disables tenancy.
+// SnowflakeNode, from 0 to 1023, tells apart the snowflake ids generated by each process inserting records.
+// EchoSQL writes every statement and its bound parameters to standard error before running it, with the values
+// of sensitive columns such as passwords redacted. ExplainOnly echoes statements but only runs those that read.
 type DatabaseConfig struct {
-	Driver        string
-	Host          string
-	Port          int
-	User          string
-	Password      string
-	Name          string
-	SSLMode       string
-	ContainerName string
-	Image         string
+	Driver           string
+	Host             string
+	Port             int
+	User             string
+	Password         string
+	Name             string
+	Path             string
+	SSLMode          string
+	ContainerName    string
+	Image            string
+	ReadOnly         bool
+	StatementTimeout string
+	TimeZone         string
+	Tenancy          string
+	SnowflakeNode    int
+	EchoSQL          bool
+	ExplainOnly      bool
+	Docker           DockerImageConfig
+	// NoPublish starts the database container without publishing its port on the host, so only containers on
+	// the Docker network of DockerConfig reach it, as in production. Commands run on the host then cannot connect.
+	NoPublish bool
+	// Pooled is set on the settings returned by PoolerConfig.Route, whose connections go through pgbouncer,
+	// which refuses the startup parameters that set ReadOnly and StatementTimeout on the server.
+	Pooled bool `json:"-"`
+}
+
+// DockerConfig represents the Docker resources shared by the containers grayv-lsm starts.
+// Network is the Docker network the database, cache, mail, storage, and pooler containers are attached to,
+// where they are reachable by the service names db, cache, mail, storage, and pooler.
+type DockerConfig struct {
+	Network string
+}
+
+// WithDefaults returns a copy of the settings with empty fields set to their defaults.
+func (d DockerConfig) WithDefaults() DockerConfig {
+	if d.Network == "" {
+		d.Network = "grayv"
+	}
+	return d
+}
+
+// DSN returns the key/value connection string of the database, as accepted by the lib/pq and pgx drivers and libpq.
+// Values that are empty or contain spaces, quotes, or backslashes are quoted.
+// For SQLite, it returns the file URI of the database instead, which turns on foreign keys, waits for locks held
+// by other connections, and opens the file read-only if ReadOnly is set. For MySQL, it returns the DSN of the
+// go-sql-driver/mysql driver; see mysqlDSN.
+func (d DatabaseConfig) DSN() string {
+	if d.IsSQLite() {
+		return d.sqliteDSN()
+	}
+	if d.IsMySQL() {
+		return d.mysqlDSN()
+	}
+	pairs := [][2]string{
+		{"host", d.Host}, {"port", strconv.Itoa(d.Port)}, {"user", d.User}, {"password", d.Password},
+		{"dbname", d.Name}, {"sslmode", d.SSLMode},
+	}
+	var b strings.Builder
+	for i, pair := range pairs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(pair[0] + "=" + dsnValue(pair[1]))
+	}
+	return b.String()
+}
+
+// IsSQLite reports whether the database is a SQLite file rather than a PostgreSQL server.
+func (d DatabaseConfig) IsSQLite() bool {
+	return d.Driver == "sqlite" || d.Driver == "sqlite3"
+}
+
+// DriverName returns the name of the database/sql driver that opens the database. It is Driver, except for
+// "sqlite3", which is an alias of the "sqlite" driver of modernc.org/sqlite, the only SQLite driver compiled in.
+func (d DatabaseConfig) DriverName() string {
+	if d.IsSQLite() {
+		return "sqlite"
+	}
+	return d.Driver
+}
+
+// IsMySQL reports whether the database is a MySQL or MariaDB server.
+func (d DatabaseConfig) IsMySQL() bool {
+	return d.Driver == "mysql"
+}
+
+// SessionTimeZone returns TimeZone, or UTC if it is empty.
+func (d DatabaseConfig) SessionTimeZone() string {
+	if d.TimeZone == "" {
+		return "UTC"
+	}
+	return d.TimeZone
+}
+
+// mysqlDSN returns the DSN of a MySQL database in the format of go-sql-driver/mysql, as in
+// "app:secret@tcp(localhost:3306)/grayv?clientFoundRows=true&multiStatements=true&parseTime=true&time_zone=%27%2B00%3A00%27".
+// It is formatted by the driver, so user names, passwords, and database names holding characters such as @, /, and :
+// are kept intact. Times are scanned into time.Time, and migrations may hold several statements. Updates report
+// the rows they matched rather than those they changed, as on PostgreSQL, so an update that changes nothing is not
+// taken for a missing row. The session and the driver use the time zone of SessionTimeZone; UTC is given to the
+// server as +00:00, which works without its time zone tables. A time zone unknown to Go is left to the server, and
+// rejected by NewConnection. An SSLMode other than disable turns on TLS, verifying the certificate of the server
+// for verify-ca and verify-full.
+func (d DatabaseConfig) mysqlDSN() string {
+	zone := d.SessionTimeZone()
+	serverZone := zone
+	if zone == "UTC" {
+		serverZone = "+00:00"
+	}
+	c := mysql.NewConfig()
+	c.User, c.Passwd, c.DBName = d.User, d.Password, d.Name
+	c.Net, c.Addr = "tcp", net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	c.ClientFoundRows, c.ParseTime, c.MultiStatements = true, true, true
+	if loc, err := time.LoadLocation(zone); err == nil {
+		c.Loc = loc
+	}
+	c.Params = map[string]string{"time_zone": "'" + serverZone + "'"}
+	switch d.SSLMode {
+	case "", "disable":
+	case "verify-ca", "verify-full":
+		c.TLSConfig = "true"
+	default:
+		c.TLSConfig = "skip-verify"
+	}
+	return c.FormatDSN()
+}
+
+// FilePath returns the file of a SQLite database: Path, or Name with the .db extension.
+func (d DatabaseConfig) FilePath() string {
+	if d.Path != "" {
+		return d.Path
+	}
+	return d.Name + ".db"
+}
+
+// sqliteDSN returns the file URI of a SQLite database, with the connection settings of the driver of
+// modernc.org/sqlite.
+func (d DatabaseConfig) sqliteDSN() string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	if d.ReadOnly {
+		params.Set("mode", "ro")
+	}
+	return "file:" + d.FilePath() + "?" + params.Encode()
+}
+
+func dsnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\") {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
+
+// URL returns the postgres:// connection URL of the database, as accepted by libpq, most drivers, and GUI clients,
+// or the mysql:// URL of a MySQL database.
+func (d DatabaseConfig) URL() string {
+	scheme := "postgres"
+	if d.IsMySQL() {
+		scheme = "mysql"
+	}
+	u := url.URL{
+		Scheme: scheme,
+		User:   url.UserPassword(d.User, d.Password),
+		Host:   net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:   "/" + d.Name,
+	}
+	if d.SSLMode != "" && !d.IsMySQL() {
+		u.RawQuery = url.Values{"sslmode": {d.SSLMode}}.Encode()
+	}
+	return u.String()
 }
 
-// ServerConfig represents the configuration for a server, including the host and port it is running on.
+// DockerImageConfig represents the contents of the database image built by db build.
+// BaseImage and Version name the PostgreSQL image the database image is built from, such as postgres:13.
+// Locale, such as "de_DE.UTF-8", is compiled into the image and made the default of new databases.
+// Extensions are packages of the PostgreSQL apt repository installed in the image, named without their
+// "postgresql-<version>-" prefix, such as "pgvector" or "postgis-3". The image of a MySQL database is built
+// from a MySQL or MariaDB image instead, such as mariadb:11, and has no locale or extensions.
+// InitScripts are SQL or shell scripts copied into the image, which run in order when a container starts
+// with an empty data directory.
+type DockerImageConfig struct {
+	BaseImage   string
+	Version     string
+	Locale      string
+	Extensions  []string
+	InitScripts []string
+}
+
+// WithDefaults returns a copy of the settings with empty fields set to their defaults. The default extensions
+// install pgvector, which provides the vector(n) type of model fields.
+func (d DockerImageConfig) WithDefaults() DockerImageConfig {
+	if d.BaseImage == "" {
+		d.BaseImage = "postgres"
+	}
+	if d.Version == "" {
+		d.Version = "13"
+	}
+	if d.Extensions == nil {
+		d.Extensions = []string{"pgvector"}
+	}
+	return d
+}
+
+// WithMySQLDefaults returns a copy of the settings with empty fields set to the defaults of the image of a
+// MySQL database, which is built from MariaDB 11.
+func (d DockerImageConfig) WithMySQLDefaults() DockerImageConfig {
+	if d.BaseImage == "" {
+		d.BaseImage = "mariadb"
+	}
+	if d.Version == "" {
+		d.Version = "11"
+	}
+	return d
+}
+
+// ServerConfig represents the configuration for a server, including the host and port it is running on
+// the CORS, CSRF, and rate limiting protection applied to its requests, and an optional directory of static
+// files (such as a frontend bundle) served alongside the API.
+// ShutdownTimeout, a duration such as "30s", is how long long-running commands (serve, jobs work, schedule run)
+// may take to finish their work in flight once interrupted; empty means DefaultShutdownTimeout.
+// Webhooks are called when rows are created, updated, or deleted through the API.
+// Versions are the versions of the API served alongside the unversioned /api routes, such as /api/v1.
 type ServerConfig struct {
-	Host string
-	Port int
+	Host            string
+	Port            int
+	StaticDir       string
+	CORS            CORSConfig
+	CSRF            CSRFConfig
+	RateLimit       RateLimitConfig
+	ShutdownTimeout string
+	Webhooks        []WebhookConfig
+	Versions        []APIVersionConfig
+}
+
+// APIVersionConfig represents a version of the API, served under /api/{Name}, such as /api/v1.
+//
+// It contains the following fields:
+//   - Name: "v" followed by a number, such as "v1"
+//   - Models: the models the version serves, by model name, and the fields it exposes; every model with all
+//     of its fields when empty
+type APIVersionConfig struct {
+	Name   string
+	Models map[string]APIModelConfig
+}
+
+// APIModelConfig represents how a version of the API exposes a model.
+//
+// It contains the following fields:
+//   - Hidden: the columns left out of responses and refused in request bodies
+//   - Renamed: the names the version gives columns, by column name, such as {"title": "headline"}
+type APIModelConfig struct {
+	Hidden  []string
+	Renamed map[string]string
+}
+
+// WebhookConfig represents a URL the server posts a signed JSON payload to when rows of a model change.
+//
+// It contains the following fields:
+//   - Model: the name of the model whose rows are watched, or "*" for every model
+//   - Events: the events that trigger the webhook, among "created", "updated", and "deleted"; all of them when empty
+//   - URL: the http or https URL the payloads are posted to
+//   - Secret: the key of the HMAC-SHA256 signature of the payloads
+//   - MaxAttempts: the number of times a payload is sent before the delivery fails, 5 when zero
+type WebhookConfig struct {
+	Model       string
+	Events      []string
+	URL         string
+	Secret      string
+	MaxAttempts int
+}
+
+// DefaultShutdownTimeout is the shutdown timeout of ServerConfig when none is set.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// ShutdownDuration returns the parsed ShutdownTimeout, or DefaultShutdownTimeout if it is empty.
+func (s ServerConfig) ShutdownDuration() (time.Duration, error) {
+	if s.ShutdownTimeout == "" {
+		return DefaultShutdownTimeout, nil
+	}
+	timeout, err := time.ParseDuration(s.ShutdownTimeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid shutdown timeout %q: must be a positive duration such as 30s", s.ShutdownTimeout)
+	}
+	return timeout, nil
+}
+
+// CORSConfig represents the cross-origin resource sharing settings of the server.
+// AllowedOrigins may contain "*" to allow any origin.
+type CORSConfig struct {
+	Enabled          bool
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+// CSRFConfig represents the cross-site request forgery protection settings of the server.
+// Empty cookie and header names fall back to the defaults of the mvc package.
+type CSRFConfig struct {
+	Enabled    bool
+	CookieName string
+	HeaderName string
+	Secure     bool
 }
 
 // LoggingConfig represents the configuration for logging.
@@ -46,14 +378,345 @@ type LoggingConfig struct {
 	File  string
 }
 
+// PasswordConfig represents the password hashing and strength settings used when creating users.
+//
+// It contains the following fields:
+//   - Algorithm: the hashing algorithm for new passwords, either "bcrypt" or "argon2id"
+//   - BcryptCost: the bcrypt cost factor, 14 when zero
+//   - MinLength: the minimum number of characters, 8 when zero
+//   - RequireUpper, RequireLower, RequireDigit, RequireSymbol: the character classes a password must contain
+type PasswordConfig struct {
+	Algorithm     string
+	BcryptCost    int
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// TracingConfig represents the OpenTelemetry tracing settings.
+//
+// It contains the following fields:
+//   - Enabled: whether spans are exported
+//   - Endpoint: the OTLP/HTTP collector to export to, such as "localhost:4318" or "https://otel.example.com"
+//   - Insecure: whether to export over plain HTTP instead of HTTPS
+//   - ServiceName: the service.name resource attribute, "grayv-lsm" when empty
+//   - SampleRatio: the fraction of traces to record, between 0 and 1; 0 records every trace
+type TracingConfig struct {
+	Enabled     bool
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+}
+
+// MessagesConfig represents the settings of the messages printed by grayv-lsm, for teams that translate or
+// rebrand its output.
+//
+// It contains the following fields:
+//   - Locale: the catalog of message templates to use, read from <Dir>/<Locale>.json; empty means English
+//   - Dir: the directory of the catalogs, "messages" when empty
+//   - DateFormat: the Go time layout of dates, such as "02.01.2006 15:04"; empty keeps each message's layout
+//   - ThousandsSeparator: the separator grouping the digits of numbers; empty leaves them ungrouped
+//   - DecimalSeparator: the separator of the fractional part of decimals, "." when empty
+type MessagesConfig struct {
+	Locale             string
+	Dir                string
+	DateFormat         string
+	ThousandsSeparator string
+	DecimalSeparator   string
+}
+
+// NamingConfig represents the house style of the names of the tables and columns of models, for databases whose
+// tables were not created by grayv-lsm. Set it before creating tables: the tables and columns of existing models are
+// not renamed when it changes.
+//
+// It contains the following fields:
+//   - Columns: "snake" for published_at, the default, or "camel" for publishedAt
+//   - Tables: "plural" for blog_posts, the default, or "singular" for blog_post
+//   - Prefix: the prefix of every table name, such as "app_"; empty means none
+//
+// The id, created_at, and updated_at columns every model inherits are named the same in every style.
+type NamingConfig struct {
+	Columns string
+	Tables  string
+	Prefix  string
+}
+
+// DefaultMessagesDir is the directory of the message catalogs when none is configured.
+const DefaultMessagesDir = "messages"
+
+// StatsConfig represents the settings of the local command timing statistics shown by `grayv-lsm stats`.
+// Statistics are only ever written to a local file; nothing is sent anywhere.
+//
+// It contains the following fields:
+//   - Enabled: whether the duration of every command is recorded
+//   - File: the file the statistics are written to, ".grayv/stats.json" when empty
+type StatsConfig struct {
+	Enabled bool
+	File    string
+}
+
+// CacheConfig represents the settings of the optional Redis cache managed by `grayv-lsm cache`.
+//
+// It contains the following fields:
+//   - Enabled: whether the API server caches list queries in Redis
+//   - Host, Port: the address of the Redis server, "localhost:6379" by default
+//   - Password: the password sent with AUTH, if any
+//   - DB: the Redis database number selected after connecting
+//   - ContainerName, Image: the Docker container and image started by `grayv-lsm cache start`,
+//     "grayv-cache" and "redis:7-alpine" when empty
+//   - TTL: how long cached query results are kept, such as "1m"; "1m" when empty
+type CacheConfig struct {
+	Enabled       bool
+	Host          string
+	Port          int
+	Password      string
+	DB            int
+	ContainerName string
+	Image         string
+	TTL           string
+}
+
+// Addr returns the host:port address of the Redis server, filling in the default host and port.
+func (c CacheConfig) Addr() string {
+	host, port := c.Host, c.Port
+	if host == "" {
+		host = "localhost"
+	}
+	if port == 0 {
+		port = 6379
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// StorageConfig represents the file storage settings used by `grayv-lsm storage` and scaffolded upload handlers.
+//
+// It contains the following fields:
+//   - Backend: where files are stored, either "local" or "s3"; "local" when empty
+//   - Dir: the directory of the local backend, "uploads" when empty
+//   - Endpoint, Bucket: the S3-compatible server and bucket, "localhost:9000" and "uploads" when empty
+//   - AccessKey, SecretKey: the S3 credentials, also used as the MinIO root user; "grayv" and "grayvsecret" when empty
+//   - Region: the region of the bucket, "us-east-1" when empty
+//   - Secure: whether to connect to the endpoint over HTTPS
+//   - ContainerName, Image: the MinIO container and image started by `grayv-lsm storage start`,
+//     "grayv-storage" and "minio/minio" when empty
+type StorageConfig struct {
+	Backend       string
+	Dir           string
+	Endpoint      string
+	Bucket        string
+	AccessKey     string
+	SecretKey     string
+	Region        string
+	Secure        bool
+	ContainerName string
+	Image         string
+}
+
+// WithDefaults returns a copy of the settings with empty fields set to their defaults.
+func (s StorageConfig) WithDefaults() StorageConfig {
+	defaults := []struct {
+		field *string
+		value string
+	}{
+		{&s.Backend, "local"},
+		{&s.Dir, "uploads"},
+		{&s.Endpoint, "localhost:9000"},
+		{&s.Bucket, "uploads"},
+		{&s.AccessKey, "grayv"},
+		{&s.SecretKey, "grayvsecret"},
+		{&s.Region, "us-east-1"},
+		{&s.ContainerName, "grayv-storage"},
+		{&s.Image, "minio/minio"},
+	}
+	for _, d := range defaults {
+		if *d.field == "" {
+			*d.field = d.value
+		}
+	}
+	return s
+}
+
+// MailConfig represents the outgoing email settings used by pkg/mail and `grayv-lsm mail`.
+//
+// It contains the following fields:
+//   - Host, Port: the SMTP server, "localhost" and 1025 (the Mailpit container) when empty
+//   - Username, Password: the SMTP credentials; no authentication is attempted when Username is empty
+//   - From: the sender address, "grayv@localhost" when empty
+//   - ContainerName, Image: the Mailpit container and image started by `grayv-lsm mail start`,
+//     "grayv-mail" and "axllent/mailpit" when empty
+//   - UIPort: the port of the Mailpit web interface, 8025 when zero
+type MailConfig struct {
+	Host          string
+	Port          int
+	Username      string
+	Password      string
+	From          string
+	ContainerName string
+	Image         string
+	UIPort        int
+}
+
+// WithDefaults returns a copy of the settings with empty fields set to their defaults.
+func (m MailConfig) WithDefaults() MailConfig {
+	if m.Host == "" {
+		m.Host = "localhost"
+	}
+	if m.Port == 0 {
+		m.Port = 1025
+	}
+	if m.From == "" {
+		m.From = "grayv@localhost"
+	}
+	if m.ContainerName == "" {
+		m.ContainerName = "grayv-mail"
+	}
+	if m.Image == "" {
+		m.Image = "axllent/mailpit"
+	}
+	if m.UIPort == 0 {
+		m.UIPort = 8025
+	}
+	return m
+}
+
+// PoolerConfig represents the settings of the optional pgbouncer container started and stopped with the
+// database by `grayv-lsm db start` and `grayv-lsm db stop`, to try out connection pooling as in production.
+//
+// It contains the following fields:
+//   - Enabled: whether the container is managed with the database and the connections of the ORM go through it
+//   - Port: the port pgbouncer is published on, 6432 when zero
+//   - PoolMode: when server connections return to the pool: "session", "transaction", or "statement";
+//     "transaction" when empty
+//   - PoolSize: the number of server connections per user and database, 20 when zero
+//   - MaxClientConn: the number of client connections pgbouncer accepts, 100 when zero
+//   - ContainerName, Image: the pgbouncer container and image, "grayv-pooler" and "edoburu/pgbouncer" when empty
+type PoolerConfig struct {
+	Enabled       bool
+	Port          int
+	PoolMode      string
+	PoolSize      int
+	MaxClientConn int
+	ContainerName string
+	Image         string
+}
+
+// PoolModes are the values PoolerConfig.PoolMode accepts.
+var PoolModes = []string{"session", "transaction", "statement"}
+
+// WithDefaults returns a copy of the settings with empty fields set to their defaults.
+func (p PoolerConfig) WithDefaults() PoolerConfig {
+	if p.Port == 0 {
+		p.Port = 6432
+	}
+	if p.PoolMode == "" {
+		p.PoolMode = "transaction"
+	}
+	if p.PoolSize == 0 {
+		p.PoolSize = 20
+	}
+	if p.MaxClientConn == 0 {
+		p.MaxClientConn = 100
+	}
+	if p.ContainerName == "" {
+		p.ContainerName = "grayv-pooler"
+	}
+	if p.Image == "" {
+		p.Image = "edoburu/pgbouncer"
+	}
+	return p
+}
+
+// Route returns the settings of connections to db that go through the pooler: when the pooler is enabled,
+// they connect to its published port on localhost, and are marked Pooled. Otherwise db is returned unchanged.
+func (p PoolerConfig) Route(db DatabaseConfig) DatabaseConfig {
+	if !p.Enabled {
+		return db
+	}
+	db.Host = "localhost"
+	db.Port = p.WithDefaults().Port
+	db.Pooled = true
+	return db
+}
+
+// SchedulerConfig represents the configuration for the task scheduler.
+// Tasks defined here are run by `grayv-lsm schedule run` alongside tasks stored in the database.
+type SchedulerConfig struct {
+	Tasks []ScheduledTaskConfig
+}
+
+// ScheduledTaskConfig describes a single scheduled task.
+//
+// It contains the following fields:
+//   - Name: the unique name of the task
+//   - Cron: the cron expression that controls when the task runs
+//   - Type: the kind of task, which can be "sql", "seed", "backup", "http", "maintain", "prune", or "refresh-view"
+//   - Target: the SQL statement, backup directory, or URL the task acts on, the options of a maintain task,
+//     such as "analyze,full:events", the tables of the retention rules a prune task applies, all when empty,
+//     or the materialized views a refresh-view task refreshes, such as "concurrently:author_stats"
+type ScheduledTaskConfig struct {
+	Name   string
+	Cron   string
+	Type   string
+	Target string
+}
+
+// RetentionConfig represents the retention rules applied by `grayv-lsm db prune`, which deletes or archives the
+// rows of log-like tables, such as audit logs and events, once they are older than a maximum age.
+//
+// It contains the following fields:
+//   - Rules: the retention rule of every pruned table
+//   - BatchSize: the number of rows deleted by each statement, so a prune never locks many rows at once;
+//     1000 when zero
+type RetentionConfig struct {
+	Rules     []RetentionRule
+	BatchSize int
+}
+
+// RetentionRule describes how long the rows of a table are kept.
+//
+// It contains the following fields:
+//   - Table: the table whose rows are pruned
+//   - Column: the timestamp column the age of rows is measured from, "created_at" when empty
+//   - MaxAge: the age rows are pruned at, a duration such as "720h" or a number of days such as "30d"
+//   - Archive: the table expired rows are moved into, with the same columns as Table; empty deletes them
+type RetentionRule struct {
+	Table   string
+	Column  string
+	MaxAge  string
+	Archive string
+}
+
+// RateLimitConfig represents the request rate limiting settings of the server.
+//
+// It contains the following fields:
+//   - Enabled: whether requests are rate limited
+//   - Backend: where token buckets are kept, either "memory" or "database"
+//   - Rate: the number of requests per second each client may make on average
+//   - Burst: the number of requests a client may make at once
+//   - KeyBy: what clients are identified by, either "ip" or "user"
+type RateLimitConfig struct {
+	Enabled bool
+	Backend string
+	Rate    float64
+	Burst   int
+	KeyBy   string
+}
+
 // LoadConfig reads the embedded config.json file and parses it into a Config object.
 // It returns a pointer to the Config object and an error if any occurs during the process.
 // The Config object holds the configuration for the program, including the database, server, and logging configurations.
+// An encrypted config.json is decrypted with the passphrase returned by EncryptionKey.
 func LoadConfig() (*Config, error) {
 	var cfg Config
 
 	// Try to load from local file first
-	localConfig, err := os.ReadFile("config.json")
+	localConfig, encrypted, err := readConfigFile("config.json")
+	if err != nil && encrypted {
+		return nil, err
+	}
 	if err == nil {
 		if err := json.Unmarshal(localConfig, &cfg); err != nil {
 			return nil, fmt.Errorf("failed to parse local config file: %w", err)
@@ -70,6 +733,9 @@ func LoadConfig() (*Config, error) {
 	}
 
 	setDefaults(&cfg)
+	if err := resolveSecrets(&cfg); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
@@ -83,6 +749,9 @@ func setDefaults(config *Config) {
 	}
 	if config.Database.Port == 0 {
 		config.Database.Port = 5432
+		if config.Database.IsMySQL() {
+			config.Database.Port = 3306
+		}
 	}
 	if config.Database.SSLMode == "" {
 		config.Database.SSLMode = "disable"
@@ -115,13 +784,35 @@ func GetConfigPath() string {
 	return "."
 }
 
+// MarshalConfig returns the unencrypted JSON that SaveConfig writes for cfg, with secrets resolved from
+// the credential store replaced by the references they were loaded from.
+func MarshalConfig(cfg *Config) ([]byte, error) {
+	data, err := json.MarshalIndent(cfg.withSecretReferences(), "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
 // SaveConfig saves the given configuration to a file specified by GetConfigPath.
 // It creates a new file using os.Create and closes it using defer file.Close().
 // It then encodes the config using json.NewEncoder and returns any error encountered.
+// If config.json is encrypted, it stays encrypted with the passphrase returned by EncryptionKey.
+// Secrets resolved from the credential store are saved as the references they were loaded from.
 func SaveConfig(cfg *Config) error {
-	data, err := json.MarshalIndent(cfg, "", "    ")
+	data, err := MarshalConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
+	}
+
+	if existing, err := os.ReadFile("config.json"); err == nil && IsEncrypted(existing) {
+		key, err := EncryptionKey()
+		if err != nil {
+			return err
+		}
+		if data, err = Encrypt(data, key); err != nil {
+			return fmt.Errorf("failed to encrypt config: %w", err)
+		}
 	}
 
 	err = os.WriteFile("config.json", data, 0644)
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeyEnvVar is the environment variable holding the passphrase that encrypts config.json.
+const KeyEnvVar = "GRAYV_CONFIG_KEY"
+
+// keychainService is the service name the passphrase is stored under in the OS keychain.
+const keychainService = "grayv-lsm-config"
+
+// encryptionVersion identifies the encryption scheme: AES-256-GCM with a key derived by scrypt.
+const encryptionVersion = "aes-256-gcm+scrypt"
+
+// ErrNoKey is returned when config.json is encrypted but no passphrase is available.
+var ErrNoKey = errors.New("config.json is encrypted: set " + KeyEnvVar + " or store the passphrase in the OS keychain under " + keychainService)
+
+// encryptedConfig is the content of an encrypted config.json. The salt and nonce are stored
+// next to the ciphertext, so only the passphrase is needed to decrypt it.
+type encryptedConfig struct {
+	Encryption string
+	Salt       []byte
+	Nonce      []byte
+	Data       []byte
+}
+
+// EncryptionKey returns the passphrase that encrypts config.json. It is read from GRAYV_CONFIG_KEY or,
+// if that is not set, from the OS keychain: the login keychain on macOS, the Secret Service
+// through secret-tool on Linux, or the Credential Manager on Windows. ErrNoKey is returned if neither holds a passphrase.
+func EncryptionKey() (string, error) {
+	if key := os.Getenv(KeyEnvVar); key != "" {
+		return key, nil
+	}
+	key, err := keychainGet(keychainService, "")
+	if err != nil || key == "" {
+		return "", ErrNoKey
+	}
+	return key, nil
+}
+
+// IsEncrypted reports whether data is an encrypted config file.
+func IsEncrypted(data []byte) bool {
+	var envelope encryptedConfig
+	return json.Unmarshal(data, &envelope) == nil && envelope.Encryption != ""
+}
+
+// Encrypt encrypts the content of a config file with passphrase and returns the encrypted file.
+func Encrypt(data []byte, passphrase string) ([]byte, error) {
+	envelope := encryptedConfig{Encryption: encryptionVersion, Salt: make([]byte, 16)}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, envelope.Salt)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, err
+	}
+	envelope.Data = aead.Seal(nil, envelope.Nonce, data, []byte(encryptionVersion))
+	return json.MarshalIndent(envelope, "", "    ")
+}
+
+// Decrypt decrypts a config file encrypted by Encrypt and returns its content.
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	var envelope encryptedConfig
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted config: %w", err)
+	}
+	if envelope.Encryption != encryptionVersion {
+		return nil, fmt.Errorf("unsupported config encryption %q", envelope.Encryption)
+	}
+	aead, err := newAEAD(passphrase, envelope.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid encrypted config: bad nonce")
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Data, []byte(encryptionVersion))
+	if err != nil {
+		return nil, errors.New("failed to decrypt config: wrong passphrase or corrupted file")
+	}
+	return plaintext, nil
+}
+
+// newAEAD derives an AES-256 key from passphrase and salt and returns a GCM cipher using it.
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive config key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readConfigFile reads config.json, decrypting it if it is encrypted. encrypted reports whether it was.
+func readConfigFile(path string) (data []byte, encrypted bool, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil || !IsEncrypted(data) {
+		return data, false, err
+	}
+	key, err := EncryptionKey()
+	if err != nil {
+		return nil, true, err
+	}
+	data, err = Decrypt(data, key)
+	return data, true, err
+}
+
+// EncryptFile encrypts the config file at path in place with passphrase.
+func EncryptFile(path, passphrase string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if IsEncrypted(data) {
+		return fmt.Errorf("%s is already encrypted", path)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("%s is not valid JSON", path)
+	}
+	encrypted, err := Encrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, encrypted, 0644)
+}
+
+// DecryptFile decrypts the config file at path in place with passphrase.
+func DecryptFile(path, passphrase string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if !IsEncrypted(data) {
+		return fmt.Errorf("%s is not encrypted", path)
+	}
+	plaintext, err := Decrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, plaintext, 0644)
+}
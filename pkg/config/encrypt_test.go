@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte(`{"Database": {"Password": "secret"}}`)
+
+	encrypted, err := Encrypt(plaintext, "passphrase")
+	if err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	if !IsEncrypted(encrypted) || IsEncrypted(plaintext) {
+		t.Fatal("IsEncrypted does not tell encrypted and plain configs apart")
+	}
+
+	decrypted, err := Decrypt(encrypted, "passphrase")
+	if err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("wanted %s but got %s", plaintext, decrypted)
+	}
+
+	if _, err := Decrypt(encrypted, "wrong"); err == nil {
+		t.Error("wanted an error for a wrong passphrase")
+	}
+}
+
+func TestLoadConfig_Encrypted(t *testing.T) {
+	defer os.Remove("config.json")
+	t.Setenv(KeyEnvVar, "passphrase")
+
+	if err := SaveConfig(&Config{Database: DatabaseConfig{Password: "secret"}}); err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	if err := EncryptFile("config.json", "passphrase"); err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	if cfg.Database.Password != "secret" {
+		t.Errorf("wanted password secret but got %q", cfg.Database.Password)
+	}
+
+	// Saving keeps the file encrypted.
+	cfg.Database.Password = "changed"
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	data, _ := os.ReadFile("config.json")
+	if !IsEncrypted(data) {
+		t.Fatal("wanted config.json to stay encrypted")
+	}
+
+	t.Setenv(KeyEnvVar, "wrong")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("wanted an error for a wrong passphrase")
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// errKeychainUnavailable is returned by the keychain functions on platforms without a supported OS keychain.
+var errKeychainUnavailable = errors.New("the OS keychain is not available")
+
+// errSecretNotFound is returned when the keychain holds no secret for a service and account.
+var errSecretNotFound = errors.New("secret not found in the OS keychain")
+
+// The platform files implement these functions for the OS keychain:
+//   - keychainGet(service, account string) (string, error) returns the secret stored for service and account,
+//     or errSecretNotFound. An empty account matches any account.
+//   - keychainSet(service, account, secret string) error stores secret, replacing any previous one.
+
+// keychainError is returned when a keychain tool fails, with the message it printed.
+type keychainError struct {
+	tool   string
+	output string
+	err    error
+}
+
+func (e *keychainError) Error() string {
+	if output := strings.TrimSpace(e.output); output != "" {
+		return e.tool + " failed: " + output
+	}
+	return e.tool + " failed: " + e.err.Error()
+}
+
+func (e *keychainError) Unwrap() error {
+	return e.err
+}
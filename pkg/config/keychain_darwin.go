@@ -0,0 +1,53 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// keychainGet reads a generic password from the login keychain with the security tool.
+func keychainGet(service, account string) (string, error) {
+	args := []string{"find-generic-password", "-s", service}
+	if account != "" {
+		args = append(args, "-a", account)
+	}
+	output, err := exec.Command("security", append(args, "-w")...).Output()
+	if err != nil {
+		return "", errSecretNotFound
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// keychainSet stores a generic password in the login keychain with the security tool. The security tool only
+// takes the password as an argument, so the command is written to its interactive mode on stdin rather than passed
+// on its command line, where the process list would show the password.
+func keychainSet(service, account, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return errors.New("the keychain cannot store a secret spanning several lines")
+	}
+	command := []string{"add-generic-password", "-U", "-s", service, "-a", account, "-w", secret}
+	for i, arg := range command {
+		command[i] = securityQuote(arg)
+	}
+
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(strings.Join(command, " ") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	// In interactive mode, security reports a failed command on stderr but still exits with status 0.
+	if err == nil && strings.TrimSpace(stderr.String()) != "" {
+		err = errors.New("add-generic-password failed")
+	}
+	if err != nil {
+		return &keychainError{tool: "security", output: stderr.String(), err: err}
+	}
+	return nil
+}
+
+// securityQuote quotes arg as one argument of a command read by the interactive mode of the security tool.
+func securityQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychainGet looks a secret up in the Secret Service (GNOME Keyring, KWallet) with secret-tool.
+func keychainGet(service, account string) (string, error) {
+	args := []string{"lookup", "service", service}
+	if account != "" {
+		args = append(args, "account", account)
+	}
+	cmd := exec.Command("secret-tool", args...)
+	if cmd.Err != nil {
+		return "", fmt.Errorf("%w: secret-tool is not installed", errKeychainUnavailable)
+	}
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return "", errSecretNotFound
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// keychainSet stores a secret in the Secret Service with secret-tool, which reads it from standard input.
+func keychainSet(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label="+service+" "+account, "service", service, "account", account)
+	if cmd.Err != nil {
+		return fmt.Errorf("%w: secret-tool is not installed", errKeychainUnavailable)
+	}
+	cmd.Stdin = strings.NewReader(secret)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return &keychainError{tool: "secret-tool", output: string(output), err: err}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !linux && !windows
+
+package config
+
+func keychainGet(service, account string) (string, error) {
+	return "", errKeychainUnavailable
+}
+
+func keychainSet(service, account, secret string) error {
+	return errKeychainUnavailable
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretPrefix marks a config value that references a secret in the credential store by alias,
+// as in "secret:database.password".
+const SecretPrefix = "secret:"
+
+// Credential stores selected by the CredentialStore setting.
+const (
+	// CredentialStoreKeychain keeps secrets in the OS keychain: the login keychain on macOS,
+	// the Secret Service on Linux, or the Credential Manager on Windows. It is the default.
+	CredentialStoreKeychain = "keychain"
+	// CredentialStoreEnv reads secrets from GRAYV_SECRET_<ALIAS> environment variables, for machines without a keychain.
+	CredentialStoreEnv = "env"
+)
+
+// secretService is the keychain service secrets are stored under. The alias is the account.
+const secretService = "grayv-lsm"
+
+// secretRef is a secret reference resolved by LoadConfig.
+type secretRef struct {
+	ref   string
+	value string
+}
+
+// secretFields returns the fields of cfg that may reference a secret, by config key.
+func secretFields(cfg *Config) map[string]*string {
+	return map[string]*string{
+		"database.password": &cfg.Database.Password,
+		"cache.password":    &cfg.Cache.Password,
+		"storage.secretkey": &cfg.Storage.SecretKey,
+		"mail.password":     &cfg.Mail.Password,
+	}
+}
+
+// IsSecretKey reports whether the config key may reference a secret in the credential store.
+func IsSecretKey(key string) bool {
+	_, ok := secretFields(&Config{})[key]
+	return ok
+}
+
+// SecretEnvVar returns the environment variable the env credential store reads the secret alias from.
+func SecretEnvVar(alias string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, alias)
+	return "GRAYV_SECRET_" + name
+}
+
+// GetSecret returns the secret stored under alias in the credential store.
+func GetSecret(store, alias string) (string, error) {
+	switch store {
+	case "", CredentialStoreKeychain:
+		secret, err := keychainGet(secretService, alias)
+		if errors.Is(err, errSecretNotFound) {
+			return "", fmt.Errorf("secret %q not found in the OS keychain; store it with `grayv-lsm config set-secret`", alias)
+		}
+		if errors.Is(err, errKeychainUnavailable) {
+			return "", fmt.Errorf("%w; set credentialstore to %q and %s instead", err, CredentialStoreEnv, SecretEnvVar(alias))
+		}
+		return secret, err
+	case CredentialStoreEnv:
+		secret, ok := os.LookupEnv(SecretEnvVar(alias))
+		if !ok {
+			return "", fmt.Errorf("secret %q not found: set %s", alias, SecretEnvVar(alias))
+		}
+		return secret, nil
+	default:
+		return "", fmt.Errorf("unknown credential store %q", store)
+	}
+}
+
+// SetSecret stores secret under alias in the credential store.
+func SetSecret(store, alias, secret string) error {
+	switch store {
+	case "", CredentialStoreKeychain:
+		if err := keychainSet(secretService, alias, secret); err != nil {
+			return fmt.Errorf("failed to store secret %q in the OS keychain: %w", alias, err)
+		}
+		return nil
+	case CredentialStoreEnv:
+		return fmt.Errorf("the env credential store is read-only: set %s instead", SecretEnvVar(alias))
+	default:
+		return fmt.Errorf("unknown credential store %q", store)
+	}
+}
+
+// resolveSecrets replaces the secret references in cfg by the secrets they name, and remembers them
+// so SaveConfig writes the references back instead of the secrets.
+func resolveSecrets(cfg *Config) error {
+	for key, field := range secretFields(cfg) {
+		alias, ok := strings.CutPrefix(*field, SecretPrefix)
+		if !ok {
+			continue
+		}
+		secret, err := GetSecret(cfg.CredentialStore, alias)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		if cfg.secrets == nil {
+			cfg.secrets = make(map[string]secretRef)
+		}
+		cfg.secrets[key] = secretRef{ref: *field, value: secret}
+		*field = secret
+	}
+	return nil
+}
+
+// withSecretReferences returns a copy of cfg in which the secrets resolved by LoadConfig are replaced
+// by their references again, unless they were changed since.
+func (cfg *Config) withSecretReferences() *Config {
+	saved := *cfg
+	for key, field := range secretFields(&saved) {
+		if secret, ok := cfg.secrets[key]; ok && *field == secret.value {
+			*field = secret.ref
+		}
+	}
+	return &saved
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSecretEnvVar(t *testing.T) {
+	if got := SecretEnvVar("database.password"); got != "GRAYV_SECRET_DATABASE_PASSWORD" {
+		t.Errorf("wanted GRAYV_SECRET_DATABASE_PASSWORD but got %s", got)
+	}
+}
+
+func TestLoadConfig_SecretReference(t *testing.T) {
+	defer os.Remove("config.json")
+	t.Setenv("GRAYV_SECRET_PROD_DB", "s3cret")
+
+	cfg := &Config{CredentialStore: CredentialStoreEnv, Database: DatabaseConfig{Password: SecretPrefix + "prod-db"}}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	if loaded.Database.Password != "s3cret" {
+		t.Fatalf("wanted the resolved secret but got %q", loaded.Database.Password)
+	}
+
+	// The reference, not the secret, is saved back.
+	loaded.Server.Port = 9090
+	if err := SaveConfig(loaded); err != nil {
+		t.Fatalf("wanted nil but got %v", err)
+	}
+	data, _ := os.ReadFile("config.json")
+	if strings.Contains(string(data), "s3cret") || !strings.Contains(string(data), SecretPrefix+"prod-db") {
+		t.Errorf("wanted the secret reference in config.json but got %s", data)
+	}
+
+	os.Unsetenv("GRAYV_SECRET_PROD_DB")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("wanted an error for a missing secret")
+	}
+}
@@ -0,0 +1,46 @@
+package grayvtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// DefaultMySQLImage is the image StartMySQLConfig runs unless MySQLImageEnvVar names another one.
+const DefaultMySQLImage = "mariadb:11"
+
+// MySQLImageEnvVar is the environment variable naming the image StartMySQLConfig runs, such as mysql:8.
+const MySQLImageEnvVar = "GRAYV_TEST_MYSQL_IMAGE"
+
+// StartMySQLConfig starts a MariaDB or MySQL container for t on a random port and returns the configuration of
+// its empty database, so the test applies the migrations itself, as db migrate does. The container is removed when
+// t and its subtests complete. If Docker is not available, t is skipped.
+func StartMySQLConfig(t testing.TB) config.DatabaseConfig {
+	t.Helper()
+	image := os.Getenv(MySQLImageEnvVar)
+	if image == "" {
+		image = DefaultMySQLImage
+	}
+	// The variables of both images are set, so either runs. Binary logging, on by default in MySQL 8, would
+	// otherwise keep the test user from creating the triggers of the migrations.
+	var options []string
+	for _, prefix := range []string{"MARIADB_", "MYSQL_"} {
+		options = append(options, "-e", prefix+"USER="+testUser, "-e", prefix+"PASSWORD="+testPassword,
+			"-e", prefix+"DATABASE="+testDatabase, "-e", prefix+"RANDOM_ROOT_PASSWORD=yes")
+	}
+	port := runContainer(t, image, "3306/tcp", options, "--log-bin-trust-function-creators=1")
+	cfg := config.DatabaseConfig{
+		Driver:   "mysql",
+		Host:     "127.0.0.1",
+		Port:     port,
+		User:     testUser,
+		Password: testPassword,
+		Name:     testDatabase,
+	}
+	if err := lsm.NewDBLifecycleManager(&config.Config{Database: cfg}).WaitReady(StartupTimeout); err != nil {
+		t.Fatalf("grayvtest: %v", err)
+	}
+	return cfg
+}
@@ -0,0 +1,152 @@
+// Package grayvtest starts throwaway PostgreSQL and MySQL databases for go test.
+package grayvtest
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/database/lsm"
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultImage is the image StartPostgres runs unless ImageEnvVar names another one.
+const DefaultImage = "postgres:16-alpine"
+
+// ImageEnvVar is the environment variable naming the image StartPostgres runs, such as one with pgvector.
+const ImageEnvVar = "GRAYV_TEST_POSTGRES_IMAGE"
+
+// StartupTimeout is how long StartPostgres waits for the database server to accept connections.
+const StartupTimeout = time.Minute
+
+const (
+	testUser     = "grayv"
+	testPassword = "grayv"
+	testDatabase = "grayv_test"
+)
+
+// StartPostgres starts a PostgreSQL container for t on a random port, applies the migrations, and returns a
+// connection to its database. The connection is closed and the container removed when t and its subtests
+// complete. If Docker is not available, t is skipped.
+func StartPostgres(t testing.TB) *orm.Connection {
+	t.Helper()
+	cfg := StartPostgresConfig(t)
+	conn, err := orm.NewConnection(&cfg)
+	if err != nil {
+		t.Fatalf("grayvtest: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// StartPostgresConfig starts a PostgreSQL container like StartPostgres, and returns the configuration of its
+// database rather than a connection, for code that connects by itself, such as the commands of the CLI.
+func StartPostgresConfig(t testing.TB) config.DatabaseConfig {
+	t.Helper()
+	image := os.Getenv(ImageEnvVar)
+	if image == "" {
+		image = DefaultImage
+	}
+	port := runContainer(t, image, "5432/tcp",
+		[]string{"-e", "POSTGRES_USER=" + testUser, "-e", "POSTGRES_PASSWORD=" + testPassword, "-e", "POSTGRES_DB=" + testDatabase})
+	cfg := config.DatabaseConfig{
+		Driver:   "postgres",
+		Host:     "127.0.0.1",
+		Port:     port,
+		User:     testUser,
+		Password: testPassword,
+		Name:     testDatabase,
+		SSLMode:  "disable",
+	}
+	if err := lsm.NewDBLifecycleManager(&config.Config{Database: cfg}).WaitReady(StartupTimeout); err != nil {
+		t.Fatalf("grayvtest: %v", err)
+	}
+	if err := migrate(t, &cfg); err != nil {
+		t.Fatalf("grayvtest: %v", err)
+	}
+	return cfg
+}
+
+// runContainer runs image in a container removed when t completes, with the options and server args given, and
+// returns the host port docker published its port containerPort on, such as "5432/tcp". If Docker is not
+// available, t is skipped.
+func runContainer(t testing.TB, image, containerPort string, options []string, args ...string) int {
+	t.Helper()
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("grayvtest: docker is not installed")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skip("grayvtest: the Docker daemon is not reachable")
+	}
+
+	run := append([]string{"run", "-d", "--rm", "-p", "127.0.0.1::" + strings.TrimSuffix(containerPort, "/tcp")}, options...)
+	output, err := exec.Command("docker", append(append(run, image), args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("grayvtest: failed to start a %s container: %v\nOutput: %s", image, err, output)
+	}
+	container := strings.TrimSpace(string(output))
+	t.Cleanup(func() {
+		if output, err := exec.Command("docker", "rm", "-f", container).CombinedOutput(); err != nil {
+			t.Logf("grayvtest: failed to remove container %s: %v\nOutput: %s", container, err, output)
+		}
+	})
+
+	port, err := publishedPort(container, containerPort)
+	if err != nil {
+		t.Fatalf("grayvtest: %v", err)
+	}
+	return port
+}
+
+// publishedPort returns the host port docker published the port containerPort of container on.
+func publishedPort(container, containerPort string) (int, error) {
+	output, err := exec.Command("docker", "port", container, containerPort).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the port of container %s: %w", container, err)
+	}
+	// The output lists one address per line, such as 127.0.0.1:49153.
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	_, port, err := net.SplitHostPort(line)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected port of container %s: %q", container, line)
+	}
+	return strconv.Atoi(port)
+}
+
+// migrate applies the migrations to the database of cfg, logging to t.
+func migrate(t testing.TB, cfg *config.DatabaseConfig) error {
+	conn, err := orm.NewConnection(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(testWriter{t})
+	migrator := migration.NewMigrator(conn.GetDB(), logger)
+	if err := migrator.LoadMigrations(); err != nil {
+		return fmt.Errorf("error loading migrations: %w", err)
+	}
+	if err := migrator.Migrate(); err != nil {
+		return fmt.Errorf("error running migrations: %w", err)
+	}
+	return nil
+}
+
+// testWriter writes the lines of a logger to the log of a test.
+type testWriter struct {
+	t testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,16 @@
+package grayvtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartPostgres(t *testing.T) {
+	conn := StartPostgres(t)
+
+	tables, err := conn.ListTables()
+	assert.NoError(t, err)
+	assert.Contains(t, tables, "users", "the migrations are applied")
+	assert.Contains(t, tables, "models")
+}
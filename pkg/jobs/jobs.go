@@ -0,0 +1,260 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Job statuses stored in the jobs table.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+)
+
+// DefaultQueue is the queue name used when none is given.
+const DefaultQueue = "default"
+
+// DefaultMaxAttempts is the number of times a job is tried before it is moved to the dead-letter state.
+const DefaultMaxAttempts = 5
+
+// lockTimeout is how long a running job may stay locked before another worker is allowed to reclaim it.
+// This covers workers that crashed in the middle of a job.
+const lockTimeout = 15 * time.Minute
+
+// ErrJobNotFound is returned when a job with the requested ID does not exist.
+var ErrJobNotFound = errors.New("job not found")
+
+// Job represents a single unit of background work stored in the jobs table.
+type Job struct {
+	ID          int64
+	Queue       string
+	Type        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	RunAt       time.Time
+	CreatedAt   time.Time
+}
+
+// Decode unmarshals the job payload into v.
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// EnqueueOptions controls when and how often a job is attempted.
+// A zero RunAt schedules the job immediately and a zero MaxAttempts uses DefaultMaxAttempts.
+type EnqueueOptions struct {
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// Queue provides access to a named job queue backed by the jobs table.
+type Queue struct {
+	db   *sql.DB
+	name string
+}
+
+// NewQueue creates a new Queue for the given database and queue name.
+// An empty name selects DefaultQueue.
+// Example usage: queue := jobs.NewQueue(conn.GetDB(), "emails")
+func NewQueue(db *sql.DB, name string) *Queue {
+	if name == "" {
+		name = DefaultQueue
+	}
+	return &Queue{db: db, name: name}
+}
+
+// Name returns the name of the queue.
+func (q *Queue) Name() string {
+	return q.name
+}
+
+// Enqueue adds a job of the given type to the queue to be run as soon as possible.
+// The payload is marshaled to JSON. It returns the ID of the new job.
+func (q *Queue) Enqueue(jobType string, payload interface{}) (int64, error) {
+	return q.EnqueueWithOptions(jobType, payload, EnqueueOptions{})
+}
+
+// EnqueueWithOptions adds a job of the given type to the queue using the provided options.
+// It returns the ID of the new job.
+func (q *Queue) EnqueueWithOptions(jobType string, payload interface{}, opts EnqueueOptions) (int64, error) {
+	if jobType == "" {
+		return 0, fmt.Errorf("job type is required")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	if opts.RunAt.IsZero() {
+		opts.RunAt = time.Now()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	var id int64
+	err = q.db.QueryRow(
+		"INSERT INTO jobs (queue, type, payload, run_at, max_attempts) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		q.name, jobType, data, opts.RunAt, opts.MaxAttempts,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return id, nil
+}
+
+// List returns up to limit jobs in the queue, newest first. An empty status returns jobs in every state.
+func (q *Queue) List(status string, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT id, queue, type, payload, status, attempts, max_attempts, COALESCE(last_error, ''), run_at, created_at
+		FROM jobs WHERE queue = $1 AND ($2 = '' OR status = $2) ORDER BY id DESC LIMIT $3`
+	rows, err := q.db.Query(query, q.name, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Retry moves a dead job back to the pending state so it is picked up again with a fresh attempt count.
+func (q *Queue) Retry(id int64) error {
+	result, err := q.db.Exec(
+		"UPDATE jobs SET status = $1, attempts = 0, run_at = NOW(), locked_at = NULL, updated_at = NOW() WHERE id = $2 AND queue = $3 AND status = $4",
+		StatusPending, id, q.name, StatusDead,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retry job %d: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to retry job %d: %w", id, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("dead job %d: %w", id, ErrJobNotFound)
+	}
+
+	return nil
+}
+
+// claim locks the next runnable job in the queue and marks it as running.
+// Jobs locked by workers that stopped responding are reclaimed after lockTimeout. The expiry is computed
+// by the database, whose clock also stamped locked_at, so workers with skewed clocks never reclaim a job early.
+// It returns nil without an error when there is nothing to do.
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	query := `UPDATE jobs SET status = $1, attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE queue = $2
+			AND ((status = $3 AND run_at <= NOW()) OR (status = $1 AND locked_at < NOW() - $4 * interval '1 second'))
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, queue, type, payload, status, attempts, max_attempts, COALESCE(last_error, ''), run_at, created_at`
+
+	row := q.db.QueryRowContext(ctx, query, StatusRunning, q.name, StatusPending, lockTimeout.Seconds())
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return job, err
+}
+
+// complete marks the job as done.
+func (q *Queue) complete(job *Job) error {
+	_, err := q.db.Exec(
+		"UPDATE jobs SET status = $1, locked_at = NULL, last_error = NULL, updated_at = NOW() WHERE id = $2",
+		StatusDone, job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// fail records a failed attempt. The job is rescheduled with an exponential backoff from the time of the
+// database, which claim compares run_at with, or moved to the dead-letter state once it has used up all of its
+// attempts.
+func (q *Queue) fail(job *Job, jobErr error) error {
+	status := StatusPending
+	query := "UPDATE jobs SET status = $1, run_at = NOW() + $2 * interval '1 second', last_error = $3, locked_at = NULL, updated_at = NOW() WHERE id = $4"
+	params := []interface{}{status, Backoff(job.Attempts).Seconds(), jobErr.Error(), job.ID}
+	if job.Attempts >= job.MaxAttempts {
+		status = StatusDead
+		query = "UPDATE jobs SET status = $1, last_error = $2, locked_at = NULL, updated_at = NOW() WHERE id = $3"
+		params = []interface{}{status, jobErr.Error(), job.ID}
+	}
+
+	_, err := q.db.Exec(query, params...)
+	if err != nil {
+		return fmt.Errorf("failed to record failure of job %d: %w", job.ID, err)
+	}
+
+	job.Status = status
+	job.LastError = jobErr.Error()
+	return nil
+}
+
+// Backoff returns the delay before the next attempt of a job that has failed the given number of times.
+// The delay doubles with every attempt starting at 10 seconds and is capped at one hour.
+func Backoff(attempts int) time.Duration {
+	const (
+		base     = 10 * time.Second
+		maxDelay = time.Hour
+	)
+
+	if attempts <= 1 {
+		return base
+	}
+
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	job := &Job{}
+	var payload []byte
+	err := row.Scan(&job.ID, &job.Queue, &job.Type, &payload, &job.Status, &job.Attempts,
+		&job.MaxAttempts, &job.LastError, &job.RunAt, &job.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = json.RawMessage(payload)
+	return job, nil
+}
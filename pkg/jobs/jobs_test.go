@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(0))
+	assert.Equal(t, 10*time.Second, Backoff(1))
+	assert.Equal(t, 20*time.Second, Backoff(2))
+	assert.Equal(t, 80*time.Second, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(20))
+}
+
+func TestWebhookHandler(t *testing.T) {
+	var gotBody string
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotHeader = r.Header.Get("X-Test")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	payload, _ := json.Marshal(WebhookPayload{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Test": "yes"},
+		Body:    json.RawMessage(`{"event":"created"}`),
+	})
+	err := WebhookHandler(nil)(context.Background(), &Job{Payload: payload})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"event":"created"}`, gotBody)
+	assert.Equal(t, "yes", gotHeader)
+}
+
+func TestWebhookHandler_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	payload, _ := json.Marshal(WebhookPayload{URL: server.URL})
+	err := WebhookHandler(nil)(context.Background(), &Job{Payload: payload})
+	assert.Error(t, err)
+
+	err = WebhookHandler(nil)(context.Background(), &Job{Payload: json.RawMessage(`{}`)})
+	assert.Error(t, err)
+}
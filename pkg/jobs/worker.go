@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HandlerFunc processes a single job. Returning an error marks the attempt as failed.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Worker pulls jobs from a Queue and dispatches them to the handler registered for their type.
+// Failed jobs are retried with an exponential backoff and moved to the dead-letter state after
+// their last attempt.
+//
+// Example usage:
+//
+//	worker := jobs.NewWorker(jobs.NewQueue(db, "default"), logger)
+//	worker.Register("send-email", sendEmail)
+//	err := worker.Run(ctx)
+type Worker struct {
+	queue        *Queue
+	handlers     map[string]HandlerFunc
+	logger       *logrus.Logger
+	PollInterval time.Duration
+}
+
+// NewWorker creates a new Worker for the given queue. The worker polls for new jobs every second by default.
+func NewWorker(queue *Queue, logger *logrus.Logger) *Worker {
+	return &Worker{
+		queue:        queue,
+		handlers:     make(map[string]HandlerFunc),
+		logger:       logger,
+		PollInterval: time.Second,
+	}
+}
+
+// Register sets the handler for jobs of the given type, replacing any existing handler.
+func (w *Worker) Register(jobType string, handler HandlerFunc) {
+	w.handlers[jobType] = handler
+}
+
+// Run processes jobs until the context is cancelled. When the queue is empty it sleeps for PollInterval.
+// It returns nil when the context is cancelled, after the job in progress, which is not cancelled along
+// with the context, has finished.
+func (w *Worker) Run(ctx context.Context) error {
+	w.logger.Infof("Worker started on queue %s", w.queue.Name())
+	for {
+		processed, err := w.ProcessNext(context.WithoutCancel(ctx))
+		if err != nil {
+			w.logger.WithError(err).Error("Error processing job")
+		}
+
+		if !processed || err != nil {
+			select {
+			case <-ctx.Done():
+				w.logger.Infof("Worker stopped on queue %s", w.queue.Name())
+				return nil
+			case <-time.After(w.PollInterval):
+			}
+		} else if ctx.Err() != nil {
+			w.logger.Infof("Worker stopped on queue %s", w.queue.Name())
+			return nil
+		}
+	}
+}
+
+// ProcessNext claims and processes a single job. It reports whether a job was found.
+// A failing handler is not an error of ProcessNext; the failure is recorded on the job instead.
+func (w *Worker) ProcessNext(ctx context.Context) (bool, error) {
+	job, err := w.queue.claim(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		jobErr := fmt.Errorf("no handler registered for job type %s", job.Type)
+		return true, w.queue.fail(job, jobErr)
+	}
+
+	if jobErr := w.runHandler(ctx, handler, job); jobErr != nil {
+		if err := w.queue.fail(job, jobErr); err != nil {
+			return true, err
+		}
+		if job.Status == StatusDead {
+			w.logger.WithError(jobErr).Errorf("Job %d (%s) moved to dead-letter after %d attempts", job.ID, job.Type, job.Attempts)
+		} else {
+			w.logger.WithError(jobErr).Warnf("Job %d (%s) failed on attempt %d, retrying", job.ID, job.Type, job.Attempts)
+		}
+		return true, nil
+	}
+
+	if err := w.queue.complete(job); err != nil {
+		return true, err
+	}
+	w.logger.Infof("Job %d (%s) completed", job.ID, job.Type)
+	return true, nil
+}
+
+// runHandler calls the handler and turns a panic into an error so a single bad job cannot stop the worker.
+func (w *Worker) runHandler(ctx context.Context, handler HandlerFunc, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// WebhookPayload is the payload expected by WebhookHandler.
+type WebhookPayload struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// WebhookHandler returns a HandlerFunc that sends the job's WebhookPayload as an HTTP request.
+// Any non-2xx response is treated as a failure so the job is retried.
+func WebhookHandler(client *http.Client) HandlerFunc {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return func(ctx context.Context, job *Job) error {
+		var payload WebhookPayload
+		if err := job.Decode(&payload); err != nil {
+			return fmt.Errorf("invalid webhook payload: %w", err)
+		}
+		if payload.URL == "" {
+			return fmt.Errorf("webhook payload is missing a url")
+		}
+		if payload.Method == "" {
+			payload.Method = http.MethodPost
+		}
+
+		req, err := http.NewRequestWithContext(ctx, payload.Method, payload.URL, bytes.NewReader(payload.Body))
+		if err != nil {
+			return fmt.Errorf("failed to create webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range payload.Headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("webhook request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// defaultTimeout bounds sending a message when the context has no deadline.
+const defaultTimeout = 30 * time.Second
+
+// Message is an email to send. Body is plain text unless HTML is set.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+	HTML    bool
+}
+
+// Sender sends email messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPSender is a Sender that delivers messages to an SMTP server, such as the Mailpit container
+// managed by `grayv-lsm mail`. STARTTLS is used when the server offers it.
+type SMTPSender struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPSender creates a new SMTPSender that sends messages from the given address through the server at addr.
+// If username is not empty, the sender authenticates with PLAIN authentication.
+func NewSMTPSender(addr, from, username, password string) *SMTPSender {
+	return &SMTPSender{addr: addr, from: from, username: username, password: password}
+}
+
+// FromConfig creates an SMTPSender from the Mail section of the configuration.
+// Example usage: sender := mail.FromConfig(cfg.Mail)
+func FromConfig(cfg config.MailConfig) *SMTPSender {
+	cfg = cfg.WithDefaults()
+	return NewSMTPSender(net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), cfg.From, cfg.Username, cfg.Password)
+}
+
+// Send delivers msg to all of its recipients.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	data, err := s.build(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server %s: %w", s.addr, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return fmt.Errorf("failed to authenticate with mail server: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("mail server rejected sender %s: %w", s.from, err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("mail server rejected recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// build validates msg and encodes it as an RFC 5322 message with CRLF line endings.
+func (s *SMTPSender) build(msg Message, date time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+	for _, address := range append([]string{s.from}, msg.To...) {
+		if _, err := mail.ParseAddress(address); err != nil || strings.ContainsAny(address, "\r\n") {
+			return nil, fmt.Errorf("invalid email address %q", address)
+		}
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("subject must not contain line breaks")
+	}
+
+	contentType := "text/plain"
+	if msg.HTML {
+		contentType = "text/html"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		buf.WriteString("\r\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// Welcome returns the welcome message sent to a newly created user.
+func Welcome(username, email string) Message {
+	return Message{
+		To:      []string{email},
+		Subject: "Welcome, " + username,
+		Body: fmt.Sprintf("Hello %s,\n\nYour account has been created. You can sign in with the username %s.\n\nWelcome aboard!\n",
+			username, username),
+	}
+}
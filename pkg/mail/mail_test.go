@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTP accepts a single SMTP session and returns the recipients and data it received.
+func fakeSMTP(t *testing.T) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var lines []string
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case command == "EHLO" || command == "HELO":
+				reply("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				lines = append(lines, line)
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 go ahead")
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					lines = append(lines, strings.TrimRight(dataLine, "\r\n"))
+				}
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	sender := NewSMTPSender(addr, "app@example.com", "", "")
+
+	err := sender.Send(context.Background(), Message{To: []string{"alice@example.com"}, Subject: "Hi", Body: "line one\nline two"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	select {
+	case lines := <-received:
+		assert.Contains(t, lines, "RCPT TO:<alice@example.com>")
+		assert.Contains(t, lines, "Subject: Hi")
+		assert.Contains(t, lines, "line two")
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+}
+
+func TestSMTPSender_Build(t *testing.T) {
+	sender := NewSMTPSender("localhost:1025", "app@example.com", "", "")
+
+	data, err := sender.build(Message{To: []string{"a@example.com"}, Subject: "Grüße", Body: "hello", HTML: true}, time.Unix(0, 0))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n")
+	assert.Contains(t, string(data), "Content-Type: text/html; charset=utf-8\r\n")
+	assert.True(t, strings.HasSuffix(string(data), "\r\n\r\nhello\r\n"))
+
+	_, err = sender.build(Message{}, time.Now())
+	assert.Error(t, err)
+	_, err = sender.build(Message{To: []string{"a@example.com\r\nBcc: b@example.com"}}, time.Now())
+	assert.Error(t, err)
+	_, err = sender.build(Message{To: []string{"a@example.com"}, Subject: "x\r\nBcc: b@example.com"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestWelcome(t *testing.T) {
+	msg := Welcome("alice", "alice@example.com")
+	assert.Equal(t, []string{"alice@example.com"}, msg.To)
+	assert.Contains(t, msg.Subject, "alice")
+}
@@ -0,0 +1,95 @@
+package mvc
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions configures the CORS middleware.
+//
+// It contains the following fields:
+//   - AllowedOrigins: origins allowed to make cross-origin requests; "*" allows any origin
+//   - AllowedMethods: methods allowed in preflight requests; defaults to GET, POST, PUT, PATCH, DELETE
+//   - AllowedHeaders: request headers allowed in preflight requests; defaults to Content-Type, Authorization, X-CSRF-Token
+//   - AllowCredentials: whether cookies and authorization headers may be sent cross-origin; browsers never send
+//     them to a wildcard, so it does not apply to the origins allowed by "*"
+//   - MaxAge: how long, in seconds, browsers may cache a preflight response; 0 leaves it to the browser
+type CORSOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+// CORS returns a Middleware that answers preflight requests and adds CORS headers to responses
+// for allowed origins. Requests from other origins are passed through without CORS headers,
+// so browsers will refuse to expose the response; preflight requests from them are rejected with 403.
+func CORS(opts CORSOptions) Middleware {
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = []string{"Content-Type", "Authorization", DefaultCSRFHeaderName}
+	}
+
+	allowAll := false
+	allowed := make(map[string]bool, len(opts.AllowedOrigins))
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[strings.TrimRight(origin, "/")] = true
+	}
+
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !allowAll && !allowed[origin] {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// A wildcard is never turned into the origin of the request: echoing it with credentials allowed
+			// would let any site make requests with the cookies of its visitors.
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if opts.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if opts.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(opts.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
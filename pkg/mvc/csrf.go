@@ -0,0 +1,103 @@
+package mvc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+// Default names used by the CSRF middleware.
+const (
+	DefaultCSRFCookieName = "grayv_csrf"
+	DefaultCSRFHeaderName = "X-CSRF-Token"
+	DefaultCSRFFormField  = "csrf_token"
+)
+
+// CSRFOptions configures the CSRF middleware. Empty names fall back to the defaults above.
+type CSRFOptions struct {
+	CookieName string
+	HeaderName string
+	FormField  string
+	Secure     bool
+}
+
+type csrfContextKey struct{}
+
+// CSRF returns a Middleware implementing the double-submit cookie pattern. Every response carries a
+// random token in a cookie; requests with unsafe methods (anything but GET, HEAD, OPTIONS, and TRACE)
+// must echo that token in the header or form field, otherwise they are rejected with 403.
+// Handlers can embed the token in forms with CSRFToken.
+func CSRF(opts CSRFOptions) Middleware {
+	if opts.CookieName == "" {
+		opts.CookieName = DefaultCSRFCookieName
+	}
+	if opts.HeaderName == "" {
+		opts.HeaderName = DefaultCSRFHeaderName
+	}
+	if opts.FormField == "" {
+		opts.FormField = DefaultCSRFFormField
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := ""
+			if cookie, err := r.Cookie(opts.CookieName); err == nil {
+				token = cookie.Value
+			}
+
+			if !isSafeMethod(r.Method) {
+				sent := r.Header.Get(opts.HeaderName)
+				if sent == "" {
+					sent = r.PostFormValue(opts.FormField)
+				}
+				if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(sent)) != 1 {
+					WriteError(w, http.StatusForbidden, "invalid CSRF token")
+					return
+				}
+			}
+
+			if token == "" {
+				var err error
+				token, err = generateCSRFToken()
+				if err != nil {
+					WriteError(w, http.StatusInternalServerError, "failed to generate CSRF token")
+					return
+				}
+				// The cookie is readable by scripts on purpose: single-page apps copy it into the header.
+				http.SetCookie(w, &http.Cookie{
+					Name:     opts.CookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   opts.Secure,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, token)))
+		})
+	}
+}
+
+// CSRFToken returns the CSRF token for the request, or "" if the CSRF middleware is not installed.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfContextKey{}).(string)
+	return token
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func generateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,52 @@
+package mvc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRF(t *testing.T) {
+	var seen string
+	handler := CSRF(CSRFOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = CSRFToken(r)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	token := cookies[0]
+	assert.Equal(t, DefaultCSRFCookieName, token.Name)
+	assert.Equal(t, token.Value, seen)
+
+	// Missing token is rejected.
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.AddCookie(token)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Token in the header is accepted.
+	req = httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.AddCookie(token)
+	req.Header.Set(DefaultCSRFHeaderName, token.Value)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Token in the form field is accepted.
+	form := url.Values{DefaultCSRFFormField: {token.Value}}
+	req = httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(token)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
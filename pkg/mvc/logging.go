@@ -0,0 +1,81 @@
+package mvc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusRecorder remembers the status code and body size written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// code returns the recorded status, defaulting to 200 OK when the handler wrote nothing.
+func (r *statusRecorder) code() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Logging returns a Middleware that logs every request with its method, path, status, response size,
+// and duration. Server errors are logged at error level, client errors at warning level, and everything
+// else at info level. When the request is traced, the trace ID is included so log lines can be matched to spans;
+// install Logging after Tracing for that to work.
+func Logging(logger *logrus.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			fields := logrus.Fields{
+				"method":   r.Method,
+				"path":     r.URL.Path,
+				"status":   rec.code(),
+				"bytes":    rec.bytes,
+				"duration": time.Since(start),
+				"remote":   r.RemoteAddr,
+			}
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				fields["trace_id"] = sc.TraceID().String()
+			}
+
+			entry := logger.WithFields(fields)
+			switch status := rec.code(); {
+			case status >= 500:
+				entry.Error("Request failed")
+			case status >= 400:
+				entry.Warn("Request rejected")
+			default:
+				entry.Info("Request handled")
+			}
+		})
+	}
+}
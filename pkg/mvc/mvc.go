@@ -0,0 +1,73 @@
+package mvc
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Middleware wraps an http.Handler with additional behavior such as sessions, logging, or access control.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the given middleware. The first middleware is the outermost one,
+// so it sees the request first and the response last.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// Router is a thin wrapper around http.ServeMux that applies a middleware stack to every request.
+// Patterns follow the http.ServeMux syntax, including method and wildcard matching such as "GET /users/{id}".
+//
+// Example usage:
+//
+//	router := mvc.NewRouter()
+//	router.Use(sessions.Middleware())
+//	router.HandleFunc("GET /users/{id}", showUser)
+//	http.ListenAndServe(":8080", router)
+type Router struct {
+	mux        *http.ServeMux
+	middleware []Middleware
+	handler    http.Handler
+}
+
+// NewRouter creates a new Router with an empty middleware stack.
+func NewRouter() *Router {
+	mux := http.NewServeMux()
+	return &Router{mux: mux, handler: mux}
+}
+
+// Use appends middleware to the router's stack. Middleware added first runs first.
+// Use is meant to be called while setting up the router, before it starts serving requests.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+	r.handler = Chain(r.mux, r.middleware...)
+}
+
+// Handle registers the handler for the given pattern.
+func (r *Router) Handle(pattern string, handler http.Handler) {
+	r.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registers the handler function for the given pattern.
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.mux.HandleFunc(pattern, handler)
+}
+
+// ServeHTTP dispatches the request through the middleware stack to the matching handler.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.handler.ServeHTTP(w, req)
+}
+
+// WriteJSON writes v as a JSON response with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes a JSON error response of the form {"error": message} with the given status code.
+func WriteError(w http.ResponseWriter, status int, message string) error {
+	return WriteJSON(w, status, map[string]string{"error": message})
+}
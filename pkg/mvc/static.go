@@ -0,0 +1,64 @@
+package mvc
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// StaticOptions configures StaticHandler.
+type StaticOptions struct {
+	// Index is the file served for directories and, with SPA enabled, for unknown routes. Defaults to "index.html".
+	Index string
+	// SPA enables single-page application fallback: GET and HEAD requests for paths that do not exist
+	// and have no file extension are answered with Index, so the frontend router can handle them.
+	// Requests for missing assets such as /app.js still receive 404 Not Found.
+	SPA bool
+}
+
+// StaticHandler returns a handler serving the files in fsys, such as an embed.FS holding a built frontend bundle.
+// Use fs.Sub to serve a subdirectory of an embedded filesystem, and http.StripPrefix to mount it below a path.
+//
+// Example usage:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	bundle, _ := fs.Sub(dist, "dist")
+//	router.HandleFunc("GET /api/users", listUsers)
+//	router.Handle("/", mvc.StaticHandler(bundle, mvc.StaticOptions{SPA: true}))
+func StaticHandler(fsys fs.FS, opts StaticOptions) http.Handler {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	files := http.FileServerFS(fsys)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.SPA || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		if _, err := fs.Stat(fsys, name); err == nil || !errors.Is(err, fs.ErrNotExist) || path.Ext(name) != "" {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		// The index of a single-page application must not be cached, or clients keep loading
+		// stale asset names after a deploy.
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFileFS(w, r, fsys, opts.Index)
+	})
+}
+
+// StaticDir returns a StaticHandler serving the files in the directory dir.
+func StaticDir(dir string, opts StaticOptions) http.Handler {
+	return StaticHandler(os.DirFS(dir), opts)
+}
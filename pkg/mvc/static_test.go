@@ -0,0 +1,48 @@
+package mvc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticHandler_SPA(t *testing.T) {
+	bundle := fstest.MapFS{
+		"index.html":    {Data: []byte("<app>")},
+		"assets/app.js": {Data: []byte("console.log(1)")},
+	}
+	handler := StaticHandler(bundle, StaticOptions{SPA: true})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "<app>"},
+		{"/assets/app.js", http.StatusOK, "console.log(1)"},
+		{"/users/42", http.StatusOK, "<app>"},
+		{"/assets/missing.js", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		assert.Equal(t, tt.status, rec.Code, tt.path)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, rec.Body.String(), tt.path)
+		}
+	}
+}
+
+func TestStaticHandler_NoSPA(t *testing.T) {
+	handler := StaticHandler(fstest.MapFS{"index.html": {Data: []byte("<app>")}}, StaticOptions{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+	"github.com/ooyeku/grayv-lsm/pkg/session"
+)
+
+// KeyFunc returns the key requests are limited by, such as the client IP or user ID.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limits requests by the IP address of the connecting client.
+// It does not look at X-Forwarded-For, which clients can forge.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// KeyBySessionValue limits requests by the session value stored under key, typically the logged-in user ID.
+// Requests without a session or without the value fall back to KeyByIP.
+// It requires the session middleware to run before the rate limiting middleware.
+func KeyBySessionValue(key string) KeyFunc {
+	return func(r *http.Request) string {
+		if s := session.FromContext(r.Context()); s != nil {
+			if value := s.Get(key); value != nil {
+				return fmt.Sprintf("user:%v", value)
+			}
+		}
+		return KeyByIP(r)
+	}
+}
+
+// Metrics counts the requests seen by the rate limiting middleware. It is safe for concurrent use.
+type Metrics struct {
+	allowed   atomic.Int64
+	throttled atomic.Int64
+	errors    atomic.Int64
+}
+
+// MetricsSnapshot is a point-in-time copy of Metrics.
+type MetricsSnapshot struct {
+	Allowed   int64 `json:"allowed"`
+	Throttled int64 `json:"throttled"`
+	Errors    int64 `json:"errors"`
+}
+
+// Snapshot returns the current counter values.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		Allowed:   m.allowed.Load(),
+		Throttled: m.throttled.Load(),
+		Errors:    m.errors.Load(),
+	}
+}
+
+// Middleware returns an mvc.Middleware that rejects requests over the limit with 429 Too Many Requests.
+// If the limiter itself fails, for example because the database is unreachable, the request is allowed
+// and counted as an error, so an outage of the limiter does not take the application down with it.
+// metrics may be nil.
+func Middleware(limiter Limiter, keyFunc KeyFunc, metrics *Metrics) mvc.Middleware {
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+	if metrics == nil {
+		metrics = &Metrics{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), keyFunc(r))
+			if err != nil {
+				metrics.errors.Add(1)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+			if !result.Allowed {
+				metrics.throttled.Add(1)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				mvc.WriteError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+
+			metrics.allowed.Add(1)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
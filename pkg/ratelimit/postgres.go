@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresLimiter is a Limiter that stores token buckets in the rate_limits table, so limits are
+// shared between every process using the same database.
+type PostgresLimiter struct {
+	db     *sql.DB
+	bucket Bucket
+}
+
+// NewPostgresLimiter creates a new PostgresLimiter that allows rate requests per second with bursts of up to burst requests.
+// Example usage: limiter := ratelimit.NewPostgresLimiter(conn.GetDB(), 5, 20)
+func NewPostgresLimiter(db *sql.DB, rate float64, burst int) *PostgresLimiter {
+	return &PostgresLimiter{db: db, bucket: Bucket{Rate: rate, Burst: burst}}
+}
+
+// Allow consumes a token from the bucket of key if one is available. The bucket row is locked
+// for the duration of the check so concurrent requests cannot spend the same token.
+func (p *PostgresLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2, NOW()) ON CONFLICT (key) DO NOTHING",
+		key, p.bucket.Burst); err != nil {
+		return Result{}, fmt.Errorf("error creating rate limit bucket: %w", err)
+	}
+
+	var tokens float64
+	var updated, now time.Time
+	if err := tx.QueryRowContext(ctx,
+		"SELECT tokens, updated_at, NOW() FROM rate_limits WHERE key = $1 FOR UPDATE", key,
+	).Scan(&tokens, &updated, &now); err != nil {
+		return Result{}, fmt.Errorf("error reading rate limit bucket: %w", err)
+	}
+
+	tokens, result := p.bucket.take(tokens, now.Sub(updated))
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE rate_limits SET tokens = $1, updated_at = $2 WHERE key = $3", tokens, now, key); err != nil {
+		return Result{}, fmt.Errorf("error updating rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("error committing rate limit bucket: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteIdle removes buckets that have not been used for longer than maxAge and returns the number of deleted rows.
+func (p *PostgresLimiter) DeleteIdle(ctx context.Context, maxAge time.Duration) (int64, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE updated_at < $1", time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result describes the outcome of a rate limit check.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether a request identified by key may proceed.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Bucket describes a token bucket: it holds at most Burst tokens and refills at Rate tokens per second.
+// Every allowed request consumes one token.
+type Bucket struct {
+	Rate  float64
+	Burst int
+}
+
+// take refills a bucket that held tokens elapsed ago and tries to consume one token.
+// It returns the new token count and the result of the attempt.
+func (b Bucket) take(tokens float64, elapsed time.Duration) (float64, Result) {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens = math.Min(float64(b.Burst), tokens+elapsed.Seconds()*b.Rate)
+
+	result := Result{Limit: b.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+		result.Remaining = int(tokens)
+		return tokens, result
+	}
+
+	if b.Rate > 0 {
+		result.RetryAfter = time.Duration((1 - tokens) / b.Rate * float64(time.Second))
+	}
+	return tokens, result
+}
+
+// MemoryLimiter is a Limiter that keeps token buckets in memory. Limits are per process,
+// so it is best suited to development and single-instance deployments.
+type MemoryLimiter struct {
+	bucket  Bucket
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	sweep   time.Time
+}
+
+type memoryEntry struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryLimiter creates a new MemoryLimiter that allows rate requests per second with bursts of up to burst requests.
+func NewMemoryLimiter(rate float64, burst int) *MemoryLimiter {
+	return &MemoryLimiter{
+		bucket:  Bucket{Rate: rate, Burst: burst},
+		entries: make(map[string]*memoryEntry),
+		sweep:   time.Now(),
+	}
+}
+
+// Allow consumes a token from the bucket of key if one is available.
+func (m *MemoryLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeIdle(now)
+
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &memoryEntry{tokens: float64(m.bucket.Burst), updated: now}
+		m.entries[key] = entry
+	}
+
+	tokens, result := m.bucket.take(entry.tokens, now.Sub(entry.updated))
+	entry.tokens = tokens
+	entry.updated = now
+	return result, nil
+}
+
+// removeIdle drops buckets that have been refilled completely, at most once a minute,
+// so memory use does not grow with every client ever seen.
+func (m *MemoryLimiter) removeIdle(now time.Time) {
+	if now.Sub(m.sweep) < time.Minute || m.bucket.Rate <= 0 {
+		return
+	}
+	m.sweep = now
+
+	full := time.Duration(float64(m.bucket.Burst) / m.bucket.Rate * float64(time.Second))
+	for key, entry := range m.entries {
+		if now.Sub(entry.updated) > full {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket_Take(t *testing.T) {
+	bucket := Bucket{Rate: 2, Burst: 3}
+
+	tokens, result := bucket.take(3, 0)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+	assert.Equal(t, 2.0, tokens)
+
+	tokens, result = bucket.take(0.5, 0)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 250*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 0.5, tokens)
+
+	// Refilling never exceeds the burst size.
+	tokens, result = bucket.take(0, time.Hour)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2.0, tokens)
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter(0.001, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow(ctx, "a")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, _ := limiter.Allow(ctx, "a")
+	assert.False(t, result.Allowed)
+
+	result, _ = limiter.Allow(ctx, "b")
+	assert.True(t, result.Allowed, "keys must have separate buckets")
+}
+
+func TestMiddleware(t *testing.T) {
+	metrics := &Metrics{}
+	handler := Middleware(NewMemoryLimiter(0.001, 1), KeyByIP, metrics)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, MetricsSnapshot{Allowed: 1, Throttled: 1}, metrics.Snapshot())
+}
@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+)
+
+// DefaultCookieName is the cookie used to carry the session ID when none is configured.
+const DefaultCookieName = "grayv_session"
+
+// DefaultTTL is how long a session stays valid after its last modification when none is configured.
+const DefaultTTL = 24 * time.Hour
+
+type contextKey struct{}
+
+// Manager loads sessions from a Store at the start of a request and persists them before the response
+// is written. Sessions are only saved when they were modified, so anonymous visitors do not create rows.
+//
+// Example usage:
+//
+//	sessions := session.NewManager(session.NewPostgresStore(db))
+//	router.Use(sessions.Middleware())
+//
+//	func login(w http.ResponseWriter, r *http.Request) {
+//	    s := session.FromContext(r.Context())
+//	    s.Renew()
+//	    s.Set("user_id", user.ID)
+//	}
+type Manager struct {
+	store        Store
+	CookieName   string
+	TTL          time.Duration
+	Path         string
+	Secure       bool
+	SameSite     http.SameSite
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// NewManager creates a new Manager using the given store with default cookie settings.
+func NewManager(store Store) *Manager {
+	return &Manager{
+		store:      store,
+		CookieName: DefaultCookieName,
+		TTL:        DefaultTTL,
+		Path:       "/",
+		SameSite:   http.SameSiteLaxMode,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "session error", http.StatusInternalServerError)
+		},
+	}
+}
+
+// FromContext returns the session attached to the request context by Manager.Middleware,
+// or nil if the middleware is not installed.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(contextKey{}).(*Session)
+	return s
+}
+
+// Middleware returns an mvc.Middleware that attaches a session to every request.
+func (m *Manager) Middleware() mvc.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := m.load(r)
+			if err != nil {
+				m.ErrorHandler(w, r, err)
+				return
+			}
+
+			sw := &sessionWriter{ResponseWriter: w, manager: m, request: r, session: s}
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, s)))
+			sw.commit()
+		})
+	}
+}
+
+// load returns the session referenced by the request cookie, or a new session if there is none.
+func (m *Manager) load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(m.CookieName)
+	if err == nil && cookie.Value != "" {
+		s, err := m.store.Load(r.Context(), cookie.Value)
+		if err == nil {
+			return s, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return newSession(m.TTL)
+}
+
+// save persists or deletes the session depending on what the handler did with it and sets the cookie.
+func (m *Manager) save(w http.ResponseWriter, r *http.Request, s *Session) error {
+	s.mu.Lock()
+	destroyed, modified, previous := s.destroyed, s.modified, s.previous
+	s.mu.Unlock()
+
+	ctx := r.Context()
+	if previous != "" {
+		if err := m.store.Delete(ctx, previous); err != nil {
+			return err
+		}
+	}
+
+	if destroyed {
+		if err := m.store.Delete(ctx, s.ID); err != nil {
+			return err
+		}
+		http.SetCookie(w, m.cookie("", time.Unix(0, 0)))
+		return nil
+	}
+
+	if !modified {
+		return nil
+	}
+
+	s.ExpiresAt = time.Now().Add(m.TTL)
+	if err := m.store.Save(ctx, s); err != nil {
+		return err
+	}
+	http.SetCookie(w, m.cookie(s.ID, s.ExpiresAt))
+	return nil
+}
+
+func (m *Manager) cookie(value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.CookieName,
+		Value:    value,
+		Path:     m.Path,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   m.Secure,
+		SameSite: m.SameSite,
+	}
+}
+
+// sessionWriter saves the session right before the response headers are sent,
+// which is the last moment the session cookie can still be set.
+type sessionWriter struct {
+	http.ResponseWriter
+	manager   *Manager
+	request   *http.Request
+	session   *Session
+	committed bool
+	failed    bool
+}
+
+func (sw *sessionWriter) commit() {
+	if sw.committed {
+		return
+	}
+	sw.committed = true
+	if err := sw.manager.save(sw.ResponseWriter, sw.request, sw.session); err != nil {
+		sw.failed = true
+		sw.manager.ErrorHandler(sw.ResponseWriter, sw.request, err)
+	}
+}
+
+func (sw *sessionWriter) WriteHeader(status int) {
+	sw.commit()
+	if sw.failed {
+		return
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *sessionWriter) Write(b []byte) (int, error) {
+	sw.commit()
+	if sw.failed {
+		return len(b), nil
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter so http.ResponseController can reach it.
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PostgresStore is a Store backed by the sessions table created by the grayv-lsm migrations.
+// Session values are stored as JSONB, so they must be JSON serializable and are decoded into
+// their generic JSON types (for example, numbers come back as float64).
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore using the given database.
+// Example usage: store := session.NewPostgresStore(conn.GetDB())
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Load returns the session with the given ID if it exists and has not expired.
+func (p *PostgresStore) Load(ctx context.Context, id string) (*Session, error) {
+	s := &Session{ID: id}
+	var data []byte
+	err := p.db.QueryRowContext(ctx,
+		"SELECT data, expires_at FROM sessions WHERE id = $1 AND expires_at > NOW()", id,
+	).Scan(&data, &s.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.Values); err != nil {
+		return nil, fmt.Errorf("failed to decode session data: %w", err)
+	}
+	if s.Values == nil {
+		s.Values = make(map[string]interface{})
+	}
+	return s, nil
+}
+
+// Save inserts the session or updates it if it already exists.
+func (p *PostgresStore) Save(ctx context.Context, s *Session) error {
+	s.mu.Lock()
+	data, err := json.Marshal(s.Values)
+	id, expiresAt := s.ID, s.ExpiresAt
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode session data: %w", err)
+	}
+
+	_, err = p.db.ExecContext(ctx, `INSERT INTO sessions (id, data, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at`,
+		id, data, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the session with the given ID.
+func (p *PostgresStore) Delete(ctx context.Context, id string) error {
+	if _, err := p.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes all expired sessions and returns the number of deleted rows.
+// It is meant to be run periodically, for example from a scheduled task.
+func (p *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
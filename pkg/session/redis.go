@@ -0,0 +1,86 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/pkg/cache"
+)
+
+// RedisStore is a Store backed by the Redis server managed by `grayv-lsm cache`.
+// Sessions expire in Redis at their ExpiresAt time, so no cleanup task is needed.
+// Like PostgresStore, values are stored as JSON and decoded into their generic JSON types.
+type RedisStore struct {
+	client *cache.Client
+}
+
+// NewRedisStore creates a new RedisStore using the given cache client.
+// Example usage: store := session.NewRedisStore(cache.NewClient(cfg.Cache.Addr(), cfg.Cache.Password, cfg.Cache.DB))
+func NewRedisStore(client *cache.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// redisSession is the JSON document stored for a session.
+type redisSession struct {
+	Values    map[string]interface{} `json:"values"`
+	ExpiresAt time.Time              `json:"expires_at"`
+}
+
+// Load returns the session with the given ID if it exists and has not expired.
+func (r *RedisStore) Load(ctx context.Context, id string) (*Session, error) {
+	data, err := r.client.Get(ctx, redisKey(id))
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	var stored redisSession
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode session data: %w", err)
+	}
+	if !stored.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	if stored.Values == nil {
+		stored.Values = make(map[string]interface{})
+	}
+	return &Session{ID: id, Values: stored.Values, ExpiresAt: stored.ExpiresAt}, nil
+}
+
+// Save creates or replaces the session, expiring it in Redis at its ExpiresAt time.
+func (r *RedisStore) Save(ctx context.Context, s *Session) error {
+	s.mu.Lock()
+	data, err := json.Marshal(redisSession{Values: s.Values, ExpiresAt: s.ExpiresAt})
+	id, expiresAt := s.ID, s.ExpiresAt
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode session data: %w", err)
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return r.Delete(ctx, id)
+	}
+	if err := r.client.Set(ctx, redisKey(id), data, ttl); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the session with the given ID.
+func (r *RedisStore) Delete(ctx context.Context, id string) error {
+	if err := r.client.Delete(ctx, redisKey(id)); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// redisKey returns the key the session with the given ID is stored under.
+func redisKey(id string) string {
+	return "grayv:session:" + id
+}
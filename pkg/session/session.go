@@ -0,0 +1,173 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when a session does not exist or has expired.
+var ErrNotFound = errors.New("session not found")
+
+// Session holds the values stored for a single client between requests.
+// Values must be JSON serializable to be persisted by PostgresStore.
+type Session struct {
+	ID        string
+	Values    map[string]interface{}
+	ExpiresAt time.Time
+
+	mu        sync.Mutex
+	previous  string
+	modified  bool
+	destroyed bool
+}
+
+// newSession creates an empty session with a fresh random ID.
+func newSession(ttl time.Duration) (*Session, error) {
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		ID:        id,
+		Values:    make(map[string]interface{}),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Get returns the value stored under key, or nil if there is none.
+func (s *Session) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Values[key]
+}
+
+// GetString returns the value stored under key as a string, or "" if it is missing or not a string.
+func (s *Session) GetString(key string) string {
+	value, _ := s.Get(key).(string)
+	return value
+}
+
+// Set stores a value under key and marks the session as modified.
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes the value stored under key and marks the session as modified.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Values, key)
+	s.modified = true
+}
+
+// Renew gives the session a new ID while keeping its values. Call it after a user logs in
+// to protect against session fixation.
+func (s *Session) Renew() error {
+	id, err := generateID()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.previous == "" {
+		s.previous = s.ID
+	}
+	s.ID = id
+	s.modified = true
+	return nil
+}
+
+// Destroy removes all values and marks the session to be deleted from the store at the end of the request.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Values = make(map[string]interface{})
+	s.destroyed = true
+}
+
+// Store persists sessions between requests.
+type Store interface {
+	// Load returns the session with the given ID, or ErrNotFound if it does not exist or has expired.
+	Load(ctx context.Context, id string) (*Session, error)
+	// Save creates or replaces the session.
+	Save(ctx context.Context, s *Session) error
+	// Delete removes the session with the given ID. Deleting a missing session is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is an in-memory Store. It is useful for tests and single-process development servers;
+// sessions are lost when the process exits.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	values    map[string]interface{}
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memoryEntry)}
+}
+
+// Load returns a copy of the stored session.
+func (m *MemoryStore) Load(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.sessions[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(m.sessions, id)
+		return nil, ErrNotFound
+	}
+
+	values := make(map[string]interface{}, len(entry.values))
+	for k, v := range entry.values {
+		values[k] = v
+	}
+	return &Session{ID: id, Values: values, ExpiresAt: entry.expiresAt}, nil
+}
+
+// Save stores a copy of the session values.
+func (m *MemoryStore) Save(ctx context.Context, s *Session) error {
+	s.mu.Lock()
+	values := make(map[string]interface{}, len(s.Values))
+	for k, v := range s.Values {
+		values[k] = v
+	}
+	id, expiresAt := s.ID, s.ExpiresAt
+	s.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[id] = memoryEntry{values: values, expiresAt: expiresAt}
+	return nil
+}
+
+// Delete removes the session from the store.
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// generateID returns a random, URL-safe session ID with 256 bits of entropy.
+func generateID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ooyeku/grayv-lsm/pkg/mvc"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRouter(manager *Manager) *mvc.Router {
+	router := mvc.NewRouter()
+	router.Use(manager.Middleware())
+	router.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		s := FromContext(r.Context())
+		s.Renew()
+		s.Set("user", "alice")
+		w.Write([]byte("ok"))
+	})
+	router.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(FromContext(r.Context()).GetString("user")))
+	})
+	router.HandleFunc("POST /logout", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Destroy()
+	})
+	return router
+}
+
+func TestManager_RoundTrip(t *testing.T) {
+	store := NewMemoryStore()
+	router := newTestRouter(NewManager(store))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, DefaultCookieName, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, "alice", rec.Body.String())
+	assert.Empty(t, rec.Result().Cookies(), "unmodified sessions should not be saved")
+
+	req = httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	_, err := store.Load(req.Context(), cookies[0].Value)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManager_AnonymousRequestsAreNotStored(t *testing.T) {
+	store := NewMemoryStore()
+	router := newTestRouter(NewManager(store))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+	assert.Empty(t, rec.Result().Cookies())
+	assert.Empty(t, store.sessions)
+}
+
+func TestSession_RenewDeletesPreviousID(t *testing.T) {
+	store := NewMemoryStore()
+	router := newTestRouter(NewManager(store))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+	first := rec.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.AddCookie(first)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	second := rec.Result().Cookies()[0]
+
+	assert.NotEqual(t, first.Value, second.Value)
+	_, err := store.Load(req.Context(), first.Value)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Load(req.Context(), second.Value)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/ooyeku/grayv-lsm/pkg/config"
+)
+
+// FromConfig creates the Storage selected by the Storage section of the configuration.
+// Example usage: store, err := storage.FromConfig(cfg.Storage)
+func FromConfig(cfg config.StorageConfig) (Storage, error) {
+	cfg = cfg.WithDefaults()
+	switch cfg.Backend {
+	case "local":
+		return NewLocalStorage(cfg.Dir), nil
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:  cfg.Endpoint,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			Region:    cfg.Region,
+			Secure:    cfg.Secure,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
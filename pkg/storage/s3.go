@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload is sent as the payload hash of requests whose body is streamed, so uploads
+// do not have to be buffered to be hashed.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// emptyPayload is the SHA-256 hash of an empty body.
+const emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Options configures an S3Storage.
+//
+// It contains the following fields:
+//   - Endpoint: the host and port of the server, such as "localhost:9000" for the MinIO container
+//   - Bucket: the bucket objects are stored in
+//   - AccessKey, SecretKey: the credentials requests are signed with
+//   - Region: the region of the bucket, "us-east-1" when empty
+//   - Secure: whether to use HTTPS
+type S3Options struct {
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string
+	Secure    bool
+}
+
+// S3Storage is a Storage backed by an S3-compatible object store, such as the MinIO container
+// managed by `grayv-lsm storage`. Buckets are addressed path-style, which MinIO requires.
+type S3Storage struct {
+	opts   S3Options
+	client *http.Client
+}
+
+// NewS3Storage creates a new S3Storage with the given options.
+// Example usage: store := storage.NewS3Storage(storage.S3Options{Endpoint: "localhost:9000", Bucket: "uploads", AccessKey: "grayv", SecretKey: "grayvsecret"})
+func NewS3Storage(opts S3Options) *S3Storage {
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	return &S3Storage{opts: opts, client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// CreateBucket creates the configured bucket. It succeeds if the bucket already exists.
+func (s *S3Storage) CreateBucket(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodPut, "", nil, 0, "")
+	if err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", s.opts.Bucket, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict || resp.StatusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("failed to create bucket %s: %w", s.opts.Bucket, responseError(resp))
+}
+
+// Put uploads the object. A negative size streams the body with chunked encoding, which some
+// S3-compatible stores reject; pass the size when it is known.
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, body, size, contentType)
+	if err != nil {
+		return fmt.Errorf("failed to store object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to store object %s: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// Get downloads the object stored under key.
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, Object{}, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, Object{}, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, Object{}, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, Object{}, fmt.Errorf("failed to get object %s: %w", key, responseError(resp))
+	}
+	return resp.Body, Object{Key: key, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// Delete removes the object stored under key.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete object %s: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// do sends a signed request for key in the bucket, or for the bucket itself if key is empty.
+func (s *S3Storage) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	scheme := "http"
+	if s.opts.Secure {
+		scheme = "https"
+	}
+	target := url.URL{Scheme: scheme, Host: s.opts.Endpoint, Path: "/" + s.opts.Bucket}
+	if key != "" {
+		target.Path += "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := emptyPayload
+	if body != nil {
+		payloadHash = unsignedPayload
+		req.ContentLength = size
+		if size < 0 {
+			req.ContentLength = -1
+		} else if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	signV4(req, payloadHash, s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, time.Now())
+	return s.client.Do(req)
+}
+
+// responseError turns an unsuccessful response into an error including the start of its body,
+// which holds the S3 error code.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// signV4 adds the headers of an AWS Signature Version 4 signature for the S3 service to req.
+// The host, Content-Type, Range, and x-amz-* headers are signed.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, with spaces encoded as %20.
+func canonicalQuery(values url.Values) string {
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
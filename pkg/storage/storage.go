@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned by a Storage when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// Storage stores files, such as user uploads, under slash-separated keys.
+type Storage interface {
+	// Put stores size bytes read from body under key, replacing any existing object.
+	// A negative size means the size is unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key, or returns ErrNotFound. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidateKey checks that key is a relative, slash-separated path without "." or ".." elements,
+// so it cannot escape the directory or bucket it is stored in.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	return nil
+}
+
+// NewKey returns a random key for a file uploaded with the given name, keeping its extension,
+// such as "uploads/3f2a...9c.png". Keys of uploads should never be taken from the client as is.
+func NewKey(prefix, filename string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate object key: %w", err)
+	}
+	ext := strings.ToLower(path.Ext(path.Base(strings.ReplaceAll(filename, "\\", "/"))))
+	if len(ext) > 16 || strings.ContainsAny(ext, " /") {
+		ext = ""
+	}
+	key := hex.EncodeToString(b) + ext
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+	return key, nil
+}
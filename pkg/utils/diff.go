@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var (
+	diffHeaderColor = color.New(color.Bold)
+	diffHunkColor   = color.New(color.FgCyan)
+	diffRemoveColor = color.New(color.FgRed)
+	diffAddColor    = color.New(color.FgGreen)
+)
+
+// UnifiedDiff returns a unified diff with three lines of context turning old into new, labelled with fromFile
+// and toFile, or "" if old and new are equal.
+func UnifiedDiff(fromFile, toFile string, old, new []byte) (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(old),
+		B:        splitLines(new),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error computing diff of %s: %w", toFile, err)
+	}
+	return diff, nil
+}
+
+// ColorDiff colors the lines of a unified diff: file headers in bold, hunk headers in cyan, removed lines
+// in red, and added lines in green. Like the log output, it is left plain when color is disabled, such as
+// when stdout is not a terminal or NO_COLOR is set.
+func ColorDiff(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		var c *color.Color
+		switch {
+		case strings.HasPrefix(text, "---"), strings.HasPrefix(text, "+++"):
+			c = diffHeaderColor
+		case strings.HasPrefix(text, "@@"):
+			c = diffHunkColor
+		case strings.HasPrefix(text, "-"):
+			c = diffRemoveColor
+		case strings.HasPrefix(text, "+"):
+			c = diffAddColor
+		default:
+			continue
+		}
+		lines[i] = c.Sprint(text) + line[len(text):]
+	}
+	return strings.Join(lines, "")
+}
+
+// splitLines splits content into lines that keep their line endings, as difflib expects.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
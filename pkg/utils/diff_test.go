@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	diff, err := UnifiedDiff("a.sql", "b.sql", []byte("one\ntwo\n"), []byte("one\nthree\n"))
+	if err != nil {
+		t.Fatalf("UnifiedDiff => unexpected error %v", err)
+	}
+	for _, want := range []string{"--- a.sql\n", "+++ b.sql\n", "-two\n", "+three\n", " one\n"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff lacks %q:\n%s", want, diff)
+		}
+	}
+
+	if diff, _ := UnifiedDiff("a", "b", []byte("same\n"), []byte("same\n")); diff != "" {
+		t.Errorf("diff of equal content = %q; want empty", diff)
+	}
+}
+
+func TestColorDiff(t *testing.T) {
+	diff := "--- a\n+++ b\n@@ -1 +1 @@\n-two\n+three\n"
+
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	color.NoColor = true
+	if colored := ColorDiff(diff); colored != diff {
+		t.Errorf("ColorDiff with color disabled = %q; want it unchanged", colored)
+	}
+
+	color.NoColor = false
+	colored := ColorDiff(diff)
+	if !strings.Contains(colored, "\x1b[31m-two\x1b[0m\n") || !strings.Contains(colored, "\x1b[32m+three\x1b[0m\n") {
+		t.Errorf("ColorDiff did not color removed and added lines: %q", colored)
+	}
+}
@@ -1,22 +1,196 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Algorithm names a password hashing algorithm.
+type Algorithm string
+
+const (
+	// AlgorithmBcrypt hashes passwords with bcrypt.
+	AlgorithmBcrypt Algorithm = "bcrypt"
+	// AlgorithmArgon2id hashes passwords with argon2id, encoded in the PHC string format
+	// ($argon2id$v=19$m=65536,t=3,p=2$salt$hash).
+	AlgorithmArgon2id Algorithm = "argon2id"
+)
+
+// Argon2Params holds the cost parameters of argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	KeyLength  uint32
+	SaltLength uint32
+}
+
+// HashOptions selects the algorithm and cost used to hash new passwords.
+type HashOptions struct {
+	Algorithm  Algorithm
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// DefaultHashOptions are the options used by HashPassword.
+var DefaultHashOptions = HashOptions{
+	Algorithm:  AlgorithmBcrypt,
+	BcryptCost: 14,
+	Argon2: Argon2Params{
+		Time:       3,
+		Memory:     64 * 1024,
+		Threads:    2,
+		KeyLength:  32,
+		SaltLength: 16,
+	},
+}
+
+// ErrUnknownHash is returned when a hash was not produced by a supported algorithm.
+var ErrUnknownHash = errors.New("unknown password hash format")
+
 // HashPassword takes a password as input and returns the hashed password as a string.
 // It uses the bcrypt algorithm to generate a secure hash from the input password.
 // The function returns the hashed password string and any error that occurred during the hashing process.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	return HashPasswordWithOptions(password, DefaultHashOptions)
+}
+
+// HashPasswordWithOptions hashes password with the algorithm and cost in opts.
+// Zero cost parameters fall back to the values in DefaultHashOptions.
+func HashPasswordWithOptions(password string, opts HashOptions) (string, error) {
+	opts = opts.withDefaults()
+
+	switch opts.Algorithm {
+	case AlgorithmBcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), opts.BcryptCost)
+		return string(bytes), err
+	case AlgorithmArgon2id:
+		return hashArgon2id(password, opts.Argon2)
+	default:
+		return "", fmt.Errorf("unsupported password hashing algorithm: %s", opts.Algorithm)
+	}
 }
 
 // CheckPasswordHash compares a given password with its hashed counterpart and
 // returns true if they match; otherwise, it returns false.
-// It uses bcrypt.CompareHashAndPassword to compare the hashed password with the
-// plain-text password.
+// Both bcrypt and argon2id hashes are supported, so passwords keep working while
+// hashes are migrated from one algorithm to the other.
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// NeedsRehash reports whether hash was produced with a different algorithm or weaker cost than opts,
+// in which case the password should be hashed again the next time the user provides it.
+// Unrecognized hashes always need rehashing.
+func NeedsRehash(hash string, opts HashOptions) bool {
+	opts = opts.withDefaults()
+
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		if opts.Algorithm != AlgorithmArgon2id {
+			return true
+		}
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		want := opts.Argon2
+		return params.Time < want.Time || params.Memory < want.Memory || params.Threads < want.Threads ||
+			uint32(len(key)) < want.KeyLength || uint32(len(salt)) < want.SaltLength
+	default:
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return true
+		}
+		return opts.Algorithm != AlgorithmBcrypt || cost < opts.BcryptCost
+	}
+}
+
+// withDefaults fills unset fields from DefaultHashOptions.
+func (o HashOptions) withDefaults() HashOptions {
+	d := DefaultHashOptions
+	if o.Algorithm == "" {
+		o.Algorithm = d.Algorithm
+	}
+	if o.BcryptCost == 0 {
+		o.BcryptCost = d.BcryptCost
+	}
+	if o.Argon2.Time == 0 {
+		o.Argon2.Time = d.Argon2.Time
+	}
+	if o.Argon2.Memory == 0 {
+		o.Argon2.Memory = d.Argon2.Memory
+	}
+	if o.Argon2.Threads == 0 {
+		o.Argon2.Threads = d.Argon2.Threads
+	}
+	if o.Argon2.KeyLength == 0 {
+		o.Argon2.KeyLength = d.Argon2.KeyLength
+	}
+	if o.Argon2.SaltLength == 0 {
+		o.Argon2.SaltLength = d.Argon2.SaltLength
+	}
+	return o
+}
+
+// hashArgon2id hashes password with a random salt and encodes the result in the PHC string format.
+func hashArgon2id(password string, p Argon2Params) (string, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// decodeArgon2id parses an argon2id hash in the PHC string format.
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != string(AlgorithmArgon2id) {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version: %s", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 key: %w", err)
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -51,3 +52,47 @@ func TestCheckPasswordHash(t *testing.T) {
 		}
 	}
 }
+
+func TestHashPasswordWithOptions_Argon2id(t *testing.T) {
+	opts := HashOptions{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}}
+
+	hash, err := HashPasswordWithOptions("password123", opts)
+	if err != nil {
+		t.Fatalf("HashPasswordWithOptions => unexpected error %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("HashPasswordWithOptions => got %q, want an argon2id PHC string", hash)
+	}
+	if !CheckPasswordHash("password123", hash) {
+		t.Errorf("CheckPasswordHash(%q, %q) => got false, want true", "password123", hash)
+	}
+	if CheckPasswordHash("wrongpassword", hash) {
+		t.Errorf("CheckPasswordHash(%q, %q) => got true, want false", "wrongpassword", hash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weakBcrypt, _ := HashPasswordWithOptions("password123", HashOptions{Algorithm: AlgorithmBcrypt, BcryptCost: 4})
+	weakArgon, _ := HashPasswordWithOptions("password123", HashOptions{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}})
+
+	cases := []struct {
+		name string
+		hash string
+		opts HashOptions
+		want bool
+	}{
+		{"same bcrypt cost", weakBcrypt, HashOptions{Algorithm: AlgorithmBcrypt, BcryptCost: 4}, false},
+		{"higher bcrypt cost", weakBcrypt, HashOptions{Algorithm: AlgorithmBcrypt, BcryptCost: 5}, true},
+		{"bcrypt to argon2id", weakBcrypt, HashOptions{Algorithm: AlgorithmArgon2id}, true},
+		{"same argon2id params", weakArgon, HashOptions{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}}, false},
+		{"more argon2id memory", weakArgon, HashOptions{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Time: 1, Memory: 2048, Threads: 1}}, true},
+		{"argon2id to bcrypt", weakArgon, HashOptions{Algorithm: AlgorithmBcrypt}, true},
+		{"unknown hash", "plaintext", DefaultHashOptions, true},
+	}
+
+	for _, tc := range cases {
+		if got := NeedsRehash(tc.hash, tc.opts); got != tc.want {
+			t.Errorf("NeedsRehash (%s) => got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 72, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+
+	cases := []struct {
+		password   string
+		violations int
+	}{
+		{"Str0ng!pass", 0},
+		{"short", 4},
+		{"alllowercase", 3},
+		{"NoDigits!!", 1},
+	}
+
+	for _, tc := range cases {
+		err := policy.Validate(tc.password)
+		if tc.violations == 0 {
+			if err != nil {
+				t.Errorf("Validate(%q) => unexpected error %v", tc.password, err)
+			}
+			continue
+		}
+
+		var policyErr *PasswordPolicyError
+		if !errors.As(err, &policyErr) {
+			t.Errorf("Validate(%q) => got %v, want a *PasswordPolicyError", tc.password, err)
+			continue
+		}
+		if len(policyErr.Violations) != tc.violations {
+			t.Errorf("Validate(%q) => got violations %v, want %d", tc.password, policyErr.Violations, tc.violations)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+// The driver benchmarks compare lib/pq with pgx on a throwaway database started by grayvtest:
+//
+//	go test ./tests -run '^$' -bench 'BulkLoad|Iterate' -benchmem
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ooyeku/grayv-lsm/internal/orm"
+	"github.com/ooyeku/grayv-lsm/pkg/grayvtest"
+)
+
+// benchDriverRows is the number of rows each iteration loads or reads.
+const benchDriverRows = 10000
+
+var benchDrivers = []string{"postgres", "pgx"}
+
+// generatedRows is a RowSource yielding n rows of the benchmark table.
+type generatedRows struct {
+	n, next int
+	created time.Time
+}
+
+func (g *generatedRows) Next() ([]interface{}, error) {
+	if g.next == g.n {
+		return nil, io.EOF
+	}
+	g.next++
+	return []interface{}{int64(g.next), fmt.Sprintf("name-%d", g.next), g.created}, nil
+}
+
+// benchDriverConnections starts a database with the benchmark table and returns a connection to it per driver.
+func benchDriverConnections(b *testing.B) map[string]*orm.Connection {
+	cfg := grayvtest.StartPostgresConfig(b)
+	connections := make(map[string]*orm.Connection)
+	for _, driver := range benchDrivers {
+		cfg.Driver = driver
+		conn, err := orm.NewConnection(&cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { conn.Close() })
+		connections[driver] = conn
+	}
+	_, err := connections["postgres"].GetDB().Exec(
+		"CREATE TABLE bench_drivers (id BIGINT NOT NULL, name TEXT NOT NULL, created_at TIMESTAMPTZ NOT NULL)")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return connections
+}
+
+func BenchmarkBulkLoad(b *testing.B) {
+	connections := benchDriverConnections(b)
+	for _, driver := range benchDrivers {
+		conn := connections[driver]
+		b.Run(driver, func(b *testing.B) {
+			loader := conn.BulkLoader()
+			for i := 0; i < b.N; i++ {
+				rows := &generatedRows{n: benchDriverRows, created: time.Now()}
+				if _, err := loader.Load("bench_drivers", []string{"id", "name", "created_at"}, rows); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			if _, err := conn.GetDB().Exec("TRUNCATE bench_drivers"); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(b.N*benchDriverRows)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
+
+func BenchmarkIterate(b *testing.B) {
+	connections := benchDriverConnections(b)
+	rows := &generatedRows{n: benchDriverRows, created: time.Now()}
+	if _, err := connections["postgres"].BulkLoader().Load("bench_drivers", []string{"id", "name", "created_at"}, rows); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, driver := range benchDrivers {
+		conn := connections[driver]
+		b.Run(driver, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				result, err := conn.Query("SELECT id, name, created_at FROM bench_drivers")
+				if err != nil {
+					b.Fatal(err)
+				}
+				var (
+					id      int64
+					name    string
+					created time.Time
+				)
+				for result.Next() {
+					if err := result.Scan(&id, &name, &created); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := result.Err(); err != nil {
+					b.Fatal(err)
+				}
+				result.Close()
+			}
+			b.ReportMetric(float64(b.N*benchDriverRows)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
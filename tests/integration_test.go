@@ -1,6 +1,7 @@
 package tests
 
-// NOTE: To run this test suite, make sure any postgres instances are stopped and removed before running the tests.
+// NOTE: The tests run against throwaway databases started by grayvtest. The benchmarks drive the database
+// container of the CLI; make sure any postgres instances are stopped and removed before running them.
 
 import (
 	"os"
@@ -10,9 +11,12 @@ import (
 
 	"fmt"
 	"github.com/ooyeku/grayv-lsm/cmd"
+	"github.com/ooyeku/grayv-lsm/internal/database/migration"
 	"github.com/ooyeku/grayv-lsm/internal/orm"
 	"github.com/ooyeku/grayv-lsm/pkg/config"
+	"github.com/ooyeku/grayv-lsm/pkg/grayvtest"
 	log "github.com/ooyeku/grayv-lsm/pkg/logging"
+	"github.com/sirupsen/logrus"
 )
 
 func TestMain(m *testing.M) {
@@ -28,12 +32,23 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// testDir is the temporary working directory of the tests, and workDir the one they were started in.
+var testDir, workDir string
+
 func setupTestEnvironment() {
 	// Create a temporary directory for test configuration
 	tempDir, err := os.MkdirTemp("", "grav-lsm-test")
 	if err != nil {
 		panic("Failed to create temp directory: " + err.Error())
 	}
+	// SaveConfig writes config.json to the working directory, which must not be the package directory.
+	if workDir, err = os.Getwd(); err != nil {
+		panic("Failed to get working directory: " + err.Error())
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		panic("Failed to change working directory: " + err.Error())
+	}
+	testDir = tempDir
 
 	// Create a specific file for the configuration
 	configFile := filepath.Join(tempDir, "config.json")
@@ -70,11 +85,12 @@ func setupTestEnvironment() {
 }
 
 func teardownTestEnvironment() {
-	// Get the temporary directory path
-	tempDir := os.Getenv("GRAVORM_CONFIG_PATH")
+	if err := os.Chdir(workDir); err != nil {
+		panic("Failed to change working directory: " + err.Error())
+	}
 
 	// Remove the temporary directory and its contents
-	err := os.RemoveAll(tempDir)
+	err := os.RemoveAll(testDir)
 	if err != nil {
 		panic("Failed to remove test configuration directory: " + err.Error())
 	}
@@ -84,58 +100,67 @@ func teardownTestEnvironment() {
 }
 
 func TestAppLifecycle(t *testing.T) {
-	var databaseStarted bool
-
-	// Setup database
-	t.Run("DatabaseLifecycle", func(t *testing.T) {
-		testDatabaseLifecycle(t)
-		databaseStarted = true
-	})
-
-	// Run other tests only if database is started
-	if databaseStarted {
-		t.Run("ModelOperations", testModelOperations)
-		t.Run("ORMOperations", testORMOperations)
-	}
-
-	// Cleanup after all tests
-	defer func() {
-		if databaseStarted {
-			cmd.RootCmd.SetArgs([]string{"db", "stop"})
-			cmd.RootCmd.Execute()
-			cmd.RootCmd.SetArgs([]string{"db", "remove"})
-			cmd.RootCmd.Execute()
-		}
-	}()
+	useDatabase(t, grayvtest.StartPostgresConfig(t))
+
+	t.Run("ModelOperations", testModelOperations)
+	t.Run("ORMOperations", testORMOperations)
 }
 
-func testDatabaseLifecycle(t *testing.T) {
-	// Test database build
-	cmd.RootCmd.SetArgs([]string{"db", "build"})
+// TestMySQL applies the built-in migrations to MySQL and creates a user, through the commands of the CLI.
+func TestMySQL(t *testing.T) {
+	db := grayvtest.StartMySQLConfig(t)
+	useDatabase(t, db)
+
+	cmd.RootCmd.SetArgs([]string{"db", "migrate"})
 	if err := cmd.RootCmd.Execute(); err != nil {
-		t.Fatalf("Failed to build database: %v", err)
+		t.Fatalf("Failed to run migrations: %v", err)
 	}
-
-	// Test database start
-	cmd.RootCmd.SetArgs([]string{"db", "start"})
+	t.Setenv("GRAYV_PASSWORD", "a long enough passphrase 1!")
+	cmd.RootCmd.SetArgs([]string{"orm", "create-user", "--username", "ada", "--email", "ada@example.com"})
 	if err := cmd.RootCmd.Execute(); err != nil {
-		t.Fatalf("Failed to start database: %v", err)
+		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	// Wait for the database to be ready
-	if err := waitForDatabaseReady(); err != nil {
-		t.Fatalf("Database failed to become ready: %v", err)
+	// The commands log their errors rather than returning them, so their effects are checked in the database.
+	conn, err := orm.NewConnection(&db)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	migrator := migration.NewMigratorFor(conn, logrus.New())
+	if err := migrator.LoadMigrations(); err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
 	}
+	if pending, err := migrator.Pending(); err != nil || len(pending) != 0 {
+		t.Errorf("Pending migrations after db migrate: %d, %v", len(pending), err)
+	}
+	var users int
+	if err := conn.GetDB().QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", "ada").Scan(&users); err != nil || users != 1 {
+		t.Errorf("Users named ada: %d, %v", users, err)
+	}
+}
 
-	// Set up the database schema
-	if err := setupDatabaseSchema(); err != nil {
-		t.Fatalf("Failed to set up database schema: %v", err)
+// useDatabase writes a config.json for db to a temporary directory and makes it the working directory until
+// the test completes, as the commands load their configuration from there.
+func useDatabase(t *testing.T, db config.DatabaseConfig) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change working directory: %v", err)
 	}
+	t.Cleanup(func() { os.Chdir(wd) })
 
-	// Test database status
-	cmd.RootCmd.SetArgs([]string{"db", "status"})
-	if err := cmd.RootCmd.Execute(); err != nil {
-		t.Fatalf("Failed to get database status: %v", err)
+	cfg := &config.Config{
+		Database: db,
+		Logging: config.LoggingConfig{
+			Level: "info",
+			File:  "test.log",
+		},
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save test configuration: %v", err)
 	}
 }
 